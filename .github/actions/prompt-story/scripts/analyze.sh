@@ -7,6 +7,12 @@ echo "Analyzing commits..."
 COMMIT_RANGE="origin/${BASE_REF}..HEAD"
 echo "  Range: $COMMIT_RANGE"
 
+# Fill the PR description instead of commenting when asked to
+FILL_PR=""
+if [ "$SUMMARY_IN_BODY" = "true" ] && [ -n "$PR_NUMBER" ]; then
+  FILL_PR="$PR_NUMBER"
+fi
+
 # Use pr summary with --gha flag
 # Outputs metadata to stdout (goes to GITHUB_OUTPUT)
 # Writes markdown to file if there are notes
@@ -14,6 +20,7 @@ echo "  Range: $COMMIT_RANGE"
   --gha \
   --output=./prompt-story-summary.md \
   ${PAGES_URL:+--pages-url="$PAGES_URL"} \
+  ${FILL_PR:+--fill-pr="$FILL_PR"} \
   >> $GITHUB_OUTPUT
 
 # Parse output for logging (metadata is also in GITHUB_OUTPUT now)
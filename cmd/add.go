@@ -0,0 +1,321 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/codexcloud"
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+	"github.com/QuesmaOrg/git-prompt-story/internal/handoff"
+	"github.com/QuesmaOrg/git-prompt-story/internal/note"
+	"github.com/QuesmaOrg/git-prompt-story/internal/policy"
+	"github.com/QuesmaOrg/git-prompt-story/internal/scrubber"
+	"github.com/QuesmaOrg/git-prompt-story/internal/show"
+	"github.com/mattn/go-isatty"
+	"github.com/spf13/cobra"
+)
+
+var (
+	addSource     string
+	addSessionID  string
+	addAuto       bool
+	addNoScrub    bool
+	addFromBundle string
+)
+
+var addCmd = &cobra.Command{
+	Use:   "add [commit]",
+	Short: "Attach a hosted agent session to a commit",
+	Long: `Fetch a session from a hosted coding agent and attach it to the commit's
+prompt-story note, keeping any sessions already recorded there.
+
+Sources:
+  claude-cloud   Claude Code Cloud sessions (same as annotate-cloud)
+  codex-cloud    OpenAI Codex cloud tasks; the token is read from
+                 CODEX_API_TOKEN or the Codex CLI login (~/.codex/auth.json)
+
+Without a commit, a terminal shows a picker of recent commits, marking those
+that already have notes, to attach the session to one or more of them.
+
+--from-bundle imports sessions written by export-sessions on another
+machine. Without a commit they are kept for the next commit, which includes
+them like local sessions; with a commit they are attached to it.
+
+Examples:
+  git-prompt-story add HEAD --source=codex-cloud --session-id=task_e_XXX
+  git-prompt-story add HEAD --source=codex-cloud --auto
+  git-prompt-story add HEAD --source=claude-cloud --session-id=session_01XXX
+  git-prompt-story add --from-bundle sessions.json`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if addFromBundle != "" {
+			commit := ""
+			if len(args) > 0 {
+				commit = args[0]
+			}
+			if err := addFromSessionExport(addFromBundle, commit, addNoScrub); err != nil {
+				fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+		if addSource == "" {
+			fmt.Fprintln(os.Stderr, "git-prompt-story: must specify --source or --from-bundle")
+			os.Exit(1)
+		}
+		if addSessionID == "" && !addAuto {
+			fmt.Fprintln(os.Stderr, "git-prompt-story: must specify --session-id or --auto")
+			os.Exit(1)
+		}
+
+		commits := []string{"HEAD"}
+		if len(args) > 0 {
+			commits = []string{args[0]}
+		} else if isatty.IsTerminal(os.Stdin.Fd()) && isatty.IsTerminal(os.Stdout.Fd()) {
+			picked, err := show.PickCommits("Select commits to attach the session to (● has prompts)")
+			if err == nil && picked != "" {
+				commits, err = git.ResolveCommitSpec(picked)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+				os.Exit(1)
+			}
+			if picked == "" {
+				return
+			}
+		}
+
+		pol, err := loadPolicy()
+		if err == nil {
+			err = pol.CheckTool(addSource)
+		}
+		if err == nil && addNoScrub {
+			err = pol.CheckNoScrub()
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+
+		for _, commit := range commits {
+			switch addSource {
+			case "claude-cloud":
+				err = annotateCloudCommit(pol, commit, addSessionID, addAuto, addNoScrub)
+			case "codex-cloud":
+				err = addCodexCloudTask(pol, commit, addSessionID, addAuto, addNoScrub)
+			default:
+				err = fmt.Errorf("unknown source %q (expected claude-cloud or codex-cloud)", addSource)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+				os.Exit(1)
+			}
+		}
+	},
+}
+
+func init() {
+	addCmd.Flags().StringVar(&addSource, "source", "", "Session source: claude-cloud or codex-cloud")
+	addCmd.Flags().StringVar(&addSessionID, "session-id", "", "Session or task ID to attach")
+	addCmd.Flags().BoolVar(&addAuto, "auto", false, "Auto-detect session from branch name")
+	addCmd.Flags().BoolVar(&addNoScrub, "no-scrub", false, "Disable PII scrubbing")
+	addCmd.Flags().StringVar(&addFromBundle, "from-bundle", "", "Import sessions written by export-sessions on another machine")
+	rootCmd.AddCommand(addCmd)
+}
+
+// addCodexCloudTask fetches a Codex cloud task and adds it to the commit's note
+func addCodexCloudTask(pol *policy.Policy, commitRef, taskID string, autoDetect, noScrub bool) error {
+	sha, err := git.ResolveCommit(commitRef)
+	if err != nil {
+		return fmt.Errorf("invalid commit reference: %w", err)
+	}
+
+	client, err := codexcloud.NewClient()
+	if err != nil {
+		return err
+	}
+
+	// Get task (either by ID or auto-detect)
+	var task *codexcloud.Task
+	if autoDetect {
+		branchName, err := git.GetCurrentBranch()
+		if err != nil {
+			return fmt.Errorf("failed to get current branch: %w", err)
+		}
+		fmt.Printf("Looking for Codex task matching branch: %s\n", branchName)
+
+		task, err = client.FindTaskByBranch(branchName)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Found task: %s (%s)\n", task.Title, task.ID)
+	} else {
+		task, err = client.GetTask(taskID)
+		if err != nil {
+			return fmt.Errorf("failed to get task: %w", err)
+		}
+	}
+
+	fmt.Printf("Fetching task turns...\n")
+	turns, err := client.GetTaskTurns(task.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get task turns: %w", err)
+	}
+
+	jsonl, err := codexcloud.TurnsToJSONL(turns, task)
+	if err != nil {
+		return fmt.Errorf("failed to convert turns: %w", err)
+	}
+	if len(jsonl) == 0 {
+		return fmt.Errorf("task %s has no messages", task.ID)
+	}
+
+	// Scrub PII from transcript (unless --no-scrub)
+	if !noScrub {
+		piiScrubber, err := pol.Scrubber()
+		if err != nil {
+			return err
+		}
+		jsonl, err = piiScrubber.ForTool("codex-cloud").Scrub(jsonl)
+		if err != nil {
+			return fmt.Errorf("failed to scrub PII: %w", err)
+		}
+	}
+
+	blobSHA, err := git.HashObject(jsonl)
+	if err != nil {
+		return fmt.Errorf("failed to store transcript: %w", err)
+	}
+
+	transcriptPath := note.GetTranscriptPath("codex-cloud", task.ID)
+	if err := note.UpdateTranscriptTree(map[string]string{transcriptPath: blobSHA}); err != nil {
+		return fmt.Errorf("failed to update transcript tree: %w", err)
+	}
+
+	// Merge into the existing note so locally captured sessions are kept
+	start, end := codexcloud.GetTaskTimeRange(task)
+	osUser, author := note.CaptureOwner()
+	psNote := &note.PromptStoryNote{
+		Version:   1,
+		CreatedBy: note.CLIVersion,
+		StartWork: start,
+		Sessions: []note.SessionEntry{{
+			Tool:     "codex-cloud",
+			ID:       task.ID,
+			Path:     transcriptPath,
+			Created:  start,
+			Modified: end,
+			OSUser:   osUser,
+			Author:   author,
+		}},
+	}
+	if err := psNote.SealTranscripts(map[string]string{transcriptPath: blobSHA}); err != nil {
+		return fmt.Errorf("failed to seal transcript: %w", err)
+	}
+	if existing, err := note.GetNote(sha); err == nil && existing != "" {
+		if parsed, err := note.ParseNote([]byte(existing)); err == nil {
+			psNote = note.MergeNotes([]*note.PromptStoryNote{parsed, psNote})
+		}
+	}
+
+	noteJSON, err := psNote.ToJSON()
+	if err != nil {
+		return fmt.Errorf("failed to serialize note: %w", err)
+	}
+	if err := git.AddNote(note.NotesRef, string(noteJSON), sha); err != nil {
+		return fmt.Errorf("failed to attach note: %w", err)
+	}
+
+	fmt.Printf("Added Codex task %s to commit %s\n", task.ID, sha[:7])
+	return nil
+}
+
+// addFromSessionExport imports the sessions of an export-sessions file.
+// Without a commit they wait for the next commit; otherwise they are added
+// to the commit's note.
+func addFromSessionExport(path, commitRef string, noScrub bool) error {
+	f, err := handoff.Read(path)
+	if err != nil {
+		return err
+	}
+	if len(f.Sessions) == 0 {
+		return fmt.Errorf("%s has no sessions", path)
+	}
+	pol, err := loadPolicy()
+	if err != nil {
+		return err
+	}
+	for _, s := range f.Sessions {
+		if err := pol.CheckTool(s.Tool); err != nil {
+			return err
+		}
+	}
+	if noScrub {
+		if err := pol.CheckNoScrub(); err != nil {
+			return err
+		}
+	}
+
+	sessions, err := handoff.Import(f)
+	if err != nil {
+		return err
+	}
+	from := "another machine"
+	if f.Host != "" {
+		from = f.Host
+	}
+	if commitRef == "" {
+		fmt.Printf("Imported %d session(s) from %s; the next commit will include them\n", len(sessions), from)
+		return nil
+	}
+
+	sha, err := git.ResolveCommit(commitRef)
+	if err != nil {
+		return fmt.Errorf("invalid commit reference: %w", err)
+	}
+
+	// Exports are normally scrubbed already; scrub again in case not
+	var piiScrubber scrubber.Scrubber
+	if !noScrub {
+		ps, err := pol.Scrubber()
+		if err != nil {
+			return err
+		}
+		piiScrubber = ps
+	}
+	blobs, err := note.StoreTranscripts(sessions, piiScrubber)
+	if err != nil {
+		return fmt.Errorf("failed to store transcripts: %w", err)
+	}
+	if err := note.UpdateTranscriptTree(blobs); err != nil {
+		return fmt.Errorf("failed to update transcript tree: %w", err)
+	}
+
+	start := sessions[0].Created
+	for _, s := range sessions {
+		if s.Created.Before(start) {
+			start = s.Created
+		}
+	}
+	psNote := note.NewPromptStoryNote(sessions, false, start)
+	psNote.CaptureContexts(sessions, piiScrubber)
+	if err := psNote.SealTranscripts(blobs); err != nil {
+		return fmt.Errorf("failed to seal transcripts: %w", err)
+	}
+	if existing, err := note.GetNote(sha); err == nil && existing != "" {
+		if parsed, err := note.ParseNote([]byte(existing)); err == nil {
+			psNote = note.MergeNotes([]*note.PromptStoryNote{parsed, psNote})
+		}
+	}
+
+	noteJSON, err := psNote.ToJSON()
+	if err != nil {
+		return fmt.Errorf("failed to serialize note: %w", err)
+	}
+	if err := git.AddNote(note.NotesRef, string(noteJSON), sha); err != nil {
+		return fmt.Errorf("failed to attach note: %w", err)
+	}
+
+	fmt.Printf("Added %d session(s) from %s to commit %s\n", len(sessions), from, sha[:7])
+	return nil
+}
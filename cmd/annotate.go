@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+	"github.com/QuesmaOrg/git-prompt-story/internal/note"
+	"github.com/spf13/cobra"
+)
+
+var (
+	annotateTags   []string
+	annotateUntags []string
+)
+
+var annotateCmd = &cobra.Command{
+	Use:   "annotate <commit|range>",
+	Short: "Tag prompt-story notes",
+	Long: `Add or remove tags on the prompt-story notes of a commit or range, to
+categorize AI-assisted work (refactor, bugfix, experiment, ...). Tags are
+lowercased; pr summary shows how many commits carry each tag and can apply
+matching labels to the pull request.
+
+Examples:
+  git-prompt-story annotate HEAD --tag refactor
+  git-prompt-story annotate main..feature --tag experiment,spike
+  git-prompt-story annotate HEAD~2 --untag experiment`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(annotateTags)+len(annotateUntags) == 0 {
+			fmt.Fprintln(os.Stderr, "git-prompt-story: --tag or --untag is required")
+			os.Exit(1)
+		}
+		add, err := normalizeTags(annotateTags)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+		remove, err := normalizeTags(annotateUntags)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+
+		commits, err := git.ResolveCommitSpec(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+
+		noted, changed := 0, 0
+		for _, sha := range commits {
+			if _, err := note.GetNote(sha); err != nil {
+				continue // No note to tag
+			}
+			noted++
+			ok, err := note.TagNote(sha, add, remove)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+				os.Exit(1)
+			}
+			if ok {
+				changed++
+			}
+		}
+
+		if noted == 0 {
+			fmt.Println("No prompt-story notes in range")
+			return
+		}
+		fmt.Printf("Updated tags on %d of %d note(s)\n", changed, noted)
+		if changed > 0 {
+			fmt.Println("Push with: git push origin refs/notes/prompt-story")
+		}
+	},
+}
+
+// normalizeTags normalizes tags given on the command line
+func normalizeTags(tags []string) ([]string, error) {
+	var normalized []string
+	for _, t := range tags {
+		n, err := note.NormalizeTag(t)
+		if err != nil {
+			return nil, err
+		}
+		normalized = append(normalized, n)
+	}
+	return normalized, nil
+}
+
+func init() {
+	annotateCmd.Flags().StringSliceVar(&annotateTags, "tag", nil, "Tags to add (repeatable or comma-separated)")
+	annotateCmd.Flags().StringSliceVar(&annotateUntags, "untag", nil, "Tags to remove (repeatable or comma-separated)")
+	rootCmd.AddCommand(annotateCmd)
+}
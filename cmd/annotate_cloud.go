@@ -8,7 +8,7 @@ import (
 	"github.com/QuesmaOrg/git-prompt-story/internal/cloud"
 	"github.com/QuesmaOrg/git-prompt-story/internal/git"
 	"github.com/QuesmaOrg/git-prompt-story/internal/note"
-	"github.com/QuesmaOrg/git-prompt-story/internal/scrubber"
+	"github.com/QuesmaOrg/git-prompt-story/internal/policy"
 	"github.com/spf13/cobra"
 )
 
@@ -40,7 +40,19 @@ Examples:
 			os.Exit(1)
 		}
 
-		if err := annotateCloudCommit(commit, sessionIDFlag, autoFlag, noScrubFlag); err != nil {
+		pol, err := loadPolicy()
+		if err == nil {
+			err = pol.CheckTool("claude-cloud")
+		}
+		if err == nil && noScrubFlag {
+			err = pol.CheckNoScrub()
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+
+		if err := annotateCloudCommit(pol, commit, sessionIDFlag, autoFlag, noScrubFlag); err != nil {
 			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
 			os.Exit(1)
 		}
@@ -54,7 +66,7 @@ func init() {
 	rootCmd.AddCommand(annotateCloudCmd)
 }
 
-func annotateCloudCommit(commitRef, sessionID string, autoDetect, noScrub bool) error {
+func annotateCloudCommit(pol *policy.Policy, commitRef, sessionID string, autoDetect, noScrub bool) error {
 	// Resolve commit
 	sha, err := git.ResolveCommit(commitRef)
 	if err != nil {
@@ -115,11 +127,11 @@ func annotateCloudCommit(commitRef, sessionID string, autoDetect, noScrub bool)
 
 	// Scrub PII from transcript (unless --no-scrub)
 	if !noScrub {
-		piiScrubber, err := scrubber.NewDefault()
+		piiScrubber, err := pol.Scrubber()
 		if err != nil {
-			return fmt.Errorf("failed to create scrubber: %w", err)
+			return err
 		}
-		jsonl, err = piiScrubber.Scrub(jsonl)
+		jsonl, err = piiScrubber.ForTool("claude-cloud").Scrub(jsonl)
 		if err != nil {
 			return fmt.Errorf("failed to scrub PII: %w", err)
 		}
@@ -137,8 +149,10 @@ func annotateCloudCommit(commitRef, sessionID string, autoDetect, noScrub bool)
 	}
 
 	// Create PromptStoryNote using main's format
+	osUser, author := note.CaptureOwner()
 	psNote := &note.PromptStoryNote{
 		Version:   1,
+		CreatedBy: note.CLIVersion,
 		StartWork: sess.CreatedAt,
 		Sessions: []note.SessionEntry{{
 			Tool:     "claude-cloud",
@@ -146,8 +160,14 @@ func annotateCloudCommit(commitRef, sessionID string, autoDetect, noScrub bool)
 			Path:     note.GetTranscriptPath("claude-cloud", sess.ID),
 			Created:  sess.CreatedAt,
 			Modified: sess.UpdatedAt,
+			OSUser:   osUser,
+			Author:   author,
 		}},
 	}
+	cloudPath := note.GetTranscriptPath("claude-cloud", sess.ID)
+	if err := psNote.SealTranscripts(map[string]string{cloudPath: blobSHA}); err != nil {
+		return fmt.Errorf("failed to seal transcript: %w", err)
+	}
 	noteJSON, err := json.MarshalIndent(psNote, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to serialize note: %w", err)
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/policy"
+	"github.com/QuesmaOrg/git-prompt-story/internal/show"
+	"github.com/spf13/cobra"
+)
+
+var (
+	applyPolicyDryRun       bool
+	applyPolicyOverrideHold bool
+)
+
+var applyPolicyCmd = &cobra.Command{
+	Use:   "apply-policy [commit-range]",
+	Short: "Apply the policy's redaction rules to stored transcripts",
+	Long: `Run the redaction_rules from .prompt-story-policy.yaml over the stored
+transcripts of a range (default: all noted commits). Each rule names a
+handler and may be narrowed to one tool and to entries older than an age:
+
+  redaction_rules:
+    - name: no-cursor
+      handler: redact       # replace message content
+      tool: cursor
+    - name: old-code
+      handler: blur-code    # replace code, keep the prose
+      older_than: 90d
+
+Rewritten transcripts are re-sealed and the change is logged in the note,
+as with manual redaction in show. Transcripts on legal hold are refused
+unless --override-hold is given. Local session files are not touched.
+
+Examples:
+  git-prompt-story apply-policy --dry-run
+  git-prompt-story apply-policy origin/main..HEAD`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		rangeSpec := "--all"
+		if len(args) > 0 {
+			rangeSpec = args[0]
+		}
+
+		pol, err := loadPolicy()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+		if len(pol.RedactionRules) == 0 {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: no redaction_rules in %s\n", policy.FileName)
+			os.Exit(1)
+		}
+
+		changes, err := show.ApplyPolicy(rangeSpec, pol.RedactionRules, applyPolicyDryRun, applyPolicyOverrideHold)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+		if len(changes) == 0 {
+			fmt.Println("No transcripts matched the redaction rules")
+			return
+		}
+
+		entries := 0
+		for _, c := range changes {
+			fmt.Printf("  %s: %d entries (%s)\n", c.Path, c.Entries, strings.Join(c.Rules, ", "))
+			entries += c.Entries
+		}
+		verb := "Rewrote"
+		if applyPolicyDryRun {
+			verb = "Would rewrite"
+		}
+		fmt.Printf("%s %d entries in %d transcript(s)\n", verb, entries, len(changes))
+
+		if !applyPolicyDryRun && show.WasNotesPushed() {
+			fmt.Println("Notes were already pushed; force-push to apply remotely:")
+			fmt.Println("  git push -f origin refs/notes/prompt-story refs/notes/prompt-story-transcripts")
+		}
+	},
+}
+
+func init() {
+	applyPolicyCmd.Flags().BoolVar(&applyPolicyDryRun, "dry-run", false, "Show what would change without rewriting transcripts")
+	applyPolicyCmd.Flags().BoolVar(&applyPolicyOverrideHold, "override-hold", false, "Also rewrite transcripts on legal hold (recorded in the note)")
+	rootCmd.AddCommand(applyPolicyCmd)
+}
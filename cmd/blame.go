@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/ci"
+	"github.com/spf13/cobra"
+)
+
+var blameCmd = &cobra.Command{
+	Use:   "blame <file>",
+	Short: "Show which prompt wrote each line of a file",
+	Long: `Blame a file at HEAD and show, next to each range of lines, the prompt
+that produced it.
+
+For each line the commit it comes from is searched for the Edit or Write
+tool call that wrote its text, as why does for a single line. When no edit
+wrote the text, the prompt of the last edit of the file in the commit is
+shown, marked (file); when the commit's sessions did not edit the file,
+its last prompt, marked (commit). Commits without captured sessions show
+their subject.
+
+Examples:
+  git-prompt-story blame internal/ci/summary.go
+  git-prompt-story blame src/app.go | less`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ranges, err := ci.BlameFile(args[0], false)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Print(ci.RenderBlame(ranges))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(blameCmd)
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/ci"
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+	"github.com/QuesmaOrg/git-prompt-story/internal/show"
+	"github.com/spf13/cobra"
+)
+
+var (
+	branchBase string
+	branchTUI  bool
+	branchFull bool
+)
+
+var branchCmd = &cobra.Command{
+	Use:   "branch [name]",
+	Short: "Show the prompt story of a whole branch",
+	Long: `Aggregate the prompt story of a branch since it forked from the default
+branch, the same summary pr summary produces, before a PR is opened.
+
+The branch defaults to the current one. The default branch is detected from
+origin/HEAD, falling back to origin/main, origin/master, main or master;
+override it with --base.
+
+Examples:
+  git-prompt-story branch
+  git-prompt-story branch feature/login --tui
+  git-prompt-story branch --base=origin/develop`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		commitRange, err := branchRange(args)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+
+		if branchTUI {
+			if err := show.RunTUI(commitRange, branchFull); err != nil {
+				fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		summary, err := ci.GenerateSummary(commitRange, branchFull)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Print(ci.RenderMarkdown(summary, "", GetVersion()))
+	},
+}
+
+// branchRange returns the commits of the branch named in args (the current
+// one by default) since its merge base with the base branch
+func branchRange(args []string) (string, error) {
+	branch := "HEAD"
+	if len(args) > 0 {
+		branch = args[0]
+	}
+	base := branchBase
+	if base == "" {
+		var err error
+		if base, err = git.DefaultBranch(); err != nil {
+			return "", err
+		}
+	}
+
+	return mergeBaseRange(branch, base)
+}
+
+// mergeBaseRange returns the commits of branch since its merge base with base
+func mergeBaseRange(branch, base string) (string, error) {
+	tip, err := git.ResolveCommit(branch)
+	if err != nil {
+		return "", fmt.Errorf("unknown branch %s", branch)
+	}
+	mergeBase, err := git.MergeBase(base, tip)
+	if err != nil {
+		return "", err
+	}
+	if mergeBase == tip {
+		return "", fmt.Errorf("%s has no commits that are not on %s (use --base to compare with another branch)", branch, base)
+	}
+	return mergeBase + ".." + tip, nil
+}
+
+func init() {
+	branchCmd.Flags().StringVar(&branchBase, "base", "", "Branch to compare with (default: detected default branch)")
+	branchCmd.Flags().BoolVar(&branchTUI, "tui", false, "Open the interactive TUI viewer")
+	branchCmd.Flags().BoolVar(&branchFull, "full", false, "Include full prompt text (not truncated)")
+	rootCmd.AddCommand(branchCmd)
+}
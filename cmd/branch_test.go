@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBranchCommand(t *testing.T) {
+	run := initTestRepo(t)
+	at := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+	run("checkout", "-q", "-b", "feature/login")
+	commitWithNote(t, "Add login form", "Build the login form", at)
+	commitWithNote(t, "Validate passwords", "Check password strength", at.Add(time.Hour))
+	run("checkout", "-q", "main")
+	commitWithNote(t, "Unrelated fix on main", "Fix the footer", at.Add(2*time.Hour))
+	run("checkout", "-q", "feature/login")
+
+	tests := []struct {
+		name     string
+		args     []string
+		want     []string
+		dontWant []string
+	}{
+		{
+			name:     "current branch since it forked from main",
+			args:     []string{"branch"},
+			want:     []string{"Build the login form", "Check password strength"},
+			dontWant: []string{"Fix the footer"},
+		},
+		{
+			name:     "named branch",
+			args:     []string{"branch", "feature/login"},
+			want:     []string{"Build the login form", "Check password strength"},
+			dontWant: []string{"Fix the footer"},
+		},
+		{
+			name:     "base given",
+			args:     []string{"branch", "--base=feature/login~1"},
+			want:     []string{"Check password strength"},
+			dontWant: []string{"Build the login form", "Fix the footer"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout, stderr, code := runCommand(t, tt.args...)
+			if code != 0 {
+				t.Fatalf("%v exited %d: %s", tt.args, code, stderr)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(stdout, want) {
+					t.Errorf("output is missing %q:\n%s", want, stdout)
+				}
+			}
+			for _, dontWant := range tt.dontWant {
+				if strings.Contains(stdout, dontWant) {
+					t.Errorf("output has %q from outside the branch:\n%s", dontWant, stdout)
+				}
+			}
+		})
+	}
+}
+
+func TestBranchCommand_Errors(t *testing.T) {
+	run := initTestRepo(t)
+	commitWithNote(t, "On main", "Prompt on main", time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC))
+
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{name: "unknown branch", args: []string{"branch", "no-such-branch"}, wantErr: "unknown branch no-such-branch"},
+		{name: "no commits of its own", args: []string{"branch", "main"}, wantErr: "main has no commits that are not on main"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, stderr, code := runCommand(t, tt.args...)
+			if code != 1 || !strings.Contains(stderr, tt.wantErr) {
+				t.Errorf("%v = exit %d, stderr %q; want exit 1 with %q", tt.args, code, stderr, tt.wantErr)
+			}
+		})
+	}
+
+	// Without main, master or an origin, there is nothing to compare with
+	run("branch", "-m", "main", "trunk")
+	_, stderr, code := runCommand(t, "branch")
+	if code != 1 || !strings.Contains(stderr, "cannot detect the default branch") {
+		t.Errorf("branch without a default branch = exit %d, stderr %q", code, stderr)
+	}
+}
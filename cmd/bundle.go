@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/bundle"
+	"github.com/spf13/cobra"
+)
+
+var bundleCmd = &cobra.Command{
+	Use:   "bundle",
+	Short: "Transfer commits with their prompt stories as a file",
+	Long: `Package commits together with their prompt-story notes and transcripts
+into a git bundle, for review environments that cannot reach the remote.
+
+Examples:
+  git-prompt-story bundle create story.bundle main..feature
+  git-prompt-story bundle verify story.bundle
+  git-prompt-story bundle apply story.bundle`,
+}
+
+var bundleCreateCmd = &cobra.Command{
+	Use:   "create <file> [range]",
+	Short: "Write commits, notes and transcripts to a bundle file",
+	Long: `Write the commits in range (default: HEAD and its history) to a git
+bundle, with the notes of those commits and the transcripts the notes
+reference. Notes of other commits are not included.
+
+Examples:
+  git-prompt-story bundle create story.bundle main..feature
+  git-prompt-story bundle create story.bundle v1.2..HEAD`,
+	Args: cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		rangeSpec := "HEAD"
+		if len(args) == 2 {
+			rangeSpec = args[1]
+		}
+
+		contents, err := bundle.Create(args[0], rangeSpec)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Wrote %s: %s with %d note(s) and %d transcript(s)\n",
+			args[0], rangeSpec, contents.Notes, contents.Transcripts)
+	},
+}
+
+var bundleVerifyCmd = &cobra.Command{
+	Use:   "verify <file>",
+	Short: "Check a bundle and the integrity of its transcripts",
+	Long: `Check that a bundle can be applied to this repository, i.e. that the
+commits it builds on exist here, and that every bundled transcript matches
+the hash chain sealed in its note. Local notes are not changed.
+
+Examples:
+  git-prompt-story bundle verify story.bundle`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		contents, err := bundle.Verify(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+		printBundleHeads(contents)
+		fmt.Printf("%d note(s), %d transcript(s)\n", contents.Notes, contents.Transcripts)
+		if len(contents.Problems) > 0 {
+			for _, p := range contents.Problems {
+				fmt.Printf("  %s\n", p)
+			}
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %d transcript(s) failed verification\n", len(contents.Problems))
+			os.Exit(1)
+		}
+		fmt.Println("OK")
+	},
+}
+
+var bundleApplyCmd = &cobra.Command{
+	Use:   "apply <file>",
+	Short: "Import the notes and transcripts of a bundle",
+	Long: `Import the prompt-story notes and transcripts of a bundle. A note for a
+commit that already has one is merged with it; transcripts already present
+are kept. The commits themselves are fetched with git, as the command
+prints, e.g.:
+
+  git fetch story.bundle refs/heads/feature:refs/heads/feature
+
+Examples:
+  git-prompt-story bundle apply story.bundle`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		contents, err := bundle.Apply(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Applied %d note(s) (%d merged) and %d transcript(s) (%d already present)\n",
+			contents.Notes, contents.Merged, contents.Transcripts, contents.Kept)
+		if len(contents.Heads) > 0 {
+			fmt.Println("Fetch the commits with:")
+			for _, h := range contents.Heads {
+				fmt.Printf("  git fetch %s %s\n", args[0], h)
+			}
+		}
+	},
+}
+
+// printBundleHeads lists the commit refs a bundle carries
+func printBundleHeads(contents *bundle.Contents) {
+	for _, h := range contents.Heads {
+		fmt.Printf("Ref: %s\n", h)
+	}
+}
+
+func init() {
+	bundleCmd.AddCommand(bundleCreateCmd)
+	bundleCmd.AddCommand(bundleVerifyCmd)
+	bundleCmd.AddCommand(bundleApplyCmd)
+	rootCmd.AddCommand(bundleCmd)
+}
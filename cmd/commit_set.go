@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+	"github.com/spf13/cobra"
+)
+
+// commitSetFlags compose the commits a command works on, see git.CommitSet
+type commitSetFlags struct {
+	in    []string
+	not   []string
+	stdin bool
+}
+
+// register adds --in, --not and --stdin to cmd
+func (f *commitSetFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringArrayVar(&f.in, "in", nil, "Also include the commits of this ref, range or list (repeatable)")
+	cmd.Flags().StringArrayVar(&f.not, "not", nil, "Leave out the commits of this range, or those on refs matching this ref or glob, cherry-picks included (repeatable)")
+	cmd.Flags().BoolVar(&f.stdin, "stdin", false, "Read specs from stdin, one per line; ^spec leaves commits out")
+}
+
+// used reports whether any of the flags was given
+func (f *commitSetFlags) used() bool {
+	return len(f.in) > 0 || len(f.not) > 0 || f.stdin
+}
+
+// resolve returns the commits of the specs in args and the flags, a ref
+// standing for the commits reachable from it if reachable is set. Without
+// a spec to include, defaultIn names the commits.
+func (f *commitSetFlags) resolve(args []string, reachable bool, defaultIn func() (string, error)) ([]string, error) {
+	set := git.CommitSet{In: append(append([]string(nil), args...), f.in...), Not: f.not, Reachable: reachable}
+	if f.stdin {
+		read, err := git.ReadCommitSet(os.Stdin)
+		if err != nil {
+			return nil, err
+		}
+		set.In = append(set.In, read.In...)
+		set.Not = append(set.Not, read.Not...)
+	}
+	if len(set.In) == 0 {
+		spec, err := defaultIn()
+		if err != nil {
+			return nil, err
+		}
+		set.In = []string{spec}
+	}
+	return set.Resolve()
+}
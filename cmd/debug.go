@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/demo"
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+	"github.com/QuesmaOrg/git-prompt-story/internal/session"
+	"github.com/spf13/cobra"
+)
+
+var (
+	captureFixtureTool string
+	captureFixtureName string
+	captureFixtureDir  string
+)
+
+var debugCmd = &cobra.Command{
+	Use:    "debug",
+	Short:  "Tools for developing git-prompt-story",
+	Hidden: true,
+}
+
+var captureFixtureCmd = &cobra.Command{
+	Use:   "capture-fixture <session-file>",
+	Short: "Store an anonymized transcript in the parser test corpus",
+	Long: `Store an anonymized copy of a transcript in the parser test corpus, with
+the entry and content block counts the current parser produces for it.
+The corpus test (go test ./internal/session) fails when a parser change
+alters those counts, so parsers can evolve against real-world transcripts.
+
+The file must hold a transcript as stored in notes: the session JSONL for
+Claude Code, or the content from refs/notes/prompt-story-transcripts for
+other tools. PII is scrubbed with the default scrubber and home directories
+are replaced; review the fixture before committing it.
+
+Examples:
+  git-prompt-story debug capture-fixture ~/.claude/projects/-home-me-app/abc.jsonl
+  git-prompt-story debug capture-fixture --tool cursor --name tool-calls cursor.jsonl`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		dir := captureFixtureDir
+		if dir == "" {
+			root, err := git.GetRepoRoot()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+				os.Exit(1)
+			}
+			dir = filepath.Join(root, session.FixtureDir)
+		}
+
+		path, err := session.CaptureFixture(args[0], captureFixtureTool, captureFixtureName, dir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Captured %s\n", path)
+	},
+}
+
+var makeDemoRepoCmd = &cobra.Command{
+	Use:   "make-demo-repo <dir>",
+	Short: "Create a repository with synthetic sessions",
+	Long: `Create a git repository with synthetic captured sessions, for demos,
+documentation screenshots and trying the read commands without real
+transcripts.
+
+The repository has a pull request range (` + demo.Range + `) of several commits
+with notes: Claude Code sessions including an agent and a session continued
+after a restart, a Cursor composer with a rejected tool call, tags, a commit
+without sessions and a redacted prompt. Commit dates and session content are fixed, so the output of the
+read commands is the same on every run. Hooks are not run.
+
+Examples:
+  git-prompt-story debug make-demo-repo /tmp/demo
+  cd /tmp/demo && git-prompt-story pr summary ` + demo.Range,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := demo.Create(args[0]); err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Created demo repository in %s\n", args[0])
+		fmt.Printf("Pull request range: %s\n", demo.Range)
+	},
+}
+
+func init() {
+	captureFixtureCmd.Flags().StringVar(&captureFixtureTool, "tool", session.ToolClaudeCode, "Tool whose parser reads the transcript")
+	captureFixtureCmd.Flags().StringVar(&captureFixtureName, "name", "", "Fixture name (default: the file name)")
+	captureFixtureCmd.Flags().StringVar(&captureFixtureDir, "dir", "", "Corpus directory (default: "+session.FixtureDir+" in the repo)")
+	debugCmd.AddCommand(captureFixtureCmd)
+	debugCmd.AddCommand(makeDemoRepoCmd)
+	rootCmd.AddCommand(debugCmd)
+}
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDefaultCommand(t *testing.T) {
+	run := initTestRepo(t)
+	commitWithNote(t, "Add login form", "Build the login form", time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC))
+
+	tests := []struct {
+		value    string // "" leaves prompt-story.defaultCommand unset, so it comes first
+		want     string
+		wantCode int
+		wantErr  string
+	}{
+		{value: "", want: "Usage:"},
+		{value: "help", want: "Usage:"},
+		{value: "status", want: "Capture:"},
+		{value: "show", want: "Build the login form"},
+		{value: "bogus", want: "Usage:", wantCode: 1, wantErr: `unknown prompt-story.defaultCommand "bogus" (expected help, status or show)`},
+	}
+	for _, tt := range tests {
+		t.Run("value="+tt.value, func(t *testing.T) {
+			if tt.value != "" {
+				run("config", "prompt-story.defaultCommand", tt.value)
+			}
+			stdout, stderr, code := runCommand(t)
+			if code != tt.wantCode {
+				t.Fatalf("exit %d, want %d; stderr %q", code, tt.wantCode, stderr)
+			}
+			if !strings.Contains(stdout, tt.want) {
+				t.Errorf("output is missing %q:\n%s", tt.want, stdout)
+			}
+			if tt.wantErr != "" && !strings.Contains(stderr, tt.wantErr) {
+				t.Errorf("stderr = %q, want %q", stderr, tt.wantErr)
+			}
+		})
+	}
+
+	// show stops on a commit without a note, like show HEAD
+	run("config", "prompt-story.defaultCommand", "show")
+	run("commit", "-q", "--allow-empty", "-m", "No note")
+	if _, stderr, code := runCommand(t); code != 1 || !strings.Contains(stderr, "no prompt-story note found") {
+		t.Errorf("show on a commit without a note = exit %d, stderr %q", code, stderr)
+	}
+}
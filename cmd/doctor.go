@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+	"github.com/QuesmaOrg/git-prompt-story/internal/pause"
+	"github.com/QuesmaOrg/git-prompt-story/internal/retryqueue"
+	"github.com/QuesmaOrg/git-prompt-story/internal/session"
+	"github.com/spf13/cobra"
+)
+
+var (
+	doctorSchema      bool
+	doctorDays        int
+	doctorRepairQueue bool
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check local setup for problems",
+	Long: `Check local setup for problems. Without flags every check runs,
+including whether capture is paused (see pause).
+
+--schema parses the Claude Code sessions of this repository from the last
+--days days and reports entry types, fields and content blocks the parser
+does not know. Claude Code's transcript format changes often; unknown
+elements may be silently dropped from notes until the parser catches up.
+The prepare-commit-msg hook also logs them to .git/prompt-story-debug.log
+on every capture.
+
+Notes the post-commit hook failed to attach (ref lock contention, a full
+disk) are queued in .git/prompt-story-retry/ and retried by the next
+git-prompt-story command. --repair-queue retries them now and lists those
+that still fail.
+
+Examples:
+  git-prompt-story doctor
+  git-prompt-story doctor --schema --days 90
+  git-prompt-story doctor --repair-queue`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		all := !doctorSchema && !doctorRepairQueue
+		if all {
+			if err := doctorCheckCapture(); err != nil {
+				fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		if all || doctorRepairQueue {
+			if err := doctorCheckQueue(doctorRepairQueue); err != nil {
+				fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		if all || doctorSchema {
+			if err := doctorCheckSchema(); err != nil {
+				fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+				os.Exit(1)
+			}
+		}
+	},
+}
+
+// doctorCheckCapture reports a paused capture, which is easy to forget
+func doctorCheckCapture() error {
+	state, err := pause.Current()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Capture: %s\n", state)
+	if state.Paused {
+		fmt.Println("  commits are marked \"Prompt-Story: paused\"; run git-prompt-story resume")
+	}
+	return nil
+}
+
+// doctorCheckQueue reports notes waiting in the retry queue, retrying
+// them first when repair is set
+func doctorCheckQueue(repair bool) error {
+	if repair {
+		attached, _, err := retryqueue.Drain()
+		if err != nil {
+			return err
+		}
+		if attached > 0 {
+			fmt.Printf("Attached %d queued note(s)\n", attached)
+		}
+	}
+
+	entries, err := retryqueue.Pending()
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		fmt.Println("Retry queue: empty")
+		return nil
+	}
+	fmt.Printf("Retry queue: %d note(s) not attached\n", len(entries))
+	for _, e := range entries {
+		fmt.Printf("  %s queued %s, %d attempt(s): %s\n",
+			e.Commit[:7], e.Queued.Local().Format("2006-01-02 15:04"), e.Attempts, e.LastError)
+	}
+	if !repair {
+		fmt.Println("  retry with: git-prompt-story doctor --repair-queue")
+	}
+	return nil
+}
+
+// doctorCheckSchema prints the schema drift report of recent sessions
+func doctorCheckSchema() error {
+	repoRoot, err := git.GetRepoRoot()
+	if err != nil {
+		return err
+	}
+
+	end := time.Now()
+	start := end.AddDate(0, 0, -doctorDays)
+	sessions, err := session.FindAllSessions(repoRoot, start, end, func(tool string) bool {
+		return tool == session.ToolClaudeCode
+	})
+	if err != nil && len(sessions) == 0 {
+		return err
+	}
+
+	report := session.CheckSessions(sessions)
+	fmt.Printf("Schema: checked %d entries in %d Claude Code sessions\n", report.Entries, len(sessions))
+	if report.Empty() {
+		fmt.Println("  no unknown entry types or fields")
+		return nil
+	}
+	for _, line := range report.Lines() {
+		fmt.Printf("  %s\n", line)
+	}
+	return nil
+}
+
+func init() {
+	doctorCmd.Flags().BoolVar(&doctorSchema, "schema", false, "Report unknown transcript entry types and fields")
+	doctorCmd.Flags().IntVar(&doctorDays, "days", 30, "How many days of sessions to check")
+	doctorCmd.Flags().BoolVar(&doctorRepairQueue, "repair-queue", false, "Retry attaching notes queued after a failed post-commit hook")
+	rootCmd.AddCommand(doctorCmd)
+}
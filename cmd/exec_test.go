@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+	"github.com/QuesmaOrg/git-prompt-story/internal/note"
+)
+
+// TestHelperProcess is not a real test: runCommand runs the command line
+// after "--" through it in a subprocess, so commands can exit with their
+// status like the binary does
+func TestHelperProcess(t *testing.T) {
+	if os.Getenv("GIT_PROMPT_STORY_HELPER_PROCESS") != "1" {
+		return
+	}
+	args := os.Args
+	for len(args) > 0 && args[0] != "--" {
+		args = args[1:]
+	}
+	rootCmd.SetArgs(args[1:])
+	Execute()
+	os.Exit(0)
+}
+
+// runCommand runs git-prompt-story with args in the working directory and
+// returns what it printed and its exit code
+func runCommand(t *testing.T, args ...string) (stdout, stderr string, code int) {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], append([]string{"-test.run=^TestHelperProcess$", "--"}, args...)...)
+	cmd.Env = append(os.Environ(), "GIT_PROMPT_STORY_HELPER_PROCESS=1")
+	var out, errOut bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &out, &errOut
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	switch {
+	case errors.As(err, &exitErr):
+		code = exitErr.ExitCode()
+	case err != nil:
+		t.Fatalf("running %v: %v", args, err)
+	}
+	return out.String(), errOut.String(), code
+}
+
+// initTestRepo makes a repository in a temporary directory the test runs
+// in, with one commit on main, and a home directory without sessions
+func initTestRepo(t *testing.T) func(args ...string) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Chdir(t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	for _, kv := range []string{"GIT_AUTHOR_NAME=A", "GIT_AUTHOR_EMAIL=a@example.com", "GIT_COMMITTER_NAME=A", "GIT_COMMITTER_EMAIL=a@example.com"} {
+		k, v, _ := strings.Cut(kv, "=")
+		t.Setenv(k, v)
+	}
+
+	run := func(args ...string) string {
+		t.Helper()
+		out, err := git.RunGit(args...)
+		if err != nil {
+			t.Fatalf("git %v: %v", args, err)
+		}
+		return out
+	}
+	run("init", "-q", "-b", "main")
+	run("commit", "-q", "--allow-empty", "-m", "base")
+	return run
+}
+
+// commitWithNote makes an empty commit at the given time, with a note
+// holding one claude-code session in which prompt was sent ten minutes
+// earlier. It returns the commit SHA.
+func commitWithNote(t *testing.T, subject, prompt string, at time.Time) string {
+	t.Helper()
+	t.Setenv("GIT_AUTHOR_DATE", at.Format(time.RFC3339))
+	t.Setenv("GIT_COMMITTER_DATE", at.Format(time.RFC3339))
+	if _, err := git.RunGit("commit", "-q", "--allow-empty", "-m", subject+"\n\nPrompt-Story: Used Claude Code"); err != nil {
+		t.Fatal(err)
+	}
+	sha, err := git.GetHead()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sent := at.Add(-10 * time.Minute)
+	id := "s-" + sha[:7]
+	transcript := fmt.Sprintf("{\"type\":\"user\",\"sessionId\":%q,\"timestamp\":%q,\"message\":{\"role\":\"user\",\"content\":%q}}\n",
+		id, sent.Format(time.RFC3339), prompt)
+	blob, err := git.HashObject([]byte(transcript))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := note.GetTranscriptPath("claude-code", id)
+	if err := note.UpdateTranscriptTree(map[string]string{path: blob}); err != nil {
+		t.Fatal(err)
+	}
+	psNote := &note.PromptStoryNote{
+		Version:   1,
+		StartWork: at.Add(-time.Hour),
+		Sessions: []note.SessionEntry{{
+			Tool: "claude-code", ID: id, Path: note.TranscriptsRef + "/" + path,
+			Created: sent, Modified: sent,
+		}},
+	}
+	data, err := psNote.ToJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := git.AddNote(note.NotesRef, string(data), sha); err != nil {
+		t.Fatal(err)
+	}
+	return sha
+}
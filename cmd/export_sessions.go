@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/config"
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+	"github.com/QuesmaOrg/git-prompt-story/internal/handoff"
+	"github.com/QuesmaOrg/git-prompt-story/internal/policy"
+	"github.com/QuesmaOrg/git-prompt-story/internal/scrubber"
+	"github.com/QuesmaOrg/git-prompt-story/internal/session"
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportSessionsSince   string
+	exportSessionsNoScrub bool
+)
+
+var exportSessionsCmd = &cobra.Command{
+	Use:   "export-sessions <file>",
+	Short: "Write local sessions to a file, to continue on another machine",
+	Long: `Write this repository's local LLM sessions to a file, so that a commit
+made on another machine can include them. Copy the file over and run
+'git-prompt-story add --from-bundle <file>' there; the next commit picks the
+sessions up as if they had been recorded on that machine.
+
+Sessions with prompts since the previous commit (or the last branch switch)
+are exported; --since takes an age such as 2d to go further back. Sessions
+are scrubbed like captured transcripts unless --no-scrub is given.
+
+Examples:
+  git-prompt-story export-sessions /tmp/sessions.json
+  git-prompt-story export-sessions --since 3d sessions.json`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		f, err := exportSessions(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+		if len(f.Sessions) == 0 {
+			fmt.Println("No sessions with prompts in the work period")
+			return
+		}
+		for _, s := range f.Sessions {
+			fmt.Printf("  %s/%s\n", s.Tool, s.ID)
+		}
+		fmt.Printf("Wrote %d session(s) to %s\n", len(f.Sessions), args[0])
+	},
+}
+
+func exportSessions(path string) (*handoff.File, error) {
+	repoRoot, err := git.GetRepoRoot()
+	if err != nil {
+		return nil, fmt.Errorf("not in a git repository: %w", err)
+	}
+	pol, err := loadPolicy()
+	if err != nil {
+		return nil, err
+	}
+	if exportSessionsNoScrub {
+		if err := pol.CheckNoScrub(); err != nil {
+			return nil, err
+		}
+	}
+
+	endWork := time.Now().UTC()
+	startWork, _ := git.CalculateWorkStartTime(false)
+	if exportSessionsSince != "" {
+		age, err := policy.ParseAge(exportSessionsSince)
+		if err != nil {
+			return nil, err
+		}
+		startWork = endWork.Add(-age)
+	}
+	startWork, endWork = session.WidenWorkPeriod(startWork, endWork, session.ClockSkew())
+
+	toolEnabled := func(tool string) bool {
+		return config.ToolEnabled(tool) && pol.ToolAllowed(tool)
+	}
+	sessions, err := session.FindAllSessions(repoRoot, startWork, endWork, toolEnabled)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "git-prompt-story: warning: %v\n", err)
+	}
+	sessions = session.FilterSessionsByUserMessages(sessions, startWork, endWork, nil)
+
+	var scrub scrubber.Scrubber
+	if !exportSessionsNoScrub {
+		ps, err := pol.Scrubber()
+		if err != nil {
+			return nil, err
+		}
+		scrub = ps
+	}
+
+	branch, _ := git.GetCurrentBranch()
+	return handoff.Export(path, sessions, branch, scrub)
+}
+
+func init() {
+	exportSessionsCmd.Flags().StringVar(&exportSessionsSince, "since", "", "Export sessions with prompts within this age (e.g. 2d) instead of the work period")
+	exportSessionsCmd.Flags().BoolVar(&exportSessionsNoScrub, "no-scrub", false, "Disable PII scrubbing")
+	rootCmd.AddCommand(exportSessionsCmd)
+}
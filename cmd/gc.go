@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/gc"
+	"github.com/QuesmaOrg/git-prompt-story/internal/policy"
+	"github.com/QuesmaOrg/git-prompt-story/internal/show"
+	"github.com/spf13/cobra"
+)
+
+var (
+	gcOlderThan    string
+	gcDryRun       bool
+	gcOverrideHold bool
+)
+
+var gcCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "Remove transcripts older than the retention window",
+	Long: `Remove session transcripts of commits older than the retention window.
+Commit notes are kept, with affected sessions marked expired, so the record
+of which tools were used survives. A transcript shared with a newer commit
+is kept, and so is one referenced by a note on legal hold (see hold) unless
+--override-hold is given; overrides are recorded in the held note. Legacy
+notes count as well, and gc stops without changes when a note cannot be
+read, since it may reference any transcript.
+
+The window comes from --older-than or, if omitted, transcript_retention in
+.prompt-story-policy.yaml. Ages take d (days), w (weeks) or y (years).
+
+Examples:
+  git-prompt-story gc --older-than 180d
+  git-prompt-story gc --dry-run        # Preview using the policy window`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		maxAge, err := gcRetention()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+
+		plan, err := gc.Collect(maxAge, gcDryRun, gcOverrideHold)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+
+		for _, p := range plan.Held {
+			fmt.Printf("Kept %s: on legal hold\n", p)
+		}
+		if plan.Empty() {
+			fmt.Println("No transcripts older than the retention window")
+			return
+		}
+
+		verb := "Removed"
+		if gcDryRun {
+			verb = "Would remove"
+		}
+		for _, p := range plan.Transcripts {
+			fmt.Printf("  %s\n", p)
+		}
+		fmt.Printf("%s %d transcript(s), marking notes on %d commit(s)\n",
+			verb, len(plan.Transcripts), len(plan.Commits))
+
+		if !gcDryRun && show.WasNotesPushed() {
+			fmt.Println("Notes were already pushed; force-push to apply remotely:")
+			fmt.Println("  git push -f origin refs/notes/prompt-story refs/notes/prompt-story-transcripts")
+		}
+	},
+}
+
+// gcRetention returns the window from --older-than, else from the policy
+func gcRetention() (time.Duration, error) {
+	if gcOlderThan != "" {
+		return policy.ParseAge(gcOlderThan)
+	}
+
+	pol, err := loadPolicy()
+	if err != nil {
+		return 0, err
+	}
+	maxAge, err := pol.RetentionPeriod()
+	if err != nil {
+		return 0, err
+	}
+	if maxAge == 0 {
+		return 0, fmt.Errorf("no retention window: pass --older-than or set transcript_retention in %s", policy.FileName)
+	}
+	return maxAge, nil
+}
+
+func init() {
+	gcCmd.Flags().StringVar(&gcOlderThan, "older-than", "", "Retention window, e.g. 180d (default: policy transcript_retention)")
+	gcCmd.Flags().BoolVar(&gcDryRun, "dry-run", false, "Show what would be removed without changing anything")
+	gcCmd.Flags().BoolVar(&gcOverrideHold, "override-hold", false, "Also remove transcripts on legal hold (recorded in the note)")
+	rootCmd.AddCommand(gcCmd)
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/editor"
+	"github.com/spf13/cobra"
+)
+
+var handleURICmd = &cobra.Command{
+	Use:   "handle-uri <uri>",
+	Short: "Open a file location from a prompt-story link",
+	Long: `Open the file and line referenced by a git-prompt-story://open link,
+as found next to file tool steps in the HTML transcripts (pr html).
+
+Register this command as the handler for the git-prompt-story:// scheme
+in your OS so clicking a link opens the local editor. The local clone is
+found in the current repository, or under the directory set with:
+
+  git config --global prompt-story.workspace ~/src
+
+The editor command defaults to "code --goto {file}:{line}" and can be
+changed with git config prompt-story.editor.
+
+Examples:
+  git-prompt-story handle-uri 'git-prompt-story://open?repo=app&path=main.go&line=12'
+  git-prompt-story handle-uri 'vscode://file/home/me/app/main.go:12'`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		req, err := editor.ParseOpenURI(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+		if err := editor.Open(req); err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(handleURICmd)
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/ci"
+	"github.com/spf13/cobra"
+)
+
+var (
+	historyHTML string
+	historyFull bool
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history <path>",
+	Short: "Show how AI sessions shaped a file over time",
+	Long: `Show the history of a file as a chronological narrative: every commit
+that changed it (following renames) and, for commits with notes, the
+prompts whose edits to the file the commit includes.
+
+Edits are matched to the file by the paths the tools recorded, so only
+Edit and Write tool calls count; changes made by shell commands are not
+attributed to a prompt.
+
+Examples:
+  git-prompt-story history internal/ci/summary.go
+  git-prompt-story history --html history.html src/app.go`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		h, err := ci.BuildFileHistory(args[0], historyFull)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+
+		if historyHTML == "" {
+			fmt.Print(ci.RenderFileHistory(h))
+			return
+		}
+		f, err := os.Create(historyHTML)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		if err := ci.WriteFileHistoryHTML(h, f); err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Wrote %s\n", historyHTML)
+	},
+}
+
+func init() {
+	historyCmd.Flags().StringVar(&historyHTML, "html", "", "Write a standalone HTML page to this file")
+	historyCmd.Flags().BoolVar(&historyFull, "full", false, "Do not truncate long prompts")
+	rootCmd.AddCommand(historyCmd)
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+	"github.com/QuesmaOrg/git-prompt-story/internal/note"
+	"github.com/spf13/cobra"
+)
+
+var (
+	holdReason  string
+	holdRelease bool
+)
+
+var holdCmd = &cobra.Command{
+	Use:   "hold <commit|range>",
+	Short: "Place notes on legal hold",
+	Long: `Mark the prompt-story notes of a commit or range as on legal hold.
+
+While held, the transcripts a note references are not redacted, cleared,
+quarantined or removed by gc; those operations fail unless --override-hold
+is passed, and every override is recorded in the note's audit trail
+together with each hold and release.
+
+Examples:
+  git-prompt-story hold HEAD --reason="Case 2025-17"
+  git-prompt-story hold v1.0..v1.2 --reason="Audit Q3"
+  git-prompt-story hold --release v1.0..v1.2 --reason="Case closed"`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if holdReason == "" && !holdRelease {
+			fmt.Fprintln(os.Stderr, "git-prompt-story: --reason is required when placing a hold")
+			os.Exit(1)
+		}
+
+		commits, err := git.ResolveCommitSpec(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+
+		changed := 0
+		for _, sha := range commits {
+			if _, err := note.GetNote(sha); err != nil {
+				continue // No note to hold
+			}
+			if holdRelease {
+				err = note.ReleaseHold(sha, holdReason)
+			} else {
+				err = note.PlaceHold(sha, holdReason)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+				os.Exit(1)
+			}
+			changed++
+		}
+
+		switch {
+		case changed == 0:
+			fmt.Println("No prompt-story notes in range")
+		case holdRelease:
+			fmt.Printf("Released hold on %d note(s)\n", changed)
+		default:
+			fmt.Printf("Placed %d note(s) on legal hold\n", changed)
+		}
+	},
+}
+
+func init() {
+	holdCmd.Flags().StringVar(&holdReason, "reason", "", "Reason recorded in the audit trail")
+	holdCmd.Flags().BoolVar(&holdRelease, "release", false, "Release the hold instead of placing it")
+	rootCmd.AddCommand(holdCmd)
+}
@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/setup"
+	"github.com/spf13/cobra"
+)
+
+var initGlobalFlag bool
+
+var initCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Interactive first-time setup",
+	Long: `Walk through what git-prompt-story captures and configure it.
+
+Asks for the PII scrubbing level, which tools to capture sessions from,
+and whether notes should be pushed automatically, then writes the
+answers to git config (prompt-story.*) and installs the hooks.
+
+Use --global to configure all repositories at once.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := setup.Run(setup.Options{Global: initGlobalFlag}); err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	initCmd.Flags().BoolVar(&initGlobalFlag, "global", false, "Write global config and install hooks globally")
+	rootCmd.AddCommand(initCmd)
+}
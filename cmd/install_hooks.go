@@ -4,13 +4,15 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/QuesmaOrg/git-prompt-story/internal/config"
 	"github.com/QuesmaOrg/git-prompt-story/internal/hooks"
 	"github.com/spf13/cobra"
 )
 
 var (
-	globalFlag   bool
-	autoPushFlag bool
+	globalFlag    bool
+	autoPushFlag  bool
+	uninstallFlag bool
 )
 
 var installHooksCmd = &cobra.Command{
@@ -20,16 +22,44 @@ var installHooksCmd = &cobra.Command{
 
 By default, installs hooks in the current repository.
 Use --global to install hooks globally for all repositories.
-Use --auto-push to also install a pre-push hook that syncs notes.`,
+Use --auto-push to also install a pre-push hook that syncs notes.
+Hooks capture nothing until install-hooks or init was run, so hooks that
+come with a shared husky configuration stay inactive until then. Hooks
+installed by earlier versions keep capturing.
+
+Existing hooks are kept and run first. In a repository whose hooks are
+managed by husky or lefthook, git-prompt-story is added to the manager's
+configuration instead (.husky/<hook>, or lefthook-local.yml), since the
+manager would overwrite hooks written to the hooks directory.
+
+Use --uninstall to remove the hooks again, restoring the hooks they were
+chained to.
+
+Examples:
+  git-prompt-story install-hooks
+  git-prompt-story install-hooks --global --auto-push
+  git-prompt-story install-hooks --uninstall`,
 	Run: func(cmd *cobra.Command, args []string) {
 		opts := hooks.InstallOptions{
 			Global:   globalFlag,
 			AutoPush: autoPushFlag,
 		}
+		if uninstallFlag {
+			if err := hooks.UninstallHooks(opts); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
 		if err := hooks.InstallHooks(opts); err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
+		// Installing the hooks is the consent the hooks wait for, see init
+		if err := config.Set(config.KeyInitialized, "true", globalFlag); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
 
 		fmt.Println()
 		fmt.Println("📋 Next steps:")
@@ -42,5 +72,6 @@ Use --auto-push to also install a pre-push hook that syncs notes.`,
 func init() {
 	installHooksCmd.Flags().BoolVar(&globalFlag, "global", false, "Install hooks globally")
 	installHooksCmd.Flags().BoolVar(&autoPushFlag, "auto-push", false, "Install pre-push hook to auto-sync notes")
+	installHooksCmd.Flags().BoolVar(&uninstallFlag, "uninstall", false, "Remove the hooks and restore the ones they were chained to")
 	rootCmd.AddCommand(installHooksCmd)
 }
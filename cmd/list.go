@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/note"
+	"github.com/spf13/cobra"
+)
+
+var (
+	listAuthor   string
+	listMaxCount int
+	listSet      commitSetFlags
+)
+
+var listCmd = &cobra.Command{
+	Use:   "list [commit-range]",
+	Short: "List commits with captured sessions",
+	Long: `List commits that carry a prompt-story note, newest first, with the
+sessions recorded on each and who captured them.
+
+--author keeps only sessions whose git author or OS user contains the given
+text (case-insensitive), which separates people committing from a shared
+checkout.
+
+--in and --not compose ranges: commits of any --in spec (or the argument)
+that are not in a --not range, not reachable from refs matching a --not ref
+or glob, and not cherry-picked onto them. --stdin reads the specs one per
+line, ^spec for those to leave out.
+
+Examples:
+  git-prompt-story list                       # All noted commits reachable from HEAD
+  git-prompt-story list origin/main..HEAD
+  git-prompt-story list --author=jane -n 10
+  git-prompt-story list --in main..feature --not 'release/*'
+  printf 'v1.2.0..v1.3.0\n^hotfix\n' | git-prompt-story list --stdin`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		rangeSpec := "HEAD"
+		if len(args) > 0 {
+			rangeSpec = args[0]
+		}
+
+		// Filtering happens after listing, so only cap here without a filter
+		limit := listMaxCount
+		if listAuthor != "" {
+			limit = 0
+		}
+		var commits []note.ListedCommit
+		var err error
+		if listSet.used() {
+			var shas []string
+			shas, err = listSet.resolve(args, true, func() (string, error) { return "HEAD", nil })
+			if err == nil {
+				commits, err = note.ListCommitsOf(shas, limit)
+			}
+		} else {
+			commits, err = note.ListCommits(rangeSpec, limit)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+
+		shown := 0
+		for _, c := range commits {
+			sessions := c.SessionsByAuthor(listAuthor)
+			if len(sessions) == 0 {
+				continue
+			}
+
+			fmt.Printf("%s  %s  %s\n", c.SHA[:7], c.Date.Local().Format("2006-01-02"), c.Subject)
+			for _, s := range sessions {
+				owner := s.Owner()
+				if owner == "" {
+					owner = "unknown"
+				}
+				fmt.Printf("    %-12s %s  %s\n", note.FormatToolName(s.Tool), s.ID, owner)
+			}
+
+			shown++
+			if listMaxCount > 0 && shown >= listMaxCount {
+				break
+			}
+		}
+
+		if shown == 0 {
+			fmt.Println("No matching commits")
+		}
+	},
+}
+
+func init() {
+	listCmd.Flags().StringVar(&listAuthor, "author", "", "Only sessions captured by this author or OS user (substring match)")
+	listCmd.Flags().IntVarP(&listMaxCount, "max-count", "n", 0, "Limit the number of commits shown")
+	listSet.register(listCmd)
+	rootCmd.AddCommand(listCmd)
+}
@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/config"
+	"github.com/QuesmaOrg/git-prompt-story/internal/usage"
+	"github.com/spf13/cobra"
+)
+
+var (
+	metricsExportOutput string
+	metricsExportCSV    bool
+)
+
+var metricsCmd = &cobra.Command{
+	Use:   "metrics",
+	Short: "Opt-in usage metrics of git-prompt-story itself",
+	Long: `Count how often each git-prompt-story command (hooks included) runs and
+how long it takes. Collection is off until enabled; the counters stay in
+the user config directory (~/.config/git-prompt-story/metrics.json on
+Linux) and are never sent anywhere. Only command names, counts and
+durations are kept, no arguments, paths or identities.
+
+Teams piloting the tool can export the counters to measure their adoption.
+
+Examples:
+  git-prompt-story metrics enable
+  git-prompt-story metrics show
+  git-prompt-story metrics export --csv --output=metrics.csv`,
+}
+
+var metricsShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show the usage counters",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		m, err := usage.Load()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+		path, _ := usage.Path()
+		fmt.Printf("Collection: %s (%s)\n", onOff(config.GetBool(config.KeyUsageMetrics, false)), path)
+		if len(m.Commands) == 0 {
+			fmt.Println("No runs recorded")
+			return
+		}
+		fmt.Printf("Since:      %s\n", m.Since.Local().Format("2006-01-02"))
+		fmt.Printf("Active:     %d day(s), %d in the last 30\n\n", m.ActiveDays(m.Since), m.ActiveDays(time.Now().AddDate(0, 0, -30)))
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "COMMAND\tRUNS\tCOMPLETED\tAVG\tMAX\tLAST RUN")
+		for _, name := range m.CommandNames() {
+			s := m.Commands[name]
+			fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%s\t%s\n", name, s.Runs, s.Completed,
+				formatMillis(s.AverageDuration()), formatMillis(time.Duration(s.MaxMs)*time.Millisecond),
+				s.LastRun.Local().Format("2006-01-02 15:04"))
+		}
+		w.Flush()
+	},
+}
+
+var metricsExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Write the usage counters as JSON or CSV",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		m, err := usage.Load()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+
+		var out io.Writer = os.Stdout
+		if metricsExportOutput != "" {
+			f, err := os.Create(metricsExportOutput)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "git-prompt-story: failed to write output: %v\n", err)
+				os.Exit(1)
+			}
+			defer f.Close()
+			out = f
+		}
+
+		if metricsExportCSV {
+			err = m.WriteCSV(out)
+		} else {
+			encoder := json.NewEncoder(out)
+			encoder.SetIndent("", "  ")
+			err = encoder.Encode(m)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var metricsEnableCmd = &cobra.Command{
+	Use:   "enable",
+	Short: "Start collecting usage counters (for all repositories)",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		setUsageMetrics(true)
+	},
+}
+
+var metricsDisableCmd = &cobra.Command{
+	Use:   "disable",
+	Short: "Stop collecting usage counters, keeping those collected",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		setUsageMetrics(false)
+	},
+}
+
+var metricsResetCmd = &cobra.Command{
+	Use:   "reset",
+	Short: "Delete the collected usage counters",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := usage.Reset(); err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("Usage counters deleted")
+	},
+}
+
+func init() {
+	metricsExportCmd.Flags().StringVar(&metricsExportOutput, "output", "", "Write to file instead of stdout")
+	metricsExportCmd.Flags().BoolVar(&metricsExportCSV, "csv", false, "Write one CSV row per command instead of JSON")
+	metricsCmd.AddCommand(metricsShowCmd, metricsExportCmd, metricsEnableCmd, metricsDisableCmd, metricsResetCmd)
+	rootCmd.AddCommand(metricsCmd)
+}
+
+// setUsageMetrics turns collection on or off in the global git config
+func setUsageMetrics(enabled bool) {
+	if err := config.Set(config.KeyUsageMetrics, fmt.Sprint(enabled), true); err != nil {
+		fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+		os.Exit(1)
+	}
+	if enabled {
+		fmt.Println("Usage metrics enabled")
+	} else {
+		fmt.Println("Usage metrics disabled")
+	}
+}
+
+// formatMillis formats a duration rounded to milliseconds
+func formatMillis(d time.Duration) string {
+	return d.Round(time.Millisecond).String()
+}
+
+// usageStart is when the running command started, for its duration
+var usageStart time.Time
+
+// recordUsageStart counts the command run when usage metrics are enabled,
+// except the metrics commands themselves. Failures never affect the command.
+func recordUsageStart(cmd *cobra.Command) {
+	if cmd == metricsCmd || cmd.Parent() == metricsCmd || !config.GetBool(config.KeyUsageMetrics, false) {
+		return
+	}
+	usageStart = time.Now()
+	usage.RecordStart(usageCommandName(cmd), usageStart)
+}
+
+// recordUsageEnd records the duration of a command that completed. Commands
+// failing exit before, so they count as runs but not completions.
+func recordUsageEnd(cmd *cobra.Command) {
+	if usageStart.IsZero() {
+		return
+	}
+	usage.RecordEnd(usageCommandName(cmd), time.Since(usageStart))
+}
+
+// usageCommandName returns the command path without the binary name, e.g.
+// "pr summary"
+func usageCommandName(cmd *cobra.Command) string {
+	return strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" ")
+}
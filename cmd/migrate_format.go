@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/note"
+	"github.com/spf13/cobra"
+)
+
+var (
+	migrateFormatDryRun       bool
+	migrateFormatRemoveLegacy bool
+)
+
+var migrateFormatCmd = &cobra.Command{
+	Use:   "migrate-format",
+	Short: "Convert legacy notes to the current JSON format",
+	Long: `Convert notes written by early versions to the current format.
+
+Notes in the old YAML format under refs/notes/prompt-story are rewritten as
+JSON in place. Prompt-story notes attached under git's default
+refs/notes/commits are converted and moved to refs/notes/prompt-story,
+merged with any note already there. Other notes under refs/notes/commits
+are left alone.
+
+Examples:
+  git-prompt-story migrate-format --dry-run
+  git-prompt-story migrate-format --remove-legacy`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		conversions, err := note.MigrateLegacyNotes(migrateFormatDryRun, migrateFormatRemoveLegacy)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+
+		if len(conversions) == 0 {
+			fmt.Println("No legacy notes found")
+			return
+		}
+
+		for _, c := range conversions {
+			detail := ""
+			if c.Merged {
+				detail = " (merged with existing note)"
+			}
+			fmt.Printf("  %s from %s%s\n", c.SHA[:7], c.Source, detail)
+		}
+
+		verb := "Converted"
+		if migrateFormatDryRun {
+			verb = "Would convert"
+		}
+		fmt.Printf("%s %d note(s)\n", verb, len(conversions))
+		if !migrateFormatDryRun {
+			fmt.Println("Push with: git push -f origin refs/notes/prompt-story")
+		}
+	},
+}
+
+func init() {
+	migrateFormatCmd.Flags().BoolVar(&migrateFormatDryRun, "dry-run", false, "Report conversions without writing notes")
+	migrateFormatCmd.Flags().BoolVar(&migrateFormatRemoveLegacy, "remove-legacy", false, "Remove converted notes from refs/notes/commits")
+	rootCmd.AddCommand(migrateFormatCmd)
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/migrate"
+	"github.com/spf13/cobra"
+)
+
+var (
+	migrateRemoteFrom     string
+	migrateRemoteTo       string
+	migrateRemotePagesURL string
+	migrateRemoteForce    bool
+	migrateRemoteDryRun   bool
+)
+
+var migrateRemoteCmd = &cobra.Command{
+	Use:   "migrate-remote",
+	Short: "Copy prompt-story notes to a new remote",
+	Long: `Copy both prompt-story notes refs, with their history, from one remote to
+another, for teams moving their repository to a new host. Notes are copied
+as the old remote has them; add the new remote with git remote add first.
+
+Pages URLs in the repository's prompt-story.* git config (such as
+prompt-story.pagesUrl) that point at the old remote's GitHub Pages site are
+rewritten to the new one. For a new remote that is not on GitHub, give its
+Pages URL with --pages-url.
+
+Examples:
+  git remote add neworigin git@github.com:acme/app.git
+  git-prompt-story migrate-remote --from origin --to neworigin
+  git-prompt-story migrate-remote --to gitlab --pages-url https://acme.gitlab.io/app/ --dry-run`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if migrateRemoteTo == "" {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: --to is required\n")
+			os.Exit(1)
+		}
+
+		res, err := migrate.Remote(migrate.Options{
+			From:     migrateRemoteFrom,
+			To:       migrateRemoteTo,
+			PagesURL: migrateRemotePagesURL,
+			Force:    migrateRemoteForce,
+			DryRun:   migrateRemoteDryRun,
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+
+		verb, rewriteVerb := "Copied", "Rewrote"
+		if migrateRemoteDryRun {
+			verb, rewriteVerb = "Would copy", "Would rewrite"
+		}
+		for _, ref := range res.Refs {
+			fmt.Printf("%s %s from %s to %s\n", verb, ref, migrateRemoteFrom, migrateRemoteTo)
+		}
+		for _, r := range res.Rewrites {
+			fmt.Printf("%s %s: %s -> %s\n", rewriteVerb, r.Key, r.Old, r.New)
+		}
+		if res.OldPages != "" && res.NewPages == "" {
+			fmt.Printf("Pages URLs under %s were kept; pass --pages-url to rewrite them\n", res.OldPages)
+		}
+	},
+}
+
+func init() {
+	migrateRemoteCmd.Flags().StringVar(&migrateRemoteFrom, "from", "origin", "Remote to copy notes from")
+	migrateRemoteCmd.Flags().StringVar(&migrateRemoteTo, "to", "", "Remote to copy notes to (required)")
+	migrateRemoteCmd.Flags().StringVar(&migrateRemotePagesURL, "pages-url", "", "Pages base URL of the new remote (default: derived from a GitHub remote)")
+	migrateRemoteCmd.Flags().BoolVar(&migrateRemoteForce, "force", false, "Overwrite notes already on the new remote")
+	migrateRemoteCmd.Flags().BoolVar(&migrateRemoteDryRun, "dry-run", false, "Show what would be copied and rewritten")
+	rootCmd.AddCommand(migrateRemoteCmd)
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+	"github.com/QuesmaOrg/git-prompt-story/internal/note"
+	"github.com/spf13/cobra"
+)
+
+var (
+	noteEditPatch        string
+	noteEditSets         []string
+	noteEditDryRun       bool
+	noteEditOverrideHold bool
+)
+
+var noteCmd = &cobra.Command{
+	Use:   "note",
+	Short: "Work with prompt-story notes directly",
+}
+
+var noteEditCmd = &cobra.Command{
+	Use:   "edit <commit>",
+	Short: "Fix fields of a commit's prompt-story note",
+	Long: `Edit the prompt-story note of a commit, e.g. to fix a wrong start of work,
+without hand-crafting git notes commands.
+
+--patch applies a JSON merge patch (RFC 7396) from a file, or stdin with
+"-": its fields replace those of the note, objects are merged and null
+removes a field. --set assigns one field by its dotted path, list elements
+by index; the value is JSON if it parses as JSON, a string otherwise. The
+patch applies first, then each --set in order.
+
+The edited note must still be valid: misspelled fields, wrong types, a
+missing start_work, duplicate sessions and unnormalized tags are rejected,
+and the legal hold, its audit log, the redaction log and transcript seals
+cannot be edited. Notes on legal hold need --override-hold, which is
+recorded in the hold audit.
+
+Examples:
+  git-prompt-story note edit HEAD --set start_work=2025-01-15T09:30:00Z
+  git-prompt-story note edit abc123 --set 'sessions.0.author=Jane Doe <jane@example.com>'
+  git-prompt-story note edit abc123 --patch fix.json --dry-run
+  echo '{"tags": null}' | git-prompt-story note edit HEAD --patch -`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if noteEditPatch == "" && len(noteEditSets) == 0 {
+			fmt.Fprintln(os.Stderr, "git-prompt-story: --patch or --set is required")
+			os.Exit(1)
+		}
+		var patch []byte
+		var err error
+		switch noteEditPatch {
+		case "":
+		case "-":
+			patch, err = io.ReadAll(os.Stdin)
+		default:
+			patch, err = os.ReadFile(noteEditPatch)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+
+		sha, err := git.ResolveCommit(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+		edited, changed, err := note.EditNote(sha, patch, noteEditSets, noteEditDryRun, noteEditOverrideHold)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+
+		switch {
+		case !changed:
+			fmt.Printf("Note on %s unchanged\n", sha[:7])
+		case noteEditDryRun:
+			data, err := edited.ToJSON()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Println(string(data))
+		default:
+			fmt.Printf("Note on %s updated\n", sha[:7])
+			fmt.Println("Push with: git push origin refs/notes/prompt-story")
+		}
+	},
+}
+
+func init() {
+	noteEditCmd.Flags().StringVar(&noteEditPatch, "patch", "", "JSON merge patch file to apply (- for stdin)")
+	noteEditCmd.Flags().StringArrayVar(&noteEditSets, "set", nil, "Set a field, key=value with a dotted key such as sessions.0.author (repeatable)")
+	noteEditCmd.Flags().BoolVar(&noteEditDryRun, "dry-run", false, "Print the edited note instead of writing it")
+	noteEditCmd.Flags().BoolVar(&noteEditOverrideHold, "override-hold", false, "Edit the note even if it is on legal hold (recorded in the note)")
+	noteCmd.AddCommand(noteEditCmd)
+	rootCmd.AddCommand(noteCmd)
+}
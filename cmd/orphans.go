@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/display"
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+	"github.com/QuesmaOrg/git-prompt-story/internal/note"
+	"github.com/QuesmaOrg/git-prompt-story/internal/show"
+	"github.com/spf13/cobra"
+)
+
+var orphansCmd = &cobra.Command{
+	Use:   "orphans",
+	Short: "List transcripts no note references",
+	Long: `List the transcripts stored in refs/notes/prompt-story-transcripts that
+no prompt-story note references, e.g. after a note was removed with
+git notes remove. They are kept until re-linked with orphans attach or the
+transcripts ref is rewritten.
+
+Each orphan is shown with its path, time range and first prompt, enough to
+tell which commit it belongs to.
+
+Examples:
+  git-prompt-story orphans
+  git-prompt-story orphans attach claude-code/abc123.jsonl HEAD~2`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		orphans, err := note.FindOrphans()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+		if len(orphans) == 0 {
+			fmt.Println("No orphaned transcripts")
+			return
+		}
+
+		for _, o := range orphans {
+			fmt.Printf("%s  (%d entries, %d bytes)\n", o.Path, o.Entries, o.Size)
+			if !o.Start.IsZero() {
+				fmt.Printf("  %s - %s\n", o.Start.Local().Format("2006-01-02 15:04"), o.End.Local().Format("2006-01-02 15:04"))
+			}
+			if o.FirstPrompt != "" {
+				fmt.Printf("  %s\n", display.TruncateText(o.FirstPrompt, 72))
+			}
+		}
+		fmt.Printf("%d orphaned transcript(s); re-link with: git-prompt-story orphans attach <path> <commit>\n", len(orphans))
+	},
+}
+
+var orphansAttachCmd = &cobra.Command{
+	Use:   "attach <path> <commit>",
+	Short: "Re-link a transcript to a commit's note",
+	Long: `Add a session for a stored transcript to the note of a commit, creating
+the note if the commit has none. Use it to restore a note that was removed
+by accident; the transcript itself is not copied or changed.
+
+Examples:
+  git-prompt-story orphans attach claude-code/abc123.jsonl HEAD~2`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		sha, err := git.ResolveCommit(args[1])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+		if err := note.AttachOrphan(args[0], sha); err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Attached %s to %s\n", args[0], sha[:7])
+
+		if show.WasNotesPushed() {
+			fmt.Println("Push the updated note with: git push origin refs/notes/prompt-story")
+		}
+	},
+}
+
+func init() {
+	orphansCmd.AddCommand(orphansAttachCmd)
+	rootCmd.AddCommand(orphansCmd)
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOrphansCommand(t *testing.T) {
+	run := initTestRepo(t)
+	sha := commitWithNote(t, "Add login form", "Build the login form", time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC))
+
+	stdout, stderr, code := runCommand(t, "orphans")
+	if code != 0 || !strings.Contains(stdout, "No orphaned transcripts") {
+		t.Fatalf("orphans with every transcript referenced = exit %d, %q %q", code, stdout, stderr)
+	}
+
+	run("notes", "--ref=refs/notes/prompt-story", "remove", sha)
+	stdout, stderr, code = runCommand(t, "orphans")
+	path := "claude-code/s-" + sha[:7] + ".jsonl"
+	if code != 0 || !strings.Contains(stdout, path) || !strings.Contains(stdout, "Build the login form") || !strings.Contains(stdout, "1 orphaned transcript(s)") {
+		t.Fatalf("orphans after removing the note = exit %d, %q %q", code, stdout, stderr)
+	}
+
+	if _, stderr, code = runCommand(t, "orphans", "attach", path, "no-such-commit"); code != 1 || stderr == "" {
+		t.Errorf("attach to an unknown commit = exit %d, stderr %q; want exit 1 with an error", code, stderr)
+	}
+	if _, stderr, code = runCommand(t, "orphans", "attach", "claude-code/nope.jsonl", "HEAD"); code != 1 || !strings.Contains(stderr, "no transcript claude-code/nope.jsonl") {
+		t.Errorf("attach of an unknown transcript = exit %d, stderr %q", code, stderr)
+	}
+
+	stdout, stderr, code = runCommand(t, "orphans", "attach", path, "HEAD")
+	if code != 0 || !strings.Contains(stdout, "Attached "+path+" to "+sha[:7]) {
+		t.Fatalf("attach = exit %d, %q %q", code, stdout, stderr)
+	}
+	if stdout, _, _ = runCommand(t, "orphans"); !strings.Contains(stdout, "No orphaned transcripts") {
+		t.Errorf("orphans after attach:\n%s", stdout)
+	}
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/pause"
+	"github.com/QuesmaOrg/git-prompt-story/internal/policy"
+	"github.com/spf13/cobra"
+)
+
+var pauseFor string
+
+var pauseCmd = &cobra.Command{
+	Use:   "pause",
+	Short: "Stop capturing sessions until resumed",
+	Long: `Stop capturing sessions in this repository, until resume or for a while.
+Commits made while paused get a "Prompt-Story: paused" line and no note or
+transcripts. Prompts from the paused period are not picked up by the first
+commit after resuming, although a session that continues after resuming is
+still stored whole; use [off-the-record] prompts for finer control.
+
+Durations take s, m, h, d (days) or w (weeks).
+
+Examples:
+  git-prompt-story pause
+  git-prompt-story pause --for 2h
+  git-prompt-story resume`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		var d time.Duration
+		if pauseFor != "" {
+			var err error
+			if d, err = policy.ParseAge(pauseFor); err != nil {
+				fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+				os.Exit(1)
+			}
+		}
+
+		state, err := pause.Pause(d)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Capture %s\n", state)
+	},
+}
+
+var resumeCmd = &cobra.Command{
+	Use:   "resume",
+	Short: "Resume capturing sessions after pause",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		resumed, err := pause.Resume()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+		if !resumed {
+			fmt.Println("Capture was not paused")
+			return
+		}
+		fmt.Println("Capture resumed")
+	},
+}
+
+func init() {
+	pauseCmd.Flags().StringVar(&pauseFor, "for", "", "Resume automatically after this long (e.g. 2h, 1d)")
+	rootCmd.AddCommand(pauseCmd)
+	rootCmd.AddCommand(resumeCmd)
+}
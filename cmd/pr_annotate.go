@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/ci"
+	"github.com/QuesmaOrg/git-prompt-story/internal/config"
+	"github.com/QuesmaOrg/git-prompt-story/internal/github"
+	"github.com/spf13/cobra"
+)
+
+var (
+	prAnnotatePR          int
+	prAnnotateRepo        string
+	prAnnotatePost        bool
+	prAnnotateMaxComments int
+	prAnnotateDelay       time.Duration
+	prAnnotatePagesURL    string
+)
+
+var prAnnotateCmd = &cobra.Command{
+	Use:   "annotate <commit-range>",
+	Short: "Comment on AI-edited lines of a PR with their prompts",
+	Long: `Find the file regions changed by Edit/Write tool calls in the captured
+sessions and map them onto the lines the pull request adds. Each region
+gets a review comment quoting the prompt that produced it.
+
+Nothing is posted unless --post is given; without it the planned comments
+are printed. Posting is capped by --max-comments and spaced by --delay to
+stay clear of GitHub's secondary rate limits; requests that still hit a
+limit wait for Retry-After and are retried. Each comment carries a hidden
+marker, so re-running skips the regions already commented on. Requires
+GITHUB_TOKEN or GH_TOKEN.
+
+Examples:
+  git-prompt-story pr annotate origin/main..HEAD --pr=42
+  git-prompt-story pr annotate origin/main..HEAD --pr=42 --post
+  git-prompt-story pr annotate origin/main..HEAD --pr=42 --post --max-comments=5`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if prAnnotatePR <= 0 {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: --pr is required\n")
+			os.Exit(1)
+		}
+
+		if prAnnotatePagesURL == "" {
+			prAnnotatePagesURL = config.Get(config.KeyPagesURL)
+		}
+
+		client, err := github.NewClient(prAnnotateRepo)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+
+		pr, err := client.GetPullRequest(prAnnotatePR)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: failed to fetch PR: %v\n", err)
+			os.Exit(1)
+		}
+
+		summary, err := ci.GenerateSummary(args[0], true)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+
+		annotations, err := ci.BuildAnnotations(summary, pr.Base.SHA, pr.Head.SHA)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+
+		existing, err := client.ListReviewComments(prAnnotatePR)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: failed to fetch review comments: %v\n", err)
+			os.Exit(1)
+		}
+		bodies := make([]string, len(existing))
+		for i, c := range existing {
+			bodies[i] = c.Body
+		}
+		if pending := ci.SkipPosted(annotations, bodies); len(pending) < len(annotations) {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: skipping %d region(s) already commented on\n", len(annotations)-len(pending))
+			annotations = pending
+		}
+
+		if len(annotations) > prAnnotateMaxComments {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %d regions found, commenting on the first %d\n", len(annotations), prAnnotateMaxComments)
+			annotations = annotations[:prAnnotateMaxComments]
+		}
+
+		if !prAnnotatePost {
+			for _, a := range annotations {
+				fmt.Printf("%s:%d\n%s\n", a.Path, a.Line, ci.RenderAnnotation(a, prAnnotatePagesURL))
+			}
+			fmt.Printf("%d comment(s) planned for %s#%d. Re-run with --post to publish.\n", len(annotations), client.Repo(), prAnnotatePR)
+			return
+		}
+
+		client.SetWriteInterval(prAnnotateDelay)
+		for _, a := range annotations {
+			err := client.CreateReviewComment(prAnnotatePR, github.ReviewComment{
+				Body:     ci.RenderAnnotation(a, prAnnotatePagesURL),
+				CommitID: pr.Head.SHA,
+				Path:     a.Path,
+				Line:     a.Line,
+			})
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "git-prompt-story: failed to comment on %s:%d: %v\n", a.Path, a.Line, err)
+				os.Exit(1)
+			}
+		}
+		fmt.Printf("Posted %d comment(s) on %s#%d\n", len(annotations), client.Repo(), prAnnotatePR)
+	},
+}
+
+func init() {
+	prAnnotateCmd.Flags().IntVar(&prAnnotatePR, "pr", 0, "Pull request number to annotate")
+	prAnnotateCmd.Flags().StringVar(&prAnnotateRepo, "repo", "", "GitHub repository (owner/name), detected from origin by default")
+	prAnnotateCmd.Flags().BoolVar(&prAnnotatePost, "post", false, "Post the comments (default is to print them)")
+	prAnnotateCmd.Flags().IntVar(&prAnnotateMaxComments, "max-comments", 20, "Maximum number of comments to post")
+	prAnnotateCmd.Flags().DurationVar(&prAnnotateDelay, "delay", 2*time.Second, "Pause between posted comments")
+	prAnnotateCmd.Flags().StringVar(&prAnnotatePagesURL, "pages-url", "", "URL to GitHub Pages transcripts, linked from each comment (default: git config "+config.KeyPagesURL+")")
+	prCmd.AddCommand(prAnnotateCmd)
+}
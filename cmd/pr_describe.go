@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/ci"
+	"github.com/QuesmaOrg/git-prompt-story/internal/github"
+	"github.com/spf13/cobra"
+)
+
+var (
+	prDescribeOutput string
+	prDescribeUpdate int
+	prDescribeRepo   string
+)
+
+var prDescribeCmd = &cobra.Command{
+	Use:   "describe <commit-range>",
+	Short: "Draft a PR description from the captured story",
+	Long: `Generate a markdown PR description draft listing the commit subjects
+together with the key prompts and answered decisions from their sessions.
+
+With --update-pr the draft replaces the description of an existing pull
+request via the GitHub API (requires GITHUB_TOKEN or GH_TOKEN).
+
+Examples:
+  git-prompt-story pr describe origin/main..HEAD
+  git-prompt-story pr describe origin/main..HEAD --output=body.md
+  git-prompt-story pr describe origin/main..HEAD --update-pr=42`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		body, err := ci.GenerateDescription(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+
+		if prDescribeUpdate > 0 {
+			client, err := github.NewClient(prDescribeRepo)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+				os.Exit(1)
+			}
+			if err := client.UpdatePullRequestBody(prDescribeUpdate, body); err != nil {
+				fmt.Fprintf(os.Stderr, "git-prompt-story: failed to update PR: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("Updated description of %s#%d\n", client.Repo(), prDescribeUpdate)
+			return
+		}
+
+		if prDescribeOutput != "" {
+			if err := os.WriteFile(prDescribeOutput, []byte(body), 0644); err != nil {
+				fmt.Fprintf(os.Stderr, "git-prompt-story: failed to write output: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+		fmt.Print(body)
+	},
+}
+
+func init() {
+	prDescribeCmd.Flags().StringVar(&prDescribeOutput, "output", "", "Write markdown to file instead of stdout")
+	prDescribeCmd.Flags().IntVar(&prDescribeUpdate, "update-pr", 0, "Replace the description of this PR number via the GitHub API")
+	prDescribeCmd.Flags().StringVar(&prDescribeRepo, "repo", "", "GitHub repository (owner/name), detected from origin by default")
+	prCmd.AddCommand(prDescribeCmd)
+}
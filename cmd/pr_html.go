@@ -11,6 +11,8 @@ import (
 var (
 	prHTMLOutputDir string
 	prHTMLPRNumber  int
+	prHTMLSubmods   bool
+	prHTMLPageSize  int
 )
 
 var prHTMLCmd = &cobra.Command{
@@ -21,6 +23,16 @@ var prHTMLCmd = &cobra.Command{
 This command creates an index.html and individual commit pages suitable for
 deployment to GitHub Pages.
 
+Sessions with more than --page-size steps show their first page inline and
+load the rest as the reader scrolls, so very long sessions stay responsive.
+Every step has a permalink (#step-<id>) that loads its page when followed.
+Use --page-size=0 to render all steps inline.
+
+Step IDs hash the session ID, the step's timestamp and its position among
+steps with that timestamp, so they stay the same across re-renders and
+versions. The same IDs link steps from pr summary (with --pages-url) and
+are shown, and copied with y, in the show TUI.
+
 Examples:
   git-prompt-story pr html HEAD~5..HEAD --output-dir=./pages
   git-prompt-story pr html main..feature --output-dir=./pr-42 --pr=42`,
@@ -35,12 +47,15 @@ Examples:
 
 		// Generate with full prompts for HTML
 		summary, err := ci.GenerateSummary(commitRange, true)
+		if err == nil && prHTMLSubmods {
+			err = ci.AddSubmoduleSummaries(summary, commitRange, true)
+		}
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
 			os.Exit(1)
 		}
 
-		if err := ci.GenerateHTML(summary, prHTMLOutputDir, prHTMLPRNumber); err != nil {
+		if err := ci.GenerateHTML(summary, prHTMLOutputDir, prHTMLPRNumber, prHTMLPageSize); err != nil {
 			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
 			os.Exit(1)
 		}
@@ -56,5 +71,7 @@ Examples:
 func init() {
 	prHTMLCmd.Flags().StringVar(&prHTMLOutputDir, "output-dir", "", "Directory to write HTML files (required)")
 	prHTMLCmd.Flags().IntVar(&prHTMLPRNumber, "pr", 0, "PR number for page title")
+	prHTMLCmd.Flags().BoolVar(&prHTMLSubmods, "submodules", false, "Include notes of submodule commits pulled in by the range")
+	prHTMLCmd.Flags().IntVar(&prHTMLPageSize, "page-size", ci.DefaultPageSize, "Steps per page of long sessions (0 for no paging)")
 	prCmd.AddCommand(prHTMLCmd)
 }
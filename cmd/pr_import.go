@@ -0,0 +1,194 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+	"github.com/QuesmaOrg/git-prompt-story/internal/github"
+	"github.com/QuesmaOrg/git-prompt-story/internal/note"
+	"github.com/QuesmaOrg/git-prompt-story/internal/policy"
+	"github.com/QuesmaOrg/git-prompt-story/internal/pragent"
+	"github.com/spf13/cobra"
+)
+
+var (
+	prImportRepo    string
+	prImportBots    []string
+	prImportNoScrub bool
+	prImportDryRun  bool
+)
+
+var prImportCmd = &cobra.Command{
+	Use:   "import <pr-number>",
+	Short: "Add notes to commits that coding agents pushed to a PR",
+	Long: `Synthesize prompt-story notes for the commits of a pull request authored
+by bot accounts, such as the Copilot coding agent, Copilot Workspace or other
+agents working on GitHub, so fully automated commits appear in the PR story
+instead of as gaps.
+
+The agents' transcripts are not available, so the pull request's
+conversation read from the GitHub API stands in for them: the description,
+comments and reviews of people become prompts, while bot comments and the
+agent's commits become responses. Each agent commit gets the session with
+its work starting at the previous commit of the pull request, keeping
+sessions already in its note. The commits must be fetched locally.
+
+Requires GITHUB_TOKEN or GH_TOKEN.
+
+Examples:
+  git-prompt-story pr import 42
+  git-prompt-story pr import 42 --bot=Copilot --dry-run
+  git-prompt-story pr import 42 --repo=QuesmaOrg/git-prompt-story`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var number int
+		if _, err := fmt.Sscanf(args[0], "%d", &number); err != nil || number <= 0 {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: invalid PR number %q\n", args[0])
+			os.Exit(1)
+		}
+
+		pol, err := loadPolicy()
+		if err == nil {
+			err = pol.CheckTool(pragent.Tool)
+		}
+		if err == nil && prImportNoScrub {
+			err = pol.CheckNoScrub()
+		}
+		if err == nil {
+			err = importPullRequestAgents(number, pol)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	prImportCmd.Flags().StringVar(&prImportRepo, "repo", "", "GitHub repository (owner/name), detected from origin by default")
+	prImportCmd.Flags().StringSliceVar(&prImportBots, "bot", nil, "Only import commits of these bot logins (default: any bot account)")
+	prImportCmd.Flags().BoolVar(&prImportNoScrub, "no-scrub", false, "Disable PII scrubbing")
+	prImportCmd.Flags().BoolVar(&prImportDryRun, "dry-run", false, "List the agent commits without adding notes")
+	prCmd.AddCommand(prImportCmd)
+}
+
+// importPullRequestAgents adds the synthesized session of a pull request to
+// the notes of its agent commits
+func importPullRequestAgents(number int, pol *policy.Policy) error {
+	client, err := github.NewClient(prImportRepo)
+	if err != nil {
+		return err
+	}
+	pr, err := client.GetPullRequest(number)
+	if err != nil {
+		return fmt.Errorf("failed to get PR: %w", err)
+	}
+	commits, err := client.ListPullRequestCommits(number)
+	if err != nil {
+		return fmt.Errorf("failed to list PR commits: %w", err)
+	}
+
+	agentCommits := make(map[string]bool)
+	var local []github.PullRequestCommit
+	for _, c := range commits {
+		if !pragent.IsAgentCommit(c, prImportBots) {
+			continue
+		}
+		if _, err := git.ResolveCommit(c.SHA); err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: skipping %s, not fetched (git fetch origin pull/%d/head)\n", c.SHA[:7], number)
+			continue
+		}
+		agentCommits[c.SHA] = true
+		local = append(local, c)
+	}
+	if len(local) == 0 {
+		fmt.Printf("No agent commits in %s#%d\n", client.Repo(), number)
+		return nil
+	}
+	if prImportDryRun {
+		for _, c := range local {
+			login := c.Commit.Author.Name
+			if c.Author != nil {
+				login = c.Author.Login
+			}
+			fmt.Printf("%s %s (%s)\n", c.SHA[:7], commitSubject(c.Commit.Message), login)
+		}
+		return nil
+	}
+
+	events, err := client.ListTimeline(number)
+	if err != nil {
+		return fmt.Errorf("failed to get PR timeline: %w", err)
+	}
+	jsonl, err := pragent.TimelineToJSONL(pr, events, agentCommits)
+	if err != nil {
+		return fmt.Errorf("failed to convert timeline: %w", err)
+	}
+
+	// Scrub PII from transcript (unless --no-scrub)
+	if !prImportNoScrub {
+		piiScrubber, err := pol.Scrubber()
+		if err != nil {
+			return err
+		}
+		jsonl, err = piiScrubber.ForTool(pragent.Tool).Scrub(jsonl)
+		if err != nil {
+			return fmt.Errorf("failed to scrub PII: %w", err)
+		}
+	}
+
+	blobSHA, err := git.HashObject(jsonl)
+	if err != nil {
+		return fmt.Errorf("failed to store transcript: %w", err)
+	}
+	id := pragent.SessionID(number)
+	transcriptPath := note.GetTranscriptPath(pragent.Tool, id)
+	blobs := map[string]string{transcriptPath: blobSHA}
+	if err := note.UpdateTranscriptTree(blobs); err != nil {
+		return fmt.Errorf("failed to update transcript tree: %w", err)
+	}
+
+	starts := pragent.WorkStarts(pr, commits, agentCommits)
+	for _, c := range local {
+		a := c.Commit.Author
+		psNote := &note.PromptStoryNote{
+			Version:   1,
+			CreatedBy: note.CLIVersion,
+			StartWork: starts[c.SHA],
+			Sessions: []note.SessionEntry{{
+				Tool:     pragent.Tool,
+				ID:       id,
+				Path:     transcriptPath,
+				Created:  pr.CreatedAt,
+				Modified: c.Commit.Committer.Date,
+				Author:   fmt.Sprintf("%s <%s>", a.Name, a.Email),
+			}},
+		}
+		if err := psNote.SealTranscripts(blobs); err != nil {
+			return fmt.Errorf("failed to seal transcript: %w", err)
+		}
+		if existing, err := note.GetNote(c.SHA); err == nil && existing != "" {
+			if parsed, err := note.ParseNote([]byte(existing)); err == nil {
+				psNote = note.MergeNotes([]*note.PromptStoryNote{parsed, psNote})
+			}
+		}
+
+		noteJSON, err := psNote.ToJSON()
+		if err != nil {
+			return fmt.Errorf("failed to serialize note: %w", err)
+		}
+		if err := git.AddNote(note.NotesRef, string(noteJSON), c.SHA); err != nil {
+			return fmt.Errorf("failed to attach note to %s: %w", c.SHA[:7], err)
+		}
+		fmt.Printf("Added %s#%d to commit %s %s\n", client.Repo(), number, c.SHA[:7], commitSubject(c.Commit.Message))
+	}
+	return nil
+}
+
+// commitSubject returns the first line of a commit message
+func commitSubject(message string) string {
+	subject, _, _ := strings.Cut(message, "\n")
+	return subject
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/ci"
+	"github.com/spf13/cobra"
+)
+
+var (
+	prPagesOut      string
+	prPagesBase     string
+	prPagesPRNumber int
+	prPagesSubmods  bool
+	prPagesPageSize int
+)
+
+var prPagesCmd = &cobra.Command{
+	Use:   "pages [commit-range]",
+	Short: "Generate a static transcript site",
+	Long: `Generate a navigable static HTML site of the transcripts in a range,
+suitable for publishing to GitHub Pages (the "View full transcripts" link of
+PR summaries with --pages-url).
+
+The site has an index listing every commit and session with a search box
+over all steps, a page per commit as generated by pr html, and a page per
+session whose steps collapse to one line. Step permalinks (#step-<id>)
+work on commit and session pages.
+
+Without a range, the current branch since its merge base with --base, or
+the branch a PR from it targets, is used as for pr summary.
+
+Examples:
+  git-prompt-story pr pages --out=./site
+  git-prompt-story pr pages main..feature --out=./pr-42 --pr=42`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if prPagesOut == "" {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: --out is required\n")
+			os.Exit(1)
+		}
+		var commitRange string
+		var err error
+		if len(args) > 0 {
+			commitRange = args[0]
+		} else if commitRange, err = prRange(prPagesBase); err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+
+		// Generate with full prompts for HTML
+		summary, err := ci.GenerateSummary(commitRange, true)
+		if err == nil && prPagesSubmods {
+			err = ci.AddSubmoduleSummaries(summary, commitRange, true)
+		}
+		if err == nil {
+			err = ci.GenerateSite(summary, prPagesOut, prPagesPRNumber, prPagesPageSize)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+
+		sessions := 0
+		for _, commit := range summary.Commits {
+			sessions += len(commit.Sessions)
+		}
+		fmt.Printf("Generated site in %s (%d commit(s), %d session(s))\n", prPagesOut, len(summary.Commits), sessions)
+	},
+}
+
+func init() {
+	prPagesCmd.Flags().StringVar(&prPagesOut, "out", "", "Directory to write the site to (required)")
+	prPagesCmd.Flags().StringVar(&prPagesBase, "base", "", "Branch the range starts from when none is given (default: the PR's target)")
+	prPagesCmd.Flags().IntVar(&prPagesPRNumber, "pr", 0, "PR number for page titles")
+	prPagesCmd.Flags().BoolVar(&prPagesSubmods, "submodules", false, "Include notes of submodule commits pulled in by the range")
+	prPagesCmd.Flags().IntVar(&prPagesPageSize, "page-size", ci.DefaultPageSize, "Steps per page of long sessions on commit pages (0 for no paging)")
+	prCmd.AddCommand(prPagesCmd)
+}
@@ -3,8 +3,14 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/QuesmaOrg/git-prompt-story/internal/ci"
+	"github.com/QuesmaOrg/git-prompt-story/internal/config"
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+	"github.com/QuesmaOrg/git-prompt-story/internal/github"
+	"github.com/QuesmaOrg/git-prompt-story/internal/policy"
+	"github.com/QuesmaOrg/git-prompt-story/internal/scrubber"
 	"github.com/spf13/cobra"
 )
 
@@ -13,33 +19,168 @@ var (
 	prSummaryPagesURL string
 	prSummaryOutput   string
 	prSummaryGHA      bool
+	prSummaryVerbose  bool
+	prSummarySubmods  bool
+	prSummaryEstimate bool
+	prSummaryLabelPR  int
+	prSummaryFillPR   int
+	prSummaryRepo     string
+	prSummaryRenderer string
+	prSummaryBase     string
+	prSummaryStrict   bool
+	prSummarySample   int
+	prSummaryJobs     int
+	prSummaryView     string
 )
 
 var prSummaryCmd = &cobra.Command{
-	Use:   "summary <commit-range>",
+	Use:   "summary [commit-range]",
 	Short: "Generate summary for commits",
 	Long: `Generate a summary of LLM sessions for commits in a range.
 
 This command is designed for CI/CD pipelines to create PR comments or reports.
 
+Without a range, the current branch is summarized since its merge base with
+the branch a PR would target: the PR's base branch in GitHub Actions, else
+the upstream branch when it is another branch (e.g. a branch created from
+origin/develop), else the default branch. --base overrides the detection.
+
 Examples:
+  git-prompt-story pr summary
+  git-prompt-story pr summary --base=origin/release-2.0
   git-prompt-story pr summary HEAD~5..HEAD
   git-prompt-story pr summary main..feature-branch --pages-url=https://example.github.io/repo/pr-42/
-  git-prompt-story pr summary origin/main..HEAD --gha --output=summary.md`,
-	Args: cobra.ExactArgs(1),
+  git-prompt-story pr summary origin/main..HEAD --gha --output=summary.md
+  git-prompt-story pr summary origin/main..HEAD --estimate
+
+With --verbose, commits and sessions left out of the counts (no note, an
+unparseable note, a missing transcript, nothing in the work period) are
+listed on stderr with the reason.
+
+With --submodules, commits that move a submodule pointer also contribute the
+notes of the submodule commits they pull in. Submodules must be checked out;
+their notes are fetched from the submodule's origin when missing.
+
+With --estimate, nothing is rendered; instead the size of the markdown, what
+its size budgets would truncate, and a per-commit breakdown are printed, to
+help decide whether to link full transcripts with --pages-url or split the PR.
+
+Notes tagged with "annotate --tag" are rolled up into a Tags line. With
+--label-pr, tags that match a label already defined in the repository
+(case-insensitively) are also added to that PR as labels; labels are never
+created.
+
+  git-prompt-story pr summary origin/main..HEAD --label-pr=42
+
+With --fill-pr, the summary replaces the section installed by "pr template"
+in that PR's description. In --gha mode, pr-body-filled=true reports that
+the description was filled, so no comment is needed.
+
+  git-prompt-story pr summary origin/main..HEAD --fill-pr=42
+
+With --renderer, the summary is written as Jira wiki markup or an HTML
+fragment instead of GitHub-flavored markdown, for posting to other
+ticketing systems. --fill-pr and --estimate always use markdown.
+
+  git-prompt-story pr summary origin/main..HEAD --renderer=jira --output=summary.txt
+
+With --sample-budget, PRs with many prompts are sampled: at most that many
+prompts per commit are shown, spread evenly over the commit's work, with the
+steps that followed them. Decisions and rejections are always kept, and a
+banner says the summary is sampled. The sample is the same on every run.
+
+  git-prompt-story pr summary origin/main..HEAD --sample-budget=20
+
+With --strict, the summary is still written but the command exits non-zero
+when a commit has a Prompt-Story trailer but no readable note, a transcript
+cannot be read, a transcript is larger than max_transcript_size in
+.prompt-story-policy.yaml (default 50MB), a commit breaks the policy (see
+verify), or a stored transcript still contains text the scrubber would
+replace. The problems are listed on stderr.
+
+  git-prompt-story pr summary origin/main..HEAD --strict --gha --output=summary.md
+
+Commits are analyzed in parallel, one per CPU by default; --jobs sets how
+many at a time. The summary is the same whatever the number of jobs.
+
+With --view, the flags saved under that name in git config apply, so a
+team's usual combination is one word. A view may also hold flags of show,
+each command takes those it has; flags on the command line win:
+
+  git config prompt-story.view.review "--sample-budget=20 --renderer=jira --full"
+  git-prompt-story pr summary origin/main..HEAD --view review`,
+	Args: cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		commitRange := args[0]
+		if err := applyView(cmd, prSummaryView); err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+		renderer, err := ci.RendererByName(prSummaryRenderer)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+		var commitRange string
+		if len(args) > 0 {
+			commitRange = args[0]
+		} else if commitRange, err = prRange(prSummaryBase); err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+		if prSummaryPagesURL == "" {
+			prSummaryPagesURL = config.Get(config.KeyPagesURL)
+		}
 
-		summary, err := ci.GenerateSummary(commitRange, prSummaryFull)
+		summary, err := ci.GenerateSummaryJobs(commitRange, prSummaryFull, prSummaryJobs)
+		if err == nil && prSummarySubmods {
+			err = ci.AddSubmoduleSummaries(summary, commitRange, prSummaryFull)
+		}
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
 			os.Exit(1)
 		}
+		if prSummaryVerbose {
+			ci.RenderDiagnostics(summary, os.Stderr)
+		}
+		ci.SampleSummary(summary, prSummarySample)
+		if prSummaryStrict {
+			problems, err := strictProblems(commitRange, summary)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+				os.Exit(1)
+			}
+			if len(problems) > 0 {
+				fmt.Fprintf(os.Stderr, "git-prompt-story: --strict: %d problem(s):\n", len(problems))
+				for _, p := range problems {
+					fmt.Fprintf(os.Stderr, "  %s %s: %s\n", p.SHA[:7], p.Subject, p.Reason)
+				}
+				// Write the summary first, so CI can still post it
+				defer os.Exit(1)
+			}
+		}
+		if prSummaryEstimate {
+			ci.RenderEstimate(ci.EstimateMarkdown(summary, prSummaryPagesURL, GetVersion()), os.Stdout)
+			return
+		}
+		if prSummaryLabelPR > 0 {
+			if err := applyTagLabels(summary); err != nil {
+				fmt.Fprintf(os.Stderr, "git-prompt-story: failed to label PR: %v\n", err)
+				os.Exit(1)
+			}
+		}
 
 		if prSummaryGHA {
 			// GitHub Actions mode: output metadata to stdout
 			shouldPost := summary.CommitsWithNotes > 0
 			notesMissing := summary.CommitsMissingNotes > 0
+			if prSummaryFillPR > 0 && shouldPost {
+				filled, err := fillPRBody(ci.RenderMarkdown(summary, prSummaryPagesURL, GetVersion()))
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "git-prompt-story: failed to fill PR description: %v\n", err)
+					os.Exit(1)
+				}
+				fmt.Printf("pr-body-filled=%t\n", filled)
+			}
 			fmt.Printf("commits-analyzed=%d\n", summary.CommitsAnalyzed)
 			fmt.Printf("commits-with-notes=%d\n", summary.CommitsWithNotes)
 			fmt.Printf("commits-missing-notes=%d\n", summary.CommitsMissingNotes)
@@ -50,9 +191,9 @@ Examples:
 			if prSummaryOutput != "" {
 				var markdown string
 				if shouldPost {
-					markdown = ci.RenderMarkdown(summary, prSummaryPagesURL, GetVersion())
+					markdown = ci.Render(renderer, summary, prSummaryPagesURL, GetVersion())
 				} else if notesMissing {
-					markdown = ci.RenderMissingNotesWarning(summary.CommitsMissingNotes, GetVersion())
+					markdown = ci.RenderMissingNotesWarning(renderer, summary.CommitsMissingNotes, GetVersion())
 				}
 				if markdown != "" {
 					if err := os.WriteFile(prSummaryOutput, []byte(markdown), 0644); err != nil {
@@ -64,9 +205,16 @@ Examples:
 			return
 		}
 
-		// Normal mode: output markdown
-		output := ci.RenderMarkdown(summary, prSummaryPagesURL, GetVersion())
+		// Normal mode: output markdown, or the chosen renderer's markup
+		if prSummaryFillPR > 0 {
+			if _, err := fillPRBody(ci.RenderMarkdown(summary, prSummaryPagesURL, GetVersion())); err != nil {
+				fmt.Fprintf(os.Stderr, "git-prompt-story: failed to fill PR description: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
 
+		output := ci.Render(renderer, summary, prSummaryPagesURL, GetVersion())
 		if prSummaryOutput != "" {
 			if err := os.WriteFile(prSummaryOutput, []byte(output), 0644); err != nil {
 				fmt.Fprintf(os.Stderr, "git-prompt-story: failed to write output: %v\n", err)
@@ -78,10 +226,147 @@ Examples:
 	},
 }
 
+// prRange returns the commits of the current branch since its merge base
+// with base, or by default the branch a PR from it targets, noting the
+// choice on stderr
+func prRange(base string) (string, error) {
+	if base == "" {
+		var err error
+		if base, err = prBaseBranch(); err != nil {
+			return "", err
+		}
+	}
+	commitRange, err := mergeBaseRange("HEAD", base)
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprintf(os.Stderr, "Summarizing HEAD since its merge base with %s\n", base)
+	return commitRange, nil
+}
+
+// prBaseBranch guesses the branch a PR from the current branch targets: the
+// PR's base in GitHub Actions, else the upstream when it is not the branch's
+// own remote copy, else the default branch
+func prBaseBranch() (string, error) {
+	if ref := os.Getenv("GITHUB_BASE_REF"); ref != "" {
+		return "origin/" + ref, nil
+	}
+	if branch, err := git.GetCurrentBranch(); err == nil && branch != "HEAD" {
+		upstream := git.Upstream(branch)
+		if upstream != "" && upstream != branch && !strings.HasSuffix(upstream, "/"+branch) {
+			return upstream, nil
+		}
+	}
+	return git.DefaultBranch()
+}
+
+// strictProblems returns what pr summary --strict fails on: unreadable
+// notes and transcripts, policy violations, oversized transcripts and,
+// unless scrubbing is off, text the scrubber would still replace
+func strictProblems(commitRange string, summary *ci.Summary) ([]policy.Violation, error) {
+	var problems []policy.Violation
+	for _, t := range ci.UnreadableData(summary) {
+		if t.Reason != "" {
+			problems = append(problems, policy.Violation{SHA: t.SHA, Subject: t.Subject, Reason: t.Reason})
+		}
+		for _, path := range t.Unreadable {
+			problems = append(problems, policy.Violation{SHA: t.SHA, Subject: t.Subject, Reason: path + ": transcript cannot be read"})
+		}
+	}
+
+	pol, err := loadPolicy()
+	if err != nil {
+		return nil, err
+	}
+	violations, err := pol.Verify(commitRange)
+	if err != nil {
+		return nil, err
+	}
+	problems = append(problems, violations...)
+
+	var scrub *scrubber.PIIScrubber
+	if config.ScrubEnabled() || pol.ScrubRequired() {
+		if scrub, err = pol.Scrubber(); err != nil {
+			return nil, err
+		}
+	}
+	violations, err = pol.VerifyTranscripts(commitRange, scrub)
+	if err != nil {
+		return nil, err
+	}
+	return append(problems, violations...), nil
+}
+
+// applyTagLabels adds the repository labels matching the summary's tags
+// to the PR given by --label-pr. It reports on stderr, as stdout carries
+// the markdown or the GitHub Actions metadata.
+func applyTagLabels(summary *ci.Summary) error {
+	rollup := ci.TagRollup(summary)
+	if len(rollup) == 0 {
+		return nil
+	}
+	client, err := github.NewClient(prSummaryRepo)
+	if err != nil {
+		return err
+	}
+	labels, err := client.ListLabels()
+	if err != nil {
+		return err
+	}
+	matched := ci.MatchingLabels(rollup, labels)
+	if len(matched) == 0 {
+		fmt.Fprintf(os.Stderr, "No tags match a label of %s\n", client.Repo())
+		return nil
+	}
+	if err := client.AddLabels(prSummaryLabelPR, matched); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Labeled %s#%d: %s\n", client.Repo(), prSummaryLabelPR, strings.Join(matched, ", "))
+	return nil
+}
+
+// fillPRBody puts markdown into the prompt-story section of the description
+// of the PR given by --fill-pr. It returns false, after a note on stderr,
+// when the description has no section.
+func fillPRBody(markdown string) (bool, error) {
+	client, err := github.NewClient(prSummaryRepo)
+	if err != nil {
+		return false, err
+	}
+	pr, err := client.GetPullRequest(prSummaryFillPR)
+	if err != nil {
+		return false, err
+	}
+	body, ok := ci.FillBody(pr.Body, markdown)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "%s#%d has no prompt-story section (add one with: git-prompt-story pr template --write)\n", client.Repo(), prSummaryFillPR)
+		return false, nil
+	}
+	if body != pr.Body {
+		if err := client.UpdatePullRequestBody(prSummaryFillPR, body); err != nil {
+			return false, err
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Filled the description of %s#%d\n", client.Repo(), prSummaryFillPR)
+	return true, nil
+}
+
 func init() {
 	prSummaryCmd.Flags().BoolVar(&prSummaryFull, "full", false, "Include full prompt text (not truncated)")
-	prSummaryCmd.Flags().StringVar(&prSummaryPagesURL, "pages-url", "", "URL to GitHub Pages transcripts")
+	prSummaryCmd.Flags().StringVar(&prSummaryPagesURL, "pages-url", "", "URL to GitHub Pages transcripts (default: git config "+config.KeyPagesURL+")")
 	prSummaryCmd.Flags().StringVar(&prSummaryOutput, "output", "", "Write markdown to file instead of stdout")
 	prSummaryCmd.Flags().BoolVar(&prSummaryGHA, "gha", false, "GitHub Actions mode: output metadata to stdout")
+	prSummaryCmd.Flags().BoolVarP(&prSummaryVerbose, "verbose", "v", false, "List skipped commits and sessions with reasons on stderr")
+	prSummaryCmd.Flags().BoolVar(&prSummarySubmods, "submodules", false, "Include notes of submodule commits pulled in by the range")
+	prSummaryCmd.Flags().BoolVar(&prSummaryEstimate, "estimate", false, "Report rendered size and truncation instead of the markdown")
+	prSummaryCmd.Flags().IntVar(&prSummaryLabelPR, "label-pr", 0, "Add repository labels matching note tags to this PR number via the GitHub API")
+	prSummaryCmd.Flags().IntVar(&prSummaryFillPR, "fill-pr", 0, "Put the summary into the prompt-story section of this PR's description via the GitHub API")
+	prSummaryCmd.Flags().StringVar(&prSummaryRenderer, "renderer", ci.DefaultRenderer, "Output format: "+strings.Join(ci.RendererNames(), ", "))
+	prSummaryCmd.Flags().StringVar(&prSummaryBase, "base", "", "Branch to compare with when no range is given (default: detected)")
+	prSummaryCmd.Flags().IntVar(&prSummarySample, "sample-budget", 0, "Show at most this many prompts per commit, keeping all decisions and rejections")
+	prSummaryCmd.Flags().BoolVar(&prSummaryStrict, "strict", false, "Exit non-zero on unreadable data, oversized transcripts or policy and scrubbing violations")
+	prSummaryCmd.Flags().IntVar(&prSummaryJobs, "jobs", 0, "Commits to analyze in parallel (default: one per CPU)")
+	prSummaryCmd.Flags().StringVar(&prSummaryRepo, "repo", "", "GitHub repository (owner/name), detected from origin by default")
+	registerViewFlag(prSummaryCmd, &prSummaryView)
 	prCmd.AddCommand(prSummaryCmd)
 }
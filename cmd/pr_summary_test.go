@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPRBaseBranch(t *testing.T) {
+	run := initTestRepo(t)
+	t.Setenv("GITHUB_BASE_REF", "")
+	origin := filepath.Join(t.TempDir(), "origin.git")
+	run("init", "-q", "--bare", origin)
+	run("remote", "add", "origin", origin)
+	run("branch", "develop")
+	run("push", "-q", "origin", "main", "develop")
+
+	tests := []struct {
+		name    string
+		setup   []string // git command run first, split on spaces
+		baseRef string
+		want    string
+	}{
+		{name: "no upstream", setup: []string{"checkout -q -b plain"}, want: "origin/main"},
+		{name: "branch created from another remote branch", setup: []string{"checkout -q -b from-develop --track origin/develop"}, want: "origin/develop"},
+		{name: "upstream is the branch's own remote copy", setup: []string{"checkout -q -b pushed", "push -q -u origin pushed"}, want: "origin/main"},
+		{name: "GitHub Actions pull request", setup: []string{"checkout -q -b in-ci --track origin/develop"}, baseRef: "release", want: "origin/release"},
+		{name: "detached HEAD", setup: []string{"checkout -q --detach"}, want: "origin/main"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, c := range tt.setup {
+				run(strings.Fields(c)...)
+			}
+			t.Setenv("GITHUB_BASE_REF", tt.baseRef)
+			got, err := prBaseBranch()
+			if err != nil || got != tt.want {
+				t.Errorf("prBaseBranch() = %q, %v; want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestPRSummary_DetectedRange(t *testing.T) {
+	run := initTestRepo(t)
+	t.Setenv("GITHUB_BASE_REF", "")
+	at := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+	commitWithNote(t, "Already on main", "Fix the footer", at)
+	run("checkout", "-q", "-b", "feature")
+	commitWithNote(t, "Add login form", "Build the login form", at.Add(time.Hour))
+	commitWithNote(t, "Validate passwords", "Check password strength", at.Add(2*time.Hour))
+
+	stdout, stderr, code := runCommand(t, "pr", "summary")
+	if code != 0 {
+		t.Fatalf("pr summary exited %d: %s", code, stderr)
+	}
+	if !strings.Contains(stderr, "Summarizing HEAD since its merge base with main") {
+		t.Errorf("stderr = %q, want the detected base", stderr)
+	}
+	for _, want := range []string{"Build the login form", "Check password strength"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("output is missing %q:\n%s", want, stdout)
+		}
+	}
+	if strings.Contains(stdout, "Fix the footer") {
+		t.Errorf("output has a commit already on main:\n%s", stdout)
+	}
+
+	stdout, stderr, code = runCommand(t, "pr", "summary", "--base=feature~1")
+	if code != 0 || !strings.Contains(stdout, "Check password strength") || strings.Contains(stdout, "Build the login form") {
+		t.Errorf("pr summary --base=feature~1 = exit %d, stderr %q, output:\n%s", code, stderr, stdout)
+	}
+
+	// Errors: nothing to summarize, an unknown base, no base to detect
+	for _, tt := range []struct {
+		args    []string
+		wantErr string
+	}{
+		{[]string{"pr", "summary", "--base=feature"}, "HEAD has no commits that are not on feature"},
+		{[]string{"pr", "summary", "--base=no-such-branch"}, "no merge base of no-such-branch"},
+	} {
+		if _, stderr, code := runCommand(t, tt.args...); code != 1 || !strings.Contains(stderr, tt.wantErr) {
+			t.Errorf("%v = exit %d, stderr %q; want exit 1 with %q", tt.args, code, stderr, tt.wantErr)
+		}
+	}
+	run("branch", "-m", "main", "trunk")
+	if _, stderr, code := runCommand(t, "pr", "summary"); code != 1 || !strings.Contains(stderr, "cannot detect the default branch") {
+		t.Errorf("pr summary without a default branch = exit %d, stderr %q", code, stderr)
+	}
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/ci"
+	"github.com/spf13/cobra"
+)
+
+var prTemplateWrite string
+
+var prTemplateCmd = &cobra.Command{
+	Use:   "template",
+	Short: "Add a prompt-story section to the PR template",
+	Long: `Print, or install into a PR template with --write, a "Prompt story" section
+whose placeholder is replaced by the summary when pr summary runs with
+--fill-pr (the GitHub Action does this with summary-in-body: true). This
+puts the summary in a fixed place in PR bodies instead of a comment.
+
+Running it again on a template that has the section resets the section and
+leaves the rest of the template alone. The section works in GitLab merge
+request templates too; filling it is only automated on GitHub.
+
+Examples:
+  git-prompt-story pr template --write .github/PULL_REQUEST_TEMPLATE.md
+  git-prompt-story pr template --write .gitlab/merge_request_templates/Default.md`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if prTemplateWrite == "" {
+			fmt.Print(ci.TemplateSection())
+			return
+		}
+
+		existing, err := os.ReadFile(prTemplateWrite)
+		if err != nil && !os.IsNotExist(err) {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+		if err := os.MkdirAll(filepath.Dir(prTemplateWrite), 0755); err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+		if err := os.WriteFile(prTemplateWrite, []byte(ci.InstallTemplate(string(existing))), 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: failed to write template: %v\n", err)
+			os.Exit(1)
+		}
+		if existing == nil {
+			fmt.Printf("Created %s\n", prTemplateWrite)
+		} else {
+			fmt.Printf("Updated %s\n", prTemplateWrite)
+		}
+	},
+}
+
+func init() {
+	prTemplateCmd.Flags().StringVar(&prTemplateWrite, "write", "", "Install the section into this template file, creating it if needed")
+	prCmd.AddCommand(prTemplateCmd)
+}
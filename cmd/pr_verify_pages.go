@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/ci"
+	"github.com/QuesmaOrg/git-prompt-story/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	prVerifyPagesSite    string
+	prVerifyPagesSubmods bool
+)
+
+var prVerifyPagesCmd = &cobra.Command{
+	Use:   "verify-pages <commit-range>",
+	Short: "Check that published transcript pages match the notes",
+	Long: `Cross-check a transcript site generated by "pr html" against the notes
+of a range, and exit non-zero when they drifted apart:
+
+  - every commit with a note has a page, linked from index.html
+  - every session and step of the notes is on the commit's page or the
+    step pages it loads, and the pages have no steps the notes lack
+  - relative links and step anchors resolve
+  - pages stay within the GitHub Pages size limits (100 MB per file,
+    1 GB per site)
+
+The site is a directory holding the generated pages or the URL they are
+published at (default: git config ` + config.KeyPagesURL + `), the same URL
+pr summary links as "View full transcripts". Pass --submodules if the
+pages were generated with it.
+
+Examples:
+  git-prompt-story pr verify-pages origin/main..HEAD --site=./pages
+  git-prompt-story pr verify-pages origin/main..HEAD --site=https://example.github.io/repo/pr-42/`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		commitRange := args[0]
+		if prVerifyPagesSite == "" {
+			prVerifyPagesSite = config.Get(config.KeyPagesURL)
+		}
+		if prVerifyPagesSite == "" {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: --site is required\n")
+			os.Exit(1)
+		}
+
+		summary, err := ci.GenerateSummary(commitRange, true)
+		if err == nil && prVerifyPagesSubmods {
+			err = ci.AddSubmoduleSummaries(summary, commitRange, true)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+
+		problems, err := ci.VerifyPages(summary, ci.OpenPagesSite(prVerifyPagesSite))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+		if len(problems) > 0 {
+			for _, p := range problems {
+				fmt.Printf("  %s\n", p)
+			}
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %s does not match the notes (%d problem(s))\n", prVerifyPagesSite, len(problems))
+			os.Exit(1)
+		}
+		fmt.Printf("Pages match the notes of %d commit(s)\n", len(summary.Commits))
+	},
+}
+
+func init() {
+	prVerifyPagesCmd.Flags().StringVar(&prVerifyPagesSite, "site", "", "Directory or URL of the pages (default: git config "+config.KeyPagesURL+")")
+	prVerifyPagesCmd.Flags().BoolVar(&prVerifyPagesSubmods, "submodules", false, "Include notes of submodule commits pulled in by the range")
+	prCmd.AddCommand(prVerifyPagesCmd)
+}
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
@@ -26,6 +27,9 @@ var prepareCommitMsgCmd = &cobra.Command{
 
 		if err := hooks.PrepareCommitMsg(msgFile, source, sha, GetVersion()); err != nil {
 			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			if errors.Is(err, hooks.ErrCaptureRequired) {
+				os.Exit(1)
+			}
 			// Don't exit with error to not block the commit
 		}
 	},
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/ci"
+	"github.com/spf13/cobra"
+)
+
+var (
+	releaseNotesOutput string
+	releaseNotesTitle  string
+)
+
+var releaseNotesCmd = &cobra.Command{
+	Use:   "release-notes <commit-range>",
+	Short: "Write a changelog enriched with the captured story",
+	Long: `Generate a markdown changelog for the commits in a range, typically
+between two release tags.
+
+Commits are grouped by their conventional commit type (feat, fix, perf,
+...); breaking changes are also listed first. Each group is followed by the
+key prompts and answered decisions from its commits' sessions, and a
+closing section tells how many commits were made with LLM sessions and with
+which tools. The title is the end of the range unless --title is given.
+
+Examples:
+  git-prompt-story release-notes v1.2.0..v1.3.0
+  git-prompt-story release-notes v1.3.0..HEAD --title="v1.4.0 (draft)"
+  git-prompt-story release-notes v1.2.0..v1.3.0 --output=CHANGELOG-1.3.md`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		notes, err := ci.GenerateReleaseNotes(args[0], releaseNotesTitle)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+
+		if releaseNotesOutput != "" {
+			if err := os.WriteFile(releaseNotesOutput, []byte(notes), 0644); err != nil {
+				fmt.Fprintf(os.Stderr, "git-prompt-story: failed to write output: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+		fmt.Print(notes)
+	},
+}
+
+func init() {
+	releaseNotesCmd.Flags().StringVar(&releaseNotesOutput, "output", "", "Write markdown to file instead of stdout")
+	releaseNotesCmd.Flags().StringVar(&releaseNotesTitle, "title", "", "Heading of the notes (default: the end of the range)")
+	rootCmd.AddCommand(releaseNotesCmd)
+}
@@ -4,8 +4,11 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/QuesmaOrg/git-prompt-story/internal/config"
 	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+	"github.com/QuesmaOrg/git-prompt-story/internal/policy"
 	"github.com/QuesmaOrg/git-prompt-story/internal/repair"
+	"github.com/QuesmaOrg/git-prompt-story/internal/session"
 	"github.com/spf13/cobra"
 )
 
@@ -36,14 +39,20 @@ Examples:
   # Preview what would be repaired
   git-prompt-story repair --dry-run HEAD`,
 	Run: func(cmd *cobra.Command, args []string) {
-		opts := repair.Options{
-			DryRun:  repairDryRun,
-			Force:   repairForce,
-			NoScrub: repairNoScrub,
+		pol, err := loadPolicy()
+		if err == nil && repairNoScrub {
+			err = pol.CheckNoScrub()
 		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+
+		opts := repairOptions(pol, repairNoScrub)
+		opts.DryRun = repairDryRun
+		opts.Force = repairForce
 
 		var commits []string
-		var err error
 
 		if repairScan {
 			// Scan mode: find commits needing repair
@@ -120,6 +129,22 @@ Examples:
 	},
 }
 
+// repairOptions applies the scrub and tool settings and the team policy
+// to repairs. Tools the repository has not opted into are left out, as
+// repairs cannot ask the way the commit hook does.
+func repairOptions(pol *policy.Policy, noScrub bool) repair.Options {
+	return repair.Options{
+		NoScrub: noScrub || (!config.ScrubEnabled() && !pol.ScrubRequired()),
+		ToolEnabled: func(tool string) bool {
+			capture, decided := config.ToolCaptureConsent(tool)
+			if !decided {
+				capture = tool == session.ToolClaudeCode
+			}
+			return capture && config.ToolEnabled(tool) && pol.ToolAllowed(tool)
+		},
+	}
+}
+
 func init() {
 	repairCmd.Flags().BoolVar(&repairDryRun, "dry-run", false, "Preview without making changes")
 	repairCmd.Flags().BoolVar(&repairForce, "force", false, "Overwrite existing notes")
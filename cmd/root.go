@@ -1,17 +1,30 @@
 package cmd
 
 import (
+	"bufio"
 	"fmt"
 	"os"
+	"slices"
 	"strings"
+	"time"
 
+	"github.com/QuesmaOrg/git-prompt-story/internal/config"
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+	"github.com/QuesmaOrg/git-prompt-story/internal/note"
+	"github.com/QuesmaOrg/git-prompt-story/internal/pause"
+	"github.com/QuesmaOrg/git-prompt-story/internal/repair"
+	"github.com/QuesmaOrg/git-prompt-story/internal/retryqueue"
+	"github.com/mattn/go-isatty"
 	"github.com/spf13/cobra"
 )
 
 var version = "dev"
 
+var rootFetch bool
+
 func SetVersionInfo(v, commit, date string) {
 	version = v
+	note.CLIVersion = v
 
 	// Build version string with optional commit and date
 	var parts []string
@@ -38,8 +51,243 @@ var rootCmd = &cobra.Command{
 	Use:   "git-prompt-story",
 	Short: "Capture LLM sessions alongside git commits",
 	Long: `git-prompt-story captures LLM sessions (Claude Code, Cursor, etc.)
-and stores them as git notes attached to your commits.`,
+and stores them as git notes attached to your commits.
+
+Without a command, prints this help. Set prompt-story.defaultCommand to
+"status" to show the capture state instead, or to "show" to open the
+viewer on HEAD.`,
 	Version: version,
+	Run: func(cmd *cobra.Command, args []string) {
+		runDefaultCommand(cmd)
+	},
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		recordUsageStart(cmd)
+		retryQueuedNotes(cmd)
+		checkNotesFetched(cmd)
+		startMissedCaptureScan(cmd)
+	},
+	PersistentPostRun: func(cmd *cobra.Command, args []string) {
+		recordUsageEnd(cmd)
+		offerMissedCaptures()
+	},
+}
+
+func init() {
+	rootCmd.PersistentFlags().BoolVar(&rootFetch, "fetch", false, "Fetch prompt-story notes from origin if they are missing")
+}
+
+// runDefaultCommand runs what prompt-story.defaultCommand configures for a
+// bare invocation
+func runDefaultCommand(cmd *cobra.Command) {
+	switch value := config.Get(config.KeyDefaultCommand); value {
+	case config.DefaultCommandStatus:
+		statusCmd.Run(statusCmd, nil)
+	case config.DefaultCommandShow:
+		checkNotesFetched(showCmd)
+		showCmd.Run(showCmd, []string{"HEAD"})
+	case "", config.DefaultCommandHelp:
+		cmd.Help()
+	default:
+		fmt.Fprintf(os.Stderr, "git-prompt-story: unknown %s %q (expected %s, %s or %s)\n\n", config.KeyDefaultCommand,
+			value, config.DefaultCommandHelp, config.DefaultCommandStatus, config.DefaultCommandShow)
+		cmd.Help()
+		os.Exit(1)
+	}
+}
+
+// notesReaders returns the commands that read notes, checked by
+// checkNotesFetched
+func notesReaders() []*cobra.Command {
+	return []*cobra.Command{
+		showCmd, listCmd, explainCmd, branchCmd, verifyCmd,
+		prSummaryCmd, prHTMLCmd, prPreviewCmd, prAnnotateCmd, prDescribeCmd,
+		annotateCmd, holdCmd, applyPolicyCmd, gcCmd, bundleCreateCmd, serveCmd, historyCmd,
+		orphansCmd, orphansAttachCmd, prVerifyPagesCmd, releaseNotesCmd, statsCmd, prPagesCmd,
+		searchCmd, whyCmd, blameCmd,
+	}
+}
+
+// checkNotesFetched stops commands reading notes with ErrNotesNotFetched
+// when the notes refs were never fetched, instead of letting them report
+// that commits have no notes. With --fetch, or when the user agrees, the
+// notes are fetched from origin first.
+func checkNotesFetched(cmd *cobra.Command) {
+	if !slices.Contains(notesReaders(), cmd) {
+		return
+	}
+	err := note.CheckNotesFetched()
+	if err == nil {
+		return
+	}
+
+	interactive := isatty.IsTerminal(os.Stdin.Fd()) && isatty.IsTerminal(os.Stderr.Fd())
+	fetch := rootFetch
+	if !fetch && interactive {
+		fmt.Fprintf(os.Stderr, "Prompt-story notes have not been fetched. Fetch them from origin now? [y/N]: ")
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		fetch = answer == "y" || answer == "yes"
+	}
+	if fetch {
+		if err = note.FetchNotes("origin"); err == nil {
+			fmt.Fprintln(os.Stderr, "git-prompt-story: fetched prompt-story notes from origin")
+			return
+		}
+	}
+	if !missingNotesFatal(cmd, interactive) {
+		fmt.Fprintf(os.Stderr, "git-prompt-story: warning: %v\n", err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+	os.Exit(1)
+}
+
+// missingNotesFatal reports whether cmd must stop when the notes were not
+// fetched. pr summary in CI (--gha, or without a terminal) goes on: it
+// reports the missing notes itself, as notes-missing.
+func missingNotesFatal(cmd *cobra.Command, interactive bool) bool {
+	if cmd == prSummaryCmd && (prSummaryGHA || !interactive) {
+		return false
+	}
+	return true
+}
+
+// internalCommand reports whether cmd runs for something other than the
+// user's work in a repository: cobra's help and shell completion, which
+// run on a tab press, and server-verify, which runs in a server's
+// repository. Such commands must not write notes or prompt.
+func internalCommand(cmd *cobra.Command) bool {
+	if cmd == serverVerifyCmd {
+		return true
+	}
+	for c := cmd; c != nil; c = c.Parent() {
+		switch c.Name() {
+		case "help", "completion", cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd:
+			return true
+		}
+	}
+	return false
+}
+
+// retryQueuedNotes attaches notes the post-commit hook failed to attach,
+// see retryqueue. Failures stay queued silently; doctor reports them.
+func retryQueuedNotes(cmd *cobra.Command) {
+	if cmd == postCommitCmd || cmd == doctorCmd {
+		return // They drain the queue themselves
+	}
+	if internalCommand(cmd) {
+		return
+	}
+	if entries, err := retryqueue.Pending(); err != nil || len(entries) == 0 {
+		return
+	}
+	if attached, _, _ := retryqueue.Drain(); attached > 0 {
+		fmt.Fprintf(os.Stderr, "git-prompt-story: attached %d note(s) queued after a failed commit hook\n", attached)
+	}
+}
+
+// missedScan delivers the result of the scan for missed captures started
+// by startMissedCaptureScan, nil when none was started
+var missedScan chan []repair.MissedCapture
+
+// missedOptions are the repair options the scan was started with
+var missedOptions repair.Options
+
+// missedScanDeadline is when offerMissedCaptures stops waiting for the scan
+var missedScanDeadline time.Time
+
+// missedScanTimeout bounds how long a command waits at exit for the scan,
+// which can take a while in a large history
+const missedScanTimeout = time.Second
+
+// missedScanCommands returns the commands looking at the captures of
+// commits, after which missed captures are offered
+func missedScanCommands() []*cobra.Command {
+	return []*cobra.Command{showCmd, listCmd, explainCmd, branchCmd, historyCmd, whyCmd, blameCmd}
+}
+
+// startMissedCaptureScan starts looking in the background for recent
+// commits made without the hooks, e.g. with --no-verify or from a GUI,
+// whose work period has local sessions; offerMissedCaptures offers them
+// once the command is done, so the scan does not delay it. Only commands
+// about captures look, and not while capture is paused.
+func startMissedCaptureScan(cmd *cobra.Command) {
+	if !slices.Contains(missedScanCommands(), cmd) {
+		return
+	}
+	if state, err := git.GetRepoState(); err == nil && state.SkipsCapture() {
+		return // Replayed commits have no trailer yet; check once done
+	}
+	if state, err := pause.Current(); err != nil || state.Paused {
+		return
+	}
+	pol, err := loadPolicy()
+	if err != nil {
+		return
+	}
+	missedOptions = repairOptions(pol, false)
+	missedScan = make(chan []repair.MissedCapture, 1)
+	missedScanDeadline = time.Now().Add(missedScanTimeout)
+	go func() {
+		missed, _ := repair.FindMissedCaptures(missedOptions)
+		missedScan <- missed
+	}()
+}
+
+// offerMissedCaptures offers to add notes for the commits the background
+// scan found. With prompt-story.autoAddMissed they are added without
+// asking. Only confident matches are offered, and each commit only once.
+func offerMissedCaptures() {
+	if missedScan == nil {
+		return
+	}
+	var missed []repair.MissedCapture
+	select {
+	case missed = <-missedScan:
+	case <-time.After(time.Until(missedScanDeadline)):
+		return // Not worth stalling the command; a later one looks again
+	}
+	if len(missed) == 0 {
+		return
+	}
+
+	auto := config.GetBool(config.KeyAutoAddMissed, false)
+	interactive := isatty.IsTerminal(os.Stdin.Fd()) && isatty.IsTerminal(os.Stderr.Fd())
+	var checked []string
+	for _, m := range missed {
+		checked = append(checked, m.SHA)
+		if m.Confidence != repair.ConfidenceHigh {
+			continue
+		}
+		if existing, err := note.GetNote(m.SHA); err == nil && existing != "" {
+			continue // The command just added one
+		}
+
+		add := auto
+		if !add && interactive {
+			fmt.Fprintf(os.Stderr, "Commit %s %q was made without prompt-story hooks, but %d session(s) with %d prompt(s) match it. Add a note? [y/N]: ",
+				m.ShortSHA, m.Subject, m.Sessions, m.Prompts)
+			answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+			answer = strings.ToLower(strings.TrimSpace(answer))
+			add = answer == "y" || answer == "yes"
+		} else if !add {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: commit %s was made without hooks but %d session(s) match it; run 'git-prompt-story repair %s' to add a note\n",
+				m.ShortSHA, m.Sessions, m.ShortSHA)
+		}
+		if !add {
+			continue
+		}
+
+		result, err := repair.RepairCommit(m.SHA, missedOptions)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: failed to add note to %s: %v\n", m.ShortSHA, err)
+			continue
+		}
+		if result.NoteCreated {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: added note to %s (%d sessions)\n", m.ShortSHA, result.SessionsFound)
+		}
+	}
+	repair.MarkChecked(checked...)
 }
 
 func Execute() {
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+	"github.com/QuesmaOrg/git-prompt-story/internal/pause"
+	"github.com/QuesmaOrg/git-prompt-story/internal/repair"
+	"github.com/spf13/cobra"
+)
+
+func TestMissingNotesFatal(t *testing.T) {
+	tests := []struct {
+		name        string
+		cmd         *cobra.Command
+		gha         bool
+		interactive bool
+		want        bool
+	}{
+		{name: "show", cmd: showCmd, interactive: true, want: true},
+		{name: "show without a terminal", cmd: showCmd, want: true},
+		{name: "pr summary", cmd: prSummaryCmd, interactive: true, want: true},
+		{name: "pr summary without a terminal", cmd: prSummaryCmd},
+		{name: "pr summary --gha", cmd: prSummaryCmd, gha: true, interactive: true},
+	}
+
+	saved := prSummaryGHA
+	defer func() { prSummaryGHA = saved }()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prSummaryGHA = tt.gha
+			if got := missingNotesFatal(tt.cmd, tt.interactive); got != tt.want {
+				t.Errorf("missingNotesFatal() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStartMissedCaptureScan(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Chdir(t.TempDir())
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("HOME", t.TempDir())
+	if _, err := git.RunGit("init", "-q", "-b", "main"); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { missedScan = nil }()
+
+	started := func(cmd *cobra.Command) bool {
+		missedScan = nil
+		startMissedCaptureScan(cmd)
+		if missedScan == nil {
+			return false
+		}
+		<-missedScan
+		return true
+	}
+	if !started(showCmd) {
+		t.Error("show did not scan for missed captures")
+	}
+	for _, cmd := range []*cobra.Command{statusCmd, pauseCmd, initCmd, metricsCmd, prepareCommitMsgCmd} {
+		if started(cmd) {
+			t.Errorf("%s scanned for missed captures", cmd.Name())
+		}
+	}
+
+	if _, err := pause.Pause(0); err != nil {
+		t.Fatal(err)
+	}
+	if started(showCmd) {
+		t.Error("scanned for missed captures while paused")
+	}
+}
+
+func TestOfferMissedCaptures_Timeout(t *testing.T) {
+	defer func() { missedScan = nil }()
+
+	// A scan that never finishes is given up on at the deadline
+	missedScan = make(chan []repair.MissedCapture)
+	missedScanDeadline = time.Now().Add(10 * time.Millisecond)
+	done := make(chan struct{})
+	go func() {
+		offerMissedCaptures()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("offerMissedCaptures() waited past the deadline")
+	}
+}
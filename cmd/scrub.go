@@ -0,0 +1,288 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/scrubber"
+	"github.com/QuesmaOrg/git-prompt-story/internal/show"
+	"github.com/spf13/cobra"
+)
+
+var (
+	scrubReport          bool
+	scrubHistory         bool
+	scrubDryRun          bool
+	scrubOverrideHold    bool
+	scrubTestPatterns    []string
+	scrubTestReplacement string
+)
+
+var scrubCmd = &cobra.Command{
+	Use:   "scrub [file]",
+	Short: "Scrub a transcript or inspect the PII scrubber",
+	Long: `Scrub a transcript, or any text, with the PII scrubber applied to
+transcripts before they are stored, and print the result. The file
+defaults to stdin.
+
+With --report nothing is printed but what would be scrubbed: each match,
+the recognizer that matched it, how often it occurs and where, and the
+allowlist entry keeping it, if any.
+
+With --history, the transcripts already stored in
+refs/notes/prompt-story-transcripts are scrubbed again, for patterns added
+since they were captured, with the scrub profiles of the team policy.
+Changed transcripts are written in a new tree, re-sealed, and the change is
+logged in the notes referencing them. --dry-run lists what would change;
+transcripts on legal hold are refused unless --override-hold is given.
+
+Besides the built-in recognizers, the scrubber runs those of the user's
+configuration (~/.config/git-prompt-story/scrubber.yaml on Linux) and of
+the repository's .git-prompt-story/scrubber.yaml, the repository's
+replacing the user's of the same name:
+
+  recognizers:
+    - name: ticket
+      entity_type: TICKET
+      patterns:
+        - regex: 'ACME-\d+'
+      replacement: <TICKET>
+
+Matches the allowlist exempts are kept, e.g. addresses in documentation.
+A literal entry must equal the whole match, a regex entry matches in it;
+recognizers limits an entry to recognizer names or entity types:
+
+  allowlist:
+    - literal: docs@example.com
+    - regex: '@example\.(com|org)$'
+      recognizers: [email]
+      comment: Addresses in the docs
+
+Examples:
+  git-prompt-story scrub session.jsonl > scrubbed.jsonl
+  git-prompt-story scrub --report session.jsonl
+  git-prompt-story scrub --history --dry-run`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if scrubHistory {
+			if len(args) > 0 || scrubReport {
+				fmt.Fprintln(os.Stderr, "git-prompt-story: --history takes no file and no --report (use --dry-run)")
+				os.Exit(1)
+			}
+			if err := rescrubHistory(); err != nil {
+				fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+		if scrubDryRun || scrubOverrideHold {
+			fmt.Fprintln(os.Stderr, "git-prompt-story: --dry-run and --override-hold need --history")
+			os.Exit(1)
+		}
+
+		var data []byte
+		var err error
+		if len(args) == 1 {
+			data, err = os.ReadFile(args[0])
+		} else {
+			data, err = io.ReadAll(os.Stdin)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+
+		s, err := scrubber.NewDefault()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+		var hits []scrubber.Hit
+		if scrubReport {
+			s.SetReport(func(h scrubber.Hit) { hits = append(hits, h) })
+		}
+		scrubbed, err := s.Scrub(data)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+		if !scrubReport {
+			os.Stdout.Write(scrubbed)
+			return
+		}
+		printScrubReport(hits)
+	},
+}
+
+// rescrubHistory scrubs the stored transcripts again and reports the
+// changes
+func rescrubHistory() error {
+	pol, err := loadPolicy()
+	if err != nil {
+		return err
+	}
+	s, err := pol.Scrubber()
+	if err != nil {
+		return err
+	}
+
+	changes, err := show.RescrubHistory(s, scrubDryRun, scrubOverrideHold)
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 {
+		fmt.Println("No stored transcripts need scrubbing")
+		return nil
+	}
+
+	replacements := 0
+	for _, c := range changes {
+		fmt.Printf("  %s: %d replacement(s) (%s)\n", c.Path, c.Replacements(), c.Kinds())
+		replacements += c.Replacements()
+	}
+	verb := "Rewrote"
+	if scrubDryRun {
+		verb = "Would rewrite"
+	}
+	fmt.Printf("%s %d transcript(s), %d replacement(s)\n", verb, len(changes), replacements)
+
+	if !scrubDryRun && show.WasNotesPushed() {
+		fmt.Println("Notes were already pushed; force-push to apply remotely:")
+		fmt.Println("  git push -f origin refs/notes/prompt-story refs/notes/prompt-story-transcripts")
+	}
+	return nil
+}
+
+// scrubReportEntry is a distinct match in a scrub report
+type scrubReportEntry struct {
+	scrubber.Hit
+	count int
+}
+
+// printScrubReport prints the hits of a scrub, each distinct match of a
+// recognizer once, the allowed ones apart
+func printScrubReport(hits []scrubber.Hit) {
+	var scrubbed, allowed []*scrubReportEntry
+	seen := make(map[string]*scrubReportEntry)
+	for _, h := range hits {
+		key := h.Recognizer + "\x00" + h.Match
+		if e, ok := seen[key]; ok {
+			e.count++
+			continue
+		}
+		e := &scrubReportEntry{Hit: h, count: 1}
+		seen[key] = e
+		if h.Allowed != nil {
+			allowed = append(allowed, e)
+		} else {
+			scrubbed = append(scrubbed, e)
+		}
+	}
+
+	if len(scrubbed) == 0 {
+		fmt.Println("Nothing would be scrubbed")
+	} else {
+		fmt.Printf("Would scrub (%d):\n", len(scrubbed))
+		for _, e := range scrubbed {
+			printScrubReportEntry(e, "")
+		}
+	}
+	if len(allowed) > 0 {
+		fmt.Printf("\nAllowed (%d):\n", len(allowed))
+		for _, e := range allowed {
+			reason := "allowed by " + e.Allowed.String()
+			if e.Allowed.Comment != "" {
+				reason += ": " + e.Allowed.Comment
+			}
+			printScrubReportEntry(e, reason)
+		}
+	}
+}
+
+func printScrubReportEntry(e *scrubReportEntry, reason string) {
+	fmt.Printf("  %-20s %-14s %s", e.Recognizer, e.EntityType, e.Match)
+	if e.count > 1 {
+		fmt.Printf(" (%dx)", e.count)
+	}
+	fmt.Println()
+	fmt.Printf("      %s\n", e.Context)
+	if reason != "" {
+		fmt.Printf("      %s\n", reason)
+	}
+}
+
+var scrubTestCmd = &cobra.Command{
+	Use:   "test [text]",
+	Short: "Scrub sample text and show what matched",
+	Long: `Scrub sample text, given as an argument or on stdin, with the scrubber
+transcripts are stored with, then list each match and the recognizer that
+replaced it, or the allowlist entry that kept it. Configuration files
+with invalid regexes are reported.
+
+Try a pattern before adding it to a configuration file with --pattern.
+
+Examples:
+  git-prompt-story scrub test "mail jane@example.com about ACME-42"
+  git-prompt-story scrub test --pattern 'ACME-\d+' < sample.txt`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		text := ""
+		if len(args) == 1 {
+			text = args[0]
+		} else {
+			data, err := io.ReadAll(os.Stdin)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+				os.Exit(1)
+			}
+			text = string(data)
+		}
+
+		s, err := scrubber.NewDefault()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+		if len(scrubTestPatterns) > 0 {
+			r := scrubber.Recognizer{Name: "test", EntityType: "TEST", Replacement: scrubTestReplacement}
+			for _, p := range scrubTestPatterns {
+				r.Patterns = append(r.Patterns, scrubber.Pattern{Regex: p})
+			}
+			if err := s.AddRecognizers([]scrubber.Recognizer{r}); err != nil {
+				fmt.Fprintf(os.Stderr, "git-prompt-story: invalid --pattern: %v\n", err)
+				os.Exit(1)
+			}
+		}
+
+		var hits []scrubber.Hit
+		s.SetReport(func(h scrubber.Hit) { hits = append(hits, h) })
+		fmt.Println(strings.TrimRight(s.ScrubText(text), "\n"))
+		fmt.Println()
+
+		if len(hits) == 0 {
+			fmt.Println("No matches")
+			return
+		}
+		fmt.Printf("Matches (%d):\n", len(hits))
+		for _, h := range hits {
+			fmt.Printf("  %-20s %-14s %s", h.Recognizer, h.EntityType, h.Match)
+			if h.Allowed != nil {
+				fmt.Printf("  (allowed by %s)", h.Allowed)
+			}
+			fmt.Println()
+		}
+	},
+}
+
+func init() {
+	scrubCmd.Flags().BoolVar(&scrubReport, "report", false, "Print what would be scrubbed and why instead of the scrubbed content")
+	scrubCmd.Flags().BoolVar(&scrubHistory, "history", false, "Scrub the transcripts already stored in git notes again")
+	scrubCmd.Flags().BoolVar(&scrubDryRun, "dry-run", false, "With --history, show what would change without rewriting transcripts")
+	scrubCmd.Flags().BoolVar(&scrubOverrideHold, "override-hold", false, "With --history, also rewrite transcripts on legal hold (recorded in the note)")
+	scrubTestCmd.Flags().StringArrayVar(&scrubTestPatterns, "pattern", nil, "Also try this regex, as recognizer \"test\" (repeatable)")
+	scrubTestCmd.Flags().StringVar(&scrubTestReplacement, "replacement", "<TEST>", "Replacement for matches of --pattern")
+	scrubCmd.AddCommand(scrubTestCmd)
+	rootCmd.AddCommand(scrubCmd)
+}
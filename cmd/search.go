@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/ci"
+	"github.com/QuesmaOrg/git-prompt-story/internal/note"
+	"github.com/QuesmaOrg/git-prompt-story/internal/policy"
+	"github.com/spf13/cobra"
+)
+
+var (
+	searchTypes       []string
+	searchAuthor      string
+	searchSince       string
+	searchUntil       string
+	searchCommitRange string
+	searchJSON        bool
+)
+
+var searchCmd = &cobra.Command{
+	Use:   "search <query>",
+	Short: "Search recorded prompts, assistant text and tool inputs",
+	Long: `Search every transcript stored in refs/notes/prompt-story-transcripts for
+steps containing all words of the query (case-insensitive). Prompts,
+assistant text and tool inputs are matched; tool output is not.
+
+--type limits the search to prompt, assistant or tool steps (repeatable).
+--since and --until take a date (2025-01-31) or an age (30d, 12w, 1y).
+--commit-range searches only the sessions noted on commits in the range;
+without it orphaned transcripts are searched too.
+
+Examples:
+  git-prompt-story search "rate limit"
+  git-prompt-story search --type=prompt --author=jane --since=30d retry
+  git-prompt-story search --commit-range=origin/main..HEAD --json migration`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		opts := ci.SearchOptions{
+			Query:  strings.Join(args, " "),
+			Types:  searchTypes,
+			Author: searchAuthor,
+			Range:  searchCommitRange,
+		}
+		var err error
+		if opts.Since, err = parseSearchTime(searchSince); err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: --since: %v\n", err)
+			os.Exit(1)
+		}
+		if opts.Until, err = parseSearchTime(searchUntil); err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: --until: %v\n", err)
+			os.Exit(1)
+		}
+
+		matches, err := ci.Search(opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+
+		if searchJSON {
+			if matches == nil {
+				matches = []ci.SearchMatch{}
+			}
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(matches); err != nil {
+				fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		if len(matches) == 0 {
+			fmt.Println("No matches")
+			return
+		}
+		for _, m := range matches {
+			where := m.Tool + "/" + m.SessionID
+			if len(m.Commits) > 0 {
+				where = m.Commits[0] + "  " + where
+			}
+			fmt.Printf("%s  %-9s %s  %s\n", m.Time.Local().Format("2006-01-02 15:04"), m.Type, note.FormatToolName(m.Tool), where)
+			fmt.Printf("    %s\n", ci.SearchSnippet(m.Text, opts.Query, 100))
+		}
+		fmt.Printf("%d match(es)\n", len(matches))
+	},
+}
+
+// parseSearchTime parses a date (2006-01-02, local time) or an age such as
+// 30d counted back from now; empty means unset
+func parseSearchTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+	age, err := policy.ParseAge(s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q (use a date like 2025-01-31 or an age like 30d)", s)
+	}
+	return time.Now().Add(-age), nil
+}
+
+func init() {
+	searchCmd.Flags().StringSliceVar(&searchTypes, "type", nil, "Only match these step types: "+strings.Join(ci.SearchTypes, ", "))
+	searchCmd.Flags().StringVar(&searchAuthor, "author", "", "Only sessions captured by this author or OS user (substring match)")
+	searchCmd.Flags().StringVar(&searchSince, "since", "", "Only steps at or after this date or age (e.g. 2025-01-31, 30d)")
+	searchCmd.Flags().StringVar(&searchUntil, "until", "", "Only steps at or before this date or age")
+	searchCmd.Flags().StringVar(&searchCommitRange, "commit-range", "", "Only sessions noted on commits in this range (e.g. origin/main..HEAD)")
+	searchCmd.Flags().BoolVar(&searchJSON, "json", false, "Write matches as JSON")
+	rootCmd.AddCommand(searchCmd)
+}
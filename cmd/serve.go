@@ -0,0 +1,221 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/ci"
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+	"github.com/QuesmaOrg/git-prompt-story/internal/note"
+	"github.com/QuesmaOrg/git-prompt-story/internal/repometrics"
+	"github.com/spf13/cobra"
+)
+
+var (
+	serveAddr     string
+	serveInterval time.Duration
+	serveRange    string
+	serveMaxCount int
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Browse prompt stories in a web browser and serve metrics over HTTP",
+	Long: `Start an HTTP server for the current repository.
+
+/ is a web UI for browsing the noted commits reachable from --range (at
+most --max-count, newest first): their sessions and transcripts, a search
+over every step that can be narrowed to one tool, and a page per commit
+showing its diff next to the prompts whose edits touched each file. It is
+the site pr pages publishes, rebuilt when the notes change.
+
+/metrics exposes Prometheus metrics, collected from the repository every
+--interval, so platform teams can watch prompt-story health on shared build
+servers:
+
+  prompt_story_notes                   commits with a note
+  prompt_story_prompts{tool}           user prompts in stored transcripts
+  prompt_story_capture_errors{kind}    commits whose trailer says a note
+                                       was made but none is attached
+                                       (missing_note), notes waiting in
+                                       the retry queue (retry_queued)
+  prompt_story_transcript_bytes{tool}  size of the stored transcripts
+
+They are gauges of the repository's current state: gc, redaction and
+repairs can lower them, so graph them directly rather than with rate().
+
+Examples:
+  git-prompt-story serve
+  git-prompt-story serve --range=origin/main --max-count=50
+  git-prompt-story serve --addr=:9464 --interval=5m`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		m := &serveMetrics{collector: repometrics.NewCollector()}
+		// Collect once up front, so the first scrape has data
+		if err := m.collect(); err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+		go func() {
+			for range time.Tick(serveInterval) {
+				if err := m.collect(); err != nil {
+					fmt.Fprintf(os.Stderr, "git-prompt-story: collecting metrics: %v\n", err)
+				}
+			}
+		}()
+
+		ui := &serveUI{}
+		defer ui.close()
+		if err := ui.build(); err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+		go func() {
+			for range time.Tick(serveInterval) {
+				if err := ui.build(); err != nil {
+					fmt.Fprintf(os.Stderr, "git-prompt-story: rebuilding the web UI: %v\n", err)
+				}
+			}
+		}()
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("/metrics", m.serveHTTP)
+		mux.Handle("/", ui)
+		server := &http.Server{Addr: serveAddr, Handler: mux}
+
+		// Stop on Ctrl-C or SIGTERM, so the web UI's files are removed
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		go func() {
+			<-ctx.Done()
+			server.Shutdown(context.Background())
+		}()
+
+		fmt.Printf("Serving the web UI on http://%s/ and metrics on http://%s/metrics\n", serveAddr, serveAddr)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			ui.close()
+			os.Exit(1)
+		}
+	},
+}
+
+// serveUI serves the browse site of the noted commits, see
+// ci.GenerateBrowseSite, from a temporary directory
+type serveUI struct {
+	mu      sync.Mutex
+	dir     string
+	handler http.Handler
+	refs    string // The notes refs the site was built from
+}
+
+// build regenerates the site when the notes refs moved since the last build
+func (u *serveUI) build() error {
+	notesRef, _ := git.GetRef(note.NotesRef)
+	transcriptsRef, _ := git.GetRef(note.TranscriptsRef)
+	refs := notesRef + " " + transcriptsRef
+	u.mu.Lock()
+	current := u.dir != "" && u.refs == refs
+	u.mu.Unlock()
+	if current {
+		return nil
+	}
+
+	summary := &ci.Summary{}
+	listed, err := note.ListCommits(serveRange, serveMaxCount)
+	if err != nil {
+		return err
+	}
+	if len(listed) > 0 {
+		shas := make([]string, len(listed))
+		for i, c := range listed {
+			shas[i] = c.SHA
+		}
+		if summary, err = ci.GenerateSummary(strings.Join(shas, ","), true); err != nil {
+			return err
+		}
+	}
+
+	dir, err := os.MkdirTemp("", "git-prompt-story-serve-")
+	if err != nil {
+		return err
+	}
+	if err := ci.GenerateBrowseSite(summary, dir, ci.DefaultPageSize); err != nil {
+		os.RemoveAll(dir)
+		return err
+	}
+
+	u.mu.Lock()
+	old := u.dir
+	u.dir, u.handler, u.refs = dir, http.FileServer(http.Dir(dir)), refs
+	u.mu.Unlock()
+	if old != "" {
+		os.RemoveAll(old)
+	}
+	return nil
+}
+
+func (u *serveUI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	u.mu.Lock()
+	handler := u.handler
+	u.mu.Unlock()
+	handler.ServeHTTP(w, r)
+}
+
+// close removes the site's files
+func (u *serveUI) close() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.dir != "" {
+		os.RemoveAll(u.dir)
+		u.dir = ""
+	}
+}
+
+// serveMetrics holds the latest metrics snapshot for /metrics
+type serveMetrics struct {
+	collector *repometrics.Collector
+
+	mu       sync.Mutex
+	snapshot *repometrics.Snapshot
+	errors   int
+}
+
+// collect replaces the snapshot. On failure the previous one is kept and
+// the error counted.
+func (m *serveMetrics) collect() error {
+	snapshot, err := m.collector.Collect()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err != nil {
+		m.errors++
+		return err
+	}
+	m.snapshot = snapshot
+	return nil
+}
+
+func (m *serveMetrics) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	snapshot, errors := m.snapshot, m.errors
+	m.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	snapshot.Render(w, errors)
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&serveAddr, "addr", "127.0.0.1:9464", "Address to listen on")
+	serveCmd.Flags().DurationVar(&serveInterval, "interval", time.Minute, "How often to collect metrics and check the notes for changes")
+	serveCmd.Flags().StringVar(&serveRange, "range", "HEAD", "Browse the noted commits reachable from this ref or range")
+	serveCmd.Flags().IntVarP(&serveMaxCount, "max-count", "n", 200, "Browse at most this many noted commits, newest first (0 for all)")
+	rootCmd.AddCommand(serveCmd)
+}
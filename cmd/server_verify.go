@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/hooks"
+	"github.com/spf13/cobra"
+)
+
+var serverVerifyCmd = &cobra.Command{
+	Use:   "server-verify",
+	Short: "Pre-receive check that pushed commits come with their notes",
+	Long: `Check a push on a self-hosted git server, for use as a pre-receive hook.
+
+Reads "<old-sha> <new-sha> <ref>" lines from stdin, as git passes them to
+pre-receive, and rejects the push if a new commit has a "Prompt-Story: Used"
+trailer but its note is neither in the push nor already on the server, or
+if a note points at a transcript that is missing.
+
+Install in the server's bare repository:
+
+  cat > hooks/pre-receive <<'HOOK'
+  #!/bin/sh
+  exec git-prompt-story server-verify
+  HOOK
+  chmod +x hooks/pre-receive`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := hooks.ServerVerify(os.Stdin, os.Stderr); err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(serverVerifyCmd)
+}
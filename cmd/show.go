@@ -3,6 +3,7 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"regexp"
 	"strings"
 	"time"
 
@@ -17,6 +18,13 @@ var (
 	noInteractiveFlag bool
 	clearSessionFlag  string
 	redactMessageFlag string
+	redactMatchFlag   string
+	quarantineFlag    string
+	showReasonFlag    string
+	restoreFlag       string
+	showOverrideHold  bool
+	showSizesFlag     bool
+	showView          string
 )
 
 var showCmd = &cobra.Command{
@@ -25,15 +33,37 @@ var showCmd = &cobra.Command{
 	Long: `Display LLM prompts and sessions attached to a commit or commit range.
 
 By default, opens an interactive TUI viewer when running in a terminal.
+In the viewer, / searches labels and message content as you type, n and N
+jump between matches and f hides everything that does not match. x copies
+the selected node and everything under it to the clipboard as markdown, X
+writes it to a prompt-story-<sha>.md file instead.
+Without a commit, a terminal first shows a picker of recent commits, marking
+those with prompts; select several with space, or press enter for the one
+under the cursor.
 Use --no-interactive for plain text output (useful for piping).
 Use --full to display complete message content.
+Use --sizes to list the stored size of each transcript and its largest
+entries, e.g. the tool outputs worth truncating.
+Use --redact-message to redact a message; with --match, only the text
+matching the regex is replaced and the rest of the message is kept.
+--reason secret|personal|irrelevant names why in the placeholder and the
+note's audit log, so summaries can count redactions by reason.
+Use --view to apply a saved set of flags, see pr summary --help.
 
 Examples:
-  git-prompt-story show                # Show prompts for HEAD
+  git-prompt-story show                # Pick commits (HEAD when not a terminal)
   git-prompt-story show abc123         # Show prompts for specific commit
-  git-prompt-story show HEAD~5..HEAD   # Show prompts for commit range`,
+  git-prompt-story show HEAD~5..HEAD   # Show prompts for commit range
+  git-prompt-story show abc123,def456  # Show prompts for a list of commits
+  git-prompt-story show --view review  # Apply the flags of the "review" view
+  git-prompt-story show --redact-message claude-code/abc-123@2025-01-15T10:00:00Z --match 'sk-[A-Za-z0-9]+' --reason secret`,
 	Args: cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
+		if err := applyView(cmd, showView); err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+
 		// Handle redaction flags (non-interactive operations)
 		if clearSessionFlag != "" {
 			if err := handleClearSession(clearSessionFlag); err != nil {
@@ -42,6 +72,10 @@ Examples:
 			}
 			return
 		}
+		if redactMatchFlag != "" && redactMessageFlag == "" {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: --match requires --redact-message\n")
+			os.Exit(1)
+		}
 		if redactMessageFlag != "" {
 			if err := handleRedactMessage(redactMessageFlag); err != nil {
 				fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
@@ -49,15 +83,47 @@ Examples:
 			}
 			return
 		}
+		if quarantineFlag != "" {
+			if err := handleQuarantineSession(quarantineFlag, showReasonFlag); err != nil {
+				fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+		if restoreFlag != "" {
+			if err := handleRestoreSession(restoreFlag); err != nil {
+				fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		// Determine if we should use interactive mode
+		isTTY := isatty.IsTerminal(os.Stdout.Fd()) || isatty.IsCygwinTerminal(os.Stdout.Fd())
+		useInteractive := (interactiveFlag || isTTY) && !noInteractiveFlag
 
 		commit := "HEAD"
 		if len(args) > 0 {
 			commit = args[0]
+		} else if isTTY && !noInteractiveFlag {
+			picked, err := show.PickCommits("Select commits to show (● has prompts)")
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+				os.Exit(1)
+			}
+			if picked == "" {
+				return
+			}
+			commit = picked
 		}
 
-		// Determine if we should use interactive mode
-		isTTY := isatty.IsTerminal(os.Stdout.Fd()) || isatty.IsCygwinTerminal(os.Stdout.Fd())
-		useInteractive := (interactiveFlag || isTTY) && !noInteractiveFlag
+		if showSizesFlag {
+			if err := show.ShowSizes(commit); err != nil {
+				fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
 
 		if useInteractive {
 			if err := show.RunTUI(commit, fullFlag); err != nil {
@@ -81,12 +147,12 @@ func handleClearSession(spec string) error {
 	}
 	tool, sessionID := parts[0], parts[1]
 
-	if err := show.DeleteSession(tool, sessionID); err != nil {
+	if err := show.DeleteSession(tool, sessionID, showReasonFlag, showOverrideHold); err != nil {
 		return err
 	}
 
 	if show.WasNotesPushed() {
-		fmt.Println("Session cleared. Force push needed: git push -f origin refs/notes/*")
+		fmt.Println("Session cleared. Force push needed: git push -f origin refs/notes/prompt-story refs/notes/prompt-story-transcripts")
 	} else {
 		fmt.Println("Session cleared")
 	}
@@ -116,23 +182,81 @@ func handleRedactMessage(spec string) error {
 		return fmt.Errorf("invalid timestamp: %s (expected RFC3339 format)", timestampStr)
 	}
 
-	if err := show.RedactMessage(tool, sessionID, timestamp); err != nil {
+	if redactMatchFlag != "" {
+		pattern, err := regexp.Compile(redactMatchFlag)
+		if err != nil {
+			return fmt.Errorf("invalid --match pattern: %w", err)
+		}
+		if err := show.RedactSpan(tool, sessionID, timestamp, pattern, showReasonFlag, showOverrideHold); err != nil {
+			return err
+		}
+	} else if err := show.RedactMessage(tool, sessionID, timestamp, showReasonFlag, showOverrideHold); err != nil {
 		return err
 	}
 
 	if show.WasNotesPushed() {
-		fmt.Println("Message redacted. Force push needed: git push -f origin refs/notes/*")
+		fmt.Println("Message redacted. Force push needed: git push -f origin refs/notes/prompt-story refs/notes/prompt-story-transcripts")
 	} else {
 		fmt.Println("Message redacted")
 	}
 	return nil
 }
 
+// handleQuarantineSession parses "tool/session-id" and quarantines the session
+func handleQuarantineSession(spec, reason string) error {
+	parts := strings.SplitN(spec, "/", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("invalid session spec: %s (expected tool/session-id)", spec)
+	}
+	tool, sessionID := parts[0], parts[1]
+
+	if reason == "" {
+		return fmt.Errorf("--reason is required when quarantining a session")
+	}
+
+	if err := show.QuarantineSession(tool, sessionID, reason, showOverrideHold); err != nil {
+		return err
+	}
+
+	fmt.Printf("Session quarantined. Restore with: git-prompt-story show --restore-session %s\n", spec)
+	if show.WasNotesPushed() {
+		fmt.Println("Force push needed: git push -f origin refs/notes/prompt-story refs/notes/prompt-story-transcripts")
+	}
+	return nil
+}
+
+// handleRestoreSession parses "tool/session-id" and restores a quarantined session
+func handleRestoreSession(spec string) error {
+	parts := strings.SplitN(spec, "/", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("invalid session spec: %s (expected tool/session-id)", spec)
+	}
+	tool, sessionID := parts[0], parts[1]
+
+	if err := show.RestoreSession(tool, sessionID); err != nil {
+		return err
+	}
+
+	if show.WasNotesPushed() {
+		fmt.Println("Session restored. Force push needed: git push -f origin refs/notes/prompt-story refs/notes/prompt-story-transcripts")
+	} else {
+		fmt.Println("Session restored")
+	}
+	return nil
+}
+
 func init() {
 	showCmd.Flags().BoolVar(&fullFlag, "full", false, "Show full message content")
 	showCmd.Flags().BoolVarP(&interactiveFlag, "interactive", "i", false, "Force interactive TUI mode")
 	showCmd.Flags().BoolVar(&noInteractiveFlag, "no-interactive", false, "Disable interactive TUI, use plain text output")
-	showCmd.Flags().StringVar(&clearSessionFlag, "clear-session", "", "Clear session content (format: tool/session-id)")
+	showCmd.Flags().StringVar(&clearSessionFlag, "clear-session", "", "Remove session content, leaving a tombstone (format: tool/session-id)")
 	showCmd.Flags().StringVar(&redactMessageFlag, "redact-message", "", "Redact message (format: tool/session-id@timestamp)")
+	showCmd.Flags().StringVar(&redactMatchFlag, "match", "", "With --redact-message, redact only the text and tool input matching this regex")
+	showCmd.Flags().StringVar(&quarantineFlag, "quarantine-session", "", "Move session to local quarantine, leaving a stub (format: tool/session-id)")
+	showCmd.Flags().StringVar(&showReasonFlag, "reason", "", "Reason recorded in the quarantine stub or session tombstone, or the reason code of a redaction (secret, personal, irrelevant)")
+	showCmd.Flags().StringVar(&restoreFlag, "restore-session", "", "Restore a quarantined session (format: tool/session-id)")
+	showCmd.Flags().BoolVar(&showOverrideHold, "override-hold", false, "Modify a transcript even if it is on legal hold (recorded in the note)")
+	showCmd.Flags().BoolVar(&showSizesFlag, "sizes", false, "Show stored transcript sizes and the largest entries")
+	registerViewFlag(showCmd, &showView)
 	rootCmd.AddCommand(showCmd)
 }
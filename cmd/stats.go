@@ -0,0 +1,344 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/ci"
+	"github.com/QuesmaOrg/git-prompt-story/internal/coverage"
+	"github.com/QuesmaOrg/git-prompt-story/internal/display"
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+	"github.com/QuesmaOrg/git-prompt-story/internal/note"
+	"github.com/spf13/cobra"
+)
+
+var (
+	statsCoverage string
+	statsJSON     bool
+	statsCSV      bool
+	statsWeekly   bool
+	statsStorage  bool
+	statsPrivacy  ci.PrivacyOptions
+	statsSet      commitSetFlags
+)
+
+var statsCmd = &cobra.Command{
+	Use:   "stats [commit-range]",
+	Short: "Show metrics for the commits in a range",
+	Long: `Show how much of a range was made with LLM sessions: the share of commits
+with notes, user prompts per commit, the split between main and agent
+sessions, the average length of a main session, how often each tool was
+used, and the same counts per commit author and per language the sessions
+touched.
+
+The range defaults to the current branch since it forked from the default
+branch, see the branch command. --weekly adds a breakdown by the week of
+the author date. --json and --csv write every breakdown for dashboards;
+CSV rows are told apart by their kind column (total, author, week,
+language, tool). A session touching several languages counts for each.
+
+--storage adds how many bytes the range's transcripts take in the notes
+refs, which tools' calls and results take them, and the largest entries, so
+truncation can be configured where it matters. See show --sizes for one
+commit.
+
+--noise and --bucket blur the author and week counts so they can be shared
+org-wide. --noise adds Laplace noise for the given differential privacy
+epsilon (smaller is noisier), which protects any one commit: it is split
+over the 16 counts a commit adds to, each noised with epsilon/16 scaled to
+how much one commit may add to it (larger commits are clipped). --bucket
+rounds to a multiple, e.g. 5. The total becomes the sum of the blurred
+authors, and the language, tool and file edit counts are left out; they
+cannot be combined with --coverage or --storage.
+
+--in and --not compose ranges: commits of any --in spec (or the argument)
+that are not in a --not range, not reachable from refs matching a --not ref
+or glob, and not cherry-picked onto them. --stdin reads the specs one per
+line, ^spec for those to leave out.
+
+With --coverage, lines written by the sessions' Edit and Write tool calls
+that are still in the checked-out files are matched against a test coverage
+report, either a Go coverage profile (go test -coverprofile) or an lcov
+tracefile, and the share of them covered by tests is reported per commit
+and overall. Make the report from the checked-out tree; lines it does not
+measure (blank lines, comments, declarations) are left out of the share.
+
+Examples:
+  git-prompt-story stats origin/main..HEAD
+  git-prompt-story stats v1.2.0..HEAD --weekly
+  git-prompt-story stats v1.2.0..HEAD --csv > stats.csv
+  git-prompt-story stats v1.2.0..HEAD --json --noise 1 --bucket 5
+  git-prompt-story stats v1.2.0..HEAD --storage
+  git-prompt-story stats --in main@{2.weeks.ago}..main --not 'release/*'
+  go test -coverprofile=cover.out ./... && git-prompt-story stats v1.2.0..HEAD --coverage cover.out
+  git-prompt-story stats origin/main..HEAD --coverage coverage/lcov.info`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if statsJSON && statsCSV {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: --json and --csv cannot be combined\n")
+			os.Exit(1)
+		}
+		if statsPrivacy.Epsilon < 0 || statsPrivacy.Bucket < 0 {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: --noise and --bucket cannot be negative\n")
+			os.Exit(1)
+		}
+		if statsPrivacy.Enabled() && (statsCoverage != "" || statsStorage) {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: --coverage and --storage report exact counts, they cannot be combined with --noise or --bucket\n")
+			os.Exit(1)
+		}
+		if statsCSV && statsCoverage != "" {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: --coverage is not written as CSV, use --json\n")
+			os.Exit(1)
+		}
+		if statsCSV && statsStorage {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: --storage is not written as CSV, use --json\n")
+			os.Exit(1)
+		}
+		commits, err := statsSet.resolve(args, false, func() (string, error) { return branchRange(nil) })
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+
+		stats, summary, err := ci.ComputeStatsCommits(commits)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+		stats.Privatize(statsPrivacy)
+
+		var attribution *ci.CoverageAttribution
+		if statsCoverage != "" {
+			attribution, err = coverageAttribution(summary)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+				os.Exit(1)
+			}
+		}
+
+		var storage *ci.StorageStats
+		if statsStorage {
+			storage, err = ci.ComputeStorage(commits)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+				os.Exit(1)
+			}
+		}
+
+		switch {
+		case statsCSV:
+			err = stats.WriteCSV(os.Stdout)
+		case statsJSON:
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			var fileEdits *int
+			if !statsPrivacy.Enabled() {
+				fileEdits = &summary.TotalFileEdits
+			}
+			err = encoder.Encode(struct {
+				*ci.Stats
+				FileEdits *int                    `json:"file_edits,omitempty"`
+				Privacy   *privacyJSON            `json:"privacy,omitempty"`
+				Coverage  *ci.CoverageAttribution `json:"coverage,omitempty"`
+				Storage   *ci.StorageStats        `json:"storage,omitempty"`
+			}{stats, fileEdits, statsPrivacyJSON(), attribution, storage})
+		default:
+			printStats(stats, summary)
+			if attribution != nil {
+				printCoverage(attribution)
+			}
+			if storage != nil {
+				printStorage(storage)
+			}
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// printStats writes the text report of stats
+func printStats(stats *ci.Stats, summary *ci.Summary) {
+	t := stats.Total
+	fmt.Printf("Commits:      %d, %d with LLM sessions (%.1f%%)\n", t.Commits, t.CommitsWithNotes, t.NotedPercent())
+	fmt.Printf("User prompts: %d, %.1f per commit with sessions\n", t.UserPrompts, t.PromptsPerCommit())
+	fmt.Printf("Sessions:     %d main (%s on average), %d agent with %d prompt(s)\n",
+		t.Sessions, formatSessionLength(t.AverageSession()), t.AgentSessions, t.AgentPrompts)
+	if statsPrivacy.Enabled() {
+		fmt.Printf("Blurred:      %s; languages, tools and file edits left out\n", describePrivacy(statsPrivacy))
+	} else {
+		fmt.Printf("File edits:   %d\n", summary.TotalFileEdits)
+	}
+
+	if len(stats.Tools) > 0 {
+		fmt.Println("\nTool uses:")
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, name := range stats.ToolNames() {
+			fmt.Fprintf(w, "  %s\t%d\t%.1f%%\n", name, stats.Tools[name], 100*float64(stats.Tools[name])/float64(t.ToolUses))
+		}
+		w.Flush()
+	}
+
+	fmt.Println("\nBy author:")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  AUTHOR\tCOMMITS\tWITH SESSIONS\tPROMPTS\tPER COMMIT\tSESSIONS+AGENT\tAVG SESSION")
+	for _, a := range stats.Authors {
+		fmt.Fprintf(w, "  %s\t%s\n", a.Author, statsRow(a.StatsCounts))
+	}
+	w.Flush()
+
+	if len(stats.Languages) > 0 {
+		fmt.Println("\nBy language:")
+		w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "  LANGUAGE\tCOMMITS\tWITH SESSIONS\tPROMPTS\tPER COMMIT\tSESSIONS+AGENT\tAVG SESSION")
+		for _, l := range stats.Languages {
+			fmt.Fprintf(w, "  %s\t%s\n", l.Language, statsRow(l.StatsCounts))
+		}
+		w.Flush()
+	}
+
+	if !statsWeekly {
+		return
+	}
+	fmt.Println("\nBy week:")
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  WEEK OF\tCOMMITS\tWITH SESSIONS\tPROMPTS\tPER COMMIT\tSESSIONS+AGENT\tAVG SESSION")
+	for _, wk := range stats.Weeks {
+		fmt.Fprintf(w, "  %s\t%s\n", wk.Week, statsRow(wk.StatsCounts))
+	}
+	w.Flush()
+}
+
+// statsRow renders the columns of a breakdown table after its name
+func statsRow(c ci.StatsCounts) string {
+	return fmt.Sprintf("%d\t%d (%.0f%%)\t%d\t%.1f\t%d+%d\t%s", c.Commits, c.CommitsWithNotes, c.NotedPercent(),
+		c.UserPrompts, c.PromptsPerCommit(), c.Sessions, c.AgentSessions, formatSessionLength(c.AverageSession()))
+}
+
+// privacyJSON is how the counts of the JSON output were blurred
+type privacyJSON struct {
+	ci.PrivacyOptions
+	CountEpsilon float64 `json:"count_epsilon,omitempty"` // The share of Epsilon each count was noised with
+}
+
+// statsPrivacyJSON returns the --noise and --bucket options for the JSON
+// output, nil when the counts are exact
+func statsPrivacyJSON() *privacyJSON {
+	if !statsPrivacy.Enabled() {
+		return nil
+	}
+	return &privacyJSON{PrivacyOptions: statsPrivacy, CountEpsilon: statsPrivacy.CountEpsilon()}
+}
+
+// describePrivacy renders how the counts were blurred, e.g.
+// "noise ε=1 per commit (ε=0.0625 per count), rounded to 5"
+func describePrivacy(opts ci.PrivacyOptions) string {
+	var parts []string
+	if opts.Epsilon > 0 {
+		parts = append(parts, fmt.Sprintf("noise ε=%g per commit (ε=%g per count)", opts.Epsilon, opts.CountEpsilon()))
+	}
+	if opts.Bucket > 1 {
+		parts = append(parts, fmt.Sprintf("rounded to %d", opts.Bucket))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatSessionLength renders a session length to the minute, e.g. "1h05m"
+func formatSessionLength(d time.Duration) string {
+	d = d.Round(time.Minute)
+	if d < time.Hour {
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+	return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+}
+
+// printCoverage writes the --coverage report
+func printCoverage(attribution *ci.CoverageAttribution) {
+	fmt.Printf("\nTest coverage of AI-written lines (%s):\n", statsCoverage)
+	if len(attribution.Commits) == 0 {
+		fmt.Println("  No AI-written lines found")
+		return
+	}
+	for _, c := range attribution.Commits {
+		fmt.Printf("  %s  %-40s  %s\n", c.ShortSHA, display.TruncateText(c.Subject, 40), formatCoverageCounts(c.CoverageCounts))
+	}
+	fmt.Printf("  %-7s  %-40s  %s\n", "Total", "", formatCoverageCounts(attribution.Total))
+}
+
+// printStorage writes the --storage report
+func printStorage(storage *ci.StorageStats) {
+	fmt.Printf("\nStorage: %s in %d transcript(s), tool output %s", note.FormatBytes(storage.Bytes),
+		storage.Sessions, note.FormatBytes(storage.ToolOutput))
+	if storage.NotStored > 0 {
+		fmt.Printf(", %d not stored", storage.NotStored)
+	}
+	fmt.Println()
+	if storage.Bytes == 0 {
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, name := range storage.ToolNames() {
+		fmt.Fprintf(w, "  %s\t%s\t%.1f%%\n", name, note.FormatBytes(storage.Tools[name]),
+			100*float64(storage.Tools[name])/float64(storage.Bytes))
+	}
+	w.Flush()
+
+	fmt.Println("\nLargest entries:")
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, e := range storage.Largest {
+		label := e.Kind
+		if e.Tool != "" {
+			label += " " + e.Tool
+		}
+		fmt.Fprintf(w, "  %s\t%s\t#%d\t%s\t%s\n", e.SHA[:7], e.Session, e.Entry, label, note.FormatBytes(int64(e.Bytes)))
+	}
+	w.Flush()
+}
+
+// coverageAttribution matches the summary's AI-written lines against the
+// --coverage report
+func coverageAttribution(summary *ci.Summary) (*ci.CoverageAttribution, error) {
+	repoRoot, err := git.GetRepoRoot()
+	if err != nil {
+		return nil, fmt.Errorf("not in a git repository: %w", err)
+	}
+	cov, err := coverage.Load(statsCoverage, repoRoot)
+	if err != nil {
+		return nil, err
+	}
+	ai, err := ci.BuildAILines(summary)
+	if err != nil {
+		return nil, err
+	}
+	return ci.AttributeCoverage(summary, ai, cov, "HEAD")
+}
+
+// formatCoverageCounts renders e.g. "12/15 covered (80.0%), 3 not measured"
+func formatCoverageCounts(c ci.CoverageCounts) string {
+	if c.Instrumented == 0 {
+		return fmt.Sprintf("no measured lines, %d not measured", c.Lines)
+	}
+	s := fmt.Sprintf("%d/%d covered (%.1f%%)", c.Covered, c.Instrumented, c.Percent())
+	if notMeasured := c.Lines - c.Instrumented; notMeasured > 0 {
+		s += fmt.Sprintf(", %d not measured", notMeasured)
+	}
+	return s
+}
+
+func init() {
+	statsCmd.Flags().BoolVar(&statsJSON, "json", false, "Write the metrics as JSON")
+	statsCmd.Flags().BoolVar(&statsCSV, "csv", false, "Write the metrics as CSV")
+	statsCmd.Flags().BoolVar(&statsWeekly, "weekly", false, "Add a breakdown by week")
+	statsCmd.Flags().BoolVar(&statsStorage, "storage", false, "Add the stored transcript size by tool and the largest entries")
+	statsCmd.Flags().Float64Var(&statsPrivacy.Epsilon, "noise", 0, "Add Laplace noise with this privacy epsilon, per commit, to author and week counts")
+	statsCmd.Flags().IntVar(&statsPrivacy.Bucket, "bucket", 0, "Round author and week counts to a multiple of this")
+	statsSet.register(statsCmd)
+	statsCmd.Flags().StringVar(&statsCoverage, "coverage", "", "Coverage report (Go profile or lcov) to check AI-written lines against")
+	rootCmd.AddCommand(statsCmd)
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/config"
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+	"github.com/QuesmaOrg/git-prompt-story/internal/note"
+	"github.com/QuesmaOrg/git-prompt-story/internal/pause"
+	"github.com/QuesmaOrg/git-prompt-story/internal/session"
+	"github.com/spf13/cobra"
+)
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show whether and how sessions are captured",
+	Long: `Show the capture state of this repository: whether capture is paused,
+the configured scrubbing and tools, and how many commits have notes.
+
+Examples:
+  git-prompt-story status`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		state, err := pause.Current()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Capture:     %s\n", state)
+
+		fmt.Printf("Tools:       %s\n", capturedTools())
+		scrubbing := onOff(config.ScrubEnabled())
+		if locales := config.Get(config.KeyScrubLocales); locales != "" && config.ScrubEnabled() {
+			scrubbing += fmt.Sprintf(" (locales: %s)", locales)
+		}
+		if detectors := config.Get(config.KeyScrubDetectors); detectors != "" && config.ScrubEnabled() {
+			scrubbing += fmt.Sprintf(" (detectors: %s)", detectors)
+		}
+		fmt.Printf("Scrubbing:   %s\n", scrubbing)
+		fmt.Printf("Initialized: %s\n", yesNo(config.IsInitialized()))
+
+		noted, err := note.ListNoteBlobs(note.NotesRef)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Notes:       %d commit(s)\n", len(noted))
+	},
+}
+
+// onOff formats a boolean setting
+func onOff(b bool) string {
+	if b {
+		return "on"
+	}
+	return "off"
+}
+
+// yesNo formats a boolean fact
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+func init() {
+	rootCmd.AddCommand(statusCmd)
+}
+
+// capturedTools lists the tools captured in this repository, marking those
+// the commit hook asks about when their sessions are first found
+func capturedTools() string {
+	// Best effort: a broken adapter file only hides its tools
+	if repoRoot, err := git.GetRepoRoot(); err == nil {
+		session.LoadCustomProviders(repoRoot)
+	}
+
+	var tools []string
+	for _, p := range session.Providers() {
+		capture, decided := config.ToolCaptureConsent(p.Name())
+		switch {
+		case capture:
+			tools = append(tools, p.Name())
+		case !decided && p.Name() == session.ToolClaudeCode:
+			tools = append(tools, p.Name())
+		case !decided:
+			tools = append(tools, p.Name()+" (asked on first use)")
+		}
+	}
+	if len(tools) == 0 {
+		return "none"
+	}
+	return strings.Join(tools, ", ")
+}
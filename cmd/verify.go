@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+	"github.com/QuesmaOrg/git-prompt-story/internal/policy"
+	"github.com/spf13/cobra"
+)
+
+var verifyIntegrity bool
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify <commit-range>",
+	Short: "Check commits against the team policy",
+	Long: `Check commits in a range against the rules in .prompt-story-policy.yaml.
+
+Reports commits without a Prompt-Story trailer (require_capture) and notes
+that contain sessions from banned tools (banned_tools). Exits non-zero when
+any violation is found, so it can gate CI.
+
+With --integrity, each transcript is also checked against the hash chain
+sealed in the note at capture time. Redaction, clearing and quarantine
+re-seal the chain and log the change in the note; any other edit to a
+stored transcript is reported.
+
+Examples:
+  git-prompt-story verify origin/main..HEAD
+  git-prompt-story verify --integrity origin/main..HEAD`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		pol, err := loadPolicy()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+
+		violations, err := pol.Verify(args[0])
+		if err == nil && verifyIntegrity {
+			var tampered []policy.Violation
+			tampered, err = policy.VerifyIntegrity(args[0])
+			violations = append(violations, tampered...)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+
+		if len(violations) == 0 {
+			fmt.Println("All commits comply with the prompt-story policy")
+			return
+		}
+
+		fmt.Printf("%d policy violation(s):\n", len(violations))
+		for _, v := range violations {
+			fmt.Printf("  %s %s: %s\n", v.SHA[:7], v.Subject, v.Reason)
+		}
+		os.Exit(1)
+	},
+}
+
+// loadPolicy loads the team policy from the repository root, or from
+// HEAD in a bare repository
+func loadPolicy() (*policy.Policy, error) {
+	if git.IsBareRepository() {
+		return policy.LoadAt("HEAD")
+	}
+	repoRoot, err := git.GetRepoRoot()
+	if err != nil {
+		return nil, fmt.Errorf("not in a git repository: %w", err)
+	}
+	return policy.Load(repoRoot)
+}
+
+func init() {
+	verifyCmd.Flags().BoolVar(&verifyIntegrity, "integrity", false, "Also check transcripts against the hash chains sealed in notes")
+	rootCmd.AddCommand(verifyCmd)
+}
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// viewCommands are the commands taking --view; a view may set the flags of
+// any of them, each command applies those it has
+var viewCommands []*cobra.Command
+
+// viewFlags are the flags a view may set: those choosing what is shown and
+// how. Flags that change notes or transcripts, post to GitHub or write files
+// must be given on the command line.
+var viewFlags = map[string]bool{
+	"base":           true,
+	"estimate":       true,
+	"full":           true,
+	"interactive":    true,
+	"jobs":           true,
+	"no-interactive": true,
+	"pages-url":      true,
+	"renderer":       true,
+	"sample-budget":  true,
+	"sizes":          true,
+	"submodules":     true,
+	"verbose":        true,
+}
+
+// registerViewFlag adds --view to cmd
+func registerViewFlag(cmd *cobra.Command, view *string) {
+	cmd.Flags().StringVar(view, "view", "", "Apply the flags of this named view, see git config "+config.KeyViewPrefix+"<name>")
+	viewCommands = append(viewCommands, cmd)
+}
+
+// applyView sets the flags the named view configures on cmd, except those
+// given on the command line. An empty name applies nothing.
+func applyView(cmd *cobra.Command, name string) error {
+	if name == "" {
+		return nil
+	}
+	value := config.Get(config.KeyViewPrefix + name)
+	if value == "" {
+		return fmt.Errorf("no view %q (define it with: git config %s%s \"--flag ...\")", name, config.KeyViewPrefix, name)
+	}
+	settings, err := parseView(value)
+	if err != nil {
+		return fmt.Errorf("view %q: %w", name, err)
+	}
+	for _, s := range settings {
+		f := cmd.Flags().Lookup(s.name)
+		if f == nil || f.Changed {
+			continue // Another command's flag, or overridden
+		}
+		if err := cmd.Flags().Set(s.name, s.value); err != nil {
+			return fmt.Errorf("view %q: --%s: %w", name, s.name, err)
+		}
+	}
+	return nil
+}
+
+// viewSetting is a flag and its value in a view
+type viewSetting struct {
+	name, value string
+}
+
+// parseView parses a view's flags, separated by spaces: --name=value,
+// --name value, or --name for boolean flags. Each flag must be one of
+// viewFlags, of a command taking --view.
+func parseView(value string) ([]viewSetting, error) {
+	var settings []viewSetting
+	fields := strings.Fields(value)
+	for i := 0; i < len(fields); i++ {
+		arg := fields[i]
+		if !strings.HasPrefix(arg, "--") {
+			return nil, fmt.Errorf("%q is not a --flag", arg)
+		}
+		name, v, hasValue := strings.Cut(arg[2:], "=")
+		noOptValue, ok := lookupViewFlag(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown flag --%s", name)
+		}
+		if !viewFlags[name] {
+			return nil, fmt.Errorf("--%s cannot be set by a view", name)
+		}
+		if !hasValue {
+			switch {
+			case noOptValue != "":
+				v = noOptValue // Boolean flags
+			case i+1 < len(fields):
+				i++
+				v = fields[i]
+			default:
+				return nil, fmt.Errorf("--%s needs a value", name)
+			}
+		}
+		settings = append(settings, viewSetting{name, v})
+	}
+	return settings, nil
+}
+
+// lookupViewFlag reports whether a command taking --view has the named
+// flag, and the value the flag takes when given without one
+func lookupViewFlag(name string) (noOptValue string, ok bool) {
+	for _, cmd := range viewCommands {
+		if f := cmd.Flags().Lookup(name); f != nil {
+			return f.NoOptDefVal, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseView(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    []viewSetting
+		wantErr bool
+	}{
+		{
+			name:  "display flags",
+			value: "--full --renderer=jira --sample-budget 5",
+			want:  []viewSetting{{"full", "true"}, {"renderer", "jira"}, {"sample-budget", "5"}},
+		},
+		{name: "empty", value: "  "},
+		{name: "not a flag", value: "full", wantErr: true},
+		{name: "unknown flag", value: "--no-such-flag", wantErr: true},
+		{name: "missing value", value: "--renderer", wantErr: true},
+		{name: "view", value: "--view=other", wantErr: true},
+		{name: "clear session", value: "--clear-session claude-code/abc", wantErr: true},
+		{name: "redact message", value: "--redact-message=claude-code/abc@2025-01-15T10:00:00Z", wantErr: true},
+		{name: "quarantine session", value: "--quarantine-session claude-code/abc", wantErr: true},
+		{name: "restore session", value: "--restore-session claude-code/abc", wantErr: true},
+		{name: "override hold", value: "--full --override-hold", wantErr: true},
+		{name: "fill PR", value: "--fill-pr 12", wantErr: true},
+		{name: "output file", value: "--output=summary.md", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseView(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseView(%q) = %+v, want an error", tt.value, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseView(%q) error: %v", tt.value, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseView(%q) = %+v, want %+v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestViewFlagsExist(t *testing.T) {
+	for name := range viewFlags {
+		if _, ok := lookupViewFlag(name); !ok {
+			t.Errorf("view flag --%s is not a flag of a command taking --view", name)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/ci"
+	"github.com/QuesmaOrg/git-prompt-story/internal/show"
+	"github.com/spf13/cobra"
+)
+
+var (
+	whyTUI  bool
+	whyFull bool
+)
+
+var whyCmd = &cobra.Command{
+	Use:   "why <file>:<line>",
+	Short: "Show the prompt behind a line of code",
+	Long: `Show which captured prompt and tool call produced a line of a file.
+
+The line is blamed at HEAD and the story of the commit it comes from is
+searched for the step closest to it: the last Edit or Write tool call that
+wrote the line's text, else the last edit of the file in the commit, else
+the last prompt of the commit. Changes made by shell commands are not
+attributed to a tool call.
+
+With --tui the viewer opens on the commit with the cursor on that step.
+Editors can bind the command to a key, passing the current file and line.
+
+Examples:
+  git-prompt-story why internal/ci/summary.go:120
+  git-prompt-story why --tui src/app.go:42`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		path, line, err := parseFileLine(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+		origin, err := ci.FindLineOrigin(path, line, whyFull)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+
+		if !whyTUI || !origin.HasNote {
+			fmt.Print(ci.RenderLineOrigin(origin))
+			return
+		}
+		if err := show.RunTUIAt(origin.SHA, whyFull, origin.StepID()); err != nil {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// parseFileLine splits "<file>:<line>"
+func parseFileLine(arg string) (string, int, error) {
+	i := strings.LastIndex(arg, ":")
+	if i <= 0 {
+		return "", 0, fmt.Errorf("invalid location %q (expected <file>:<line>)", arg)
+	}
+	line, err := strconv.Atoi(arg[i+1:])
+	if err != nil || line < 1 {
+		return "", 0, fmt.Errorf("invalid line number in %q", arg)
+	}
+	return arg[:i], line, nil
+}
+
+func init() {
+	whyCmd.Flags().BoolVar(&whyTUI, "tui", false, "Open the interactive viewer focused on the step")
+	whyCmd.Flags().BoolVar(&whyFull, "full", false, "Do not truncate long prompts")
+	rootCmd.AddCommand(whyCmd)
+}
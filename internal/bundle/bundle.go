@@ -0,0 +1,331 @@
+// Package bundle moves commits together with their prompt-story notes and
+// transcripts through git bundle files, for environments without access
+// to the origin remote.
+package bundle
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+	"github.com/QuesmaOrg/git-prompt-story/internal/note"
+)
+
+// Refs under which a bundle carries prompt-story data. They hold only the
+// notes of the bundled commits and the transcripts those notes reference,
+// never the full notes history. They live under refs/notes/ because git
+// notes only reads notes from there.
+const (
+	notesRef       = "refs/notes/prompt-story-bundle"
+	transcriptsRef = "refs/notes/prompt-story-bundle-transcripts"
+
+	incomingNotesRef       = "refs/notes/prompt-story-incoming"
+	incomingTranscriptsRef = "refs/notes/prompt-story-incoming-transcripts"
+)
+
+// Contents describes the prompt-story data in a bundle
+type Contents struct {
+	Heads       []string // other refs in the bundle, i.e. the commits
+	Notes       int
+	Transcripts int
+	Merged      int      // apply: notes merged into existing ones
+	Kept        int      // apply: transcripts already present locally
+	Problems    []string // verify: notes whose transcripts do not check out
+}
+
+// Create writes a bundle of the commits in rangeSpec (anything git bundle
+// accepts, e.g. main..feature) with their notes and transcripts to file
+func Create(file, rangeSpec string) (*Contents, error) {
+	if strings.Contains(rangeSpec, ",") {
+		return nil, fmt.Errorf("bundle needs a range or ref, not a commit list: %s", rangeSpec)
+	}
+	commits, err := git.ResolveCommitSpec(rangeSpec)
+	if err != nil {
+		return nil, err
+	}
+	noted, err := note.ListNoteBlobs(note.NotesRef)
+	if err != nil {
+		return nil, err
+	}
+
+	defer deleteRefs(notesRef, transcriptsRef)
+	deleteRefs(notesRef, transcriptsRef)
+
+	contents := &Contents{}
+	transcripts := make(map[string]string) // path -> blob SHA
+	for _, sha := range commits {
+		blob, ok := noted[sha]
+		if !ok {
+			continue
+		}
+		if err := git.AddNoteFromBlob(notesRef, blob, sha); err != nil {
+			return nil, err
+		}
+		contents.Notes++
+
+		psNote, _, err := note.LoadNote(sha)
+		if err != nil {
+			continue
+		}
+		for _, s := range psNote.Sessions {
+			p := s.TranscriptPath()
+			if _, ok := transcripts[p]; ok || s.Expired != nil {
+				continue
+			}
+			if out, err := git.RunGit("rev-parse", "--verify", "--quiet", note.TranscriptsRef+":"+p); err == nil {
+				transcripts[p] = strings.TrimSpace(out)
+			}
+		}
+	}
+	if contents.Notes == 0 {
+		return nil, fmt.Errorf("no prompt-story notes in %s", rangeSpec)
+	}
+	contents.Transcripts = len(transcripts)
+
+	args := []string{"bundle", "create", "--quiet", file, rangeSpec, notesRef}
+	if len(transcripts) > 0 {
+		tree, err := buildTree(transcripts)
+		if err != nil {
+			return nil, err
+		}
+		if err := git.UpdateRef(transcriptsRef, tree); err != nil {
+			return nil, err
+		}
+		args = append(args, transcriptsRef)
+	}
+	if _, err := git.RunGit(args...); err != nil {
+		return nil, fmt.Errorf("git bundle create: %w", err)
+	}
+	return contents, nil
+}
+
+// Verify checks that file is a valid bundle whose prerequisites exist
+// here, and that every bundled transcript matches the hash chain sealed
+// in its note. The repository is left unchanged, apart from unreachable
+// objects.
+func Verify(file string) (*Contents, error) {
+	contents, err := fetch(file)
+	defer deleteRefs(incomingNotesRef, incomingTranscriptsRef)
+	if err != nil {
+		return nil, err
+	}
+
+	noted, err := note.ListNoteBlobs(incomingNotesRef)
+	if err != nil {
+		return nil, err
+	}
+	for sha, blob := range noted {
+		data, err := git.ReadBlob(blob)
+		if err != nil {
+			return nil, err
+		}
+		psNote, _, err := note.ParseAnyNote(data)
+		if err != nil {
+			contents.Problems = append(contents.Problems, fmt.Sprintf("%s: unparseable note", sha[:7]))
+			continue
+		}
+		for _, s := range psNote.Sessions {
+			if problem := note.VerifyChainAt(incomingTranscriptsRef, s); problem != "" {
+				contents.Problems = append(contents.Problems, fmt.Sprintf("%s %s: %s", sha[:7], s.TranscriptPath(), problem))
+			}
+		}
+	}
+	sort.Strings(contents.Problems)
+	return contents, nil
+}
+
+// Apply imports the notes and transcripts of a bundle. Notes for commits
+// that already have one are merged; transcripts already present are kept.
+// The bundled commits themselves are fetched with plain git fetch. When a
+// step fails, e.g. git notes add without a git identity, the notes refs
+// are restored, so nothing is half imported.
+func Apply(file string) (*Contents, error) {
+	contents, err := fetch(file)
+	defer deleteRefs(incomingNotesRef, incomingTranscriptsRef)
+	if err != nil {
+		return nil, err
+	}
+
+	saved := saveRefs(note.NotesRef, note.TranscriptsRef)
+	if err := apply(contents); err != nil {
+		restoreRefs(saved)
+		return nil, err
+	}
+	return contents, nil
+}
+
+// apply imports the fetched incoming refs, counting into contents
+func apply(contents *Contents) error {
+	noted, err := note.ListNoteBlobs(incomingNotesRef)
+	if err != nil {
+		return err
+	}
+	// Transcripts first, so imported notes never point at missing data
+	if tree, _ := git.GetRef(incomingTranscriptsRef); tree != "" {
+		incoming, err := readTree(tree, "")
+		if err != nil {
+			return err
+		}
+		missing := make(map[string]string)
+		for p, blob := range incoming {
+			if _, err := git.RunGit("rev-parse", "--verify", "--quiet", note.TranscriptsRef+":"+p); err == nil {
+				contents.Kept++
+				continue
+			}
+			missing[p] = blob
+		}
+		if len(missing) > 0 {
+			if err := note.UpdateTranscriptTree(missing); err != nil {
+				return err
+			}
+		}
+	}
+
+	for sha, blob := range noted {
+		existing, _, err := note.LoadNote(sha)
+		if err != nil {
+			if err := git.AddNoteFromBlob(note.NotesRef, blob, sha); err != nil {
+				return err
+			}
+			continue
+		}
+		data, err := git.ReadBlob(blob)
+		if err != nil {
+			return err
+		}
+		imported, _, err := note.ParseAnyNote(data)
+		if err != nil {
+			return fmt.Errorf("bundled note for %s: %w", sha[:7], err)
+		}
+		merged, err := note.MergeNotes([]*note.PromptStoryNote{existing, imported}).ToJSON()
+		if err != nil {
+			return err
+		}
+		if err := git.AddNote(note.NotesRef, string(merged), sha); err != nil {
+			return err
+		}
+		contents.Merged++
+	}
+	return nil
+}
+
+// fetch checks the bundle and fetches its prompt-story refs under
+// the incoming refs, describing what it holds
+func fetch(file string) (*Contents, error) {
+	if _, err := git.RunGit("bundle", "verify", "--quiet", file); err != nil {
+		return nil, fmt.Errorf("bundle %s cannot be used here (missing prerequisite commits?): %w", file, err)
+	}
+	heads, err := git.RunGit("bundle", "list-heads", file)
+	if err != nil {
+		return nil, fmt.Errorf("git bundle list-heads: %w", err)
+	}
+
+	contents := &Contents{}
+	refspecs := []string{}
+	for _, line := range strings.Split(heads, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		switch fields[1] {
+		case notesRef:
+			refspecs = append(refspecs, "+"+notesRef+":"+incomingNotesRef)
+		case transcriptsRef:
+			refspecs = append(refspecs, "+"+transcriptsRef+":"+incomingTranscriptsRef)
+		default:
+			contents.Heads = append(contents.Heads, fields[1])
+		}
+	}
+	if len(refspecs) == 0 {
+		return nil, fmt.Errorf("%s holds no prompt-story data", file)
+	}
+
+	args := append([]string{"fetch", "--quiet", "--no-tags", file}, refspecs...)
+	if _, err := git.RunGit(args...); err != nil {
+		return nil, fmt.Errorf("git fetch %s: %w", file, err)
+	}
+
+	if noted, err := note.ListNoteBlobs(incomingNotesRef); err == nil {
+		contents.Notes = len(noted)
+	}
+	if tree, _ := git.GetRef(incomingTranscriptsRef); tree != "" {
+		if blobs, err := readTree(tree, ""); err == nil {
+			contents.Transcripts = len(blobs)
+		}
+	}
+	return contents, nil
+}
+
+// buildTree creates a transcript tree (tool/id.jsonl) holding blobs
+func buildTree(blobs map[string]string) (string, error) {
+	byTool := make(map[string][]git.TreeEntry)
+	for p, sha := range blobs {
+		tool, name := path.Split(p)
+		tool = strings.TrimSuffix(tool, "/")
+		byTool[tool] = append(byTool[tool], git.TreeEntry{Mode: "100644", Type: "blob", SHA: sha, Name: name})
+	}
+
+	var root []git.TreeEntry
+	for tool, entries := range byTool {
+		sha, err := git.CreateTree(entries)
+		if err != nil {
+			return "", err
+		}
+		root = append(root, git.TreeEntry{Mode: "040000", Type: "tree", SHA: sha, Name: tool})
+	}
+	return git.CreateTree(root)
+}
+
+// readTree lists the blobs under a tree as path -> blob SHA
+func readTree(tree, prefix string) (map[string]string, error) {
+	entries, err := git.ReadTree(tree)
+	if err != nil {
+		return nil, err
+	}
+	blobs := make(map[string]string)
+	for _, e := range entries {
+		if e.Type == "tree" {
+			sub, err := readTree(e.SHA, prefix+e.Name+"/")
+			if err != nil {
+				return nil, err
+			}
+			for p, sha := range sub {
+				blobs[p] = sha
+			}
+			continue
+		}
+		blobs[prefix+e.Name] = e.SHA
+	}
+	return blobs, nil
+}
+
+// saveRefs returns the commit or tree each ref points at, "" for refs that
+// do not exist, for restoreRefs
+func saveRefs(refs ...string) map[string]string {
+	saved := make(map[string]string, len(refs))
+	for _, ref := range refs {
+		saved[ref], _ = git.GetRef(ref)
+	}
+	return saved
+}
+
+// restoreRefs points refs back to what saveRefs found, deleting the ones
+// that did not exist
+func restoreRefs(saved map[string]string) {
+	for ref, sha := range saved {
+		if sha == "" {
+			deleteRefs(ref)
+			continue
+		}
+		git.UpdateRef(ref, sha)
+	}
+}
+
+// deleteRefs removes temporary refs, ignoring ones that do not exist
+func deleteRefs(refs ...string) {
+	for _, ref := range refs {
+		git.RunGit("update-ref", "-d", ref)
+	}
+}
@@ -0,0 +1,203 @@
+package bundle
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+	"github.com/QuesmaOrg/git-prompt-story/internal/note"
+)
+
+// testRepo is a repository made by a test
+type testRepo struct {
+	t   *testing.T
+	dir string
+}
+
+func (r testRepo) run(args ...string) string {
+	r.t.Helper()
+	out, err := git.RunGit(append([]string{"-C", r.dir}, args...)...)
+	if err != nil {
+		r.t.Fatalf("git %v: %v", args, err)
+	}
+	return out
+}
+
+// enter makes r the repository the bundle functions work in
+func (r testRepo) enter() { r.t.Chdir(r.dir) }
+
+// setUp makes a source repository with a noted commit on feature, and the
+// bundle of main..feature
+func setUp(t *testing.T) (src testRepo, sha, bundleFile string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	for _, kv := range []string{"GIT_AUTHOR_NAME=A", "GIT_AUTHOR_EMAIL=a@example.com", "GIT_COMMITTER_NAME=A", "GIT_COMMITTER_EMAIL=a@example.com"} {
+		k, v, _ := strings.Cut(kv, "=")
+		t.Setenv(k, v)
+	}
+
+	src = testRepo{t, t.TempDir()}
+	src.enter()
+	src.run("init", "-q", "-b", "main")
+	src.run("commit", "-q", "--allow-empty", "-m", "base")
+	src.run("checkout", "-q", "-b", "feature")
+	src.run("commit", "-q", "--allow-empty", "-m", "feature\n\nPrompt-Story: Used Claude Code")
+	sha = src.run("rev-parse", "HEAD")
+	writeNote(t, sha, "s1", "{\"type\":\"user\",\"message\":{\"content\":\"hello\"}}\n")
+
+	bundleFile = filepath.Join(t.TempDir(), "feature.bundle")
+	contents, err := Create(bundleFile, "main..feature")
+	if err != nil {
+		t.Fatalf("Create() error: %v", err)
+	}
+	if contents.Notes != 1 || contents.Transcripts != 1 {
+		t.Errorf("Create() = %+v, want 1 note and 1 transcript", contents)
+	}
+	return src, sha, bundleFile
+}
+
+// writeNote stores a transcript for session id and a note on sha sealing it
+func writeNote(t *testing.T, sha, id, transcript string) {
+	t.Helper()
+	blob, err := git.HashObject([]byte(transcript))
+	if err != nil {
+		t.Fatal(err)
+	}
+	blobs := map[string]string{note.GetTranscriptPath("claude-code", id): blob}
+	if err := note.UpdateTranscriptTree(blobs); err != nil {
+		t.Fatal(err)
+	}
+	psNote := &note.PromptStoryNote{Version: 1, Sessions: []note.SessionEntry{{Tool: "claude-code", ID: id}}}
+	if err := psNote.SealTranscripts(blobs); err != nil {
+		t.Fatal(err)
+	}
+	data, err := psNote.ToJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := git.AddNote(note.NotesRef, string(data), sha); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// clone makes a repository with main of src, and enters it
+func clone(t *testing.T, src testRepo) testRepo {
+	t.Helper()
+	dst := testRepo{t, t.TempDir()}
+	src.run("clone", "-q", "--single-branch", "-b", "main", src.dir, dst.dir)
+	dst.enter()
+	return dst
+}
+
+func TestRoundTrip(t *testing.T) {
+	src, sha, bundleFile := setUp(t)
+	dst := clone(t, src)
+
+	contents, err := Verify(bundleFile)
+	if err != nil {
+		t.Fatalf("Verify() error: %v", err)
+	}
+	if contents.Notes != 1 || contents.Transcripts != 1 || len(contents.Problems) != 0 {
+		t.Errorf("Verify() = %+v", contents)
+	}
+	if !slices.Contains(contents.Heads, "refs/heads/feature") {
+		t.Errorf("Verify() heads = %v, want refs/heads/feature", contents.Heads)
+	}
+	if ref, _ := git.GetRef(note.NotesRef); ref != "" {
+		t.Error("Verify() imported notes")
+	}
+
+	dst.run("fetch", "-q", bundleFile, "refs/heads/feature:refs/heads/feature")
+	if contents, err = Apply(bundleFile); err != nil {
+		t.Fatalf("Apply() error: %v", err)
+	}
+	if contents.Notes != 1 || contents.Merged != 0 || contents.Kept != 0 {
+		t.Errorf("Apply() = %+v", contents)
+	}
+	psNote, _, err := note.LoadNote(sha)
+	if err != nil || len(psNote.Sessions) != 1 {
+		t.Fatalf("imported note = %+v, %v", psNote, err)
+	}
+	if problem := note.VerifyChain(psNote.Sessions[0]); problem != "" {
+		t.Errorf("imported transcript: %s", problem)
+	}
+	for _, ref := range []string{incomingNotesRef, incomingTranscriptsRef} {
+		if got, _ := git.GetRef(ref); got != "" {
+			t.Errorf("%s left behind", ref)
+		}
+	}
+
+	// Applying again merges into the note and keeps the transcript
+	if contents, err = Apply(bundleFile); err != nil {
+		t.Fatalf("second Apply() error: %v", err)
+	}
+	if contents.Merged != 1 || contents.Kept != 1 {
+		t.Errorf("second Apply() = %+v, want 1 merged and 1 kept", contents)
+	}
+}
+
+func TestVerify_Tampered(t *testing.T) {
+	src, _, _ := setUp(t)
+
+	// Change the stored transcript without resealing its note
+	blob, err := git.HashObject([]byte("{\"type\":\"user\",\"message\":{\"content\":\"edited\"}}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := note.UpdateTranscriptTree(map[string]string{note.GetTranscriptPath("claude-code", "s1"): blob}); err != nil {
+		t.Fatal(err)
+	}
+	tampered := filepath.Join(t.TempDir(), "tampered.bundle")
+	if _, err := Create(tampered, "main..feature"); err != nil {
+		t.Fatalf("Create() error: %v", err)
+	}
+
+	clone(t, src)
+	contents, err := Verify(tampered)
+	if err != nil {
+		t.Fatalf("Verify() error: %v", err)
+	}
+	if len(contents.Problems) != 1 || !strings.Contains(contents.Problems[0], "claude-code/s1.jsonl: transcript modified") {
+		t.Errorf("Verify() problems = %q", contents.Problems)
+	}
+}
+
+func TestVerify_MissingPrerequisites(t *testing.T) {
+	_, _, bundleFile := setUp(t)
+
+	other := testRepo{t, t.TempDir()}
+	other.enter()
+	other.run("init", "-q", "-b", "main")
+	if _, err := Verify(bundleFile); err == nil || !strings.Contains(err.Error(), "missing prerequisite") {
+		t.Errorf("Verify() without main's commits: err = %v", err)
+	}
+}
+
+func TestApply_RollsBack(t *testing.T) {
+	src, _, bundleFile := setUp(t)
+	dst := clone(t, src)
+	dst.run("fetch", "-q", bundleFile, "refs/heads/feature:refs/heads/feature")
+
+	// git notes add commits, which fails without an identity
+	t.Setenv("GIT_COMMITTER_NAME", "")
+	t.Setenv("GIT_COMMITTER_EMAIL", "")
+	t.Setenv("GIT_AUTHOR_NAME", "")
+	t.Setenv("GIT_AUTHOR_EMAIL", "")
+	dst.run("config", "user.useConfigOnly", "true")
+
+	if _, err := Apply(bundleFile); err == nil {
+		t.Fatal("Apply() without a git identity succeeded")
+	}
+	for _, ref := range []string{note.NotesRef, note.TranscriptsRef, incomingNotesRef, incomingTranscriptsRef} {
+		if got, _ := git.GetRef(ref); got != "" {
+			t.Errorf("%s left behind after a failed apply", ref)
+		}
+	}
+}
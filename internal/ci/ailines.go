@@ -0,0 +1,229 @@
+package ci
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/coverage"
+	"github.com/QuesmaOrg/git-prompt-story/internal/editor"
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+)
+
+// AILines maps commits to the lines their sessions wrote: per repository
+// path, the numbers (in the commit's version of the file) of lines the
+// commit added whose text an Edit or Write of its sessions produced
+type AILines map[string]map[string]map[int]bool
+
+// BuildAILines finds the lines each commit of summary added that came from
+// its sessions' Edit and Write tool calls. Lines are matched by their
+// trimmed text among the lines the commit's diff adds to the edited file.
+func BuildAILines(summary *Summary) (AILines, error) {
+	resolver := editor.NewResolver()
+	ai := make(AILines)
+	for _, cs := range summary.Commits {
+		if cs.Submodule != "" {
+			continue // Its files are in another repository
+		}
+		written := make(map[string]map[string]bool)
+		for _, sess := range cs.Sessions {
+			for _, p := range sess.Prompts {
+				if p.Written == "" || p.FilePath == "" {
+					continue
+				}
+				relPath := resolver.RelPath(cs.SHA, p.FilePath)
+				if relPath == "" {
+					continue
+				}
+				if written[relPath] == nil {
+					written[relPath] = make(map[string]bool)
+				}
+				for _, line := range strings.Split(p.Written, "\n") {
+					if line = strings.TrimSpace(line); line != "" {
+						written[relPath][line] = true
+					}
+				}
+			}
+		}
+
+		for relPath, texts := range written {
+			diff, err := git.RunGit("show", "--format=", "--unified=0", cs.SHA, "--", relPath)
+			if err != nil {
+				return nil, fmt.Errorf("git show %s -- %s: %w", cs.ShortSHA, relPath, err)
+			}
+			for _, added := range parseAddedLineTexts(diff) {
+				if !texts[strings.TrimSpace(added.text)] {
+					continue
+				}
+				if ai[cs.SHA] == nil {
+					ai[cs.SHA] = make(map[string]map[int]bool)
+				}
+				if ai[cs.SHA][relPath] == nil {
+					ai[cs.SHA][relPath] = make(map[int]bool)
+				}
+				ai[cs.SHA][relPath][added.line] = true
+			}
+		}
+	}
+	return ai, nil
+}
+
+// addedLine is a line added by a diff, numbered in the new file
+type addedLine struct {
+	line int
+	text string
+}
+
+// parseAddedLineTexts extracts the added lines of unified diff output
+func parseAddedLineTexts(diff string) []addedLine {
+	var added []addedLine
+	next := 0
+	for _, line := range strings.Split(diff, "\n") {
+		if m := hunkHeader.FindStringSubmatch(line); m != nil {
+			next, _ = strconv.Atoi(m[1])
+			continue
+		}
+		if next == 0 || strings.HasPrefix(line, "+++") {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(line, "+"):
+			added = append(added, addedLine{line: next, text: line[1:]})
+			next++
+		case strings.HasPrefix(line, " "):
+			next++
+		}
+	}
+	return added
+}
+
+// blameLine is the origin of a line of a blamed file
+type blameLine struct {
+	commit string
+	line   int // Line number in commit's version of the file
+}
+
+// blamePorcelainHeader matches the first line of a porcelain blame group:
+// "<sha> <original line> <final line> [<lines in group>]"
+var blamePorcelainHeader = regexp.MustCompile(`^([0-9a-f]{40,64}) (\d+) (\d+)`)
+
+// parseBlamePorcelain maps the final line numbers of git blame --porcelain
+// output to their origins
+func parseBlamePorcelain(out string) map[int]blameLine {
+	origins := make(map[int]blameLine)
+	for _, line := range strings.Split(out, "\n") {
+		m := blamePorcelainHeader.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		orig, _ := strconv.Atoi(m[2])
+		final, _ := strconv.Atoi(m[3])
+		origins[final] = blameLine{commit: m[1], line: orig}
+	}
+	return origins
+}
+
+// CoverageCounts counts AI-written lines by test coverage
+type CoverageCounts struct {
+	Lines        int `json:"lines"`        // AI-written lines still in the file
+	Instrumented int `json:"instrumented"` // Lines the coverage report measures
+	Covered      int `json:"covered"`
+}
+
+// Percent returns the share of instrumented lines that are covered
+func (c CoverageCounts) Percent() float64 {
+	if c.Instrumented == 0 {
+		return 0
+	}
+	return 100 * float64(c.Covered) / float64(c.Instrumented)
+}
+
+func (c *CoverageCounts) add(o CoverageCounts) {
+	c.Lines += o.Lines
+	c.Instrumented += o.Instrumented
+	c.Covered += o.Covered
+}
+
+// CommitCoverage is the coverage of one commit's AI-written lines
+type CommitCoverage struct {
+	SHA      string `json:"sha"`
+	ShortSHA string `json:"short_sha"`
+	Subject  string `json:"subject"`
+	CoverageCounts
+}
+
+// CoverageAttribution is the coverage of the AI-written lines of a range
+type CoverageAttribution struct {
+	Commits []CommitCoverage `json:"commits"`
+	Total   CoverageCounts   `json:"total"`
+}
+
+// AttributeCoverage blames the files ai touched at rev, the revision the
+// coverage report was made from, and counts how many of the AI-written
+// lines still there the report covers. Lines rewritten since no longer
+// count for their commit.
+func AttributeCoverage(summary *Summary, ai AILines, cov coverage.Lines, rev string) (*CoverageAttribution, error) {
+	paths := make(map[string]bool)
+	for _, files := range ai {
+		for path := range files {
+			paths[path] = true
+		}
+	}
+
+	blames := make(map[string]map[int]blameLine)
+	for path := range paths {
+		out, err := git.RunGit("blame", "--porcelain", rev, "--", path)
+		if err != nil {
+			continue // Deleted or renamed since
+		}
+		blames[path] = parseBlamePorcelain(out)
+	}
+	return attributeCoverage(summary, ai, cov, blames), nil
+}
+
+// attributeCoverage counts the covered AI-written lines given the blame
+// of each file
+func attributeCoverage(summary *Summary, ai AILines, cov coverage.Lines, blames map[string]map[int]blameLine) *CoverageAttribution {
+	perCommit := make(map[string]*CoverageCounts)
+	paths := make([]string, 0, len(blames))
+	for path := range blames {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		for final, origin := range blames[path] {
+			if !ai[origin.commit][path][origin.line] {
+				continue
+			}
+			counts := perCommit[origin.commit]
+			if counts == nil {
+				counts = &CoverageCounts{}
+				perCommit[origin.commit] = counts
+			}
+			counts.Lines++
+			if covered, instrumented := cov.Covered(path, final); instrumented {
+				counts.Instrumented++
+				if covered {
+					counts.Covered++
+				}
+			}
+		}
+	}
+
+	result := &CoverageAttribution{}
+	for _, cs := range summary.Commits {
+		if _, ok := ai[cs.SHA]; !ok {
+			continue
+		}
+		c := CommitCoverage{SHA: cs.SHA, ShortSHA: cs.ShortSHA, Subject: cs.Subject}
+		if counts := perCommit[cs.SHA]; counts != nil {
+			c.CoverageCounts = *counts
+		}
+		result.Commits = append(result.Commits, c)
+		result.Total.add(c.CoverageCounts)
+	}
+	return result
+}
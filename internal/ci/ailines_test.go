@@ -0,0 +1,83 @@
+package ci
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/coverage"
+)
+
+func TestParseAddedLineTexts(t *testing.T) {
+	diff := `diff --git a/main.go b/main.go
+--- a/main.go
++++ b/main.go
+@@ -3,0 +4,2 @@ package main
++func main() {
++}
+@@ -10 +12 @@ func helper() {
+-	return 1
++	return 2
+`
+	got := parseAddedLineTexts(diff)
+	want := []addedLine{{4, "func main() {"}, {5, "}"}, {12, "\treturn 2"}}
+	if len(got) != len(want) {
+		t.Fatalf("parseAddedLineTexts() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("line %d: got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestParseBlamePorcelain(t *testing.T) {
+	a := strings.Repeat("a", 40)
+	b := strings.Repeat("b", 40)
+	out := a + ` 1 1 2
+author Jane
+filename main.go
+	package main
+` + a + ` 2 2
+	
+` + b + ` 4 3 1
+author Joe
+filename main.go
+	func main() {}
+`
+	got := parseBlamePorcelain(out)
+	if len(got) != 3 {
+		t.Fatalf("Expected 3 lines, got %+v", got)
+	}
+	if got[3] != (blameLine{commit: b, line: 4}) {
+		t.Errorf("Line 3: got %+v", got[3])
+	}
+}
+
+func TestAttributeCoverage(t *testing.T) {
+	a := strings.Repeat("a", 40)
+	b := strings.Repeat("b", 40)
+	summary := &Summary{Commits: []CommitSummary{
+		{SHA: a, ShortSHA: "aaaaaaa", Subject: "Add server"},
+		{SHA: b, ShortSHA: "bbbbbbb", Subject: "Fix typo"},
+	}}
+	ai := AILines{a: {"server.go": {10: true, 11: true, 12: true, 13: true}}}
+	blames := map[string]map[int]blameLine{"server.go": {
+		20: {a, 10},
+		21: {a, 11},
+		22: {a, 12},
+		23: {b, 1}, // a's line 13 was rewritten by b
+	}}
+	cov := coverage.Lines{"server.go": {20: true, 21: false}}
+
+	got := attributeCoverage(summary, ai, cov, blames)
+	if len(got.Commits) != 1 || got.Commits[0].ShortSHA != "aaaaaaa" {
+		t.Fatalf("Expected only the commit with AI lines, got %+v", got.Commits)
+	}
+	want := CoverageCounts{Lines: 3, Instrumented: 2, Covered: 1}
+	if got.Total != want || got.Commits[0].CoverageCounts != want {
+		t.Errorf("Got %+v, want %+v", got.Total, want)
+	}
+	if got.Total.Percent() != 50 {
+		t.Errorf("Percent() = %v, want 50", got.Total.Percent())
+	}
+}
@@ -0,0 +1,201 @@
+package ci
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/display"
+	"github.com/QuesmaOrg/git-prompt-story/internal/editor"
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+	"github.com/QuesmaOrg/git-prompt-story/internal/note"
+)
+
+// maxAnnotationPromptLength truncates the prompt quoted in a review comment
+const maxAnnotationPromptLength = 500
+
+// annotationMarker starts the hidden HTML comment that ends each rendered
+// annotation, so a re-run can tell which regions it already commented on
+const annotationMarker = "<!-- git-prompt-story:annotation "
+
+// Annotation ties a line in the PR diff to the prompt that produced it
+type Annotation struct {
+	Path      string // Path relative to the repository root
+	Line      int    // Line in the head version of the file
+	CommitSHA string // Commit whose session made the edit
+	Tool      string // LLM tool (e.g. claude-code)
+	ToolName  string // Edit or Write
+	Prompt    string // User prompt that led to the edit
+}
+
+// LineRange is an inclusive range of line numbers
+type LineRange struct {
+	Start int
+	End   int
+}
+
+// BuildAnnotations finds the Edit/Write tool calls in summary, locates the
+// lines they produced in headSHA and keeps those that are added lines in
+// the baseSHA...headSHA diff, one per file line
+func BuildAnnotations(summary *Summary, baseSHA, headSHA string) ([]Annotation, error) {
+	resolver := editor.NewResolver()
+	addedByPath := make(map[string][]LineRange)
+	seen := make(map[string]bool)
+
+	var annotations []Annotation
+	for _, cs := range summary.Commits {
+		for _, sess := range cs.Sessions {
+			lastPrompt := ""
+			for _, p := range sess.Prompts {
+				if p.Type == "PROMPT" {
+					lastPrompt = p.Text
+					continue
+				}
+				if p.Type != "TOOL_USE" || (p.ToolName != "Edit" && p.ToolName != "Write") || p.FilePath == "" || lastPrompt == "" {
+					continue
+				}
+
+				relPath := resolver.RelPath(headSHA, p.FilePath)
+				if relPath == "" {
+					continue
+				}
+
+				added, ok := addedByPath[relPath]
+				if !ok {
+					var err error
+					added, err = addedLines(baseSHA, headSHA, relPath)
+					if err != nil {
+						return nil, err
+					}
+					addedByPath[relPath] = added
+				}
+				if len(added) == 0 {
+					continue
+				}
+
+				// Edits are located by their text; whole-file writes point
+				// at the first line the PR adds to the file
+				line := 0
+				if p.FileAnchor != "" {
+					line = resolver.Line(headSHA, relPath, p.FileAnchor)
+				} else if p.ToolName == "Write" {
+					line = added[0].Start
+				}
+				if line == 0 || !inRanges(added, line) {
+					continue
+				}
+
+				key := fmt.Sprintf("%s:%d", relPath, line)
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+
+				annotations = append(annotations, Annotation{
+					Path:      relPath,
+					Line:      line,
+					CommitSHA: cs.SHA,
+					Tool:      sess.Tool,
+					ToolName:  p.ToolName,
+					Prompt:    lastPrompt,
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(annotations, func(i, j int) bool {
+		if annotations[i].Path != annotations[j].Path {
+			return annotations[i].Path < annotations[j].Path
+		}
+		return annotations[i].Line < annotations[j].Line
+	})
+	return annotations, nil
+}
+
+// RenderAnnotation formats the review comment body for an annotation
+func RenderAnnotation(a Annotation, pagesURL string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s %s via %s, prompted by:\n\n", display.GetTypeEmoji("TOOL_USE"), a.ToolName, note.FormatToolName(a.Tool))
+	for _, line := range strings.Split(display.TruncateText(a.Prompt, maxAnnotationPromptLength), "\n") {
+		fmt.Fprintf(&sb, "> %s\n", line)
+	}
+	sb.WriteString("\n")
+	if pagesURL != "" {
+		fmt.Fprintf(&sb, "<sub>From commit %s · [full transcript](%s/%s.html) · git-prompt-story</sub>\n",
+			a.CommitSHA[:7], strings.TrimSuffix(pagesURL, "/"), a.CommitSHA[:7])
+	} else {
+		fmt.Fprintf(&sb, "<sub>From commit %s · git-prompt-story</sub>\n", a.CommitSHA[:7])
+	}
+	fmt.Fprintf(&sb, "%s%s -->\n", annotationMarker, annotationKey(a))
+	return sb.String()
+}
+
+// annotationKey identifies the region an annotation comments on
+func annotationKey(a Annotation) string {
+	return fmt.Sprintf("%s %s:%d", a.CommitSHA, a.Path, a.Line)
+}
+
+// SkipPosted returns the annotations not yet posted, given the bodies of
+// the pull request's existing review comments
+func SkipPosted(annotations []Annotation, bodies []string) []Annotation {
+	posted := make(map[string]bool)
+	for _, body := range bodies {
+		for _, line := range strings.Split(body, "\n") {
+			if key, ok := strings.CutPrefix(strings.TrimSpace(line), annotationMarker); ok {
+				posted[strings.TrimSuffix(key, " -->")] = true
+			}
+		}
+	}
+	var pending []Annotation
+	for _, a := range annotations {
+		if !posted[annotationKey(a)] {
+			pending = append(pending, a)
+		}
+	}
+	return pending
+}
+
+// hunkHeader matches the new-file side of a unified diff hunk header
+var hunkHeader = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,(\d+))? @@`)
+
+// addedLines returns the line ranges added to path between base and head
+func addedLines(baseSHA, headSHA, path string) ([]LineRange, error) {
+	out, err := git.RunGit("diff", "--unified=0", baseSHA+"..."+headSHA, "--", path)
+	if err != nil {
+		return nil, fmt.Errorf("git diff %s: %w", path, err)
+	}
+	return parseAddedLines(out), nil
+}
+
+// parseAddedLines extracts added line ranges from unified diff output
+func parseAddedLines(diff string) []LineRange {
+	var ranges []LineRange
+	for _, line := range strings.Split(diff, "\n") {
+		m := hunkHeader.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		start, _ := strconv.Atoi(m[1])
+		count := 1
+		if m[2] != "" {
+			count, _ = strconv.Atoi(m[2])
+		}
+		if count == 0 {
+			continue // pure deletion
+		}
+		ranges = append(ranges, LineRange{Start: start, End: start + count - 1})
+	}
+	return ranges
+}
+
+// inRanges reports whether line falls in any of ranges
+func inRanges(ranges []LineRange, line int) bool {
+	for _, r := range ranges {
+		if line >= r.Start && line <= r.End {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+package ci
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseAddedLines(t *testing.T) {
+	diff := `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -3,0 +4,2 @@ func main() {
++	foo()
++	bar()
+@@ -10 +12 @@ func other() {
+-	old()
++	new()
+@@ -20,3 +21,0 @@ func gone() {
+-	a()
+-	b()
+-	c()
+`
+	got := parseAddedLines(diff)
+	want := []LineRange{{Start: 4, End: 5}, {Start: 12, End: 12}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseAddedLines() = %v, want %v", got, want)
+	}
+
+	if !inRanges(got, 5) || inRanges(got, 6) || !inRanges(got, 12) {
+		t.Error("inRanges() gave wrong membership")
+	}
+}
+
+func TestRenderAnnotation(t *testing.T) {
+	a := Annotation{
+		Path:      "main.go",
+		Line:      4,
+		CommitSHA: "abc1234def",
+		Tool:      "claude-code",
+		ToolName:  "Edit",
+		Prompt:    "Call foo from main",
+	}
+
+	got := RenderAnnotation(a, "https://example.github.io/repo/pr-1/")
+	for _, want := range []string{
+		"Edit via Claude Code",
+		"> Call foo from main",
+		"[full transcript](https://example.github.io/repo/pr-1/abc1234.html)",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("RenderAnnotation() missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestSkipPosted(t *testing.T) {
+	first := Annotation{Path: "main.go", Line: 4, CommitSHA: "abc1234def", Tool: "claude-code", ToolName: "Edit", Prompt: "Call foo"}
+	second := first
+	second.Line = 9
+
+	bodies := []string{"Looks good", RenderAnnotation(first, "")}
+	got := SkipPosted([]Annotation{first, second}, bodies)
+	if len(got) != 1 || got[0].Line != 9 {
+		t.Errorf("SkipPosted() = %+v, want only line 9", got)
+	}
+	if got := SkipPosted([]Annotation{first, second}, nil); len(got) != 2 {
+		t.Errorf("SkipPosted() without comments = %d annotations, want 2", len(got))
+	}
+}
@@ -0,0 +1,134 @@
+package ci
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/display"
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+	"github.com/QuesmaOrg/git-prompt-story/internal/note"
+)
+
+// blamePromptWidth is the display width prompts are cut to in the blame
+const blamePromptWidth = 72
+
+// BlameRange is a run of consecutive lines from the same commit and step
+type BlameRange struct {
+	Start, End int
+	*LineOrigin
+}
+
+// BlameFile blames every line of the file at path, relative to the current
+// directory, at HEAD and finds the step of each line's commit closest to
+// it, see FindLineOrigin. Consecutive lines with the same origin are
+// grouped.
+func BlameFile(path string, full bool) ([]BlameRange, error) {
+	prefix, _ := git.RunGit("rev-parse", "--show-prefix")
+	path = filepath.ToSlash(filepath.Clean(filepath.Join(prefix, path)))
+
+	top, err := git.GetRepoRoot()
+	if err != nil {
+		return nil, fmt.Errorf("not in a git repository: %w", err)
+	}
+	out, err := git.RunGit("-C", top, "blame", "--line-porcelain", "HEAD", "--", path)
+	if err != nil {
+		return nil, fmt.Errorf("git blame %s: %w", path, err)
+	}
+	lines := parseBlameLines(out)
+
+	type commitStory struct {
+		subject string
+		cs      *CommitSummary
+		idx     ProvenanceIndex
+	}
+	stories := make(map[string]*commitStory)
+	for _, o := range lines {
+		o.Path = path
+		if !o.Committed() {
+			continue
+		}
+		o.ShortSHA = o.SHA[:7]
+		story, ok := stories[o.SHA]
+		if !ok {
+			story = &commitStory{}
+			story.subject, _ = getCommitSubject(o.SHA)
+			cs, err := analyzeCommit(o.SHA, full, nil)
+			switch {
+			case errors.Is(err, note.ErrNoNote):
+			case err != nil:
+				return nil, fmt.Errorf("commit %s: %w", o.ShortSHA, err)
+			default:
+				story.cs = cs
+				story.idx = BuildProvenanceIndex([]CommitSummary{*cs})
+			}
+			stories[o.SHA] = story
+		}
+		o.Subject = story.subject
+		if story.cs != nil {
+			o.HasNote = true
+			o.closestStepIn(*story.cs, story.idx)
+		}
+	}
+	return groupBlameLines(lines), nil
+}
+
+// groupBlameLines groups consecutive lines from the same commit and step
+func groupBlameLines(lines []*LineOrigin) []BlameRange {
+	var ranges []BlameRange
+	for _, o := range lines {
+		if n := len(ranges); n > 0 {
+			last := &ranges[n-1]
+			if last.End == o.Line-1 && last.SHA == o.SHA && last.Match == o.Match && last.StepID() == o.StepID() {
+				last.End = o.Line
+				continue
+			}
+		}
+		ranges = append(ranges, BlameRange{Start: o.Line, End: o.Line, LineOrigin: o})
+	}
+	return ranges
+}
+
+// RenderBlame renders the ranges one per line: the lines, the commit, and
+// the prompt that led to them. Prompts of edits that did not write the
+// text of the lines are marked "(file)", prompts of commits that did not
+// edit the file "(commit)"; commits without a prompt show their subject.
+func RenderBlame(ranges []BlameRange) string {
+	var sb strings.Builder
+	width := 1
+	if n := len(ranges); n > 0 {
+		width = len(fmt.Sprint(ranges[n-1].End))
+	}
+	for _, r := range ranges {
+		lines := fmt.Sprint(r.Start)
+		if r.End > r.Start {
+			lines += fmt.Sprintf("-%d", r.End)
+		}
+		fmt.Fprintf(&sb, "%-*s  ", 2*width+1, lines)
+
+		switch {
+		case !r.Committed():
+			sb.WriteString("0000000  Not committed yet\n")
+			continue
+		case r.Prompt == nil:
+			why := "no sessions"
+			if r.HasNote {
+				why = "no prompt"
+			}
+			fmt.Fprintf(&sb, "%s  %s (%s)\n", r.ShortSHA, blameText(r.Subject), why)
+			continue
+		}
+		fmt.Fprintf(&sb, "%s  %s %s", r.ShortSHA, display.GetTypeEmoji(r.Prompt.Type), blameText(r.Prompt.Text))
+		if r.Match != MatchLine {
+			fmt.Fprintf(&sb, " (%s)", r.Match)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// blameText puts a prompt on one line of at most blamePromptWidth columns
+func blameText(text string) string {
+	return subjectWidth.Truncate(strings.Join(strings.Fields(text), " "), blamePromptWidth, "...")
+}
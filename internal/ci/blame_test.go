@@ -0,0 +1,51 @@
+package ci
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseBlameLines(t *testing.T) {
+	a, b := strings.Repeat("a", 40), strings.Repeat("b", 40)
+	out := a + " 1 1 2\nauthor Ann\nfilename old.go\n\tpackage app\n" +
+		a + " 2 2\nauthor Ann\nfilename old.go\n\t\n" +
+		b + " 7 3 1\nauthor Bob\nfilename app.go\n\tfunc main() {}"
+	lines := parseBlameLines(out)
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines, want 3", len(lines))
+	}
+	if lines[1].SHA != a || lines[1].Line != 2 || lines[1].OrigPath != "old.go" || lines[1].Text != "" {
+		t.Errorf("line 2 = %+v", lines[1])
+	}
+	if lines[2].SHA != b || lines[2].OrigLine != 7 || lines[2].Text != "func main() {}" {
+		t.Errorf("line 3 = %+v", lines[2])
+	}
+}
+
+func TestGroupBlameLines(t *testing.T) {
+	p1 := &PromptEntry{Type: "PROMPT", Text: "Add the\nhandler", StepID: "step-1"}
+	p2 := &PromptEntry{Type: "PROMPT", Text: "Log failures", StepID: "step-2"}
+	lines := []*LineOrigin{
+		{Line: 1, SHA: "aaaaaaa1", ShortSHA: "aaaaaaa", HasNote: true, Match: MatchLine, Prompt: p1},
+		{Line: 2, SHA: "aaaaaaa1", ShortSHA: "aaaaaaa", HasNote: true, Match: MatchLine, Prompt: p1},
+		{Line: 3, SHA: "aaaaaaa1", ShortSHA: "aaaaaaa", HasNote: true, Match: MatchFile, Prompt: p2},
+		{Line: 4, SHA: "bbbbbbb2", ShortSHA: "bbbbbbb", Subject: "Tidy"},
+		{Line: 5, SHA: strings.Repeat("0", 40)},
+	}
+	ranges := groupBlameLines(lines)
+	if len(ranges) != 4 || ranges[0].Start != 1 || ranges[0].End != 2 {
+		t.Fatalf("ranges = %+v", ranges)
+	}
+
+	out := RenderBlame(ranges)
+	for _, want := range []string{
+		"1-2  aaaaaaa  💬 Add the handler\n",
+		"3    aaaaaaa  💬 Log failures (file)\n",
+		"4    bbbbbbb  Tidy (no sessions)\n",
+		"5    0000000  Not committed yet\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("blame missing %q:\n%s", want, out)
+		}
+	}
+}
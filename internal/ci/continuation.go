@@ -0,0 +1,167 @@
+package ci
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/config"
+)
+
+// DefaultContinuationGap is the longest pause between two sessions that
+// still counts as a restart of the tool, see config.KeyContinuationGap
+const DefaultContinuationGap = 30 * time.Minute
+
+// continuationPrompts start the first prompt of a session resuming
+// earlier work, lowercased
+var continuationPrompts = []string{
+	"this session is being continued",
+	"continue",
+	"keep going",
+	"resume",
+	"where were we",
+	"pick up where",
+}
+
+// continuationGap returns the configured continuation gap. Zero disables
+// linking.
+func continuationGap() time.Duration {
+	value := config.Get(config.KeyContinuationGap)
+	if value == "" {
+		return DefaultContinuationGap
+	}
+	gap, err := time.ParseDuration(value)
+	if err != nil || gap < 0 {
+		return DefaultContinuationGap
+	}
+	return gap
+}
+
+// sessionSpan is what linking needs to know about a session, merged over
+// every commit it appears in
+type sessionSpan struct {
+	tool, id, branch string
+	start, end       time.Time
+	firstPrompt      string
+	files            map[string]bool
+}
+
+// linkContinuations sets ContinuesFrom on sessions that look like the same
+// task after a tool restart, which starts a new session ID: a session of the
+// same tool on the same branch, starting less than gap after the previous
+// one ended, that opens with a continuation prompt or works on a file the
+// previous one touched. Agent sessions are not linked.
+func linkContinuations(commits []CommitSummary, gap time.Duration) {
+	if gap <= 0 {
+		return
+	}
+
+	spans := make(map[string]*sessionSpan)
+	var order []*sessionSpan
+	for _, cs := range commits {
+		for _, sess := range cs.Sessions {
+			if sess.IsAgent {
+				continue
+			}
+			sp := spans[sess.Tool+"/"+sess.ID]
+			if sp == nil {
+				sp = &sessionSpan{tool: sess.Tool, id: sess.ID, branch: sess.Branch, start: sess.Start, end: sess.End, files: make(map[string]bool)}
+				spans[sess.Tool+"/"+sess.ID] = sp
+				order = append(order, sp)
+			}
+			if sess.Start.Before(sp.start) {
+				sp.start = sess.Start
+			}
+			if sess.End.After(sp.end) {
+				sp.end = sess.End
+			}
+			for _, p := range sess.Prompts {
+				if p.FilePath != "" {
+					sp.files[p.FilePath] = true
+				}
+				if p.Type == "PROMPT" && sp.firstPrompt == "" {
+					sp.firstPrompt = p.Text
+				}
+			}
+		}
+	}
+
+	links := continuations(order, gap)
+	if len(links) == 0 {
+		return
+	}
+	for i := range commits {
+		for j := range commits[i].Sessions {
+			sess := &commits[i].Sessions[j]
+			if prev, ok := links[sess.Tool+"/"+sess.ID]; ok {
+				sess.ContinuesFrom = prev
+			}
+		}
+	}
+}
+
+// continuations returns, for each span continuing an earlier one, the ID
+// of that one keyed by "tool/id". Each span continues at most one earlier
+// span and is continued by at most one.
+func continuations(spans []*sessionSpan, gap time.Duration) map[string]string {
+	sorted := append([]*sessionSpan(nil), spans...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].start.Before(sorted[j].start) })
+
+	links := make(map[string]string)
+	continued := make(map[*sessionSpan]bool)
+	for i, next := range sorted {
+		// The closest earlier session wins
+		for k := i - 1; k >= 0; k-- {
+			prev := sorted[k]
+			if continued[prev] || !continues(prev, next, gap) {
+				continue
+			}
+			links[next.tool+"/"+next.id] = prev.id
+			continued[prev] = true
+			break
+		}
+	}
+	return links
+}
+
+// continues reports whether next looks like prev resumed after a restart
+func continues(prev, next *sessionSpan, gap time.Duration) bool {
+	if prev.tool != next.tool || prev.branch != next.branch {
+		return false
+	}
+	pause := next.start.Sub(prev.end)
+	if pause < 0 || pause >= gap {
+		return false
+	}
+
+	first := strings.ToLower(strings.TrimSpace(next.firstPrompt))
+	for _, p := range continuationPrompts {
+		if strings.HasPrefix(first, p) {
+			return true
+		}
+	}
+	for f := range next.files {
+		if prev.files[f] {
+			return true
+		}
+	}
+	return false
+}
+
+// Continuations returns the sessions of the commits in commitSpec that
+// continue an earlier one of them, as "tool/id" -> ID of the earlier session
+func Continuations(commitSpec string) (map[string]string, error) {
+	summary, err := GenerateSummary(commitSpec, false)
+	if err != nil {
+		return nil, err
+	}
+	links := make(map[string]string)
+	for _, cs := range summary.Commits {
+		for _, sess := range cs.Sessions {
+			if sess.ContinuesFrom != "" {
+				links[sess.Tool+"/"+sess.ID] = sess.ContinuesFrom
+			}
+		}
+	}
+	return links, nil
+}
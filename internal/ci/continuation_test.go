@@ -0,0 +1,92 @@
+package ci
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func continuationSession(id string, start, end time.Time, firstPrompt string, files ...string) SessionSummary {
+	ss := SessionSummary{Tool: "claude-code", ID: id, Branch: "feature", Start: start, End: end}
+	ss.Prompts = append(ss.Prompts, PromptEntry{Time: start, Type: "PROMPT", Text: firstPrompt})
+	for _, f := range files {
+		ss.Prompts = append(ss.Prompts, PromptEntry{Time: start, Type: "TOOL_USE", ToolName: "Edit", FilePath: f})
+	}
+	return ss
+}
+
+func TestLinkContinuations(t *testing.T) {
+	at := func(h, m int) time.Time { return time.Date(2025, 1, 15, h, m, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name string
+		next SessionSummary
+		want string
+	}{
+		{"continuation prompt", continuationSession("s2", at(10, 10), at(10, 40), "Continue with the tests"), "s1"},
+		{"shared file", continuationSession("s2", at(10, 10), at(10, 40), "Fix the failing case", "/repo/login.go"), "s1"},
+		{"unrelated", continuationSession("s2", at(10, 10), at(10, 40), "Write the changelog", "/repo/CHANGELOG.md"), ""},
+		{"gap too long", continuationSession("s2", at(11, 0), at(11, 30), "Continue"), ""},
+		{"overlapping", continuationSession("s2", at(9, 50), at(10, 30), "Continue"), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			commits := []CommitSummary{
+				{Sessions: []SessionSummary{continuationSession("s1", at(9, 0), at(10, 0), "Add login", "/repo/login.go")}},
+				{Sessions: []SessionSummary{tt.next}},
+			}
+			linkContinuations(commits, 30*time.Minute)
+			if got := commits[1].Sessions[0].ContinuesFrom; got != tt.want {
+				t.Errorf("ContinuesFrom = %q, want %q", got, tt.want)
+			}
+			if got := commits[0].Sessions[0].ContinuesFrom; got != "" {
+				t.Errorf("first session ContinuesFrom = %q, want none", got)
+			}
+		})
+	}
+}
+
+func TestLinkContinuations_Chain(t *testing.T) {
+	at := func(h, m int) time.Time { return time.Date(2025, 1, 15, h, m, 0, 0, time.UTC) }
+
+	s1 := continuationSession("s1", at(9, 0), at(9, 30), "Add login")
+	s2 := continuationSession("s2", at(9, 35), at(10, 0), "continue")
+	s3 := continuationSession("s3", at(10, 5), at(10, 30), "keep going")
+	other := continuationSession("s4", at(9, 40), at(9, 45), "continue")
+	other.Branch = "main"
+	agent := continuationSession("agent-1", at(9, 32), at(9, 34), "continue")
+	agent.IsAgent = true
+
+	commits := []CommitSummary{{Sessions: []SessionSummary{s1, s2, other, agent, s3}}}
+	linkContinuations(commits, 30*time.Minute)
+
+	want := map[string]string{"s1": "", "s2": "s1", "s4": "", "agent-1": "", "s3": "s2"}
+	for _, sess := range commits[0].Sessions {
+		if sess.ContinuesFrom != want[sess.ID] {
+			t.Errorf("%s: ContinuesFrom = %q, want %q", sess.ID, sess.ContinuesFrom, want[sess.ID])
+		}
+	}
+}
+
+func TestLinkContinuations_SameSessionAcrossCommits(t *testing.T) {
+	at := func(h, m int) time.Time { return time.Date(2025, 1, 15, h, m, 0, 0, time.UTC) }
+
+	// A session captured by two commits is one span, not a continuation of itself
+	commits := []CommitSummary{
+		{Sessions: []SessionSummary{continuationSession("s1", at(9, 0), at(9, 30), "Add login", "/repo/a.go")}},
+		{Sessions: []SessionSummary{continuationSession("s1", at(9, 0), at(10, 30), "Add login", "/repo/a.go")}},
+	}
+	linkContinuations(commits, 30*time.Minute)
+	for i, cs := range commits {
+		if got := cs.Sessions[0].ContinuesFrom; got != "" {
+			t.Errorf("commit %d: ContinuesFrom = %q, want none", i, got)
+		}
+	}
+}
+
+func TestSessionHeader_Continued(t *testing.T) {
+	sess := SessionSummary{Tool: "claude-code", ID: "s2", ContinuesFrom: "s1", Prompts: []PromptEntry{{}}}
+	if got := sessionHeader(MarkdownRenderer, sess, 1); !strings.Contains(got, ", continued session)") {
+		t.Errorf("sessionHeader() = %q, want it marked as continued", got)
+	}
+}
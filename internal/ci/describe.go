@@ -0,0 +1,149 @@
+package ci
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/display"
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+)
+
+const (
+	// maxKeyPromptsPerCommit limits how many prompts are quoted per commit
+	maxKeyPromptsPerCommit = 3
+
+	// minKeyPromptLength skips short follow-ups ("yes", "continue") when
+	// picking key prompts after the first one
+	minKeyPromptLength = 40
+
+	// maxDescribePromptLength truncates quoted prompts
+	maxDescribePromptLength = 300
+)
+
+// DescribeCommit is a commit listed in a PR description draft
+type DescribeCommit struct {
+	SHA      string
+	ShortSHA string
+	Subject  string
+}
+
+// GenerateDescription builds a markdown PR description draft for the
+// commits in a range from their subjects and captured sessions
+func GenerateDescription(commitRange string) (string, error) {
+	shas, err := git.ResolveCommitSpec(commitRange)
+	if err != nil {
+		return "", err
+	}
+
+	summary, err := GenerateSummary(commitRange, false)
+	if err != nil {
+		return "", err
+	}
+
+	// Oldest first, like the commits read in a PR
+	commits := make([]DescribeCommit, 0, len(shas))
+	for i := len(shas) - 1; i >= 0; i-- {
+		subject, _ := getCommitSubject(shas[i])
+		commits = append(commits, DescribeCommit{
+			SHA:      shas[i],
+			ShortSHA: shas[i][:7],
+			Subject:  subject,
+		})
+	}
+
+	return RenderDescription(commits, summary), nil
+}
+
+// RenderDescription renders a PR description draft: every commit subject,
+// followed by the key prompts and decisions from its sessions
+func RenderDescription(commits []DescribeCommit, summary *Summary) string {
+	bySHA := make(map[string]CommitSummary, len(summary.Commits))
+	for _, cs := range summary.Commits {
+		bySHA[cs.SHA] = cs
+	}
+
+	var sb strings.Builder
+	sb.WriteString("## Summary\n\n")
+	sb.WriteString("<!-- Draft generated by git-prompt-story from the captured LLM sessions. Review before publishing. -->\n\n")
+	sb.WriteString("## Changes\n\n")
+
+	for _, c := range commits {
+		fmt.Fprintf(&sb, "- **%s** (`%s`)\n", escapeMarkdownLine(c.Subject), c.ShortSHA)
+
+		cs, ok := bySHA[c.SHA]
+		if !ok {
+			continue
+		}
+		for _, p := range keyEntries(cs) {
+			text := display.TruncateText(p.Text, maxDescribePromptLength)
+			switch p.Type {
+			case "DECISION":
+				line := text
+				if p.DecisionHeader != "" {
+					line = p.DecisionHeader + ": " + text
+				}
+				fmt.Fprintf(&sb, "  - %s %s → **%s**\n", display.GetTypeEmoji(p.Type), escapeMarkdownLine(line), escapeMarkdownLine(p.DecisionAnswer))
+			default:
+				fmt.Fprintf(&sb, "  - %s %s\n", display.GetTypeEmoji(p.Type), escapeMarkdownLine(text))
+			}
+		}
+	}
+
+	if summary.CommitsWithNotes > 0 {
+		sb.WriteString("\n## AI assistance\n\n")
+		fmt.Fprintf(&sb, "%d of %d commits were made with LLM sessions (%d user prompts).\n",
+			summary.CommitsWithNotes, summary.CommitsAnalyzed, summary.TotalUserPrompts)
+	}
+
+	return sb.String()
+}
+
+// keyEntries picks the prompts and answered decisions worth quoting for a
+// commit: the first prompt (usually the task), further substantial
+// prompts, and every answered decision, in chronological order
+func keyEntries(cs CommitSummary) []PromptEntry {
+	var all []PromptEntry
+	for _, sess := range cs.Sessions {
+		if sess.IsAgent {
+			continue
+		}
+		for _, p := range sess.Prompts {
+			if p.InWorkPeriod {
+				all = append(all, p)
+			}
+		}
+	}
+	sort.SliceStable(all, func(i, j int) bool {
+		return all[i].Time.Before(all[j].Time)
+	})
+
+	var result []PromptEntry
+	prompts := 0
+	for _, p := range all {
+		switch p.Type {
+		case "PROMPT":
+			if prompts >= maxKeyPromptsPerCommit {
+				continue
+			}
+			if prompts > 0 && len(strings.TrimSpace(p.Text)) < minKeyPromptLength {
+				continue
+			}
+			prompts++
+			result = append(result, p)
+		case "DECISION":
+			if p.DecisionAnswer != "" {
+				result = append(result, p)
+			}
+		}
+	}
+	return result
+}
+
+// escapeMarkdownLine keeps user text from breaking list formatting
+func escapeMarkdownLine(s string) string {
+	s = strings.ReplaceAll(s, "\n", " ")
+	s = strings.ReplaceAll(s, "<", "&lt;")
+	s = strings.ReplaceAll(s, ">", "&gt;")
+	return strings.TrimSpace(s)
+}
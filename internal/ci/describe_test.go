@@ -0,0 +1,63 @@
+package ci
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderDescription(t *testing.T) {
+	now := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+	commits := []DescribeCommit{
+		{SHA: "aaa1111aaaa", ShortSHA: "aaa1111", Subject: "Add parser"},
+		{SHA: "bbb2222bbbb", ShortSHA: "bbb2222", Subject: "Fix typo"},
+	}
+	summary := &Summary{
+		CommitsAnalyzed:  2,
+		CommitsWithNotes: 1,
+		TotalUserPrompts: 3,
+		Commits: []CommitSummary{{
+			SHA: "aaa1111aaaa",
+			Sessions: []SessionSummary{
+				{
+					Prompts: []PromptEntry{
+						{Type: "PROMPT", Text: "Write a parser for <config> files", Time: now, InWorkPeriod: true},
+						{Type: "PROMPT", Text: "yes", Time: now.Add(time.Minute), InWorkPeriod: true},
+						{Type: "DECISION", Text: "Which format?", DecisionHeader: "Format", DecisionAnswer: "YAML", Time: now.Add(2 * time.Minute), InWorkPeriod: true},
+						{Type: "TOOL_USE", Text: "Edit", Time: now.Add(3 * time.Minute), InWorkPeriod: true},
+						{Type: "PROMPT", Text: "Before work period, should not be quoted at all", Time: now.Add(-time.Hour), InWorkPeriod: false},
+					},
+				},
+				{
+					ID:      "agent-1",
+					IsAgent: true,
+					Prompts: []PromptEntry{
+						{Type: "PROMPT", Text: "Agent prompt that should be skipped entirely", Time: now, InWorkPeriod: true},
+					},
+				},
+			},
+		}},
+	}
+
+	got := RenderDescription(commits, summary)
+
+	for _, want := range []string{
+		"- **Add parser** (`aaa1111`)",
+		"- **Fix typo** (`bbb2222`)",
+		"💬 Write a parser for &lt;config&gt; files",
+		"❓ Format: Which format? → **YAML**",
+		"1 of 2 commits",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("description missing %q:\n%s", want, got)
+		}
+	}
+	for _, unwanted := range []string{"yes", "Before work period", "Agent prompt", "Edit"} {
+		if strings.Contains(got, unwanted) {
+			t.Errorf("description should not contain %q:\n%s", unwanted, got)
+		}
+	}
+	if strings.Index(got, "Add parser") > strings.Index(got, "Fix typo") {
+		t.Error("commits should keep the given order")
+	}
+}
@@ -0,0 +1,80 @@
+package ci
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/session"
+)
+
+// CommitTrace explains why a commit, or some of its sessions, did not
+// contribute to a summary. It reuses the explain trace structures.
+type CommitTrace struct {
+	SHA     string `json:"sha"`
+	Subject string `json:"subject"`
+
+	// Reason is set when the whole commit was skipped
+	Reason string `json:"reason,omitempty"`
+
+	// Trailer is set when a skipped commit has a Prompt-Story trailer,
+	// i.e. its note should have been readable
+	Trailer bool `json:"trailer,omitempty"`
+
+	// Unreadable lists transcripts that could not be fetched or parsed
+	Unreadable []string `json:"unreadable,omitempty"`
+
+	WorkPeriod session.WorkPeriodTrace `json:"work_period"`
+	Sessions   []session.SessionTrace  `json:"sessions,omitempty"`
+}
+
+// skippedSessions counts sessions left out of the summary
+func (t *CommitTrace) skippedSessions() int {
+	n := 0
+	for _, s := range t.Sessions {
+		if !s.Included {
+			n++
+		}
+	}
+	return n
+}
+
+// UnreadableData returns the skipped commits whose data should have been
+// readable: commits with a Prompt-Story trailer but no usable note, and
+// commits with transcripts that could not be fetched or parsed
+func UnreadableData(summary *Summary) []CommitTrace {
+	var traces []CommitTrace
+	for _, t := range summary.Skipped {
+		if (t.Trailer && t.Reason != "") || len(t.Unreadable) > 0 {
+			traces = append(traces, t)
+		}
+	}
+	return traces
+}
+
+// RenderDiagnostics writes a plain-text report of skipped commits and
+// sessions, meant for CI logs
+func RenderDiagnostics(summary *Summary, w io.Writer) {
+	fmt.Fprintf(w, "=== Diagnostics: %d of %d commit(s) with skipped data ===\n",
+		len(summary.Skipped), summary.CommitsAnalyzed)
+
+	for _, t := range summary.Skipped {
+		fmt.Fprintf(w, "\n%s %s\n", t.SHA[:7], t.Subject)
+		if t.Reason != "" {
+			fmt.Fprintf(w, "  Skipped: %s\n", t.Reason)
+		}
+		if !t.WorkPeriod.CalculatedStart.IsZero() {
+			fmt.Fprintf(w, "  Work period: %s → %s\n",
+				t.WorkPeriod.CalculatedStart.Local().Format("2006-01-02 15:04:05"),
+				t.WorkPeriod.EndWork.Local().Format("2006-01-02 15:04:05"))
+		}
+		for _, s := range t.Sessions {
+			if s.TimestampRegressions > 0 {
+				fmt.Fprintf(w, "  Session %s: %d timestamp(s) go back in time (clock skew?)\n", s.Path, s.TimestampRegressions)
+			}
+			if s.Included {
+				continue
+			}
+			fmt.Fprintf(w, "  Session %s: %s\n", s.Path, s.FinalReason)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package ci
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+)
+
+// maxDiffLines is how many lines of a file's diff a diff page shows
+const maxDiffLines = 2000
+
+// DiffPageData holds data for the page showing a commit's diff next to
+// the prompts whose edits touched each file
+type DiffPageData struct {
+	Commit CommitViewData
+	Files  []DiffFile
+}
+
+// DiffFile is one file of a commit's diff
+type DiffFile struct {
+	Path    string
+	Lines   []DiffLine
+	Omitted int               // Lines left out after maxDiffLines
+	Steps   []FileHistoryStep // Prompts whose edits touched the file
+}
+
+// DiffLine is a line of a diff; Kind is add, del, hunk or ctx
+type DiffLine struct {
+	Kind string
+	Text string
+}
+
+// DiffPageName returns the file name of a commit's diff page
+func DiffPageName(shortSHA string) string {
+	return shortSHA + "-diff.html"
+}
+
+// commitDiff returns the diff of a commit, each file with the steps of the
+// commit's sessions that edited it
+func commitDiff(cs CommitSummary) ([]DiffFile, error) {
+	out, err := git.RunGit("show", "--format=", "--no-color", "--no-ext-diff", "-M", cs.SHA)
+	if err != nil {
+		return nil, fmt.Errorf("git show %s: %w", cs.ShortSHA, err)
+	}
+	files := parseDiff(out)
+	idx := BuildProvenanceIndex([]CommitSummary{cs})
+	for i := range files {
+		files[i].Steps = groupEdits(idx.Lookup(files[i].Path))
+	}
+	return files, nil
+}
+
+// parseDiff splits unified diff output into files, dropping the extended
+// headers but keeping notes like "Binary files differ"
+func parseDiff(out string) []DiffFile {
+	var files []DiffFile
+	var f *DiffFile
+	inHunk := false
+	for _, line := range strings.Split(out, "\n") {
+		if strings.HasPrefix(line, "diff --git ") {
+			files = append(files, DiffFile{})
+			f = &files[len(files)-1]
+			if _, b, ok := strings.Cut(line, " b/"); ok {
+				f.Path = b
+			}
+			inHunk = false
+			continue
+		}
+		if f == nil || line == "" {
+			continue // Context lines keep their leading space
+		}
+
+		kind := ""
+		switch {
+		case strings.HasPrefix(line, "@@"):
+			kind, inHunk = "hunk", true
+		case !inHunk:
+			if strings.HasPrefix(line, "+++ b/") {
+				f.Path = strings.TrimPrefix(line, "+++ b/")
+			}
+			if strings.HasPrefix(line, "Binary files") {
+				kind = "ctx"
+			}
+		case strings.HasPrefix(line, "+"):
+			kind = "add"
+		case strings.HasPrefix(line, "-"):
+			kind = "del"
+		default:
+			kind = "ctx"
+		}
+		if kind == "" {
+			continue
+		}
+		if len(f.Lines) >= maxDiffLines {
+			f.Omitted++
+			continue
+		}
+		f.Lines = append(f.Lines, DiffLine{Kind: kind, Text: line})
+	}
+	return files
+}
+
+// writeDiffPages writes the diff page of each commit
+func (r *pageRenderer) writeDiffPages(summary *Summary, outputDir string, commits []CommitViewData) error {
+	tmpl, err := r.parse("diff")
+	if err != nil {
+		return err
+	}
+	for i, cvd := range commits {
+		if cvd.DiffPage == "" {
+			continue
+		}
+		files, err := commitDiff(summary.Commits[i])
+		if err != nil {
+			return err
+		}
+		data := DiffPageData{Commit: cvd, Files: files}
+		if err := renderFile(tmpl, filepath.Join(outputDir, cvd.DiffPage), data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package ci
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseDiff(t *testing.T) {
+	out := `diff --git a/auth.go b/login.go
+similarity index 90%
+rename from auth.go
+rename to login.go
+--- a/auth.go
++++ b/login.go
+@@ -1,3 +1,3 @@
+ package app
+-func auth() {}
++func login() {}
+diff --git a/logo.png b/logo.png
+new file mode 100644
+index 0000000..1234567
+Binary files /dev/null and b/logo.png differ`
+
+	files := parseDiff(out)
+	if len(files) != 2 {
+		t.Fatalf("got %d files, want 2", len(files))
+	}
+	f := files[0]
+	if f.Path != "login.go" || len(f.Lines) != 4 {
+		t.Fatalf("file 0 = %+v", f)
+	}
+	kinds := []string{"hunk", "ctx", "del", "add"}
+	for i, l := range f.Lines {
+		if l.Kind != kinds[i] {
+			t.Errorf("line %d %q is %s, want %s", i, l.Text, l.Kind, kinds[i])
+		}
+	}
+	if files[1].Path != "logo.png" || len(files[1].Lines) != 1 || !strings.HasPrefix(files[1].Lines[0].Text, "Binary files") {
+		t.Errorf("file 1 = %+v", files[1])
+	}
+}
+
+func TestParseDiffOmitsLongFiles(t *testing.T) {
+	out := "diff --git a/big.txt b/big.txt\n@@ -0,0 +1,3000 @@\n" + strings.Repeat("+x\n", 3000)
+	f := parseDiff(out)[0]
+	if len(f.Lines) != maxDiffLines || f.Omitted != 3001-maxDiffLines {
+		t.Errorf("got %d lines, %d omitted", len(f.Lines), f.Omitted)
+	}
+}
@@ -0,0 +1,94 @@
+package ci
+
+import (
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/note"
+)
+
+// commentLimit is the maximum size of a GitHub comment body
+const commentLimit = 65536
+
+// Estimate predicts the rendered size of a PR summary and what its
+// budgets will cut, before anything is posted
+type Estimate struct {
+	Bytes             int
+	UserPrompts       int
+	TruncatedPrompts  int
+	TruncatedSessions int
+	TruncatedSteps    int
+	Commits           []CommitEstimate
+}
+
+// CommitEstimate is one commit's share of the "All steps" section
+type CommitEstimate struct {
+	ShortSHA          string
+	Subject           string
+	Sessions          int
+	Steps             int
+	Bytes             int // size of its steps rendered without truncation
+	TruncatedSessions int
+	TruncatedSteps    int
+}
+
+// EstimateMarkdown renders the summary as RenderMarkdown would and reports
+// its size and truncation, broken down per commit (oldest first)
+func EstimateMarkdown(summary *Summary, pagesURL, version string) Estimate {
+	markdown, stats := render(MarkdownRenderer, summary, pagesURL, version)
+	est := Estimate{
+		Bytes:             len(markdown),
+		UserPrompts:       stats.UserPrompts,
+		TruncatedPrompts:  stats.TruncatedPrompts,
+		TruncatedSessions: stats.TruncatedSessions,
+		TruncatedSteps:    stats.TruncatedSteps,
+	}
+	if summary.CommitsWithNotes == 0 {
+		return est
+	}
+
+	commits := chronologicalCommits(summary)
+	sel := selectSteps(MarkdownRenderer, commits, maxAllStepsSize, pagesURL)
+	for i, c := range commits {
+		ce := CommitEstimate{ShortSHA: c.ShortSHA, Subject: c.Subject, Sessions: len(c.Sessions)}
+		for si, sess := range c.Sessions {
+			ce.Steps += len(sess.Prompts)
+			shown := sel.shown(i, si)
+			ce.TruncatedSteps += len(sess.Prompts) - shown
+			if shown == 0 && len(sess.Prompts) > 0 {
+				ce.TruncatedSessions++
+			}
+		}
+		full, _, _ := renderAllSteps(MarkdownRenderer, commits[i:i+1], math.MaxInt, "")
+		ce.Bytes = len(full)
+		est.Commits = append(est.Commits, ce)
+	}
+	return est
+}
+
+// RenderEstimate writes a plain-text report of an estimate
+func RenderEstimate(est Estimate, w io.Writer) {
+	fmt.Fprintf(w, "Rendered markdown: %s (GitHub comment limit %s)\n",
+		note.FormatBytes(int64(est.Bytes)), note.FormatBytes(commentLimit))
+	if est.Bytes > commentLimit {
+		fmt.Fprintln(w, "  Over the limit: posting will fail")
+	}
+	fmt.Fprintf(w, "User prompts: %d, %d truncated (budget %s per section)\n",
+		est.UserPrompts, est.TruncatedPrompts, note.FormatBytes(maxUserPromptsSize))
+	fmt.Fprintf(w, "All steps: %d sessions with %d steps truncated (budget %s)\n",
+		est.TruncatedSessions, est.TruncatedSteps, note.FormatBytes(maxAllStepsSize))
+
+	if len(est.Commits) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\n%-8s %8s %6s %9s  %s\n", "Commit", "Sessions", "Steps", "Size", "Truncated")
+	for _, c := range est.Commits {
+		truncated := "-"
+		if c.TruncatedSessions > 0 || c.TruncatedSteps > 0 {
+			truncated = fmt.Sprintf("%d sessions, %d steps", c.TruncatedSessions, c.TruncatedSteps)
+		}
+		fmt.Fprintf(w, "%-8s %8d %6d %9s  %s\n", c.ShortSHA, c.Sessions, c.Steps,
+			note.FormatBytes(int64(c.Bytes)), truncated)
+	}
+}
@@ -0,0 +1,62 @@
+package ci
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEstimateMarkdown(t *testing.T) {
+	start := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+	small := SessionSummary{Tool: "claude-code", ID: "s1", Start: start, End: start.Add(time.Minute),
+		Prompts: []PromptEntry{{Type: "PROMPT", Text: "Add main", Time: start}}}
+
+	var steps []PromptEntry
+	for i := 0; i < 600; i++ {
+		steps = append(steps, PromptEntry{Type: "ASSISTANT", Text: fmt.Sprintf("Step %d %s", i, strings.Repeat("x", 80)),
+			Time: start.Add(time.Hour + time.Duration(i)*time.Second)})
+	}
+	large := SessionSummary{Tool: "claude-code", ID: "s2", Start: start.Add(time.Hour), End: start.Add(2 * time.Hour), Prompts: steps}
+
+	summary := &Summary{
+		CommitsWithNotes: 2,
+		// Newest first, as GenerateSummary returns them
+		Commits: []CommitSummary{
+			{ShortSHA: "bbbbbbb", Subject: "Big change", Sessions: []SessionSummary{large}},
+			{ShortSHA: "aaaaaaa", Subject: "Small change", Sessions: []SessionSummary{small}},
+		},
+	}
+
+	est := EstimateMarkdown(summary, "", "test")
+	if est.Bytes != len(RenderMarkdown(summary, "", "test")) {
+		t.Errorf("Bytes = %d, want rendered size %d", est.Bytes, len(RenderMarkdown(summary, "", "test")))
+	}
+	if est.UserPrompts != 1 {
+		t.Errorf("UserPrompts = %d, want 1", est.UserPrompts)
+	}
+	if est.TruncatedSteps == 0 {
+		t.Fatal("expected steps to be truncated")
+	}
+	if len(est.Commits) != 2 || est.Commits[0].ShortSHA != "aaaaaaa" {
+		t.Fatalf("Commits = %+v, want oldest first", est.Commits)
+	}
+
+	older, newer := est.Commits[0], est.Commits[1]
+	if older.TruncatedSteps != 0 || older.Steps != 1 {
+		t.Errorf("older commit = %+v, want 1 step, none truncated", older)
+	}
+	if newer.Steps != 600 || newer.TruncatedSteps != est.TruncatedSteps || newer.TruncatedSessions != est.TruncatedSessions {
+		t.Errorf("newer commit = %+v, want all truncation (%d steps)", newer, est.TruncatedSteps)
+	}
+	if newer.Bytes <= maxAllStepsSize {
+		t.Errorf("newer commit Bytes = %d, want untruncated size over the budget", newer.Bytes)
+	}
+
+	var out bytes.Buffer
+	RenderEstimate(est, &out)
+	if !strings.Contains(out.String(), "bbbbbbb") || !strings.Contains(out.String(), "steps truncated") {
+		t.Errorf("RenderEstimate() =\n%s", out.String())
+	}
+}
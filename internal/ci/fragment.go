@@ -0,0 +1,25 @@
+package ci
+
+// Markdown fragments of a summary, for pasting part of a story elsewhere.
+// They render like the matching parts of RenderMarkdown.
+
+// MarkdownCommitHeader is the heading of a commit
+func MarkdownCommitHeader(commit CommitSummary) string {
+	return commitHeader(MarkdownRenderer, commit)
+}
+
+// MarkdownSessionHeader is the line introducing a session's steps
+func MarkdownSessionHeader(sess SessionSummary) string {
+	return sessionHeader(MarkdownRenderer, sess, len(sess.Prompts))
+}
+
+// MarkdownUserAction is a user action with its full text, long text
+// collapsed
+func MarkdownUserAction(entry PromptEntry) string {
+	return formatEntryCollapsible(MarkdownRenderer, entry)
+}
+
+// MarkdownStep is a step indented under the user action it followed
+func MarkdownStep(entry PromptEntry) string {
+	return formatStep(MarkdownRenderer, entry, "")
+}
@@ -0,0 +1,233 @@
+package ci
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/display"
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+	"github.com/QuesmaOrg/git-prompt-story/internal/note"
+)
+
+// historyPromptWidth is the display width prompts are cut to in the text
+// history
+const historyPromptWidth = 100
+
+// FileHistory is how captured sessions shaped a file, commit by commit
+type FileHistory struct {
+	Path    string
+	Commits []FileHistoryCommit
+}
+
+// FileHistoryCommit is a commit that changed the file
+type FileHistoryCommit struct {
+	git.FileCommit
+	ShortSHA string
+	HasNote  bool
+
+	// Steps are the prompts whose edits to the file the commit includes
+	Steps []FileHistoryStep
+}
+
+// FileHistoryStep is a prompt and the edits of the file that followed it
+type FileHistoryStep struct {
+	Tool      string
+	SessionID string
+	Prompt    *PromptEntry // nil for edits before the session's first prompt
+	Edits     []PromptEntry
+}
+
+// BuildFileHistory collects the history of the file at path, relative to
+// the repository root, from the commits that changed it and the edits of
+// their sessions found through the provenance index
+func BuildFileHistory(path string, full bool) (*FileHistory, error) {
+	// Paths are given relative to the current directory
+	prefix, _ := git.RunGit("rev-parse", "--show-prefix")
+	path = filepath.ToSlash(filepath.Clean(filepath.Join(prefix, path)))
+
+	commits, err := git.FileLog(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(commits) == 0 {
+		return nil, fmt.Errorf("no commits changed %s", path)
+	}
+
+	h := &FileHistory{Path: path}
+	for _, c := range commits {
+		hc := FileHistoryCommit{FileCommit: c, ShortSHA: c.SHA[:7]}
+		cs, err := analyzeCommit(c.SHA, full, nil)
+		switch {
+		case errors.Is(err, note.ErrNoNote):
+		case err != nil:
+			return nil, fmt.Errorf("commit %s: %w", hc.ShortSHA, err)
+		default:
+			hc.HasNote = true
+			hc.Steps = groupEdits(BuildProvenanceIndex([]CommitSummary{*cs}).Lookup(c.Path))
+		}
+		h.Commits = append(h.Commits, hc)
+	}
+	return h, nil
+}
+
+// groupEdits groups consecutive edits that followed the same prompt
+func groupEdits(edits []Provenance) []FileHistoryStep {
+	var steps []FileHistoryStep
+	for _, e := range edits {
+		if n := len(steps); n > 0 {
+			last := &steps[n-1]
+			if last.Tool == e.Tool && last.SessionID == e.SessionID && samePrompt(last.Prompt, e.Prompt) {
+				last.Edits = append(last.Edits, e.Edit)
+				continue
+			}
+		}
+		steps = append(steps, FileHistoryStep{
+			Tool:      e.Tool,
+			SessionID: e.SessionID,
+			Prompt:    e.Prompt,
+			Edits:     []PromptEntry{e.Edit},
+		})
+	}
+	return steps
+}
+
+func samePrompt(a, b *PromptEntry) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.StepID == b.StepID
+}
+
+// AICommits returns how many of the commits have edits from sessions
+func (h *FileHistory) AICommits() int {
+	n := 0
+	for _, c := range h.Commits {
+		if len(c.Steps) > 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// Prompts returns how many prompts led to edits of the file
+func (h *FileHistory) Prompts() int {
+	n := 0
+	for _, c := range h.Commits {
+		for _, s := range c.Steps {
+			if s.Prompt != nil {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// RenderFileHistory renders the history as a chronological narrative for
+// the terminal
+func RenderFileHistory(h *FileHistory) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "History of %s\n", h.Path)
+	fmt.Fprintf(&sb, "%d commit(s), %d with edits from AI sessions, %d prompt(s) led to edits\n",
+		len(h.Commits), h.AICommits(), h.Prompts())
+
+	for _, c := range h.Commits {
+		fmt.Fprintf(&sb, "\n%s  %s  %s\n", c.Date.Local().Format("2006-01-02 15:04"), c.ShortSHA, truncateSubject(c.Subject))
+		by := c.Author
+		if c.Path != h.Path {
+			by += fmt.Sprintf(" (as %s)", c.Path)
+		}
+		fmt.Fprintf(&sb, "  %s\n", by)
+
+		switch {
+		case !c.HasNote:
+			sb.WriteString("  No captured sessions\n")
+			continue
+		case len(c.Steps) == 0:
+			sb.WriteString("  Sessions captured, none edited this file\n")
+			continue
+		}
+
+		session := ""
+		for _, s := range c.Steps {
+			if key := s.Tool + "/" + s.SessionID; key != session {
+				session = key
+				fmt.Fprintf(&sb, "  %s %s\n", note.FormatToolName(s.Tool), shortSessionID(s.SessionID))
+			}
+			if s.Prompt != nil {
+				fmt.Fprintf(&sb, "    %s %s %s\n", s.Prompt.Time.Local().Format("15:04"),
+					display.GetTypeEmoji(s.Prompt.Type), historyText(s.Prompt.Text))
+			}
+			fmt.Fprintf(&sb, "          %s\n", editCounts(s.Edits))
+		}
+	}
+	return sb.String()
+}
+
+// historyText puts a prompt on one line of at most historyPromptWidth
+// columns
+func historyText(text string) string {
+	return subjectWidth.Truncate(strings.Join(strings.Fields(text), " "), historyPromptWidth, "...")
+}
+
+// editCounts describes edits as "Edit ×2, Write"
+func editCounts(edits []PromptEntry) string {
+	counts := make(map[string]int)
+	var order []string
+	for _, e := range edits {
+		if counts[e.ToolName] == 0 {
+			order = append(order, e.ToolName)
+		}
+		counts[e.ToolName]++
+	}
+	parts := make([]string, len(order))
+	for i, name := range order {
+		parts[i] = name
+		if counts[name] > 1 {
+			parts[i] += fmt.Sprintf(" ×%d", counts[name])
+		}
+	}
+	return display.GetTypeEmoji("TOOL_USE") + " " + strings.Join(parts, ", ")
+}
+
+func shortSessionID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
+
+// WriteFileHistoryHTML renders the history as a standalone HTML page
+func WriteFileHistoryHTML(h *FileHistory, w io.Writer) error {
+	cssBytes, err := templateFS.ReadFile("templates/styles.css")
+	if err != nil {
+		return fmt.Errorf("failed to load CSS: %w", err)
+	}
+	tmplBytes, err := templateFS.ReadFile("templates/history.html.tmpl")
+	if err != nil {
+		return fmt.Errorf("failed to load history template: %w", err)
+	}
+	tmpl, err := template.New("history").Funcs(template.FuncMap{
+		"formatTime": func(t time.Time) string {
+			return t.Local().Format("2006-01-02 15:04")
+		},
+		"formatTimeShort": func(t time.Time) string {
+			return t.Local().Format("15:04")
+		},
+		"formatToolName": note.FormatToolName,
+		"shortSessionID": shortSessionID,
+		"editCounts":     editCounts,
+	}).Parse(string(tmplBytes))
+	if err != nil {
+		return fmt.Errorf("failed to parse history template: %w", err)
+	}
+
+	return tmpl.Execute(w, struct {
+		*FileHistory
+		CSS template.CSS
+	}{h, template.CSS(cssBytes)})
+}
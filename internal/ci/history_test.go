@@ -0,0 +1,89 @@
+package ci
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+)
+
+func TestGroupEdits(t *testing.T) {
+	p1 := &PromptEntry{Type: "PROMPT", Text: "first", StepID: "step-1"}
+	p2 := &PromptEntry{Type: "PROMPT", Text: "second", StepID: "step-2"}
+	edit := PromptEntry{Type: "TOOL_USE", ToolName: "Edit"}
+
+	steps := groupEdits([]Provenance{
+		{Tool: "claude-code", SessionID: "s1", Prompt: p1, Edit: edit},
+		{Tool: "claude-code", SessionID: "s1", Prompt: p1, Edit: edit},
+		{Tool: "claude-code", SessionID: "s1", Prompt: p2, Edit: edit},
+		{Tool: "cursor", SessionID: "c1", Prompt: nil, Edit: edit},
+	})
+	if len(steps) != 3 {
+		t.Fatalf("got %d steps, want 3", len(steps))
+	}
+	if len(steps[0].Edits) != 2 {
+		t.Errorf("step 0 has %d edits, want 2", len(steps[0].Edits))
+	}
+	if steps[2].Prompt != nil || steps[2].Tool != "cursor" {
+		t.Errorf("step 2 = %+v, want the cursor edit without a prompt", steps[2])
+	}
+}
+
+func testFileHistory() *FileHistory {
+	at := func(h int) time.Time { return time.Date(2025, 1, 15, h, 0, 0, 0, time.UTC) }
+	return &FileHistory{
+		Path: "login.go",
+		Commits: []FileHistoryCommit{
+			{FileCommit: git.FileCommit{SHA: "aaaaaaa1", Author: "Ann", Date: at(9), Subject: "Initial", Path: "auth.go"}, ShortSHA: "aaaaaaa"},
+			{
+				FileCommit: git.FileCommit{SHA: "bbbbbbb2", Author: "Ann", Date: at(10), Subject: "Add login", Path: "login.go"},
+				ShortSHA:   "bbbbbbb",
+				HasNote:    true,
+				Steps: []FileHistoryStep{{
+					Tool:      "claude-code",
+					SessionID: "5f2b8c1e-0d4a",
+					Prompt:    &PromptEntry{Time: at(10), Type: "PROMPT", Text: "Add a\nlogin <handler>"},
+					Edits:     []PromptEntry{{ToolName: "Edit"}, {ToolName: "Edit"}, {ToolName: "Write"}},
+				}},
+			},
+			{FileCommit: git.FileCommit{SHA: "ccccccc3", Author: "Bob", Date: at(11), Subject: "Tidy", Path: "login.go"}, ShortSHA: "ccccccc", HasNote: true},
+		},
+	}
+}
+
+func TestRenderFileHistory(t *testing.T) {
+	out := RenderFileHistory(testFileHistory())
+
+	for _, want := range []string{
+		"3 commit(s), 1 with edits from AI sessions, 1 prompt(s) led to edits",
+		"Ann (as auth.go)",
+		"No captured sessions",
+		"Claude Code 5f2b8c1e\n",
+		"Add a login <handler>",
+		"Edit ×2, Write",
+		"Sessions captured, none edited this file",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("history missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Index(out, "aaaaaaa") > strings.Index(out, "ccccccc") {
+		t.Error("commits are not in chronological order")
+	}
+}
+
+func TestWriteFileHistoryHTML(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteFileHistoryHTML(testFileHistory(), &buf); err != nil {
+		t.Fatalf("WriteFileHistoryHTML() error: %v", err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "login &lt;handler&gt;") {
+		t.Error("prompt text is not escaped")
+	}
+	if !strings.Contains(out, "as <code>auth.go</code>") {
+		t.Error("renamed path not shown")
+	}
+}
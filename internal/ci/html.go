@@ -9,6 +9,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/QuesmaOrg/git-prompt-story/internal/display"
+	"github.com/QuesmaOrg/git-prompt-story/internal/editor"
 	"github.com/QuesmaOrg/git-prompt-story/internal/note"
 )
 
@@ -25,35 +27,101 @@ type IndexData struct {
 	TotalPrompts     int
 }
 
+// DefaultPageSize is how many steps of a session a commit page shows
+// before loading the rest in pages as the reader scrolls
+const DefaultPageSize = 500
+
 // CommitViewData holds data for displaying a commit
 type CommitViewData struct {
 	SHA         string
 	ShortSHA    string
 	Subject     string
 	Sessions    []SessionSummary
+	Views       []SessionView
+	StepPages   map[string]string // step ID -> fragment file, for paged steps
 	StartWork   time.Time
 	EndWork     time.Time
 	ToolNames   string
 	PromptCount int
 	CSS         template.CSS
+	DiffPage    string // Page showing the diff next to the prompts, if generated
+}
+
+// SessionView is a session as rendered on a commit page. Only its first
+// page of steps is inline; the other pages are HTML fragment files fetched
+// by the page as the reader scrolls or follows a step permalink.
+type SessionView struct {
+	SessionSummary
+	Number   int        // 1-based position on the page
+	Steps    []StepView // the inline first page
+	Pages    []string   // fragment file names of the remaining pages
+	PageSize int
+}
+
+// StepView is one step of a session view; its StepID is the permalink
+type StepView struct {
+	PromptEntry
+	SHA string // commit SHA, for editor links
+}
+
+// GenerateHTML creates HTML files for the summary in the output directory.
+// Sessions with more than pageSize steps are split into lazily loaded
+// pages; pageSize 0 renders every step inline.
+func GenerateHTML(summary *Summary, outputDir string, prNumber, pageSize int) error {
+	r, err := newPageRenderer(outputDir)
+	if err != nil {
+		return err
+	}
+	indexTmpl, err := r.parse("index")
+	if err != nil {
+		return err
+	}
+	commits := r.commitViews(summary, pageSize)
+
+	// Generate index.html
+	indexData := IndexData{
+		PRNumber:         prNumber,
+		CSS:              r.css,
+		Commits:          commits,
+		CommitsAnalyzed:  summary.CommitsAnalyzed,
+		CommitsWithNotes: summary.CommitsWithNotes,
+		TotalPrompts:     summary.TotalPrompts,
+	}
+	if err := renderFile(indexTmpl, filepath.Join(outputDir, "index.html"), indexData); err != nil {
+		return err
+	}
+	return r.writeCommitPages(outputDir, commits)
+}
+
+// pageRenderer holds the stylesheet and template helpers shared by the
+// generated pages
+type pageRenderer struct {
+	css   template.CSS
+	funcs template.FuncMap
 }
 
-// GenerateHTML creates HTML files for the summary in the output directory
-func GenerateHTML(summary *Summary, outputDir string, prNumber int) error {
+// newPageRenderer loads the stylesheet and creates the output directory
+func newPageRenderer(outputDir string) (*pageRenderer, error) {
 	// Load CSS
 	cssBytes, err := templateFS.ReadFile("templates/styles.css")
 	if err != nil {
-		return fmt.Errorf("failed to load CSS: %w", err)
+		return nil, fmt.Errorf("failed to load CSS: %w", err)
 	}
-	css := template.CSS(cssBytes)
 
 	// Create output directory
 	if err := os.MkdirAll(outputDir, 0755); err != nil {
-		return fmt.Errorf("failed to create output directory: %w", err)
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
 	}
 
-	// Parse templates with helper functions
+	// Editor links need the repository name to find the reader's local clone
+	repoName := editor.RepoName()
+	resolver := editor.NewResolver()
+
+	// Template helper functions
 	funcMap := template.FuncMap{
+		"editorLink": func(commitSHA string, p PromptEntry) template.URL {
+			return editorLink(resolver, repoName, commitSHA, p)
+		},
 		"formatTime": func(t time.Time) string {
 			return t.Local().Format("2006-01-02 15:04")
 		},
@@ -70,41 +138,29 @@ func GenerateHTML(summary *Summary, outputDir string, prNumber int) error {
 		"add": func(a, b int) int {
 			return a + b
 		},
-		"entryCategory": func(entryType string) string {
-			switch entryType {
-			case "PROMPT", "COMMAND", "TOOL_REJECT":
-				return "user"
-			case "ASSISTANT":
-				return "assistant"
-			case "TOOL_USE", "TOOL_RESULT":
-				return "tool"
-			default:
-				return "other"
-			}
-		},
-	}
-
-	// Load and parse index template
-	indexTmplBytes, err := templateFS.ReadFile("templates/index.html.tmpl")
-	if err != nil {
-		return fmt.Errorf("failed to load index template: %w", err)
-	}
-	indexTmpl, err := template.New("index").Funcs(funcMap).Parse(string(indexTmplBytes))
-	if err != nil {
-		return fmt.Errorf("failed to parse index template: %w", err)
+		"entryCategory": display.TypeCategory,
+		"oneLine":       display.TruncateText,
+		"sessionPage":   SessionPageName,
+		"editCounts":    editCounts,
 	}
+	return &pageRenderer{css: template.CSS(cssBytes), funcs: funcMap}, nil
+}
 
-	// Load and parse commit template
-	commitTmplBytes, err := templateFS.ReadFile("templates/commit.html.tmpl")
+// parse loads and parses templates/<name>.html.tmpl
+func (r *pageRenderer) parse(name string) (*template.Template, error) {
+	tmplBytes, err := templateFS.ReadFile("templates/" + name + ".html.tmpl")
 	if err != nil {
-		return fmt.Errorf("failed to load commit template: %w", err)
+		return nil, fmt.Errorf("failed to load %s template: %w", name, err)
 	}
-	commitTmpl, err := template.New("commit").Funcs(funcMap).Parse(string(commitTmplBytes))
+	tmpl, err := template.New(name).Funcs(r.funcs).Parse(string(tmplBytes))
 	if err != nil {
-		return fmt.Errorf("failed to parse commit template: %w", err)
+		return nil, fmt.Errorf("failed to parse %s template: %w", name, err)
 	}
+	return tmpl, nil
+}
 
-	// Prepare commit view data
+// commitViews prepares the commit view data of the summary
+func (r *pageRenderer) commitViews(summary *Summary, pageSize int) []CommitViewData {
 	var commits []CommitViewData
 	for _, cs := range summary.Commits {
 		cvd := CommitViewData{
@@ -114,7 +170,7 @@ func GenerateHTML(summary *Summary, outputDir string, prNumber int) error {
 			Sessions:  cs.Sessions,
 			StartWork: cs.StartWork,
 			EndWork:   cs.EndWork,
-			CSS:       css,
+			CSS:       r.css,
 		}
 
 		// Calculate tool names and prompt count
@@ -128,45 +184,133 @@ func GenerateHTML(summary *Summary, outputDir string, prNumber int) error {
 			toolNames = append(toolNames, t)
 		}
 		cvd.ToolNames = strings.Join(toolNames, ", ")
+		cvd.Views = sessionViews(cs, pageSize)
+		cvd.StepPages = stepPages(cvd.Views)
 
 		commits = append(commits, cvd)
 	}
+	return commits
+}
 
-	// Generate index.html
-	indexData := IndexData{
-		PRNumber:         prNumber,
-		CSS:              css,
-		Commits:          commits,
-		CommitsAnalyzed:  summary.CommitsAnalyzed,
-		CommitsWithNotes: summary.CommitsWithNotes,
-		TotalPrompts:     summary.TotalPrompts,
+// writeCommitPages generates the page of each commit, with the fragment
+// files of its long sessions
+func (r *pageRenderer) writeCommitPages(outputDir string, commits []CommitViewData) error {
+	commitTmpl, err := r.parse("commit")
+	if err != nil {
+		return err
+	}
+	for _, cvd := range commits {
+		if err := renderFile(commitTmpl, filepath.Join(outputDir, cvd.ShortSHA+".html"), cvd); err != nil {
+			return err
+		}
+		if err := writeStepPages(commitTmpl, outputDir, cvd); err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
-	indexPath := filepath.Join(outputDir, "index.html")
-	indexFile, err := os.Create(indexPath)
+// renderFile executes tmpl with data into the file at path
+func renderFile(tmpl *template.Template, path string, data any) error {
+	f, err := os.Create(path)
 	if err != nil {
-		return fmt.Errorf("failed to create index.html: %w", err)
+		return fmt.Errorf("failed to create %s: %w", filepath.Base(path), err)
 	}
-	defer indexFile.Close()
-
-	if err := indexTmpl.Execute(indexFile, indexData); err != nil {
-		return fmt.Errorf("failed to render index.html: %w", err)
+	if err := tmpl.Execute(f, data); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to render %s: %w", filepath.Base(path), err)
 	}
+	return f.Close()
+}
 
-	// Generate individual commit pages
-	for _, cvd := range commits {
-		commitPath := filepath.Join(outputDir, cvd.ShortSHA+".html")
-		commitFile, err := os.Create(commitPath)
-		if err != nil {
-			return fmt.Errorf("failed to create %s.html: %w", cvd.ShortSHA, err)
+// sessionViews splits the sessions of a commit into pages of pageSize steps
+func sessionViews(cs CommitSummary, pageSize int) []SessionView {
+	views := make([]SessionView, 0, len(cs.Sessions))
+	for i, sess := range cs.Sessions {
+		v := SessionView{SessionSummary: sess, Number: i + 1, PageSize: pageSize}
+		inline := len(sess.Prompts)
+		if pageSize > 0 && inline > pageSize {
+			inline = pageSize
+			for page := 1; page*pageSize < len(sess.Prompts); page++ {
+				v.Pages = append(v.Pages, fmt.Sprintf("%s-s%d-p%d.html", cs.ShortSHA, v.Number, page+1))
+			}
 		}
+		v.Steps = v.stepViews(cs.SHA, 0, inline)
+		views = append(views, v)
+	}
+	return views
+}
 
-		if err := commitTmpl.Execute(commitFile, cvd); err != nil {
-			commitFile.Close()
-			return fmt.Errorf("failed to render %s.html: %w", cvd.ShortSHA, err)
+// stepViews returns the views of steps [from, to) of the session
+func (v SessionView) stepViews(sha string, from, to int) []StepView {
+	steps := make([]StepView, 0, to-from)
+	for i := from; i < to; i++ {
+		steps = append(steps, StepView{PromptEntry: v.Prompts[i], SHA: sha})
+	}
+	return steps
+}
+
+// stepPages maps the ID of every step outside a session's first page to
+// the fragment file holding it, so permalinks can load the right page
+func stepPages(views []SessionView) map[string]string {
+	pages := make(map[string]string)
+	for _, v := range views {
+		for i, name := range v.Pages {
+			from := (i + 1) * v.PageSize
+			to := min(from+v.PageSize, len(v.Prompts))
+			for _, p := range v.Prompts[from:to] {
+				pages[p.StepID] = name
+			}
 		}
-		commitFile.Close()
 	}
+	return pages
+}
+
+// writeStepPages writes the fragment files holding the steps of each
+// session beyond its first page
+func writeStepPages(tmpl *template.Template, outputDir string, cvd CommitViewData) error {
+	for _, v := range cvd.Views {
+		for i, name := range v.Pages {
+			from := (i + 1) * v.PageSize
+			to := min(from+v.PageSize, len(v.Prompts))
 
+			f, err := os.Create(filepath.Join(outputDir, name))
+			if err != nil {
+				return fmt.Errorf("failed to create %s: %w", name, err)
+			}
+			for _, step := range v.stepViews(cvd.SHA, from, to) {
+				if err := tmpl.ExecuteTemplate(f, "step", step); err != nil {
+					f.Close()
+					return fmt.Errorf("failed to render %s: %w", name, err)
+				}
+			}
+			f.Close()
+		}
+	}
 	return nil
 }
+
+// editorLink returns a git-prompt-story://open link to the file a tool
+// entry touched, or empty if the file is not part of the commit
+func editorLink(resolver *editor.Resolver, repoName, commitSHA string, p PromptEntry) template.URL {
+	if p.FilePath == "" {
+		return ""
+	}
+	relPath := resolver.RelPath(commitSHA, p.FilePath)
+	if relPath == "" {
+		return ""
+	}
+
+	line := p.FileLine
+	if line == 0 && p.FileAnchor != "" {
+		line = resolver.Line(commitSHA, relPath, p.FileAnchor)
+	}
+
+	// The scheme is ours, so mark it safe for html/template
+	return template.URL(editor.BuildOpenURI(editor.OpenRequest{
+		Repo:   repoName,
+		Path:   relPath,
+		Line:   line,
+		Commit: commitSHA,
+	}))
+}
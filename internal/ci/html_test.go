@@ -0,0 +1,46 @@
+package ci
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestSessionViews_Paging(t *testing.T) {
+	cs := CommitSummary{SHA: "abc1234def", ShortSHA: "abc1234", Sessions: []SessionSummary{
+		{ID: "short", Prompts: make([]PromptEntry, 3)},
+		{ID: "long", Prompts: make([]PromptEntry, 12)},
+	}}
+	for i := range cs.Sessions[1].Prompts {
+		cs.Sessions[1].Prompts[i].StepID = fmt.Sprintf("step-%d", i)
+	}
+
+	views := sessionViews(cs, 5)
+	if len(views[0].Steps) != 3 || views[0].Pages != nil {
+		t.Errorf("short session: %d steps, pages %v; want 3 inline, no pages", len(views[0].Steps), views[0].Pages)
+	}
+
+	long := views[1]
+	if len(long.Steps) != 5 {
+		t.Errorf("long session inline steps = %d, want 5", len(long.Steps))
+	}
+	wantPages := []string{"abc1234-s2-p2.html", "abc1234-s2-p3.html"}
+	if !reflect.DeepEqual(long.Pages, wantPages) {
+		t.Errorf("Pages = %v, want %v", long.Pages, wantPages)
+	}
+	if long.Steps[0].SHA != cs.SHA {
+		t.Errorf("first step = %+v, want commit SHA", long.Steps[0])
+	}
+	if last := long.stepViews(cs.SHA, 10, 12); len(last) != 2 {
+		t.Errorf("last page = %+v, want 2 steps", last)
+	}
+
+	pages := stepPages(views)
+	if len(pages) != 7 || pages["step-4"] != "" || pages["step-5"] != wantPages[0] || pages["step-11"] != wantPages[1] {
+		t.Errorf("stepPages() = %v, want steps 5-11 mapped to their pages", pages)
+	}
+
+	if views := sessionViews(cs, 0); len(views[1].Steps) != 12 || views[1].Pages != nil {
+		t.Error("page size 0 should render every step inline")
+	}
+}
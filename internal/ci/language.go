@@ -0,0 +1,127 @@
+package ci
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Language detection limits: a language is dominant in a session when at
+// least minLanguageShare of its file touches are in it, and at most
+// maxLanguages are kept
+const (
+	minLanguageShare = 0.2
+	maxLanguages     = 3
+)
+
+// languageByExt maps lowercase file extensions to language names
+var languageByExt = map[string]string{
+	".go":     "Go",
+	".py":     "Python",
+	".js":     "JavaScript",
+	".jsx":    "JavaScript",
+	".mjs":    "JavaScript",
+	".cjs":    "JavaScript",
+	".ts":     "TypeScript",
+	".tsx":    "TypeScript",
+	".rs":     "Rust",
+	".java":   "Java",
+	".kt":     "Kotlin",
+	".kts":    "Kotlin",
+	".swift":  "Swift",
+	".rb":     "Ruby",
+	".php":    "PHP",
+	".cs":     "C#",
+	".c":      "C",
+	".h":      "C",
+	".cc":     "C++",
+	".cpp":    "C++",
+	".cxx":    "C++",
+	".hpp":    "C++",
+	".scala":  "Scala",
+	".ex":     "Elixir",
+	".exs":    "Elixir",
+	".erl":    "Erlang",
+	".hs":     "Haskell",
+	".lua":    "Lua",
+	".dart":   "Dart",
+	".r":      "R",
+	".sql":    "SQL",
+	".sh":     "Shell",
+	".bash":   "Shell",
+	".zsh":    "Shell",
+	".ps1":    "PowerShell",
+	".html":   "HTML",
+	".css":    "CSS",
+	".scss":   "CSS",
+	".vue":    "Vue",
+	".svelte": "Svelte",
+	".tf":     "Terraform",
+	".proto":  "Protobuf",
+	".md":     "Markdown",
+	".yaml":   "YAML",
+	".yml":    "YAML",
+	".json":   "JSON",
+	".toml":   "TOML",
+}
+
+// languageByName maps file names without a telling extension
+var languageByName = map[string]string{
+	"dockerfile":  "Dockerfile",
+	"makefile":    "Makefile",
+	"gnumakefile": "Makefile",
+	"go.mod":      "Go",
+	"go.sum":      "Go",
+}
+
+// LanguageOf returns the language of a file from its name, or "" if unknown
+func LanguageOf(path string) string {
+	base := strings.ToLower(filepath.Base(path))
+	if lang, ok := languageByName[base]; ok {
+		return lang
+	}
+	return languageByExt[filepath.Ext(base)]
+}
+
+// detectLanguages returns the dominant languages of the files a session's
+// tools touched, most touched first
+func detectLanguages(prompts []PromptEntry) []string {
+	counts := make(map[string]int)
+	total := 0
+	for _, p := range prompts {
+		if p.FilePath == "" {
+			continue
+		}
+		if lang := LanguageOf(p.FilePath); lang != "" {
+			counts[lang]++
+			total++
+		}
+	}
+
+	var langs []string
+	for lang, n := range counts {
+		if float64(n) >= minLanguageShare*float64(total) {
+			langs = append(langs, lang)
+		}
+	}
+	sort.Slice(langs, func(i, j int) bool {
+		if counts[langs[i]] != counts[langs[j]] {
+			return counts[langs[i]] > counts[langs[j]]
+		}
+		return langs[i] < langs[j]
+	})
+	if len(langs) > maxLanguages {
+		langs = langs[:maxLanguages]
+	}
+	return langs
+}
+
+// languageChips renders languages as inline code chips, with a leading
+// space, or "" when there are none
+func languageChips(r Renderer, langs []string) string {
+	var sb strings.Builder
+	for _, lang := range langs {
+		sb.WriteString(" " + r.Code(lang))
+	}
+	return sb.String()
+}
@@ -0,0 +1,70 @@
+package ci
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLanguageOf(t *testing.T) {
+	tests := map[string]string{
+		"/work/app/main.go":         "Go",
+		"src/App.TSX":               "TypeScript",
+		"scripts/build.sh":          "Shell",
+		"/work/app/Dockerfile":      "Dockerfile",
+		"go.mod":                    "Go",
+		"README":                    "",
+		"/work/app/assets/logo.png": "",
+	}
+	for path, want := range tests {
+		if got := LanguageOf(path); got != want {
+			t.Errorf("LanguageOf(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestDetectLanguages(t *testing.T) {
+	touch := func(paths ...string) []PromptEntry {
+		var prompts []PromptEntry
+		for _, p := range paths {
+			prompts = append(prompts, PromptEntry{Type: "TOOL_USE", ToolName: "Edit", FilePath: p})
+		}
+		return append(prompts, PromptEntry{Type: "PROMPT", Text: "no file"})
+	}
+
+	tests := []struct {
+		name    string
+		prompts []PromptEntry
+		want    []string
+	}{
+		{"none", touch(), nil},
+		{"single", touch("a.go", "b.go"), []string{"Go"}},
+		{"most touched first", touch("a.ts", "a.go", "b.ts", "c.ts", "b.go"), []string{"TypeScript", "Go"}},
+		// One Markdown file among nine Go ones is under the 20% share
+		{"minor language dropped", touch("1.go", "2.go", "3.go", "4.go", "5.go", "6.go", "7.go", "8.go", "9.go", "README.md"), []string{"Go"}},
+		{"at most three", touch("a.go", "b.py", "c.rs", "d.rb"), []string{"Go", "Python", "Ruby"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detectLanguages(tt.prompts); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("detectLanguages() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSessionHeader_LanguageChips(t *testing.T) {
+	start := time.Date(2025, 1, 15, 10, 0, 0, 0, time.Local)
+	sess := SessionSummary{
+		Tool:      "claude-code",
+		Start:     start,
+		End:       start.Add(30 * time.Minute),
+		Prompts:   make([]PromptEntry, 4),
+		Languages: []string{"Go", "TypeScript"},
+	}
+	header := sessionHeader(MarkdownRenderer, sess, 4)
+	if !strings.HasSuffix(header, "(10:00-10:30, 4 steps) `Go` `TypeScript`\n") {
+		t.Errorf("sessionHeader() = %q", header)
+	}
+}
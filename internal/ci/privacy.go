@@ -0,0 +1,157 @@
+package ci
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// PrivacyOptions blur the per-author and per-week counts of Stats, so
+// adoption metrics can be shared without exact per-person prompt counts
+type PrivacyOptions struct {
+	// Epsilon is the differential privacy budget for one commit; smaller
+	// is noisier. Zero adds no noise. It is split evenly over every count
+	// released, see CountEpsilon.
+	Epsilon float64 `json:"epsilon,omitempty"`
+
+	// Bucket rounds each count to the nearest multiple of Bucket, after
+	// the noise; session lengths to multiples of Bucket minutes. Zero or
+	// one keeps exact counts.
+	Bucket int `json:"bucket,omitempty"`
+
+	// Rand is the source of the noise, a time-seeded one when nil
+	Rand *rand.Rand `json:"-"`
+}
+
+// commitBounds caps what one commit adds to each count once noise is
+// added: the cap is the sensitivity the noise of the count is scaled to.
+// A commit adding more is clipped to it.
+var commitBounds = StatsCounts{
+	Commits:          1,
+	CommitsWithNotes: 1,
+	UserPrompts:      50,
+	AgentPrompts:     100,
+	Sessions:         5,
+	AgentSessions:    20,
+	ToolUses:         500,
+	SessionSeconds:   4 * 60 * 60,
+}
+
+// privateCounts is how many counts are released per commit: the eight of
+// StatsCounts, once in its author's row and once in its week's
+const privateCounts = 2 * 8
+
+// Enabled reports whether opts change any count
+func (opts PrivacyOptions) Enabled() bool {
+	return opts.Epsilon > 0 || opts.Bucket > 1
+}
+
+// CountEpsilon returns the share of Epsilon each count is noised with. By
+// sequential composition the counts of a commit together spend Epsilon;
+// the rows of different authors, or weeks, hold different commits and
+// spend it in parallel.
+func (opts PrivacyOptions) CountEpsilon() float64 {
+	return opts.Epsilon / privateCounts
+}
+
+// Privatize replaces the author and week counts of stats made by
+// BuildStats with blurred ones. With Epsilon, each commit is clipped to
+// commitBounds and each count gets Laplace noise calibrated to its bound
+// and CountEpsilon. The total is the sum of the blurred author rows, and
+// the language and tool breakdowns, which a commit can add to several rows
+// of, are left out, so no exact count is released alongside the blurred
+// ones.
+func (s *Stats) Privatize(opts PrivacyOptions) {
+	if !opts.Enabled() {
+		return
+	}
+	if opts.Rand == nil {
+		opts.Rand = rand.New(rand.NewSource(rand.Int63()))
+	}
+
+	authors := make(map[string]StatsCounts)
+	weeks := make(map[string]StatsCounts)
+	for _, c := range s.commits {
+		counts := c.counts
+		if opts.Epsilon > 0 {
+			counts = counts.clip(commitBounds)
+		}
+		a, w := authors[c.author], weeks[c.week]
+		a.add(counts)
+		w.add(counts)
+		authors[c.author], weeks[c.week] = a, w
+	}
+
+	s.Total = StatsCounts{}
+	for i := range s.Authors {
+		s.Authors[i].StatsCounts = opts.blurCounts(authors[s.Authors[i].Author])
+		s.Total.add(s.Authors[i].StatsCounts)
+	}
+	for i := range s.Weeks {
+		s.Weeks[i].StatsCounts = opts.blurCounts(weeks[s.Weeks[i].Week])
+	}
+	s.Languages = nil
+	s.Tools = nil
+
+	sort.SliceStable(s.Authors, func(i, j int) bool {
+		a, b := s.Authors[i], s.Authors[j]
+		if a.UserPrompts != b.UserPrompts {
+			return a.UserPrompts > b.UserPrompts
+		}
+		return a.Author < b.Author
+	})
+}
+
+// clip caps each count at the one of bounds
+func (c StatsCounts) clip(bounds StatsCounts) StatsCounts {
+	return StatsCounts{
+		Commits:          min(c.Commits, bounds.Commits),
+		CommitsWithNotes: min(c.CommitsWithNotes, bounds.CommitsWithNotes),
+		UserPrompts:      min(c.UserPrompts, bounds.UserPrompts),
+		AgentPrompts:     min(c.AgentPrompts, bounds.AgentPrompts),
+		Sessions:         min(c.Sessions, bounds.Sessions),
+		AgentSessions:    min(c.AgentSessions, bounds.AgentSessions),
+		ToolUses:         min(c.ToolUses, bounds.ToolUses),
+		SessionSeconds:   min(c.SessionSeconds, bounds.SessionSeconds),
+	}
+}
+
+// blurCounts blurs each count of c, see blur
+func (opts PrivacyOptions) blurCounts(c StatsCounts) StatsCounts {
+	b := commitBounds
+	c.Commits = int(opts.blur(int64(c.Commits), int64(b.Commits), 1))
+	c.CommitsWithNotes = min(int(opts.blur(int64(c.CommitsWithNotes), int64(b.CommitsWithNotes), 1)), c.Commits)
+	c.UserPrompts = int(opts.blur(int64(c.UserPrompts), int64(b.UserPrompts), 1))
+	c.AgentPrompts = int(opts.blur(int64(c.AgentPrompts), int64(b.AgentPrompts), 1))
+	c.Sessions = int(opts.blur(int64(c.Sessions), int64(b.Sessions), 1))
+	c.AgentSessions = int(opts.blur(int64(c.AgentSessions), int64(b.AgentSessions), 1))
+	c.ToolUses = int(opts.blur(int64(c.ToolUses), int64(b.ToolUses), 1))
+	c.SessionSeconds = opts.blur(c.SessionSeconds, b.SessionSeconds, 60)
+	return c
+}
+
+// blur adds noise scaled to sensitivity to n and rounds it to the bucket,
+// in multiples of unit, never below zero
+func (opts PrivacyOptions) blur(n, sensitivity, unit int64) int64 {
+	v := float64(n)
+	if opts.Epsilon > 0 {
+		v += laplace(opts.Rand, float64(sensitivity)/opts.CountEpsilon())
+	}
+	if opts.Bucket > 1 {
+		step := float64(int64(opts.Bucket) * unit)
+		v = math.Round(v/step) * step
+	}
+	return max(int64(math.Round(v)), 0)
+}
+
+// laplace draws from the Laplace distribution centered on zero
+func laplace(r *rand.Rand, scale float64) float64 {
+	u := r.Float64() - 0.5
+	if u == -0.5 {
+		return 0 // log(0) would be infinite; the chance is 2^-53
+	}
+	if u < 0 {
+		return scale * math.Log(1+2*u)
+	}
+	return -scale * math.Log(1-2*u)
+}
@@ -0,0 +1,132 @@
+package ci
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// privacyStats are stats of two authors' commits, one with an outsized
+// session
+func privacyStats() *Stats {
+	at := func(day int) time.Time { return time.Date(2025, 1, day, 12, 0, 0, 0, time.UTC) }
+	prompts := func(n int) []PromptEntry {
+		p := make([]PromptEntry, n)
+		for i := range p {
+			p[i] = PromptEntry{Type: "PROMPT"}
+		}
+		return p
+	}
+	summary := &Summary{Commits: []CommitSummary{
+		{SHA: "a1", Sessions: []SessionSummary{
+			{ID: "s1", Start: at(14), End: at(14).Add(10 * time.Hour), Languages: []string{"Go"}, Prompts: prompts(200)},
+		}},
+		{SHA: "a2", Sessions: []SessionSummary{{ID: "s2", Prompts: append(prompts(7), PromptEntry{Type: "TOOL_USE", ToolName: "Edit"})}}},
+		{SHA: "b1", Sessions: []SessionSummary{{ID: "s3", Prompts: prompts(13)}}},
+	}}
+	return BuildStats(summary, []StatsCommit{
+		{SHA: "a1", Author: "Ann", Date: at(14)},
+		{SHA: "a2", Author: "Ann", Date: at(21)},
+		{SHA: "b1", Author: "Bob", Date: at(21)},
+		{SHA: "b2", Author: "Bob", Date: at(21)},
+	})
+}
+
+func TestPrivatize_Bucket(t *testing.T) {
+	stats := privacyStats()
+	stats.Privatize(PrivacyOptions{Bucket: 5})
+
+	ann, bob := stats.Authors[0], stats.Authors[1]
+	if ann.Author != "Ann" || ann.Commits != 0 || ann.UserPrompts != 205 || ann.SessionSeconds != 10*60*60 {
+		t.Errorf("bucketed Ann = %+v", ann)
+	}
+	if bob.Commits != 0 || bob.UserPrompts != 15 {
+		t.Errorf("bucketed Bob = %+v", bob)
+	}
+	if stats.Total.UserPrompts != 220 || stats.Total.Commits != 0 {
+		t.Errorf("total = %+v, want the sum of the blurred authors", stats.Total)
+	}
+	if len(stats.Weeks) != 2 || stats.Weeks[1].UserPrompts != 20 || stats.Weeks[1].Commits != 5 {
+		t.Errorf("weeks = %+v", stats.Weeks)
+	}
+	if stats.Languages != nil || stats.Tools != nil {
+		t.Errorf("breakdowns kept: %+v, %v", stats.Languages, stats.Tools)
+	}
+
+	stats = privacyStats()
+	stats.Privatize(PrivacyOptions{})
+	if stats.Total.UserPrompts != 220 || stats.Total.Commits != 4 || len(stats.Languages) != 2 || stats.Tools["Edit"] != 1 {
+		t.Errorf("disabled options changed %+v", stats)
+	}
+}
+
+func TestPrivatize_Noise(t *testing.T) {
+	stats := privacyStats()
+	stats.Privatize(PrivacyOptions{Epsilon: 0.5, Bucket: 5, Rand: rand.New(rand.NewSource(1))})
+
+	var total StatsCounts
+	for _, a := range stats.Authors {
+		for _, n := range []int{a.Commits, a.CommitsWithNotes, a.UserPrompts, a.Sessions} {
+			if n < 0 || n%5 != 0 {
+				t.Errorf("%s: count %d is not a non-negative multiple of 5", a.Author, n)
+			}
+		}
+		if a.SessionSeconds%(5*60) != 0 {
+			t.Errorf("%s: session length %ds is not a multiple of 5 minutes", a.Author, a.SessionSeconds)
+		}
+		if a.CommitsWithNotes > a.Commits {
+			t.Errorf("%s: %d of %d commits with notes", a.Author, a.CommitsWithNotes, a.Commits)
+		}
+		total.add(a.StatsCounts)
+	}
+	if stats.Total != total {
+		t.Errorf("total = %+v, want the sum of the authors %+v", stats.Total, total)
+	}
+}
+
+func TestPrivacyOptions_CountEpsilon(t *testing.T) {
+	opts := PrivacyOptions{Epsilon: 1, Rand: rand.New(rand.NewSource(1))}
+	if got := opts.CountEpsilon(); got != 1.0/16 {
+		t.Errorf("CountEpsilon() = %g, want 1/16", got)
+	}
+
+	// Each count is noised at the scale of its bound over its epsilon
+	const n = 20000
+	var abs float64
+	for i := 0; i < n; i++ {
+		abs += math.Abs(float64(opts.blur(1e6, int64(commitBounds.UserPrompts), 1) - 1e6))
+	}
+	if want := 50 * 16.0; math.Abs(abs/n-want) > want/20 {
+		t.Errorf("mean noise = %.0f prompts, want about %.0f", abs/n, want)
+	}
+}
+
+func TestStatsCounts_Clip(t *testing.T) {
+	c := StatsCounts{Commits: 1, CommitsWithNotes: 1, UserPrompts: 200, Sessions: 2, ToolUses: 900, SessionSeconds: 10 * 60 * 60}
+	want := StatsCounts{Commits: 1, CommitsWithNotes: 1, UserPrompts: 50, Sessions: 2, ToolUses: 500, SessionSeconds: 4 * 60 * 60}
+	if got := c.clip(commitBounds); got != want {
+		t.Errorf("clip() = %+v, want %+v", got, want)
+	}
+}
+
+func TestLaplace(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	var sum, abs float64
+	const n = 20000
+	for i := 0; i < n; i++ {
+		x := laplace(r, 2)
+		sum += x
+		if x < 0 {
+			x = -x
+		}
+		abs += x
+	}
+	// The mean is 0 and the mean absolute value is the scale
+	if mean := sum / n; mean < -0.1 || mean > 0.1 {
+		t.Errorf("mean = %f, want about 0", mean)
+	}
+	if meanAbs := abs / n; meanAbs < 1.9 || meanAbs > 2.1 {
+		t.Errorf("mean absolute value = %f, want about 2", meanAbs)
+	}
+}
@@ -0,0 +1,93 @@
+package ci
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Provenance ties an edit of a file to the prompt that led to it
+type Provenance struct {
+	CommitSHA string
+	Tool      string
+	SessionID string
+	Cwd       string // The session's working directory, if recorded
+
+	// Prompt is the prompt, command or decision the edit followed; nil if
+	// the session had none before it in the work period
+	Prompt *PromptEntry
+
+	// Edit is the Edit or Write tool use
+	Edit PromptEntry
+}
+
+// ProvenanceIndex maps file paths, as the tools recorded them, to the edits
+// captured sessions made to them
+type ProvenanceIndex map[string][]Provenance
+
+// BuildProvenanceIndex indexes the file edits of the commits' sessions
+func BuildProvenanceIndex(commits []CommitSummary) ProvenanceIndex {
+	idx := make(ProvenanceIndex)
+	for _, cs := range commits {
+		for _, sess := range cs.Sessions {
+			var prompt *PromptEntry
+			for _, p := range sess.Prompts {
+				switch {
+				case p.Type == "PROMPT" || p.Type == "COMMAND" || p.Type == "DECISION":
+					entry := p
+					prompt = &entry
+				case p.Type == "TOOL_USE" && (p.ToolName == "Edit" || p.ToolName == "Write") && p.FilePath != "":
+					idx[p.FilePath] = append(idx[p.FilePath], Provenance{
+						CommitSHA: cs.SHA,
+						Tool:      sess.Tool,
+						SessionID: sess.ID,
+						Cwd:       sess.Cwd,
+						Prompt:    prompt,
+						Edit:      p,
+					})
+				}
+			}
+		}
+	}
+	return idx
+}
+
+// Lookup returns the edits of the file at path, relative to the repository
+// root, oldest first. Tools mostly record absolute paths, so recorded paths
+// ending in path match, unless the session's working directory shows they
+// are another file with the same name.
+func (idx ProvenanceIndex) Lookup(path string) []Provenance {
+	path = strings.TrimPrefix(filepath.ToSlash(filepath.Clean(path)), "./")
+
+	var matches []string
+	for recorded := range idx {
+		slashed := filepath.ToSlash(recorded)
+		if slashed == path || strings.HasSuffix(slashed, "/"+path) {
+			matches = append(matches, recorded)
+		}
+	}
+	sort.Strings(matches)
+
+	var found []Provenance
+	for _, recorded := range matches {
+		for _, p := range idx[recorded] {
+			if underCwd(filepath.ToSlash(recorded), p.Cwd, path) {
+				found = append(found, p)
+			}
+		}
+	}
+	sort.SliceStable(found, func(i, j int) bool { return found[i].Edit.Time.Before(found[j].Edit.Time) })
+	return found
+}
+
+// underCwd reports whether recorded, a path ending in path, is path in a
+// session working in cwd. The session may have worked in a subdirectory of
+// the repository, where its paths are relative to that directory.
+func underCwd(recorded, cwd, path string) bool {
+	cwd = strings.TrimSuffix(filepath.ToSlash(cwd), "/")
+	if cwd == "" || !strings.HasPrefix(recorded, cwd+"/") {
+		return true
+	}
+	rel := strings.TrimPrefix(recorded, cwd+"/")
+	return rel == path || strings.HasSuffix(path, "/"+rel)
+}
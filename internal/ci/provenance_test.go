@@ -0,0 +1,80 @@
+package ci
+
+import (
+	"testing"
+	"time"
+)
+
+func TestProvenanceIndex_Lookup(t *testing.T) {
+	at := func(m int) time.Time { return time.Date(2025, 1, 15, 10, m, 0, 0, time.UTC) }
+	commits := []CommitSummary{{
+		SHA: "abc1234def",
+		Sessions: []SessionSummary{{
+			Tool: "claude-code",
+			ID:   "s1",
+			Cwd:  "/home/me/app",
+			Prompts: []PromptEntry{
+				{Time: at(0), Type: "TOOL_USE", ToolName: "Edit", FilePath: "/home/me/app/main.go"},
+				{Time: at(1), Type: "PROMPT", Text: "Add a flag", StepID: "step-1"},
+				{Time: at(2), Type: "TOOL_USE", ToolName: "Read", FilePath: "/home/me/app/main.go"},
+				{Time: at(3), Type: "TOOL_USE", ToolName: "Edit", FilePath: "/home/me/app/main.go"},
+				{Time: at(4), Type: "TOOL_USE", ToolName: "Write", FilePath: "/home/me/app/cmd/main.go"},
+				{Time: at(5), Type: "PROMPT", Text: "Rename it", StepID: "step-2"},
+				{Time: at(6), Type: "TOOL_USE", ToolName: "Edit", FilePath: "/home/me/app/domain.go"},
+			},
+		}},
+	}}
+
+	idx := BuildProvenanceIndex(commits)
+
+	got := idx.Lookup("main.go")
+	if len(got) != 2 {
+		t.Fatalf("Lookup(main.go) returned %d edits, want 2 (reads and other files excluded)", len(got))
+	}
+	if got[0].Prompt != nil {
+		t.Errorf("edit before any prompt: Prompt = %q, want nil", got[0].Prompt.Text)
+	}
+	if got[1].Prompt == nil || got[1].Prompt.Text != "Add a flag" {
+		t.Errorf("edit after prompt: Prompt = %v, want %q", got[1].Prompt, "Add a flag")
+	}
+	if got[1].CommitSHA != "abc1234def" || got[1].SessionID != "s1" {
+		t.Errorf("edit provenance = %s/%s, want abc1234def/s1", got[1].CommitSHA, got[1].SessionID)
+	}
+
+	if got := idx.Lookup("./cmd/main.go"); len(got) != 1 || got[0].Edit.ToolName != "Write" {
+		t.Errorf("Lookup(./cmd/main.go) = %v, want the Write", got)
+	}
+	if got := idx.Lookup("ain.go"); len(got) != 0 {
+		t.Errorf("Lookup(ain.go) matched %d edits, want only whole path components", len(got))
+	}
+}
+
+func TestProvenanceIndex_LookupWithoutCwd(t *testing.T) {
+	idx := BuildProvenanceIndex([]CommitSummary{{Sessions: []SessionSummary{{
+		ID: "s1",
+		Prompts: []PromptEntry{
+			{Type: "TOOL_USE", ToolName: "Edit", FilePath: "/home/me/app/cmd/main.go"},
+		},
+	}}}})
+	if got := idx.Lookup("main.go"); len(got) != 1 {
+		t.Errorf("Lookup(main.go) = %d edits, want 1: without a working directory any suffix matches", len(got))
+	}
+}
+
+func TestUnderCwd(t *testing.T) {
+	tests := []struct {
+		recorded, cwd, path string
+		want                bool
+	}{
+		{"/repo/main.go", "/repo", "main.go", true},
+		{"/repo/cmd/main.go", "/repo", "main.go", false},
+		{"/repo/sub/x.go", "/repo/sub", "sub/x.go", true},
+		{"/elsewhere/main.go", "/repo", "main.go", true},
+		{"/repo/main.go", "", "main.go", true},
+	}
+	for _, tt := range tests {
+		if got := underCwd(tt.recorded, tt.cwd, tt.path); got != tt.want {
+			t.Errorf("underCwd(%q, %q, %q) = %v, want %v", tt.recorded, tt.cwd, tt.path, got, tt.want)
+		}
+	}
+}
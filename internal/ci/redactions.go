@@ -0,0 +1,79 @@
+package ci
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// redactedMarker matches the placeholder of a user redaction, capturing
+// its reason code, see show.RedactedPlaceholder
+var redactedMarker = regexp.MustCompile(`<REDACTED BY USER(?:: ([a-z]+))?>`)
+
+// unspecifiedReason counts redactions made without a reason code
+const unspecifiedReason = "unspecified"
+
+// RedactionCount is how many steps of a summary were redacted for a reason
+type RedactionCount struct {
+	Reason string
+	Steps  int
+}
+
+// RedactionRollup counts the redacted steps of a summary by reason, most
+// common first. A step is counted once per reason, and once even if its
+// session is listed under several commits.
+func RedactionRollup(summary *Summary) []RedactionCount {
+	counts := make(map[string]int)
+	seen := make(map[string]bool)
+	for _, c := range summary.Commits {
+		for _, sess := range c.Sessions {
+			for _, p := range sess.Prompts {
+				key := sess.Tool + "/" + sess.ID + "@" + p.Time.String() + " " + p.Type
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+
+				reasons := make(map[string]bool)
+				for _, text := range []string{p.Text, p.ToolInput, p.ToolOutput} {
+					for _, m := range redactedMarker.FindAllStringSubmatch(text, -1) {
+						reason := m[1]
+						if reason == "" {
+							reason = unspecifiedReason
+						}
+						reasons[reason] = true
+					}
+				}
+				for reason := range reasons {
+					counts[reason]++
+				}
+			}
+		}
+	}
+
+	rollup := make([]RedactionCount, 0, len(counts))
+	for reason, n := range counts {
+		rollup = append(rollup, RedactionCount{Reason: reason, Steps: n})
+	}
+	sort.Slice(rollup, func(i, j int) bool {
+		if rollup[i].Steps != rollup[j].Steps {
+			return rollup[i].Steps > rollup[j].Steps
+		}
+		return rollup[i].Reason < rollup[j].Reason
+	})
+	return rollup
+}
+
+// renderRedactionRollup formats the rollup as a paragraph, or "" when
+// nothing was redacted
+func renderRedactionRollup(r Renderer, rollup []RedactionCount) string {
+	if len(rollup) == 0 {
+		return ""
+	}
+	parts := make([]string, len(rollup))
+	for i, rc := range rollup {
+		parts[i] = fmt.Sprintf("%s (%d)", r.Escape(rc.Reason), rc.Steps)
+	}
+	return r.Paragraph(r.Bold("Redacted steps:") + " " + strings.Join(parts, ", "))
+}
@@ -0,0 +1,55 @@
+package ci
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRedactionRollup(t *testing.T) {
+	t0 := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+	sess := SessionSummary{Tool: "claude-code", ID: "s1", Prompts: []PromptEntry{
+		{Time: t0, Type: "PROMPT", Text: "Deploy with <REDACTED BY USER: secret> and <REDACTED BY USER: secret>"},
+		{Time: t0.Add(time.Minute), Type: "PROMPT", Text: "<REDACTED BY USER: personal>"},
+		{Time: t0.Add(2 * time.Minute), Type: "TOOL_RESULT", ToolOutput: "token=<REDACTED BY USER: secret>"},
+		{Time: t0.Add(3 * time.Minute), Type: "ASSISTANT", Text: "<REDACTED BY USER>"},
+		{Time: t0.Add(4 * time.Minute), Type: "ASSISTANT", Text: "Done"},
+	}}
+	// The same session listed under two commits is counted once
+	summary := &Summary{Commits: []CommitSummary{
+		{ShortSHA: "aaa", Sessions: []SessionSummary{sess}},
+		{ShortSHA: "bbb", Sessions: []SessionSummary{sess}},
+	}}
+
+	rollup := RedactionRollup(summary)
+	want := []RedactionCount{{"secret", 2}, {"personal", 1}, {"unspecified", 1}}
+	if !reflect.DeepEqual(rollup, want) {
+		t.Errorf("RedactionRollup() = %v, want %v", rollup, want)
+	}
+
+	got := renderRedactionRollup(MarkdownRenderer, rollup)
+	if got != "**Redacted steps:** secret (2), personal (1), unspecified (1)\n\n" {
+		t.Errorf("renderRedactionRollup() = %q", got)
+	}
+	if got := renderRedactionRollup(MarkdownRenderer, nil); got != "" {
+		t.Errorf("renderRedactionRollup(nil) = %q, want empty", got)
+	}
+}
+
+func TestRenderMarkdown_RedactionRollup(t *testing.T) {
+	summary := &Summary{
+		CommitsWithNotes: 1,
+		Commits: []CommitSummary{{
+			SHA: "abc1234567890", ShortSHA: "abc1234", Subject: "Add login",
+			Sessions: []SessionSummary{{Tool: "claude-code", ID: "s1", Prompts: []PromptEntry{
+				{Time: time.Now(), Type: "PROMPT", Text: "Use <REDACTED BY USER: secret>"},
+			}}},
+		}},
+	}
+
+	md := RenderMarkdown(summary, "", "v1")
+	if !strings.Contains(md, "**Redacted steps:** secret (1)") {
+		t.Errorf("Expected redaction rollup in markdown:\n%s", md)
+	}
+}
@@ -0,0 +1,282 @@
+package ci
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/display"
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+	"github.com/QuesmaOrg/git-prompt-story/internal/note"
+)
+
+// ReleaseCommit is a commit listed in release notes
+type ReleaseCommit struct {
+	SHA      string
+	ShortSHA string
+	Subject  string
+	Body     string
+}
+
+// releaseGroup is a section of release notes: the commits of one or more
+// conventional commit types
+type releaseGroup struct {
+	Title string
+	Types []string
+}
+
+// releaseGroups are the sections of release notes, in order; commits of
+// other types, or without one, go to "Other changes"
+var releaseGroups = []releaseGroup{
+	{"Features", []string{"feat", "feature"}},
+	{"Bug fixes", []string{"fix", "bugfix"}},
+	{"Performance", []string{"perf"}},
+	{"Refactoring", []string{"refactor"}},
+	{"Documentation", []string{"docs"}},
+	{"Tests", []string{"test", "tests"}},
+	{"Build and CI", []string{"build", "ci"}},
+	{"Chores", []string{"chore", "style", "revert"}},
+}
+
+// otherChanges is the section of commits without a known type
+const otherChanges = "Other changes"
+
+// conventionalSubject matches "type(scope)!: description"
+var conventionalSubject = regexp.MustCompile(`^(\w+)(?:\(([^)]*)\))?(!)?:\s*(.+)$`)
+
+// ConventionalCommit is a commit subject split per the Conventional Commits
+// specification
+type ConventionalCommit struct {
+	Type        string // Lowercased; empty if the subject does not follow the convention
+	Scope       string
+	Description string
+	Breaking    bool
+}
+
+// ParseConventionalCommit splits a commit subject, and its body for a
+// BREAKING CHANGE footer. Other subjects are returned whole as the
+// description.
+func ParseConventionalCommit(subject, body string) ConventionalCommit {
+	breakingFooter := strings.Contains(body, "BREAKING CHANGE:") || strings.Contains(body, "BREAKING-CHANGE:")
+	m := conventionalSubject.FindStringSubmatch(subject)
+	if m == nil {
+		return ConventionalCommit{Description: subject, Breaking: breakingFooter}
+	}
+	return ConventionalCommit{
+		Type:        strings.ToLower(m[1]),
+		Scope:       m[2],
+		Description: m[4],
+		Breaking:    m[3] == "!" || breakingFooter,
+	}
+}
+
+// GenerateReleaseNotes builds a markdown changelog for the commits in a
+// range, titled after the range's end unless title is given
+func GenerateReleaseNotes(commitRange, title string) (string, error) {
+	shas, err := git.ResolveCommitSpec(commitRange)
+	if err != nil {
+		return "", err
+	}
+
+	summary, err := GenerateSummary(commitRange, false)
+	if err != nil {
+		return "", err
+	}
+
+	// Oldest first, as the changes were made
+	commits := make([]ReleaseCommit, 0, len(shas))
+	for i := len(shas) - 1; i >= 0; i-- {
+		subject, _ := getCommitSubject(shas[i])
+		body, _ := git.RunGit("log", "-1", "--format=%b", shas[i])
+		commits = append(commits, ReleaseCommit{
+			SHA:      shas[i],
+			ShortSHA: shas[i][:7],
+			Subject:  subject,
+			Body:     body,
+		})
+	}
+
+	if title == "" {
+		title = releaseTitle(commitRange)
+	}
+	return RenderReleaseNotes(title, commits, summary), nil
+}
+
+// releaseTitle names a release after the end of its range, e.g. v1.3.0
+// for v1.2.0..v1.3.0
+func releaseTitle(commitRange string) string {
+	end := commitRange
+	if i := strings.LastIndex(commitRange, ".."); i >= 0 {
+		end = strings.TrimPrefix(commitRange[i+2:], ".")
+	}
+	if end == "" || end == "HEAD" {
+		return "Unreleased"
+	}
+	return end
+}
+
+// RenderReleaseNotes renders a changelog: the commits grouped by
+// conventional commit type, each group followed by the key prompts and
+// decisions of its commits, and a closing section on how much of the
+// release was built with LLM sessions
+func RenderReleaseNotes(title string, commits []ReleaseCommit, summary *Summary) string {
+	bySHA := make(map[string]CommitSummary, len(summary.Commits))
+	for _, cs := range summary.Commits {
+		bySHA[cs.SHA] = cs
+	}
+
+	groupOf := make(map[string]string)
+	for _, g := range releaseGroups {
+		for _, t := range g.Types {
+			groupOf[t] = g.Title
+		}
+	}
+	grouped := make(map[string][]ReleaseCommit)
+	var breaking []ReleaseCommit
+	for _, c := range commits {
+		cc := ParseConventionalCommit(c.Subject, c.Body)
+		group, ok := groupOf[cc.Type]
+		if !ok {
+			group = otherChanges
+		}
+		grouped[group] = append(grouped[group], c)
+		if cc.Breaking {
+			breaking = append(breaking, c)
+		}
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# %s\n", escapeMarkdownLine(title))
+
+	if len(breaking) > 0 {
+		sb.WriteString("\n## Breaking changes\n\n")
+		for _, c := range breaking {
+			writeReleaseCommit(&sb, c, bySHA[c.SHA])
+		}
+	}
+
+	type groupStats struct {
+		title           string
+		commits, withAI int
+	}
+	var stats []groupStats
+	titles := make([]string, 0, len(releaseGroups)+1)
+	for _, g := range releaseGroups {
+		titles = append(titles, g.Title)
+	}
+	titles = append(titles, otherChanges)
+
+	for _, groupTitle := range titles {
+		group := grouped[groupTitle]
+		if len(group) == 0 {
+			continue
+		}
+		fmt.Fprintf(&sb, "\n## %s\n\n", groupTitle)
+		st := groupStats{title: groupTitle, commits: len(group)}
+		type keyEntry struct {
+			shortSHA string
+			entry    PromptEntry
+		}
+		var keys []keyEntry
+		for _, c := range group {
+			cs, ok := bySHA[c.SHA]
+			writeReleaseCommit(&sb, c, cs)
+			if !ok || len(cs.Sessions) == 0 {
+				continue
+			}
+			st.withAI++
+			for _, p := range keyEntries(cs) {
+				keys = append(keys, keyEntry{c.ShortSHA, p})
+			}
+		}
+		stats = append(stats, st)
+
+		if len(keys) == 0 {
+			continue
+		}
+		fmt.Fprintf(&sb, "\n<details>\n<summary>Key prompts and decisions (%d)</summary>\n\n", len(keys))
+		for _, k := range keys {
+			text := escapeMarkdownLine(display.TruncateText(k.entry.Text, maxDescribePromptLength))
+			if k.entry.Type == "DECISION" {
+				if k.entry.DecisionHeader != "" {
+					text = escapeMarkdownLine(k.entry.DecisionHeader) + ": " + text
+				}
+				text += " → **" + escapeMarkdownLine(k.entry.DecisionAnswer) + "**"
+			}
+			fmt.Fprintf(&sb, "- `%s` %s %s\n", k.shortSHA, display.GetTypeEmoji(k.entry.Type), text)
+		}
+		sb.WriteString("\n</details>\n")
+	}
+
+	if summary.CommitsWithNotes > 0 {
+		sb.WriteString("\n## How it was built\n\n")
+		fmt.Fprintf(&sb, "%d of %d commits were made with LLM sessions (%d user prompts", summary.CommitsWithNotes, summary.CommitsAnalyzed, summary.TotalUserPrompts)
+		if tools := releaseTools(summary); len(tools) > 0 {
+			fmt.Fprintf(&sb, "; %s", strings.Join(tools, ", "))
+		}
+		sb.WriteString(").\n\n")
+		sb.WriteString("| Changes | Commits | With LLM sessions |\n")
+		sb.WriteString("| ------- | ------: | ----------------: |\n")
+		for _, st := range stats {
+			fmt.Fprintf(&sb, "| %s | %d | %d |\n", st.title, st.commits, st.withAI)
+		}
+	}
+
+	return sb.String()
+}
+
+// writeReleaseCommit writes a changelog line, noting the tools of the
+// commit's sessions
+func writeReleaseCommit(sb *strings.Builder, c ReleaseCommit, cs CommitSummary) {
+	cc := ParseConventionalCommit(c.Subject, c.Body)
+	line := escapeMarkdownLine(cc.Description)
+	if cc.Scope != "" {
+		line = "**" + escapeMarkdownLine(cc.Scope) + ":** " + line
+	}
+	fmt.Fprintf(sb, "- %s (`%s`)", line, c.ShortSHA)
+
+	var tools []string
+	for _, sess := range cs.Sessions {
+		name := note.FormatToolName(sess.Tool)
+		if !sess.IsAgent && sess.Withheld() == "" && !slices.Contains(tools, name) {
+			tools = append(tools, name)
+		}
+	}
+	if len(tools) > 0 {
+		fmt.Fprintf(sb, " · %s", strings.Join(tools, ", "))
+	}
+	sb.WriteString("\n")
+}
+
+// releaseTools counts the commits made with each tool, most used first
+func releaseTools(summary *Summary) []string {
+	counts := make(map[string]int)
+	for _, cs := range summary.Commits {
+		seen := make(map[string]bool)
+		for _, sess := range cs.Sessions {
+			name := note.FormatToolName(sess.Tool)
+			if !sess.IsAgent && !seen[name] {
+				seen[name] = true
+				counts[name]++
+			}
+		}
+	}
+
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if counts[names[i]] != counts[names[j]] {
+			return counts[names[i]] > counts[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	tools := make([]string, len(names))
+	for i, name := range names {
+		tools[i] = fmt.Sprintf("%s in %d", name, counts[name])
+	}
+	return tools
+}
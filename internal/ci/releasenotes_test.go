@@ -0,0 +1,87 @@
+package ci
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseConventionalCommit(t *testing.T) {
+	tests := []struct {
+		subject, body string
+		want          ConventionalCommit
+	}{
+		{"feat(parser): add YAML support", "", ConventionalCommit{Type: "feat", Scope: "parser", Description: "add YAML support"}},
+		{"Fix!: drop the v1 format", "", ConventionalCommit{Type: "fix", Description: "drop the v1 format", Breaking: true}},
+		{"refactor: split config", "Details.\n\nBREAKING CHANGE: config moved", ConventionalCommit{Type: "refactor", Description: "split config", Breaking: true}},
+		{"Update README: typos", "", ConventionalCommit{Description: "Update README: typos"}},
+	}
+	for _, tt := range tests {
+		if got := ParseConventionalCommit(tt.subject, tt.body); got != tt.want {
+			t.Errorf("ParseConventionalCommit(%q) = %+v, want %+v", tt.subject, got, tt.want)
+		}
+	}
+}
+
+func TestReleaseTitle(t *testing.T) {
+	for rng, want := range map[string]string{
+		"v1.2.0..v1.3.0":  "v1.3.0",
+		"v1.2.0...v1.3.0": "v1.3.0",
+		"v1.3.0..HEAD":    "Unreleased",
+		"v1.3.0..":        "Unreleased",
+	} {
+		if got := releaseTitle(rng); got != want {
+			t.Errorf("releaseTitle(%q) = %q, want %q", rng, got, want)
+		}
+	}
+}
+
+func TestRenderReleaseNotes(t *testing.T) {
+	now := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+	commits := []ReleaseCommit{
+		{SHA: "aaa1111aaaa", ShortSHA: "aaa1111", Subject: "feat(parser): add YAML support"},
+		{SHA: "bbb2222bbbb", ShortSHA: "bbb2222", Subject: "fix: handle empty files"},
+		{SHA: "ccc3333cccc", ShortSHA: "ccc3333", Subject: "Bump version"},
+		{SHA: "ddd4444dddd", ShortSHA: "ddd4444", Subject: "feat!: drop the v1 format"},
+	}
+	summary := &Summary{
+		CommitsAnalyzed:  4,
+		CommitsWithNotes: 1,
+		TotalUserPrompts: 2,
+		Commits: []CommitSummary{{
+			SHA: "aaa1111aaaa",
+			Sessions: []SessionSummary{{
+				Tool: "claude-code",
+				Prompts: []PromptEntry{
+					{Type: "PROMPT", Text: "Add YAML support to the <config> parser", Time: now, InWorkPeriod: true},
+					{Type: "DECISION", Text: "Which library?", DecisionHeader: "Library", DecisionAnswer: "yaml.v3", Time: now.Add(time.Minute), InWorkPeriod: true},
+				},
+			}},
+		}},
+	}
+
+	got := RenderReleaseNotes("v1.3.0", commits, summary)
+
+	for _, want := range []string{
+		"# v1.3.0\n",
+		"## Breaking changes\n\n- drop the v1 format (`ddd4444`)",
+		"## Features\n\n- **parser:** add YAML support (`aaa1111`) · Claude Code\n- drop the v1 format (`ddd4444`)\n",
+		"## Bug fixes\n\n- handle empty files (`bbb2222`)\n",
+		"## Other changes\n\n- Bump version (`ccc3333`)\n",
+		"<summary>Key prompts and decisions (2)</summary>",
+		"- `aaa1111` 💬 Add YAML support to the &lt;config&gt; parser",
+		"Library: Which library? → **yaml.v3**",
+		"1 of 4 commits were made with LLM sessions (2 user prompts; Claude Code in 1)",
+		"| Features | 2 | 1 |",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("release notes missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Index(got, "## Features") > strings.Index(got, "## Bug fixes") {
+		t.Error("groups should keep their order")
+	}
+	if strings.Count(got, "<details>") != 1 {
+		t.Errorf("only groups with sessions should list prompts:\n%s", got)
+	}
+}
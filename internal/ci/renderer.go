@@ -0,0 +1,263 @@
+package ci
+
+import (
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+)
+
+// Renderer writes the markup of a rendered summary. The layout (sections,
+// ordering, truncation) is the same for every renderer; only the markup
+// differs. Text passed to the block methods is already escaped with
+// Escape, except for Code and CodeBlock, which take raw text.
+type Renderer interface {
+	Heading(level int, text string) string
+	Paragraph(text string) string
+	// Line is a line of text directly followed by other blocks, such as
+	// a session header above its steps
+	Line(text string) string
+	// ListStart and ListEnd enclose a run of ListItems
+	ListStart() string
+	ListEnd() string
+	// ListItem is an item with optional sub-items nested under it. depth
+	// indents the item in plain-text formats; it is not a nesting level.
+	ListItem(depth int, text string, sub ...string) string
+	// Details is a collapsed block under a summary line
+	Details(summary, body string) string
+	// InlineDetails shows summary, the start of a long text ending in
+	// "...", and reveals more, the rest of it, on demand
+	InlineDetails(summary, more string) string
+	Table(header []string, rows [][]string) string
+	Rule() string
+	CodeBlock(lang, code string) string
+	Bold(text string) string
+	Italic(text string) string
+	Code(text string) string
+	Link(text, url string) string
+	Escape(text string) string
+}
+
+// Renderers by name, for pr summary --renderer
+var renderers = map[string]Renderer{
+	"markdown": MarkdownRenderer,
+	"jira":     JiraRenderer,
+	"html":     HTMLRenderer,
+}
+
+// DefaultRenderer is the name of the renderer used for PR comments
+const DefaultRenderer = "markdown"
+
+// RendererNames returns the names accepted by RendererByName, sorted
+func RendererNames() []string {
+	names := make([]string, 0, len(renderers))
+	for name := range renderers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RendererByName returns the named renderer
+func RendererByName(name string) (Renderer, error) {
+	r, ok := renderers[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown renderer %q (available: %s)", name, strings.Join(RendererNames(), ", "))
+	}
+	return r, nil
+}
+
+// MarkdownRenderer writes GitHub-flavored markdown, with HTML for
+// collapsible sections
+var MarkdownRenderer Renderer = markdownRenderer{}
+
+type markdownRenderer struct{}
+
+func (markdownRenderer) Heading(level int, text string) string {
+	return strings.Repeat("#", level) + " " + text + "\n\n"
+}
+
+func (markdownRenderer) Paragraph(text string) string { return text + "\n\n" }
+func (markdownRenderer) Line(text string) string      { return text + "\n" }
+func (markdownRenderer) ListStart() string            { return "" }
+func (markdownRenderer) ListEnd() string              { return "" }
+
+func (markdownRenderer) ListItem(depth int, text string, sub ...string) string {
+	item := strings.Repeat("  ", depth) + "- " + text + "\n"
+	for _, s := range sub {
+		item += strings.Repeat("  ", depth+1) + "- " + s + "\n"
+	}
+	return item
+}
+
+func (markdownRenderer) Details(summary, body string) string {
+	return "<details><summary>" + summary + "</summary>\n\n" + body + "</details>\n\n"
+}
+
+func (markdownRenderer) InlineDetails(summary, more string) string {
+	return "<details><summary>" + summary + "</summary>" + more + "</details>"
+}
+
+func (markdownRenderer) Table(header []string, rows [][]string) string {
+	var sb strings.Builder
+	sep := make([]string, len(header))
+	for i, h := range header {
+		sep[i] = strings.Repeat("-", len(h)+2)
+	}
+	sb.WriteString("| " + strings.Join(header, " | ") + " |\n")
+	sb.WriteString("|" + strings.Join(sep, "|") + "|\n")
+	for _, row := range rows {
+		cells := make([]string, len(row))
+		for i, c := range row {
+			cells[i] = markdownCell(c)
+		}
+		sb.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// markdownCell backslash-escapes pipes, as GitHub splits table rows on them
+// before parsing inline markup, even inside code spans
+func markdownCell(text string) string {
+	return strings.ReplaceAll(text, "|", `\|`)
+}
+
+func (markdownRenderer) Rule() string { return "---\n" }
+
+func (markdownRenderer) CodeBlock(lang, code string) string {
+	return "```" + lang + "\n" + code + "\n```\n"
+}
+
+func (markdownRenderer) Bold(text string) string      { return "**" + text + "**" }
+func (markdownRenderer) Italic(text string) string    { return "*" + text + "*" }
+func (markdownRenderer) Code(text string) string      { return "`" + text + "`" }
+func (markdownRenderer) Link(text, url string) string { return "[" + text + "](" + url + ")" }
+func (markdownRenderer) Escape(text string) string    { return html.EscapeString(text) }
+
+// JiraRenderer writes Jira wiki markup. Jira has no collapsible text, so
+// collapsed blocks become panels and long prompts are shown in full.
+var JiraRenderer Renderer = jiraRenderer{}
+
+type jiraRenderer struct{}
+
+func (jiraRenderer) Heading(level int, text string) string {
+	return fmt.Sprintf("h%d. %s\n\n", level, text)
+}
+
+func (jiraRenderer) Paragraph(text string) string { return text + "\n\n" }
+func (jiraRenderer) Line(text string) string      { return text + "\n" }
+func (jiraRenderer) ListStart() string            { return "" }
+func (jiraRenderer) ListEnd() string              { return "" }
+
+func (jiraRenderer) ListItem(depth int, text string, sub ...string) string {
+	item := "* " + text + "\n"
+	for _, s := range sub {
+		item += "** " + s + "\n"
+	}
+	return item
+}
+
+func (jiraRenderer) Details(summary, body string) string {
+	return "{panel:title=" + summary + "}\n" + body + "{panel}\n\n"
+}
+
+func (jiraRenderer) InlineDetails(summary, more string) string {
+	return strings.TrimSuffix(summary, "...") + strings.TrimPrefix(more, "...")
+}
+
+func (jiraRenderer) Table(header []string, rows [][]string) string {
+	var sb strings.Builder
+	sb.WriteString("||" + strings.Join(header, "||") + "||\n")
+	for _, row := range rows {
+		cells := make([]string, len(row))
+		for i, c := range row {
+			if c == "" {
+				c = " " // Empty cells merge with the next one
+			}
+			cells[i] = c
+		}
+		sb.WriteString("|" + strings.Join(cells, "|") + "|\n")
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+func (jiraRenderer) Rule() string { return "----\n" }
+
+func (jiraRenderer) CodeBlock(lang, code string) string {
+	if lang == "" {
+		return "{code}\n" + code + "\n{code}\n"
+	}
+	return "{code:" + lang + "}\n" + code + "\n{code}\n"
+}
+
+func (jiraRenderer) Bold(text string) string      { return "*" + text + "*" }
+func (jiraRenderer) Italic(text string) string    { return "_" + text + "_" }
+func (r jiraRenderer) Code(text string) string    { return "{{" + r.Escape(text) + "}}" }
+func (jiraRenderer) Link(text, url string) string { return "[" + text + "|" + url + "]" }
+
+// jiraEscaper backslash-escapes the characters Jira reads as markup
+var jiraEscaper = strings.NewReplacer(
+	`\`, `\\`, "{", `\{`, "}", `\}`, "[", `\[`, "]", `\]`, "|", `\|`,
+	"*", `\*`, "_", `\_`, "^", `\^`, "~", `\~`, "+", `\+`, "-", `\-`, "!", `\!`,
+)
+
+func (jiraRenderer) Escape(text string) string { return jiraEscaper.Replace(text) }
+
+// HTMLRenderer writes an HTML fragment, for systems that accept neither
+// markdown nor Jira markup
+var HTMLRenderer Renderer = htmlRenderer{}
+
+type htmlRenderer struct{}
+
+func (htmlRenderer) Heading(level int, text string) string {
+	return fmt.Sprintf("<h%d>%s</h%d>\n", level, text, level)
+}
+
+func (htmlRenderer) Paragraph(text string) string { return "<p>" + text + "</p>\n" }
+func (htmlRenderer) Line(text string) string      { return "<p>" + text + "</p>\n" }
+func (htmlRenderer) ListStart() string            { return "<ul>\n" }
+func (htmlRenderer) ListEnd() string              { return "</ul>\n" }
+
+func (htmlRenderer) ListItem(depth int, text string, sub ...string) string {
+	item := "<li>" + text
+	if len(sub) > 0 {
+		item += "<ul><li>" + strings.Join(sub, "</li><li>") + "</li></ul>"
+	}
+	return item + "</li>\n"
+}
+
+func (htmlRenderer) Details(summary, body string) string {
+	return "<details><summary>" + summary + "</summary>\n" + body + "</details>\n"
+}
+
+func (htmlRenderer) InlineDetails(summary, more string) string {
+	return "<details><summary>" + summary + "</summary>" + more + "</details>"
+}
+
+func (htmlRenderer) Table(header []string, rows [][]string) string {
+	var sb strings.Builder
+	sb.WriteString("<table>\n<tr><th>" + strings.Join(header, "</th><th>") + "</th></tr>\n")
+	for _, row := range rows {
+		sb.WriteString("<tr><td>" + strings.Join(row, "</td><td>") + "</td></tr>\n")
+	}
+	sb.WriteString("</table>\n")
+	return sb.String()
+}
+
+func (htmlRenderer) Rule() string { return "<hr>\n" }
+
+func (htmlRenderer) CodeBlock(lang, code string) string {
+	return "<pre><code>" + html.EscapeString(code) + "</code></pre>\n"
+}
+
+func (htmlRenderer) Bold(text string) string   { return "<strong>" + text + "</strong>" }
+func (htmlRenderer) Italic(text string) string { return "<em>" + text + "</em>" }
+func (htmlRenderer) Code(text string) string   { return "<code>" + html.EscapeString(text) + "</code>" }
+
+func (htmlRenderer) Link(text, url string) string {
+	return `<a href="` + html.EscapeString(url) + `">` + text + "</a>"
+}
+
+func (htmlRenderer) Escape(text string) string { return html.EscapeString(text) }
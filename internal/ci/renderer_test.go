@@ -0,0 +1,88 @@
+package ci
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func rendererTestSummary() *Summary {
+	now := time.Date(2025, 1, 15, 10, 0, 0, 0, time.Local)
+	return &Summary{
+		CommitsWithNotes: 1,
+		Commits: []CommitSummary{{
+			SHA: "abc1234567890", ShortSHA: "abc1234", Subject: "Fix a|b [parser] <tags>",
+			Sessions: []SessionSummary{{
+				Tool: "claude-code", Start: now, End: now.Add(10 * time.Minute),
+				Prompts: []PromptEntry{
+					{Type: "PROMPT", Text: "Handle *bold* and <b>tags</b>", Time: now},
+					{Type: "COMMAND", Text: "/review <file>", Time: now.Add(time.Minute)},
+					{Type: "PROMPT", Text: strings.Repeat("long prompt ", 30), Time: now.Add(2 * time.Minute)},
+					{Type: "TOOL_USE", ToolName: "Edit", ToolInput: `{"file_path":"/src/a.go"}`, Time: now.Add(3 * time.Minute)},
+				},
+			}},
+		}},
+	}
+}
+
+func TestRenderJira(t *testing.T) {
+	out := Render(JiraRenderer, rendererTestSummary(), "https://example.com/pr-1/", "v1")
+
+	for _, want := range []string{
+		"h1. 3 user prompts (1 file edits)",
+		"* Handle \\*bold\\* and <b>tags</b>",
+		"* {{/review <file>}}",
+		"{panel:title=Show all...}",
+		"||Commit||Subject||Tool(s)||User Prompts||Steps||",
+		"|abc1234|Fix a\\|b \\[parser\\] <tags>|Claude Code|3|4|",
+		"[View full transcripts|https://example.com/pr-1/]",
+		"_Generated by [git-prompt-story|https://github.com/QuesmaOrg/git-prompt-story] v1_",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Jira output missing %q:\n%s", want, out)
+		}
+	}
+	// Long prompts are shown in full, as Jira cannot collapse them
+	if !strings.Contains(out, strings.TrimSpace(strings.Repeat("long prompt ", 30))) {
+		t.Errorf("Expected the long prompt in full:\n%s", out)
+	}
+	for _, markup := range []string{"<details>", "`", "](", "# "} {
+		if strings.Contains(out, markup) {
+			t.Errorf("Jira output contains markdown %q:\n%s", markup, out)
+		}
+	}
+}
+
+func TestRenderHTML(t *testing.T) {
+	out := Render(HTMLRenderer, rendererTestSummary(), "", "v1")
+
+	for _, want := range []string{
+		"<h1>3 user prompts (1 file edits)</h1>",
+		"<li>Handle *bold* and &lt;b&gt;tags&lt;/b&gt;</li>",
+		"<li><code>/review &lt;file&gt;</code></li>",
+		"<details><summary>Show all...</summary>",
+		"<tr><th>Commit</th><th>Subject</th><th>Tool(s)</th><th>User Prompts</th><th>Steps</th></tr>",
+		"<tr><td>abc1234</td><td>Fix a|b [parser] &lt;tags&gt;</td>",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("HTML output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Count(out, "<ul>") != strings.Count(out, "</ul>") {
+		t.Errorf("Unbalanced lists:\n%s", out)
+	}
+}
+
+func TestRendererByName(t *testing.T) {
+	for _, name := range RendererNames() {
+		if _, err := RendererByName(name); err != nil {
+			t.Errorf("RendererByName(%q) error = %v", name, err)
+		}
+	}
+	if r, err := RendererByName("Markdown"); err != nil || r != MarkdownRenderer {
+		t.Errorf("RendererByName is not case-insensitive: %v, %v", r, err)
+	}
+	if _, err := RendererByName("rst"); err == nil {
+		t.Error("Expected an error for an unknown renderer")
+	}
+}
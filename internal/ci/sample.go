@@ -0,0 +1,103 @@
+package ci
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Sampling keeps every decision and rejection but only some prompts
+type Sampling struct {
+	Budget int `json:"budget"` // Prompts kept per commit
+	Kept   int `json:"kept"`   // Prompts kept across all commits
+	Total  int `json:"total"`  // Prompts before sampling
+}
+
+// sampledAlways are the user actions sampling never drops
+var sampledAlways = map[string]bool{"DECISION": true, "TOOL_REJECT": true}
+
+// SampleSummary keeps at most budget prompts (and commands) per commit,
+// spread evenly over the commit's main sessions in time order, with the
+// steps that followed them. Decisions, rejections and agent sessions are
+// kept whole. The result is deterministic; summary.Sampled is set when
+// anything was dropped.
+func SampleSummary(summary *Summary, budget int) {
+	if budget <= 0 {
+		return
+	}
+	sampling := &Sampling{Budget: budget}
+	for c := range summary.Commits {
+		kept, total := sampleCommit(&summary.Commits[c], budget)
+		sampling.Kept += kept
+		sampling.Total += total
+	}
+	if sampling.Kept < sampling.Total {
+		summary.Sampled = sampling
+	}
+}
+
+// sampleCommit drops the exchanges of the prompts of one commit beyond
+// budget, returning the number of prompts kept and found
+func sampleCommit(cs *CommitSummary, budget int) (int, int) {
+	type ref struct{ session, step int }
+	var prompts []ref
+	for si, sess := range cs.Sessions {
+		if sess.IsAgent {
+			continue
+		}
+		for i, p := range sess.Prompts {
+			if IsUserAction(p.Type) && !sampledAlways[p.Type] {
+				prompts = append(prompts, ref{si, i})
+			}
+		}
+	}
+	if len(prompts) <= budget {
+		return len(prompts), len(prompts)
+	}
+
+	// Sessions are in start order; interleave prompts by time
+	sort.SliceStable(prompts, func(i, j int) bool {
+		a, b := prompts[i], prompts[j]
+		return cs.Sessions[a.session].Prompts[a.step].Time.Before(cs.Sessions[b.session].Prompts[b.step].Time)
+	})
+	dropped := make(map[ref]bool, len(prompts))
+	for _, p := range prompts {
+		dropped[p] = true
+	}
+	for _, i := range evenlySpaced(len(prompts), budget) {
+		delete(dropped, prompts[i])
+	}
+
+	for si := range cs.Sessions {
+		sess := &cs.Sessions[si]
+		var kept []PromptEntry
+		dropping := false
+		for i, p := range sess.Prompts {
+			if IsUserAction(p.Type) {
+				dropping = dropped[ref{si, i}]
+			}
+			if !dropping {
+				kept = append(kept, p)
+			}
+		}
+		sess.Prompts = kept
+	}
+	return budget, len(prompts)
+}
+
+// evenlySpaced picks k of n indices spread evenly from first to last
+func evenlySpaced(n, k int) []int {
+	if k == 1 {
+		return []int{0}
+	}
+	picked := make([]int, k)
+	for i := range picked {
+		picked[i] = i * (n - 1) / (k - 1)
+	}
+	return picked
+}
+
+// sampledBanner explains that the summary shows a sample of the prompts
+func sampledBanner(r Renderer, s *Sampling) string {
+	return r.Paragraph(r.Bold("Sampled:") + " " + r.Italic(fmt.Sprintf(
+		"showing %d of %d prompts, at most %d per commit; decisions and rejections are always shown", s.Kept, s.Total, s.Budget)))
+}
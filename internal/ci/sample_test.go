@@ -0,0 +1,58 @@
+package ci
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSampleSummary(t *testing.T) {
+	at := func(m int) time.Time { return time.Date(2025, 1, 15, 10, m, 0, 0, time.UTC) }
+	var prompts []PromptEntry
+	for i := range 6 {
+		prompts = append(prompts,
+			PromptEntry{Type: "PROMPT", Text: "prompt " + string(rune('a'+i)), Time: at(2 * i)},
+			PromptEntry{Type: "ASSISTANT", Text: "reply", Time: at(2*i + 1)},
+		)
+	}
+	prompts = append(prompts, PromptEntry{Type: "DECISION", Text: "Which?", Time: at(20)})
+	summary := &Summary{CommitsWithNotes: 1, Commits: []CommitSummary{{
+		ShortSHA: "abc1234",
+		Sessions: []SessionSummary{
+			{ID: "main", Prompts: prompts},
+			{ID: "agent-1", IsAgent: true, Prompts: []PromptEntry{{Type: "PROMPT", Text: "agent task", Time: at(3)}}},
+		},
+	}}}
+
+	SampleSummary(summary, 3)
+
+	if s := summary.Sampled; s == nil || s.Kept != 3 || s.Total != 6 || s.Budget != 3 {
+		t.Fatalf("Sampled = %+v, want 3 of 6 prompts", summary.Sampled)
+	}
+	var texts []string
+	for _, p := range summary.Commits[0].Sessions[0].Prompts {
+		texts = append(texts, p.Text)
+	}
+	want := "prompt a,reply,prompt c,reply,prompt f,reply,Which?"
+	if got := strings.Join(texts, ","); got != want {
+		t.Errorf("kept %q, want %q", got, want)
+	}
+	if len(summary.Commits[0].Sessions[1].Prompts) != 1 {
+		t.Error("agent sessions should not be sampled")
+	}
+
+	out := RenderMarkdown(summary, "", "test")
+	if !strings.Contains(out, "showing 3 of 6 prompts") {
+		t.Errorf("missing sampled banner:\n%s", out)
+	}
+}
+
+func TestSampleSummary_UnderBudget(t *testing.T) {
+	summary := &Summary{Commits: []CommitSummary{{Sessions: []SessionSummary{
+		{Prompts: []PromptEntry{{Type: "PROMPT", Text: "only"}}},
+	}}}}
+	SampleSummary(summary, 5)
+	if summary.Sampled != nil || len(summary.Commits[0].Sessions[0].Prompts) != 1 {
+		t.Error("a summary within the budget should be left alone")
+	}
+}
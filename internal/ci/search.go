@@ -0,0 +1,237 @@
+package ci
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/display"
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+	"github.com/QuesmaOrg/git-prompt-story/internal/note"
+	"github.com/QuesmaOrg/git-prompt-story/internal/session"
+)
+
+// SearchTypes are the kinds of steps Search matches
+var SearchTypes = []string{"prompt", "assistant", "tool"}
+
+// SearchOptions selects what Search matches
+type SearchOptions struct {
+	Query  string    // Whitespace-separated terms that must all appear, ignoring case
+	Types  []string  // SearchTypes to match, all when empty
+	Author string    // Part of the session's author or OS user
+	Since  time.Time // Steps at or after, when set
+	Until  time.Time // Steps at or before, when set
+	Range  string    // Only transcripts noted on commits in this range, all stored ones when empty
+}
+
+// SearchMatch is a step of a stored transcript that matched a search
+type SearchMatch struct {
+	Time      time.Time `json:"time"`
+	Type      string    `json:"type"` // prompt, assistant or tool
+	Tool      string    `json:"tool"`
+	SessionID string    `json:"session_id"`
+	Author    string    `json:"author,omitempty"`
+	Commits   []string  `json:"commits,omitempty"` // Short SHAs of the commits noting the session, newest first
+	ToolName  string    `json:"tool_name,omitempty"`
+	Text      string    `json:"text"`
+	StepID    string    `json:"step_id"`
+}
+
+// searchedSession is a stored transcript with the commits that reference it
+type searchedSession struct {
+	entry   note.SessionEntry
+	commits []string
+}
+
+// Search scans the transcripts in note.TranscriptsRef for steps whose
+// prompt, assistant text or tool input contains every term of the query.
+// Matches are ordered by time; transcripts that fail to parse are skipped.
+func Search(opts SearchOptions) ([]SearchMatch, error) {
+	terms := strings.Fields(strings.ToLower(opts.Query))
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("empty search query")
+	}
+	for _, t := range opts.Types {
+		if !slices.Contains(SearchTypes, t) {
+			return nil, fmt.Errorf("unknown type %q (want %s)", t, strings.Join(SearchTypes, ", "))
+		}
+	}
+
+	rangeSpec := opts.Range
+	if rangeSpec == "" {
+		rangeSpec = "--all"
+	}
+	commits, err := note.ListCommits(rangeSpec, 0)
+	if err != nil {
+		return nil, err
+	}
+	stored, err := note.StoredTranscripts()
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make(map[string]*searchedSession)
+	for _, c := range commits {
+		for _, s := range c.Note.Sessions {
+			path := s.TranscriptPath()
+			if found, ok := sessions[path]; ok {
+				found.commits = append(found.commits, c.SHA[:7])
+				continue
+			}
+			sessions[path] = &searchedSession{entry: s, commits: []string{c.SHA[:7]}}
+		}
+	}
+	if opts.Range == "" {
+		// Orphaned transcripts have no note, so no author either
+		for path := range stored {
+			if _, ok := sessions[path]; !ok {
+				tool, name, _ := strings.Cut(path, "/")
+				sessions[path] = &searchedSession{entry: note.SessionEntry{Tool: tool, ID: strings.TrimSuffix(name, ".jsonl")}}
+			}
+		}
+	}
+
+	var matches []SearchMatch
+	for path, s := range sessions {
+		blob, ok := stored[path]
+		if !ok || (opts.Author != "" && !s.entry.MatchesAuthor(opts.Author)) {
+			continue
+		}
+		content, err := git.ReadBlob(blob)
+		if err != nil {
+			continue
+		}
+		if _, ok := note.ParseTombstone(content); ok {
+			continue
+		}
+		if _, ok := note.ParseQuarantineStub(content); ok {
+			continue
+		}
+		entries, err := session.ParseTranscript(s.entry.Tool, content)
+		if err != nil {
+			continue
+		}
+		session.MonotonicTimestamps(entries)
+		matches = append(matches, searchSession(s, entries, terms, opts)...)
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if !matches[i].Time.Equal(matches[j].Time) {
+			return matches[i].Time.Before(matches[j].Time)
+		}
+		return matches[i].StepID < matches[j].StepID
+	})
+	return matches, nil
+}
+
+// searchSession returns the steps of one transcript matching all terms
+// (lowercase) within the options' types and time bounds
+func searchSession(s *searchedSession, entries []session.MessageEntry, terms []string, opts SearchOptions) []SearchMatch {
+	until := opts.Until
+	if until.IsZero() {
+		until = time.Date(9999, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	ss := summarizeEntries(s.entry, entries, opts.Since, until, true)
+
+	var matches []SearchMatch
+	for _, p := range ss.Prompts {
+		kind, text := searchableText(p)
+		if kind == "" || (len(opts.Types) > 0 && !slices.Contains(opts.Types, kind)) {
+			continue
+		}
+		lower := strings.ToLower(text)
+		if !containsAll(lower, terms) {
+			continue
+		}
+		matches = append(matches, SearchMatch{
+			Time:      p.Time,
+			Type:      kind,
+			Tool:      ss.Tool,
+			SessionID: ss.ID,
+			Author:    ss.Owner,
+			Commits:   s.commits,
+			ToolName:  p.ToolName,
+			Text:      text,
+			StepID:    p.StepID,
+		})
+	}
+	return matches
+}
+
+// searchableText returns the search type and text of a step, or "" for
+// steps search ignores (tool output, notifications). Types registered by
+// providers are searched by their display.TypeCategory.
+func searchableText(p PromptEntry) (string, string) {
+	switch p.Type {
+	case "DECISION":
+		return "prompt", searchDecisionText(p)
+	case "TOOL_RESULT", "TASK_NOTIFICATION", "CONTEXT":
+		return "", ""
+	}
+	switch display.TypeCategory(p.Type) {
+	case display.CategoryUser:
+		return "prompt", p.Text
+	case display.CategoryAssistant:
+		return "assistant", p.Text
+	case display.CategoryTool:
+		return "tool", toolCallText(p)
+	}
+	if IsUserAction(p.Type) {
+		return "prompt", p.Text
+	}
+	return "", ""
+}
+
+// searchDecisionText returns the question and answer of a DECISION step
+func searchDecisionText(p PromptEntry) string {
+	return p.DecisionHeader + ": " + p.Text + " → " + p.DecisionAnswer
+}
+
+// toolCallText returns the tool and input of a tool call, or the text of
+// a registered tool type's step that is not a call
+func toolCallText(p PromptEntry) string {
+	if p.ToolName == "" {
+		return p.Text
+	}
+	return p.ToolName + " " + p.ToolInput
+}
+
+func containsAll(text string, terms []string) bool {
+	for _, t := range terms {
+		if !strings.Contains(text, t) {
+			return false
+		}
+	}
+	return true
+}
+
+// SearchSnippet returns one line of text of at most width runes around the
+// first term of the query it contains
+func SearchSnippet(text, query string, width int) string {
+	runes := []rune(strings.Join(strings.Fields(text), " "))
+	if len(runes) <= width {
+		return string(runes)
+	}
+	lower := []rune(strings.ToLower(string(runes)))
+	at := 0
+	for _, t := range strings.Fields(strings.ToLower(query)) {
+		if i := strings.Index(string(lower), t); i >= 0 {
+			at = len([]rune(string(lower)[:i]))
+			break
+		}
+	}
+
+	start := max(0, at-width/3)
+	end := min(len(runes), start+width)
+	start = max(0, end-width)
+	snippet := string(runes[start:end])
+	if start > 0 {
+		snippet = "…" + snippet
+	}
+	if end < len(runes) {
+		snippet += "…"
+	}
+	return snippet
+}
@@ -0,0 +1,68 @@
+package ci
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/display"
+)
+
+func TestSearchableText(t *testing.T) {
+	display.RegisterEntryType(display.EntryType{Name: "TEST_SEARCH_BROWSER", Category: display.CategoryTool})
+	display.RegisterEntryType(display.EntryType{Name: "TEST_SEARCH_CHECKPOINT", UserAction: true})
+	tests := []struct {
+		entry    PromptEntry
+		wantType string
+		wantText string
+	}{
+		{PromptEntry{Type: "PROMPT", Text: "fix the bug"}, "prompt", "fix the bug"},
+		{PromptEntry{Type: "ASSISTANT", Text: "Done"}, "assistant", "Done"},
+		{PromptEntry{Type: "TOOL_USE", ToolName: "Bash", ToolInput: "go test ./..."}, "tool", "Bash go test ./..."},
+		{PromptEntry{Type: "DECISION", DecisionHeader: "Approach", Text: "Which one?", DecisionAnswer: "A"}, "prompt", "Approach: Which one? → A"},
+		{PromptEntry{Type: "TOOL_RESULT", Text: "PASS"}, "", ""},
+		{PromptEntry{Type: "TASK_NOTIFICATION", Text: "Task done"}, "", ""},
+		{PromptEntry{Type: "TEST_SEARCH_BROWSER", ToolName: "browser_click", ToolInput: "#submit"}, "tool", "browser_click #submit"},
+		{PromptEntry{Type: "TEST_SEARCH_BROWSER", Text: "Opened page"}, "tool", "Opened page"},
+		{PromptEntry{Type: "TEST_SEARCH_CHECKPOINT", Text: "saved"}, "prompt", "saved"},
+		{PromptEntry{Type: "UNREGISTERED", Text: "x"}, "", ""},
+	}
+	for _, tt := range tests {
+		gotType, gotText := searchableText(tt.entry)
+		if gotType != tt.wantType || gotText != tt.wantText {
+			t.Errorf("searchableText(%s) = %q, %q; want %q, %q", tt.entry.Type, gotType, gotText, tt.wantType, tt.wantText)
+		}
+	}
+}
+
+func TestContainsAll(t *testing.T) {
+	if !containsAll("add a rate limit to the client", []string{"rate", "client"}) {
+		t.Error("want match when all terms appear")
+	}
+	if containsAll("add a rate limit", []string{"rate", "client"}) {
+		t.Error("want no match when a term is missing")
+	}
+}
+
+func TestSearchSnippet(t *testing.T) {
+	if got := SearchSnippet("short\ntext", "text", 40); got != "short text" {
+		t.Errorf("short text = %q", got)
+	}
+
+	long := strings.Repeat("a ", 50) + "needle" + strings.Repeat(" b", 50)
+	got := SearchSnippet(long, "NEEDLE", 30)
+	if !strings.Contains(got, "needle") {
+		t.Errorf("snippet %q does not contain the match", got)
+	}
+	if !strings.HasPrefix(got, "…") || !strings.HasSuffix(got, "…") {
+		t.Errorf("snippet %q should be elided on both sides", got)
+	}
+}
+
+func TestSearchRejectsBadOptions(t *testing.T) {
+	if _, err := Search(SearchOptions{Query: "  "}); err == nil {
+		t.Error("want error for an empty query")
+	}
+	if _, err := Search(SearchOptions{Query: "x", Types: []string{"output"}}); err == nil {
+		t.Error("want error for an unknown type")
+	}
+}
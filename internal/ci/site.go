@@ -0,0 +1,151 @@
+package ci
+
+import (
+	"fmt"
+	"html/template"
+	"path/filepath"
+	"slices"
+	"sort"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/display"
+)
+
+// searchTextLen is how much of each step's text the search index keeps
+const searchTextLen = 300
+
+// SiteIndexData holds data for the index page of a site
+type SiteIndexData struct {
+	IndexData
+	Search []SearchEntry
+	Tools  []string // Tools of the sessions, for the tool filter
+}
+
+// SearchEntry is a step in the search index embedded in a site's index
+type SearchEntry struct {
+	Page   string `json:"p"` // Session page
+	Step   string `json:"s"` // Step ID
+	Commit string `json:"c"` // Short SHA
+	Tool   string `json:"o"`
+	Type   string `json:"t"`
+	Text   string `json:"x"`
+}
+
+// SessionPageData holds data for the page of one session
+type SessionPageData struct {
+	CSS      template.CSS
+	PRNumber int
+	Commit   CommitViewData
+	View     SessionView // Every step is inline
+	Prev     string      // Page of the previous session on the site, if any
+	Next     string
+}
+
+// SessionPageName returns the file name of a session's page; number is the
+// session's 1-based position in its commit
+func SessionPageName(shortSHA string, number int) string {
+	return fmt.Sprintf("%s-session-%d.html", shortSHA, number)
+}
+
+// GenerateSite creates a navigable static site for the summary: the commit
+// pages of GenerateHTML, a page per session whose steps collapse, and an
+// index listing every session with a search box over all steps. Step
+// permalinks (#step-<id>) work on both commit and session pages.
+func GenerateSite(summary *Summary, outputDir string, prNumber, pageSize int) error {
+	return generateSite(summary, outputDir, prNumber, pageSize, false)
+}
+
+// GenerateBrowseSite creates the site of GenerateSite for browsing a
+// repository, with a page per commit showing its diff next to the prompts
+// whose edits touched each file
+func GenerateBrowseSite(summary *Summary, outputDir string, pageSize int) error {
+	return generateSite(summary, outputDir, 0, pageSize, true)
+}
+
+func generateSite(summary *Summary, outputDir string, prNumber, pageSize int, diffs bool) error {
+	r, err := newPageRenderer(outputDir)
+	if err != nil {
+		return err
+	}
+	siteTmpl, err := r.parse("site")
+	if err != nil {
+		return err
+	}
+	sessionTmpl, err := r.parse("session")
+	if err != nil {
+		return err
+	}
+	commits := r.commitViews(summary, pageSize)
+	if diffs {
+		for i := range commits {
+			if summary.Commits[i].Submodule == "" {
+				commits[i].DiffPage = DiffPageName(commits[i].ShortSHA)
+			}
+		}
+		if err := r.writeDiffPages(summary, outputDir, commits); err != nil {
+			return err
+		}
+	}
+	if err := r.writeCommitPages(outputDir, commits); err != nil {
+		return err
+	}
+
+	var pages []SessionPageData
+	for _, cvd := range commits {
+		for _, v := range cvd.Views {
+			v.Steps = v.stepViews(cvd.SHA, 0, len(v.Prompts))
+			v.Pages = nil
+			pages = append(pages, SessionPageData{CSS: r.css, PRNumber: prNumber, Commit: cvd, View: v})
+		}
+	}
+
+	index := SiteIndexData{IndexData: IndexData{
+		PRNumber:         prNumber,
+		CSS:              r.css,
+		Commits:          commits,
+		CommitsAnalyzed:  summary.CommitsAnalyzed,
+		CommitsWithNotes: summary.CommitsWithNotes,
+		TotalPrompts:     summary.TotalPrompts,
+	}}
+	for i := range pages {
+		p := &pages[i]
+		name := SessionPageName(p.Commit.ShortSHA, p.View.Number)
+		if i > 0 {
+			p.Prev = SessionPageName(pages[i-1].Commit.ShortSHA, pages[i-1].View.Number)
+		}
+		if i < len(pages)-1 {
+			p.Next = SessionPageName(pages[i+1].Commit.ShortSHA, pages[i+1].View.Number)
+		}
+		if err := renderFile(sessionTmpl, filepath.Join(outputDir, name), p); err != nil {
+			return err
+		}
+		index.Search = append(index.Search, searchEntries(name, p.Commit.ShortSHA, p.View.Tool, p.View.Prompts)...)
+		if !slices.Contains(index.Tools, p.View.Tool) {
+			index.Tools = append(index.Tools, p.View.Tool)
+		}
+	}
+	sort.Strings(index.Tools)
+
+	return renderFile(siteTmpl, filepath.Join(outputDir, "index.html"), index)
+}
+
+// searchEntries returns the search index entries of a session's steps.
+// Tool calls of types registered by providers are indexed like TOOL_USE.
+func searchEntries(page, shortSHA, tool string, prompts []PromptEntry) []SearchEntry {
+	var entries []SearchEntry
+	for _, p := range prompts {
+		text := p.Text
+		switch {
+		case p.Type == "TOOL_RESULT":
+			text = p.ToolOutput
+		case p.Type == "DECISION":
+			text = searchDecisionText(p)
+		case display.TypeCategory(p.Type) == display.CategoryTool:
+			text = toolCallText(p)
+		}
+		if text = display.TruncateText(text, searchTextLen); text == "" {
+			continue
+		}
+		entries = append(entries, SearchEntry{Page: page, Step: p.StepID, Commit: shortSHA, Tool: tool, Type: p.Type, Text: text})
+	}
+	return entries
+}
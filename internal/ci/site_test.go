@@ -0,0 +1,75 @@
+package ci
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/display"
+)
+
+func TestGenerateSite(t *testing.T) {
+	dir := t.TempDir()
+	summary := pagesTestSummary()
+	if err := GenerateSite(summary, dir, 42, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	read := func(name string) string {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	index := read("index.html")
+	for _, want := range []string{
+		`href="aaa1111-session-1.html"`,
+		`href="bbb2222-session-1.html"`,
+		`id="search-input"`,
+		`"p":"bbb2222-session-1.html","s":"step-1-4","c":"bbb2222","o":"claude-code","t":"PROMPT","x":"prompt 4"`,
+	} {
+		if !strings.Contains(index, want) {
+			t.Errorf("index.html missing %q", want)
+		}
+	}
+
+	// Session pages show every step, even beyond the commit page's first page
+	session := read("aaa1111-session-1.html")
+	for _, want := range []string{`id="step-0-0"`, `id="step-0-4"`, `href="bbb2222-session-1.html"`, `href="aaa1111.html"`} {
+		if !strings.Contains(session, want) {
+			t.Errorf("aaa1111-session-1.html missing %q", want)
+		}
+	}
+	if strings.Contains(session, "Previous session") {
+		t.Error("The first session should have no previous session")
+	}
+
+	// The commit pages keep working as pr html generates them
+	problems, err := VerifyPages(summary, OpenPagesSite(dir))
+	if err != nil || len(problems) != 0 {
+		t.Errorf("VerifyPages() = %v, %v; want no problems", problems, err)
+	}
+}
+
+func TestSearchEntries(t *testing.T) {
+	display.RegisterEntryType(display.EntryType{Name: "TEST_SITE_BROWSER", Category: display.CategoryTool})
+	prompts := []PromptEntry{
+		{Type: "PROMPT", Text: "add login", StepID: "s1"},
+		{Type: "TOOL_USE", ToolName: "Bash", ToolInput: "go test", StepID: "s2"},
+		{Type: "TOOL_RESULT", ToolOutput: "PASS", StepID: "s3"},
+		{Type: "TEST_SITE_BROWSER", ToolName: "browser_click", ToolInput: "#login", StepID: "s4"},
+		{Type: "ASSISTANT", StepID: "s5"}, // No text, not indexed
+	}
+
+	var got []string
+	for _, e := range searchEntries("page.html", "abc1234", "claude-code", prompts) {
+		got = append(got, e.Step+" "+e.Text)
+	}
+	want := []string{"s1 add login", "s2 Bash go test", "s3 PASS", "s4 browser_click #login"}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("searchEntries() = %q, want %q", got, want)
+	}
+}
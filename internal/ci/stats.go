@@ -0,0 +1,327 @@
+package ci
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+)
+
+// weekLayout is the key format of Stats.Weeks, the Monday starting the week
+const weekLayout = "2006-01-02"
+
+// StatsCounts are the aggregates of a set of commits
+type StatsCounts struct {
+	Commits          int `json:"commits"`
+	CommitsWithNotes int `json:"commits_with_notes"`
+	UserPrompts      int `json:"user_prompts"`  // User actions in main sessions
+	AgentPrompts     int `json:"agent_prompts"` // User actions in agent sessions
+	Sessions         int `json:"sessions"`      // Main sessions
+	AgentSessions    int `json:"agent_sessions"`
+	ToolUses         int `json:"tool_uses"`
+
+	// SessionSeconds is the summed length of the main sessions
+	SessionSeconds int64 `json:"session_seconds"`
+}
+
+// NotedPercent returns the share of commits with notes
+func (c StatsCounts) NotedPercent() float64 {
+	if c.Commits == 0 {
+		return 0
+	}
+	return 100 * float64(c.CommitsWithNotes) / float64(c.Commits)
+}
+
+// PromptsPerCommit returns the user prompts per commit with notes
+func (c StatsCounts) PromptsPerCommit() float64 {
+	if c.CommitsWithNotes == 0 {
+		return 0
+	}
+	return float64(c.UserPrompts) / float64(c.CommitsWithNotes)
+}
+
+// AverageSession returns the average length of a main session
+func (c StatsCounts) AverageSession() time.Duration {
+	if c.Sessions == 0 {
+		return 0
+	}
+	return time.Duration(c.SessionSeconds/int64(c.Sessions)) * time.Second
+}
+
+// AuthorStats are the aggregates of one commit author
+type AuthorStats struct {
+	Author string `json:"author"`
+	StatsCounts
+}
+
+// WeekStats are the aggregates of the commits authored in one week
+type WeekStats struct {
+	Week string `json:"week"` // The Monday starting the week, YYYY-MM-DD
+	StatsCounts
+}
+
+// otherLanguage groups the sessions without a detected language, see
+// SessionSummary.Languages
+const otherLanguage = "Other"
+
+// LanguageStats are the aggregates of the sessions touching one language.
+// A session touching several languages counts for each, and a commit for
+// each language of its sessions.
+type LanguageStats struct {
+	Language string `json:"language"`
+	StatsCounts
+}
+
+// Stats aggregates the notes of a range of commits
+type Stats struct {
+	Total     StatsCounts     `json:"total"`
+	Tools     map[string]int  `json:"tools"` // Tool uses by tool name, e.g. Edit, Bash
+	Authors   []AuthorStats   `json:"authors"`
+	Weeks     []WeekStats     `json:"weeks"`
+	Languages []LanguageStats `json:"languages"`
+
+	// commits are the counts of each commit, for Privatize
+	commits []commitStats
+}
+
+// commitStats are the counts of one commit and the rows it adds to
+type commitStats struct {
+	author, week string
+	counts       StatsCounts
+}
+
+// StatsCommit is a commit of the range, noted or not
+type StatsCommit struct {
+	SHA    string
+	Author string
+	Date   time.Time
+}
+
+// ComputeStats aggregates the commits of commitRange. Commits of
+// submodules pulled in by the range are not counted.
+func ComputeStats(commitRange string) (*Stats, *Summary, error) {
+	shas, err := git.ResolveCommitSpec(commitRange)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ComputeStatsCommits(shas)
+}
+
+// ComputeStatsCommits is ComputeStats for resolved commits, e.g. those of
+// a git.CommitSet
+func ComputeStatsCommits(shas []string) (*Stats, *Summary, error) {
+	summary, err := GenerateSummaryCommits(shas, false, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	commits := make([]StatsCommit, 0, len(shas))
+	for _, sha := range shas {
+		out, err := git.RunGit("show", "-s", "--format=%an%x1f%aI", sha)
+		if err != nil {
+			return nil, nil, fmt.Errorf("git show %s: %w", sha, err)
+		}
+		c := StatsCommit{SHA: sha}
+		if author, date, ok := strings.Cut(out, "\x1f"); ok {
+			c.Author = author
+			c.Date, _ = time.Parse(time.RFC3339, date)
+		}
+		commits = append(commits, c)
+	}
+	return BuildStats(summary, commits), summary, nil
+}
+
+// BuildStats aggregates the notes of summary over commits, per author, per
+// week of the author date and per language of the sessions
+func BuildStats(summary *Summary, commits []StatsCommit) *Stats {
+	noted := make(map[string]CommitSummary, len(summary.Commits))
+	for _, cs := range summary.Commits {
+		if cs.Submodule == "" {
+			noted[cs.SHA] = cs
+		}
+	}
+
+	stats := &Stats{Tools: make(map[string]int)}
+	authors := make(map[string]*AuthorStats)
+	weeks := make(map[string]*WeekStats)
+	languages := make(map[string]*LanguageStats)
+	for _, c := range commits {
+		if authors[c.Author] == nil {
+			authors[c.Author] = &AuthorStats{Author: c.Author}
+		}
+		week := weekStart(c.Date).Format(weekLayout)
+		if weeks[week] == nil {
+			weeks[week] = &WeekStats{Week: week}
+		}
+
+		var counts StatsCounts
+		counts.Commits = 1
+		if cs, ok := noted[c.SHA]; ok {
+			counts = commitCounts(cs, stats.Tools)
+			for lang, lc := range languageCounts(cs) {
+				if languages[lang] == nil {
+					languages[lang] = &LanguageStats{Language: lang}
+				}
+				languages[lang].add(lc)
+			}
+		}
+		stats.commits = append(stats.commits, commitStats{author: c.Author, week: week, counts: counts})
+		stats.Total.add(counts)
+		authors[c.Author].add(counts)
+		weeks[week].add(counts)
+	}
+
+	for _, a := range authors {
+		stats.Authors = append(stats.Authors, *a)
+	}
+	sort.Slice(stats.Authors, func(i, j int) bool {
+		a, b := stats.Authors[i], stats.Authors[j]
+		if a.UserPrompts != b.UserPrompts {
+			return a.UserPrompts > b.UserPrompts
+		}
+		return a.Author < b.Author
+	})
+	for _, w := range weeks {
+		stats.Weeks = append(stats.Weeks, *w)
+	}
+	sort.Slice(stats.Weeks, func(i, j int) bool { return stats.Weeks[i].Week < stats.Weeks[j].Week })
+	for _, l := range languages {
+		stats.Languages = append(stats.Languages, *l)
+	}
+	sort.Slice(stats.Languages, func(i, j int) bool {
+		a, b := stats.Languages[i], stats.Languages[j]
+		if a.UserPrompts != b.UserPrompts {
+			return a.UserPrompts > b.UserPrompts
+		}
+		return a.Language < b.Language
+	})
+	return stats
+}
+
+// commitCounts counts a noted commit, adding its tool uses to tools
+func commitCounts(cs CommitSummary, tools map[string]int) StatsCounts {
+	counts := StatsCounts{Commits: 1, CommitsWithNotes: 1}
+	for _, sess := range cs.Sessions {
+		counts.add(sessionCounts(sess))
+		for _, p := range sess.Prompts {
+			if p.Type == "TOOL_USE" {
+				tools[p.ToolName]++
+			}
+		}
+	}
+	return counts
+}
+
+// languageCounts counts a noted commit per language of its sessions
+func languageCounts(cs CommitSummary) map[string]StatsCounts {
+	counts := make(map[string]StatsCounts)
+	for _, sess := range cs.Sessions {
+		langs := sess.Languages
+		if len(langs) == 0 {
+			langs = []string{otherLanguage}
+		}
+		for _, lang := range langs {
+			c, ok := counts[lang]
+			if !ok {
+				c = StatsCounts{Commits: 1, CommitsWithNotes: 1}
+			}
+			c.add(sessionCounts(sess))
+			counts[lang] = c
+		}
+	}
+	return counts
+}
+
+// sessionCounts counts the sessions, prompts and tool uses of a session
+func sessionCounts(sess SessionSummary) StatsCounts {
+	var counts StatsCounts
+	if sess.IsAgent {
+		counts.AgentSessions++
+		counts.AgentPrompts += countUserPrompts(sess.Prompts)
+	} else {
+		counts.Sessions++
+		counts.UserPrompts += countUserPrompts(sess.Prompts)
+		if sess.End.After(sess.Start) {
+			counts.SessionSeconds += int64(sess.End.Sub(sess.Start) / time.Second)
+		}
+	}
+	for _, p := range sess.Prompts {
+		if p.Type == "TOOL_USE" {
+			counts.ToolUses++
+		}
+	}
+	return counts
+}
+
+func (c *StatsCounts) add(o StatsCounts) {
+	c.Commits += o.Commits
+	c.CommitsWithNotes += o.CommitsWithNotes
+	c.UserPrompts += o.UserPrompts
+	c.AgentPrompts += o.AgentPrompts
+	c.Sessions += o.Sessions
+	c.AgentSessions += o.AgentSessions
+	c.ToolUses += o.ToolUses
+	c.SessionSeconds += o.SessionSeconds
+}
+
+// weekStart returns the Monday starting the week of t, in UTC
+func weekStart(t time.Time) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+}
+
+// ToolNames returns the tools by number of uses, most used first
+func (s *Stats) ToolNames() []string {
+	names := make([]string, 0, len(s.Tools))
+	for name := range s.Tools {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if s.Tools[names[i]] != s.Tools[names[j]] {
+			return s.Tools[names[i]] > s.Tools[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+// WriteCSV writes one row for the total, then one per author, per week, per
+// language and per tool; kind tells them apart. Tool rows only fill tool_uses.
+func (s *Stats) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"kind", "name", "commits", "commits_with_notes", "user_prompts", "agent_prompts",
+		"sessions", "agent_sessions", "avg_session_seconds", "tool_uses"})
+	row := func(kind, name string, c StatsCounts) {
+		cw.Write([]string{
+			kind, name,
+			strconv.Itoa(c.Commits),
+			strconv.Itoa(c.CommitsWithNotes),
+			strconv.Itoa(c.UserPrompts),
+			strconv.Itoa(c.AgentPrompts),
+			strconv.Itoa(c.Sessions),
+			strconv.Itoa(c.AgentSessions),
+			strconv.FormatInt(int64(c.AverageSession()/time.Second), 10),
+			strconv.Itoa(c.ToolUses),
+		})
+	}
+	row("total", "", s.Total)
+	for _, a := range s.Authors {
+		row("author", a.Author, a.StatsCounts)
+	}
+	for _, wk := range s.Weeks {
+		row("week", wk.Week, wk.StatsCounts)
+	}
+	for _, l := range s.Languages {
+		row("language", l.Language, l.StatsCounts)
+	}
+	for _, name := range s.ToolNames() {
+		cw.Write([]string{"tool", name, "", "", "", "", "", "", "", strconv.Itoa(s.Tools[name])})
+	}
+	cw.Flush()
+	return cw.Error()
+}
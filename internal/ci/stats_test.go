@@ -0,0 +1,80 @@
+package ci
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildStats(t *testing.T) {
+	at := func(day, h int) time.Time { return time.Date(2025, 1, day, h, 0, 0, 0, time.UTC) }
+	summary := &Summary{Commits: []CommitSummary{
+		{SHA: "a1", Sessions: []SessionSummary{
+			{ID: "s1", Start: at(14, 10), End: at(14, 11), Languages: []string{"Go", "SQL"}, Prompts: []PromptEntry{
+				{Type: "PROMPT"}, {Type: "TOOL_USE", ToolName: "Edit"}, {Type: "TOOL_USE", ToolName: "Bash"}, {Type: "PROMPT"},
+			}},
+			{ID: "agent-1", IsAgent: true, Prompts: []PromptEntry{{Type: "PROMPT"}, {Type: "TOOL_USE", ToolName: "Edit"}}},
+		}},
+		{SHA: "c3", Sessions: []SessionSummary{
+			{ID: "s2", Start: at(20, 9), End: at(20, 9).Add(30 * time.Minute), Languages: []string{"Go"}, Prompts: []PromptEntry{{Type: "COMMAND"}}},
+		}},
+	}}
+	commits := []StatsCommit{
+		{SHA: "a1", Author: "Ann", Date: at(14, 12)}, // Tuesday
+		{SHA: "b2", Author: "Bob", Date: at(19, 12)}, // Sunday, same week
+		{SHA: "c3", Author: "Ann", Date: at(20, 12)}, // Monday
+	}
+
+	stats := BuildStats(summary, commits)
+	total := stats.Total
+	if total.Commits != 3 || total.CommitsWithNotes != 2 || total.UserPrompts != 3 || total.AgentPrompts != 1 {
+		t.Errorf("total = %+v", total)
+	}
+	if total.Sessions != 2 || total.AgentSessions != 1 || total.AverageSession() != 45*time.Minute {
+		t.Errorf("sessions = %+v, average %s", total, total.AverageSession())
+	}
+	if stats.Tools["Edit"] != 2 || stats.Tools["Bash"] != 1 || stats.ToolNames()[0] != "Edit" {
+		t.Errorf("tools = %v", stats.Tools)
+	}
+
+	if len(stats.Authors) != 2 || stats.Authors[0].Author != "Ann" || stats.Authors[0].PromptsPerCommit() != 1.5 {
+		t.Errorf("authors = %+v", stats.Authors)
+	}
+	if len(stats.Weeks) != 2 || stats.Weeks[0].Week != "2025-01-13" || stats.Weeks[0].Commits != 2 || stats.Weeks[1].Week != "2025-01-20" {
+		t.Errorf("weeks = %+v", stats.Weeks)
+	}
+
+	languages := map[string]StatsCounts{}
+	for _, l := range stats.Languages {
+		languages[l.Language] = l.StatsCounts
+	}
+	if len(stats.Languages) != 3 || stats.Languages[0].Language != "Go" {
+		t.Errorf("languages = %+v", stats.Languages)
+	}
+	if goCounts := languages["Go"]; goCounts.Commits != 2 || goCounts.UserPrompts != 3 || goCounts.Sessions != 2 {
+		t.Errorf("Go = %+v", goCounts)
+	}
+	if sql := languages["SQL"]; sql.Commits != 1 || sql.UserPrompts != 2 || sql.AgentSessions != 0 {
+		t.Errorf("SQL = %+v", sql)
+	}
+	if other := languages[otherLanguage]; other.Commits != 1 || other.AgentSessions != 1 || other.ToolUses != 1 {
+		t.Errorf("Other (the agent session) = %+v", other)
+	}
+
+	var buf bytes.Buffer
+	if err := stats.WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"total,,3,2,3,1,2,1,2700,3\n",
+		"author,Bob,1,0,0,0,0,0,0,0\n",
+		"week,2025-01-20,1,1,1,0,1,0,1800,0\n",
+		"language,SQL,1,1,2,0,1,0,3600,2\n",
+		"tool,Edit,,,,,,,,2\n",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("CSV missing %q:\n%s", want, buf.String())
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package ci
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// StepID returns the stable identifier of a step: a hash of the session
+// ID, the step's timestamp and its position among the session's steps
+// with that same timestamp. It does not depend on rendering or on which
+// other steps are shown, so links built from it survive re-renders and
+// new versions. Changing this function breaks published links.
+func StepID(sessionID string, t time.Time, ordinal int) string {
+	key := fmt.Sprintf("%s\x00%s\x00%d", sessionID, t.UTC().Format(time.RFC3339Nano), ordinal)
+	sum := sha256.Sum256([]byte(key))
+	return "step-" + hex.EncodeToString(sum[:6])
+}
+
+// assignStepIDs sets StepID on every step of a session
+func assignStepIDs(ss *SessionSummary) {
+	ordinals := make(map[int64]int)
+	for i := range ss.Prompts {
+		ts := ss.Prompts[i].Time.UnixNano()
+		ss.Prompts[i].StepID = StepID(ss.ID, ss.Prompts[i].Time, ordinals[ts])
+		ordinals[ts]++
+	}
+}
+
+// stepURL links a step on the transcript pages published at pagesURL
+func stepURL(pagesURL, shortSHA, stepID string) string {
+	if pagesURL == "" || stepID == "" {
+		return ""
+	}
+	if !strings.HasSuffix(pagesURL, "/") {
+		pagesURL += "/"
+	}
+	return pagesURL + shortSHA + ".html#" + stepID
+}
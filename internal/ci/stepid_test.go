@@ -0,0 +1,45 @@
+package ci
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStepID_Stable(t *testing.T) {
+	ts := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	// Published links depend on this exact value; do not change it
+	if got := StepID("s1", ts, 0); got != StepID("s1", ts.In(time.FixedZone("X", 3600)), 0) {
+		t.Errorf("StepID depends on the time zone: %s", got)
+	}
+	if got, want := StepID("s1", ts, 0), "step-09724594305d"; got != want {
+		t.Errorf("StepID() = %s, want %s", got, want)
+	}
+	if StepID("s1", ts, 0) == StepID("s1", ts, 1) || StepID("s1", ts, 0) == StepID("s2", ts, 0) {
+		t.Error("StepID should differ by ordinal and session")
+	}
+}
+
+func TestAssignStepIDs(t *testing.T) {
+	ts := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+	ss := &SessionSummary{ID: "s1", Prompts: []PromptEntry{
+		{Time: ts, Type: "ASSISTANT"},
+		{Time: ts, Type: "TOOL_USE"},
+		{Time: ts.Add(time.Second), Type: "PROMPT"},
+	}}
+	assignStepIDs(ss)
+
+	want := []string{StepID("s1", ts, 0), StepID("s1", ts, 1), StepID("s1", ts.Add(time.Second), 0)}
+	for i, p := range ss.Prompts {
+		if p.StepID != want[i] {
+			t.Errorf("step %d ID = %s, want %s", i, p.StepID, want[i])
+		}
+	}
+
+	// Dropping an earlier step with another timestamp keeps the IDs
+	ss2 := &SessionSummary{ID: "s1", Prompts: ss.Prompts[2:3]}
+	assignStepIDs(ss2)
+	if ss2.Prompts[0].StepID != want[2] {
+		t.Errorf("ID changed when earlier steps were dropped: %s", ss2.Prompts[0].StepID)
+	}
+}
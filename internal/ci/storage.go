@@ -0,0 +1,91 @@
+package ci
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/note"
+)
+
+// storageLargest is how many of the biggest entries StorageStats keeps
+const storageLargest = 10
+
+// StorageStats account for the transcript storage of a range of commits, to
+// find the tools and tool calls that bloat it
+type StorageStats struct {
+	Bytes      int64            `json:"bytes"`
+	ToolOutput int64            `json:"tool_output"` // bytes of tool results
+	Sessions   int              `json:"sessions"`    // Sessions with a stored transcript
+	NotStored  int              `json:"not_stored"`  // Sessions whose transcript expired or is missing
+	Tools      map[string]int64 `json:"tools"`       // Bytes of tool calls and results by tool name
+	Largest    []StoredEntry    `json:"largest"`
+}
+
+// StoredEntry is one of the largest transcript entries of a range
+type StoredEntry struct {
+	SHA     string `json:"sha"`
+	Session string `json:"session"` // tool/id
+	note.EntrySize
+}
+
+// ComputeStorage accounts for the stored transcripts of the notes of shas.
+// A transcript referenced by several notes is stored once, and counted for
+// the first of them.
+func ComputeStorage(shas []string) (*StorageStats, error) {
+	storage := &StorageStats{Tools: make(map[string]int64)}
+	seen := make(map[string]bool)
+	for _, sha := range shas {
+		psNote, _, err := note.LoadNote(sha)
+		if errors.Is(err, note.ErrNoNote) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, sess := range psNote.Sessions {
+			if seen[sess.TranscriptPath()] {
+				continue
+			}
+			seen[sess.TranscriptPath()] = true
+			storage.add(sha, sess, sess.StoredSize())
+		}
+	}
+	return storage, nil
+}
+
+// add counts a session of the note of sha, size nil when its transcript is
+// not stored
+func (s *StorageStats) add(sha string, sess note.SessionEntry, size *note.SizeStats) {
+	if size == nil {
+		s.NotStored++
+		return
+	}
+	s.Sessions++
+	s.Bytes += size.Bytes
+	s.ToolOutput += size.ToolOutput
+	for tool, n := range size.ToolBytes {
+		s.Tools[tool] += n
+	}
+	for _, e := range size.Largest {
+		s.Largest = append(s.Largest, StoredEntry{SHA: sha, Session: sess.Tool + "/" + sess.ID, EntrySize: e})
+	}
+	sort.SliceStable(s.Largest, func(i, j int) bool { return s.Largest[i].Bytes > s.Largest[j].Bytes })
+	if len(s.Largest) > storageLargest {
+		s.Largest = s.Largest[:storageLargest]
+	}
+}
+
+// ToolNames returns the tools by stored bytes, largest first
+func (s *StorageStats) ToolNames() []string {
+	names := make([]string, 0, len(s.Tools))
+	for name := range s.Tools {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if s.Tools[names[i]] != s.Tools[names[j]] {
+			return s.Tools[names[i]] > s.Tools[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
@@ -0,0 +1,45 @@
+package ci
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/note"
+)
+
+func TestStorageStats(t *testing.T) {
+	storage := &StorageStats{Tools: make(map[string]int64)}
+	storage.add("a1", note.SessionEntry{Tool: "claude-code", ID: "s1"}, &note.SizeStats{
+		Bytes: 1000, ToolOutput: 700,
+		ToolBytes: map[string]int64{"Bash": 600, "Read": 150},
+		Largest: []note.EntrySize{
+			{Entry: 4, Kind: "tool_result", Tool: "Bash", Bytes: 550},
+			{Entry: 2, Kind: "tool_result", Tool: "Read", Bytes: 150},
+		},
+	})
+	storage.add("b2", note.SessionEntry{Tool: "codex", ID: "s2"}, &note.SizeStats{
+		Bytes: 400, ToolOutput: 300,
+		ToolBytes: map[string]int64{"Read": 320},
+		Largest:   []note.EntrySize{{Entry: 7, Kind: "tool_result", Tool: "Read", Bytes: 300}},
+	})
+	storage.add("b2", note.SessionEntry{Tool: "cursor", ID: "s3"}, nil)
+
+	if storage.Bytes != 1400 || storage.ToolOutput != 1000 || storage.Sessions != 2 || storage.NotStored != 1 {
+		t.Errorf("storage = %+v", storage)
+	}
+	if got, want := storage.ToolNames(), []string{"Bash", "Read"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ToolNames() = %v, want %v", got, want)
+	}
+	if storage.Tools["Read"] != 470 {
+		t.Errorf("Tools[Read] = %d, want 470", storage.Tools["Read"])
+	}
+
+	var order []string
+	for _, e := range storage.Largest {
+		order = append(order, e.SHA+" "+e.Session+" "+e.Tool)
+	}
+	want := []string{"a1 claude-code/s1 Bash", "b2 codex/s2 Read", "a1 claude-code/s1 Read"}
+	if !reflect.DeepEqual(order, want) {
+		t.Errorf("Largest = %v, want %v", order, want)
+	}
+}
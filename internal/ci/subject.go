@@ -0,0 +1,35 @@
+package ci
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// maxSubjectWidth is the display width commit subjects are cut to in the
+// summary table and step headings
+const maxSubjectWidth = 40
+
+// subjectWidth measures display width independently of the locale, so the
+// summary renders the same on every CI runner: wide East Asian characters
+// and emoji count as two columns, ambiguous ones as one.
+var subjectWidth = &runewidth.Condition{StrictEmojiNeutral: true}
+
+// truncateSubject shortens a commit subject to maxSubjectWidth columns.
+// It cuts between grapheme clusters, never inside a multi-byte rune or an
+// emoji sequence, and turns control characters into spaces.
+func truncateSubject(subject string) string {
+	subject = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return ' '
+		}
+		return r
+	}, subject)
+	return subjectWidth.Truncate(subject, maxSubjectWidth, "...")
+}
+
+// subjectCell renders a commit subject as a summary table cell
+func subjectCell(r Renderer, subject string) string {
+	return r.Escape(truncateSubject(subject))
+}
@@ -0,0 +1,88 @@
+package ci
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncateSubject(t *testing.T) {
+	tests := []struct {
+		name    string
+		subject string
+		want    string
+	}{
+		{"short ASCII", "Add main function", "Add main function"},
+		{"long ASCII", strings.Repeat("a", 45), strings.Repeat("a", 37) + "..."},
+		{"CJK fits", "修复登录页面的错误", "修复登录页面的错误"},
+		// Each CJK character takes two columns
+		{"long CJK", strings.Repeat("漢", 25), strings.Repeat("漢", 18) + "..."},
+		{"CJK at odd boundary", "a" + strings.Repeat("漢", 25), "a" + strings.Repeat("漢", 18) + "..."},
+		{"emoji", strings.Repeat("🚀", 25), strings.Repeat("🚀", 18) + "..."},
+		{"ZWJ sequence kept whole", strings.Repeat("x", 36) + "👩‍💻 done", strings.Repeat("x", 36) + "..."},
+		{"control characters", "Fix\ttabs\rhere", "Fix tabs here"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := truncateSubject(tt.subject)
+			if got != tt.want {
+				t.Errorf("truncateSubject(%q) = %q, want %q", tt.subject, got, tt.want)
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("truncateSubject(%q) is not valid UTF-8", tt.subject)
+			}
+			if w := subjectWidth.StringWidth(got); w > maxSubjectWidth {
+				t.Errorf("truncateSubject(%q) is %d columns wide", tt.subject, w)
+			}
+		})
+	}
+}
+
+func TestSubjectCell(t *testing.T) {
+	tests := []struct {
+		subject string
+		want    string
+	}{
+		{"Use a | b in parser", `Use a \| b in parser`},
+		{"Fix `a|b` & <c>", "Fix `a\\|b` &amp; &lt;c&gt;"},
+		{"日本語 | テスト", `日本語 \| テスト`},
+	}
+
+	for _, tt := range tests {
+		if got := markdownCell(subjectCell(MarkdownRenderer, tt.subject)); got != tt.want {
+			t.Errorf("subjectCell(%q) = %q, want %q", tt.subject, got, tt.want)
+		}
+	}
+}
+
+func TestRenderMarkdown_SubjectTable(t *testing.T) {
+	summary := &Summary{
+		CommitsWithNotes: 2,
+		Commits: []CommitSummary{
+			{ShortSHA: "aaa1111", Subject: "Split A|B pipeline | add 日本語のサポートとテストケースを追加する"},
+			{ShortSHA: "bbb2222", Subject: "Ship it 🚀🚀🚀"},
+		},
+	}
+
+	md := RenderMarkdown(summary, "", "v1")
+	for _, line := range strings.Split(md, "\n") {
+		if !strings.HasPrefix(line, "| aaa1111") && !strings.HasPrefix(line, "| bbb2222") {
+			continue
+		}
+		// Escaped pipes must not add cells
+		cells := strings.Count(line, "|") - strings.Count(line, `\|`)
+		if cells != 6 {
+			t.Errorf("Expected 5 cells, got row %q", line)
+		}
+		if !utf8.ValidString(line) {
+			t.Errorf("Row is not valid UTF-8: %q", line)
+		}
+	}
+	if !strings.Contains(md, `| aaa1111 | Split A\|B pipeline \| add 日本語のサポ... |`) {
+		t.Errorf("Expected width-truncated, escaped subject:\n%s", md)
+	}
+	if !strings.Contains(md, "| bbb2222 | Ship it 🚀🚀🚀 |") {
+		t.Errorf("Expected emoji subject kept:\n%s", md)
+	}
+}
@@ -0,0 +1,128 @@
+package ci
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+	"github.com/QuesmaOrg/git-prompt-story/internal/note"
+)
+
+// submoduleMode is the tree entry mode of a submodule (gitlink)
+const submoduleMode = "160000"
+
+// SubmoduleUpdate is a commit moving a submodule pointer from Old to New
+type SubmoduleUpdate struct {
+	Commit string
+	Path   string
+	Old    string
+	New    string
+}
+
+// AddSubmoduleSummaries extends summary with the notes of submodule
+// commits pulled in by commits in commitRange. Each submodule must be
+// checked out; its notes are fetched from its origin if missing locally.
+// Newly added submodules are skipped, as their whole history would count.
+func AddSubmoduleSummaries(summary *Summary, commitRange string, full bool) error {
+	commits, err := git.ResolveCommitSpec(commitRange)
+	if err != nil {
+		return err
+	}
+	repoRoot, err := git.GetRepoRoot()
+	if err != nil {
+		return fmt.Errorf("submodules need a working tree: %w", err)
+	}
+
+	for _, sha := range commits {
+		raw, err := git.RunGit("diff-tree", "-r", "--no-commit-id", "--root", sha)
+		if err != nil {
+			return fmt.Errorf("git diff-tree %s: %w", sha[:7], err)
+		}
+		for _, u := range parseSubmoduleUpdates(sha, raw) {
+			sub, err := summarizeSubmodule(filepath.Join(repoRoot, u.Path), u, full)
+			if err != nil {
+				summary.Skipped = append(summary.Skipped, CommitTrace{
+					SHA:     sha,
+					Subject: fmt.Sprintf("submodule %s", u.Path),
+					Reason:  err.Error(),
+				})
+				continue
+			}
+			summary.merge(sub, u.Path)
+		}
+	}
+	return nil
+}
+
+// parseSubmoduleUpdates extracts submodule pointer moves from
+// `git diff-tree -r` output, ignoring added and removed submodules
+func parseSubmoduleUpdates(commit, raw string) []SubmoduleUpdate {
+	var updates []SubmoduleUpdate
+	for _, line := range strings.Split(raw, "\n") {
+		meta, path, ok := strings.Cut(line, "\t")
+		if !ok || !strings.HasPrefix(meta, ":") {
+			continue
+		}
+		// :<old mode> <new mode> <old sha> <new sha> <status>
+		fields := strings.Fields(strings.TrimPrefix(meta, ":"))
+		if len(fields) < 5 || fields[0] != submoduleMode || fields[1] != submoduleMode {
+			continue
+		}
+		updates = append(updates, SubmoduleUpdate{
+			Commit: commit,
+			Path:   path,
+			Old:    fields[2],
+			New:    fields[3],
+		})
+	}
+	return updates
+}
+
+// summarizeSubmodule runs GenerateSummary on the commits between the old
+// and new pointer inside the submodule checkout at dir
+func summarizeSubmodule(dir string, u SubmoduleUpdate, full bool) (*Summary, error) {
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err != nil {
+		return nil, fmt.Errorf("submodule %s is not checked out (run git submodule update --init)", u.Path)
+	}
+
+	// The git helpers work on the current directory
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chdir(dir); err != nil {
+		return nil, err
+	}
+	defer os.Chdir(cwd)
+
+	if ref, _ := git.GetRef(note.NotesRef); ref == "" {
+		// Best effort: the submodule clone may not have fetched notes
+		git.RunGit("fetch", "--quiet", "origin",
+			"+"+note.NotesRef+":"+note.NotesRef,
+			"+"+note.TranscriptsRef+":"+note.TranscriptsRef)
+	}
+
+	sub, err := GenerateSummary(u.Old+".."+u.New, full)
+	if err != nil {
+		return nil, fmt.Errorf("submodule %s: %w", u.Path, err)
+	}
+	return sub, nil
+}
+
+// merge adds the commits and totals of a submodule summary, labelling
+// each commit with the submodule path
+func (s *Summary) merge(sub *Summary, path string) {
+	for _, cs := range sub.Commits {
+		cs.Submodule = path
+		cs.Subject = fmt.Sprintf("[%s] %s", path, cs.Subject)
+		s.addCommit(cs)
+	}
+	s.CommitsAnalyzed += sub.CommitsAnalyzed
+	s.CommitsMissingNotes += sub.CommitsMissingNotes
+	for _, t := range sub.Skipped {
+		t.Subject = fmt.Sprintf("[%s] %s", path, t.Subject)
+		s.Skipped = append(s.Skipped, t)
+	}
+}
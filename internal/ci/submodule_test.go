@@ -0,0 +1,47 @@
+package ci
+
+import "testing"
+
+func TestParseSubmoduleUpdates(t *testing.T) {
+	raw := ":100644 100644 1111111111111111111111111111111111111111 2222222222222222222222222222222222222222 M\tmain.go\n" +
+		":160000 160000 aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb M\tvendor/lib\n" +
+		":000000 160000 0000000000000000000000000000000000000000 cccccccccccccccccccccccccccccccccccccccc A\tvendor/new\n" +
+		":160000 000000 dddddddddddddddddddddddddddddddddddddddd 0000000000000000000000000000000000000000 D\tvendor/old\n"
+
+	got := parseSubmoduleUpdates("feed", raw)
+	if len(got) != 1 {
+		t.Fatalf("parseSubmoduleUpdates() = %+v, want one update", got)
+	}
+	want := SubmoduleUpdate{
+		Commit: "feed",
+		Path:   "vendor/lib",
+		Old:    "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+		New:    "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb",
+	}
+	if got[0] != want {
+		t.Errorf("update = %+v, want %+v", got[0], want)
+	}
+}
+
+func TestSummaryMerge(t *testing.T) {
+	s := &Summary{CommitsAnalyzed: 2}
+	sub := &Summary{
+		CommitsAnalyzed: 3,
+		Commits: []CommitSummary{{
+			SHA:     "abc",
+			Subject: "Fix parser",
+			Sessions: []SessionSummary{{
+				Prompts: []PromptEntry{{Type: "PROMPT"}, {Type: "TOOL_USE"}},
+			}},
+		}},
+	}
+	s.merge(sub, "vendor/lib")
+
+	if len(s.Commits) != 1 || s.Commits[0].Submodule != "vendor/lib" || s.Commits[0].Subject != "[vendor/lib] Fix parser" {
+		t.Errorf("merged commits = %+v", s.Commits)
+	}
+	if s.CommitsAnalyzed != 5 || s.CommitsWithNotes != 1 || s.TotalSteps != 2 {
+		t.Errorf("totals = analyzed %d, with notes %d, steps %d; want 5, 1, 2",
+			s.CommitsAnalyzed, s.CommitsWithNotes, s.TotalSteps)
+	}
+}
@@ -2,12 +2,14 @@ package ci
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"html"
 	"path/filepath"
 	"regexp"
+	"runtime"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/QuesmaOrg/git-prompt-story/internal/display"
@@ -39,16 +41,68 @@ type PromptEntry struct {
 	DecisionAnswerDescription string         `json:"decision_answer_description,omitempty"` // Description of selected option
 	ToolCounts                map[string]int `json:"tool_counts,omitempty"`                 // For user prompts: counts of tool uses that followed
 	EditedFiles               []string       `json:"edited_files,omitempty"`                // For user prompts: list of files edited
+	// For file tools (Read, Edit, Write): location used for editor links
+	FilePath   string `json:"file_path,omitempty"`
+	FileLine   int    `json:"file_line,omitempty"` // Known line (e.g. Read offset), 0 if unknown
+	FileAnchor string `json:"-"`                   // First line of written text, to locate the line later
+	Written    string `json:"-"`                   // Text an Edit or Write put in the file, see AILines
+	// Stable permalink ID, see StepID
+	StepID string `json:"step_id,omitempty"`
+	// Timestamp in the transcript when it went back and Time shows the
+	// previous entry's, see session.MonotonicTimestamps
+	RecordedTime time.Time `json:"recorded_time,omitempty"`
+}
+
+// TranscriptTime returns the timestamp the entry has in its transcript,
+// which finds it there, e.g. to redact it
+func (p *PromptEntry) TranscriptTime() time.Time {
+	if !p.RecordedTime.IsZero() {
+		return p.RecordedTime
+	}
+	return p.Time
 }
 
 // SessionSummary represents a summarized session within a commit
 type SessionSummary struct {
 	Tool    string        `json:"tool"`
 	ID      string        `json:"id"`
-	IsAgent bool          `json:"is_agent"` // True if this is an agent/subagent session
+	IsAgent bool          `json:"is_agent"`        // True if this is an agent/subagent session
+	Owner   string        `json:"owner,omitempty"` // Who captured it, "Author (os user)"
 	Start   time.Time     `json:"start"`
 	End     time.Time     `json:"end"`
 	Prompts []PromptEntry `json:"prompts"`
+
+	// Languages are the dominant languages of the files the session's
+	// tools touched, most touched first
+	Languages []string `json:"languages,omitempty"`
+
+	// Branch and Cwd are where the session started, if recorded
+	Branch string `json:"branch,omitempty"`
+	Cwd    string `json:"cwd,omitempty"`
+
+	// ContinuesFrom is the ID of the session this one resumes after a
+	// tool restart, see linkContinuations
+	ContinuesFrom string `json:"continues_from,omitempty"`
+
+	// Removed is set when the author removed the transcript, leaving a
+	// tombstone; the session then has no prompts
+	Removed *note.Tombstone `json:"removed,omitempty"`
+
+	// Quarantined is set when the transcript was moved to the local
+	// quarantine, leaving a stub; the session then has no prompts
+	Quarantined *note.QuarantineStub `json:"quarantined,omitempty"`
+}
+
+// Withheld describes why the session's transcript is not shown, removed
+// or quarantined, or returns "" if it is shown
+func (ss *SessionSummary) Withheld() string {
+	switch {
+	case ss.Removed != nil:
+		return ss.Removed.Description()
+	case ss.Quarantined != nil:
+		return ss.Quarantined.Description()
+	}
+	return ""
 }
 
 // IsAgentSession returns true if the session ID indicates an agent session
@@ -65,6 +119,13 @@ type CommitSummary struct {
 	Sessions  []SessionSummary `json:"sessions"`
 	StartWork time.Time        `json:"start_work"`
 	EndWork   time.Time        `json:"end_work"`
+
+	// Submodule is the path of the submodule the commit belongs to, if
+	// it was pulled in by a superproject commit
+	Submodule string `json:"submodule,omitempty"`
+
+	// Tags are the note's tags, see note.NormalizeTag
+	Tags []string `json:"tags,omitempty"`
 }
 
 // Summary represents the full analysis result
@@ -80,57 +141,133 @@ type Summary struct {
 	CommitsWithNotes    int             `json:"commits_with_notes"`
 	CommitsAnalyzed     int             `json:"commits_analyzed"`
 	CommitsMissingNotes int             `json:"commits_missing_notes"` // Commits with markers but no notes
+
+	// Skipped explains commits and sessions left out of the counts
+	Skipped []CommitTrace `json:"skipped,omitempty"`
+
+	// Sampled is set when SampleSummary dropped prompts
+	Sampled *Sampling `json:"sampled,omitempty"`
 }
 
-// GenerateSummary analyzes commits in a range and extracts prompt data
+// GenerateSummary analyzes commits in a range and extracts prompt data,
+// analyzing one commit per CPU at a time
 func GenerateSummary(commitRange string, full bool) (*Summary, error) {
+	return GenerateSummaryJobs(commitRange, full, 0)
+}
+
+// GenerateSummaryJobs is GenerateSummary analyzing up to jobs commits in
+// parallel, one per CPU if jobs is 0 or less. The result does not depend
+// on jobs.
+func GenerateSummaryJobs(commitRange string, full bool, jobs int) (*Summary, error) {
 	// Resolve commit range to list of SHAs
 	commits, err := git.ResolveCommitSpec(commitRange)
 	if err != nil {
 		return nil, err
 	}
+	return GenerateSummaryCommits(commits, full, jobs)
+}
 
+// GenerateSummaryCommits is GenerateSummaryJobs for resolved commits, e.g.
+// those of a git.CommitSet
+func GenerateSummaryCommits(commits []string, full bool, jobs int) (*Summary, error) {
 	summary := &Summary{
 		Commits:         make([]CommitSummary, 0),
 		CommitsAnalyzed: len(commits),
 	}
 
-	for _, sha := range commits {
-		cs, err := analyzeCommit(sha, full)
+	for i, a := range analyzeCommits(commits, full, jobs) {
+		sha, cs, trace, err := commits[i], a.cs, a.trace, a.err
 		if err != nil {
 			// Check if commit has a marker indicating AI was used
+			trace.Reason = err.Error()
 			if hasAIMarker(sha) {
 				summary.CommitsMissingNotes++
+				trace.Trailer = true
+				trace.Reason += " (commit has a Prompt-Story trailer)"
+			} else if errors.Is(err, note.ErrNoNote) {
+				// Plain commit made without an AI session
+				continue
 			}
+			trace.Subject, _ = getCommitSubject(sha)
+			summary.Skipped = append(summary.Skipped, *trace)
 			continue
 		}
-		if len(cs.Sessions) > 0 {
-			summary.Commits = append(summary.Commits, *cs)
-			summary.CommitsWithNotes++
-			for _, sess := range cs.Sessions {
-				stepCount := len(sess.Prompts)
-				userPromptCount := countUserPrompts(sess.Prompts)
-				fileEditCount := countFileEdits(sess.Prompts)
-				failedTaskCount := countFailedTasks(sess.Prompts)
-				summary.TotalSteps += stepCount
-				summary.TotalPrompts += stepCount // Keep for backward compatibility
-				summary.TotalFileEdits += fileEditCount
-				summary.TotalFailedTasks += failedTaskCount
-
-				// Separate counts for main vs agent sessions
-				if sess.IsAgent {
-					summary.TotalAgentPrompts += userPromptCount
-					summary.TotalAgentSessions++
-				} else {
-					summary.TotalUserPrompts += userPromptCount
-				}
+		if trace.skippedSessions() > 0 || len(cs.Sessions) == 0 {
+			if len(cs.Sessions) == 0 {
+				trace.Reason = "no session has entries in the work period"
 			}
+			summary.Skipped = append(summary.Skipped, *trace)
+		}
+		if len(cs.Sessions) > 0 {
+			summary.addCommit(*cs)
 		}
 	}
 
+	linkContinuations(summary.Commits, continuationGap())
 	return summary, nil
 }
 
+// analyzedCommit is the result of analyzeCommit
+type analyzedCommit struct {
+	cs    *CommitSummary
+	trace *CommitTrace
+	err   error
+}
+
+// analyzeCommits runs analyzeCommit on the commits with a pool of jobs
+// workers, returning the results in the order of commits
+func analyzeCommits(commits []string, full bool, jobs int) []analyzedCommit {
+	if jobs <= 0 {
+		jobs = runtime.NumCPU()
+	}
+	jobs = min(jobs, len(commits))
+
+	results := make([]analyzedCommit, len(commits))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				trace := &CommitTrace{SHA: commits[i]}
+				cs, err := analyzeCommit(commits[i], full, trace)
+				results[i] = analyzedCommit{cs: cs, trace: trace, err: err}
+			}
+		}()
+	}
+	for i := range commits {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	return results
+}
+
+// addCommit appends a commit with sessions and adds it to the totals
+func (s *Summary) addCommit(cs CommitSummary) {
+	s.Commits = append(s.Commits, cs)
+	s.CommitsWithNotes++
+	for _, sess := range cs.Sessions {
+		stepCount := len(sess.Prompts)
+		userPromptCount := countUserPrompts(sess.Prompts)
+		fileEditCount := countFileEdits(sess.Prompts)
+		failedTaskCount := countFailedTasks(sess.Prompts)
+		s.TotalSteps += stepCount
+		s.TotalPrompts += stepCount // Keep for backward compatibility
+		s.TotalFileEdits += fileEditCount
+		s.TotalFailedTasks += failedTaskCount
+
+		// Separate counts for main vs agent sessions
+		if sess.IsAgent {
+			s.TotalAgentPrompts += userPromptCount
+			s.TotalAgentSessions++
+		} else {
+			s.TotalUserPrompts += userPromptCount
+		}
+	}
+}
+
 // hasAIMarker checks if a commit message contains a Prompt-Story marker indicating AI was used
 // Returns true for "Prompt-Story: Used ..." but false for "Prompt-Story: none"
 func hasAIMarker(sha string) bool {
@@ -142,18 +279,13 @@ func hasAIMarker(sha string) bool {
 	return strings.Contains(msg, "Prompt-Story: Used")
 }
 
-// analyzeCommit extracts prompt data for a single commit
-func analyzeCommit(sha string, full bool) (*CommitSummary, error) {
-	// Get note attached to commit
-	noteContent, err := note.GetNote(sha)
+// analyzeCommit extracts prompt data for a single commit.
+// If trace is not nil, it records the work period and why sessions were left out.
+func analyzeCommit(sha string, full bool, trace *CommitTrace) (*CommitSummary, error) {
+	// Get note attached to commit, reading legacy notes too
+	psNote, _, err := note.LoadNote(sha)
 	if err != nil {
-		return nil, fmt.Errorf("no prompt-story note found for commit %s", sha[:7])
-	}
-
-	// Parse note JSON
-	var psNote note.PromptStoryNote
-	if err := json.Unmarshal([]byte(noteContent), &psNote); err != nil {
-		return nil, fmt.Errorf("failed to parse note: %w", err)
+		return nil, err
 	}
 
 	// Get commit subject
@@ -161,12 +293,14 @@ func analyzeCommit(sha string, full bool) (*CommitSummary, error) {
 
 	// Get commit timestamp (end of work period)
 	endWork, _ := git.GetPreviousCommitTimestamp(sha)
+	explanation := "start_work from the note, ending at the commit date"
 	if endWork.IsZero() {
 		for _, sess := range psNote.Sessions {
 			if sess.Modified.After(endWork) {
 				endWork = sess.Modified
 			}
 		}
+		explanation = "start_work from the note, ending at the last session change (commit date unavailable)"
 	}
 
 	cs := &CommitSummary{
@@ -176,50 +310,102 @@ func analyzeCommit(sha string, full bool) (*CommitSummary, error) {
 		Sessions:  make([]SessionSummary, 0),
 		StartWork: psNote.StartWork,
 		EndWork:   endWork,
+		Tags:      psNote.Tags,
 	}
 
-	// Process each session
-	for _, sess := range psNote.Sessions {
-		ss, err := analyzeSession(sess, psNote.StartWork, endWork, full)
-		if err != nil {
-			continue
+	if trace != nil {
+		trace.Subject = subject
+		trace.WorkPeriod = session.WorkPeriodTrace{
+			Ref:             sha,
+			CalculatedStart: psNote.StartWork,
+			EndWork:         endWork,
+			ClockSkew:       psNote.ClockSkew,
+			Explanation:     explanation,
 		}
-		if len(ss.Prompts) > 0 {
+	}
+
+	// Process each session, allowing for the clock skew it was captured with
+	startEntries, endEntries := session.WidenWorkPeriod(psNote.StartWork, endWork, psNote.ClockSkew)
+	for _, sess := range psNote.Sessions {
+		st := session.SessionTrace{ID: sess.ID, Path: sess.TranscriptPath(), Created: sess.Created, Modified: sess.Modified}
+
+		ss, regressions, err := analyzeSession(sess, startEntries, endEntries, full)
+		st.TimestampRegressions = regressions
+		switch {
+		case sess.Expired != nil:
+			st.FinalReason = "transcript removed by retention gc"
+		case err != nil:
+			st.FinalReason = err.Error()
+			if trace != nil {
+				trace.Unreadable = append(trace.Unreadable, sess.TranscriptPath())
+			}
+		case len(ss.Prompts) == 0 && ss.Withheld() == "":
+			st.FinalReason = "no entries in work period"
+		default:
+			st.Included = true
 			cs.Sessions = append(cs.Sessions, *ss)
 		}
+
+		if trace != nil {
+			trace.Sessions = append(trace.Sessions, st)
+		}
 	}
 
 	return cs, nil
 }
 
-// analyzeSession extracts all entries from a session, marking which are in work period
-func analyzeSession(sess note.SessionEntry, startWork, endWork time.Time, full bool) (*SessionSummary, error) {
+// analyzeSession extracts all entries from a session, marking which are in
+// work period. It also returns how many timestamps went back in the
+// transcript, see session.MonotonicTimestamps.
+func analyzeSession(sess note.SessionEntry, startWork, endWork time.Time, full bool) (*SessionSummary, int, error) {
 	// Extract relative path from full ref path
 	relPath := strings.TrimPrefix(sess.Path, note.TranscriptsRef+"/")
 
 	// Fetch transcript content
 	content, err := git.GetBlobContent(note.TranscriptsRef, relPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch transcript: %w", err)
+		return nil, 0, fmt.Errorf("failed to fetch transcript: %w", err)
+	}
+	if tombstone, ok := note.ParseTombstone(content); ok {
+		ss := summarizeEntries(sess, nil, startWork, endWork, full)
+		ss.Removed = tombstone
+		return ss, 0, nil
+	}
+	if stub, ok := note.ParseQuarantineStub(content); ok {
+		ss := summarizeEntries(sess, nil, startWork, endWork, full)
+		ss.Quarantined = stub
+		return ss, 0, nil
 	}
 
 	// Parse messages
-	entries, err := session.ParseMessages(content)
+	entries, err := session.ParseTranscript(sess.Tool, content)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse messages: %w", err)
+		return nil, 0, fmt.Errorf("failed to parse messages: %w", err)
 	}
+	regressions := session.MonotonicTimestamps(entries)
 
+	return summarizeEntries(sess, entries, startWork, endWork, full), regressions, nil
+}
+
+// summarizeEntries builds the session summary from parsed transcript entries
+func summarizeEntries(sess note.SessionEntry, entries []session.MessageEntry, startWork, endWork time.Time, full bool) *SessionSummary {
 	ss := &SessionSummary{
 		Tool:    sess.Tool,
 		ID:      sess.ID,
 		IsAgent: IsAgentSession(sess.ID),
+		Owner:   sess.Owner(),
 		Start:   sess.Created,
 		End:     sess.Modified,
 		Prompts: make([]PromptEntry, 0),
 	}
+	if sess.Context != nil {
+		ss.Branch = sess.Context.Branch
+		ss.Cwd = sess.Context.Cwd
+	}
 
-	// Map to track tool use entries by ID for linking with results
-	toolUseEntries := make(map[string]*PromptEntry)
+	// Map tool use IDs to their index in ss.Prompts for linking with results.
+	// Indices, not pointers: appending to ss.Prompts may move the entries.
+	toolUseEntries := make(map[string]int)
 
 	// Map to track AskUserQuestion entries by tool ID for linking with answers
 	// Key is tool ID, value is slice of indices into ss.Prompts for the DECISION entries
@@ -258,6 +444,7 @@ func analyzeSession(sess note.SessionEntry, startWork, endWork time.Time, full b
 						cmdName = strings.TrimPrefix(cmdName, "/")
 						pe := PromptEntry{
 							Time:         ts,
+							RecordedTime: entry.Recorded,
 							Type:         "COMMAND",
 							Text:         "/" + cmdName,
 							InWorkPeriod: inWorkPeriod,
@@ -286,6 +473,7 @@ func analyzeSession(sess note.SessionEntry, startWork, endWork time.Time, full b
 					}
 					pe := PromptEntry{
 						Time:         ts,
+						RecordedTime: entry.Recorded,
 						Type:         "TASK_NOTIFICATION",
 						Text:         displayText,
 						InWorkPeriod: inWorkPeriod,
@@ -315,6 +503,7 @@ func analyzeSession(sess note.SessionEntry, startWork, endWork time.Time, full b
 							}
 							pe := PromptEntry{
 								Time:         ts,
+								RecordedTime: entry.Recorded,
 								Type:         "TOOL_REJECT",
 								Text:         text,
 								InWorkPeriod: inWorkPeriod,
@@ -326,8 +515,12 @@ func analyzeSession(sess note.SessionEntry, startWork, endWork time.Time, full b
 							continue
 						}
 						// Find and update the corresponding tool use entry
-						if toolUse, ok := toolUseEntries[tr.ToolUseID]; ok {
-							toolUse.ToolOutput = tr.Content
+						if idx, ok := toolUseEntries[tr.ToolUseID]; ok {
+							output := tr.Content
+							if !full && len(output) > 2000 {
+								output = output[:2000] + "...[TRUNCATED]"
+							}
+							ss.Prompts[idx].ToolOutput = output
 						}
 						// Check if this is an answer to AskUserQuestion
 						if indices, ok := askUserQuestionEntries[tr.ToolUseID]; ok {
@@ -362,6 +555,7 @@ func analyzeSession(sess note.SessionEntry, startWork, endWork time.Time, full b
 				if msgText != "" {
 					pe := PromptEntry{
 						Time:         ts,
+						RecordedTime: entry.Recorded,
 						Type:         "PROMPT",
 						Text:         msgText,
 						InWorkPeriod: inWorkPeriod,
@@ -385,6 +579,7 @@ func analyzeSession(sess note.SessionEntry, startWork, endWork time.Time, full b
 			}
 			pe := PromptEntry{
 				Time:         ts,
+				RecordedTime: entry.Recorded,
 				Type:         "TOOL_REJECT",
 				Text:         text,
 				InWorkPeriod: inWorkPeriod,
@@ -408,6 +603,7 @@ func analyzeSession(sess note.SessionEntry, startWork, endWork time.Time, full b
 								for _, q := range askInput.Questions {
 									pe := PromptEntry{
 										Time:           ts,
+										RecordedTime:   entry.Recorded,
 										Type:           "DECISION",
 										Text:           q.Question,
 										ToolID:         tool.ID,
@@ -432,13 +628,16 @@ func analyzeSession(sess note.SessionEntry, startWork, endWork time.Time, full b
 
 						pe := PromptEntry{
 							Time:         ts,
-							Type:         "TOOL_USE",
+							RecordedTime: entry.Recorded,
+							Type:         display.TypeForTool(tool.Name),
 							Text:         tool.Name,
 							ToolID:       tool.ID,
 							ToolName:     tool.Name,
 							ToolInput:    tool.Input,
 							InWorkPeriod: inWorkPeriod,
 						}
+						pe.FilePath, pe.FileLine, pe.FileAnchor = fileLocation(tool.Name, tool.RawInput)
+						pe.Written = writtenText(tool.Name, tool.RawInput)
 						if !full && len(pe.ToolInput) > 500 {
 							pe.ToolInput = pe.ToolInput[:500] + "...[TRUNCATED]"
 							pe.Truncated = true
@@ -446,12 +645,13 @@ func analyzeSession(sess note.SessionEntry, startWork, endWork time.Time, full b
 						if inWorkPeriod {
 							ss.Prompts = append(ss.Prompts, pe)
 							// Track for linking with results
-							toolUseEntries[tool.ID] = &ss.Prompts[len(ss.Prompts)-1]
+							toolUseEntries[tool.ID] = len(ss.Prompts) - 1
 						}
 					}
 				} else if entryType == "ASSISTANT" && text != "" {
 					pe := PromptEntry{
 						Time:         ts,
+						RecordedTime: entry.Recorded,
 						Type:         "ASSISTANT",
 						Text:         text,
 						InWorkPeriod: inWorkPeriod,
@@ -486,6 +686,7 @@ func analyzeSession(sess note.SessionEntry, startWork, endWork time.Time, full b
 					}
 					pe := PromptEntry{
 						Time:         ts,
+						RecordedTime: entry.Recorded,
 						Type:         "TASK_NOTIFICATION",
 						Text:         displayText,
 						InWorkPeriod: inWorkPeriod,
@@ -501,6 +702,7 @@ func analyzeSession(sess note.SessionEntry, startWork, endWork time.Time, full b
 				}
 				pe := PromptEntry{
 					Time:         ts,
+					RecordedTime: entry.Recorded,
 					Type:         "PROMPT",
 					Text:         entry.Content,
 					InWorkPeriod: inWorkPeriod,
@@ -516,7 +718,31 @@ func analyzeSession(sess note.SessionEntry, startWork, endWork time.Time, full b
 		}
 	}
 
-	return ss, nil
+	assignStepIDs(ss)
+	ss.Languages = detectLanguages(ss.Prompts)
+	prependContext(ss, sess)
+	return ss
+}
+
+// prependContext leads a session that has steps with the repository state
+// it started in. The context gets an ordinal no real step uses, so the IDs
+// of the transcript's own steps do not change.
+func prependContext(ss *SessionSummary, sess note.SessionEntry) {
+	if sess.Context == nil || len(ss.Prompts) == 0 {
+		return
+	}
+	lines := sess.Context.Lines()
+	if len(lines) == 0 {
+		return
+	}
+	ctx := PromptEntry{
+		Time:         sess.Created,
+		Type:         "CONTEXT",
+		Text:         strings.Join(lines, "; "),
+		InWorkPeriod: true,
+		StepID:       StepID(ss.ID, sess.Created, -1),
+	}
+	ss.Prompts = append([]PromptEntry{ctx}, ss.Prompts...)
 }
 
 // ToolResultInfo holds extracted tool result information
@@ -746,6 +972,57 @@ func formatToolInput(toolName string, input json.RawMessage) string {
 	return ""
 }
 
+// fileLocation extracts the file a tool touched and, where the input tells,
+// the line: Read has an offset, Edit carries the replacement text
+func fileLocation(toolName string, input json.RawMessage) (path string, line int, anchor string) {
+	var in struct {
+		FilePath  string `json:"file_path"`
+		Offset    int    `json:"offset"`
+		NewString string `json:"new_string"`
+	}
+	if len(input) == 0 || json.Unmarshal(input, &in) != nil {
+		return "", 0, ""
+	}
+
+	switch toolName {
+	case "Read":
+		return in.FilePath, in.Offset, ""
+	case "Edit":
+		return in.FilePath, 0, firstNonEmptyLine(in.NewString)
+	case "Write":
+		return in.FilePath, 1, ""
+	}
+	return "", 0, ""
+}
+
+// writtenText returns the text an Edit or Write tool call put in its file
+func writtenText(toolName string, input json.RawMessage) string {
+	var in struct {
+		NewString string `json:"new_string"`
+		Content   string `json:"content"`
+	}
+	if len(input) == 0 || json.Unmarshal(input, &in) != nil {
+		return ""
+	}
+	switch toolName {
+	case "Edit":
+		return in.NewString
+	case "Write":
+		return in.Content
+	}
+	return ""
+}
+
+// firstNonEmptyLine returns the first line of s with non-space content
+func firstNonEmptyLine(s string) string {
+	for _, line := range strings.Split(s, "\n") {
+		if strings.TrimSpace(line) != "" {
+			return line
+		}
+	}
+	return ""
+}
+
 // getCommitSubject gets the first line of a commit message
 func getCommitSubject(sha string) (string, error) {
 	out, err := git.RunGit("log", "-1", "--format=%s", sha)
@@ -760,34 +1037,64 @@ type TimelineEntry struct {
 	Entry       PromptEntry
 	CommitSHA   string
 	CommitSubj  string
-	CommitIndex int // Order of commit in the PR
+	CommitIndex int   // Order of commit in the PR
+	Task        *Task // Task grouping the commit with its neighbours, if any
 }
 
 // RenderMarkdown generates markdown output for PR comment
 func RenderMarkdown(summary *Summary, pagesURL string, version string) string {
-	var sb strings.Builder
+	return Render(MarkdownRenderer, summary, pagesURL, version)
+}
 
-	if summary.CommitsWithNotes == 0 {
-		sb.WriteString("No prompt-story notes found in this PR.\n")
-		return sb.String()
-	}
+// Render generates the PR summary with the given renderer
+func Render(r Renderer, summary *Summary, pagesURL string, version string) string {
+	out, _ := render(r, summary, pagesURL, version)
+	return out
+}
+
+// renderStats counts what RenderMarkdown cut to stay within its budgets
+type renderStats struct {
+	UserPrompts       int
+	TruncatedPrompts  int
+	TruncatedSessions int
+	TruncatedSteps    int
+}
 
-	// Reverse commits to show oldest first (chronological order)
+// chronologicalCommits returns the commits oldest first, with sessions
+// sorted by start time, as they are rendered
+func chronologicalCommits(summary *Summary) []CommitSummary {
 	commits := make([]CommitSummary, len(summary.Commits))
 	for i, c := range summary.Commits {
 		commits[len(summary.Commits)-1-i] = c
 	}
-
-	// Sort sessions within each commit by start time (earliest first)
 	for i := range commits {
 		sort.Slice(commits[i].Sessions, func(a, b int) bool {
 			return commits[i].Sessions[a].Start.Before(commits[i].Sessions[b].Start)
 		})
 	}
+	return commits
+}
+
+func render(r Renderer, summary *Summary, pagesURL string, version string) (string, renderStats) {
+	var sb strings.Builder
+	var stats renderStats
+
+	if summary.CommitsWithNotes == 0 {
+		sb.WriteString(r.Line("No prompt-story notes found in this PR."))
+		return sb.String(), stats
+	}
+
+	if summary.Sampled != nil {
+		sb.WriteString(sampledBanner(r, summary.Sampled))
+	}
+
+	// Oldest first (chronological order)
+	commits := chronologicalCommits(summary)
 
 	// Build timeline entries from all commits
 	var userTimeline []TimelineEntry
 	var fullTimeline []TimelineEntry
+	tasks := taskByCommit(groupTasks(commits))
 
 	for i, commit := range commits {
 		for _, sess := range commit.Sessions {
@@ -797,6 +1104,7 @@ func RenderMarkdown(summary *Summary, pagesURL string, version string) string {
 					CommitSHA:   commit.ShortSHA,
 					CommitSubj:  commit.Subject,
 					CommitIndex: i,
+					Task:        tasks[i],
 				}
 				fullTimeline = append(fullTimeline, te)
 				if IsUserAction(p.Type) && !sess.IsAgent {
@@ -858,6 +1166,7 @@ func RenderMarkdown(summary *Summary, pagesURL string, version string) string {
 
 	// Deduplicate consecutive identical entries
 	userTimeline = deduplicateConsecutive(userTimeline)
+	stats.UserPrompts = len(userTimeline)
 
 	// Count file edits (Write/Edit operations) from fullTimeline
 	fileEditCount := 0
@@ -868,11 +1177,13 @@ func RenderMarkdown(summary *Summary, pagesURL string, version string) string {
 	}
 
 	// Render Prompts section - markdown header, show first 10, collapse rest
+	tagLine := renderTagRollup(r, TagRollup(summary)) + renderRedactionRollup(r, RedactionRollup(summary))
 	if len(userTimeline) == 0 {
-		sb.WriteString("*No user prompts in this PR*\n\n")
+		sb.WriteString(r.Paragraph(r.Italic("No user prompts in this PR")))
+		sb.WriteString(tagLine)
 	} else {
 		// Build header with optional extras
-		header := fmt.Sprintf("# %d user prompts", len(userTimeline))
+		header := fmt.Sprintf("%d user prompts", len(userTimeline))
 		var extras []string
 		if fileEditCount > 0 {
 			extras = append(extras, fmt.Sprintf("%d file edits", fileEditCount))
@@ -887,14 +1198,16 @@ func RenderMarkdown(summary *Summary, pagesURL string, version string) string {
 		if len(extras) > 0 {
 			header += " (" + strings.Join(extras, ", ") + ")"
 		}
-		sb.WriteString(header + "\n\n")
+		sb.WriteString(r.Heading(1, header))
+		sb.WriteString(tagLine)
 
 		if len(userTimeline) <= 10 {
 			// Show all prompts
 			if allPromptsShort(userTimeline) {
-				renderTimeline(&sb, userTimeline, formatSimple)
+				renderTimeline(&sb, r, userTimeline, formatSimple)
 			} else {
-				userPromptsContent, _ := renderUserTimelineWithTruncation(userTimeline, maxUserPromptsSize)
+				userPromptsContent, truncated := renderUserTimelineWithTruncation(r, userTimeline, maxUserPromptsSize)
+				stats.TruncatedPrompts += truncated
 				sb.WriteString(userPromptsContent)
 			}
 		} else {
@@ -904,40 +1217,40 @@ func RenderMarkdown(summary *Summary, pagesURL string, version string) string {
 
 			// Render first 10
 			if allPromptsShort(first10) {
-				renderTimeline(&sb, first10, formatSimple)
+				renderTimeline(&sb, r, first10, formatSimple)
 			} else {
-				content, _ := renderUserTimelineWithTruncation(first10, maxUserPromptsSize)
+				content, truncated := renderUserTimelineWithTruncation(r, first10, maxUserPromptsSize)
+				stats.TruncatedPrompts += truncated
 				sb.WriteString(content)
 			}
 
 			// Render remaining in collapsible section
-			sb.WriteString(fmt.Sprintf("\n<details><summary>Show %d more...</summary>\n\n", len(remaining)))
+			var more strings.Builder
 			if allPromptsShort(remaining) {
-				renderTimeline(&sb, remaining, formatSimple)
+				renderTimeline(&more, r, remaining, formatSimple)
 			} else {
-				content, _ := renderUserTimelineWithTruncation(remaining, maxUserPromptsSize)
-				sb.WriteString(content)
+				content, truncated := renderUserTimelineWithTruncation(r, remaining, maxUserPromptsSize)
+				stats.TruncatedPrompts += truncated
+				more.WriteString(content)
 			}
-			sb.WriteString("</details>\n\n")
+			sb.WriteString("\n")
+			sb.WriteString(r.Details(fmt.Sprintf("Show %d more...", len(remaining)), more.String()))
 		}
 	}
 
 	// Render All Steps section - markdown header with all steps collapsed
-	sb.WriteString(fmt.Sprintf("# All %d steps\n\n", len(fullTimeline)))
-	sb.WriteString("<details><summary>Show all...</summary>\n\n")
-	allStepsContent, _, _ := renderAllSteps(commits, maxAllStepsSize, pagesURL)
-	sb.WriteString(allStepsContent)
-	sb.WriteString("</details>\n\n")
+	sb.WriteString(r.Heading(1, fmt.Sprintf("All %d steps", len(fullTimeline))))
+	allStepsContent, truncSessions, truncSteps := renderAllSteps(r, commits, maxAllStepsSize, pagesURL)
+	stats.TruncatedSessions, stats.TruncatedSteps = truncSessions, truncSteps
+	sb.WriteString(r.Details("Show all...", allStepsContent))
 
 	// Link to full transcripts (only if not already shown in truncation message)
 	if pagesURL != "" {
-		sb.WriteString(fmt.Sprintf("[View full transcripts](%s)\n\n", pagesURL))
+		sb.WriteString(r.Paragraph(r.Link("View full transcripts", pagesURL)))
 	}
 
 	// Summary table (at the bottom)
-	sb.WriteString("| Commit | Subject | Tool(s) | User Prompts | Steps |\n")
-	sb.WriteString("|--------|---------|---------|--------------|-------|\n")
-
+	var rows [][]string
 	for _, commit := range commits {
 		// Collect unique tools
 		tools := make(map[string]bool)
@@ -959,46 +1272,34 @@ func RenderMarkdown(summary *Summary, pagesURL string, version string) string {
 		// Format tool names
 		toolDisplay := formatToolDisplay(tools)
 
-		// Truncate subject for table
-		subject := commit.Subject
-		if len(subject) > 40 {
-			subject = subject[:37] + "..."
-		}
-		subject = html.EscapeString(subject)
-
 		// Format user prompts (main session only)
 		promptDisplay := fmt.Sprintf("%d", userPromptCount)
 
-		sb.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %d |\n",
-			commit.ShortSHA, subject, toolDisplay, promptDisplay, totalSteps))
+		rows = append(rows, []string{commit.ShortSHA, subjectCell(r, commit.Subject), toolDisplay, promptDisplay, fmt.Sprint(totalSteps)})
 	}
-	sb.WriteString("\n")
+	sb.WriteString(r.Table([]string{"Commit", "Subject", "Tool(s)", "User Prompts", "Steps"}, rows))
+	sb.WriteString(footer(r, version))
 
-	sb.WriteString(fmt.Sprintf("---\n*Generated by [git-prompt-story](https://github.com/QuesmaOrg/git-prompt-story) %s*\n", version))
-
-	return sb.String()
+	return sb.String(), stats
 }
 
-// RenderMissingNotesWarning generates markdown warning when commits have markers but notes are missing
-func RenderMissingNotesWarning(commitsMissing int, version string) string {
-	return fmt.Sprintf(`## ⚠️ Prompt Story Notes Not Found
-
-This PR has %d commit(s) with `+"`Prompt-Story:`"+` markers, but the notes could not be fetched.
-
-**Did you forget to push your notes?**
-
-`+"```bash"+`
-git push origin refs/notes/prompt-story
-`+"```"+`
-
-Or push all refs including notes:
-`+"```bash"+`
-git push --all && git push origin refs/notes/prompt-story
-`+"```"+`
+// footer credits the tool at the end of a summary
+func footer(r Renderer, version string) string {
+	return r.Rule() + r.Line(r.Italic("Generated by "+r.Link("git-prompt-story", "https://github.com/QuesmaOrg/git-prompt-story")+" "+r.Escape(version)))
+}
 
----
-*Generated by [git-prompt-story](https://github.com/QuesmaOrg/git-prompt-story) %s*
-`, commitsMissing, version)
+// RenderMissingNotesWarning generates a warning when commits have markers but notes are missing
+func RenderMissingNotesWarning(r Renderer, commitsMissing int, version string) string {
+	var sb strings.Builder
+	sb.WriteString(r.Heading(2, "⚠️ Prompt Story Notes Not Found"))
+	sb.WriteString(r.Paragraph(fmt.Sprintf("This PR has %d commit(s) with %s markers, but the notes could not be fetched.",
+		commitsMissing, r.Code("Prompt-Story:"))))
+	sb.WriteString(r.Paragraph(r.Bold("Did you forget to push your notes?")))
+	sb.WriteString(r.CodeBlock("bash", "git push origin refs/notes/prompt-story") + "\n")
+	sb.WriteString(r.Line("Or push all refs including notes:"))
+	sb.WriteString(r.CodeBlock("bash", "git push --all && git push origin refs/notes/prompt-story") + "\n")
+	sb.WriteString(footer(r, version))
+	return sb.String()
 }
 
 // Format modes for renderTimeline
@@ -1009,303 +1310,329 @@ const (
 )
 
 // renderTimeline renders a list of timeline entries with commit markers
-func renderTimeline(sb *strings.Builder, entries []TimelineEntry, formatMode string) {
+func renderTimeline(sb *strings.Builder, r Renderer, entries []TimelineEntry, formatMode string) {
 	lastCommitIndex := -1
+	var lastTask *Task
 
 	for _, te := range entries {
 		// Insert commit marker when we cross to a new commit (including the first one)
 		if te.CommitIndex != lastCommitIndex {
-			subject := te.CommitSubj
-			if len(subject) > 40 {
-				subject = subject[:37] + "..."
+			if lastCommitIndex >= 0 {
+				sb.WriteString(r.ListEnd())
 			}
-			subject = html.EscapeString(subject)
-			sb.WriteString(fmt.Sprintf("\n#### %s: %s\n\n", te.CommitSHA, subject))
+			sb.WriteString(timelineHeader(r, te, lastTask))
+			sb.WriteString(r.ListStart())
 		}
 		lastCommitIndex = te.CommitIndex
+		lastTask = te.Task
 
 		// Format the entry based on mode
 		switch formatMode {
 		case formatCollapsible:
 			if IsUserAction(te.Entry.Type) {
-				sb.WriteString(formatMarkdownEntryCollapsible(te.Entry))
+				sb.WriteString(formatEntryCollapsible(r, te.Entry))
 			} else {
-				sb.WriteString(formatMarkdownEntry(te.Entry))
+				sb.WriteString(formatEntry(r, te.Entry))
 			}
 		case formatSimple:
-			sb.WriteString(formatMarkdownEntrySimple(te.Entry))
+			sb.WriteString(formatEntrySimple(r, te.Entry))
 		default: // formatRegular
-			sb.WriteString(formatMarkdownEntry(te.Entry))
+			sb.WriteString(formatEntry(r, te.Entry))
 		}
 	}
+	if lastCommitIndex >= 0 {
+		sb.WriteString(r.ListEnd())
+	}
 }
 
-// renderAllSteps renders all steps grouped by session with truncation support
-// Returns the rendered string and count of truncated sessions/steps
-func renderAllSteps(commits []CommitSummary, maxSize int, pagesURL string) (string, int, int) {
+// renderAllSteps renders all steps grouped by session, dropping steps by
+// priority (see selectSteps) when they exceed maxSize. It returns the
+// rendered string, the number of sessions dropped entirely and the number
+// of steps dropped.
+func renderAllSteps(r Renderer, commits []CommitSummary, maxSize int, pagesURL string) (string, int, int) {
 	var sb strings.Builder
 	truncatedSessions := 0
 	truncatedSteps := 0
-
-	for _, commit := range commits {
-		// Format commit header
-		subject := commit.Subject
-		if len(subject) > 40 {
-			subject = subject[:37] + "..."
-		}
-		subject = html.EscapeString(subject)
-		commitHeader := fmt.Sprintf("\n#### %s: %s\n\n", commit.ShortSHA, subject)
-
-		// Check if we can fit this commit header
-		if sb.Len()+len(commitHeader) > maxSize {
-			// Count remaining sessions and steps
-			for _, sess := range commit.Sessions {
-				truncatedSessions++
-				truncatedSteps += len(sess.Prompts)
+	sel := selectSteps(r, commits, maxSize, pagesURL)
+	tasks := taskByCommit(groupTasks(commits))
+	var lastTask *Task
+	writeHeader := func(c int) {
+		if t := tasks[c]; t != nil && t != lastTask {
+			sb.WriteString(taskHeader(r, t))
+			lastTask = t
+		}
+		sb.WriteString(commitHeader(r, commits[c]))
+	}
+
+	for c, commit := range commits {
+		headerWritten := false
+		for si, sess := range commit.Sessions {
+			if withheld := sess.Withheld(); withheld != "" {
+				if !headerWritten {
+					writeHeader(c)
+					headerWritten = true
+				}
+				sb.WriteString(r.Line(r.Bold("Session: "+note.FormatToolName(sess.Tool)) + " " + r.Italic(r.Escape(withheld))))
+				sb.WriteString("\n")
+				continue
 			}
-			continue
-		}
-		sb.WriteString(commitHeader)
-
-		for _, sess := range commit.Sessions {
-			// Format session header
-			toolName := note.FormatToolName(sess.Tool)
-			startTime := sess.Start.Local().Format("15:04")
-			endTime := sess.End.Local().Format("15:04")
-			sessionHeader := fmt.Sprintf("**Session: %s** (%s-%s, %d steps)\n", toolName, startTime, endTime, len(sess.Prompts))
-
-			// Estimate session size (header + entries)
-			estimatedEntrySize := len(sess.Prompts) * 80 // rough estimate per entry
-			if sb.Len()+len(sessionHeader)+estimatedEntrySize > maxSize {
-				truncatedSessions++
-				truncatedSteps += len(sess.Prompts)
+			shown := sel.shown(c, si)
+			truncatedSteps += len(sess.Prompts) - shown
+			if shown == 0 {
+				if len(sess.Prompts) > 0 {
+					truncatedSessions++
+				}
 				continue
 			}
 
-			sb.WriteString(sessionHeader)
+			if !headerWritten {
+				writeHeader(c)
+				headerWritten = true
+			}
+			sb.WriteString(sessionHeader(r, sess, shown))
 
 			// Render entries with indent
-			for _, p := range sess.Prompts {
-				entryStr := formatMarkdownEntryIndented(p)
-				if sb.Len()+len(entryStr) > maxSize {
-					// Count remaining entries in this session
-					truncatedSteps++
-					continue
+			sb.WriteString(r.ListStart())
+			for i, p := range sess.Prompts {
+				if sel.kept[c][si][i] {
+					sb.WriteString(formatStep(r, p, stepURL(pagesURL, commit.ShortSHA, p.StepID)))
 				}
-				sb.WriteString(entryStr)
 			}
+			sb.WriteString(r.ListEnd())
 			sb.WriteString("\n")
 		}
 	}
 
 	// Add truncation notice if needed
 	if truncatedSessions > 0 || truncatedSteps > 0 {
-		notice := fmt.Sprintf("\n*...truncated %d older sessions with %d steps", truncatedSessions, truncatedSteps)
+		notice := fmt.Sprintf("...truncated %d steps, tool calls first", truncatedSteps)
+		if truncatedSessions > 0 {
+			notice += fmt.Sprintf("; %d sessions omitted entirely", truncatedSessions)
+		}
 		if pagesURL != "" {
-			notice += fmt.Sprintf(". [View full transcripts](%s)", pagesURL)
+			notice += ". " + r.Link("View full transcripts", pagesURL)
 		}
-		notice += "*\n"
-		sb.WriteString(notice)
+		sb.WriteString("\n" + r.Line(r.Italic(notice)))
 	}
 
 	return sb.String(), truncatedSessions, truncatedSteps
 }
 
-// formatMarkdownEntryIndented formats a single entry with indentation for session grouping
-func formatMarkdownEntryIndented(entry PromptEntry) string {
-	timeStr := entry.Time.Local().Format("15:04")
-	emoji := display.GetTypeEmoji(entry.Type)
-	text := strings.ReplaceAll(entry.Text, "\n", " ")
-	if len(text) > 100 {
-		text = text[:97] + "..."
+// commitHeader is the heading of a commit in the "All steps" section
+func commitHeader(r Renderer, commit CommitSummary) string {
+	return "\n" + r.Heading(4, commit.ShortSHA+": "+r.Escape(truncateSubject(commit.Subject)))
+}
+
+// sessionHeader is the heading of a session, noting how many of its steps
+// are shown when some were truncated
+func sessionHeader(r Renderer, sess SessionSummary, shown int) string {
+	toolName := note.FormatToolName(sess.Tool)
+	startTime := sess.Start.Local().Format("15:04")
+	endTime := sess.End.Local().Format("15:04")
+	steps := fmt.Sprintf("%d steps", len(sess.Prompts))
+	if shown < len(sess.Prompts) {
+		steps += fmt.Sprintf(", %d shown", shown)
+	}
+	if sess.ContinuesFrom != "" {
+		steps += ", continued session"
 	}
-	text = html.EscapeString(text)
+	return r.Line(fmt.Sprintf("%s (%s-%s, %s)%s", r.Bold("Session: "+toolName), startTime, endTime, steps, languageChips(r, sess.Languages)))
+}
 
-	switch entry.Type {
-	case "TOOL_USE":
-		if entry.ToolName != "" {
-			input := entry.ToolInput
-			if len(input) > 60 {
-				input = input[:57] + "..."
-			}
-			input = strings.ReplaceAll(input, "\n", " ")
-			input = html.EscapeString(input)
-			return fmt.Sprintf("  - %s %s %s: %s\n", timeStr, emoji, entry.ToolName, input)
-		}
-		return fmt.Sprintf("  - %s %s %s\n", timeStr, emoji, text)
-	case "DECISION":
-		header := entry.DecisionHeader
-		if header == "" {
-			header = "Question"
-		}
-		answer := entry.DecisionAnswer
-		if answer == "" {
-			answer = "(no answer)"
-		}
-		answer = html.EscapeString(answer)
-		// Include description in italic if available
-		desc := ""
-		if entry.DecisionAnswerDescription != "" {
-			desc = " *" + html.EscapeString(entry.DecisionAnswerDescription) + "*"
-		}
-		return fmt.Sprintf("  - %s %s %s: %s → %s%s\n", timeStr, emoji, header, text, answer, desc)
-	default:
-		if entry.Type == "PROMPT" || entry.Type == "ASSISTANT" || entry.Type == "COMMAND" || entry.Type == "TOOL_REJECT" {
-			return fmt.Sprintf("  - %s %s %s\n", timeStr, emoji, text)
-		}
-		return fmt.Sprintf("  - %s %s %s: %s\n", timeStr, emoji, entry.Type, text)
+// formatStep formats an entry of the "All steps" section, indented under
+// its session and linking its time to the step on the transcript pages
+// when link is set
+func formatStep(r Renderer, entry PromptEntry, link string) string {
+	timeStr := entry.Time.Local().Format("15:04")
+	if link != "" {
+		timeStr = r.Link(timeStr, link)
 	}
+	return r.ListItem(1, entryText(r, entry, timeStr))
 }
 
-// renderUserTimelineWithTruncation renders user prompts with size limit
+// renderUserTimelineWithTruncation renders user prompts with size limit.
+// Every entry is a user action, so before any is dropped the longest ones
+// are shortened to a single line.
 // Returns the rendered string and count of truncated prompts
-func renderUserTimelineWithTruncation(entries []TimelineEntry, maxSize int) (string, int) {
+func renderUserTimelineWithTruncation(r Renderer, entries []TimelineEntry, maxSize int) (string, int) {
 	var sb strings.Builder
 	truncatedCount := 0
 	lastCommitIndex := -1
 
-	for _, te := range entries {
+	var lastTask *Task
+
+	rendered := make([]string, len(entries))
+	total := 0
+	for i, te := range entries {
+		if te.CommitIndex != lastCommitIndex {
+			total += len(timelineHeader(r, te, lastTask)) + len(r.ListStart()) + len(r.ListEnd())
+		}
+		lastCommitIndex = te.CommitIndex
+		lastTask = te.Task
+		rendered[i] = formatEntryCollapsible(r, te.Entry)
+		total += len(rendered[i])
+	}
+	if total > maxSize {
+		order := make([]int, len(entries))
+		for i := range order {
+			order[i] = i
+		}
+		sort.SliceStable(order, func(a, b int) bool { return len(rendered[order[a]]) > len(rendered[order[b]]) })
+		for _, i := range order {
+			if total <= maxSize {
+				break
+			}
+			compact := formatEntryCompact(r, entries[i].Entry)
+			total -= len(rendered[i]) - len(compact)
+			rendered[i] = compact
+		}
+	}
+
+	lastCommitIndex = -1
+	lastTask = nil
+	listOpen := false
+	for i, te := range entries {
 		// Insert commit marker when we cross to a new commit
 		if te.CommitIndex != lastCommitIndex {
-			subject := te.CommitSubj
-			if len(subject) > 40 {
-				subject = subject[:37] + "..."
+			header := timelineHeader(r, te, lastTask) + r.ListStart()
+			if listOpen {
+				header = r.ListEnd() + header
 			}
-			subject = html.EscapeString(subject)
-			header := fmt.Sprintf("\n#### %s: %s\n\n", te.CommitSHA, subject)
 			if sb.Len()+len(header) > maxSize {
 				truncatedCount++
 				continue
 			}
 			sb.WriteString(header)
+			listOpen = true
 		}
 		lastCommitIndex = te.CommitIndex
+		lastTask = te.Task
 
-		// Format the entry
-		entryStr := formatMarkdownEntryCollapsible(te.Entry)
-		if sb.Len()+len(entryStr) > maxSize {
+		if sb.Len()+len(rendered[i]) > maxSize {
 			truncatedCount++
 			continue
 		}
-		sb.WriteString(entryStr)
+		sb.WriteString(rendered[i])
+	}
+	if listOpen {
+		sb.WriteString(r.ListEnd())
 	}
 
 	// Add truncation notice if needed
 	if truncatedCount > 0 {
-		notice := fmt.Sprintf("\n*...truncated %d older user prompts*\n", truncatedCount)
-		sb.WriteString(notice)
+		sb.WriteString("\n" + r.Line(r.Italic(fmt.Sprintf("...truncated %d older user prompts", truncatedCount))))
 	}
 
 	return sb.String(), truncatedCount
 }
 
-// formatMarkdownEntry formats a single entry for markdown display
-func formatMarkdownEntry(entry PromptEntry) string {
-	timeStr := entry.Time.Local().Format("15:04")
+// timelineCommitHeader marks where a timeline crosses to a new commit
+func timelineCommitHeader(r Renderer, te TimelineEntry) string {
+	return "\n" + r.Heading(4, te.CommitSHA+": "+r.Escape(truncateSubject(te.CommitSubj)))
+}
+
+// timelineHeader is the commit header of te, preceded by its task's
+// header when te starts a task other than the one before it (prev)
+func timelineHeader(r Renderer, te TimelineEntry, prev *Task) string {
+	if te.Task != nil && te.Task != prev {
+		return taskHeader(r, te.Task) + timelineCommitHeader(r, te)
+	}
+	return timelineCommitHeader(r, te)
+}
+
+// formatEntryCompact formats an entry on a single line, cutting long text
+// instead of making it collapsible
+func formatEntryCompact(r Renderer, entry PromptEntry) string {
+	if len(entry.Text) > 250 {
+		entry.Text = entry.Text[:247] + "..."
+	}
+	return formatEntryCollapsible(r, entry)
+}
+
+// formatEntry formats a single entry as a list item
+func formatEntry(r Renderer, entry PromptEntry) string {
+	return r.ListItem(0, entryText(r, entry, entry.Time.Local().Format("15:04")))
+}
+
+// entryText is the text of a step: its time, emoji and a line of content
+func entryText(r Renderer, entry PromptEntry, timeStr string) string {
 	emoji := display.GetTypeEmoji(entry.Type)
 	text := strings.ReplaceAll(entry.Text, "\n", " ")
 	if len(text) > 100 {
 		text = text[:97] + "..."
 	}
-	// Escape HTML to prevent breaking markdown structure
-	text = html.EscapeString(text)
-
-	switch entry.Type {
-	case "TOOL_USE":
-		if entry.ToolName != "" {
-			input := entry.ToolInput
-			if len(input) > 60 {
-				input = input[:57] + "..."
-			}
-			input = strings.ReplaceAll(input, "\n", " ")
-			input = html.EscapeString(input)
-			return fmt.Sprintf("- %s %s %s: %s\n", timeStr, emoji, entry.ToolName, input)
-		}
-		return fmt.Sprintf("- %s %s %s\n", timeStr, emoji, text)
-	case "DECISION":
-		header := entry.DecisionHeader
-		if header == "" {
-			header = "Question"
-		}
-		answer := entry.DecisionAnswer
-		if answer == "" {
-			answer = "(no answer)"
-		}
-		answer = html.EscapeString(answer)
-		// Include description in italic if available
-		desc := ""
-		if entry.DecisionAnswerDescription != "" {
-			desc = " *" + html.EscapeString(entry.DecisionAnswerDescription) + "*"
-		}
-		return fmt.Sprintf("- %s %s %s: %s → %s%s\n", timeStr, emoji, header, text, answer, desc)
+	// Escape markup to prevent breaking the document structure
+	text = r.Escape(text)
+
+	switch {
+	case entry.IsToolCall():
+		input := entry.ToolInput
+		if len(input) > 60 {
+			input = input[:57] + "..."
+		}
+		input = strings.ReplaceAll(input, "\n", " ")
+		input = r.Escape(input)
+		return fmt.Sprintf("%s %s %s: %s", timeStr, emoji, entry.ToolName, input)
+	case entry.Type == "TOOL_USE":
+		return fmt.Sprintf("%s %s %s", timeStr, emoji, text)
+	case entry.Type == "DECISION":
+		return fmt.Sprintf("%s %s %s", timeStr, emoji, decisionText(r, entry, text))
 	default:
-		// For known types (PROMPT, ASSISTANT), just show emoji + text
-		// For unknown types, show emoji + type + text
-		if entry.Type == "PROMPT" || entry.Type == "ASSISTANT" || entry.Type == "COMMAND" || entry.Type == "TOOL_REJECT" {
-			return fmt.Sprintf("- %s %s %s\n", timeStr, emoji, text)
-		}
-		return fmt.Sprintf("- %s %s %s: %s\n", timeStr, emoji, entry.Type, text)
+		return fmt.Sprintf("%s %s %s%s", timeStr, emoji, typePrefix(entry.Type), text)
 	}
 }
 
-// formatMarkdownEntryCollapsible formats an entry, making long ones collapsible
-func formatMarkdownEntryCollapsible(entry PromptEntry) string {
-	text := strings.ReplaceAll(entry.Text, "\n", " ")
-	toolCountsStr := formatToolCountsSubBullet(entry.ToolCounts, entry.EditedFiles)
-
-	// [Request interrupted] entries: format as user action
-	if strings.HasPrefix(text, "[Request interrupted") {
-		return fmt.Sprintf("- ⏸️ User interrupted%s\n", toolCountsStr)
+// decisionText formats an answered question with its escaped text, e.g.
+// "Version: Which one? → v2 *the newest*"
+func decisionText(r Renderer, entry PromptEntry, text string) string {
+	header := entry.DecisionHeader
+	if header == "" {
+		header = "Question"
 	}
-
-	// TASK_NOTIFICATION entries: show the formatted text directly
-	if entry.Type == "TASK_NOTIFICATION" {
-		return fmt.Sprintf("- %s%s\n", text, toolCountsStr)
+	answer := entry.DecisionAnswer
+	if answer == "" {
+		answer = "(no answer)"
 	}
-
-	// COMMAND entries: format with backticks
-	if entry.Type == "COMMAND" {
-		text = html.EscapeString(text)
-		return fmt.Sprintf("- `%s`%s\n", text, toolCountsStr)
+	// Include description in italic if available
+	desc := ""
+	if entry.DecisionAnswerDescription != "" {
+		desc = " " + r.Italic(r.Escape(entry.DecisionAnswerDescription))
 	}
+	return fmt.Sprintf("%s: %s → %s%s", r.Escape(header), text, r.Escape(answer), desc)
+}
 
-	// DECISION entries: always show in full with answer
-	if entry.Type == "DECISION" {
-		header := entry.DecisionHeader
-		if header == "" {
-			header = "Question"
-		}
-		answer := entry.DecisionAnswer
-		if answer == "" {
-			answer = "(no answer)"
-		}
-		// Escape HTML
-		text = html.EscapeString(text)
-		answer = html.EscapeString(answer)
-		// Include description in italic if available
-		desc := ""
-		if entry.DecisionAnswerDescription != "" {
-			desc = " *" + html.EscapeString(entry.DecisionAnswerDescription) + "*"
-		}
-		return fmt.Sprintf("- %s: %s → %s%s%s\n", header, text, answer, desc, toolCountsStr)
-	}
+// IsToolCall reports whether the entry is a call to a named tool, either
+// TOOL_USE or a registered type claiming the tool
+func (e PromptEntry) IsToolCall() bool {
+	return e.ToolName != "" && display.TypeCategory(e.Type) == display.CategoryTool
+}
 
-	// Short prompts (≤250 chars): simple bullet
-	if len(text) <= 250 {
-		text = html.EscapeString(text)
-		return fmt.Sprintf("- %s%s\n", text, toolCountsStr)
+// typePrefix returns the "Label: " shown before an entry's text. User and
+// assistant messages need none; unregistered types show their raw name.
+func typePrefix(entryType string) string {
+	t, ok := display.LookupEntryType(entryType)
+	switch {
+	case !ok:
+		return entryType + ": "
+	case t.Category == display.CategoryUser || t.Category == display.CategoryAssistant:
+		return ""
+	default:
+		return t.Label + ": "
 	}
+}
 
-	// Long prompts: <details> (collapsed) with truncated summary
-	summary := text[:247] + "..."
-	continuation := strings.ReplaceAll(entry.Text[247:], "\n", " ")
-
-	// Escape HTML in both summary and continuation
-	summary = html.EscapeString(summary)
-	continuation = html.EscapeString(continuation)
+// formatEntryCollapsible formats an entry, making long prompts collapsible
+func formatEntryCollapsible(r Renderer, entry PromptEntry) string {
+	text := strings.ReplaceAll(entry.Text, "\n", " ")
+	if len(text) <= 250 || strings.HasPrefix(text, "[Request interrupted") ||
+		entry.Type == "TASK_NOTIFICATION" || entry.Type == "COMMAND" || entry.Type == "DECISION" {
+		return formatEntrySimple(r, entry)
+	}
 
-	return fmt.Sprintf("- <details><summary>%s</summary>...%s</details>%s\n",
-		summary, continuation, toolCountsStr)
+	// Long prompts: collapsed with truncated summary
+	summary := r.Escape(text[:247] + "...")
+	continuation := r.Escape(strings.ReplaceAll(entry.Text[247:], "\n", " "))
+	return r.ListItem(0, r.InlineDetails(summary, "..."+continuation), toolCountsItems(r, entry)...)
 }
 
 // extractFilePath extracts file_path from tool input string
@@ -1326,7 +1653,7 @@ func extractFilePath(toolInput string) string {
 }
 
 // formatToolCountsWithFiles formats tool counts, with Edit last showing file paths
-func formatToolCountsWithFiles(counts map[string]int, editedFiles []string) string {
+func formatToolCountsWithFiles(r Renderer, counts map[string]int, editedFiles []string) string {
 	if len(counts) == 0 {
 		return ""
 	}
@@ -1350,7 +1677,7 @@ func formatToolCountsWithFiles(counts map[string]int, editedFiles []string) stri
 	if editCount > 0 {
 		editPart := fmt.Sprintf("%d Edit", editCount)
 		if len(editedFiles) > 0 {
-			editPart += ": " + formatFilePaths(editedFiles)
+			editPart += ": " + formatFilePaths(r, editedFiles)
 		}
 		parts = append(parts, editPart)
 	}
@@ -1359,7 +1686,7 @@ func formatToolCountsWithFiles(counts map[string]int, editedFiles []string) stri
 }
 
 // formatFilePaths formats file paths smartly with common prefix grouping
-func formatFilePaths(files []string) string {
+func formatFilePaths(r Renderer, files []string) string {
 	if len(files) == 0 {
 		return ""
 	}
@@ -1403,14 +1730,14 @@ func formatFilePaths(files []string) string {
 		if allSame && commonDir != "." && commonDir != "/" {
 			// Format as dir/{file1,file2,file3}
 			shortDir := shortenPath(commonDir)
-			return fmt.Sprintf("`%s/{%s}`", shortDir, strings.Join(names, ","))
+			return r.Code(shortDir + "/{" + strings.Join(names, ",") + "}")
 		}
 	}
 
 	// No common directory, just show shortened paths
 	var shortened []string
 	for _, f := range files {
-		shortened = append(shortened, "`"+shortenPath(f)+"`")
+		shortened = append(shortened, r.Code(shortenPath(f)))
 	}
 	return strings.Join(shortened, ", ")
 }
@@ -1424,24 +1751,21 @@ func shortenPath(p string) string {
 	return strings.Join(parts[len(parts)-3:], "/")
 }
 
-// formatToolCountsSubBullet formats tool counts as a sub-bullet line
-func formatToolCountsSubBullet(counts map[string]int, editedFiles []string) string {
-	tc := formatToolCountsWithFiles(counts, editedFiles)
+// toolCountsItems is the sub-item listing the tools that followed a
+// prompt, if any
+func toolCountsItems(r Renderer, entry PromptEntry) []string {
+	tc := formatToolCountsWithFiles(r, entry.ToolCounts, entry.EditedFiles)
 	if tc == "" {
-		return ""
+		return nil
 	}
-	return "\n  - " + tc
+	return []string{tc}
 }
 
 // IsUserAction returns true if the entry type represents a user action
-// (PROMPT, COMMAND, TOOL_REJECT, DECISION, TASK_NOTIFICATION) vs system/assistant actions.
+// (PROMPT, COMMAND, TOOL_REJECT, DECISION, TASK_NOTIFICATION or a registered
+// user-action type) vs system/assistant actions.
 func IsUserAction(entryType string) bool {
-	switch entryType {
-	case "PROMPT", "COMMAND", "TOOL_REJECT", "DECISION", "TASK_NOTIFICATION":
-		return true
-	default:
-		return false
-	}
+	return display.IsUserActionType(entryType)
 }
 
 // allPromptsShort returns true if all entries have short text (≤250 chars)
@@ -1455,48 +1779,25 @@ func allPromptsShort(entries []TimelineEntry) bool {
 	return true
 }
 
-// formatMarkdownEntrySimple formats an entry as a simple bullet without details tags
-func formatMarkdownEntrySimple(entry PromptEntry) string {
+// formatEntrySimple formats an entry as a simple list item, in full
+func formatEntrySimple(r Renderer, entry PromptEntry) string {
 	text := strings.ReplaceAll(entry.Text, "\n", " ")
-	toolCountsStr := formatToolCountsSubBullet(entry.ToolCounts, entry.EditedFiles)
-
-	// [Request interrupted] entries: format as user action
-	if strings.HasPrefix(text, "[Request interrupted") {
-		return fmt.Sprintf("- ⏸️ User interrupted%s\n", toolCountsStr)
-	}
-
-	// TASK_NOTIFICATION entries: show the formatted text directly
-	if entry.Type == "TASK_NOTIFICATION" {
-		return fmt.Sprintf("- %s%s\n", text, toolCountsStr)
-	}
-
-	text = html.EscapeString(text)
-
-	// COMMAND entries: format with backticks
-	if entry.Type == "COMMAND" {
-		return fmt.Sprintf("- `%s`%s\n", text, toolCountsStr)
-	}
-
-	// DECISION entries: show with answer
-	if entry.Type == "DECISION" {
-		header := entry.DecisionHeader
-		if header == "" {
-			header = "Question"
-		}
-		answer := entry.DecisionAnswer
-		if answer == "" {
-			answer = "(no answer)"
-		}
-		answer = html.EscapeString(answer)
-		// Include description in italic if available
-		desc := ""
-		if entry.DecisionAnswerDescription != "" {
-			desc = " *" + html.EscapeString(entry.DecisionAnswerDescription) + "*"
-		}
-		return fmt.Sprintf("- %s: %s → %s%s%s\n", header, text, answer, desc, toolCountsStr)
+	toolCounts := toolCountsItems(r, entry)
+
+	switch {
+	case strings.HasPrefix(text, "[Request interrupted"):
+		// Format as user action
+		return r.ListItem(0, "⏸️ User interrupted", toolCounts...)
+	case entry.Type == "TASK_NOTIFICATION":
+		// Show the formatted text directly
+		return r.ListItem(0, text, toolCounts...)
+	case entry.Type == "COMMAND":
+		return r.ListItem(0, r.Code(text), toolCounts...)
+	case entry.Type == "DECISION":
+		return r.ListItem(0, decisionText(r, entry, r.Escape(text)), toolCounts...)
+	default:
+		return r.ListItem(0, r.Escape(text), toolCounts...)
 	}
-
-	return fmt.Sprintf("- %s%s\n", text, toolCountsStr)
 }
 
 // countUserPrompts counts user action entries in a slice
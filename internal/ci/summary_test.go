@@ -2,11 +2,19 @@ package ci
 
 import (
 	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/QuesmaOrg/git-prompt-story/internal/display"
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+	"github.com/QuesmaOrg/git-prompt-story/internal/note"
+	"github.com/QuesmaOrg/git-prompt-story/internal/session"
 )
 
 // Note: Tests for GetTypeEmoji are in internal/display/display_test.go
@@ -315,14 +323,31 @@ func TestFormatMarkdownEntry(t *testing.T) {
 			entry:    PromptEntry{Type: "CUSTOM_TYPE", Text: "Some content", Time: now},
 			contains: []string{"09:30", "•", "CUSTOM_TYPE:", "Some content"},
 		},
+		{
+			name:     "registered tool type shows tool call",
+			entry:    PromptEntry{Type: "TEST_BROWSER", ToolName: "browser_click", ToolInput: "#submit", Time: now},
+			contains: []string{"09:30", "🌐", "browser_click:", "#submit"},
+		},
+		{
+			name:     "registered type shows label",
+			entry:    PromptEntry{Type: "TEST_BROWSER", Text: "Opened page", Time: now},
+			contains: []string{"🌐", "Browser action: Opened page"},
+		},
 	}
 
+	display.RegisterEntryType(display.EntryType{
+		Name:     "TEST_BROWSER",
+		Emoji:    "🌐",
+		Label:    "Browser action",
+		Category: display.CategoryTool,
+	})
+
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := formatMarkdownEntry(tt.entry)
+			result := formatEntry(MarkdownRenderer, tt.entry)
 			for _, substr := range tt.contains {
 				if !strings.Contains(result, substr) {
-					t.Errorf("formatMarkdownEntry() = %q, should contain %q", result, substr)
+					t.Errorf("formatEntry() = %q, should contain %q", result, substr)
 				}
 			}
 		})
@@ -430,7 +455,7 @@ func TestFormatMarkdownEntryCollapsible_ShortText(t *testing.T) {
 		Time: time.Date(2025, 1, 15, 9, 30, 0, 0, time.Local),
 	}
 
-	result := formatMarkdownEntryCollapsible(entry)
+	result := formatEntryCollapsible(MarkdownRenderer, entry)
 
 	// Short prompts should be simple bullets (no details tag)
 	if strings.Contains(result, "<details") {
@@ -458,7 +483,7 @@ func TestFormatMarkdownEntryCollapsible_LongText(t *testing.T) {
 		Time: time.Date(2025, 1, 15, 9, 30, 0, 0, time.Local),
 	}
 
-	result := formatMarkdownEntryCollapsible(entry)
+	result := formatEntryCollapsible(MarkdownRenderer, entry)
 
 	// Long prompts should use <details> (not open)
 	if !strings.Contains(result, "<details><summary>") {
@@ -489,7 +514,7 @@ func TestFormatMarkdownEntryCollapsible_EscapesHTML(t *testing.T) {
 		Time: time.Date(2025, 1, 15, 9, 30, 0, 0, time.Local),
 	}
 
-	result := formatMarkdownEntryCollapsible(entry)
+	result := formatEntryCollapsible(MarkdownRenderer, entry)
 
 	// The literal <details> in the prompt should be escaped to &lt;details&gt;
 	// Otherwise it would break the outer <details> structure
@@ -515,7 +540,7 @@ func TestFormatMarkdownEntry_EscapesHTML(t *testing.T) {
 		Time: time.Date(2025, 1, 15, 9, 30, 0, 0, time.Local),
 	}
 
-	result := formatMarkdownEntry(entry)
+	result := formatEntry(MarkdownRenderer, entry)
 
 	// Should not contain unescaped script tag
 	if strings.Contains(result, "<script>") {
@@ -655,7 +680,7 @@ func TestRenderUserTimelineWithTruncation(t *testing.T) {
 			{Entry: PromptEntry{Type: "PROMPT", Text: "Second prompt", Time: now.Add(time.Minute)}, CommitSHA: "abc1234", CommitSubj: "Test", CommitIndex: 0},
 		}
 
-		result, truncated := renderUserTimelineWithTruncation(entries, 10000)
+		result, truncated := renderUserTimelineWithTruncation(MarkdownRenderer, entries, 10000)
 
 		if truncated != 0 {
 			t.Errorf("Expected 0 truncated, got %d", truncated)
@@ -676,7 +701,7 @@ func TestRenderUserTimelineWithTruncation(t *testing.T) {
 		}
 
 		// Very small limit to force truncation (reduced since format is now more compact)
-		result, truncated := renderUserTimelineWithTruncation(entries, 50)
+		result, truncated := renderUserTimelineWithTruncation(MarkdownRenderer, entries, 50)
 
 		if truncated == 0 {
 			t.Error("Expected some entries to be truncated")
@@ -710,7 +735,7 @@ func TestRenderAllSteps(t *testing.T) {
 			},
 		}
 
-		result, truncSess, truncSteps := renderAllSteps(commits, 10000, "")
+		result, truncSess, truncSteps := renderAllSteps(MarkdownRenderer, commits, 10000, "")
 
 		if truncSess != 0 || truncSteps != 0 {
 			t.Errorf("Expected no truncation, got sessions=%d steps=%d", truncSess, truncSteps)
@@ -727,6 +752,55 @@ func TestRenderAllSteps(t *testing.T) {
 		}
 	})
 
+	t.Run("notes removed sessions", func(t *testing.T) {
+		commits := []CommitSummary{
+			{
+				ShortSHA: "abc1234",
+				Subject:  "Test commit",
+				Sessions: []SessionSummary{
+					{
+						Tool:    "claude-code",
+						ID:      "session-1",
+						Prompts: []PromptEntry{},
+						Removed: &note.Tombstone{By: "Jane Doe <jane@example.com>", DeletedAt: now},
+					},
+				},
+			},
+		}
+
+		result, truncSess, _ := renderAllSteps(MarkdownRenderer, commits, 10000, "")
+
+		if truncSess != 0 {
+			t.Errorf("Removed session counted as truncated: %d", truncSess)
+		}
+		if !strings.Contains(result, "#### abc1234") || !strings.Contains(result, "*Session removed by Jane Doe on ") {
+			t.Errorf("Expected commit header and removal note:\n%s", result)
+		}
+	})
+
+	t.Run("notes quarantined sessions", func(t *testing.T) {
+		commits := []CommitSummary{
+			{
+				ShortSHA: "abc1234",
+				Subject:  "Test commit",
+				Sessions: []SessionSummary{
+					{
+						Tool:        "claude-code",
+						ID:          "session-1",
+						Prompts:     []PromptEntry{},
+						Quarantined: &note.QuarantineStub{By: "Jane Doe <jane@example.com>", At: now, Reason: "customer data"},
+					},
+				},
+			},
+		}
+
+		result, _, _ := renderAllSteps(MarkdownRenderer, commits, 10000, "")
+
+		if !strings.Contains(result, "*Session quarantined by Jane Doe on ") || !strings.Contains(result, ": customer data*") {
+			t.Errorf("Expected the quarantine stub's reason, who and when:\n%s", result)
+		}
+	})
+
 	t.Run("truncates sessions when over limit", func(t *testing.T) {
 		commits := []CommitSummary{
 			{
@@ -758,7 +832,7 @@ func TestRenderAllSteps(t *testing.T) {
 		}
 
 		// Very small limit to force truncation
-		result, truncSess, truncSteps := renderAllSteps(commits, 300, "https://example.com/transcripts")
+		result, truncSess, truncSteps := renderAllSteps(MarkdownRenderer, commits, 150, "https://example.com/transcripts")
 
 		if truncSess == 0 && truncSteps == 0 {
 			t.Error("Expected some truncation with small limit")
@@ -796,7 +870,7 @@ func TestRenderAllSteps(t *testing.T) {
 			},
 		}
 
-		result, _, _ := renderAllSteps(commits, 10000, "")
+		result, _, _ := renderAllSteps(MarkdownRenderer, commits, 10000, "")
 
 		// Find positions of "Early" and "Late" in output
 		earlyPos := strings.Index(result, "Early")
@@ -962,3 +1036,359 @@ func TestRenderMarkdown_AllUserActionTypes(t *testing.T) {
 		t.Error("Should contain DECISION text")
 	}
 }
+
+func TestFileLocation(t *testing.T) {
+	tests := []struct {
+		name       string
+		tool       string
+		input      string
+		wantPath   string
+		wantLine   int
+		wantAnchor string
+	}{
+		{
+			name:     "read with offset",
+			tool:     "Read",
+			input:    `{"file_path":"/repo/main.go","offset":40,"limit":20}`,
+			wantPath: "/repo/main.go",
+			wantLine: 40,
+		},
+		{
+			name:       "edit anchors on first non-empty new line",
+			tool:       "Edit",
+			input:      `{"file_path":"/repo/main.go","old_string":"a","new_string":"\n\tfoo()\n\tbar()"}`,
+			wantPath:   "/repo/main.go",
+			wantAnchor: "\tfoo()",
+		},
+		{
+			name:     "write starts at top",
+			tool:     "Write",
+			input:    `{"file_path":"/repo/new.go","content":"package main"}`,
+			wantPath: "/repo/new.go",
+			wantLine: 1,
+		},
+		{
+			name:  "bash has no file",
+			tool:  "Bash",
+			input: `{"command":"ls"}`,
+		},
+		{
+			name:  "invalid input",
+			tool:  "Read",
+			input: `not json`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, line, anchor := fileLocation(tt.tool, json.RawMessage(tt.input))
+			if path != tt.wantPath || line != tt.wantLine || anchor != tt.wantAnchor {
+				t.Errorf("fileLocation() = (%q, %d, %q), want (%q, %d, %q)",
+					path, line, anchor, tt.wantPath, tt.wantLine, tt.wantAnchor)
+			}
+		})
+	}
+}
+
+func TestSummarizeEntries_LinksToolOutputs(t *testing.T) {
+	base := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+	var lines []string
+	// Many tool calls so ss.Prompts grows (and reallocates) between a
+	// tool use and its result
+	for i := 0; i < 20; i++ {
+		ts := base.Add(time.Duration(i) * time.Minute).Format(time.RFC3339)
+		id := fmt.Sprintf("t%d", i)
+		lines = append(lines,
+			fmt.Sprintf(`{"type":"assistant","timestamp":%q,"message":{"role":"assistant","content":[{"type":"tool_use","id":%q,"name":"Bash","input":{"command":"echo %d"}}]}}`, ts, id, i),
+			fmt.Sprintf(`{"type":"user","timestamp":%q,"message":{"role":"user","content":[{"type":"tool_result","tool_use_id":%q,"content":"out %d"}]}}`, ts, id, i),
+		)
+	}
+	entries, err := session.ParseMessages([]byte(strings.Join(lines, "\n")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ss := summarizeEntries(note.SessionEntry{Tool: "claude-code", ID: "s1"}, entries, base, base.Add(time.Hour), false)
+	if len(ss.Prompts) != 20 {
+		t.Fatalf("got %d entries, want 20", len(ss.Prompts))
+	}
+	for i, p := range ss.Prompts {
+		if want := fmt.Sprintf("out %d", i); p.ToolOutput != want {
+			t.Errorf("entry %d (%s) ToolOutput = %q, want %q", i, p.ToolID, p.ToolOutput, want)
+		}
+	}
+}
+
+func TestSummarizeEntries_Context(t *testing.T) {
+	base := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+	content := `{"type":"user","timestamp":"2025-01-15T10:00:00Z","gitBranch":"feature/login","cwd":"/work/app","message":{"role":"user","content":"Add login"}}`
+	entries, err := session.ParseMessages([]byte(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plain := summarizeEntries(note.SessionEntry{Tool: "claude-code", ID: "s1", Created: base}, entries, base, base.Add(time.Hour), false)
+
+	sess := note.SessionEntry{Tool: "claude-code", ID: "s1", Created: base, Context: &note.SessionContext{
+		Branch: "feature/login", Cwd: "/work/app", Head: "0123456789abcdef", Dirty: 2,
+	}}
+	ss := summarizeEntries(sess, entries, base, base.Add(time.Hour), false)
+	if len(ss.Prompts) != 2 {
+		t.Fatalf("got %d entries, want context and prompt", len(ss.Prompts))
+	}
+	ctx := ss.Prompts[0]
+	if ctx.Type != "CONTEXT" {
+		t.Fatalf("first entry type = %q, want CONTEXT", ctx.Type)
+	}
+	want := "Branch: feature/login; HEAD: 0123456, 2 uncommitted file(s) at capture; Directory: /work/app"
+	if ctx.Text != want {
+		t.Errorf("context text = %q, want %q", ctx.Text, want)
+	}
+	// The context must not shift the IDs of the transcript's steps
+	if ss.Prompts[1].StepID != plain.Prompts[0].StepID || ctx.StepID == plain.Prompts[0].StepID {
+		t.Errorf("step IDs changed: context %s, prompt %s, want prompt %s", ctx.StepID, ss.Prompts[1].StepID, plain.Prompts[0].StepID)
+	}
+
+	// A session with nothing in the work period stays empty
+	empty := summarizeEntries(sess, entries, base.Add(time.Hour), base.Add(2*time.Hour), false)
+	if len(empty.Prompts) != 0 {
+		t.Errorf("got %d entries outside the work period, want 0", len(empty.Prompts))
+	}
+}
+
+func TestRenderDiagnostics(t *testing.T) {
+	summary := &Summary{
+		CommitsAnalyzed: 3,
+		Skipped: []CommitTrace{
+			{SHA: "aaaaaaa1111", Subject: "Broken note", Reason: "invalid character 'x'"},
+			{
+				SHA:     "bbbbbbb2222",
+				Subject: "Partly recorded",
+				Sessions: []session.SessionTrace{
+					{Path: "claude-code/kept.jsonl", Included: true},
+					{Path: "claude-code/gone.jsonl", FinalReason: "transcript removed by retention gc"},
+				},
+			},
+		},
+	}
+	if got := summary.Skipped[1].skippedSessions(); got != 1 {
+		t.Errorf("skippedSessions() = %d, want 1", got)
+	}
+
+	var buf strings.Builder
+	RenderDiagnostics(summary, &buf)
+	out := buf.String()
+
+	for _, want := range []string{
+		"2 of 3 commit(s)",
+		"aaaaaaa Broken note",
+		"Skipped: invalid character 'x'",
+		"Session claude-code/gone.jsonl: transcript removed by retention gc",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "kept.jsonl") {
+		t.Errorf("included session should not be listed:\n%s", out)
+	}
+}
+
+func TestUnreadableData(t *testing.T) {
+	summary := &Summary{
+		Skipped: []CommitTrace{
+			{SHA: "aaaaaaa", Reason: "no prompt-story note", Trailer: true},
+			{SHA: "bbbbbbb", Reason: "no session has entries in the work period"},
+			{SHA: "ccccccc", Unreadable: []string{"claude-code/gone.jsonl"}},
+		},
+	}
+
+	got := UnreadableData(summary)
+	if len(got) != 2 || got[0].SHA != "aaaaaaa" || got[1].SHA != "ccccccc" {
+		t.Errorf("UnreadableData() = %+v, want the commit with a trailer and the one with an unreadable transcript", got)
+	}
+}
+
+func TestAnalyzeCommitsKeepsOrder(t *testing.T) {
+	commits := make([]string, 20)
+	for i := range commits {
+		commits[i] = fmt.Sprintf("%040x", i+1) // Unknown commits, each fails
+	}
+	for _, jobs := range []int{0, 1, 3, 50} {
+		results := analyzeCommits(commits, false, jobs)
+		if len(results) != len(commits) {
+			t.Fatalf("jobs=%d: got %d results, want %d", jobs, len(results), len(commits))
+		}
+		for i, r := range results {
+			if r.trace.SHA != commits[i] || r.err == nil {
+				t.Errorf("jobs=%d: result %d is for %s (err %v), want %s", jobs, i, r.trace.SHA, r.err, commits[i])
+			}
+		}
+	}
+}
+
+func TestSummarizeEntries_ClockSkew(t *testing.T) {
+	base := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+	content := `{"type":"user","timestamp":"2025-01-15T10:05:00Z","message":{"role":"user","content":"First"}}
+{"type":"user","timestamp":"2025-01-15T09:58:00Z","message":{"role":"user","content":"Clock set back"}}
+{"type":"user","timestamp":"2025-01-15T11:01:00Z","message":{"role":"user","content":"Clock ahead"}}`
+	entries, err := session.ParseMessages([]byte(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := session.MonotonicTimestamps(entries); got != 1 {
+		t.Fatalf("got %d regressions, want 1", got)
+	}
+
+	// The regressed prompt keeps its place; the one past the end needs the tolerance
+	sess := note.SessionEntry{Tool: "claude-code", ID: "s1"}
+	ss := summarizeEntries(sess, entries, base, base.Add(time.Hour), false)
+	if len(ss.Prompts) != 2 || ss.Prompts[1].Text != "Clock set back" {
+		t.Fatalf("got %d entries, want the first two in transcript order", len(ss.Prompts))
+	}
+	// Redaction finds the regressed prompt by the timestamp it has in the transcript
+	if got, want := ss.Prompts[1].TranscriptTime(), base.Add(-2*time.Minute); !got.Equal(want) {
+		t.Errorf("TranscriptTime() = %s, want %s", got, want)
+	}
+	if got, want := ss.Prompts[0].TranscriptTime(), base.Add(5*time.Minute); !got.Equal(want) {
+		t.Errorf("TranscriptTime() of the first prompt = %s, want %s", got, want)
+	}
+	start, end := session.WidenWorkPeriod(base, base.Add(time.Hour), 2*time.Minute)
+	ss = summarizeEntries(sess, entries, start, end, false)
+	if len(ss.Prompts) != 3 {
+		t.Errorf("got %d entries with a 2m tolerance, want 3", len(ss.Prompts))
+	}
+}
+
+// summaryFixture makes a repository in the working directory with
+// main~8..main holding six commits with notes, a plain commit and one with
+// a trailer but no note
+func summaryFixture(t *testing.T) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	for _, kv := range []string{"GIT_AUTHOR_NAME=A", "GIT_AUTHOR_EMAIL=a@example.com", "GIT_COMMITTER_NAME=A", "GIT_COMMITTER_EMAIL=a@example.com"} {
+		k, v, _ := strings.Cut(kv, "=")
+		t.Setenv(k, v)
+	}
+	run := func(args ...string) string {
+		t.Helper()
+		out, err := git.RunGit(args...)
+		if err != nil {
+			t.Fatalf("git %v: %v", args, err)
+		}
+		return out
+	}
+	commit := func(msg string, at time.Time) string {
+		t.Helper()
+		t.Setenv("GIT_AUTHOR_DATE", at.Format(time.RFC3339))
+		t.Setenv("GIT_COMMITTER_DATE", at.Format(time.RFC3339))
+		run("commit", "-q", "--allow-empty", "-m", msg)
+		return run("rev-parse", "HEAD")
+	}
+
+	base := time.Date(2025, 1, 15, 9, 0, 0, 0, time.UTC)
+	run("init", "-q", "-b", "main")
+	commit("base", base)
+	for i := range 8 {
+		start := base.Add(time.Duration(i) * time.Hour)
+		end := start.Add(30 * time.Minute)
+		switch i {
+		case 3:
+			commit("plain change", end) // No note, no trailer: not listed
+			continue
+		case 5:
+			commit("lost note\n\nPrompt-Story: Used Claude Code", end) // Skipped, missing its note
+			continue
+		}
+		sha := commit(fmt.Sprintf("change %d\n\nPrompt-Story: Used Claude Code", i), end)
+
+		psNote := &note.PromptStoryNote{Version: 1, StartWork: start}
+		blobs := make(map[string]string)
+		for j := range 2 {
+			id := fmt.Sprintf("s%d-%d", i, j)
+			at := start.Add(time.Duration(j+1) * 5 * time.Minute)
+			transcript := fmt.Sprintf(`{"type":"user","timestamp":%q,"message":{"role":"user","content":"Prompt %d.%d"}}
+{"type":"assistant","timestamp":%q,"message":{"role":"assistant","content":[{"type":"text","text":"Done %d.%d"}]}}
+`, at.Format(time.RFC3339), i, j, at.Add(time.Minute).Format(time.RFC3339), i, j)
+			blob, err := git.HashObject([]byte(transcript))
+			if err != nil {
+				t.Fatal(err)
+			}
+			path := note.GetTranscriptPath("claude-code", id)
+			blobs[path] = blob
+			psNote.Sessions = append(psNote.Sessions, note.SessionEntry{
+				Tool: "claude-code", ID: id, Path: note.TranscriptsRef + "/" + path,
+				Created: at, Modified: at.Add(time.Minute),
+			})
+		}
+		if err := note.UpdateTranscriptTree(blobs); err != nil {
+			t.Fatal(err)
+		}
+		data, err := psNote.ToJSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := git.AddNote(note.NotesRef, string(data), sha); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestGenerateSummaryJobs(t *testing.T) {
+	t.Chdir(t.TempDir())
+	summaryFixture(t)
+
+	want, err := GenerateSummaryJobs("main~8..main", false, 1)
+	if err != nil {
+		t.Fatalf("GenerateSummaryJobs(jobs=1) error: %v", err)
+	}
+	if want.CommitsAnalyzed != 8 || want.CommitsWithNotes != 6 || want.CommitsMissingNotes != 1 || want.TotalUserPrompts != 12 {
+		t.Fatalf("GenerateSummaryJobs(jobs=1) = %d analyzed, %d with notes, %d missing, %d prompts; want 8, 6, 1, 12",
+			want.CommitsAnalyzed, want.CommitsWithNotes, want.CommitsMissingNotes, want.TotalUserPrompts)
+	}
+	if len(want.Skipped) != 1 || want.Skipped[0].Subject != "lost note" {
+		t.Errorf("GenerateSummaryJobs(jobs=1) skipped %+v, want only the commit that lost its note", want.Skipped)
+	}
+
+	for _, jobs := range []int{0, 3, 50} {
+		got, err := GenerateSummaryJobs("main~8..main", false, jobs)
+		if err != nil {
+			t.Fatalf("GenerateSummaryJobs(jobs=%d) error: %v", jobs, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("GenerateSummaryJobs(jobs=%d) differs from jobs=1", jobs)
+		}
+	}
+}
+
+func TestGenerateSummary_BareRepository(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	summaryFixture(t)
+	want, err := GenerateSummaryJobs("main~8..main", false, 1)
+	if err != nil {
+		t.Fatalf("GenerateSummaryJobs() in the working tree error: %v", err)
+	}
+
+	bare := filepath.Join(t.TempDir(), "fixture.git")
+	for _, args := range [][]string{
+		{"clone", "-q", "--bare", dir, bare},
+		{"-C", bare, "fetch", "-q", dir, "refs/notes/*:refs/notes/*"},
+	} {
+		if _, err := git.RunGit(args...); err != nil {
+			t.Fatalf("git %v: %v", args, err)
+		}
+	}
+	t.Chdir(bare)
+
+	got, err := GenerateSummaryJobs("main~8..main", false, 1)
+	if err != nil {
+		t.Fatalf("GenerateSummaryJobs() in a bare repository error: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("summary in a bare repository differs from the working tree one")
+	}
+}
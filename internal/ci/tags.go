@@ -0,0 +1,66 @@
+package ci
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// TagCount is how many commits of a summary carry a tag
+type TagCount struct {
+	Tag     string
+	Commits int
+}
+
+// TagRollup counts the tagged commits of a summary, most used first
+func TagRollup(summary *Summary) []TagCount {
+	counts := make(map[string]int)
+	for _, c := range summary.Commits {
+		for _, t := range c.Tags {
+			counts[t]++
+		}
+	}
+
+	rollup := make([]TagCount, 0, len(counts))
+	for t, n := range counts {
+		rollup = append(rollup, TagCount{Tag: t, Commits: n})
+	}
+	sort.Slice(rollup, func(i, j int) bool {
+		if rollup[i].Commits != rollup[j].Commits {
+			return rollup[i].Commits > rollup[j].Commits
+		}
+		return rollup[i].Tag < rollup[j].Tag
+	})
+	return rollup
+}
+
+// renderTagRollup formats the rollup as a paragraph, or "" when no commit
+// is tagged
+func renderTagRollup(r Renderer, rollup []TagCount) string {
+	if len(rollup) == 0 {
+		return ""
+	}
+	parts := make([]string, len(rollup))
+	for i, tc := range rollup {
+		parts[i] = fmt.Sprintf("%s (%d)", r.Code(tc.Tag), tc.Commits)
+	}
+	return r.Paragraph(r.Bold("Tags:") + " " + strings.Join(parts, ", "))
+}
+
+// MatchingLabels returns the repository labels named like a tag of the
+// rollup, compared case-insensitively, so only labels the repository
+// already defines are applied
+func MatchingLabels(rollup []TagCount, labels []string) []string {
+	byName := make(map[string]string, len(labels))
+	for _, l := range labels {
+		byName[strings.ToLower(l)] = l
+	}
+
+	var matched []string
+	for _, tc := range rollup {
+		if l, ok := byName[tc.Tag]; ok {
+			matched = append(matched, l)
+		}
+	}
+	return matched
+}
@@ -0,0 +1,50 @@
+package ci
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTagRollup(t *testing.T) {
+	summary := &Summary{Commits: []CommitSummary{
+		{ShortSHA: "aaa", Tags: []string{"refactor"}},
+		{ShortSHA: "bbb", Tags: []string{"bugfix", "refactor"}},
+		{ShortSHA: "ccc"},
+	}}
+
+	rollup := TagRollup(summary)
+	want := []TagCount{{"refactor", 2}, {"bugfix", 1}}
+	if !reflect.DeepEqual(rollup, want) {
+		t.Errorf("TagRollup() = %v, want %v", rollup, want)
+	}
+
+	if got := renderTagRollup(MarkdownRenderer, rollup); got != "**Tags:** `refactor` (2), `bugfix` (1)\n\n" {
+		t.Errorf("renderTagRollup() = %q", got)
+	}
+	if got := renderTagRollup(MarkdownRenderer, nil); got != "" {
+		t.Errorf("renderTagRollup(nil) = %q, want empty", got)
+	}
+}
+
+func TestMatchingLabels(t *testing.T) {
+	rollup := []TagCount{{"refactor", 2}, {"experiment", 1}}
+	got := MatchingLabels(rollup, []string{"bug", "Refactor", "documentation"})
+	if want := []string{"Refactor"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("MatchingLabels() = %v, want %v", got, want)
+	}
+}
+
+func TestRenderMarkdown_TagRollup(t *testing.T) {
+	summary := &Summary{
+		CommitsWithNotes: 1,
+		Commits: []CommitSummary{
+			{SHA: "abc1234567890", ShortSHA: "abc1234", Subject: "Refactor parser", Tags: []string{"refactor"}},
+		},
+	}
+
+	md := RenderMarkdown(summary, "", "v1")
+	if !strings.Contains(md, "**Tags:** `refactor` (1)") {
+		t.Errorf("Expected tag rollup in markdown:\n%s", md)
+	}
+}
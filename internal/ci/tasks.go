@@ -0,0 +1,97 @@
+package ci
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Task is a run of consecutive commits made during the same session(s),
+// rendered under one heading so that frequent commits in one conversation
+// read as a single piece of work
+type Task struct {
+	Title string // First user prompt of the run, or the first commit's subject
+	First int    // Index of the first commit, in chronological order
+	Last  int    // Index of the last commit
+}
+
+// Commits returns the number of commits in the task
+func (t *Task) Commits() int {
+	return t.Last - t.First + 1
+}
+
+// groupTasks finds runs of two or more consecutive commits (oldest first)
+// where each commit shares a main session with the one before it. Commits
+// outside a run belong to no task.
+func groupTasks(commits []CommitSummary) []*Task {
+	var tasks []*Task
+	for i := 1; i < len(commits); i++ {
+		if !shareSession(commits[i-1], commits[i]) {
+			continue
+		}
+		if n := len(tasks); n > 0 && tasks[n-1].Last == i-1 {
+			tasks[n-1].Last = i
+			continue
+		}
+		tasks = append(tasks, &Task{First: i - 1, Last: i})
+	}
+	for _, t := range tasks {
+		t.Title = taskTitle(commits[t.First : t.Last+1])
+	}
+	return tasks
+}
+
+// taskByCommit maps commit indices to the task they belong to
+func taskByCommit(tasks []*Task) map[int]*Task {
+	byCommit := make(map[int]*Task)
+	for _, t := range tasks {
+		for i := t.First; i <= t.Last; i++ {
+			byCommit[i] = t
+		}
+	}
+	return byCommit
+}
+
+// shareSession reports whether two commits carry a main (non-agent)
+// session with the same ID
+func shareSession(a, b CommitSummary) bool {
+	ids := make(map[string]bool)
+	for _, s := range a.Sessions {
+		if !s.IsAgent && s.ID != "" {
+			ids[s.Tool+"/"+s.ID] = true
+		}
+	}
+	for _, s := range b.Sessions {
+		if !s.IsAgent && ids[s.Tool+"/"+s.ID] {
+			return true
+		}
+	}
+	return false
+}
+
+// taskTitle is the earliest user prompt in the commits' main sessions,
+// falling back to the first commit's subject
+func taskTitle(commits []CommitSummary) string {
+	var prompts []PromptEntry
+	for _, c := range commits {
+		for _, s := range c.Sessions {
+			if s.IsAgent {
+				continue
+			}
+			for _, p := range s.Prompts {
+				if p.Type == "PROMPT" && p.Text != "" {
+					prompts = append(prompts, p)
+				}
+			}
+		}
+	}
+	if len(prompts) == 0 {
+		return commits[0].Subject
+	}
+	sort.SliceStable(prompts, func(i, j int) bool { return prompts[i].Time.Before(prompts[j].Time) })
+	return prompts[0].Text
+}
+
+// taskHeader is the heading of a task, above the headers of its commits
+func taskHeader(r Renderer, t *Task) string {
+	return "\n" + r.Heading(3, fmt.Sprintf("Task: %s (%d commits)", r.Escape(truncateSubject(t.Title)), t.Commits()))
+}
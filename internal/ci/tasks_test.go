@@ -0,0 +1,71 @@
+package ci
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func taskCommit(sha string, at time.Time, prompt string, sessionIDs ...string) CommitSummary {
+	c := CommitSummary{ShortSHA: sha, Subject: "Commit " + sha}
+	for _, id := range sessionIDs {
+		c.Sessions = append(c.Sessions, SessionSummary{
+			Tool:    "claude-code",
+			ID:      id,
+			Start:   at,
+			End:     at,
+			Prompts: []PromptEntry{{Type: "PROMPT", Text: prompt, Time: at}},
+		})
+	}
+	return c
+}
+
+func TestGroupTasks(t *testing.T) {
+	now := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+	commits := []CommitSummary{
+		taskCommit("a", now, "Add login", "s1"),
+		taskCommit("b", now.Add(time.Hour), "Fix tests", "s1"),
+		taskCommit("c", now.Add(2*time.Hour), "Polish", "s1", "s2"),
+		taskCommit("d", now.Add(3*time.Hour), "Unrelated", "s3"),
+		taskCommit("e", now.Add(4*time.Hour), "Docs", "s4"),
+		taskCommit("f", now.Add(5*time.Hour), "More docs", "s4"),
+	}
+	commits[3].Sessions = append(commits[3].Sessions, SessionSummary{Tool: "claude-code", ID: "s2", IsAgent: true})
+
+	tasks := groupTasks(commits)
+	if len(tasks) != 2 {
+		t.Fatalf("got %d tasks, want 2: %+v", len(tasks), tasks)
+	}
+	if tasks[0].First != 0 || tasks[0].Last != 2 || tasks[0].Title != "Add login" {
+		t.Errorf("task 0 = %+v, want commits 0-2 titled by the first prompt", tasks[0])
+	}
+	if tasks[1].First != 4 || tasks[1].Last != 5 || tasks[1].Commits() != 2 {
+		t.Errorf("task 1 = %+v, want commits 4-5", tasks[1])
+	}
+}
+
+func TestRenderMarkdown_Tasks(t *testing.T) {
+	now := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+	// Newest first, as GenerateSummary returns them
+	summary := &Summary{
+		CommitsWithNotes: 3,
+		Commits: []CommitSummary{
+			taskCommit("ccccccc", now.Add(2*time.Hour), "Write docs", "s2"),
+			taskCommit("bbbbbbb", now.Add(time.Hour), "Now fix the tests", "s1"),
+			taskCommit("aaaaaaa", now, "Add a login form", "s1"),
+		},
+	}
+
+	result := RenderMarkdown(summary, "", "test")
+
+	header := "### Task: Add a login form (2 commits)"
+	if n := strings.Count(result, header); n != 2 {
+		t.Errorf("task header appears %d times, want once per section:\n%s", n, result)
+	}
+	if strings.Contains(result, "Task: Write docs") {
+		t.Error("a single commit should not get a task header")
+	}
+	if strings.Index(result, header) > strings.Index(result, "#### aaaaaaa") {
+		t.Error("task header should come before its first commit")
+	}
+}
@@ -0,0 +1,58 @@
+package ci
+
+import "strings"
+
+// Markers delimit the prompt-story section of a PR template and of the PR
+// bodies created from it
+const (
+	templateStart       = "<!-- git-prompt-story:start -->"
+	templateEnd         = "<!-- git-prompt-story:end -->"
+	templatePlaceholder = "_Filled in by git-prompt-story when the PR is checked._"
+)
+
+// TemplateSection returns the section installed into PR templates
+func TemplateSection() string {
+	return "## Prompt story\n\n" + templateBlock(templatePlaceholder)
+}
+
+// InstallTemplate adds the prompt-story section to a PR template, or
+// resets the section of a template that already has one
+func InstallTemplate(template string) string {
+	if updated, ok := replaceSection(template, templatePlaceholder); ok {
+		return updated
+	}
+	if template == "" {
+		return TemplateSection()
+	}
+	if !strings.HasSuffix(template, "\n") {
+		template += "\n"
+	}
+	return template + "\n" + TemplateSection()
+}
+
+// FillBody replaces the prompt-story section of a PR body with markdown.
+// It returns false when the body has no section, e.g. the PR was opened
+// before the template was installed or the author removed it.
+func FillBody(body, markdown string) (string, bool) {
+	return replaceSection(body, strings.TrimRight(markdown, "\n"))
+}
+
+// replaceSection replaces what is between the markers, keeping the markers
+func replaceSection(text, content string) (string, bool) {
+	start := strings.Index(text, templateStart)
+	if start < 0 {
+		return text, false
+	}
+	end := strings.Index(text[start:], templateEnd)
+	if end < 0 {
+		return text, false
+	}
+	end += start + len(templateEnd)
+	rest := strings.TrimPrefix(strings.TrimPrefix(text[end:], "\r"), "\n")
+	return text[:start] + templateBlock(content) + rest, true
+}
+
+// templateBlock wraps content in the markers
+func templateBlock(content string) string {
+	return templateStart + "\n" + content + "\n" + templateEnd + "\n"
+}
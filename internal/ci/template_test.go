@@ -0,0 +1,48 @@
+package ci
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestInstallTemplate(t *testing.T) {
+	if got := InstallTemplate(""); got != TemplateSection() {
+		t.Errorf("InstallTemplate(empty) = %q, want the section", got)
+	}
+
+	existing := "## Description\n\nWhat and why"
+	installed := InstallTemplate(existing)
+	want := existing + "\n\n" + TemplateSection()
+	if installed != want {
+		t.Errorf("InstallTemplate() = %q, want %q", installed, want)
+	}
+
+	// Installing again resets the section instead of adding another
+	filled, _ := FillBody(installed, "# 3 user prompts\n")
+	if again := InstallTemplate(filled); again != installed {
+		t.Errorf("InstallTemplate() on a filled template = %q, want %q", again, installed)
+	}
+}
+
+func TestFillBody(t *testing.T) {
+	body := "Fixes #12\r\n\r\n## Prompt story\r\n\r\n" + templateStart + "\r\n" + templatePlaceholder + "\r\n" + templateEnd + "\r\n\r\n## Checklist\r\n"
+
+	filled, ok := FillBody(body, "# 2 user prompts\n\n- Add login\n")
+	if !ok {
+		t.Fatal("FillBody() found no section")
+	}
+	want := "Fixes #12\r\n\r\n## Prompt story\r\n\r\n" + templateStart + "\n# 2 user prompts\n\n- Add login\n" + templateEnd + "\n\r\n## Checklist\r\n"
+	if filled != want {
+		t.Errorf("FillBody() = %q, want %q", filled, want)
+	}
+
+	// Filling again replaces the previous summary
+	refilled, _ := FillBody(filled, "# 3 user prompts\n")
+	if strings.Contains(refilled, "2 user prompts") || !strings.Contains(refilled, "## Checklist") {
+		t.Errorf("FillBody() refill = %q", refilled)
+	}
+
+	if got, ok := FillBody("No template here", "x"); ok || got != "No template here" {
+		t.Errorf("FillBody() without markers = %q, %v", got, ok)
+	}
+}
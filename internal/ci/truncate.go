@@ -0,0 +1,128 @@
+package ci
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/display"
+)
+
+// Step priorities for truncation: when the "All steps" budget is exceeded,
+// the highest priority number is dropped first, so a truncated summary
+// still tells the story of what was asked and how it ended.
+const (
+	priorityUserAction  = iota // prompts, commands, rejections, decisions
+	priorityKeyResponse        // first and last assistant message of an exchange
+	priorityOther              // assistant messages in between, unknown types
+	priorityToolChatter        // tool calls and results
+)
+
+// stepPriorities ranks a session's steps. An exchange is a user action
+// and everything up to the next one.
+func stepPriorities(prompts []PromptEntry) []int {
+	prio := make([]int, len(prompts))
+	firstAssistant, lastAssistant := -1, -1
+	closeExchange := func() {
+		if firstAssistant >= 0 {
+			prio[firstAssistant] = priorityKeyResponse
+			prio[lastAssistant] = priorityKeyResponse
+		}
+		firstAssistant, lastAssistant = -1, -1
+	}
+
+	for i, p := range prompts {
+		switch {
+		case IsUserAction(p.Type):
+			closeExchange()
+			prio[i] = priorityUserAction
+		case display.TypeCategory(p.Type) == display.CategoryTool:
+			prio[i] = priorityToolChatter
+		case display.TypeCategory(p.Type) == display.CategoryAssistant:
+			prio[i] = priorityOther
+			if firstAssistant < 0 {
+				firstAssistant = i
+			}
+			lastAssistant = i
+		default:
+			prio[i] = priorityOther
+		}
+	}
+	closeExchange()
+	return prio
+}
+
+// stepSelection records which steps of each commit's sessions fit the budget
+type stepSelection struct {
+	kept [][][]bool // commit -> session -> step
+}
+
+// shown counts the kept steps of a session
+func (s stepSelection) shown(c, sess int) int {
+	n := 0
+	for _, k := range s.kept[c][sess] {
+		if k {
+			n++
+		}
+	}
+	return n
+}
+
+// selectSteps picks the steps that fit maxSize, one priority level at a
+// time and in order within a level. Task, commit and session headers are
+// paid for by the first step kept under them.
+func selectSteps(r Renderer, commits []CommitSummary, maxSize int, pagesURL string) stepSelection {
+	type candidate struct {
+		commit, session, step int
+		prio, size            int
+	}
+
+	sel := stepSelection{kept: make([][][]bool, len(commits))}
+	var candidates []candidate
+	for c, commit := range commits {
+		sel.kept[c] = make([][]bool, len(commit.Sessions))
+		for si, sess := range commit.Sessions {
+			sel.kept[c][si] = make([]bool, len(sess.Prompts))
+			for i, prio := range stepPriorities(sess.Prompts) {
+				line := formatStep(r, sess.Prompts[i], stepURL(pagesURL, commit.ShortSHA, sess.Prompts[i].StepID))
+				candidates = append(candidates, candidate{c, si, i, prio, len(line)})
+			}
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].prio < candidates[j].prio })
+
+	used := 0
+	tasks := taskByCommit(groupTasks(commits))
+	taskOpen := make(map[*Task]bool)
+	commitOpen := make([]bool, len(commits))
+	sessionOpen := make(map[[2]int]bool)
+	for _, cand := range candidates {
+		cost := cand.size
+		task := tasks[cand.commit]
+		if !commitOpen[cand.commit] {
+			cost += len(commitHeader(r, commits[cand.commit]))
+			if task != nil && !taskOpen[task] {
+				cost += len(taskHeader(r, task))
+			}
+		}
+		key := [2]int{cand.commit, cand.session}
+		if !sessionOpen[key] {
+			// Reserve room for the ", N shown" a truncated header needs,
+			// plus the list around the steps and the blank line closing
+			// the session
+			sess := commits[cand.commit].Sessions[cand.session]
+			cost += len(sessionHeader(r, sess, 0)) - 1 + len(strconv.Itoa(len(sess.Prompts))) +
+				len(r.ListStart()) + len(r.ListEnd()) + 1
+		}
+		if used+cost > maxSize {
+			continue
+		}
+		used += cost
+		if task != nil {
+			taskOpen[task] = true
+		}
+		commitOpen[cand.commit] = true
+		sessionOpen[key] = true
+		sel.kept[cand.commit][cand.session][cand.step] = true
+	}
+	return sel
+}
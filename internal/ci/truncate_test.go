@@ -0,0 +1,89 @@
+package ci
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStepPriorities(t *testing.T) {
+	prompts := []PromptEntry{
+		{Type: "ASSISTANT"}, // before any prompt: its own exchange
+		{Type: "PROMPT"},
+		{Type: "ASSISTANT"},
+		{Type: "TOOL_USE", ToolName: "Read"},
+		{Type: "ASSISTANT"},
+		{Type: "ASSISTANT"},
+		{Type: "TOOL_REJECT"},
+		{Type: "TOOL_USE", ToolName: "Edit"},
+		{Type: "SOMETHING_NEW"},
+	}
+	want := []int{
+		priorityKeyResponse,
+		priorityUserAction,
+		priorityKeyResponse,
+		priorityToolChatter,
+		priorityOther,
+		priorityKeyResponse,
+		priorityUserAction,
+		priorityToolChatter,
+		priorityOther,
+	}
+	if got := stepPriorities(prompts); !reflect.DeepEqual(got, want) {
+		t.Errorf("stepPriorities() = %v, want %v", got, want)
+	}
+}
+
+func TestRenderAllSteps_DropsToolChatterFirst(t *testing.T) {
+	now := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+	var prompts []PromptEntry
+	add := func(typ, text, tool string) {
+		prompts = append(prompts, PromptEntry{Type: typ, Text: text, ToolName: tool, ToolInput: text, Time: now.Add(time.Duration(len(prompts)) * time.Second)})
+	}
+	add("PROMPT", "Fix the flaky test", "")
+	add("ASSISTANT", "Looking into it", "")
+	for i := 0; i < 20; i++ {
+		add("TOOL_USE", "chatter", "Bash")
+	}
+	add("ASSISTANT", "Thinking out loud", "")
+	add("ASSISTANT", "Fixed: the timeout was too short", "")
+
+	commits := []CommitSummary{{ShortSHA: "abc1234", Subject: "Fix test", Sessions: []SessionSummary{
+		{Tool: "claude-code", ID: "s1", Start: now, End: now.Add(time.Minute), Prompts: prompts},
+	}}}
+
+	full, _, _ := renderAllSteps(MarkdownRenderer, commits, 100000, "")
+	result, truncSess, truncSteps := renderAllSteps(MarkdownRenderer, commits, len(full)/2, "")
+
+	if truncSess != 0 || truncSteps == 0 {
+		t.Errorf("truncated sessions=%d steps=%d, want steps only", truncSess, truncSteps)
+	}
+	for _, kept := range []string{"Fix the flaky test", "Looking into it", "Fixed: the timeout", "Thinking out loud", "shown"} {
+		if !strings.Contains(result, kept) {
+			t.Errorf("expected %q to be kept:\n%s", kept, result)
+		}
+	}
+	if strings.Count(result, "chatter") >= 20 {
+		t.Errorf("expected tool calls to be dropped:\n%s", result)
+	}
+	if !strings.Contains(result, "tool calls first") {
+		t.Errorf("expected truncation notice:\n%s", result)
+	}
+}
+
+func TestRenderUserTimelineWithTruncation_CompactsBeforeDropping(t *testing.T) {
+	now := time.Now()
+	entries := []TimelineEntry{
+		{Entry: PromptEntry{Type: "PROMPT", Text: strings.Repeat("long ", 400), Time: now}, CommitSHA: "abc1234", CommitSubj: "Test"},
+		{Entry: PromptEntry{Type: "PROMPT", Text: "Short one", Time: now.Add(time.Minute)}, CommitSHA: "abc1234", CommitSubj: "Test"},
+	}
+
+	result, truncated := renderUserTimelineWithTruncation(MarkdownRenderer, entries, 500)
+	if truncated != 0 {
+		t.Errorf("truncated = %d, want 0:\n%s", truncated, result)
+	}
+	if strings.Contains(result, "<details>") || !strings.Contains(result, "Short one") {
+		t.Errorf("expected long prompt compacted and both kept:\n%s", result)
+	}
+}
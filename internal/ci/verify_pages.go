@@ -0,0 +1,259 @@
+package ci
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Size limits of a GitHub Pages site
+const (
+	maxPagesFileSize = 100 << 20
+	maxPagesSiteSize = 1 << 30
+)
+
+// errPageNotFound is returned by a PagesSite for a missing page
+var errPageNotFound = errors.New("page not found")
+
+// PagesSite reads the pages of a site generated by GenerateHTML
+type PagesSite interface {
+	Read(name string) ([]byte, error)
+}
+
+// OpenPagesSite returns the site at location, an http(s) URL of the
+// published site or a directory holding it
+func OpenPagesSite(location string) PagesSite {
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		if !strings.HasSuffix(location, "/") {
+			location += "/"
+		}
+		return httpSite{base: location, client: &http.Client{Timeout: 30 * time.Second}}
+	}
+	return dirSite(location)
+}
+
+type dirSite string
+
+func (d dirSite) Read(name string) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(string(d), filepath.FromSlash(name)))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, errPageNotFound
+	}
+	return data, err
+}
+
+type httpSite struct {
+	base   string
+	client *http.Client
+}
+
+func (s httpSite) Read(name string) ([]byte, error) {
+	resp, err := s.client.Get(s.base + name)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errPageNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s%s: %s", s.base, name, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// PagesProblem is a difference between a pages site and the notes it was
+// generated from
+type PagesProblem struct {
+	Page    string // Page the problem was found on, or "" for the site
+	Message string
+}
+
+func (p PagesProblem) String() string {
+	if p.Page == "" {
+		return p.Message
+	}
+	return p.Page + ": " + p.Message
+}
+
+var (
+	hrefAttr      = regexp.MustCompile(`href="([^"]*)"`)
+	dataPagesAttr = regexp.MustCompile(`data-pages="([^"]*)"`)
+	idAttr        = regexp.MustCompile(`id="([^"]*)"`)
+	stepItem      = regexp.MustCompile(`<li id="step-[^"]*" class="prompt-item`)
+)
+
+// pagesChecker accumulates what VerifyPages has read of a site
+type pagesChecker struct {
+	site     PagesSite
+	pages    map[string][]byte // Pages read, nil when missing
+	ids      map[string]map[string]bool
+	size     int
+	problems []PagesProblem
+}
+
+// VerifyPages cross-checks a pages site against the summary generated from
+// the notes: every commit has a page listed on the index, every session
+// and step of it is on that page or its fragment pages, relative links
+// resolve, and the pages stay within the GitHub Pages size limits. It
+// returns the problems found; an error means the site could not be read.
+func VerifyPages(summary *Summary, site PagesSite) ([]PagesProblem, error) {
+	c := &pagesChecker{site: site, pages: make(map[string][]byte), ids: make(map[string]map[string]bool)}
+
+	index, err := c.read("index.html")
+	if err != nil {
+		return nil, err
+	}
+	if index == nil {
+		return []PagesProblem{{Message: "index.html is missing"}}, nil
+	}
+	if err := c.checkLinks("index.html", index); err != nil {
+		return nil, err
+	}
+
+	for _, cs := range summary.Commits {
+		name := cs.ShortSHA + ".html"
+		if !strings.Contains(string(index), `href="`+name+`"`) {
+			c.report("index.html", "no link to commit %s", cs.ShortSHA)
+		}
+		page, err := c.read(name)
+		if err != nil {
+			return nil, err
+		}
+		if page == nil {
+			c.report("", "no page for commit %s (%s)", cs.ShortSHA, name)
+			continue
+		}
+		if err := c.checkCommit(cs, name, page); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.size > maxPagesSiteSize {
+		c.report("", "site is %d MB, over the %d MB GitHub Pages limit", c.size>>20, maxPagesSiteSize>>20)
+	}
+	return c.problems, nil
+}
+
+// checkCommit compares a commit page and its fragment pages with the
+// commit's sessions
+func (c *pagesChecker) checkCommit(cs CommitSummary, name string, page []byte) error {
+	if err := c.checkLinks(name, page); err != nil {
+		return err
+	}
+
+	// Steps beyond a session's first page are in the fragments it loads
+	ids := make(map[string]bool)
+	for id := range c.idsOf(name, page) {
+		ids[id] = true
+	}
+	steps := len(stepItem.FindAll(page, -1))
+	for _, m := range dataPagesAttr.FindAllSubmatch(page, -1) {
+		for _, fragment := range strings.Fields(html.UnescapeString(string(m[1]))) {
+			data, err := c.read(fragment)
+			if err != nil {
+				return err
+			}
+			if data == nil {
+				c.report(name, "missing step page %s", fragment)
+				continue
+			}
+			for id := range c.idsOf(fragment, data) {
+				ids[id] = true
+			}
+			steps += len(stepItem.FindAll(data, -1))
+		}
+	}
+
+	want := 0
+	for _, sess := range cs.Sessions {
+		if !strings.Contains(string(page), `<code class="session-id">`+html.EscapeString(sess.ID)+`</code>`) {
+			c.report(name, "session %s is missing", sess.ID)
+			continue
+		}
+		for _, p := range sess.Prompts {
+			want++
+			if p.StepID != "" && !ids[p.StepID] {
+				c.report(name, "step %s of session %s is missing", p.StepID, sess.ID)
+			}
+		}
+	}
+	if steps > want {
+		c.report(name, "%d steps, but the notes have %d", steps, want)
+	}
+	return nil
+}
+
+// checkLinks reports relative links of a page to missing pages or anchors
+func (c *pagesChecker) checkLinks(name string, page []byte) error {
+	for _, m := range hrefAttr.FindAllSubmatch(page, -1) {
+		link := html.UnescapeString(string(m[1]))
+		if link == "" || strings.Contains(link, ":") {
+			continue // External, or an editor link
+		}
+		target, anchor, _ := strings.Cut(link, "#")
+		if target == "" {
+			target = name
+		}
+		data := page
+		if target != name {
+			var err error
+			if data, err = c.read(target); err != nil {
+				return err
+			}
+			if data == nil {
+				c.report(name, "broken link to %s", link)
+				continue
+			}
+		}
+		if anchor != "" && !c.idsOf(target, data)[anchor] {
+			c.report(name, "broken link to %s", link)
+		}
+	}
+	return nil
+}
+
+// read returns a page, or nil when it is missing. Each page is read once.
+func (c *pagesChecker) read(name string) ([]byte, error) {
+	if data, ok := c.pages[name]; ok {
+		return data, nil
+	}
+	data, err := c.site.Read(name)
+	if errors.Is(err, errPageNotFound) {
+		c.pages[name] = nil
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	c.pages[name] = data
+	c.size += len(data)
+	if len(data) > maxPagesFileSize {
+		c.report(name, "%d MB, over the %d MB GitHub Pages file limit", len(data)>>20, maxPagesFileSize>>20)
+	}
+	return data, nil
+}
+
+func (c *pagesChecker) report(page, format string, args ...any) {
+	c.problems = append(c.problems, PagesProblem{Page: page, Message: fmt.Sprintf(format, args...)})
+}
+
+// idsOf returns the element IDs of a page
+func (c *pagesChecker) idsOf(name string, page []byte) map[string]bool {
+	if ids, ok := c.ids[name]; ok {
+		return ids
+	}
+	ids := make(map[string]bool)
+	for _, m := range idAttr.FindAllSubmatch(page, -1) {
+		ids[html.UnescapeString(string(m[1]))] = true
+	}
+	c.ids[name] = ids
+	return ids
+}
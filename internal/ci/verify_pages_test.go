@@ -0,0 +1,110 @@
+package ci
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func pagesTestSummary() *Summary {
+	now := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+	summary := &Summary{CommitsWithNotes: 2}
+	for c, short := range []string{"aaa1111", "bbb2222"} {
+		sess := SessionSummary{Tool: "claude-code", ID: "sess-" + short, Start: now, End: now}
+		for i := 0; i < 5; i++ {
+			sess.Prompts = append(sess.Prompts, PromptEntry{
+				Type: "PROMPT", Text: fmt.Sprintf("prompt %d", i), Time: now, InWorkPeriod: true,
+				StepID: fmt.Sprintf("step-%d-%d", c, i),
+			})
+		}
+		summary.Commits = append(summary.Commits, CommitSummary{
+			SHA: short + "000", ShortSHA: short, Subject: "Commit " + short, Sessions: []SessionSummary{sess},
+		})
+	}
+	return summary
+}
+
+func TestVerifyPages(t *testing.T) {
+	dir := t.TempDir()
+	summary := pagesTestSummary()
+	if err := GenerateHTML(summary, dir, 1, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	problems, err := VerifyPages(summary, OpenPagesSite(dir))
+	if err != nil || len(problems) != 0 {
+		t.Fatalf("VerifyPages() = %v, %v; want no problems for a fresh site", problems, err)
+	}
+
+	// The notes gained a session and a step since the site was generated
+	drifted := pagesTestSummary()
+	drifted.Commits[0].Sessions[0].Prompts = append(drifted.Commits[0].Sessions[0].Prompts,
+		PromptEntry{Type: "PROMPT", Text: "late", StepID: "step-new"})
+	drifted.Commits[1].Sessions = append(drifted.Commits[1].Sessions, SessionSummary{ID: "sess-new"})
+
+	// and part of the site went missing
+	if err := os.Remove(filepath.Join(dir, "aaa1111-s1-p3.html")); err != nil {
+		t.Fatal(err)
+	}
+
+	problems, err = VerifyPages(drifted, OpenPagesSite(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, p := range problems {
+		got = append(got, p.String())
+	}
+	for _, want := range []string{
+		"aaa1111.html: missing step page aaa1111-s1-p3.html",
+		"aaa1111.html: step step-0-4 of session sess-aaa1111 is missing",
+		"aaa1111.html: step step-new of session sess-aaa1111 is missing",
+		"bbb2222.html: session sess-new is missing",
+	} {
+		if !strings.Contains(strings.Join(got, "\n"), want) {
+			t.Errorf("missing problem %q in:\n%s", want, strings.Join(got, "\n"))
+		}
+	}
+
+	if err := os.Remove(filepath.Join(dir, "bbb2222.html")); err != nil {
+		t.Fatal(err)
+	}
+	problems, _ = VerifyPages(summary, OpenPagesSite(dir))
+	found := false
+	for _, p := range problems {
+		found = found || p.String() == "no page for commit bbb2222 (bbb2222.html)"
+	}
+	if !found {
+		t.Errorf("expected the missing commit page to be reported, got %v", problems)
+	}
+}
+
+func TestVerifyPages_ExtraSteps(t *testing.T) {
+	dir := t.TempDir()
+	summary := pagesTestSummary()
+	if err := GenerateHTML(summary, dir, 1, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	// Steps removed from the notes, e.g. by gc, are still published
+	trimmed := pagesTestSummary()
+	trimmed.Commits[1].Sessions[0].Prompts = trimmed.Commits[1].Sessions[0].Prompts[:3]
+
+	problems, err := VerifyPages(trimmed, OpenPagesSite(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) != 1 || problems[0].String() != "bbb2222.html: 5 steps, but the notes have 3" {
+		t.Errorf("VerifyPages() = %v, want the extra steps reported", problems)
+	}
+}
+
+func TestVerifyPages_NoIndex(t *testing.T) {
+	problems, err := VerifyPages(pagesTestSummary(), OpenPagesSite(t.TempDir()))
+	if err != nil || len(problems) != 1 || problems[0].Message != "index.html is missing" {
+		t.Errorf("VerifyPages() = %v, %v; want a missing index", problems, err)
+	}
+}
@@ -0,0 +1,224 @@
+package ci
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/display"
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+	"github.com/QuesmaOrg/git-prompt-story/internal/note"
+)
+
+// How closely the step of a LineOrigin is tied to the line
+const (
+	MatchLine   = "line"   // An Edit or Write wrote the line's text
+	MatchFile   = "file"   // The last edit of the file in the commit
+	MatchCommit = "commit" // No captured edit of the file, the commit's last prompt
+)
+
+// LineOrigin is the commit a line of a file comes from and the captured
+// step closest to it
+type LineOrigin struct {
+	Path string // Relative to the repository root
+	Line int
+	Text string
+
+	SHA      string
+	ShortSHA string
+	Subject  string
+	OrigPath string // The file's path in the commit, it may have been renamed since
+	OrigLine int    // The line's number in the commit's version of the file
+	HasNote  bool
+
+	// Match is one of MatchLine, MatchFile or MatchCommit, empty if no
+	// step was found
+	Match     string
+	Tool      string
+	SessionID string
+	Prompt    *PromptEntry // The prompt the edit followed, or the matched prompt
+	Edit      *PromptEntry // The Edit or Write tool use, nil for MatchCommit
+}
+
+// FindLineOrigin blames line of the file at path, relative to the current
+// directory, and finds the step of the blamed commit's sessions that
+// produced it: the last edit that wrote its text, else the last edit of
+// the file, else the last prompt of the commit
+func FindLineOrigin(path string, line int, full bool) (*LineOrigin, error) {
+	prefix, _ := git.RunGit("rev-parse", "--show-prefix")
+	path = filepath.ToSlash(filepath.Clean(filepath.Join(prefix, path)))
+
+	top, err := git.GetRepoRoot()
+	if err != nil {
+		return nil, fmt.Errorf("not in a git repository: %w", err)
+	}
+	out, err := git.RunGit("-C", top, "blame", "--porcelain", "-L", fmt.Sprintf("%d,%d", line, line), "HEAD", "--", path)
+	if err != nil {
+		return nil, fmt.Errorf("git blame %s:%d: %w", path, line, err)
+	}
+	o, err := parseLineBlame(out)
+	if err != nil {
+		return nil, err
+	}
+	o.Path, o.Line = path, line
+
+	o.ShortSHA = o.SHA[:7]
+	o.Subject, _ = getCommitSubject(o.SHA)
+	cs, err := analyzeCommit(o.SHA, full, nil)
+	switch {
+	case errors.Is(err, note.ErrNoNote):
+		return o, nil
+	case err != nil:
+		return nil, fmt.Errorf("commit %s: %w", o.ShortSHA, err)
+	}
+	o.HasNote = true
+	o.closestStep(*cs)
+	return o, nil
+}
+
+// parseLineBlame reads the origin of a single line from git blame
+// --porcelain output
+func parseLineBlame(out string) (*LineOrigin, error) {
+	lines := parseBlameLines(out)
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("unexpected git blame output")
+	}
+	if !lines[0].Committed() {
+		return nil, fmt.Errorf("the line is not committed yet")
+	}
+	return lines[0], nil
+}
+
+// parseBlameLines reads the origin of every line from git blame
+// --line-porcelain output, which repeats the filename for each line
+func parseBlameLines(out string) []*LineOrigin {
+	var lines []*LineOrigin
+	var o *LineOrigin
+	for _, line := range strings.Split(out, "\n") {
+		if m := blamePorcelainHeader.FindStringSubmatch(line); m != nil {
+			o = &LineOrigin{SHA: m[1]}
+			o.OrigLine, _ = strconv.Atoi(m[2])
+			o.Line, _ = strconv.Atoi(m[3])
+			lines = append(lines, o)
+			continue
+		}
+		switch {
+		case o == nil:
+		case strings.HasPrefix(line, "filename "):
+			o.OrigPath = strings.TrimPrefix(line, "filename ")
+		case strings.HasPrefix(line, "\t"):
+			o.Text = strings.TrimPrefix(line, "\t")
+		}
+	}
+	return lines
+}
+
+// Committed reports whether the line is in a commit, blame gives
+// uncommitted changes the all-zero SHA
+func (o *LineOrigin) Committed() bool {
+	return strings.Trim(o.SHA, "0") != ""
+}
+
+// closestStep sets the step of cs closest to the line
+func (o *LineOrigin) closestStep(cs CommitSummary) {
+	o.closestStepIn(cs, BuildProvenanceIndex([]CommitSummary{cs}))
+}
+
+// closestStepIn is closestStep with the provenance index of cs
+func (o *LineOrigin) closestStepIn(cs CommitSummary, idx ProvenanceIndex) {
+	edits := idx.Lookup(o.OrigPath)
+	text := strings.TrimSpace(o.Text)
+
+	var found *Provenance
+	for i := len(edits) - 1; i >= 0 && text != ""; i-- {
+		if writes(edits[i].Edit, text) {
+			found, o.Match = &edits[i], MatchLine
+			break
+		}
+	}
+	if found == nil && len(edits) > 0 {
+		found, o.Match = &edits[len(edits)-1], MatchFile
+	}
+	if found != nil {
+		edit := found.Edit
+		o.Tool, o.SessionID, o.Prompt, o.Edit = found.Tool, found.SessionID, found.Prompt, &edit
+		return
+	}
+
+	// Nothing edited the file, fall back to the last prompt of the work
+	for _, sess := range cs.Sessions {
+		if sess.IsAgent {
+			continue
+		}
+		for i := range sess.Prompts {
+			p := sess.Prompts[i]
+			if !IsUserAction(p.Type) || !p.InWorkPeriod {
+				continue
+			}
+			if o.Prompt == nil || p.Time.After(o.Prompt.Time) {
+				o.Match, o.Tool, o.SessionID, o.Prompt = MatchCommit, sess.Tool, sess.ID, &p
+			}
+		}
+	}
+}
+
+// writes reports whether an Edit or Write put a line with text in the file
+func writes(edit PromptEntry, text string) bool {
+	for _, line := range strings.Split(edit.Written, "\n") {
+		if strings.TrimSpace(line) == text {
+			return true
+		}
+	}
+	return false
+}
+
+// StepID returns the ID of the step the TUI should focus on, the edit if
+// there is one
+func (o *LineOrigin) StepID() string {
+	switch {
+	case o.Edit != nil:
+		return o.Edit.StepID
+	case o.Prompt != nil:
+		return o.Prompt.StepID
+	}
+	return ""
+}
+
+// RenderLineOrigin describes the origin of a line for the terminal
+func RenderLineOrigin(o *LineOrigin) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s:%d  %s\n", o.Path, o.Line, strings.TrimSpace(o.Text))
+	fmt.Fprintf(&sb, "Commit %s  %s\n", o.ShortSHA, truncateSubject(o.Subject))
+	if o.OrigPath != o.Path || o.OrigLine != o.Line {
+		fmt.Fprintf(&sb, "  (line %d of %s in that commit)\n", o.OrigLine, o.OrigPath)
+	}
+
+	switch o.Match {
+	case "":
+		if o.HasNote {
+			sb.WriteString("\nSessions captured, none with a prompt in the work period\n")
+		} else {
+			sb.WriteString("\nNo captured sessions\n")
+		}
+		return sb.String()
+	case MatchLine:
+		sb.WriteString("\nWritten by:\n")
+	case MatchFile:
+		sb.WriteString("\nNo edit wrote this text; the last edit of the file:\n")
+	case MatchCommit:
+		sb.WriteString("\nNo captured edit of the file; the commit's last prompt:\n")
+	}
+
+	fmt.Fprintf(&sb, "  %s %s\n", note.FormatToolName(o.Tool), shortSessionID(o.SessionID))
+	if o.Prompt != nil {
+		fmt.Fprintf(&sb, "    %s %s %s\n", o.Prompt.Time.Local().Format("2006-01-02 15:04"),
+			display.GetTypeEmoji(o.Prompt.Type), historyText(o.Prompt.Text))
+	}
+	if o.Edit != nil {
+		fmt.Fprintf(&sb, "    %s %s %s: %s\n", o.Edit.Time.Local().Format("2006-01-02 15:04"),
+			display.GetTypeEmoji("TOOL_USE"), o.Edit.ToolName, historyText(o.Edit.ToolInput))
+	}
+	return sb.String()
+}
@@ -0,0 +1,58 @@
+package ci
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseLineBlame(t *testing.T) {
+	sha := strings.Repeat("ab", 20)
+	out := sha + " 12 15 1\nauthor Ann\nsummary Add login\nfilename auth/login.go\n\treturn nil"
+	o, err := parseLineBlame(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o.SHA != sha || o.OrigLine != 12 || o.OrigPath != "auth/login.go" || o.Text != "return nil" {
+		t.Errorf("parseLineBlame = %+v", o)
+	}
+
+	if _, err := parseLineBlame(strings.Repeat("0", 40) + " 3 3 1\nfilename a.go\n\tx"); err == nil {
+		t.Error("expected an error for an uncommitted line")
+	}
+}
+
+func TestClosestStep(t *testing.T) {
+	at := func(m int) time.Time { return time.Date(2025, 1, 15, 10, m, 0, 0, time.UTC) }
+	cs := CommitSummary{Sessions: []SessionSummary{{
+		Tool: "claude-code",
+		ID:   "s1",
+		Prompts: []PromptEntry{
+			{Time: at(0), Type: "PROMPT", Text: "Add login", InWorkPeriod: true, StepID: "step-p1"},
+			{Time: at(1), Type: "TOOL_USE", ToolName: "Write", FilePath: "/repo/login.go", Written: "func login() error {\n\treturn nil\n}", StepID: "step-w"},
+			{Time: at(2), Type: "PROMPT", Text: "Log failures", InWorkPeriod: true, StepID: "step-p2"},
+			{Time: at(3), Type: "TOOL_USE", ToolName: "Edit", FilePath: "/repo/login.go", Written: "\tlog.Print(err)", StepID: "step-e"},
+		},
+	}}}
+
+	o := &LineOrigin{OrigPath: "login.go", Text: "    return nil"}
+	o.closestStep(cs)
+	if o.Match != MatchLine || o.StepID() != "step-w" || o.Prompt.Text != "Add login" {
+		t.Errorf("line match = %s %s, want the Write after the first prompt", o.Match, o.StepID())
+	}
+
+	o = &LineOrigin{OrigPath: "login.go", Text: "}  // changed by hand"}
+	o.closestStep(cs)
+	if o.Match != MatchFile || o.StepID() != "step-e" {
+		t.Errorf("file match = %s %s, want the last edit", o.Match, o.StepID())
+	}
+
+	o = &LineOrigin{OrigPath: "README.md", Text: "Login"}
+	o.closestStep(cs)
+	if o.Match != MatchCommit || o.StepID() != "step-p2" || o.Edit != nil {
+		t.Errorf("commit match = %s %s, want the last prompt", o.Match, o.StepID())
+	}
+	if out := RenderLineOrigin(o); !strings.Contains(out, "the commit's last prompt") || !strings.Contains(out, "Log failures") {
+		t.Errorf("RenderLineOrigin:\n%s", out)
+	}
+}
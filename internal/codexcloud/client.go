@@ -0,0 +1,166 @@
+package codexcloud
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const defaultBaseURL = "https://chatgpt.com/backend-api"
+
+// getBaseURL returns the API base URL, allowing override via env var for testing
+func getBaseURL() string {
+	if url := os.Getenv("CODEX_API_URL"); url != "" {
+		return strings.TrimSuffix(url, "/")
+	}
+	return defaultBaseURL
+}
+
+// Client is the Codex cloud tasks API client
+type Client struct {
+	token     string
+	accountID string
+	http      *http.Client
+}
+
+// codexAuth represents the ~/.codex/auth.json file written by the Codex CLI
+type codexAuth struct {
+	Tokens struct {
+		AccessToken string `json:"access_token"`
+		AccountID   string `json:"account_id"`
+	} `json:"tokens"`
+}
+
+// NewClient creates a Codex cloud client. The token comes from
+// CODEX_API_TOKEN or, failing that, from the Codex CLI login in
+// ~/.codex/auth.json.
+func NewClient() (*Client, error) {
+	if token := os.Getenv("CODEX_API_TOKEN"); token != "" {
+		return &Client{token: token, accountID: os.Getenv("CODEX_ACCOUNT_ID"), http: &http.Client{}}, nil
+	}
+
+	auth, err := loadCodexAuth()
+	if err != nil {
+		return nil, fmt.Errorf("no Codex token: set CODEX_API_TOKEN or log in with the Codex CLI: %w", err)
+	}
+	return &Client{token: auth.Tokens.AccessToken, accountID: auth.Tokens.AccountID, http: &http.Client{}}, nil
+}
+
+// loadCodexAuth reads the Codex CLI credentials
+func loadCodexAuth() (*codexAuth, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(filepath.Join(homeDir, ".codex", "auth.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var auth codexAuth
+	if err := json.Unmarshal(data, &auth); err != nil {
+		return nil, fmt.Errorf("failed to parse ~/.codex/auth.json: %w", err)
+	}
+	if auth.Tokens.AccessToken == "" {
+		return nil, fmt.Errorf("no access token found in ~/.codex/auth.json")
+	}
+	return &auth, nil
+}
+
+// doRequest performs an authenticated API request
+func (c *Client) doRequest(method, path string) ([]byte, error) {
+	req, err := http.NewRequest(method, getBaseURL()+path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Content-Type", "application/json")
+	if c.accountID != "" {
+		req.Header.Set("ChatGPT-Account-Id", c.accountID)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Codex API error (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	return body, nil
+}
+
+// ListTasks returns recent cloud tasks
+func (c *Client) ListTasks(limit int) ([]Task, error) {
+	body, err := c.doRequest("GET", fmt.Sprintf("/wham/tasks/list?limit=%d", limit))
+	if err != nil {
+		return nil, err
+	}
+
+	var resp TasksResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse tasks response: %w", err)
+	}
+	return resp.Items, nil
+}
+
+// GetTask returns a specific task by ID
+func (c *Client) GetTask(taskID string) (*Task, error) {
+	body, err := c.doRequest("GET", "/wham/tasks/"+url.PathEscape(taskID))
+	if err != nil {
+		return nil, err
+	}
+
+	var resp TaskResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse task response: %w", err)
+	}
+	return &resp.Task, nil
+}
+
+// GetTaskTurns returns the conversation turns of a task
+func (c *Client) GetTaskTurns(taskID string) ([]Turn, error) {
+	body, err := c.doRequest("GET", "/wham/tasks/"+url.PathEscape(taskID)+"/turns")
+	if err != nil {
+		return nil, err
+	}
+
+	var resp TurnsResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse turns response: %w", err)
+	}
+	return resp.Items, nil
+}
+
+// FindTaskByBranch finds a recent task whose branch matches branchName
+func (c *Client) FindTaskByBranch(branchName string) (*Task, error) {
+	tasks, err := c.ListTasks(50)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, t := range tasks {
+		if t.BranchName == "" {
+			continue
+		}
+		if t.BranchName == branchName || strings.HasSuffix(branchName, t.BranchName) || strings.HasSuffix(t.BranchName, branchName) {
+			return &t, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no Codex task found for branch: %s", branchName)
+}
@@ -0,0 +1,145 @@
+package codexcloud
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/session"
+)
+
+// TurnsToJSONL converts task turns to JSONL compatible with local Claude
+// Code sessions, so the regular parser and viewers handle Codex tasks.
+// Messages become text entries, shell and function calls become tool_use
+// parts, and their outputs become tool_result entries.
+func TurnsToJSONL(turns []Turn, task *Task) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+
+	for _, entry := range TurnsToMessageEntries(turns, task) {
+		if err := encoder.Encode(entry); err != nil {
+			return nil, err
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+// TurnsToMessageEntries converts task turns to MessageEntry slice
+func TurnsToMessageEntries(turns []Turn, task *Task) []session.MessageEntry {
+	var entries []session.MessageEntry
+
+	for _, turn := range turns {
+		// Turns without their own timestamp fall back to the task's
+		ts := unixSeconds(turn.CreatedAt)
+		if ts.IsZero() {
+			ts = task.Created()
+		}
+
+		items := turn.OutputItems
+		if turn.Role == "user" {
+			items = turn.InputItems
+		}
+
+		for _, item := range items {
+			role, content := convertItem(item)
+			if content == nil {
+				continue
+			}
+			raw, err := json.Marshal(content)
+			if err != nil {
+				continue
+			}
+			entries = append(entries, session.MessageEntry{
+				Type:      role,
+				SessionID: task.ID,
+				Timestamp: ts,
+				GitBranch: task.BranchName,
+				Message:   &session.Message{Role: role, RawContent: raw},
+			})
+		}
+	}
+
+	return entries
+}
+
+// convertItem returns the role and Claude-style content for an item,
+// or nil content for items that are not part of the conversation
+func convertItem(item Item) (string, any) {
+	switch item.Type {
+	case "message":
+		text := itemText(item)
+		if text == "" {
+			return "", nil
+		}
+		if item.Role == "user" {
+			return "user", text
+		}
+		return "assistant", []map[string]any{{"type": "text", "text": text}}
+
+	case "local_shell_call":
+		command := ""
+		if item.Action != nil {
+			command = strings.Join(item.Action.Command, " ")
+		}
+		return "assistant", []map[string]any{{
+			"type":  "tool_use",
+			"id":    item.CallID,
+			"name":  "Bash",
+			"input": map[string]any{"command": command},
+		}}
+
+	case "function_call":
+		var input map[string]any
+		if json.Unmarshal([]byte(item.Arguments), &input) != nil {
+			input = map[string]any{"arguments": item.Arguments}
+		}
+		return "assistant", []map[string]any{{
+			"type":  "tool_use",
+			"id":    item.CallID,
+			"name":  item.Name,
+			"input": input,
+		}}
+
+	case "local_shell_call_output", "function_call_output":
+		return "user", []map[string]any{{
+			"type":        "tool_result",
+			"tool_use_id": item.CallID,
+			"content":     outputText(item.Output),
+		}}
+	}
+
+	return "", nil
+}
+
+// itemText joins the text parts of a message item
+func itemText(item Item) string {
+	var texts []string
+	for _, part := range item.Content {
+		if part.Text != "" {
+			texts = append(texts, part.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// outputText extracts a tool output that is either a string or {"output": "..."}
+func outputText(raw json.RawMessage) string {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	var wrapped struct {
+		Output string `json:"output"`
+	}
+	if json.Unmarshal(raw, &wrapped) == nil {
+		return wrapped.Output
+	}
+	return string(raw)
+}
+
+// GetTaskTimeRange returns the time range covered by a task
+func GetTaskTimeRange(task *Task) (start, end time.Time) {
+	return task.Created(), task.Updated()
+}
@@ -0,0 +1,83 @@
+package codexcloud
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/session"
+)
+
+func TestTurnsToJSONL(t *testing.T) {
+	task := &Task{ID: "task_e_1", CreatedAt: 1736931600, BranchName: "codex/fix-bug"}
+	turns := []Turn{
+		{
+			Role:      "user",
+			CreatedAt: 1736931660,
+			InputItems: []Item{
+				{Type: "message", Role: "user", Content: []ContentPart{{ContentType: "text", Text: "Fix the failing test"}}},
+			},
+		},
+		{
+			Role: "assistant",
+			OutputItems: []Item{
+				{Type: "local_shell_call", CallID: "c1", Action: &ShellAction{Command: []string{"go", "test", "./..."}}},
+				{Type: "local_shell_call_output", CallID: "c1", Output: json.RawMessage(`{"output":"ok"}`)},
+				{Type: "function_call", CallID: "c2", Name: "apply_patch", Arguments: `{"input":"*** Begin Patch"}`},
+				{Type: "function_call_output", CallID: "c2", Output: json.RawMessage(`"Done"`)},
+				{Type: "message", Role: "assistant", Content: []ContentPart{{ContentType: "text", Text: "Fixed."}}},
+				{Type: "reasoning"},
+			},
+		},
+	}
+
+	jsonl, err := TurnsToJSONL(turns, task)
+	if err != nil {
+		t.Fatalf("TurnsToJSONL() error: %v", err)
+	}
+
+	entries, err := session.ParseMessages(jsonl)
+	if err != nil {
+		t.Fatalf("ParseMessages() error: %v", err)
+	}
+	if len(entries) != 6 {
+		t.Fatalf("Expected 6 entries, got %d:\n%s", len(entries), jsonl)
+	}
+
+	if entries[0].Type != "user" || entries[0].Message.GetTextContent() != "Fix the failing test" {
+		t.Errorf("Entry 0: expected user prompt, got %s", entries[0].Message.RawContent)
+	}
+	if !entries[0].Timestamp.Equal(time.Unix(1736931660, 0)) {
+		t.Errorf("Entry 0: unexpected timestamp %v", entries[0].Timestamp)
+	}
+	if entries[0].GitBranch != "codex/fix-bug" || entries[0].SessionID != "task_e_1" {
+		t.Errorf("Entry 0: unexpected branch/session %q %q", entries[0].GitBranch, entries[0].SessionID)
+	}
+
+	// Turns without a timestamp fall back to the task creation time
+	if !entries[1].Timestamp.Equal(time.Unix(1736931600, 0)) {
+		t.Errorf("Entry 1: expected task timestamp, got %v", entries[1].Timestamp)
+	}
+
+	var toolUse []struct {
+		Type  string         `json:"type"`
+		Name  string         `json:"name"`
+		Input map[string]any `json:"input"`
+	}
+	if err := json.Unmarshal(entries[1].Message.RawContent, &toolUse); err != nil || toolUse[0].Name != "Bash" || toolUse[0].Input["command"] != "go test ./..." {
+		t.Errorf("Entry 1: expected Bash tool_use, got %s", entries[1].Message.RawContent)
+	}
+
+	var toolResult []struct {
+		Type      string `json:"type"`
+		ToolUseID string `json:"tool_use_id"`
+		Content   string `json:"content"`
+	}
+	if err := json.Unmarshal(entries[2].Message.RawContent, &toolResult); err != nil || toolResult[0].ToolUseID != "c1" || toolResult[0].Content != "ok" {
+		t.Errorf("Entry 2: expected tool_result for c1, got %s", entries[2].Message.RawContent)
+	}
+
+	if entries[5].Type != "assistant" || entries[5].Message.GetTextContent() != "Fixed." {
+		t.Errorf("Entry 5: expected assistant text, got %s", entries[5].Message.RawContent)
+	}
+}
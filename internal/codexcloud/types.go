@@ -0,0 +1,92 @@
+package codexcloud
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Task represents a Codex cloud task from the API
+type Task struct {
+	ID          string       `json:"id"`
+	Title       string       `json:"title"`
+	CreatedAt   float64      `json:"created_at"` // Unix seconds
+	UpdatedAt   float64      `json:"updated_at"` // Unix seconds
+	Status      string       `json:"status"`
+	BranchName  string       `json:"branch_name"`
+	Environment *Environment `json:"environment,omitempty"`
+}
+
+// Environment describes the repository a task ran against
+type Environment struct {
+	ID    string   `json:"id"`
+	Label string   `json:"label"`
+	Repos []string `json:"repos"` // e.g. "QuesmaOrg/git-prompt-story"
+}
+
+// Created returns the task creation time
+func (t *Task) Created() time.Time {
+	return unixSeconds(t.CreatedAt)
+}
+
+// Updated returns the time of the last task update
+func (t *Task) Updated() time.Time {
+	if t.UpdatedAt == 0 {
+		return t.Created()
+	}
+	return unixSeconds(t.UpdatedAt)
+}
+
+// Turn is one user request or agent response within a task
+type Turn struct {
+	ID          string  `json:"id"`
+	Role        string  `json:"role"`       // "user", "assistant"
+	CreatedAt   float64 `json:"created_at"` // Unix seconds
+	InputItems  []Item  `json:"input_items"`
+	OutputItems []Item  `json:"output_items"`
+}
+
+// Item is a message, tool call or tool output inside a turn
+type Item struct {
+	Type      string          `json:"type"` // "message", "local_shell_call", "function_call", "*_output"
+	Role      string          `json:"role,omitempty"`
+	Content   []ContentPart   `json:"content,omitempty"`
+	CallID    string          `json:"call_id,omitempty"`
+	Name      string          `json:"name,omitempty"`
+	Arguments string          `json:"arguments,omitempty"` // JSON-encoded function arguments
+	Action    *ShellAction    `json:"action,omitempty"`
+	Output    json.RawMessage `json:"output,omitempty"` // String or {"output": "..."}
+}
+
+// ContentPart is a piece of message content
+type ContentPart struct {
+	ContentType string `json:"content_type"` // "text"
+	Text        string `json:"text"`
+}
+
+// ShellAction is the command run by a local_shell_call
+type ShellAction struct {
+	Command []string `json:"command"`
+}
+
+// TasksResponse is the response from /wham/tasks/list
+type TasksResponse struct {
+	Items []Task `json:"items"`
+}
+
+// TaskResponse is the response from /wham/tasks/{id}
+type TaskResponse struct {
+	Task Task `json:"task"`
+}
+
+// TurnsResponse is the response from /wham/tasks/{id}/turns
+type TurnsResponse struct {
+	Items []Turn `json:"items"`
+}
+
+// unixSeconds converts fractional Unix seconds to UTC time
+func unixSeconds(s float64) time.Time {
+	if s == 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(int64(s * 1000)).UTC()
+}
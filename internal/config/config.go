@@ -0,0 +1,219 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Git config keys used by prompt-story. Values live in the regular git
+// config files, so `git config prompt-story.<key>` can inspect or change them.
+const (
+	// KeyInitialized is set by `git-prompt-story init` once setup completed
+	KeyInitialized = "prompt-story.initialized"
+
+	// KeyInitHintShown records that the one-time init pointer was printed
+	KeyInitHintShown = "prompt-story.initHintShown"
+
+	// KeyScrub enables PII scrubbing of transcripts (default true)
+	KeyScrub = "prompt-story.scrub"
+
+	// KeyTools is a comma-separated list of tools whose sessions are captured
+	KeyTools = "prompt-story.tools"
+
+	// KeyAutoPush records whether notes are pushed by the pre-push hook
+	KeyAutoPush = "prompt-story.autoPush"
+
+	// KeyCommitDigest adds a commented-out digest of the captured sessions
+	// to the commit message being edited (default false)
+	KeyCommitDigest = "prompt-story.commitDigest"
+
+	// KeyConfirmInEditor adds a commented-out preview of every captured
+	// prompt to the commit message being edited (default false)
+	KeyConfirmInEditor = "prompt-story.confirmInEditor"
+
+	// KeyPagesURL is the default --pages-url for PR summaries and
+	// annotations, rewritten by migrate-remote when hosting moves
+	KeyPagesURL = "prompt-story.pagesUrl"
+
+	// KeyContinuationGap is the longest pause (a Go duration, default 30m)
+	// after which a new session can still be shown as continuing the
+	// previous one; 0 disables the linking
+	KeyContinuationGap = "prompt-story.continuationGap"
+
+	// KeyClockSkew is how far (a Go duration, default 0) session entries
+	// may be stamped outside the work period and still be captured, for
+	// tools running on machines whose clocks are off
+	KeyClockSkew = "prompt-story.clockSkew"
+
+	// KeyAutoAddMissed adds notes to commits made without the hooks, when
+	// their sessions match confidently, instead of offering to (default false)
+	KeyAutoAddMissed = "prompt-story.autoAddMissed"
+
+	// KeyScrubLocales is a comma-separated list of scrubber locale packs
+	// (e.g. "eu,uk") whose recognizers run in addition to the default ones
+	KeyScrubLocales = "prompt-story.scrubLocales"
+
+	// KeyScrubDetectors is a comma-separated list of scrubber detectors
+	// (e.g. "phone,ip") for personal data the default recognizers keep
+	KeyScrubDetectors = "prompt-story.scrubDetectors"
+
+	// KeyScrubReview set to ScrubReviewInteractive asks, at capture time,
+	// whether to replace each match of the scrubber
+	KeyScrubReview = "prompt-story.scrubReview"
+
+	// KeyWorkStart is a comma-separated list of the heuristics whose
+	// latest proposal is taken as the start of work on a commit (default
+	// "previous-commit,branch-switch")
+	KeyWorkStart = "prompt-story.workStart"
+
+	// KeySummaryFormat is the format of the commit message summary after
+	// "Prompt-Story: Used" (default note.DefaultSummaryFormat)
+	KeySummaryFormat = "prompt-story.summaryFormat"
+
+	// KeyDefaultCommand is what a bare `git-prompt-story` does: one of the
+	// DefaultCommand* values (default DefaultCommandHelp)
+	KeyDefaultCommand = "prompt-story.defaultCommand"
+
+	// KeyUsageMetrics enables the local usage counters of the CLI itself,
+	// see the usage package (default false)
+	KeyUsageMetrics = "prompt-story.usageMetrics"
+
+	// KeyViewPrefix followed by a name is a saved view: the flags, e.g.
+	// "--full --renderer=jira", that show and pr summary take with --view
+	KeyViewPrefix = "prompt-story.view."
+)
+
+// ScrubReviewInteractive is the KeyScrubReview value enabling the review
+const ScrubReviewInteractive = "interactive"
+
+// KeyDefaultCommand values
+const (
+	DefaultCommandHelp   = "help"   // Print the usage
+	DefaultCommandStatus = "status" // Show the capture state, like status
+	DefaultCommandShow   = "show"   // Open the viewer on HEAD, like show HEAD
+)
+
+// noScrubEnv disables scrubbing for a single invocation
+const noScrubEnv = "GIT_PROMPT_STORY_NO_SCRUB"
+
+// Get returns the value of a git config key, or empty if unset
+func Get(key string) string {
+	cmd := exec.Command("git", "config", "--get", key)
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// GetBool returns a boolean git config value, or def if unset or invalid
+func GetBool(key string, def bool) bool {
+	switch strings.ToLower(Get(key)) {
+	case "true", "yes", "on", "1":
+		return true
+	case "false", "no", "off", "0":
+		return false
+	default:
+		return def
+	}
+}
+
+// Set writes a git config value to the repository config, or to the
+// user's global config when global is true
+func Set(key, value string, global bool) error {
+	args := []string{"config"}
+	if global {
+		args = append(args, "--global")
+	}
+	args = append(args, key, value)
+	if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("git config %s: %s", key, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// IsInitialized reports whether `git-prompt-story init` has been run
+func IsInitialized() bool {
+	return GetBool(KeyInitialized, false)
+}
+
+// ScrubEnabled reports whether transcripts should be PII-scrubbed.
+// GIT_PROMPT_STORY_NO_SCRUB=1 overrides the configured value.
+func ScrubEnabled() bool {
+	if os.Getenv(noScrubEnv) == "1" {
+		return false
+	}
+	return GetBool(KeyScrub, true)
+}
+
+// ScrubLocales returns the scrubber locale packs enabled in
+// prompt-story.scrubLocales
+func ScrubLocales() []string {
+	value := Get(KeyScrubLocales)
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
+
+// ScrubDetectors returns the scrubber detectors enabled in
+// prompt-story.scrubDetectors
+func ScrubDetectors() []string {
+	value := Get(KeyScrubDetectors)
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
+
+// WorkStartStrategies returns the heuristics enabled in
+// prompt-story.workStart, or nil if it is unset
+func WorkStartStrategies() []string {
+	var strategies []string
+	for _, name := range strings.Split(Get(KeyWorkStart), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			strategies = append(strategies, name)
+		}
+	}
+	return strategies
+}
+
+// ToolCaptureKey returns the key recording whether sessions of tool are
+// captured in this repository, set when the user is asked the first time
+// its sessions are found (e.g. prompt-story.cursor.capture)
+func ToolCaptureKey(tool string) string {
+	return "prompt-story." + tool + ".capture"
+}
+
+// ToolCaptureConsent returns whether sessions of tool are captured in this
+// repository, and whether that was decided: by the tool's ToolCaptureKey,
+// or by listing the tools to capture in prompt-story.tools
+func ToolCaptureConsent(tool string) (capture, decided bool) {
+	switch strings.ToLower(Get(ToolCaptureKey(tool))) {
+	case "true", "yes", "on", "1":
+		return true, true
+	case "false", "no", "off", "0":
+		return false, true
+	}
+	if Get(KeyTools) != "" {
+		return ToolEnabled(tool), true
+	}
+	return false, false
+}
+
+// ToolEnabled reports whether sessions from tool should be captured.
+// All tools are enabled until prompt-story.tools is configured.
+func ToolEnabled(tool string) bool {
+	value := Get(KeyTools)
+	if value == "" {
+		return true
+	}
+	for _, t := range strings.Split(value, ",") {
+		if strings.TrimSpace(t) == tool {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,138 @@
+// Package coverage reads test coverage reports: Go coverage profiles
+// (go test -coverprofile) and lcov tracefiles.
+package coverage
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Lines maps repository-relative file paths (with forward slashes) to their
+// instrumented lines and whether tests covered each
+type Lines map[string]map[int]bool
+
+// Covered reports whether line of path is instrumented, and if so whether
+// it was covered
+func (l Lines) Covered(path string, line int) (covered, instrumented bool) {
+	covered, instrumented = l[path][line]
+	return covered, instrumented
+}
+
+func (l Lines) mark(path string, line int, covered bool) {
+	if l[path] == nil {
+		l[path] = make(map[int]bool)
+	}
+	l[path][line] = l[path][line] || covered
+}
+
+// Load reads a coverage report, detecting its format. Paths are made
+// relative to repoRoot; Go profiles name files by import path, which is
+// mapped using the module path in repoRoot's go.mod.
+func Load(path, repoRoot string) (Lines, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read coverage report: %w", err)
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	head, _ := r.Peek(5)
+	if string(head) == "mode:" {
+		return ParseGoProfile(r, modulePath(repoRoot))
+	}
+	return ParseLCOV(r, repoRoot)
+}
+
+// modulePath returns the module declared in dir's go.mod, or ""
+func modulePath(dir string) string {
+	data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if rest, ok := strings.CutPrefix(strings.TrimSpace(line), "module "); ok {
+			return strings.Trim(strings.TrimSpace(rest), `"`)
+		}
+	}
+	return ""
+}
+
+// goProfileBlock matches a block of a Go coverage profile:
+// "file.go:startLine.startCol,endLine.endCol numStatements count"
+var goProfileBlock = regexp.MustCompile(`^(.+):(\d+)\.\d+,(\d+)\.\d+ \d+ (\d+)$`)
+
+// ParseGoProfile parses a Go coverage profile. A line is covered if any
+// block spanning it ran; file paths lose the modulePath prefix.
+func ParseGoProfile(r io.Reader, modulePath string) (Lines, error) {
+	lines := make(Lines)
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "mode:") {
+			continue
+		}
+		m := goProfileBlock.FindStringSubmatch(text)
+		if m == nil {
+			return nil, fmt.Errorf("coverage profile line %d: unexpected %q", lineNum, text)
+		}
+		path := m[1]
+		if modulePath != "" {
+			path = strings.TrimPrefix(path, modulePath+"/")
+		}
+		start, _ := strconv.Atoi(m[2])
+		end, _ := strconv.Atoi(m[3])
+		count, _ := strconv.Atoi(m[4])
+		for line := start; line <= end; line++ {
+			lines.mark(path, line, count > 0)
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// ParseLCOV parses an lcov tracefile's line records (SF and DA). Absolute
+// source paths are made relative to repoRoot.
+func ParseLCOV(r io.Reader, repoRoot string) (Lines, error) {
+	lines := make(Lines)
+	scanner := bufio.NewScanner(r)
+	path := ""
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		text := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(text, "SF:"):
+			path = filepath.Clean(strings.TrimPrefix(text, "SF:"))
+			if filepath.IsAbs(path) && repoRoot != "" {
+				if rel, err := filepath.Rel(repoRoot, path); err == nil && !strings.HasPrefix(rel, "..") {
+					path = rel
+				}
+			}
+			path = filepath.ToSlash(path)
+		case strings.HasPrefix(text, "DA:"):
+			if path == "" {
+				return nil, fmt.Errorf("lcov line %d: DA record outside a source file", lineNum)
+			}
+			fields := strings.Split(strings.TrimPrefix(text, "DA:"), ",")
+			if len(fields) < 2 {
+				return nil, fmt.Errorf("lcov line %d: unexpected %q", lineNum, text)
+			}
+			line, err1 := strconv.Atoi(fields[0])
+			hits, err2 := strconv.ParseFloat(fields[1], 64)
+			if err1 != nil || err2 != nil {
+				return nil, fmt.Errorf("lcov line %d: unexpected %q", lineNum, text)
+			}
+			lines.mark(path, line, hits > 0)
+		case text == "end_of_record":
+			path = ""
+		}
+	}
+	return lines, scanner.Err()
+}
@@ -0,0 +1,90 @@
+package coverage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseGoProfile(t *testing.T) {
+	profile := `mode: set
+example.com/app/server.go:10.2,12.16 2 1
+example.com/app/server.go:12.16,14.3 1 0
+example.com/app/internal/db.go:5.1,5.20 1 0
+`
+	lines, err := ParseGoProfile(strings.NewReader(profile), "example.com/app")
+	if err != nil {
+		t.Fatalf("ParseGoProfile() error: %v", err)
+	}
+
+	tests := []struct {
+		path                  string
+		line                  int
+		covered, instrumented bool
+	}{
+		{"server.go", 10, true, true},
+		{"server.go", 12, true, true}, // Shared by a covered and an uncovered block
+		{"server.go", 13, false, true},
+		{"server.go", 15, false, false},
+		{"internal/db.go", 5, false, true},
+	}
+	for _, tt := range tests {
+		covered, instrumented := lines.Covered(tt.path, tt.line)
+		if covered != tt.covered || instrumented != tt.instrumented {
+			t.Errorf("Covered(%s, %d) = %v, %v, want %v, %v", tt.path, tt.line, covered, instrumented, tt.covered, tt.instrumented)
+		}
+	}
+
+	if _, err := ParseGoProfile(strings.NewReader("mode: set\nnot a block\n"), ""); err == nil {
+		t.Error("Expected an error for a malformed profile")
+	}
+}
+
+func TestParseLCOV(t *testing.T) {
+	report := `TN:
+SF:/work/app/src/index.ts
+DA:1,3
+DA:2,0
+end_of_record
+SF:lib/util.js
+DA:7,1
+end_of_record
+`
+	lines, err := ParseLCOV(strings.NewReader(report), "/work/app")
+	if err != nil {
+		t.Fatalf("ParseLCOV() error: %v", err)
+	}
+	if covered, ok := lines.Covered("src/index.ts", 1); !covered || !ok {
+		t.Error("src/index.ts:1 should be covered")
+	}
+	if covered, ok := lines.Covered("src/index.ts", 2); covered || !ok {
+		t.Error("src/index.ts:2 should be instrumented but not covered")
+	}
+	if covered, _ := lines.Covered("lib/util.js", 7); !covered {
+		t.Error("lib/util.js:7 should be covered")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/app\n\ngo 1.24\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "cover.out"), []byte("mode: atomic\nexample.com/app/main.go:3.13,5.2 1 4\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "lcov.info"), []byte("SF:"+filepath.Join(dir, "main.js")+"\nDA:3,0\nend_of_record\n"), 0644)
+
+	lines, err := Load(filepath.Join(dir, "cover.out"), dir)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if covered, _ := lines.Covered("main.go", 4); !covered {
+		t.Errorf("main.go:4 should be covered, got %v", lines)
+	}
+
+	lines, err = Load(filepath.Join(dir, "lcov.info"), dir)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if _, ok := lines.Covered("main.js", 3); !ok {
+		t.Errorf("main.js:3 should be instrumented, got %v", lines)
+	}
+}
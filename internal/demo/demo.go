@@ -0,0 +1,395 @@
+// Package demo creates a synthetic repository with captured sessions, for
+// demos, documentation screenshots and trying the read commands.
+package demo
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+	"github.com/QuesmaOrg/git-prompt-story/internal/note"
+	"github.com/QuesmaOrg/git-prompt-story/internal/session"
+	"github.com/QuesmaOrg/git-prompt-story/internal/show"
+)
+
+const (
+	// Base and Branch are the branches of the demo pull request
+	Base   = "main"
+	Branch = "feature/login"
+
+	// Range is the demo pull request's commit range
+	Range = Base + ".." + Branch
+
+	authorName  = "Demo Developer"
+	authorEmail = "demo@example.com"
+	osUser      = "demo"
+	projectDir  = "/home/demo/shop"
+)
+
+// Session IDs of the demo transcripts
+const (
+	loginSessionID  = "5f2b8c1e-0d4a-4e7b-9a61-3c8e2f7d1a01"
+	resumeSessionID = "9e4d2a7b-61c3-4f5e-b8a0-2d7c1e9f3b42"
+	agentSessionID  = "agent-7c3e91d0"
+	cursorComposeID = "b41c7a2e-93d5-4f08-8e6a-0a9d5c2e4f17"
+)
+
+// at returns a time on the demo day
+func at(hour, min int) time.Time {
+	return time.Date(2025, 1, 15, hour, min, 0, 0, time.UTC)
+}
+
+// capture is one session attached to a demo commit
+type capture struct {
+	tool     string
+	id       string
+	content  []byte
+	created  time.Time
+	modified time.Time
+	prompts  int
+}
+
+// step is one commit of the demo repository
+type step struct {
+	when     time.Time
+	start    time.Time // Start of the work period; zero for commits without sessions
+	subject  string
+	files    map[string]string
+	captures []capture
+	tags     []string
+}
+
+// Create builds the demo repository in dir, which must not exist or be
+// empty. Branch ends up checked out.
+func Create(dir string) error {
+	if entries, err := os.ReadDir(dir); err == nil && len(entries) > 0 {
+		return fmt.Errorf("%s is not empty", dir)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	// The git helpers work on the current directory
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	if err := os.Chdir(dir); err != nil {
+		return err
+	}
+	defer os.Chdir(wd)
+
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"symbolic-ref", "HEAD", "refs/heads/" + Base},
+		{"config", "user.name", authorName},
+		{"config", "user.email", authorEmail},
+		{"config", "commit.gpgsign", "false"},
+	} {
+		if _, err := git.RunGit(args...); err != nil {
+			return fmt.Errorf("git %s: %w", args[0], err)
+		}
+	}
+
+	if err := commitStep(step{
+		when:    at(9, 0),
+		subject: "Initial commit",
+		files: map[string]string{
+			"README.md":     "# Shop\n\nA small web shop.\n",
+			"go.mod":        "module example.com/shop\n\ngo 1.24\n",
+			"server.go":     serverGo,
+			"db/schema.sql": usersSchema,
+		},
+	}); err != nil {
+		return err
+	}
+	if _, err := git.RunGit("checkout", "-q", "-b", Branch); err != nil {
+		return fmt.Errorf("failed to create branch %s: %w", Branch, err)
+	}
+
+	steps, login, err := featureSteps()
+	if err != nil {
+		return err
+	}
+	for _, s := range steps {
+		if err := commitStep(s); err != nil {
+			return err
+		}
+	}
+
+	// A prompt leaked a key, so it was redacted after capture
+	login.redact(leakedPrompt, show.RedactedPlaceholder)
+	return redactTranscript(login)
+}
+
+// featureSteps returns the commits of the feature branch, and the login
+// session whose leaked key is redacted afterwards
+func featureSteps() ([]step, *claudeTranscript, error) {
+	login := newClaudeTranscript(loginSessionID)
+	login.prompt(at(9, 5), "Add a /login endpoint to server.go that checks the credentials against the users table")
+	login.say(at(9, 6), "I'll look at how the server is set up first, and ask an agent to find the users table schema.")
+	login.tool(at(9, 6), "Read", map[string]any{"file_path": projectDir + "/server.go"}, serverGo)
+	login.tool(at(9, 8), "Task", map[string]any{
+		"description":   "Find users table schema",
+		"prompt":        "Find where the users table is defined and list its columns.",
+		"subagent_type": "Explore",
+	}, "The users table is created in db/schema.sql with columns id, email and password_hash.")
+	login.tool(at(9, 15), "Edit", map[string]any{
+		"file_path":  projectDir + "/server.go",
+		"old_string": "\tmux.HandleFunc(\"/\", handleIndex)",
+		"new_string": "\tmux.HandleFunc(\"/\", handleIndex)\n\tmux.HandleFunc(\"/login\", handleLogin)",
+	}, "The file "+projectDir+"/server.go has been updated.")
+	login.tool(at(9, 18), "Bash", map[string]any{"command": "go build ./...", "description": "Build the server"}, "")
+	login.say(at(9, 19), "Added `handleLogin`, which looks the user up by email and compares the password hash.")
+	login.prompt(at(9, 40), "Use bcrypt for the password comparison")
+	login.tool(at(9, 41), "Edit", map[string]any{
+		"file_path":  projectDir + "/login.go",
+		"old_string": "if user.PasswordHash != hash(password) {",
+		"new_string": "if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil {",
+	}, "The file "+projectDir+"/login.go has been updated.")
+	login.say(at(9, 45), "Passwords are now compared with `bcrypt.CompareHashAndPassword`.")
+
+	agent := newAgentTranscript(agentSessionID, loginSessionID)
+	agent.prompt(at(9, 8), "Find where the users table is defined and list its columns.")
+	agent.tool(at(9, 9), "Grep", map[string]any{"pattern": "CREATE TABLE users"}, "db/schema.sql:3:CREATE TABLE users (")
+	agent.tool(at(9, 10), "Read", map[string]any{"file_path": projectDir + "/db/schema.sql"}, usersSchema)
+	agent.say(at(9, 12), "The users table is created in db/schema.sql with columns id, email and password_hash.")
+
+	cursor := newCursorComposer(cursorComposeID)
+	cursor.prompt(at(10, 10), "Reject passwords shorter than 12 characters on signup")
+	cursor.say(at(10, 11), "I'll add a validation helper and call it from the signup handler.")
+	if err := cursor.tool(at(10, 12), "edit_file", map[string]any{
+		"target_file": projectDir + "/password.go",
+		"code_edit":   passwordGo,
+	}, "ok"); err != nil {
+		return nil, nil, err
+	}
+	if err := cursor.tool(at(10, 14), "run_terminal_cmd", map[string]any{"command": "go test ./... -count=1 -race"}, ""); err != nil {
+		return nil, nil, err
+	}
+	cursor.prompt(at(10, 20), "Skip the race detector, just run the unit tests")
+	if err := cursor.tool(at(10, 21), "run_terminal_cmd", map[string]any{"command": "go test ./..."}, "ok  \texample.com/shop\t0.012s"); err != nil {
+		return nil, nil, err
+	}
+	cursor.say(at(10, 22), "Tests pass. Signup now rejects passwords shorter than 12 characters.")
+
+	login.prompt(at(11, 5), "Write table-driven tests for handleLogin")
+	login.tool(at(11, 7), "Write", map[string]any{"file_path": projectDir + "/login_test.go", "content": loginTestGo}, "File created successfully at: "+projectDir+"/login_test.go")
+	login.prompt(leakedPrompt, "They fail against staging, the API key there is sk-demo-4f9a2c7e1b8d")
+	login.say(at(11, 21), "The tests don't call staging; they use an in-memory user store. Running them locally.")
+	login.tool(at(11, 22), "Bash", map[string]any{"command": "go test ./...", "description": "Run the tests"}, "ok  \texample.com/shop\t0.015s")
+	login.say(at(11, 23), "All login tests pass.")
+
+	// Claude Code was restarted, which started a new session
+	resumed := newClaudeTranscript(resumeSessionID)
+	resumed.prompt(at(11, 35), "Continue with a test for a locked account")
+	resumed.tool(at(11, 36), "Edit", map[string]any{
+		"file_path":  projectDir + "/login_test.go",
+		"old_string": "\t\t{\"unknown user\", \"bob@example.com\", \"whatever\", http.StatusUnauthorized},",
+		"new_string": "\t\t{\"unknown user\", \"bob@example.com\", \"whatever\", http.StatusUnauthorized},\n\t\t{\"locked account\", \"eve@example.com\", \"correct horse battery\", http.StatusForbidden},",
+	}, "The file "+projectDir+"/login_test.go has been updated.")
+	resumed.say(at(11, 38), "Added a case for a locked account.")
+
+	var steps []step
+
+	first := step{
+		when:    at(10, 0),
+		start:   at(9, 0),
+		subject: "Add login endpoint",
+		files:   map[string]string{"server.go": serverGoLogin, "login.go": loginGo},
+		tags:    []string{"feature"},
+	}
+	for _, t := range []*claudeTranscript{login, agent} {
+		c, err := captureClaude(t, first.start, first.when)
+		if err != nil {
+			return nil, nil, err
+		}
+		first.captures = append(first.captures, c)
+	}
+	steps = append(steps, first)
+
+	content, err := cursor.content()
+	if err != nil {
+		return nil, nil, err
+	}
+	steps = append(steps, step{
+		when:    at(11, 0),
+		start:   at(10, 0),
+		subject: "Validate password length on signup",
+		files:   map[string]string{"password.go": passwordGo},
+		captures: []capture{{
+			tool:     session.ToolCursor,
+			id:       cursorComposeID,
+			content:  content,
+			created:  cursor.first,
+			modified: cursor.last,
+			prompts:  len(cursor.prompts),
+		}},
+	})
+
+	third := step{
+		when:    at(12, 0),
+		start:   at(11, 0),
+		subject: "Add login tests",
+		files:   map[string]string{"login_test.go": loginTestGo},
+		tags:    []string{"tests"},
+	}
+	for _, t := range []*claudeTranscript{login, resumed} {
+		c, err := captureClaude(t, third.start, third.when)
+		if err != nil {
+			return nil, nil, err
+		}
+		third.captures = append(third.captures, c)
+	}
+	steps = append(steps, third)
+
+	steps = append(steps, step{
+		when:    at(12, 30),
+		subject: "Document the login endpoint",
+		files:   map[string]string{"README.md": "# Shop\n\nA small web shop.\n\n`POST /login` signs a user in.\n"},
+	})
+
+	return steps, login, nil
+}
+
+// leakedPrompt is when the login session's prompt with a key was made
+var leakedPrompt = at(11, 20)
+
+// captureClaude captures t as the hook would have at the end of a work
+// period
+func captureClaude(t *claudeTranscript, start, end time.Time) (capture, error) {
+	content, err := t.content(end)
+	if err != nil {
+		return capture{}, err
+	}
+	return capture{
+		tool:     session.ToolClaudeCode,
+		id:       t.id,
+		content:  content,
+		created:  t.created(),
+		modified: t.modifiedBy(end),
+		prompts:  t.promptsIn(start, end),
+	}, nil
+}
+
+// commitStep writes the step's files and commits them at the step's time,
+// with a note for its sessions
+func commitStep(s step) error {
+	for name, content := range s.files {
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+			return err
+		}
+	}
+	if _, err := git.RunGit("add", "-A"); err != nil {
+		return fmt.Errorf("git add: %w", err)
+	}
+
+	parent, _ := git.GetHead()
+	psNote, blobs, err := buildNote(s, parent)
+	if err != nil {
+		return err
+	}
+	// Prompts to agents are not the user's, as in the hook's count
+	prompts := 0
+	for _, c := range s.captures {
+		if !strings.HasPrefix(c.id, "agent-") {
+			prompts += c.prompts
+		}
+	}
+	message := s.subject + "\n\n" + psNote.GenerateSummary(prompts, note.CLIVersion)
+
+	// Bypass hooks: a global install would capture the user's own sessions
+	date := s.when.Format(time.RFC3339)
+	cmd := exec.Command("git", "-c", "core.hooksPath="+os.DevNull, "commit", "-q", "-m", message)
+	cmd.Env = append(os.Environ(), "GIT_AUTHOR_DATE="+date, "GIT_COMMITTER_DATE="+date)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to commit %q: %w: %s", s.subject, err, bytes.TrimSpace(out))
+	}
+	if len(s.captures) == 0 {
+		return nil
+	}
+
+	sha, err := git.GetHead()
+	if err != nil {
+		return err
+	}
+	if err := note.UpdateTranscriptTree(blobs); err != nil {
+		return err
+	}
+	if err := psNote.SealTranscripts(blobs); err != nil {
+		return err
+	}
+	payload, err := psNote.ToJSON()
+	if err != nil {
+		return err
+	}
+	return note.AttachNote(sha, payload)
+}
+
+// buildNote returns the note for a step's sessions and their transcript
+// blobs, keyed by transcript path
+func buildNote(s step, parent string) (*note.PromptStoryNote, map[string]string, error) {
+	n := &note.PromptStoryNote{
+		Version:   1,
+		StartWork: s.start,
+		Sessions:  []note.SessionEntry{},
+		CreatedBy: note.CLIVersion,
+		Tags:      s.tags,
+	}
+	if len(parent) > 7 {
+		parent = parent[:7]
+	}
+
+	blobs := make(map[string]string)
+	for _, c := range s.captures {
+		sha, err := git.HashObject(c.content)
+		if err != nil {
+			return nil, nil, err
+		}
+		path := note.GetTranscriptPath(c.tool, c.id)
+		blobs[path] = sha
+
+		entry := note.SessionEntry{
+			Tool:     c.tool,
+			ID:       c.id,
+			Path:     path,
+			Created:  c.created,
+			Modified: c.modified,
+			OSUser:   osUser,
+			Author:   fmt.Sprintf("%s <%s>", authorName, authorEmail),
+			Context:  &note.SessionContext{Head: parent},
+		}
+		if c.tool == session.ToolClaudeCode {
+			entry.Context.Branch = Branch
+			entry.Context.Cwd = projectDir
+		}
+		n.Sessions = append(n.Sessions, entry)
+	}
+	return n, blobs, nil
+}
+
+// redactTranscript stores the redacted transcript of t, re-sealing the
+// notes referencing it
+func redactTranscript(t *claudeTranscript) error {
+	content, err := t.content(at(23, 59))
+	if err != nil {
+		return err
+	}
+	sha, err := git.HashObject(content)
+	if err != nil {
+		return err
+	}
+	path := note.GetTranscriptPath(session.ToolClaudeCode, t.id)
+	if err := note.UpdateTranscriptTree(map[string]string{path: sha}); err != nil {
+		return err
+	}
+	return note.RecordRedaction(path, "redact", "", content)
+}
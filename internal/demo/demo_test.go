@@ -0,0 +1,74 @@
+package demo
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/session"
+)
+
+func TestFeatureSteps_TranscriptsParse(t *testing.T) {
+	steps, _, err := featureSteps()
+	if err != nil {
+		t.Fatalf("featureSteps() error: %v", err)
+	}
+
+	for _, s := range steps {
+		for _, c := range s.captures {
+			entries, err := session.ParseTranscript(c.tool, c.content)
+			if err != nil {
+				t.Fatalf("%s: ParseTranscript(%s) error: %v", s.subject, c.id, err)
+			}
+			if len(entries) == 0 {
+				t.Errorf("%s: transcript %s has no entries", s.subject, c.id)
+			}
+			if c.prompts == 0 {
+				t.Errorf("%s: transcript %s has no prompts in the work period", s.subject, c.id)
+			}
+			if c.created.Before(s.start) && c.modified.Before(s.start) || c.modified.After(s.when) {
+				t.Errorf("%s: session %s (%v-%v) is outside the work period", s.subject, c.id, c.created, c.modified)
+			}
+		}
+	}
+}
+
+func TestFeatureSteps_PromptCounts(t *testing.T) {
+	steps, _, err := featureSteps()
+	if err != nil {
+		t.Fatalf("featureSteps() error: %v", err)
+	}
+
+	// The first capture of each session holds only its own prompts, so the
+	// tracked count must match what the parser counts
+	seen := make(map[string]bool)
+	for _, s := range steps {
+		for _, c := range s.captures {
+			if seen[c.id] {
+				continue
+			}
+			seen[c.id] = true
+			if got := session.CountUserActions(c.tool, c.content); got != c.prompts {
+				t.Errorf("%s: CountUserActions() = %d, tracked %d", c.id, got, c.prompts)
+			}
+		}
+	}
+}
+
+func TestRedact(t *testing.T) {
+	_, login, err := featureSteps()
+	if err != nil {
+		t.Fatalf("featureSteps() error: %v", err)
+	}
+
+	login.redact(leakedPrompt, "REDACTED")
+	content, err := login.content(at(23, 59))
+	if err != nil {
+		t.Fatalf("content() error: %v", err)
+	}
+	if strings.Contains(string(content), "sk-demo") {
+		t.Error("redacted transcript still contains the key")
+	}
+	if got := strings.Count(string(content), "REDACTED"); got != 1 {
+		t.Errorf("expected 1 redacted entry, got %d", got)
+	}
+}
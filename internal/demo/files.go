@@ -0,0 +1,125 @@
+package demo
+
+// Source files of the demo repository
+
+const serverGo = `package main
+
+import (
+	"log"
+	"net/http"
+)
+
+func main() {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", handleIndex)
+	log.Fatal(http.ListenAndServe(":8080", mux))
+}
+
+func handleIndex(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte("Welcome to the shop"))
+}
+`
+
+const serverGoLogin = `package main
+
+import (
+	"log"
+	"net/http"
+)
+
+func main() {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", handleIndex)
+	mux.HandleFunc("/login", handleLogin)
+	log.Fatal(http.ListenAndServe(":8080", mux))
+}
+
+func handleIndex(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte("Welcome to the shop"))
+}
+`
+
+const usersSchema = `-- Shop database schema
+
+CREATE TABLE users (
+    id            SERIAL PRIMARY KEY,
+    email         TEXT NOT NULL UNIQUE,
+    password_hash TEXT NOT NULL
+);
+`
+
+const loginGo = `package main
+
+import (
+	"net/http"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func handleLogin(w http.ResponseWriter, r *http.Request) {
+	email, password := r.FormValue("email"), r.FormValue("password")
+	user, err := users.ByEmail(r.Context(), email)
+	if err != nil {
+		http.Error(w, "invalid credentials", http.StatusUnauthorized)
+		return
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil {
+		http.Error(w, "invalid credentials", http.StatusUnauthorized)
+		return
+	}
+	startSession(w, user)
+}
+`
+
+const passwordGo = `package main
+
+import "errors"
+
+const minPasswordLength = 12
+
+var errPasswordTooShort = errors.New("password must be at least 12 characters")
+
+func validatePassword(password string) error {
+	if len(password) < minPasswordLength {
+		return errPasswordTooShort
+	}
+	return nil
+}
+`
+
+const loginTestGo = `package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestHandleLogin(t *testing.T) {
+	tests := []struct {
+		name     string
+		email    string
+		password string
+		want     int
+	}{
+		{"valid", "ann@example.com", "correct horse battery", http.StatusOK},
+		{"wrong password", "ann@example.com", "hunter2", http.StatusUnauthorized},
+		{"unknown user", "bob@example.com", "whatever", http.StatusUnauthorized},
+		{"locked account", "eve@example.com", "correct horse battery", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{"email": {tt.email}, "password": {tt.password}}
+			req := httptest.NewRequest("POST", "/login", strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			rec := httptest.NewRecorder()
+			handleLogin(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
+`
@@ -0,0 +1,193 @@
+package demo
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// claudeTranscript builds a Claude Code session JSONL
+type claudeTranscript struct {
+	id      string
+	parent  string // For agent sessions, the session that spawned it
+	entries []claudeEntry
+	prompts []time.Time
+	tools   int
+}
+
+type claudeEntry struct {
+	ts      time.Time
+	typ     string
+	content any
+}
+
+func newClaudeTranscript(id string) *claudeTranscript {
+	return &claudeTranscript{id: id}
+}
+
+// newAgentTranscript builds the sidechain of an agent spawned by parent
+func newAgentTranscript(id, parent string) *claudeTranscript {
+	return &claudeTranscript{id: id, parent: parent}
+}
+
+// prompt adds a user prompt
+func (t *claudeTranscript) prompt(ts time.Time, text string) {
+	t.entries = append(t.entries, claudeEntry{ts, "user", text})
+	t.prompts = append(t.prompts, ts)
+}
+
+// say adds an assistant text message
+func (t *claudeTranscript) say(ts time.Time, text string) {
+	t.entries = append(t.entries, claudeEntry{ts, "assistant", []map[string]any{
+		{"type": "text", "text": text},
+	}})
+}
+
+// tool adds a tool call and its result, returned two seconds later
+func (t *claudeTranscript) tool(ts time.Time, name string, input map[string]any, result string) {
+	t.tools++
+	id := fmt.Sprintf("toolu_demo_%s_%02d", t.id[:8], t.tools)
+	t.entries = append(t.entries,
+		claudeEntry{ts, "assistant", []map[string]any{
+			{"type": "tool_use", "id": id, "name": name, "input": input},
+		}},
+		claudeEntry{ts.Add(2 * time.Second), "user", []map[string]any{
+			{"type": "tool_result", "tool_use_id": id, "content": result},
+		}},
+	)
+}
+
+// redact replaces the content of the entry at ts, as show's redaction does
+func (t *claudeTranscript) redact(ts time.Time, placeholder string) {
+	for i := range t.entries {
+		if t.entries[i].ts.Equal(ts) {
+			t.entries[i].content = placeholder
+		}
+	}
+}
+
+// promptsIn counts the prompts made in (from, to]
+func (t *claudeTranscript) promptsIn(from, to time.Time) int {
+	n := 0
+	for _, ts := range t.prompts {
+		if ts.After(from) && !ts.After(to) {
+			n++
+		}
+	}
+	return n
+}
+
+// created returns the time of the first entry
+func (t *claudeTranscript) created() time.Time {
+	if len(t.entries) == 0 {
+		return time.Time{}
+	}
+	return t.entries[0].ts
+}
+
+// modifiedBy returns the time of the last entry written by until
+func (t *claudeTranscript) modifiedBy(until time.Time) time.Time {
+	var last time.Time
+	for _, e := range t.entries {
+		if !e.ts.After(until) {
+			last = e.ts
+		}
+	}
+	return last
+}
+
+// content renders the entries written by until, as the session file read
+// at that time would be
+func (t *claudeTranscript) content(until time.Time) ([]byte, error) {
+	var out []byte
+	for _, e := range t.entries {
+		if e.ts.After(until) {
+			continue
+		}
+		line := map[string]any{
+			"type":      e.typ,
+			"sessionId": t.id,
+			"timestamp": e.ts.UTC().Format(time.RFC3339),
+			"cwd":       projectDir,
+			"gitBranch": Branch,
+			"message":   map[string]any{"role": e.typ, "content": e.content},
+		}
+		if t.parent != "" {
+			line["sessionId"] = t.parent
+			line["agentId"] = t.id
+			line["isSidechain"] = true
+		}
+		data, err := json.Marshal(line)
+		if err != nil {
+			return nil, err
+		}
+		out = append(append(out, data...), '\n')
+	}
+	return out, nil
+}
+
+// cursorComposer builds a stored Cursor composer
+type cursorComposer struct {
+	id      string
+	bubbles []map[string]any
+	prompts []time.Time
+	first   time.Time
+	last    time.Time
+}
+
+func newCursorComposer(id string) *cursorComposer {
+	return &cursorComposer{id: id}
+}
+
+func (c *cursorComposer) add(ts time.Time, bubble map[string]any) {
+	if c.first.IsZero() {
+		c.first = ts
+	}
+	c.last = ts
+	bubble["bubbleId"] = fmt.Sprintf("%s-b%d", c.id, len(c.bubbles)+1)
+	bubble["createdAt"] = ts.UTC().Format(time.RFC3339)
+	c.bubbles = append(c.bubbles, bubble)
+}
+
+// prompt adds a user bubble
+func (c *cursorComposer) prompt(ts time.Time, text string) {
+	c.add(ts, map[string]any{"type": 1, "text": text})
+	c.prompts = append(c.prompts, ts)
+}
+
+// say adds an assistant text bubble
+func (c *cursorComposer) say(ts time.Time, text string) {
+	c.add(ts, map[string]any{"type": 2, "text": text})
+}
+
+// tool adds an assistant bubble calling a Cursor tool. An empty result
+// records the call as rejected by the user, which counts as a prompt.
+func (c *cursorComposer) tool(ts time.Time, name string, args map[string]any, result string) error {
+	raw, err := json.Marshal(args)
+	if err != nil {
+		return err
+	}
+	call := map[string]any{
+		"toolCallId": fmt.Sprintf("call-%d", len(c.bubbles)+1),
+		"name":       name,
+		"rawArgs":    string(raw),
+	}
+	if result == "" {
+		call["userDecision"] = "rejected"
+		c.prompts = append(c.prompts, ts)
+	} else {
+		call["status"] = "completed"
+		call["result"] = result
+	}
+	c.add(ts, map[string]any{"type": 2, "toolFormerData": call})
+	return nil
+}
+
+// content renders the composer as stored in the transcript tree
+func (c *cursorComposer) content() ([]byte, error) {
+	return json.Marshal(map[string]any{
+		"composerId":   c.id,
+		"createdAt":    c.first.UnixMilli(),
+		"conversation": c.bubbles,
+	})
+}
@@ -1,26 +1,103 @@
 // Package display provides shared display utilities for terminal and HTML output.
 package display
 
-// TypeEmoji maps entry types to their display emojis.
-var TypeEmoji = map[string]string{
-	"PROMPT":      "💬",
-	"COMMAND":     "📋",
-	"TOOL_REJECT": "❌",
-	"DECISION":    "❓",
-	"TOOL_USE":    "🔧",
-	"ASSISTANT":   "🤖",
-	"TOOL_RESULT": "📤",
+// Entry type categories, used to style entries in HTML output
+const (
+	CategoryUser      = "user"
+	CategoryAssistant = "assistant"
+	CategoryTool      = "tool"
+	CategoryOther     = "other"
+)
+
+// EntryType describes how entries of one type are displayed and counted
+type EntryType struct {
+	Name       string // Type as stored in entries, e.g. "PROMPT"
+	Emoji      string
+	Label      string // Human-readable name, e.g. "Prompt"
+	UserAction bool   // Counts as a user action rather than agent activity
+	Category   string // One of the Category* constants
+
+	// ToolNames lists tool calls (after provider conversion) shown as this
+	// type instead of TOOL_USE
+	ToolNames []string
+}
+
+// entryTypes is the registry of known entry types, keyed by name;
+// toolTypes maps claimed tool names to their type
+var (
+	entryTypes = map[string]EntryType{}
+	toolTypes  = map[string]string{}
+)
+
+func init() {
+	for _, t := range []EntryType{
+		{Name: "PROMPT", Emoji: "💬", Label: "Prompt", UserAction: true, Category: CategoryUser},
+		{Name: "COMMAND", Emoji: "📋", Label: "Command", UserAction: true, Category: CategoryUser},
+		{Name: "TOOL_REJECT", Emoji: "❌", Label: "Tool rejected", UserAction: true, Category: CategoryUser},
+		{Name: "DECISION", Emoji: "❓", Label: "Decision", UserAction: true, Category: CategoryOther},
+		{Name: "TASK_NOTIFICATION", Emoji: "•", Label: "Task notification", UserAction: true, Category: CategoryOther},
+		{Name: "TOOL_USE", Emoji: "🔧", Label: "Tool use", Category: CategoryTool},
+		{Name: "ASSISTANT", Emoji: "🤖", Label: "Assistant", Category: CategoryAssistant},
+		{Name: "TOOL_RESULT", Emoji: "📤", Label: "Tool result", Category: CategoryTool},
+		{Name: "CONTEXT", Emoji: "📍", Label: "Context", Category: CategoryOther},
+	} {
+		RegisterEntryType(t)
+	}
+}
+
+// RegisterEntryType adds or replaces an entry type. Providers call it from
+// init to introduce types such as BROWSER_ACTION, which summaries, HTML and
+// the TUI then render without further changes.
+func RegisterEntryType(t EntryType) {
+	if t.Category == "" {
+		t.Category = CategoryOther
+	}
+	if t.Label == "" {
+		t.Label = t.Name
+	}
+	entryTypes[t.Name] = t
+	for _, n := range t.ToolNames {
+		toolTypes[n] = t.Name
+	}
+}
+
+// LookupEntryType returns the registered entry type
+func LookupEntryType(name string) (EntryType, bool) {
+	t, ok := entryTypes[name]
+	return t, ok
 }
 
 // GetTypeEmoji returns an emoji for the given entry type.
 // Returns "•" for unknown types.
 func GetTypeEmoji(entryType string) string {
-	if emoji, ok := TypeEmoji[entryType]; ok {
-		return emoji
+	if t, ok := entryTypes[entryType]; ok && t.Emoji != "" {
+		return t.Emoji
 	}
 	return "•"
 }
 
+// IsUserActionType reports whether entries of the type are user actions
+func IsUserActionType(entryType string) bool {
+	return entryTypes[entryType].UserAction
+}
+
+// TypeCategory returns the category of the entry type, CategoryOther if unknown
+func TypeCategory(entryType string) string {
+	if t, ok := entryTypes[entryType]; ok {
+		return t.Category
+	}
+	return CategoryOther
+}
+
+// TypeForTool returns the entry type for a call to the named tool:
+// a registered type claiming it, else TOOL_USE
+func TypeForTool(toolName string) string {
+	if name, ok := toolTypes[toolName]; ok {
+		return name
+	}
+	return "TOOL_USE"
+}
+
 // TruncateText truncates text to maxLen characters, replacing newlines with spaces.
 // If truncated, adds "..." suffix.
 func TruncateText(s string, maxLen int) string {
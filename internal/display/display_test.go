@@ -28,6 +28,42 @@ func TestGetTypeEmoji(t *testing.T) {
 	}
 }
 
+func TestRegisterEntryType(t *testing.T) {
+	RegisterEntryType(EntryType{
+		Name:      "BROWSER_ACTION",
+		Emoji:     "🌐",
+		Label:     "Browser action",
+		Category:  CategoryTool,
+		ToolNames: []string{"browser_navigate", "browser_click"},
+	})
+
+	if got := GetTypeEmoji("BROWSER_ACTION"); got != "🌐" {
+		t.Errorf("GetTypeEmoji = %q, want 🌐", got)
+	}
+	if got := TypeForTool("browser_click"); got != "BROWSER_ACTION" {
+		t.Errorf("TypeForTool(browser_click) = %q, want BROWSER_ACTION", got)
+	}
+	if got := TypeForTool("Bash"); got != "TOOL_USE" {
+		t.Errorf("TypeForTool(Bash) = %q, want TOOL_USE", got)
+	}
+	if IsUserActionType("BROWSER_ACTION") {
+		t.Error("BROWSER_ACTION should not be a user action")
+	}
+	if got := TypeCategory("BROWSER_ACTION"); got != CategoryTool {
+		t.Errorf("TypeCategory = %q, want %q", got, CategoryTool)
+	}
+
+	// Defaults for a minimal registration
+	RegisterEntryType(EntryType{Name: "CHECKPOINT", UserAction: true})
+	ct, ok := LookupEntryType("CHECKPOINT")
+	if !ok || ct.Label != "CHECKPOINT" || ct.Category != CategoryOther || !IsUserActionType("CHECKPOINT") {
+		t.Errorf("unexpected registration: %+v", ct)
+	}
+	if got := GetTypeEmoji("CHECKPOINT"); got != "•" {
+		t.Errorf("GetTypeEmoji without emoji = %q, want •", got)
+	}
+}
+
 func TestTruncateText(t *testing.T) {
 	tests := []struct {
 		name     string
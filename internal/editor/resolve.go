@@ -0,0 +1,95 @@
+package editor
+
+import (
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+)
+
+// RepoName returns the name used to find a local clone of the current
+// repository: the origin remote's basename, or the repository directory name
+func RepoName() string {
+	if remote, err := git.RunGit("remote", "get-url", "origin"); err == nil && remote != "" {
+		name := path.Base(strings.TrimSuffix(strings.TrimSuffix(remote, "/"), ".git"))
+		if i := strings.LastIndex(name, ":"); i != -1 {
+			name = name[i+1:]
+		}
+		if name != "" && name != "." {
+			return name
+		}
+	}
+	if name, err := git.GetRepoName(); err == nil {
+		return name
+	}
+	return ""
+}
+
+// Resolver maps absolute paths recorded in transcripts to repository
+// paths at a given commit. Transcripts record paths from the author's
+// machine (often with the home directory scrubbed), so the repository
+// path is found by dropping leading components until one exists.
+type Resolver struct {
+	cache map[string]string
+}
+
+// NewResolver creates a Resolver
+func NewResolver() *Resolver {
+	return &Resolver{cache: make(map[string]string)}
+}
+
+// RelPath returns filePath relative to the repository root at commit,
+// or empty if no suffix of it exists in that commit
+func (r *Resolver) RelPath(commit, filePath string) string {
+	key := commit + "\x00" + filePath
+	if rel, ok := r.cache[key]; ok {
+		return rel
+	}
+
+	rel := ""
+	for _, candidate := range pathSuffixes(filePath) {
+		if objectExists(commit + ":" + candidate) {
+			rel = candidate
+			break
+		}
+	}
+	r.cache[key] = rel
+	return rel
+}
+
+// Line returns the 1-based line at which anchor first appears in relPath
+// at commit, or 0 if it can't be found
+func (r *Resolver) Line(commit, relPath, anchor string) int {
+	anchor = strings.TrimSpace(anchor)
+	if anchor == "" {
+		return 0
+	}
+	content, err := git.RunGit("show", commit+":"+relPath)
+	if err != nil {
+		return 0
+	}
+	for i, line := range strings.Split(content, "\n") {
+		if strings.Contains(line, anchor) {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// pathSuffixes returns the suffixes of an absolute path, longest first:
+// /a/b/c.go -> a/b/c.go, b/c.go, c.go
+func pathSuffixes(filePath string) []string {
+	parts := strings.Split(strings.Trim(filepath.ToSlash(filePath), "/"), "/")
+	var suffixes []string
+	for i := range parts {
+		suffixes = append(suffixes, strings.Join(parts[i:], "/"))
+	}
+	return suffixes
+}
+
+// objectExists checks whether a <rev>:<path> object exists
+func objectExists(spec string) bool {
+	return exec.Command("git", "cat-file", "-e", spec).Run() == nil
+}
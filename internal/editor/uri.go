@@ -0,0 +1,193 @@
+package editor
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/config"
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+)
+
+// Scheme is the URI scheme handled by `git-prompt-story handle-uri`
+const Scheme = "git-prompt-story"
+
+// defaultEditorCommand opens a file at a line in VS Code
+const defaultEditorCommand = "code --goto {file}:{line}"
+
+// OpenRequest identifies a file location inside a repository
+type OpenRequest struct {
+	Repo   string // Repository directory name, used to find the local clone
+	Path   string // Path relative to the repository root
+	Line   int    // 1-based line number (0 = unknown)
+	Commit string // Commit the location was taken from (informational)
+}
+
+// BuildOpenURI returns a git-prompt-story://open link for req
+func BuildOpenURI(req OpenRequest) string {
+	q := url.Values{}
+	if req.Repo != "" {
+		q.Set("repo", req.Repo)
+	}
+	q.Set("path", req.Path)
+	if req.Line > 0 {
+		q.Set("line", strconv.Itoa(req.Line))
+	}
+	if req.Commit != "" {
+		q.Set("commit", req.Commit)
+	}
+	return Scheme + "://open?" + q.Encode()
+}
+
+// ParseOpenURI parses a git-prompt-story://open link. VS Code style
+// vscode://file/<absolute-path>:<line> links are accepted as well.
+func ParseOpenURI(raw string) (*OpenRequest, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URI: %w", err)
+	}
+
+	switch u.Scheme {
+	case Scheme:
+		if u.Host != "open" {
+			return nil, fmt.Errorf("unsupported action: %s", u.Host)
+		}
+		q := u.Query()
+		req := &OpenRequest{
+			Repo:   q.Get("repo"),
+			Path:   q.Get("path"),
+			Commit: q.Get("commit"),
+		}
+		if req.Path == "" {
+			return nil, fmt.Errorf("missing path in URI: %s", raw)
+		}
+		if strings.Contains(req.Path, "..") || filepath.IsAbs(req.Path) {
+			return nil, fmt.Errorf("path must be relative to the repository: %s", req.Path)
+		}
+		if l := q.Get("line"); l != "" {
+			if req.Line, err = strconv.Atoi(l); err != nil {
+				return nil, fmt.Errorf("invalid line: %s", l)
+			}
+		}
+		return req, nil
+
+	case "vscode":
+		if u.Host != "file" {
+			return nil, fmt.Errorf("unsupported vscode URI: %s", raw)
+		}
+		req := &OpenRequest{Path: u.Path}
+		if idx := strings.LastIndex(u.Path, ":"); idx != -1 {
+			if line, err := strconv.Atoi(u.Path[idx+1:]); err == nil {
+				req.Path = u.Path[:idx]
+				req.Line = line
+			}
+		}
+		return req, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported URI scheme: %s", u.Scheme)
+	}
+}
+
+// Open locates the requested file on disk and opens it in the editor
+// configured by prompt-story.editor (default: VS Code). The file must exist
+// inside the local clone: links come from transcripts and PR comments,
+// which anyone may have written.
+func Open(req *OpenRequest) error {
+	root, err := findLocalRepo(req.Repo)
+	if err != nil {
+		return err
+	}
+	file, err := localFile(root, req.Path)
+	if err != nil {
+		return err
+	}
+
+	line := req.Line
+	if line < 1 {
+		line = 1
+	}
+
+	command := config.Get("prompt-story.editor")
+	if command == "" {
+		command = defaultEditorCommand
+	}
+	args := editorArgs(command, file, line)
+	if len(args) == 0 {
+		return fmt.Errorf("prompt-story.editor is empty")
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to run editor %q: %w", args[0], err)
+	}
+	return nil
+}
+
+// localFile returns the file at path, relative to root or absolute, if it
+// exists inside root
+func localFile(root, path string) (string, error) {
+	file := path
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(root, path)
+	}
+	resolved, err := filepath.EvalSymlinks(file)
+	if err != nil {
+		return "", fmt.Errorf("file not found in %s: %s", root, path)
+	}
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(realRoot, resolved)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("path is outside the repository %s: %s", root, path)
+	}
+	return resolved, nil
+}
+
+// editorArgs splits the editor command template into arguments, then
+// substitutes {file} and {line} in each, so a file name cannot add
+// arguments of its own
+func editorArgs(template, file string, line int) []string {
+	args := strings.Fields(template)
+	for i, arg := range args {
+		arg = strings.ReplaceAll(arg, "{file}", file)
+		args[i] = strings.ReplaceAll(arg, "{line}", strconv.Itoa(line))
+	}
+	return args
+}
+
+// findLocalRepo returns the local clone for repo: the current repository
+// if it matches, otherwise <prompt-story.workspace>/<repo>
+func findLocalRepo(repo string) (string, error) {
+	if root, err := git.GetRepoRoot(); err == nil {
+		if repo == "" || filepath.Base(root) == repo {
+			return root, nil
+		}
+	}
+
+	if workspace := config.Get("prompt-story.workspace"); workspace != "" && repo != "" {
+		candidate := filepath.Join(expandHome(workspace), repo)
+		if info, err := os.Stat(candidate); err == nil && info.IsDir() {
+			return candidate, nil
+		}
+	}
+
+	return "", fmt.Errorf("local clone of %q not found (set git config --global prompt-story.workspace to the directory containing your clones)", repo)
+}
+
+// expandHome expands a leading ~ to the home directory
+func expandHome(path string) string {
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[2:])
+		}
+	}
+	return path
+}
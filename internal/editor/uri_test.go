@@ -0,0 +1,106 @@
+package editor
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestOpenURIRoundTrip(t *testing.T) {
+	req := OpenRequest{Repo: "my-app", Path: "internal/x y.go", Line: 42, Commit: "abc1234"}
+	uri := BuildOpenURI(req)
+
+	got, err := ParseOpenURI(uri)
+	if err != nil {
+		t.Fatalf("ParseOpenURI(%q) error: %v", uri, err)
+	}
+	if *got != req {
+		t.Errorf("round trip = %+v, want %+v", *got, req)
+	}
+}
+
+func TestParseOpenURI(t *testing.T) {
+	tests := []struct {
+		name    string
+		uri     string
+		want    *OpenRequest
+		wantErr bool
+	}{
+		{
+			name: "vscode file link",
+			uri:  "vscode://file/home/me/app/main.go:12",
+			want: &OpenRequest{Path: "/home/me/app/main.go", Line: 12},
+		},
+		{
+			name: "no line",
+			uri:  "git-prompt-story://open?path=main.go",
+			want: &OpenRequest{Path: "main.go"},
+		},
+		{name: "missing path", uri: "git-prompt-story://open?line=3", wantErr: true},
+		{name: "path escapes repo", uri: "git-prompt-story://open?path=../etc/passwd", wantErr: true},
+		{name: "absolute path", uri: "git-prompt-story://open?path=/etc/passwd", wantErr: true},
+		{name: "unknown action", uri: "git-prompt-story://delete?path=a", wantErr: true},
+		{name: "unknown scheme", uri: "https://example.com", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseOpenURI(tt.uri)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPathSuffixes(t *testing.T) {
+	got := pathSuffixes("/<REDACTED>/src/app/main.go")
+	want := []string{"<REDACTED>/src/app/main.go", "src/app/main.go", "app/main.go", "main.go"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("pathSuffixes() = %v, want %v", got, want)
+	}
+}
+
+func TestEditorArgs(t *testing.T) {
+	got := editorArgs("code --goto {file}:{line}", "/repo/README.md --install-extension evil.publisher", 7)
+	want := []string{"code", "--goto", "/repo/README.md --install-extension evil.publisher:7"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("editorArgs() = %q, want %q", got, want)
+	}
+}
+
+func TestLocalFile(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "main.go"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	outside := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(outside, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := localFile(root, "main.go"); err != nil {
+		t.Errorf("localFile(main.go) error: %v", err)
+	}
+	if _, err := localFile(root, filepath.Join(root, "main.go")); err != nil {
+		t.Errorf("localFile(absolute path in repo) error: %v", err)
+	}
+	for _, path := range []string{
+		"README.md --install-extension evil.publisher", // Does not exist
+		outside, // vscode://file links are absolute
+	} {
+		if got, err := localFile(root, path); err == nil {
+			t.Errorf("localFile(%q) = %q, want an error", path, got)
+		}
+	}
+}
@@ -28,6 +28,9 @@ func Explain(commitRef string, opts ExplainOptions, w io.Writer) error {
 
 	// Create trace context
 	trace := &session.TraceContext{}
+	if state, err := git.GetRepoState(); err == nil {
+		trace.RepoState = state
+	}
 
 	// For explain, we always simulate a normal (non-amend) commit
 	isAmend := false
@@ -50,20 +53,36 @@ func Explain(commitRef string, opts ExplainOptions, w io.Writer) error {
 
 	// Store work period trace
 	trace.WorkPeriod = session.WorkPeriodTrace{
-		IsAmend:             workTrace.IsAmend,
-		Ref:                 workTrace.Ref,
-		PrevCommitTimestamp: workTrace.PrevCommitTimestamp,
-		BranchSwitchTime:    workTrace.BranchSwitchTime,
-		CalculatedStart:     workTrace.CalculatedStart,
-		EndWork:             endWork,
-		Explanation:         workTrace.Explanation,
+		IsAmend:         workTrace.IsAmend,
+		Ref:             workTrace.Ref,
+		Candidates:      workTrace.Candidates,
+		CalculatedStart: workTrace.CalculatedStart,
+		Margin:          workTrace.Margin,
+		EndWork:         endWork,
+		ClockSkew:       session.ClockSkew(),
+		Explanation:     workTrace.Explanation,
 	}
 
+	// Sessions are searched in the period widened by the clock skew tolerance
+	startWork, endWork = session.WidenWorkPeriod(startWork, endWork, trace.WorkPeriod.ClockSkew)
+
 	// Discover sessions with tracing (includes time filtering)
-	sessions, err := session.FindSessions(repoRoot, startWork, endWork, trace)
-	if err != nil {
+	if _, err := session.LoadCustomProviders(repoRoot); err != nil {
 		fmt.Fprintf(w, "Warning: %v\n", err)
-		sessions = nil
+	}
+	var sessions []session.ClaudeSession
+	for _, p := range session.Providers() {
+		found, err := p.FindSessions(repoRoot, startWork, endWork, trace)
+		if err != nil {
+			fmt.Fprintf(w, "Warning: %s: %v\n", p.Name(), err)
+			continue
+		}
+		sessions = append(sessions, found...)
+	}
+	if imported, err := session.FindImportedSessions(startWork, endWork, nil); err != nil {
+		fmt.Fprintf(w, "Warning: imported sessions: %v\n", err)
+	} else {
+		sessions = append(sessions, imported...)
 	}
 
 	// Filter by user messages with tracing
@@ -80,6 +99,10 @@ func renderExplanation(trace *session.TraceContext, showAll bool, w io.Writer) e
 
 	// Session directory info
 	fmt.Fprintf(w, "Repository: %s\n", trace.RepoPath)
+	fmt.Fprintf(w, "State: %s\n", trace.RepoState)
+	if trace.RepoState.SkipsCapture() {
+		fmt.Fprintf(w, "  Commits made now are not captured; capture resumes when the %s finishes\n", trace.RepoState.Operation)
+	}
 
 	// Show candidate directories
 	if len(trace.CandidateDirs) > 0 {
@@ -114,17 +137,9 @@ func renderExplanation(trace *session.TraceContext, showAll bool, w io.Writer) e
 	fmt.Fprintln(w, "=== Work Period ===")
 	fmt.Fprintln(w)
 	fmt.Fprintf(w, "Reference: %s\n", trace.WorkPeriod.Ref)
-	if !trace.WorkPeriod.PrevCommitTimestamp.IsZero() {
-		fmt.Fprintf(w, "Previous commit: %s\n",
-			trace.WorkPeriod.PrevCommitTimestamp.Local().Format("2006-01-02 15:04:05"))
-	} else {
-		fmt.Fprintln(w, "Previous commit: (none - initial commit)")
-	}
-	if !trace.WorkPeriod.BranchSwitchTime.IsZero() {
-		fmt.Fprintf(w, "Branch switch: %s\n",
-			trace.WorkPeriod.BranchSwitchTime.Local().Format("2006-01-02 15:04:05"))
-	} else {
-		fmt.Fprintln(w, "Branch switch: (none found)")
+	fmt.Fprintln(w, "Candidates:")
+	for _, c := range trace.WorkPeriod.Candidates {
+		printCandidate(c, w)
 	}
 	fmt.Fprintf(w, "Result: %s\n", trace.WorkPeriod.Explanation)
 
@@ -135,6 +150,9 @@ func renderExplanation(trace *session.TraceContext, showAll bool, w io.Writer) e
 	fmt.Fprintf(w, "Work period: %s → %s\n",
 		startStr,
 		trace.WorkPeriod.EndWork.Local().Format("2006-01-02 15:04:05"))
+	if trace.WorkPeriod.ClockSkew > 0 {
+		fmt.Fprintf(w, "Clock skew tolerance: %s either side\n", trace.WorkPeriod.ClockSkew)
+	}
 	fmt.Fprintln(w)
 
 	if len(trace.Sessions) == 0 {
@@ -219,6 +237,9 @@ func printSessionDetails(s session.SessionTrace, w io.Writer) {
 		}
 		fmt.Fprintf(w, "  User messages: %s\n", msgInfo)
 	}
+	if s.TimestampRegressions > 0 {
+		fmt.Fprintf(w, "  Warning: %d timestamp(s) go back in time, kept in transcript order (clock skew?)\n", s.TimestampRegressions)
+	}
 
 	// Final decision with arrow indicator
 	if s.Included {
@@ -229,6 +250,30 @@ func printSessionDetails(s session.SessionTrace, w io.Writer) {
 	fmt.Fprintln(w)
 }
 
+// printCandidate writes one strategy's proposed start, marking the chosen
+// one and those disabled in prompt-story.workStart
+func printCandidate(c git.WorkStartCandidate, w io.Writer) {
+	marker := " "
+	if c.Chosen {
+		marker = "*"
+	}
+	when := "(none)"
+	if !c.Time.IsZero() {
+		when = c.Time.Local().Format("2006-01-02 15:04:05")
+	}
+	line := fmt.Sprintf("  %s %-16s %-19s", marker, c.Strategy, when)
+	switch {
+	case c.Error != "":
+		line += "  error: " + c.Error
+	case c.Detail != "":
+		line += "  " + c.Detail
+	}
+	if !c.Enabled {
+		line += " (disabled)"
+	}
+	fmt.Fprintln(w, strings.TrimRight(line, " "))
+}
+
 func printSummary(trace *session.TraceContext, w io.Writer) {
 	included := 0
 	for _, s := range trace.Sessions {
@@ -240,4 +285,14 @@ func printSummary(trace *session.TraceContext, w io.Writer) {
 	fmt.Fprintln(w, "=== Summary ===")
 	fmt.Fprintf(w, "Found: %d session(s)\n", len(trace.Sessions))
 	fmt.Fprintf(w, "Included: %d session(s)\n", included)
+
+	skewed := 0
+	for _, s := range trace.Sessions {
+		if s.TimestampRegressions > 0 {
+			skewed++
+		}
+	}
+	if skewed > 0 {
+		fmt.Fprintf(w, "Warning: %d session(s) with timestamps going back in time; if entries are missing, set prompt-story.clockSkew\n", skewed)
+	}
 }
@@ -0,0 +1,282 @@
+// Package gc enforces the transcript retention window: transcripts of old
+// commits are removed while their notes are kept as a summary record.
+package gc
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+	"github.com/QuesmaOrg/git-prompt-story/internal/note"
+)
+
+// Plan lists what a gc run removes
+type Plan struct {
+	// Transcripts are paths (tool/id.jsonl) in the transcripts tree
+	Transcripts []string
+
+	// Commits are the annotated commits whose notes get marked expired
+	Commits []string
+
+	// Held are expired transcripts kept because a note on legal hold
+	// references them
+	Held []string
+}
+
+// Empty reports whether there is nothing to collect
+func (p *Plan) Empty() bool {
+	return len(p.Transcripts) == 0
+}
+
+// BuildPlan selects transcripts whose every referencing commit is older
+// than cutoff. A session shared with a newer commit is kept in full, and so
+// is one referenced by a held note unless overrideHold is set.
+func BuildPlan(commits []note.ListedCommit, cutoff time.Time, overrideHold bool) *Plan {
+	newest := make(map[string]time.Time)
+	live := make(map[string]bool) // Referenced by a session not yet expired
+	for _, c := range commits {
+		for _, s := range c.Note.Sessions {
+			p := s.TranscriptPath()
+			if c.Date.After(newest[p]) {
+				newest[p] = c.Date
+			}
+			if s.Expired == nil {
+				live[p] = true
+			}
+		}
+	}
+
+	plan := &Plan{}
+	expire := make(map[string]bool)
+	for p, date := range newest {
+		if !live[p] || !date.Before(cutoff) {
+			continue
+		}
+		if !overrideHold && len(note.HeldReferences(commits, []string{p})) > 0 {
+			plan.Held = append(plan.Held, p)
+			continue
+		}
+		expire[p] = true
+	}
+	sort.Strings(plan.Held)
+
+	for _, c := range commits {
+		for _, s := range c.Note.Sessions {
+			if s.Expired == nil && expire[s.TranscriptPath()] {
+				plan.Commits = append(plan.Commits, c.SHA)
+				break
+			}
+		}
+	}
+	for p := range expire {
+		plan.Transcripts = append(plan.Transcripts, p)
+	}
+	sort.Strings(plan.Transcripts)
+	return plan
+}
+
+// Collect removes transcripts of noted commits older than maxAge. With
+// dryRun nothing is changed and the plan is only returned. Overriding a
+// legal hold is recorded in the held notes' audit trail.
+func Collect(maxAge time.Duration, dryRun, overrideHold bool) (*Plan, error) {
+	commits, err := listNotedCommits()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	plan := BuildPlan(commits, now.Add(-maxAge), overrideHold)
+	if dryRun || plan.Empty() {
+		return plan, nil
+	}
+
+	if err := removeTranscripts(plan.Transcripts); err != nil {
+		return nil, fmt.Errorf("failed to update transcript tree: %w", err)
+	}
+	if err := markExpired(commits, plan, now.UTC()); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// listNotedCommits returns every commit with a prompt-story note: legacy
+// notes and notes on commits no ref reaches included, since a transcript
+// any of them references must not be expired for the others
+func listNotedCommits() ([]note.ListedCommit, error) {
+	notes, err := readNotes(note.NotesRef)
+	if err != nil {
+		return nil, err
+	}
+	legacy, err := readNotes(note.LegacyNotesRef)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := parseNotes(notes, legacy)
+	if err != nil || len(parsed) == 0 {
+		return nil, err
+	}
+
+	out, err := git.RunGit("log", "--all", "--format=%H%x00%ct%x00%s")
+	if err != nil {
+		return nil, fmt.Errorf("git log: %w", err)
+	}
+	var commits []note.ListedCommit
+	add := func(line string) {
+		fields := strings.SplitN(line, "\x00", 3)
+		if len(fields) != 3 || parsed[fields[0]] == nil {
+			return
+		}
+		unix, _ := strconv.ParseInt(fields[1], 10, 64)
+		commits = append(commits, note.ListedCommit{SHA: fields[0], Subject: fields[2], Date: time.Unix(unix, 0), Note: parsed[fields[0]]})
+		delete(parsed, fields[0])
+	}
+	for _, line := range strings.Split(out, "\n") {
+		add(line)
+	}
+	for sha := range parsed {
+		// Not reachable from any ref; the commit may be gone altogether
+		if line, err := git.RunGit("log", "-1", "--format=%H%x00%ct%x00%s", sha); err == nil {
+			add(line)
+		}
+	}
+	return commits, nil
+}
+
+// readNotes returns the content of the notes under ref by annotated commit
+func readNotes(ref string) (map[string]string, error) {
+	blobs, err := note.ListNoteBlobs(ref)
+	if err != nil {
+		return nil, err
+	}
+	notes := make(map[string]string, len(blobs))
+	for sha, blob := range blobs {
+		content, err := git.RunGit("cat-file", "-p", blob)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read note on %s: %w", sha[:7], err)
+		}
+		notes[sha] = content
+	}
+	return notes, nil
+}
+
+// parseNotes parses the notes under note.NotesRef, in the current or the
+// legacy format, and the prompt-story notes among the legacy ones, by
+// annotated commit. A note under note.NotesRef that cannot be parsed is an
+// error: gc cannot tell which transcripts it references. Other tools' notes
+// under git's default ref are skipped.
+func parseNotes(notes, legacy map[string]string) (map[string]*note.PromptStoryNote, error) {
+	parsed := make(map[string]*note.PromptStoryNote, len(notes)+len(legacy))
+	for sha, content := range legacy {
+		if n, _, err := note.ParseAnyNote([]byte(content)); err == nil {
+			parsed[sha] = n
+		}
+	}
+	for sha, content := range notes {
+		n, _, err := note.ParseAnyNote([]byte(content))
+		if err != nil {
+			return nil, fmt.Errorf("note on %s cannot be read, fix or remove it before running gc: %w", sha[:7], err)
+		}
+		parsed[sha] = n
+	}
+	return parsed, nil
+}
+
+// removeTranscripts drops paths from the transcripts tree in a single
+// update, leaving tool subtrees that become empty out
+func removeTranscripts(paths []string) error {
+	rootSHA, err := git.GetRef(note.TranscriptsRef)
+	if err != nil || rootSHA == "" {
+		return nil // Nothing stored locally
+	}
+	rootEntries, err := git.ReadTree(rootSHA)
+	if err != nil {
+		return err
+	}
+
+	byTool := make(map[string]map[string]bool)
+	for _, p := range paths {
+		tool, name, ok := strings.Cut(p, "/")
+		if !ok {
+			continue
+		}
+		if byTool[tool] == nil {
+			byTool[tool] = make(map[string]bool)
+		}
+		byTool[tool][name] = true
+	}
+
+	var newRoot []git.TreeEntry
+	for _, entry := range rootEntries {
+		remove := byTool[entry.Name]
+		if entry.Type != "tree" || remove == nil {
+			newRoot = append(newRoot, entry)
+			continue
+		}
+
+		toolEntries, err := git.ReadTree(entry.SHA)
+		if err != nil {
+			return err
+		}
+		var kept []git.TreeEntry
+		for _, e := range toolEntries {
+			if !remove[e.Name] {
+				kept = append(kept, e)
+			}
+		}
+		if len(kept) == 0 {
+			continue
+		}
+		if len(kept) != len(toolEntries) {
+			if entry.SHA, err = git.CreateTree(kept); err != nil {
+				return err
+			}
+		}
+		newRoot = append(newRoot, entry)
+	}
+
+	newRootSHA, err := git.CreateTree(newRoot)
+	if err != nil {
+		return err
+	}
+	return git.UpdateRef(note.TranscriptsRef, newRootSHA)
+}
+
+// markExpired rewrites the notes in plan.Commits, stamping sessions whose
+// transcript was removed
+func markExpired(commits []note.ListedCommit, plan *Plan, at time.Time) error {
+	removed := make(map[string]bool, len(plan.Transcripts))
+	for _, p := range plan.Transcripts {
+		removed[p] = true
+	}
+	update := make(map[string]bool, len(plan.Commits))
+	for _, sha := range plan.Commits {
+		update[sha] = true
+	}
+
+	for _, c := range commits {
+		if !update[c.SHA] {
+			continue
+		}
+		var expired []string
+		for i, s := range c.Note.Sessions {
+			if s.Expired == nil && removed[s.TranscriptPath()] {
+				c.Note.Sessions[i].Expired = &at
+				expired = append(expired, s.TranscriptPath())
+			}
+		}
+		if c.Note.OnHold() {
+			c.Note.RecordOverride("gc", strings.Join(expired, ", "))
+		}
+		data, err := c.Note.ToJSON()
+		if err != nil {
+			return fmt.Errorf("failed to serialize note for %s: %w", c.SHA[:7], err)
+		}
+		if err := git.AddNote(note.NotesRef, string(data), c.SHA); err != nil {
+			return fmt.Errorf("failed to update note for %s: %w", c.SHA[:7], err)
+		}
+	}
+	return nil
+}
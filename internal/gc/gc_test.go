@@ -0,0 +1,99 @@
+package gc
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/note"
+)
+
+func listed(sha string, date time.Time, sessions ...note.SessionEntry) note.ListedCommit {
+	return note.ListedCommit{SHA: sha, Date: date, Note: &note.PromptStoryNote{Version: 1, Sessions: sessions}}
+}
+
+func TestBuildPlan(t *testing.T) {
+	cutoff := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	old := cutoff.AddDate(0, -2, 0)
+	recent := cutoff.AddDate(0, 1, 0)
+
+	s1 := note.SessionEntry{Tool: "claude-code", ID: "s1", Path: note.TranscriptsRef + "/claude-code/s1.jsonl"}
+	s2 := note.SessionEntry{Tool: "claude-code", ID: "s2", Path: note.TranscriptsRef + "/claude-code/s2.jsonl"}
+	s3 := note.SessionEntry{Tool: "cursor", ID: "s3"}
+
+	commits := []note.ListedCommit{
+		listed("c3", recent, s2),
+		listed("c2", old, s1, s2),
+		listed("c1", old, s3),
+	}
+
+	plan := BuildPlan(commits, cutoff, false)
+
+	// s2 is still referenced by a recent commit and must be kept
+	wantTranscripts := []string{"claude-code/s1.jsonl", "cursor/s3.jsonl"}
+	if !reflect.DeepEqual(plan.Transcripts, wantTranscripts) {
+		t.Errorf("Transcripts = %v, want %v", plan.Transcripts, wantTranscripts)
+	}
+	wantCommits := []string{"c2", "c1"}
+	if !reflect.DeepEqual(plan.Commits, wantCommits) {
+		t.Errorf("Commits = %v, want %v", plan.Commits, wantCommits)
+	}
+}
+
+func TestBuildPlan_AlreadyExpired(t *testing.T) {
+	cutoff := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	expired := cutoff.AddDate(0, -1, 0)
+	s1 := note.SessionEntry{Tool: "claude-code", ID: "s1", Expired: &expired}
+
+	plan := BuildPlan([]note.ListedCommit{listed("c1", cutoff.AddDate(-1, 0, 0), s1)}, cutoff, false)
+	if !plan.Empty() || len(plan.Commits) != 0 {
+		t.Errorf("expected empty plan, got %+v", plan)
+	}
+}
+
+func TestBuildPlan_Hold(t *testing.T) {
+	cutoff := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	s1 := note.SessionEntry{Tool: "claude-code", ID: "s1"}
+	c := listed("c1", cutoff.AddDate(-1, 0, 0), s1)
+	c.Note.Hold = &note.Hold{Reason: "case 42", By: "legal", At: cutoff}
+
+	plan := BuildPlan([]note.ListedCommit{c}, cutoff, false)
+	if !plan.Empty() || !reflect.DeepEqual(plan.Held, []string{"claude-code/s1.jsonl"}) {
+		t.Errorf("expected held transcript to be kept, got %+v", plan)
+	}
+
+	plan = BuildPlan([]note.ListedCommit{c}, cutoff, true)
+	if plan.Empty() || len(plan.Held) != 0 {
+		t.Errorf("expected override to collect held transcript, got %+v", plan)
+	}
+}
+
+func TestParseNotes(t *testing.T) {
+	current := `{"v":1,"sessions":[{"tool":"claude-code","id":"s1"}]}`
+	yamlNote := "sessions:\n  - tool: cursor\n    id: c1\n"
+	notes := map[string]string{"aaaaaaa1": current, "bbbbbbb2": yamlNote}
+	legacy := map[string]string{
+		"bbbbbbb2": "sessions:\n  - tool: codex\n    id: x1\n", // Superseded by the current ref
+		"ccccccc3": "sessions:\n  - tool: codex\n    id: x2\n",
+		"ddddddd4": "Reviewed-by: someone", // Not a prompt-story note
+	}
+
+	parsed, err := parseNotes(notes, legacy)
+	if err != nil {
+		t.Fatalf("parseNotes() error: %v", err)
+	}
+	got := map[string]string{}
+	for sha, n := range parsed {
+		got[sha] = n.Sessions[0].TranscriptPath()
+	}
+	want := map[string]string{"aaaaaaa1": "claude-code/s1.jsonl", "bbbbbbb2": "cursor/c1.jsonl", "ccccccc3": "codex/x2.jsonl"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseNotes() = %v, want %v", got, want)
+	}
+
+	// A note that cannot be read may reference any transcript
+	notes["eeeeeee5"] = "{not json"
+	if _, err := parseNotes(notes, legacy); err == nil {
+		t.Error("parseNotes() with an unreadable note: want an error")
+	}
+}
@@ -0,0 +1,213 @@
+package git
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// CommitSet composes commit specs: the commits of any In spec that are in
+// none of the Not specs. Specs are those of ResolveCommitSpec. A Not spec
+// naming refs, a ref or a glob such as release/*, excludes the commits
+// reachable from them and those whose changes were cherry-picked onto them.
+type CommitSet struct {
+	In  []string
+	Not []string
+
+	// Reachable makes a ref among the In specs stand for every commit
+	// reachable from it, as git log takes it, instead of the one commit
+	Reachable bool
+}
+
+// ReadCommitSet reads specs one per line, e.g. from stdin: lines starting
+// with ^ are Not specs, blank lines and lines starting with # are skipped
+func ReadCommitSet(r io.Reader) (CommitSet, error) {
+	var set CommitSet
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+		case strings.HasPrefix(line, "^"):
+			set.Not = append(set.Not, strings.TrimSpace(line[1:]))
+		default:
+			set.In = append(set.In, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return set, fmt.Errorf("failed to read commit specs: %w", err)
+	}
+	return set, nil
+}
+
+// Resolve returns the commits of the set, in the order of the In specs,
+// each newest first
+func (s CommitSet) Resolve() ([]string, error) {
+	resolve := ResolveCommitSpec
+	if s.Reachable {
+		resolve = resolveReachable
+	}
+	in, err := resolveCommitList(s.In, resolve)
+	if err != nil {
+		return nil, err
+	}
+	if len(s.Not) == 0 {
+		return in, nil
+	}
+
+	excluded := make(map[string]bool)
+	var tips []string
+	for _, spec := range s.Not {
+		if strings.Contains(spec, "..") || strings.Contains(spec, ",") {
+			shas, err := ResolveCommitSpec(spec)
+			if err != nil {
+				return nil, err
+			}
+			for _, sha := range shas {
+				excluded[sha] = true
+			}
+			continue
+		}
+		refs, err := resolveRefs(spec)
+		if err != nil {
+			return nil, err
+		}
+		tips = append(tips, refs...)
+	}
+	if len(tips) > 0 {
+		if err := excludeOnRefs(in, tips, excluded); err != nil {
+			return nil, err
+		}
+	}
+
+	var commits []string
+	for _, sha := range in {
+		if !excluded[sha] {
+			commits = append(commits, sha)
+		}
+	}
+	if len(commits) == 0 {
+		return nil, fmt.Errorf("no commits left in the set")
+	}
+	return commits, nil
+}
+
+// resolveReachable resolves a spec like ResolveCommitSpec, but a ref to
+// the commits reachable from it
+func resolveReachable(spec string) ([]string, error) {
+	if strings.Contains(spec, "..") || strings.Contains(spec, ",") {
+		return ResolveCommitSpec(spec)
+	}
+	commits, err := RevList(spec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", spec, err)
+	}
+	return commits, nil
+}
+
+// resolveRefs returns the commits a ref names, or those of the branches,
+// remote-tracking branches and tags matching a glob
+func resolveRefs(spec string) ([]string, error) {
+	if !strings.ContainsAny(spec, "*?[") {
+		sha, err := ResolveCommit(spec)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %s: %w", spec, err)
+		}
+		return []string{sha}, nil
+	}
+	out, err := RunGit("for-each-ref", "--format=%(objectname)",
+		"refs/heads/"+spec, "refs/remotes/"+spec, "refs/tags/"+spec)
+	if err != nil {
+		return nil, fmt.Errorf("git for-each-ref %s: %w", spec, err)
+	}
+	if out == "" {
+		return nil, fmt.Errorf("no refs match %s", spec)
+	}
+	return strings.Split(out, "\n"), nil
+}
+
+// excludeOnRefs marks the commits of in that are reachable from tips, or
+// whose patch is that of a commit reachable from tips only
+func excludeOnRefs(in, tips []string, excluded map[string]bool) error {
+	var input strings.Builder
+	for _, sha := range in {
+		fmt.Fprintln(&input, sha)
+	}
+	for _, tip := range tips {
+		fmt.Fprintln(&input, "^"+tip)
+	}
+	out, err := RunGitInput(input.String(), "rev-list", "--stdin")
+	if err != nil {
+		return fmt.Errorf("git rev-list: %w", err)
+	}
+	notOnRefs := make(map[string]bool)
+	for _, sha := range strings.Fields(out) {
+		notOnRefs[sha] = true
+	}
+	var rest []string
+	for _, sha := range in {
+		if notOnRefs[sha] {
+			rest = append(rest, sha)
+		} else {
+			excluded[sha] = true
+		}
+	}
+	if len(rest) == 0 {
+		return nil
+	}
+
+	// Commits of the refs the set does not reach, cherry-picks among them
+	input.Reset()
+	for _, tip := range tips {
+		fmt.Fprintln(&input, tip)
+	}
+	for _, sha := range in {
+		fmt.Fprintln(&input, "^"+sha)
+	}
+	out, err = RunGitInput(input.String(), "rev-list", "--no-merges", "--stdin")
+	if err != nil {
+		return fmt.Errorf("git rev-list: %w", err)
+	}
+	if out == "" {
+		return nil
+	}
+	onRefs, err := patchIDs(strings.Fields(out))
+	if err != nil {
+		return err
+	}
+	picked := make(map[string]bool, len(onRefs))
+	for _, id := range onRefs {
+		picked[id] = true
+	}
+	ids, err := patchIDs(rest)
+	if err != nil {
+		return err
+	}
+	for sha, id := range ids {
+		if picked[id] {
+			excluded[sha] = true
+		}
+	}
+	return nil
+}
+
+// patchIDs maps the commits to their stable patch IDs, which cherry-picks
+// share with their originals; merges and empty commits have none
+func patchIDs(shas []string) (map[string]string, error) {
+	patches, err := RunGitInput(strings.Join(shas, "\n"), "log", "--no-walk=unsorted", "--stdin", "-p", "--format=commit %H")
+	if err != nil {
+		return nil, fmt.Errorf("git log: %w", err)
+	}
+	out, err := RunGitInput(patches+"\n", "patch-id", "--stable")
+	if err != nil {
+		return nil, fmt.Errorf("git patch-id: %w", err)
+	}
+	ids := make(map[string]string)
+	for _, line := range strings.Split(out, "\n") {
+		if id, sha, ok := strings.Cut(line, " "); ok {
+			ids[sha] = id
+		}
+	}
+	return ids, nil
+}
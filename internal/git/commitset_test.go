@@ -0,0 +1,105 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadCommitSet(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  CommitSet
+	}{
+		{
+			name:  "in and not specs",
+			input: "main..feature\n^release/*\nabc1234\n",
+			want:  CommitSet{In: []string{"main..feature", "abc1234"}, Not: []string{"release/*"}},
+		},
+		{
+			name:  "comments, blank lines and spaces",
+			input: "# from the release script\n\n  v1.2.0..v1.3.0  \n^ v1.2.1\n\t\n#^ignored\n",
+			want:  CommitSet{In: []string{"v1.2.0..v1.3.0"}, Not: []string{"v1.2.1"}},
+		},
+		{
+			name:  "no final newline",
+			input: "^main\nHEAD",
+			want:  CommitSet{In: []string{"HEAD"}, Not: []string{"main"}},
+		},
+		{
+			name:  "empty",
+			input: "",
+			want:  CommitSet{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadCommitSet(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("ReadCommitSet() error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReadCommitSet() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommitSetExcludesCherryPicks(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Chdir(t.TempDir())
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	for _, kv := range []string{"GIT_AUTHOR_NAME=A", "GIT_AUTHOR_EMAIL=a@example.com", "GIT_COMMITTER_NAME=A", "GIT_COMMITTER_EMAIL=a@example.com"} {
+		k, v, _ := strings.Cut(kv, "=")
+		t.Setenv(k, v)
+	}
+
+	run := func(args ...string) string {
+		t.Helper()
+		out, err := RunGit(args...)
+		if err != nil {
+			t.Fatalf("git %v: %v", args, err)
+		}
+		return out
+	}
+	commit := func(file string) string {
+		t.Helper()
+		if err := os.WriteFile(file, []byte(file+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		run("add", file)
+		run("commit", "-q", "-m", file)
+		return run("rev-parse", "HEAD")
+	}
+
+	run("init", "-q", "-b", "main")
+	commit("base")
+	run("checkout", "-q", "-b", "feature")
+	kept := commit("kept")
+	picked := commit("picked")
+	run("checkout", "-q", "-b", "release/1.0", "main")
+	run("cherry-pick", picked)
+
+	got, err := CommitSet{In: []string{"main..feature"}, Not: []string{"release/*"}}.Resolve()
+	if err != nil {
+		t.Fatalf("Resolve() error: %v", err)
+	}
+	if want := []string{kept}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Resolve() = %v, want only %s (%s was cherry-picked to release/1.0)", got, kept, picked)
+	}
+
+	// A range among the Not specs only leaves out its own commits
+	got, err = CommitSet{In: []string{"main..feature"}, Not: []string{"main..feature~1"}}.Resolve()
+	if err != nil {
+		t.Fatalf("Resolve() error: %v", err)
+	}
+	if want := []string{picked}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Resolve() with a Not range = %v, want %v", got, want)
+	}
+}
@@ -88,6 +88,16 @@ func GetBlobContent(ref, path string) ([]byte, error) {
 	return out, nil
 }
 
+// ReadBlob returns the content of a blob by SHA
+func ReadBlob(sha string) ([]byte, error) {
+	cmd := exec.Command("git", "cat-file", "blob", sha)
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git cat-file blob %s: %w", sha, err)
+	}
+	return out, nil
+}
+
 // ResolveCommit resolves a commit reference (HEAD, hash, etc.) to full SHA
 func ResolveCommit(ref string) (string, error) {
 	cmd := exec.Command("git", "rev-parse", ref)
@@ -117,9 +127,16 @@ func RevList(rangeSpec string) ([]string, error) {
 }
 
 // ResolveCommitSpec resolves a commit specification to a list of commit SHAs.
-// Supports: single ref (HEAD, abc123), ranges (A..B)
+// Supports: single ref (HEAD, abc123), ranges (A..B), and comma-separated
+// lists of either (abc123,def456)
 // Returns commits in reverse chronological order (newest first)
 func ResolveCommitSpec(spec string) ([]string, error) {
+	if strings.Contains(spec, ",") {
+		if _, err := ResolveCommit(spec); err != nil {
+			return resolveCommitList(strings.Split(spec, ","), ResolveCommitSpec)
+		}
+	}
+
 	// Check for range (contains ..)
 	if strings.Contains(spec, "..") {
 		commits, err := RevList(spec)
@@ -140,3 +157,30 @@ func ResolveCommitSpec(spec string) ([]string, error) {
 	return []string{sha}, nil
 }
 
+// resolveCommitList resolves each spec in a list with resolve, dropping
+// duplicates and keeping the order given
+func resolveCommitList(specs []string, resolve func(string) ([]string, error)) ([]string, error) {
+	seen := make(map[string]bool)
+	var commits []string
+	for _, spec := range specs {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+		shas, err := resolve(spec)
+		if err != nil {
+			return nil, err
+		}
+		for _, sha := range shas {
+			if !seen[sha] {
+				seen[sha] = true
+				commits = append(commits, sha)
+			}
+		}
+	}
+	if len(commits) == 0 {
+		return nil, fmt.Errorf("no commits in list")
+	}
+	return commits, nil
+}
+
@@ -1,7 +1,9 @@
 package git
 
 import (
+	"fmt"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"time"
 )
@@ -26,6 +28,34 @@ func GetGitDir() (string, error) {
 	return strings.TrimSpace(string(out)), nil
 }
 
+// IsBareRepository reports whether the current repository (found from the
+// working directory or GIT_DIR) has no working tree, as on a hosting server
+func IsBareRepository() bool {
+	cmd := exec.Command("git", "rev-parse", "--is-bare-repository")
+	out, err := cmd.Output()
+	if err != nil {
+		return false
+	}
+	return strings.TrimSpace(string(out)) == "true"
+}
+
+// GetRepoName returns the directory name of the repository: the root of
+// the working tree, or for a bare repository its git directory without a
+// ".git" suffix. Inside a .git directory, it is the name of its parent.
+func GetRepoName() (string, error) {
+	if root, err := GetRepoRoot(); err == nil && root != "" {
+		return filepath.Base(root), nil
+	}
+	out, err := RunGit("rev-parse", "--absolute-git-dir")
+	if err != nil {
+		return "", err
+	}
+	if filepath.Base(out) == ".git" {
+		return filepath.Base(filepath.Dir(out)), nil
+	}
+	return strings.TrimSuffix(filepath.Base(out), ".git"), nil
+}
+
 // IsInsideWorkTree checks if we're in a git repository
 func IsInsideWorkTree() bool {
 	cmd := exec.Command("git", "rev-parse", "--is-inside-work-tree")
@@ -76,6 +106,53 @@ func GetCurrentBranch() (string, error) {
 	return strings.TrimSpace(string(out)), nil
 }
 
+// DefaultBranch returns the ref of the repository's default branch: the
+// branch origin/HEAD points at, else the first of origin/main,
+// origin/master, main and master that exists
+func DefaultBranch() (string, error) {
+	if ref, err := RunGit("symbolic-ref", "--quiet", "--short", "refs/remotes/origin/HEAD"); err == nil && ref != "" {
+		return ref, nil
+	}
+	for _, ref := range []string{"origin/main", "origin/master", "main", "master"} {
+		if _, err := RunGit("rev-parse", "--verify", "--quiet", ref+"^{commit}"); err == nil {
+			return ref, nil
+		}
+	}
+	return "", fmt.Errorf("cannot detect the default branch (set origin/HEAD with: git remote set-head origin --auto)")
+}
+
+// Upstream returns the upstream (tracking) branch of a local branch, e.g.
+// origin/main, or "" when it has none
+func Upstream(branch string) string {
+	out, err := RunGit("rev-parse", "--abbrev-ref", "--symbolic-full-name", branch+"@{upstream}")
+	if err != nil {
+		return ""
+	}
+	return out
+}
+
+// MergeBase returns the best common ancestor of two commits
+func MergeBase(a, b string) (string, error) {
+	out, err := RunGit("merge-base", a, b)
+	if err != nil {
+		return "", fmt.Errorf("no merge base of %s and %s", a, b)
+	}
+	return out, nil
+}
+
+// CountDirtyFiles returns how many files have staged, unstaged or
+// untracked changes
+func CountDirtyFiles() (int, error) {
+	out, err := RunGit("status", "--porcelain")
+	if err != nil {
+		return 0, err
+	}
+	if out == "" {
+		return 0, nil
+	}
+	return strings.Count(out, "\n") + 1, nil
+}
+
 // RunGit executes a git command and returns the output
 func RunGit(args ...string) (string, error) {
 	cmd := exec.Command("git", args...)
@@ -85,3 +162,70 @@ func RunGit(args ...string) (string, error) {
 	}
 	return strings.TrimSpace(string(out)), nil
 }
+
+// RunGitInput executes a git command with input on its stdin and returns
+// the output
+func RunGitInput(input string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Stdin = strings.NewReader(input)
+	out, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// GetAuthorIdent returns the author identity ("Name <email>") git would use
+// for a commit made now, honoring GIT_AUTHOR_* overrides
+func GetAuthorIdent() (string, error) {
+	out, err := RunGit("var", "GIT_AUTHOR_IDENT")
+	if err != nil {
+		return "", err
+	}
+	// Drop the trailing "<unix-time> <tz>"
+	if idx := strings.LastIndex(out, ">"); idx != -1 {
+		out = out[:idx+1]
+	}
+	return out, nil
+}
+
+// FileCommit is a commit that changed a file, see FileLog
+type FileCommit struct {
+	SHA     string
+	Author  string
+	Date    time.Time
+	Subject string
+	Path    string // The file's path in the commit, which renames change
+}
+
+// FileLog returns the commits that changed path, relative to the
+// repository root, following renames, oldest first
+func FileLog(path string) ([]FileCommit, error) {
+	out, err := RunGit("log", "--follow", "--name-only", "--format=%x1e%H%x1f%an%x1f%aI%x1f%s", "--", ":(top)"+path)
+	if err != nil {
+		return nil, fmt.Errorf("git log %s: %w", path, err)
+	}
+
+	var commits []FileCommit
+	for _, record := range strings.Split(out, "\x1e") {
+		lines := strings.Split(strings.TrimSpace(record), "\n")
+		fields := strings.SplitN(lines[0], "\x1f", 4)
+		if len(fields) != 4 {
+			continue
+		}
+		c := FileCommit{SHA: fields[0], Author: fields[1], Subject: fields[3], Path: path}
+		c.Date, _ = time.Parse(time.RFC3339, fields[2])
+		for _, line := range lines[1:] {
+			if line = strings.TrimSpace(line); line != "" {
+				c.Path = line
+			}
+		}
+		commits = append(commits, c)
+	}
+
+	// git log lists newest first
+	for i, j := 0, len(commits)-1; i < j; i, j = i+1, j-1 {
+		commits[i], commits[j] = commits[j], commits[i]
+	}
+	return commits, nil
+}
@@ -0,0 +1,60 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBareRepository(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	for _, kv := range []string{"GIT_AUTHOR_NAME=A", "GIT_AUTHOR_EMAIL=a@example.com", "GIT_COMMITTER_NAME=A", "GIT_COMMITTER_EMAIL=a@example.com"} {
+		k, v, _ := strings.Cut(kv, "=")
+		t.Setenv(k, v)
+	}
+	run := func(args ...string) {
+		t.Helper()
+		if _, err := RunGit(args...); err != nil {
+			t.Fatalf("git %v: %v", args, err)
+		}
+	}
+
+	work := filepath.Join(dir, "project")
+	run("init", "-q", "-b", "main", work)
+	run("-C", work, "commit", "-q", "--allow-empty", "-m", "base")
+	run("clone", "-q", "--bare", work, filepath.Join(dir, "project.git"))
+
+	tests := []struct {
+		dir      string
+		wantBare bool
+	}{
+		{"project", false},
+		{filepath.Join("project", ".git"), false},
+		{"project.git", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dir, func(t *testing.T) {
+			t.Chdir(filepath.Join(dir, tt.dir))
+			if got := IsBareRepository(); got != tt.wantBare {
+				t.Errorf("IsBareRepository() = %v, want %v", got, tt.wantBare)
+			}
+			if name, err := GetRepoName(); err != nil || name != "project" {
+				t.Errorf("GetRepoName() = %q, %v; want project", name, err)
+			}
+		})
+	}
+
+	t.Chdir(dir)
+	if IsBareRepository() {
+		t.Error("IsBareRepository() outside a repository = true")
+	}
+	if name, err := GetRepoName(); err == nil {
+		t.Errorf("GetRepoName() outside a repository = %q, want an error", name)
+	}
+}
@@ -0,0 +1,107 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Operations git can be in the middle of, see GetRepoState
+const (
+	OpRebase     = "rebase"
+	OpAm         = "am"
+	OpCherryPick = "cherry-pick"
+	OpRevert     = "revert"
+	OpBisect     = "bisect"
+	OpMerge      = "merge"
+)
+
+// RepoState is what the repository is in the middle of, read from the
+// files git keeps in the git directory while an operation runs
+type RepoState struct {
+	Operation string // One of the Op constants, "" when none
+	Sequence  bool   // Several commits are being replayed (cherry-pick or revert of a range)
+	Detached  bool   // HEAD is not on a branch
+}
+
+// GetRepoState inspects the git directory of the current worktree
+func GetRepoState() (RepoState, error) {
+	gitDir, err := GetGitDir()
+	if err != nil {
+		return RepoState{}, err
+	}
+	_, err = RunGit("symbolic-ref", "--quiet", "HEAD")
+	return repoStateIn(gitDir, err != nil), nil
+}
+
+// repoStateIn reads the operation in progress from gitDir
+func repoStateIn(gitDir string, detached bool) RepoState {
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(gitDir, name))
+		return err == nil
+	}
+
+	state := RepoState{Detached: detached}
+	switch {
+	case exists("rebase-merge"):
+		state.Operation = OpRebase
+	case exists("rebase-apply"):
+		// git am uses the same directory, marked by an "applying" file
+		state.Operation = OpRebase
+		if exists(filepath.Join("rebase-apply", "applying")) {
+			state.Operation = OpAm
+		}
+	case exists("BISECT_LOG"):
+		state.Operation = OpBisect
+	case exists("CHERRY_PICK_HEAD"):
+		state.Operation = OpCherryPick
+	case exists("REVERT_HEAD"):
+		state.Operation = OpRevert
+	case exists("MERGE_HEAD"):
+		state.Operation = OpMerge
+	}
+	if exists(filepath.Join("sequencer", "todo")) {
+		state.Sequence = true
+		if state.Operation == "" {
+			// Between picks of a range, nothing else marks the sequence
+			state.Operation = OpCherryPick
+			if todo, err := os.ReadFile(filepath.Join(gitDir, "sequencer", "todo")); err == nil && strings.HasPrefix(string(todo), "revert") {
+				state.Operation = OpRevert
+			}
+		}
+	}
+	return state
+}
+
+// SkipsCapture reports whether commits made now replay or test existing
+// work rather than record new work: rebases, git am, bisecting and
+// cherry-picking or reverting a range. Their notes are carried over by
+// post-rewrite or belong to the original commits.
+func (s RepoState) SkipsCapture() bool {
+	switch s.Operation {
+	case OpRebase, OpAm, OpBisect:
+		return true
+	case OpCherryPick, OpRevert:
+		return s.Sequence
+	}
+	return false
+}
+
+// String describes the state, e.g. "rebase in progress, detached HEAD"
+func (s RepoState) String() string {
+	var parts []string
+	if s.Operation != "" {
+		op := s.Operation + " in progress"
+		if s.Sequence {
+			op = s.Operation + " of several commits in progress"
+		}
+		parts = append(parts, op)
+	}
+	if s.Detached {
+		parts = append(parts, "detached HEAD")
+	}
+	if len(parts) == 0 {
+		return "on a branch, no operation in progress"
+	}
+	return strings.Join(parts, ", ")
+}
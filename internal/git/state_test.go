@@ -0,0 +1,178 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRepoStateIn(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string // path in the git directory -> content; a trailing / makes a directory
+		detached bool
+		want     RepoState
+		skips    bool
+	}{
+		{
+			name: "no operation on a branch",
+			want: RepoState{},
+		},
+		{
+			name:     "no operation on a detached HEAD",
+			detached: true,
+			want:     RepoState{Detached: true},
+		},
+		{
+			name:     "interactive or merge rebase",
+			files:    map[string]string{"rebase-merge/": ""},
+			detached: true,
+			want:     RepoState{Operation: OpRebase, Detached: true},
+			skips:    true,
+		},
+		{
+			name:     "apply rebase",
+			files:    map[string]string{"rebase-apply/": ""},
+			detached: true,
+			want:     RepoState{Operation: OpRebase, Detached: true},
+			skips:    true,
+		},
+		{
+			name:  "am",
+			files: map[string]string{"rebase-apply/applying": ""},
+			want:  RepoState{Operation: OpAm},
+			skips: true,
+		},
+		{
+			name:     "bisect",
+			files:    map[string]string{"BISECT_LOG": "git bisect start\n"},
+			detached: true,
+			want:     RepoState{Operation: OpBisect, Detached: true},
+			skips:    true,
+		},
+		{
+			name:  "single cherry-pick",
+			files: map[string]string{"CHERRY_PICK_HEAD": "abc\n"},
+			want:  RepoState{Operation: OpCherryPick},
+		},
+		{
+			name:  "cherry-pick of a range",
+			files: map[string]string{"CHERRY_PICK_HEAD": "abc\n", "sequencer/todo": "pick abc one\npick def two\n"},
+			want:  RepoState{Operation: OpCherryPick, Sequence: true},
+			skips: true,
+		},
+		{
+			name:  "single revert",
+			files: map[string]string{"REVERT_HEAD": "abc\n"},
+			want:  RepoState{Operation: OpRevert},
+		},
+		{
+			name:  "sequencer between picks",
+			files: map[string]string{"sequencer/todo": "pick def two\n"},
+			want:  RepoState{Operation: OpCherryPick, Sequence: true},
+			skips: true,
+		},
+		{
+			name:  "sequencer between reverts",
+			files: map[string]string{"sequencer/todo": "revert def two\n"},
+			want:  RepoState{Operation: OpRevert, Sequence: true},
+			skips: true,
+		},
+		{
+			name:  "merge",
+			files: map[string]string{"MERGE_HEAD": "abc\n"},
+			want:  RepoState{Operation: OpMerge},
+		},
+		{
+			name:  "rebase wins over a stale merge",
+			files: map[string]string{"rebase-merge/": "", "MERGE_HEAD": "abc\n"},
+			want:  RepoState{Operation: OpRebase},
+			skips: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gitDir := t.TempDir()
+			for name, content := range tt.files {
+				path := filepath.Join(gitDir, filepath.FromSlash(name))
+				if name[len(name)-1] == '/' {
+					if err := os.MkdirAll(path, 0o755); err != nil {
+						t.Fatal(err)
+					}
+					continue
+				}
+				if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got := repoStateIn(gitDir, tt.detached)
+			if got != tt.want {
+				t.Errorf("repoStateIn() = %+v, want %+v", got, tt.want)
+			}
+			if got.SkipsCapture() != tt.skips {
+				t.Errorf("SkipsCapture() = %v, want %v", got.SkipsCapture(), tt.skips)
+			}
+		})
+	}
+}
+
+func TestGetRepoState_Detached(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Chdir(t.TempDir())
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	for _, kv := range []string{"GIT_AUTHOR_NAME=A", "GIT_AUTHOR_EMAIL=a@example.com", "GIT_COMMITTER_NAME=A", "GIT_COMMITTER_EMAIL=a@example.com"} {
+		k, v, _ := strings.Cut(kv, "=")
+		t.Setenv(k, v)
+	}
+	run := func(args ...string) {
+		t.Helper()
+		if _, err := RunGit(args...); err != nil {
+			t.Fatalf("git %v: %v", args, err)
+		}
+	}
+
+	run("init", "-q", "-b", "main")
+	run("commit", "-q", "--allow-empty", "-m", "base")
+	state, err := GetRepoState()
+	if err != nil {
+		t.Fatalf("GetRepoState() error: %v", err)
+	}
+	if state != (RepoState{}) {
+		t.Errorf("GetRepoState() on main = %+v, want no operation, attached", state)
+	}
+
+	run("checkout", "-q", "--detach")
+	state, err = GetRepoState()
+	if err != nil {
+		t.Fatalf("GetRepoState() error: %v", err)
+	}
+	if state != (RepoState{Detached: true}) {
+		t.Errorf("GetRepoState() detached = %+v, want no operation, detached", state)
+	}
+}
+
+func TestRepoState_String(t *testing.T) {
+	tests := []struct {
+		state RepoState
+		want  string
+	}{
+		{RepoState{}, "on a branch, no operation in progress"},
+		{RepoState{Detached: true}, "detached HEAD"},
+		{RepoState{Operation: OpRebase, Detached: true}, "rebase in progress, detached HEAD"},
+		{RepoState{Operation: OpRevert, Sequence: true}, "revert of several commits in progress"},
+	}
+	for _, tt := range tests {
+		if got := tt.state.String(); got != tt.want {
+			t.Errorf("%+v.String() = %q, want %q", tt.state, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/config"
+)
+
+// Heuristics proposing the start of work on a commit, for
+// prompt-story.workStart
+const (
+	StrategyPreviousCommit = "previous-commit"
+	StrategyBranchSwitch   = "branch-switch"
+	StrategyIndexMtime     = "index-mtime"
+	StrategyFirstPrompt    = "first-prompt"
+)
+
+// DefaultWorkStartStrategies are used when prompt-story.workStart is unset
+var DefaultWorkStartStrategies = []string{StrategyPreviousCommit, StrategyBranchSwitch}
+
+// WorkStartStrategy is a heuristic proposing when work on a commit began.
+// Find gets the ref of the previous commit ("HEAD", or "HEAD^" when
+// amending) and returns a zero time when it has nothing to propose.
+type WorkStartStrategy struct {
+	Name string
+	Find func(ref string) (start time.Time, detail string, err error)
+}
+
+// workStartStrategies are the known strategies, in trace order
+var workStartStrategies = []WorkStartStrategy{
+	{Name: StrategyPreviousCommit, Find: findPreviousCommit},
+	{Name: StrategyBranchSwitch, Find: findBranchSwitch},
+	{Name: StrategyIndexMtime, Find: findIndexMtime},
+}
+
+// RegisterWorkStartStrategy adds a strategy, for heuristics that need more
+// than git to propose a start (e.g. the sessions themselves)
+func RegisterWorkStartStrategy(s WorkStartStrategy) {
+	workStartStrategies = append(workStartStrategies, s)
+}
+
+// WorkStartCandidate is the start proposed by one strategy
+type WorkStartCandidate struct {
+	Strategy string
+	Time     time.Time // Zero when the strategy had nothing to propose
+	Detail   string
+	Error    string
+	Enabled  bool // Counted towards the result; others are only traced
+	Chosen   bool
+}
+
+// WorkPeriodTrace captures how the work period was calculated (for explainability)
+type WorkPeriodTrace struct {
+	IsAmend         bool
+	Ref             string
+	Candidates      []WorkStartCandidate
+	CalculatedStart time.Time
+	Margin          time.Duration // Lead of the chosen start over the next enabled candidate
+	Explanation     string
+}
+
+// CalculateWorkStartTime determines the start of work for the current commit
+// Returns the most recent start proposed by the enabled strategies, by
+// default the previous commit or branch switch timestamp
+// isAmend: set to true when amending a commit (uses HEAD^ instead of HEAD)
+func CalculateWorkStartTime(isAmend bool) (time.Time, error) {
+	trace := calculateWorkStart(isAmend, false)
+	return trace.CalculatedStart, nil
+}
+
+// CalculateWorkStartTimeWithTrace is like CalculateWorkStartTime but also
+// returns trace info, including the proposals of disabled strategies
+func CalculateWorkStartTimeWithTrace(isAmend bool) (time.Time, *WorkPeriodTrace, error) {
+	trace := calculateWorkStart(isAmend, true)
+	return trace.CalculatedStart, trace, nil
+}
+
+// calculateWorkStart runs the strategies and picks the latest start the
+// enabled ones propose. Disabled strategies only run when traced.
+func calculateWorkStart(isAmend, traceAll bool) *WorkPeriodTrace {
+	trace := &WorkPeriodTrace{
+		IsAmend: isAmend,
+		Ref:     "HEAD",
+	}
+	if isAmend {
+		trace.Ref = "HEAD^"
+	}
+
+	enabled, unknown := enabledWorkStartStrategies()
+	for _, s := range workStartStrategies {
+		c := WorkStartCandidate{Strategy: s.Name, Enabled: enabled[s.Name]}
+		if !c.Enabled && !traceAll {
+			continue
+		}
+		start, detail, err := s.Find(trace.Ref)
+		if err != nil {
+			c.Error = err.Error()
+		} else {
+			c.Time, c.Detail = start.UTC(), detail
+		}
+		trace.Candidates = append(trace.Candidates, c)
+	}
+
+	pickWorkStart(trace, unknown)
+	return trace
+}
+
+// pickWorkStart chooses the latest start the enabled candidates of trace
+// propose and explains the choice, naming the runner-up it won over and
+// the unknown strategy names that were ignored
+func pickWorkStart(trace *WorkPeriodTrace, unknown []string) {
+	chosen, runnerUp := -1, -1
+	for i, c := range trace.Candidates {
+		if !c.Enabled || c.Time.IsZero() {
+			continue
+		}
+		switch {
+		case chosen == -1 || c.Time.After(trace.Candidates[chosen].Time):
+			chosen, runnerUp = i, chosen
+		case runnerUp == -1 || c.Time.After(trace.Candidates[runnerUp].Time):
+			runnerUp = i
+		}
+	}
+
+	switch {
+	case chosen == -1:
+		trace.Explanation = "No strategy proposed a start (initial commit)"
+	case runnerUp == -1:
+		trace.Candidates[chosen].Chosen = true
+		trace.CalculatedStart = trace.Candidates[chosen].Time
+		trace.Explanation = fmt.Sprintf("Using %s (no other candidate)", trace.Candidates[chosen].Strategy)
+	default:
+		trace.Candidates[chosen].Chosen = true
+		trace.CalculatedStart = trace.Candidates[chosen].Time
+		trace.Margin = trace.CalculatedStart.Sub(trace.Candidates[runnerUp].Time)
+		trace.Explanation = fmt.Sprintf("Using %s, %s after %s",
+			trace.Candidates[chosen].Strategy, trace.Margin.Round(time.Second), trace.Candidates[runnerUp].Strategy)
+	}
+	if len(unknown) > 0 {
+		trace.Explanation += fmt.Sprintf("; ignored unknown %s strategies: %s", config.KeyWorkStart, strings.Join(unknown, ", "))
+	}
+}
+
+// enabledWorkStartStrategies returns the strategies named in
+// prompt-story.workStart, or the defaults, and the unknown names
+func enabledWorkStartStrategies() (map[string]bool, []string) {
+	return selectWorkStartStrategies(config.WorkStartStrategies())
+}
+
+// selectWorkStartStrategies returns the known strategies among names and
+// the unknown names. No names, or only unknown ones, select the defaults.
+func selectWorkStartStrategies(names []string) (map[string]bool, []string) {
+	if len(names) == 0 {
+		names = DefaultWorkStartStrategies
+	}
+
+	enabled := make(map[string]bool)
+	var unknown []string
+	for _, name := range names {
+		known := false
+		for _, s := range workStartStrategies {
+			known = known || s.Name == name
+		}
+		if !known {
+			unknown = append(unknown, name)
+			continue
+		}
+		enabled[name] = true
+	}
+	if len(enabled) == 0 {
+		for _, name := range DefaultWorkStartStrategies {
+			enabled[name] = true
+		}
+	}
+	return enabled, unknown
+}
+
+func findPreviousCommit(ref string) (time.Time, string, error) {
+	t, err := GetPreviousCommitTimestamp(ref)
+	if err != nil || t.IsZero() {
+		return t, "", err
+	}
+	return t, "timestamp of " + ref, nil
+}
+
+func findBranchSwitch(string) (time.Time, string, error) {
+	t, err := GetLastBranchSwitchTimestamp()
+	if err != nil || t.IsZero() {
+		return t, "", err
+	}
+	return t, "last checkout in the reflog", nil
+}
+
+// findIndexMtime proposes the last write of the index, which checkouts,
+// resets and staging all update
+func findIndexMtime(string) (time.Time, string, error) {
+	gitDir, err := GetGitDir()
+	if err != nil {
+		return time.Time{}, "", nil // No repository, nothing to propose
+	}
+	info, err := os.Stat(filepath.Join(gitDir, "index"))
+	if err != nil {
+		return time.Time{}, "", nil // No index yet
+	}
+	return info.ModTime(), "last write of the index", nil
+}
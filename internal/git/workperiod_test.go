@@ -0,0 +1,105 @@
+package git
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPickWorkStart(t *testing.T) {
+	at := func(h, m int) time.Time { return time.Date(2025, 1, 15, h, m, 0, 0, time.UTC) }
+	tests := []struct {
+		name        string
+		candidates  []WorkStartCandidate
+		unknown     []string
+		wantChosen  string // "" when none is chosen
+		wantStart   time.Time
+		wantMargin  time.Duration
+		explanation string
+	}{
+		{
+			name: "latest enabled start wins over the runner-up",
+			candidates: []WorkStartCandidate{
+				{Strategy: StrategyPreviousCommit, Time: at(9, 0), Enabled: true},
+				{Strategy: StrategyBranchSwitch, Time: at(10, 30), Enabled: true},
+				{Strategy: StrategyIndexMtime, Time: at(10, 0), Enabled: true},
+			},
+			wantChosen:  StrategyBranchSwitch,
+			wantStart:   at(10, 30),
+			wantMargin:  30 * time.Minute,
+			explanation: "Using branch-switch, 30m0s after index-mtime",
+		},
+		{
+			name: "disabled and empty candidates are only traced",
+			candidates: []WorkStartCandidate{
+				{Strategy: StrategyPreviousCommit, Time: at(9, 0), Enabled: true},
+				{Strategy: StrategyBranchSwitch, Enabled: true},
+				{Strategy: StrategyIndexMtime, Time: at(11, 0)},
+			},
+			wantChosen:  StrategyPreviousCommit,
+			wantStart:   at(9, 0),
+			explanation: "Using previous-commit (no other candidate)",
+		},
+		{
+			name: "nothing proposed",
+			candidates: []WorkStartCandidate{
+				{Strategy: StrategyPreviousCommit, Enabled: true, Error: "no HEAD"},
+			},
+			explanation: "No strategy proposed a start (initial commit)",
+		},
+		{
+			name: "unknown strategies are named",
+			candidates: []WorkStartCandidate{
+				{Strategy: StrategyPreviousCommit, Time: at(9, 0), Enabled: true},
+			},
+			unknown:     []string{"git-stash"},
+			wantChosen:  StrategyPreviousCommit,
+			wantStart:   at(9, 0),
+			explanation: "Using previous-commit (no other candidate); ignored unknown prompt-story.workStart strategies: git-stash",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trace := &WorkPeriodTrace{Candidates: tt.candidates}
+			pickWorkStart(trace, tt.unknown)
+
+			var chosen []string
+			for _, c := range trace.Candidates {
+				if c.Chosen {
+					chosen = append(chosen, c.Strategy)
+				}
+			}
+			if got := strings.Join(chosen, ","); got != tt.wantChosen {
+				t.Errorf("chosen = %q, want %q", got, tt.wantChosen)
+			}
+			if !trace.CalculatedStart.Equal(tt.wantStart) || trace.Margin != tt.wantMargin {
+				t.Errorf("start %s, margin %s; want %s, %s", trace.CalculatedStart, trace.Margin, tt.wantStart, tt.wantMargin)
+			}
+			if trace.Explanation != tt.explanation {
+				t.Errorf("explanation = %q, want %q", trace.Explanation, tt.explanation)
+			}
+		})
+	}
+}
+
+func TestSelectWorkStartStrategies(t *testing.T) {
+	defaults := map[string]bool{StrategyPreviousCommit: true, StrategyBranchSwitch: true}
+	tests := []struct {
+		names       []string
+		wantEnabled map[string]bool
+		wantUnknown []string
+	}{
+		{nil, defaults, nil},
+		{[]string{StrategyIndexMtime}, map[string]bool{StrategyIndexMtime: true}, nil},
+		{[]string{StrategyIndexMtime, "typo"}, map[string]bool{StrategyIndexMtime: true}, []string{"typo"}},
+		{[]string{"typo"}, defaults, []string{"typo"}}, // Falls back rather than enabling nothing
+	}
+	for _, tt := range tests {
+		enabled, unknown := selectWorkStartStrategies(tt.names)
+		if !reflect.DeepEqual(enabled, tt.wantEnabled) || !reflect.DeepEqual(unknown, tt.wantUnknown) {
+			t.Errorf("selectWorkStartStrategies(%v) = %v, %v; want %v, %v", tt.names, enabled, unknown, tt.wantEnabled, tt.wantUnknown)
+		}
+	}
+}
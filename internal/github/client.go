@@ -0,0 +1,312 @@
+package github
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+)
+
+const defaultBaseURL = "https://api.github.com"
+
+// getBaseURL returns the API base URL. GitHub Actions sets GITHUB_API_URL,
+// which also covers GitHub Enterprise Server.
+func getBaseURL() string {
+	if url := os.Getenv("GITHUB_API_URL"); url != "" {
+		return strings.TrimSuffix(url, "/")
+	}
+	return defaultBaseURL
+}
+
+// Client is a minimal GitHub REST API client for one repository
+type Client struct {
+	token string
+	owner string
+	repo  string
+	http  *http.Client
+
+	limiter *limiter
+	etags   etagCache
+}
+
+// PullRequest is the subset of the pull request object we use
+type PullRequest struct {
+	Number    int       `json:"number"`
+	Title     string    `json:"title"`
+	Body      string    `json:"body"`
+	User      User      `json:"user"`
+	CreatedAt time.Time `json:"created_at"`
+	Head      struct {
+		SHA string `json:"sha"`
+		Ref string `json:"ref"`
+	} `json:"head"`
+	Base struct {
+		SHA string `json:"sha"`
+		Ref string `json:"ref"`
+	} `json:"base"`
+}
+
+// NewClient creates a client for repo ("owner/name"). An empty repo is
+// detected from GITHUB_REPOSITORY or the origin remote. The token is read
+// from GITHUB_TOKEN or GH_TOKEN.
+func NewClient(repo string) (*Client, error) {
+	token := os.Getenv("GITHUB_TOKEN")
+	if token == "" {
+		token = os.Getenv("GH_TOKEN")
+	}
+	if token == "" {
+		return nil, fmt.Errorf("GITHUB_TOKEN or GH_TOKEN must be set")
+	}
+
+	if repo == "" {
+		var err error
+		repo, err = detectRepo()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	owner, name, ok := strings.Cut(repo, "/")
+	if !ok || owner == "" || name == "" {
+		return nil, fmt.Errorf("invalid repository %q (expected owner/name)", repo)
+	}
+
+	return &Client{
+		token:   token,
+		owner:   owner,
+		repo:    name,
+		http:    &http.Client{},
+		limiter: newLimiter(),
+	}, nil
+}
+
+// SetWriteInterval sets the minimum pause between mutating requests
+// (comments, edits). Reads are not spaced.
+func (c *Client) SetWriteInterval(d time.Duration) {
+	c.limiter.writeInterval = d
+}
+
+// Repo returns "owner/name"
+func (c *Client) Repo() string {
+	return c.owner + "/" + c.repo
+}
+
+// detectRepo finds "owner/name" for the current repository
+func detectRepo() (string, error) {
+	if repo := os.Getenv("GITHUB_REPOSITORY"); repo != "" {
+		return repo, nil
+	}
+	remote, err := git.RunGit("remote", "get-url", "origin")
+	if err != nil {
+		return "", fmt.Errorf("cannot detect GitHub repository (no origin remote); use --repo owner/name")
+	}
+	repo := ParseRemoteURL(remote)
+	if repo == "" {
+		return "", fmt.Errorf("origin %q is not a GitHub URL; use --repo owner/name", remote)
+	}
+	return repo, nil
+}
+
+// ParseRemoteURL extracts "owner/name" from a GitHub remote URL
+// (https://github.com/o/r.git, git@github.com:o/r.git, ssh://git@github.com/o/r)
+func ParseRemoteURL(remote string) string {
+	remote = strings.TrimSpace(remote)
+	var path string
+	switch {
+	case strings.HasPrefix(remote, "git@"):
+		_, path, _ = strings.Cut(remote, ":")
+	case strings.Contains(remote, "://"):
+		_, rest, _ := strings.Cut(remote, "://")
+		_, path, _ = strings.Cut(rest, "/")
+	default:
+		return ""
+	}
+
+	path = strings.TrimSuffix(strings.TrimSuffix(path, "/"), ".git")
+	parts := strings.Split(path, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return ""
+	}
+	return parts[0] + "/" + parts[1]
+}
+
+// doRequest performs an authenticated API request with an optional JSON payload.
+// Requests are paced by the client's limiter and retried when GitHub
+// answers with a rate limit; GETs are sent as conditional requests when an
+// earlier response carried an ETag.
+func (c *Client) doRequest(method, path string, payload any) ([]byte, error) {
+	var data []byte
+	if payload != nil {
+		var err error
+		data, err = json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode request: %w", err)
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		c.limiter.wait(method)
+
+		resp, body, err := c.send(method, path, data)
+		if err != nil {
+			return nil, err
+		}
+		c.limiter.observe(resp)
+
+		if resp.StatusCode == http.StatusNotModified {
+			if cached, ok := c.etags.get(path); ok {
+				return cached.body, nil
+			}
+		}
+
+		if wait, limited := c.limiter.retryAfter(resp); limited && attempt < maxRetries {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: GitHub rate limit hit, retrying in %s\n", wait)
+			c.limiter.sleep(wait)
+			continue
+		}
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return nil, fmt.Errorf("GitHub API error (status %d): %s", resp.StatusCode, string(body))
+		}
+
+		if method == http.MethodGet {
+			c.etags.put(path, resp.Header.Get("ETag"), body)
+		}
+		return body, nil
+	}
+}
+
+// send performs a single HTTP round trip and reads the whole response
+func (c *Client) send(method, path string, data []byte) (*http.Response, []byte, error) {
+	var reqBody io.Reader
+	if data != nil {
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, getBaseURL()+path, reqBody)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if data != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if method == http.MethodGet {
+		if cached, ok := c.etags.get(path); ok {
+			req.Header.Set("If-None-Match", cached.etag)
+		}
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	return resp, body, nil
+}
+
+// GetPullRequest returns a pull request by number
+func (c *Client) GetPullRequest(number int) (*PullRequest, error) {
+	path := fmt.Sprintf("/repos/%s/%s/pulls/%d", c.owner, c.repo, number)
+
+	body, err := c.doRequest("GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var pr PullRequest
+	if err := json.Unmarshal(body, &pr); err != nil {
+		return nil, fmt.Errorf("failed to parse pull request: %w", err)
+	}
+	return &pr, nil
+}
+
+// UpdatePullRequestBody replaces the description of a pull request
+func (c *Client) UpdatePullRequestBody(number int, text string) error {
+	path := fmt.Sprintf("/repos/%s/%s/pulls/%d", c.owner, c.repo, number)
+	_, err := c.doRequest("PATCH", path, map[string]string{"body": text})
+	return err
+}
+
+// ListLabels returns the names of the labels defined in the repository
+func (c *Client) ListLabels() ([]string, error) {
+	path := fmt.Sprintf("/repos/%s/%s/labels?per_page=100", c.owner, c.repo)
+
+	body, err := c.doRequest("GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var labels []struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(body, &labels); err != nil {
+		return nil, fmt.Errorf("failed to parse labels: %w", err)
+	}
+	names := make([]string, len(labels))
+	for i, l := range labels {
+		names[i] = l.Name
+	}
+	return names, nil
+}
+
+// AddLabels adds existing repository labels to a pull request
+func (c *Client) AddLabels(number int, labels []string) error {
+	path := fmt.Sprintf("/repos/%s/%s/issues/%d/labels", c.owner, c.repo, number)
+	_, err := c.doRequest("POST", path, map[string][]string{"labels": labels})
+	return err
+}
+
+// ReviewComment is a comment on a single line of a pull request diff
+type ReviewComment struct {
+	Body     string `json:"body"`
+	CommitID string `json:"commit_id"`
+	Path     string `json:"path"`
+	Line     int    `json:"line"`
+	Side     string `json:"side"`
+}
+
+// CreateReviewComment posts a comment on a line of a pull request diff
+func (c *Client) CreateReviewComment(number int, comment ReviewComment) error {
+	path := fmt.Sprintf("/repos/%s/%s/pulls/%d/comments", c.owner, c.repo, number)
+	if comment.Side == "" {
+		comment.Side = "RIGHT"
+	}
+	_, err := c.doRequest("POST", path, comment)
+	return err
+}
+
+// ListReviewComments returns the review comments on a pull request's diff
+func (c *Client) ListReviewComments(number int) ([]ReviewComment, error) {
+	var comments []ReviewComment
+	path := fmt.Sprintf("/repos/%s/%s/pulls/%d/comments", c.owner, c.repo, number)
+	if err := getPaged(c, path, &comments); err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+// PagesURL returns the GitHub Pages base URL of a repository hosted at
+// remote (https://owner.github.io/name/), or empty if it is not on GitHub
+func PagesURL(remote string) string {
+	repo := ParseRemoteURL(remote)
+	if repo == "" || !strings.Contains(remote, "github.com") {
+		return ""
+	}
+	owner, name, _ := strings.Cut(repo, "/")
+	return "https://" + strings.ToLower(owner) + ".github.io/" + name + "/"
+}
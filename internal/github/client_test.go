@@ -0,0 +1,45 @@
+package github
+
+import "testing"
+
+func TestParseRemoteURL(t *testing.T) {
+	tests := []struct {
+		remote string
+		want   string
+	}{
+		{"https://github.com/QuesmaOrg/git-prompt-story.git", "QuesmaOrg/git-prompt-story"},
+		{"https://github.com/QuesmaOrg/git-prompt-story", "QuesmaOrg/git-prompt-story"},
+		{"git@github.com:QuesmaOrg/git-prompt-story.git", "QuesmaOrg/git-prompt-story"},
+		{"ssh://git@github.com/QuesmaOrg/git-prompt-story.git", "QuesmaOrg/git-prompt-story"},
+		{"https://github.com/QuesmaOrg/", ""},
+		{"/srv/git/repo.git", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.remote, func(t *testing.T) {
+			if got := ParseRemoteURL(tt.remote); got != tt.want {
+				t.Errorf("ParseRemoteURL(%q) = %q, want %q", tt.remote, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPagesURL(t *testing.T) {
+	tests := []struct {
+		remote string
+		want   string
+	}{
+		{"https://github.com/QuesmaOrg/git-prompt-story.git", "https://quesmaorg.github.io/git-prompt-story/"},
+		{"git@github.com:QuesmaOrg/git-prompt-story.git", "https://quesmaorg.github.io/git-prompt-story/"},
+		{"git@gitlab.com:QuesmaOrg/git-prompt-story.git", ""},
+		{"/srv/git/repo.git", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.remote, func(t *testing.T) {
+			if got := PagesURL(tt.remote); got != tt.want {
+				t.Errorf("PagesURL(%q) = %q, want %q", tt.remote, got, tt.want)
+			}
+		})
+	}
+}
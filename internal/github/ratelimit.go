@@ -0,0 +1,141 @@
+package github
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultWriteInterval spaces mutating requests, as GitHub recommends
+	// at least a second between them to avoid secondary rate limits
+	defaultWriteInterval = time.Second
+
+	// maxRetries bounds how often a rate-limited request is retried
+	maxRetries = 3
+
+	// maxRetryWait caps a single wait, so a bad header cannot hang CI
+	maxRetryWait = 2 * time.Minute
+)
+
+// limiter paces requests from one client: mutating requests are spaced by
+// writeInterval, and all requests wait while the primary quota is used up
+type limiter struct {
+	mu            sync.Mutex
+	writeInterval time.Duration
+	lastWrite     time.Time
+	resetAt       time.Time // set when the primary quota is exhausted
+
+	now   func() time.Time
+	sleep func(time.Duration)
+}
+
+func newLimiter() *limiter {
+	return &limiter{
+		writeInterval: defaultWriteInterval,
+		now:           time.Now,
+		sleep:         time.Sleep,
+	}
+}
+
+// wait blocks until a request with the given method may be sent
+func (l *limiter) wait(method string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if now.Before(l.resetAt) {
+		l.sleep(min(l.resetAt.Sub(now), maxRetryWait))
+		now = l.now()
+	}
+
+	if method == http.MethodGet || method == http.MethodHead {
+		return
+	}
+	if !l.lastWrite.IsZero() {
+		if d := l.lastWrite.Add(l.writeInterval).Sub(now); d > 0 {
+			l.sleep(d)
+			now = l.now()
+		}
+	}
+	l.lastWrite = now
+}
+
+// observe records the quota reported by a response
+func (l *limiter) observe(resp *http.Response) {
+	if resp.Header.Get("X-RateLimit-Remaining") != "0" {
+		return
+	}
+	reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return
+	}
+	l.mu.Lock()
+	l.resetAt = time.Unix(reset, 0)
+	l.mu.Unlock()
+}
+
+// retryAfter reports whether resp is a rate-limit rejection and how long
+// to wait before retrying. GitHub signals both primary and secondary limits
+// with 403 or 429, using Retry-After or X-RateLimit-Reset.
+func (l *limiter) retryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+
+	if s := resp.Header.Get("Retry-After"); s != "" {
+		secs, err := strconv.Atoi(s)
+		if err != nil {
+			return 0, false
+		}
+		return min(time.Duration(secs)*time.Second, maxRetryWait), true
+	}
+
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+		if err != nil {
+			return 0, false
+		}
+		return min(max(time.Unix(reset, 0).Sub(l.now()), 0), maxRetryWait), true
+	}
+
+	// A 429 without headers is still a secondary limit; GitHub asks for
+	// at least a minute before retrying
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return time.Minute, true
+	}
+	return 0, false
+}
+
+// etagCache remembers GET responses so repeated reads can be sent as
+// conditional requests, which do not count against the quota when
+// answered with 304 Not Modified
+type etagCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedResponse
+}
+
+type cachedResponse struct {
+	etag string
+	body []byte
+}
+
+func (c *etagCache) get(path string) (cachedResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[path]
+	return e, ok
+}
+
+func (c *etagCache) put(path, etag string, body []byte) {
+	if etag == "" {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = make(map[string]cachedResponse)
+	}
+	c.entries[path] = cachedResponse{etag: etag, body: body}
+}
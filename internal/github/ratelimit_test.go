@@ -0,0 +1,145 @@
+package github
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newTestClient returns a client for srv whose limiter records sleeps
+// instead of blocking
+func newTestClient(t *testing.T, srv *httptest.Server) (*Client, *[]time.Duration) {
+	t.Helper()
+	t.Setenv("GITHUB_API_URL", srv.URL)
+
+	var slept []time.Duration
+	l := newLimiter()
+	l.sleep = func(d time.Duration) { slept = append(slept, d) }
+	return &Client{token: "t", owner: "o", repo: "r", http: srv.Client(), limiter: l}, &slept
+}
+
+func TestDoRequest_RetriesAfterRateLimit(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("Retry-After", "7")
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"message":"secondary rate limit"}`))
+			return
+		}
+		w.Write([]byte(`{"number":5}`))
+	}))
+	defer srv.Close()
+
+	c, slept := newTestClient(t, srv)
+	pr, err := c.GetPullRequest(5)
+	if err != nil {
+		t.Fatalf("GetPullRequest() error = %v", err)
+	}
+	if pr.Number != 5 || calls != 2 {
+		t.Errorf("got PR %d after %d calls, want PR 5 after 2", pr.Number, calls)
+	}
+	if len(*slept) != 1 || (*slept)[0] != 7*time.Second {
+		t.Errorf("slept %v, want [7s]", *slept)
+	}
+}
+
+func TestDoRequest_GivesUpAfterMaxRetries(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Retry-After", "1")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	c, _ := newTestClient(t, srv)
+	if _, err := c.GetPullRequest(1); err == nil {
+		t.Fatal("expected error after exhausting retries")
+	}
+	if calls != maxRetries+1 {
+		t.Errorf("calls = %d, want %d", calls, maxRetries+1)
+	}
+}
+
+func TestDoRequest_PlainForbiddenIsNotRetried(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	c, _ := newTestClient(t, srv)
+	if _, err := c.GetPullRequest(1); err == nil {
+		t.Fatal("expected error")
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+}
+
+func TestDoRequest_ConditionalGet(t *testing.T) {
+	var gotIfNoneMatch []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotIfNoneMatch = append(gotIfNoneMatch, r.Header.Get("If-None-Match"))
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(`{"number":9,"title":"cached"}`))
+	}))
+	defer srv.Close()
+
+	c, _ := newTestClient(t, srv)
+	for i := 0; i < 2; i++ {
+		pr, err := c.GetPullRequest(9)
+		if err != nil {
+			t.Fatalf("GetPullRequest() #%d error = %v", i+1, err)
+		}
+		if pr.Title != "cached" {
+			t.Errorf("GetPullRequest() #%d title = %q, want %q", i+1, pr.Title, "cached")
+		}
+	}
+	if len(gotIfNoneMatch) != 2 || gotIfNoneMatch[0] != "" || gotIfNoneMatch[1] != `"v1"` {
+		t.Errorf("If-None-Match headers = %q", gotIfNoneMatch)
+	}
+}
+
+func TestLimiter_SpacesWrites(t *testing.T) {
+	now := time.Unix(1000, 0)
+	var slept []time.Duration
+	l := newLimiter()
+	l.now = func() time.Time { return now }
+	l.sleep = func(d time.Duration) { slept = append(slept, d); now = now.Add(d) }
+
+	l.wait(http.MethodPost)
+	now = now.Add(300 * time.Millisecond)
+	l.wait(http.MethodGet)
+	l.wait(http.MethodPost)
+
+	if len(slept) != 1 || slept[0] != 700*time.Millisecond {
+		t.Errorf("slept %v, want [700ms]", slept)
+	}
+}
+
+func TestLimiter_WaitsForQuotaReset(t *testing.T) {
+	now := time.Unix(1000, 0)
+	var slept []time.Duration
+	l := newLimiter()
+	l.now = func() time.Time { return now }
+	l.sleep = func(d time.Duration) { slept = append(slept, d); now = now.Add(d) }
+
+	resp := &http.Response{Header: http.Header{}}
+	resp.Header.Set("X-RateLimit-Remaining", "0")
+	resp.Header.Set("X-RateLimit-Reset", "1030")
+	l.observe(resp)
+
+	l.wait(http.MethodGet)
+	if len(slept) != 1 || slept[0] != 30*time.Second {
+		t.Errorf("slept %v, want [30s]", slept)
+	}
+}
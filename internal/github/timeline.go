@@ -0,0 +1,123 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// perPage is the page size of paginated list requests (GitHub's maximum)
+const perPage = 100
+
+// User is a GitHub account
+type User struct {
+	Login string `json:"login"`
+	Type  string `json:"type"` // "User", "Bot" or "Organization"
+}
+
+// IsBot reports whether the account is an app or bot, such as the Copilot
+// coding agent or "renovate[bot]"
+func (u *User) IsBot() bool {
+	return u != nil && (u.Type == "Bot" || strings.HasSuffix(u.Login, "[bot]"))
+}
+
+// GitActor is the author or committer recorded in a git commit
+type GitActor struct {
+	Name  string    `json:"name"`
+	Email string    `json:"email"`
+	Date  time.Time `json:"date"`
+}
+
+// PullRequestCommit is a commit of a pull request
+type PullRequestCommit struct {
+	SHA    string `json:"sha"`
+	Commit struct {
+		Message   string   `json:"message"`
+		Author    GitActor `json:"author"`
+		Committer GitActor `json:"committer"`
+	} `json:"commit"`
+	Author    *User `json:"author"` // Nil when the email matches no account
+	Committer *User `json:"committer"`
+}
+
+// TimelineEvent is an event of an issue or pull request timeline. Fields
+// are filled depending on the event type.
+type TimelineEvent struct {
+	Event string `json:"event"` // "commented", "reviewed", "committed", ...
+
+	Actor       *User     `json:"actor"` // Most events
+	User        *User     `json:"user"`  // reviewed
+	Body        string    `json:"body"`
+	State       string    `json:"state"` // reviewed: "approved", "changes_requested", ...
+	CreatedAt   time.Time `json:"created_at"`
+	SubmittedAt time.Time `json:"submitted_at"` // reviewed
+
+	// committed
+	SHA       string    `json:"sha"`
+	Message   string    `json:"message"`
+	Committer *GitActor `json:"committer"`
+}
+
+// Who returns the account behind the event, if GitHub records one
+func (e TimelineEvent) Who() *User {
+	if e.Actor != nil {
+		return e.Actor
+	}
+	return e.User
+}
+
+// Time returns when the event happened
+func (e TimelineEvent) Time() time.Time {
+	switch {
+	case !e.SubmittedAt.IsZero():
+		return e.SubmittedAt
+	case e.Committer != nil && !e.Committer.Date.IsZero():
+		return e.Committer.Date
+	}
+	return e.CreatedAt
+}
+
+// getPaged fetches every page of a list endpoint, appending the items of
+// each page to items
+func getPaged[T any](c *Client, path string, items *[]T) error {
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	for page := 1; ; page++ {
+		body, err := c.doRequest("GET", fmt.Sprintf("%s%sper_page=%d&page=%d", path, sep, perPage, page), nil)
+		if err != nil {
+			return err
+		}
+		var batch []T
+		if err := json.Unmarshal(body, &batch); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		*items = append(*items, batch...)
+		if len(batch) < perPage {
+			return nil
+		}
+	}
+}
+
+// ListPullRequestCommits returns the commits of a pull request, oldest first
+func (c *Client) ListPullRequestCommits(number int) ([]PullRequestCommit, error) {
+	var commits []PullRequestCommit
+	path := fmt.Sprintf("/repos/%s/%s/pulls/%d/commits", c.owner, c.repo, number)
+	if err := getPaged(c, path, &commits); err != nil {
+		return nil, err
+	}
+	return commits, nil
+}
+
+// ListTimeline returns the timeline events of a pull request (or issue),
+// oldest first
+func (c *Client) ListTimeline(number int) ([]TimelineEvent, error) {
+	var events []TimelineEvent
+	path := fmt.Sprintf("/repos/%s/%s/issues/%d/timeline", c.owner, c.repo, number)
+	if err := getPaged(c, path, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
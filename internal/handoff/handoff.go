@@ -0,0 +1,116 @@
+// Package handoff moves local session files between machines, so that a
+// commit made on one machine can include sessions started on another.
+package handoff
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/scrubber"
+	"github.com/QuesmaOrg/git-prompt-story/internal/session"
+)
+
+// Version is the format version of export files
+const Version = 1
+
+// File is an export file: session transcripts as their providers store
+// them, with the metadata discovery needs
+type File struct {
+	Version    int       `json:"version"`
+	ExportedAt time.Time `json:"exported_at"`
+	Host       string    `json:"host,omitempty"`
+	Branch     string    `json:"branch,omitempty"`
+	Scrubbed   bool      `json:"scrubbed"`
+	Sessions   []Session `json:"sessions"`
+}
+
+// Session is one exported session
+type Session struct {
+	Tool     string    `json:"tool"`
+	ID       string    `json:"id"`
+	Created  time.Time `json:"created"`
+	Modified time.Time `json:"modified"`
+	Content  string    `json:"content"`
+}
+
+// Export writes the sessions to path, scrubbed with scrub unless it is nil
+func Export(path string, sessions []session.ClaudeSession, branch string, scrub scrubber.Scrubber) (*File, error) {
+	host, _ := os.Hostname()
+	f := &File{
+		Version:    Version,
+		ExportedAt: time.Now().UTC(),
+		Host:       host,
+		Branch:     branch,
+		Scrubbed:   scrub != nil,
+	}
+
+	for _, s := range sessions {
+		content, err := session.ReadContent(s)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read session %s: %w", s.ID, err)
+		}
+		if scrub != nil {
+			sessionScrub := scrub
+			if ts, ok := scrub.(scrubber.ToolScrubber); ok {
+				sessionScrub = ts.ForTool(s.ToolName())
+			}
+			if content, err = sessionScrub.Scrub(content); err != nil {
+				return nil, fmt.Errorf("scrubbing session %s: %w", s.ID, err)
+			}
+		}
+		f.Sessions = append(f.Sessions, Session{
+			Tool:     s.ToolName(),
+			ID:       s.ID,
+			Created:  s.Created,
+			Modified: s.Modified,
+			Content:  string(content),
+		})
+	}
+
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode export: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return f, nil
+}
+
+// Read reads an export file
+func Read(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var f File
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("invalid session export %s: %w", path, err)
+	}
+	if f.Version != Version {
+		return nil, fmt.Errorf("unsupported session export version %d in %s", f.Version, path)
+	}
+	return &f, nil
+}
+
+// Import stores the sessions of an export file in the repository, where
+// discovery finds them for the next commit, and returns them
+func Import(f *File) ([]session.ClaudeSession, error) {
+	var sessions []session.ClaudeSession
+	for _, s := range f.Sessions {
+		imported, err := session.SaveImported(session.ImportedSession{
+			Tool:     s.Tool,
+			ID:       s.ID,
+			Created:  s.Created,
+			Modified: s.Modified,
+			Host:     f.Host,
+		}, []byte(s.Content))
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, imported)
+	}
+	return sessions, nil
+}
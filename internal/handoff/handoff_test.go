@@ -0,0 +1,57 @@
+package handoff
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/scrubber"
+	"github.com/QuesmaOrg/git-prompt-story/internal/session"
+)
+
+func TestExportRead(t *testing.T) {
+	dir := t.TempDir()
+	transcript := filepath.Join(dir, "abc.jsonl")
+	content := `{"type":"user","timestamp":"2025-01-15T10:00:00Z","message":{"role":"user","content":"mail jane@example.com"}}`
+	if err := os.WriteFile(transcript, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	created := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+	sessions := []session.ClaudeSession{{ID: "abc", Path: transcript, Created: created, Modified: created}}
+
+	scrub, err := scrubber.New(scrubber.DefaultRecognizers(), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "sessions.json")
+	if _, err := Export(path, sessions, "feature", scrub); err != nil {
+		t.Fatalf("Export() error: %v", err)
+	}
+
+	f, err := Read(path)
+	if err != nil {
+		t.Fatalf("Read() error: %v", err)
+	}
+	if !f.Scrubbed || f.Branch != "feature" || len(f.Sessions) != 1 {
+		t.Fatalf("Read() = %+v", f)
+	}
+	s := f.Sessions[0]
+	if s.Tool != session.ToolClaudeCode || s.ID != "abc" || !s.Created.Equal(created) {
+		t.Errorf("session = %+v", s)
+	}
+	if strings.Contains(s.Content, "jane@example.com") {
+		t.Errorf("content not scrubbed: %s", s.Content)
+	}
+}
+
+func TestReadUnsupportedVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.json")
+	if err := os.WriteFile(path, []byte(`{"version":99,"sessions":[]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Read(path); err == nil {
+		t.Error("Read() of a future version should fail")
+	}
+}
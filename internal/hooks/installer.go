@@ -6,6 +6,8 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
 )
 
 const prepareCommitMsgScript = `#!/bin/sh
@@ -80,8 +82,48 @@ type InstallOptions struct {
 	AutoPush bool
 }
 
-// InstallHooks installs the git hooks
+// hookSpec is a git hook git-prompt-story runs from
+type hookSpec struct {
+	name   string
+	script string
+	args   string // Arguments passed on when chained from a hook manager
+}
+
+// commitHooks are always installed; prePushHook with --auto-push
+var (
+	commitHooks = []hookSpec{
+		{name: "prepare-commit-msg", script: prepareCommitMsgScript, args: `"$@"`},
+		{name: "post-commit", script: postCommitScript},
+		// For squash/rebase note transfer
+		{name: "post-rewrite", script: postRewriteScript, args: `"$@"`},
+	}
+	prePushHook = hookSpec{name: "pre-push", script: prePushScript, args: `"$@"`}
+)
+
+// InstallHooks installs the git hooks. In a repository whose hooks are
+// managed by husky or lefthook, they are chained into its configuration
+// instead, as the manager would overwrite hooks written directly.
 func InstallHooks(opts InstallOptions) error {
+	specs := commitHooks
+	if opts.AutoPush {
+		specs = append(append([]hookSpec(nil), specs...), prePushHook)
+	}
+
+	if !opts.Global {
+		manager, err := DetectHookManager()
+		if err != nil {
+			return err
+		}
+		switch manager.Name {
+		case Husky:
+			return installHusky(manager.Dir, specs)
+		case Lefthook:
+			return installLefthook(manager.Dir, specs)
+		case PreCommit:
+			fmt.Println("pre-commit detected: its hooks keep running, chained before git-prompt-story's")
+		}
+	}
+
 	hooksDir, err := getHooksDir(opts.Global)
 	if err != nil {
 		return err
@@ -92,35 +134,70 @@ func InstallHooks(opts InstallOptions) error {
 		return fmt.Errorf("failed to create hooks directory: %w", err)
 	}
 
-	// Install prepare-commit-msg hook
-	if err := writeHookScript(hooksDir, "prepare-commit-msg", prepareCommitMsgScript); err != nil {
-		return err
+	for _, spec := range specs {
+		if err := writeHookScript(hooksDir, spec.name, spec.script); err != nil {
+			return err
+		}
+	}
+	if opts.AutoPush {
+		fmt.Println("Pre-push hook installed (notes will auto-sync on push)")
 	}
 
-	// Install post-commit hook
-	if err := writeHookScript(hooksDir, "post-commit", postCommitScript); err != nil {
-		return err
+	if opts.Global {
+		fmt.Printf("Hooks installed globally to %s\n", hooksDir)
+	} else {
+		fmt.Printf("Hooks installed to %s\n", hooksDir)
+	}
+
+	return nil
+}
+
+// UninstallHooks removes the git hooks installed by InstallHooks, restoring
+// the hooks they were chained to
+func UninstallHooks(opts InstallOptions) error {
+	specs := append(append([]hookSpec(nil), commitHooks...), prePushHook)
+
+	if !opts.Global {
+		manager, err := DetectHookManager()
+		if err != nil {
+			return err
+		}
+		switch manager.Name {
+		case Husky:
+			return uninstallHusky(manager.Dir, specs)
+		case Lefthook:
+			return uninstallLefthook(manager.Dir, specs)
+		}
 	}
 
-	// Install post-rewrite hook (for squash/rebase note transfer)
-	if err := writeHookScript(hooksDir, "post-rewrite", postRewriteScript); err != nil {
+	hooksDir, err := installedHooksDir(opts.Global)
+	if err != nil {
 		return err
 	}
+	if hooksDir == "" {
+		fmt.Println("No global hooks path configured, nothing to uninstall")
+		return nil
+	}
 
-	// Optionally install pre-push hook for auto-syncing notes
-	if opts.AutoPush {
-		if err := writeHookScript(hooksDir, "pre-push", prePushScript); err != nil {
+	removed := 0
+	for _, spec := range specs {
+		ok, err := removeHookScript(hooksDir, spec.name)
+		if err != nil {
 			return err
 		}
-		fmt.Println("Pre-push hook installed (notes will auto-sync on push)")
+		if ok {
+			removed++
+		}
 	}
+	if removed == 0 {
+		fmt.Printf("No git-prompt-story hooks found in %s\n", hooksDir)
+		return nil
+	}
+	fmt.Printf("Hooks removed from %s\n", hooksDir)
 
 	if opts.Global {
-		fmt.Printf("Hooks installed globally to %s\n", hooksDir)
-	} else {
-		fmt.Printf("Hooks installed to %s\n", hooksDir)
+		return resetGlobalHooksPath(hooksDir)
 	}
-
 	return nil
 }
 
@@ -153,17 +230,82 @@ func getHooksDir(global bool) (string, error) {
 		return hooksPath, nil
 	}
 
-	// Local repo hooks
+	return localHooksDir()
+}
+
+// localHooksDir returns the repository's hooks directory: its own
+// core.hooksPath if set, or .git/hooks
+func localHooksDir() (string, error) {
 	cmd := exec.Command("git", "rev-parse", "--git-dir")
 	out, err := cmd.Output()
 	if err != nil {
 		return "", fmt.Errorf("not in a git repository: %w", err)
 	}
-
 	gitDir := strings.TrimSpace(string(out))
+
+	if path := localHooksPath(); path != "" {
+		if filepath.IsAbs(path) {
+			return path, nil
+		}
+		repoRoot, err := git.GetRepoRoot()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(repoRoot, path), nil
+	}
 	return filepath.Join(gitDir, "hooks"), nil
 }
 
+// localHooksPath returns core.hooksPath from the repository's own config
+func localHooksPath() string {
+	out, err := exec.Command("git", "config", "--local", "--get", "core.hooksPath").Output()
+	if err != nil {
+		return ""
+	}
+	return expandPath(strings.TrimSpace(string(out)))
+}
+
+// installedHooksDir is like getHooksDir, but does not configure a global
+// hooks path; it returns "" when there is none
+func installedHooksDir(global bool) (string, error) {
+	if !global {
+		return localHooksDir()
+	}
+	out, err := exec.Command("git", "config", "--global", "--get", "core.hooksPath").Output()
+	if err != nil {
+		return "", nil
+	}
+	return expandPath(strings.TrimSpace(string(out))), nil
+}
+
+// defaultGlobalHooksDir is the global hooks path set by getHooksDir when
+// there was none
+func defaultGlobalHooksDir() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(homeDir, ".config", "git", "hooks")
+}
+
+// resetGlobalHooksPath unsets the global core.hooksPath that getHooksDir
+// set up, once no hooks are left in it
+func resetGlobalHooksPath(hooksDir string) error {
+	if hooksDir != defaultGlobalHooksDir() {
+		return nil
+	}
+	entries, err := os.ReadDir(hooksDir)
+	if err != nil || len(entries) > 0 {
+		return nil
+	}
+	if out, err := exec.Command("git", "config", "--global", "--unset", "core.hooksPath").CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to unset global hooks path: %s", strings.TrimSpace(string(out)))
+	}
+	os.Remove(hooksDir)
+	fmt.Println("Global core.hooksPath unset")
+	return nil
+}
+
 // writeHookScript writes a hook script file
 func writeHookScript(hooksDir, hookName, content string) error {
 	hookPath := filepath.Join(hooksDir, hookName)
@@ -174,9 +316,13 @@ func writeHookScript(hooksDir, hookName, content string) error {
 			fmt.Printf("Hook %s already installed, skipping\n", hookName)
 			return nil
 		}
-		// Backup existing hook
+		// Backup existing hook; a symlink is moved, not written through
 		backupPath := hookPath + ".orig"
-		if err := os.WriteFile(backupPath, existing, 0755); err != nil {
+		if info, err := os.Lstat(hookPath); err == nil && info.Mode()&os.ModeSymlink != 0 {
+			if err := os.Rename(hookPath, backupPath); err != nil {
+				return fmt.Errorf("failed to backup existing hook: %w", err)
+			}
+		} else if err := os.WriteFile(backupPath, existing, 0755); err != nil {
 			return fmt.Errorf("failed to backup existing hook: %w", err)
 		}
 		fmt.Printf("Backed up existing %s to %s.orig\n", hookName, hookName)
@@ -189,6 +335,34 @@ func writeHookScript(hooksDir, hookName, content string) error {
 	return nil
 }
 
+// removeHookScript removes a hook written by writeHookScript and restores
+// the hook it backed up. Hooks not written by it are left alone.
+func removeHookScript(hooksDir, hookName string) (bool, error) {
+	hookPath := filepath.Join(hooksDir, hookName)
+	existing, err := os.ReadFile(hookPath)
+	if err != nil {
+		return false, nil
+	}
+	if !strings.Contains(string(existing), "exec git-prompt-story ") {
+		if strings.Contains(string(existing), "git-prompt-story") {
+			fmt.Printf("Hook %s calls git-prompt-story but was not installed by it, leaving it\n", hookName)
+		}
+		return false, nil
+	}
+
+	if err := os.Remove(hookPath); err != nil {
+		return false, fmt.Errorf("failed to remove %s hook: %w", hookName, err)
+	}
+	backupPath := hookPath + ".orig"
+	if _, err := os.Lstat(backupPath); err == nil {
+		if err := os.Rename(backupPath, hookPath); err != nil {
+			return true, fmt.Errorf("failed to restore %s hook: %w", hookName, err)
+		}
+		fmt.Printf("Restored %s from %s.orig\n", hookName, hookName)
+	}
+	return true, nil
+}
+
 // expandPath expands ~ to home directory
 func expandPath(path string) string {
 	if strings.HasPrefix(path, "~/") {
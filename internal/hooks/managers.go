@@ -0,0 +1,356 @@
+package hooks
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+	"gopkg.in/yaml.v3"
+)
+
+// Hook managers git-prompt-story chains its hooks into
+const (
+	Husky     = "husky"
+	Lefthook  = "lefthook"
+	PreCommit = "pre-commit"
+)
+
+// HookManager is a tool managing a repository's git hooks
+type HookManager struct {
+	Name string // Husky, Lefthook, PreCommit, or "" for none
+	Dir  string // Husky's hooks directory, or the directory of lefthook's config
+}
+
+// lefthookConfigs are the names of lefthook's main configuration file
+var lefthookConfigs = []string{"lefthook.yml", "lefthook.yaml", ".lefthook.yml", ".lefthook.yaml"}
+
+// DetectHookManager returns the tool managing the current repository's
+// hooks, if any
+func DetectHookManager() (HookManager, error) {
+	repoRoot, err := git.GetRepoRoot()
+	if err != nil {
+		return HookManager{}, fmt.Errorf("not in a git repository: %w", err)
+	}
+
+	// husky points core.hooksPath at .husky/_ (v9) or .husky (older)
+	if path := localHooksPath(); strings.Contains(path, "husky") {
+		if filepath.Base(path) == "_" {
+			path = filepath.Dir(path)
+		}
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(repoRoot, path)
+		}
+		return HookManager{Name: Husky, Dir: path}, nil
+	}
+
+	for _, name := range lefthookConfigs {
+		if fileExists(filepath.Join(repoRoot, name)) {
+			return HookManager{Name: Lefthook, Dir: repoRoot}, nil
+		}
+	}
+	if fileExists(filepath.Join(repoRoot, ".pre-commit-config.yaml")) {
+		return HookManager{Name: PreCommit, Dir: repoRoot}, nil
+	}
+	return HookManager{}, nil
+}
+
+// huskyBlock is appended to a husky hook file to run git-prompt-story
+// hook; it does nothing for team members without it installed
+func huskyBlock(spec hookSpec) string {
+	command := "git-prompt-story " + spec.name
+	if spec.args != "" {
+		command += " " + spec.args
+	}
+	return "# git-prompt-story\n" +
+		"if command -v git-prompt-story >/dev/null 2>&1; then\n" +
+		"  " + command + "\n" +
+		"fi\n"
+}
+
+// installHusky appends git-prompt-story to husky's hook files
+func installHusky(dir string, specs []hookSpec) error {
+	for _, spec := range specs {
+		hookPath := filepath.Join(dir, spec.name)
+		existing, err := os.ReadFile(hookPath)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to read husky %s hook: %w", spec.name, err)
+		}
+		if strings.Contains(string(existing), huskyBlock(spec)) {
+			fmt.Printf("Hook %s already installed, skipping\n", spec.name)
+			continue
+		}
+
+		content := string(existing)
+		if content == "" {
+			content = "#!/usr/bin/env sh\n"
+		} else if !strings.HasSuffix(content, "\n") {
+			content += "\n"
+		}
+		if err := os.WriteFile(hookPath, []byte(content+huskyBlock(spec)), 0755); err != nil {
+			return fmt.Errorf("failed to write husky %s hook: %w", spec.name, err)
+		}
+	}
+
+	fmt.Printf("husky detected: hooks chained into %s\n", dir)
+	fmt.Println("Commit the changed hook files to share them; they do nothing where git-prompt-story is not installed.")
+	return nil
+}
+
+// uninstallHusky removes git-prompt-story from husky's hook files,
+// deleting files left with nothing else in them
+func uninstallHusky(dir string, specs []hookSpec) error {
+	removed := 0
+	for _, spec := range specs {
+		hookPath := filepath.Join(dir, spec.name)
+		existing, err := os.ReadFile(hookPath)
+		if err != nil || !strings.Contains(string(existing), huskyBlock(spec)) {
+			continue
+		}
+
+		content := strings.Replace(string(existing), huskyBlock(spec), "", 1)
+		if rest := strings.TrimSpace(content); rest == "" || rest == "#!/usr/bin/env sh" {
+			err = os.Remove(hookPath)
+		} else {
+			err = os.WriteFile(hookPath, []byte(content), 0755)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to update husky %s hook: %w", spec.name, err)
+		}
+		removed++
+	}
+
+	if removed == 0 {
+		fmt.Printf("No git-prompt-story hooks found in %s\n", dir)
+		return nil
+	}
+	fmt.Printf("Hooks removed from %s\n", dir)
+	return nil
+}
+
+// lefthookCommand is the name of git-prompt-story's commands in lefthook's
+// configuration
+const lefthookCommand = "git-prompt-story"
+
+// lefthookLocalConfig returns the path of lefthook's local configuration
+// (not committed), named after its main one
+func lefthookLocalConfig(dir string) string {
+	for _, name := range lefthookConfigs {
+		if fileExists(filepath.Join(dir, name)) {
+			base, ext := strings.TrimSuffix(name, filepath.Ext(name)), filepath.Ext(name)
+			return filepath.Join(dir, base+"-local"+ext)
+		}
+	}
+	return filepath.Join(dir, "lefthook-local.yml")
+}
+
+// installLefthook adds git-prompt-story commands to lefthook's local
+// configuration and reinstalls lefthook's hooks
+func installLefthook(dir string, specs []hookSpec) error {
+	path := lefthookLocalConfig(dir)
+	doc, err := readYAMLDoc(path)
+	if err != nil {
+		return err
+	}
+
+	if err := addLefthookCommands(doc.Content[0], specs); err != nil {
+		return fmt.Errorf("cannot add hooks to %s: %w", path, err)
+	}
+	if err := writeYAMLDoc(path, doc); err != nil {
+		return err
+	}
+
+	fmt.Printf("lefthook detected: hooks added to %s\n", path)
+	return runLefthookInstall()
+}
+
+// uninstallLefthook removes git-prompt-story commands from lefthook's local
+// configuration
+func uninstallLefthook(dir string, specs []hookSpec) error {
+	path := lefthookLocalConfig(dir)
+	if !fileExists(path) {
+		fmt.Printf("No git-prompt-story hooks found in %s\n", path)
+		return nil
+	}
+	doc, err := readYAMLDoc(path)
+	if err != nil {
+		return err
+	}
+
+	root := doc.Content[0]
+	removed := removeLefthookCommands(root, specs)
+	if removed == 0 {
+		fmt.Printf("No git-prompt-story hooks found in %s\n", path)
+		return nil
+	}
+
+	if len(root.Content) == 0 {
+		err = os.Remove(path)
+	} else {
+		err = writeYAMLDoc(path, doc)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update %s: %w", path, err)
+	}
+	fmt.Printf("Hooks removed from %s\n", path)
+	return runLefthookInstall()
+}
+
+// addLefthookCommands sets git-prompt-story's command in each hook of a
+// lefthook configuration, keeping the hooks' other commands
+func addLefthookCommands(root *yaml.Node, specs []hookSpec) error {
+	for _, spec := range specs {
+		run := "git-prompt-story " + spec.name
+		if spec.args != "" {
+			run += " {0}" // lefthook's placeholder for the hook's arguments
+		}
+		hook, err := yamlMapping(root, spec.name)
+		if err != nil {
+			return err
+		}
+		commands, err := yamlMapping(hook, "commands")
+		if err != nil {
+			return fmt.Errorf("%s: %w", spec.name, err)
+		}
+		command, err := yamlMapping(commands, lefthookCommand)
+		if err != nil {
+			return fmt.Errorf("%s.commands: %w", spec.name, err)
+		}
+		setYAMLString(command, "run", run)
+	}
+	return nil
+}
+
+// removeLefthookCommands deletes git-prompt-story's commands from a
+// lefthook configuration, and the hooks left empty, returning how many
+// were removed
+func removeLefthookCommands(root *yaml.Node, specs []hookSpec) int {
+	removed := 0
+	for _, spec := range specs {
+		hook := yamlValue(root, spec.name)
+		commands := yamlValue(hook, "commands")
+		if !deleteYAMLKey(commands, lefthookCommand) {
+			continue
+		}
+		removed++
+		if len(commands.Content) == 0 {
+			deleteYAMLKey(hook, "commands")
+		}
+		if len(hook.Content) == 0 {
+			deleteYAMLKey(root, spec.name)
+		}
+	}
+	return removed
+}
+
+// runLefthookInstall regenerates lefthook's hooks from its configuration,
+// or says how to when lefthook is not on the PATH
+func runLefthookInstall() error {
+	if _, err := exec.LookPath("lefthook"); err != nil {
+		fmt.Println("Run 'lefthook install' to apply the change.")
+		return nil
+	}
+	if out, err := exec.Command("lefthook", "install").CombinedOutput(); err != nil {
+		return fmt.Errorf("lefthook install: %s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// readYAMLDoc reads a YAML document whose root is a mapping; a missing
+// file yields an empty one
+func readYAMLDoc(path string) (*yaml.Node, error) {
+	doc := &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) || (err == nil && len(bytes.TrimSpace(data)) == 0) {
+		return doc, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err := yaml.Unmarshal(data, doc); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", path, err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("invalid %s: not a mapping", path)
+	}
+	return doc, nil
+}
+
+func writeYAMLDoc(path string, doc *yaml.Node) error {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("failed to encode %s: %w", path, err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// yamlValue returns the value of key in a mapping node, or nil
+func yamlValue(m *yaml.Node, key string) *yaml.Node {
+	if m == nil || m.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// yamlMapping returns the mapping under key, creating it (or replacing an
+// empty value) if needed. Any other value is an error rather than being
+// overwritten.
+func yamlMapping(m *yaml.Node, key string) (*yaml.Node, error) {
+	if v := yamlValue(m, key); v != nil {
+		switch {
+		case v.Kind == yaml.MappingNode:
+		case v.Kind == yaml.ScalarNode && v.ShortTag() == "!!null":
+			*v = yaml.Node{Kind: yaml.MappingNode}
+		default:
+			return nil, fmt.Errorf("%s is not a mapping (line %d)", key, v.Line)
+		}
+		return v, nil
+	}
+	v := &yaml.Node{Kind: yaml.MappingNode}
+	m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, v)
+	return v, nil
+}
+
+func setYAMLString(m *yaml.Node, key, value string) {
+	if v := yamlValue(m, key); v != nil {
+		*v = yaml.Node{Kind: yaml.ScalarNode, Value: value}
+		return
+	}
+	m.Content = append(m.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Value: key},
+		&yaml.Node{Kind: yaml.ScalarNode, Value: value})
+}
+
+// deleteYAMLKey removes key from a mapping node, reporting whether it was
+// there
+func deleteYAMLKey(m *yaml.Node, key string) bool {
+	if m == nil || m.Kind != yaml.MappingNode {
+		return false
+	}
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			m.Content = append(m.Content[:i], m.Content[i+2:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
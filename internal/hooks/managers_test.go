@@ -0,0 +1,232 @@
+package hooks
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+var testSpecs = []hookSpec{
+	{name: "prepare-commit-msg", args: `"$@"`},
+	{name: "post-commit"},
+}
+
+func TestHuskyRoundTrip(t *testing.T) {
+	tests := []struct {
+		name      string
+		existing  *string // nil when the hook file does not exist
+		installed string
+		restored  *string // nil when uninstall deletes the file
+	}{
+		{
+			name: "no hook file",
+			installed: "#!/usr/bin/env sh\n# git-prompt-story\nif command -v git-prompt-story >/dev/null 2>&1; then\n" +
+				"  git-prompt-story prepare-commit-msg \"$@\"\nfi\n",
+		},
+		{
+			name:     "existing hook",
+			existing: ptr("#!/usr/bin/env sh\nnpx lint-staged\n"),
+			installed: "#!/usr/bin/env sh\nnpx lint-staged\n# git-prompt-story\nif command -v git-prompt-story >/dev/null 2>&1; then\n" +
+				"  git-prompt-story prepare-commit-msg \"$@\"\nfi\n",
+			restored: ptr("#!/usr/bin/env sh\nnpx lint-staged\n"),
+		},
+		{
+			name:     "existing hook without a final newline",
+			existing: ptr("npx lint-staged"),
+			installed: "npx lint-staged\n# git-prompt-story\nif command -v git-prompt-story >/dev/null 2>&1; then\n" +
+				"  git-prompt-story prepare-commit-msg \"$@\"\nfi\n",
+			restored: ptr("npx lint-staged\n"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			hookPath := filepath.Join(dir, "prepare-commit-msg")
+			if tt.existing != nil {
+				if err := os.WriteFile(hookPath, []byte(*tt.existing), 0755); err != nil {
+					t.Fatal(err)
+				}
+			}
+			specs := testSpecs[:1]
+
+			// Installing twice adds the block once
+			for i := 0; i < 2; i++ {
+				if err := installHusky(dir, specs); err != nil {
+					t.Fatalf("installHusky() error: %v", err)
+				}
+			}
+			if got := readFile(t, hookPath); got != tt.installed {
+				t.Errorf("installed hook = %q, want %q", got, tt.installed)
+			}
+
+			if err := uninstallHusky(dir, specs); err != nil {
+				t.Fatalf("uninstallHusky() error: %v", err)
+			}
+			if tt.restored == nil {
+				if fileExists(hookPath) {
+					t.Errorf("hook file left behind: %q", readFile(t, hookPath))
+				}
+				return
+			}
+			if got := readFile(t, hookPath); got != *tt.restored {
+				t.Errorf("restored hook = %q, want %q", got, *tt.restored)
+			}
+		})
+	}
+}
+
+func TestLefthookCommands(t *testing.T) {
+	tests := []struct {
+		name      string
+		existing  string
+		installed string
+		restored  string // "" when uninstall empties the configuration
+	}{
+		{
+			name:     "no configuration yet",
+			existing: "",
+			installed: `prepare-commit-msg:
+  commands:
+    git-prompt-story:
+      run: git-prompt-story prepare-commit-msg {0}
+post-commit:
+  commands:
+    git-prompt-story:
+      run: git-prompt-story post-commit
+`,
+		},
+		{
+			name: "other commands and settings kept",
+			existing: `# Local overrides
+pre-commit:
+  commands:
+    lint:
+      run: npm run lint
+post-commit:
+  parallel: true
+  commands:
+    notify:
+      run: ./notify.sh
+`,
+			installed: `# Local overrides
+pre-commit:
+  commands:
+    lint:
+      run: npm run lint
+post-commit:
+  parallel: true
+  commands:
+    notify:
+      run: ./notify.sh
+    git-prompt-story:
+      run: git-prompt-story post-commit
+prepare-commit-msg:
+  commands:
+    git-prompt-story:
+      run: git-prompt-story prepare-commit-msg {0}
+`,
+			restored: `# Local overrides
+pre-commit:
+  commands:
+    lint:
+      run: npm run lint
+post-commit:
+  parallel: true
+  commands:
+    notify:
+      run: ./notify.sh
+`,
+		},
+		{
+			name: "empty hook and outdated command",
+			existing: `post-commit:
+prepare-commit-msg:
+  commands:
+    git-prompt-story:
+      run: git-prompt-story prepare-commit-msg
+`,
+			installed: `post-commit:
+  commands:
+    git-prompt-story:
+      run: git-prompt-story post-commit
+prepare-commit-msg:
+  commands:
+    git-prompt-story:
+      run: git-prompt-story prepare-commit-msg {0}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := parseYAML(t, tt.existing)
+			if err := addLefthookCommands(doc.Content[0], testSpecs); err != nil {
+				t.Fatalf("addLefthookCommands() error: %v", err)
+			}
+			if got := encodeYAML(t, doc); got != tt.installed {
+				t.Errorf("installed =\n%s\nwant\n%s", got, tt.installed)
+			}
+
+			if n := removeLefthookCommands(doc.Content[0], testSpecs); n != len(testSpecs) {
+				t.Errorf("removeLefthookCommands() = %d, want %d", n, len(testSpecs))
+			}
+			if tt.restored == "" {
+				if len(doc.Content[0].Content) != 0 {
+					t.Errorf("restored = %s, want an empty configuration", encodeYAML(t, doc))
+				}
+				return
+			}
+			if got := encodeYAML(t, doc); got != tt.restored {
+				t.Errorf("restored =\n%s\nwant\n%s", got, tt.restored)
+			}
+		})
+	}
+}
+
+func TestLefthookCommands_NotAMapping(t *testing.T) {
+	for _, existing := range []string{
+		"post-commit: ./run-everything.sh\n",
+		"post-commit:\n  commands: [lint]\n",
+	} {
+		doc := parseYAML(t, existing)
+		if err := addLefthookCommands(doc.Content[0], testSpecs); err == nil {
+			t.Errorf("addLefthookCommands(%q): want an error, got\n%s", existing, encodeYAML(t, doc))
+		}
+	}
+}
+
+func ptr(s string) *string { return &s }
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func parseYAML(t *testing.T, content string) *yaml.Node {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "lefthook-local.yml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	doc, err := readYAMLDoc(path)
+	if err != nil {
+		t.Fatalf("readYAMLDoc() error: %v", err)
+	}
+	return doc
+}
+
+func encodeYAML(t *testing.T, doc *yaml.Node) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "lefthook-local.yml")
+	if err := writeYAMLDoc(path, doc); err != nil {
+		t.Fatal(err)
+	}
+	return readFile(t, path)
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/QuesmaOrg/git-prompt-story/internal/git"
 	"github.com/QuesmaOrg/git-prompt-story/internal/note"
+	"github.com/QuesmaOrg/git-prompt-story/internal/retryqueue"
 )
 
 // PostCommit implements the post-commit hook logic
@@ -20,6 +21,13 @@ func PostCommit() error {
 
 	pendingFile := filepath.Join(gitDir, "PENDING-PROMPT-STORY")
 
+	// A pending note left by an aborted commit must not land on a commit
+	// replayed by a rebase, see PrepareCommitMsg
+	if state, err := git.GetRepoState(); err == nil && state.SkipsCapture() {
+		os.Remove(pendingFile)
+		return nil
+	}
+
 	// Read pending note SHA
 	content, err := os.ReadFile(pendingFile)
 	if os.IsNotExist(err) {
@@ -45,11 +53,19 @@ func PostCommit() error {
 	// Attach note to HEAD by reusing the existing blob SHA
 	// This ensures the note hash matches what's in the commit message trailer
 	if err := git.AddNoteFromBlob(note.NotesRef, noteSHA, headSHA); err != nil {
-		return fmt.Errorf("failed to attach note: %w", err)
+		// Keep the note for a later retry rather than losing the capture
+		if qerr := retryqueue.Enqueue(headSHA, noteSHA, err); qerr != nil {
+			return fmt.Errorf("failed to attach note: %w (and to queue it for retry: %v)", err, qerr)
+		}
+		os.Remove(pendingFile)
+		return fmt.Errorf("failed to attach note, queued for retry: %w", err)
 	}
 
 	// Cleanup
 	os.Remove(pendingFile)
 
+	// A successful attach is a good moment to retry earlier failures
+	retryqueue.Drain()
+
 	return nil
 }
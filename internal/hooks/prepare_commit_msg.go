@@ -1,14 +1,19 @@
 package hooks
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/QuesmaOrg/git-prompt-story/internal/config"
 	"github.com/QuesmaOrg/git-prompt-story/internal/git"
 	"github.com/QuesmaOrg/git-prompt-story/internal/note"
+	"github.com/QuesmaOrg/git-prompt-story/internal/pause"
+	"github.com/QuesmaOrg/git-prompt-story/internal/policy"
 	"github.com/QuesmaOrg/git-prompt-story/internal/scrubber"
 	"github.com/QuesmaOrg/git-prompt-story/internal/session"
 )
@@ -31,6 +36,65 @@ func PrepareCommitMsg(msgFile, source, sha, version string) error {
 	debugLog.log("repoRoot: %s", repoRoot)
 	debugLog.log("msgFile: %s, source: %q, sha: %q", msgFile, source, sha)
 
+	// Commits replayed by a rebase or made while bisecting record no new
+	// work: leave their message alone, post-rewrite carries notes over
+	if state, err := git.GetRepoState(); err != nil {
+		debugLog.log("GetRepoState error: %v", err)
+	} else {
+		debugLog.log("repo state: %s", state)
+		if state.SkipsCapture() {
+			debugLog.log("capture skipped until the %s finishes", state.Operation)
+			os.Remove(filepath.Join(gitDir, "PENDING-PROMPT-STORY"))
+			return nil
+		}
+	}
+
+	// Load team policy; a broken policy file must not block commits
+	pol, err := policy.Load(repoRoot)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "git-prompt-story: warning: %v\n", err)
+		debugLog.log("policy.Load error: %v", err)
+		pol = &policy.Policy{}
+	}
+
+	// Capture nothing until the user set up capture, e.g. when hooks come
+	// with a shared husky config; point them at init once instead. Capture
+	// set up by an older version, before init asked, keeps running.
+	initialized := config.IsInitialized()
+	if !initialized && setUpBeforeInit() {
+		debugLog.log("capture set up before init, marking initialized")
+		if err := config.Set(config.KeyInitialized, "true", false); err != nil {
+			debugLog.log("failed to mark initialized: %v", err)
+		}
+		initialized = true
+	}
+	if !initialized {
+		debugLog.log("capture skipped: not initialized")
+		os.Remove(filepath.Join(gitDir, "PENDING-PROMPT-STORY"))
+		if pol.RequireCapture {
+			// The commit would fail verify once pushed
+			return fmt.Errorf("%w, but it is not set up in this repository; run 'git-prompt-story init'", ErrCaptureRequired)
+		}
+		if !config.GetBool(config.KeyInitHintShown, false) {
+			fmt.Fprintln(os.Stderr, "git-prompt-story: LLM sessions are not captured in this repository yet.")
+			fmt.Fprintln(os.Stderr, "git-prompt-story: run 'git-prompt-story init' to review what is captured and turn capture on.")
+			if err := config.Set(config.KeyInitHintShown, "true", false); err != nil {
+				debugLog.log("failed to record init hint: %v", err)
+			}
+		}
+		return nil
+	}
+
+	// While paused, mark the commit but capture nothing
+	if state, err := pause.Current(); err != nil {
+		debugLog.log("pause.Current error: %v", err)
+	} else if state.Paused {
+		debugLog.log("capture %s", state)
+		warnCaptureRequired(pol, fmt.Sprintf("capture is %s", state))
+		os.Remove(filepath.Join(gitDir, "PENDING-PROMPT-STORY"))
+		return appendToCommitMessage(msgFile, fmt.Sprintf("Prompt-Story: paused [%s]", version))
+	}
+
 	// Read current commit message to detect if this is an amend
 	msgContent, err := os.ReadFile(msgFile)
 	if err != nil {
@@ -44,22 +108,34 @@ func PrepareCommitMsg(msgFile, source, sha, version string) error {
 	isAmend := (source == "commit" && sha != "") || hasMarker
 	debugLog.log("isAmend: %v (source=commit&&sha: %v, hasMarker: %v)", isAmend, source == "commit" && sha != "", hasMarker)
 
-	// Calculate work period
-	startWork, _ := git.CalculateWorkStartTime(isAmend)
-	endWork := time.Now().UTC()
-	debugLog.log("Work period: %s - %s (now)", startWork.UTC().Format(time.RFC3339), endWork.Format(time.RFC3339))
+	// Calculate work period, widened by the clock skew tolerance
+	skew := session.ClockSkew()
+	startWork, endWork := session.WidenWorkPeriod(workStart(isAmend), time.Now().UTC(), skew)
+	debugLog.log("Work period: %s - %s (now, clock skew %s)", startWork.UTC().Format(time.RFC3339), endWork.Format(time.RFC3339), skew)
 
-	// Find Claude Code sessions for this repo (includes time filtering)
-	sessions, err := session.FindSessions(repoRoot, startWork, endWork, nil)
+	// Find sessions of every enabled tool for this repo (includes time filtering)
+	var disabledTools []string
+	toolEnabled := func(tool string) bool {
+		if !config.ToolEnabled(tool) {
+			debugLog.log("%s capture disabled by %s", tool, config.KeyTools)
+			disabledTools = append(disabledTools, tool)
+			return false
+		}
+		if !pol.ToolAllowed(tool) {
+			debugLog.log("%s banned by %s", tool, policy.FileName)
+			return false
+		}
+		return true
+	}
+	sessions, err := session.FindAllSessions(repoRoot, startWork, endWork, toolEnabled)
 	if err != nil {
 		// Don't fail the commit, just log
 		fmt.Fprintf(os.Stderr, "git-prompt-story: warning: %v\n", err)
-		debugLog.log("FindSessions error: %v", err)
-		sessions = nil
+		debugLog.log("FindAllSessions error: %v", err)
 	}
 	debugLog.log("FindSessions returned %d sessions", len(sessions))
 	for _, s := range sessions {
-		debugLog.log("  - %s: created=%s, modified=%s", s.ID, s.Created.UTC().Format(time.RFC3339), s.Modified.UTC().Format(time.RFC3339))
+		debugLog.log("  - %s/%s: created=%s, modified=%s", s.ToolName(), s.ID, s.Created.UTC().Format(time.RFC3339), s.Modified.UTC().Format(time.RFC3339))
 	}
 
 	// Filter to only sessions with actual user messages in work period
@@ -73,22 +149,56 @@ func PrepareCommitMsg(msgFile, source, sha, version string) error {
 		}
 	}
 
+	// Tools found here for the first time are captured only once opted in
+	if len(sessions) > 0 {
+		found := len(sessions)
+		sessions = confirmNewTools(sessions, debugLog)
+		if skipped := found - len(sessions); skipped > 0 {
+			warnCaptureRequired(pol, fmt.Sprintf("%d session(s) of tools not opted into are left out", skipped))
+		}
+	}
+	if pol.RequireCapture && len(disabledTools) > 0 {
+		skipped, _ := session.FindAllSessions(repoRoot, startWork, endWork, func(tool string) bool {
+			return slices.Contains(disabledTools, tool)
+		})
+		if skipped = session.FilterSessionsByUserMessages(skipped, startWork, endWork, nil); len(skipped) > 0 {
+			warnCaptureRequired(pol, fmt.Sprintf("%d session(s) of tools turned off by %s are left out", len(skipped), config.KeyTools))
+		}
+	}
+
+	// Surface transcript schema drift before the parser silently drops data
+	if report := session.CheckSessions(sessions); !report.Empty() {
+		for _, line := range report.Lines() {
+			debugLog.log("schema drift: %s", line)
+		}
+	}
+
 	pendingFile := filepath.Join(gitDir, "PENDING-PROMPT-STORY")
 
-	var summary string
+	var summary, digest string
 
 	if len(sessions) == 0 {
 		summary = fmt.Sprintf("Prompt-Story: none [%s]", version)
 		// Clean up any stale pending file
 		os.Remove(pendingFile)
 	} else {
-		// Create PII scrubber (disabled via GIT_PROMPT_STORY_NO_SCRUB=1)
+		// Create PII scrubber (disabled via GIT_PROMPT_STORY_NO_SCRUB=1 or prompt-story.scrub=false)
+		scrub := config.ScrubEnabled()
+		if !scrub && pol.ScrubRequired() {
+			fmt.Fprintf(os.Stderr, "git-prompt-story: scrubbing is required by %s, ignoring local setting\n", policy.FileName)
+			scrub = true
+		}
 		var piiScrubber scrubber.Scrubber
-		if os.Getenv("GIT_PROMPT_STORY_NO_SCRUB") != "1" {
-			piiScrubber, err = scrubber.NewDefault()
+		if scrub {
+			ps, err := pol.Scrubber()
 			if err != nil {
-				return fmt.Errorf("failed to create scrubber: %w", err)
+				return err
 			}
+			if review := startScrubReview(pol, debugLog); review != nil {
+				defer review.close()
+				ps.SetReview(review.review)
+			}
+			piiScrubber = ps
 		}
 
 		// Store transcripts as blobs (with optional PII scrubbing)
@@ -104,6 +214,10 @@ func PrepareCommitMsg(msgFile, source, sha, version string) error {
 
 		// Create PromptStoryNote
 		psNote := note.NewPromptStoryNote(sessions, isAmend)
+		psNote.CaptureContexts(sessions, piiScrubber)
+		if err := psNote.SealTranscripts(blobs); err != nil {
+			return fmt.Errorf("failed to seal transcripts: %w", err)
+		}
 		noteJSON, err := psNote.ToJSON()
 		if err != nil {
 			return fmt.Errorf("failed to serialize note: %w", err)
@@ -121,18 +235,186 @@ func PrepareCommitMsg(msgFile, source, sha, version string) error {
 		}
 
 		// Count user actions (prompts, commands, tool rejects) for the summary
-		startWork, _ := git.CalculateWorkStartTime(isAmend)
-		endWork := time.Now().UTC()
+		startWork, endWork := session.WidenWorkPeriod(workStart(isAmend), time.Now().UTC(), skew)
 		promptCount := session.CountUserActionsInRange(sessions, startWork, endWork)
 
-		summary = psNote.GenerateSummary(promptCount, version)
+		format := config.Get(config.KeySummaryFormat)
+		if format == "" {
+			format = note.DefaultSummaryFormat
+		}
+		summary = psNote.FormatSummary(format, promptCount, noteSHA, version)
+
+		// The preview lists every prompt, so it replaces the digest
+		preview := config.GetBool(config.KeyConfirmInEditor, false)
+		if preview || config.GetBool(config.KeyCommitDigest, false) {
+			commentChar, ok := digestCommentChar(source)
+			switch {
+			case !ok:
+				debugLog.log("commit digest skipped: message is not edited with comment stripping")
+			case preview:
+				prompts := session.PromptsInRange(sessions, startWork, endWork)
+				// Show prompts as stored, not as typed
+				if ps, ok := piiScrubber.(*scrubber.PIIScrubber); ok {
+					for i := range prompts {
+						prompts[i].Text = ps.ScrubText(prompts[i].Text)
+					}
+				}
+				digest = commitPreview(psNote, prompts, commentChar)
+			default:
+				firstPrompt := session.FirstPromptInRange(sessions, startWork, endWork)
+				digest = commitDigest(psNote, firstPrompt, commentChar)
+			}
+		}
 	}
 
 	debugLog.log("Final summary: %s", summary)
 	debugLog.log("=== prepare-commit-msg finished ===\n")
 
 	// Append summary to commit message
-	return appendToCommitMessage(msgFile, summary)
+	return appendToCommitMessage(msgFile, summary+digest)
+}
+
+// ErrCaptureRequired is returned when the policy requires capture but the
+// commit would be made without it; the hook then refuses the commit
+var ErrCaptureRequired = errors.New("capture is required by " + policy.FileName)
+
+// warnCaptureRequired warns that sessions are not captured although the
+// policy requires capture; the trailer still satisfies verify
+func warnCaptureRequired(pol *policy.Policy, reason string) {
+	if pol.RequireCapture {
+		fmt.Fprintf(os.Stderr, "git-prompt-story: warning: %s requires capture, but %s\n", policy.FileName, reason)
+	}
+}
+
+// setUpBeforeInit reports whether the user set up capture before init
+// existed: hooks written by install-hooks into .git/hooks or the global
+// hooks directory, where a shared configuration cannot put them, or a
+// note on one of their own commits from the last year
+func setUpBeforeInit() bool {
+	var dirs []string
+	if commonDir, err := git.RunGit("rev-parse", "--git-common-dir"); err == nil {
+		dirs = append(dirs, filepath.Join(commonDir, "hooks"))
+	}
+	if globalDir, _ := installedHooksDir(true); globalDir != "" {
+		dirs = append(dirs, globalDir)
+	}
+	for _, dir := range dirs {
+		content, err := os.ReadFile(filepath.Join(dir, "prepare-commit-msg"))
+		if err == nil && strings.Contains(string(content), "exec git-prompt-story prepare-commit-msg") {
+			return true
+		}
+	}
+
+	author, err := git.GetAuthorIdent()
+	if err != nil {
+		return false
+	}
+	sha, err := git.RunGit("log", "-1", "--since=1.year", "--fixed-strings", "--author="+author, "--grep=Prompt-Story: ", "--format=%H")
+	if err != nil || sha == "" {
+		return false
+	}
+	existing, err := note.GetNote(sha)
+	return err == nil && existing != ""
+}
+
+// workStart returns when work on the commit began, but never before capture
+// last resumed: prompts from a paused period are not captured later
+func workStart(isAmend bool) time.Time {
+	start, _ := git.CalculateWorkStartTime(isAmend)
+	if resumed := pause.ResumedAt(); resumed.After(start) {
+		return resumed
+	}
+	return start
+}
+
+// digestMaxPrompt caps the first prompt quoted in the commit digest
+const digestMaxPrompt = 200
+
+// digestCommentChar returns the comment character git will strip from the
+// message, and false when comment lines would survive into the commit: the
+// message comes from -m/-F or an existing commit (possibly --no-edit), or
+// commit.cleanup does not strip comments.
+func digestCommentChar(source string) (string, bool) {
+	if source != "" && source != "template" {
+		return "", false
+	}
+	switch config.Get("commit.cleanup") {
+	case "", "default", "strip":
+	default:
+		return "", false
+	}
+
+	commentChar := config.Get("core.commentChar")
+	switch commentChar {
+	case "":
+		return "#", true
+	case "auto":
+		// Git picks a character after this hook runs
+		return "", false
+	default:
+		return commentChar, true
+	}
+}
+
+// commitDigest returns commented-out lines reminding the author which
+// tools were used and what was asked first. Git strips them when the
+// message is saved.
+func commitDigest(psNote *note.PromptStoryNote, firstPrompt, commentChar string) string {
+	tools := make(map[string]bool)
+	var toolNames []string
+	for _, s := range psNote.Sessions {
+		name := note.FormatToolName(s.Tool)
+		if !tools[name] {
+			tools[name] = true
+			toolNames = append(toolNames, name)
+		}
+	}
+
+	lines := []string{
+		"",
+		commentChar + " Prompt-Story digest (removed from the final message):",
+		commentChar + "   Tools: " + strings.Join(toolNames, ", "),
+	}
+	if firstPrompt != "" {
+		lines = append(lines, commentChar+"   First prompt: "+digestLine(firstPrompt))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// previewMaxPrompts caps the prompts listed in the commit preview
+const previewMaxPrompts = 30
+
+// commitPreview returns commented-out lines listing the transcripts being
+// attached and the prompts captured in the work period, so the author
+// sees what the commit will carry. Git strips them when the message is
+// saved.
+func commitPreview(psNote *note.PromptStoryNote, prompts []session.Prompt, commentChar string) string {
+	lines := []string{
+		"",
+		commentChar + " Prompt-Story preview (removed from the final message):",
+	}
+	for _, s := range psNote.Sessions {
+		lines = append(lines, fmt.Sprintf("%s   Attaching %s transcript %s", commentChar, note.FormatToolName(s.Tool), s.TranscriptPath()))
+	}
+	lines = append(lines, fmt.Sprintf("%s   %d prompt(s) in this commit's work period:", commentChar, len(prompts)))
+	for i, p := range prompts {
+		if i == previewMaxPrompts {
+			lines = append(lines, fmt.Sprintf("%s     ... and %d more", commentChar, len(prompts)-i))
+			break
+		}
+		lines = append(lines, fmt.Sprintf("%s     %s %s", commentChar, p.Time.Local().Format("15:04"), digestLine(p.Text)))
+	}
+	lines = append(lines, commentChar+"   To commit without them, abort (empty message) and run git-prompt-story pause")
+	return strings.Join(lines, "\n")
+}
+
+// digestLine flattens a prompt to one line of at most digestMaxPrompt runes
+func digestLine(prompt string) string {
+	prompt = strings.Join(strings.Fields(prompt), " ")
+	if runes := []rune(prompt); len(runes) > digestMaxPrompt {
+		prompt = string(runes[:digestMaxPrompt-3]) + "..."
+	}
+	return prompt
 }
 
 // appendToCommitMessage appends the summary line to the commit message file
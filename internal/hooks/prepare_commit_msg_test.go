@@ -0,0 +1,186 @@
+package hooks
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/config"
+	"github.com/QuesmaOrg/git-prompt-story/internal/note"
+	"github.com/QuesmaOrg/git-prompt-story/internal/pause"
+	"github.com/QuesmaOrg/git-prompt-story/internal/policy"
+)
+
+// initHookRepo is initTestRepo with a home directory of its own, so the
+// hook finds only the sessions a test writes there
+func initHookRepo(t *testing.T) (run func(args ...string) string, home string) {
+	t.Helper()
+	run = initTestRepo(t)
+	home = t.TempDir()
+	t.Setenv("HOME", home)
+	return run, home
+}
+
+// runPrepareCommitMsg runs the hook on a fresh message and returns the
+// message it leaves and what it printed
+func runPrepareCommitMsg(t *testing.T) (msg, stderr string, err error) {
+	t.Helper()
+	msgFile := filepath.Join(t.TempDir(), "COMMIT_EDITMSG")
+	if err := os.WriteFile(msgFile, []byte("subject\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	stderr = captureStderr(t, func() { err = PrepareCommitMsg(msgFile, "message", "", "test") })
+	return readFile(t, msgFile), stderr, err
+}
+
+// writeClaudeSession writes a Claude Code session for the repository with
+// a prompt made now
+func writeClaudeSession(t *testing.T, run func(args ...string) string, home string) {
+	t.Helper()
+	root := run("rev-parse", "--show-toplevel")
+	dir := filepath.Join(home, ".claude", "projects", strings.ReplaceAll(root, string(filepath.Separator), "-"))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	entry := `{"type":"user","sessionId":"s1","cwd":"` + root + `","timestamp":"` + now + `","message":{"role":"user","content":"Fix the tests"},"uuid":"u1"}` + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "s1.jsonl"), []byte(entry), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPrepareCommitMsg_NotInitialized(t *testing.T) {
+	run, _ := initHookRepo(t)
+
+	// Hooks arriving with a shared configuration, or a teammate's note
+	run("commit", "-q", "--allow-empty", "--author=B <b@example.com>", "-m", "theirs\n\nPrompt-Story: none [0.1.0]")
+	run("notes", "--ref="+note.NotesRef, "add", "-m", `{"v":1,"sessions":[]}`)
+
+	msg, stderr, err := runPrepareCommitMsg(t)
+	if err != nil || msg != "subject\n" {
+		t.Errorf("message = %q, err %v; want it left alone", msg, err)
+	}
+	if !strings.Contains(stderr, "git-prompt-story init") {
+		t.Errorf("no pointer to init printed: %q", stderr)
+	}
+	if msg, stderr, _ = runPrepareCommitMsg(t); msg != "subject\n" || stderr != "" {
+		t.Errorf("second commit: message = %q, printed %q; want it left alone, silently", msg, stderr)
+	}
+	if config.IsInitialized() {
+		t.Error("repository marked initialized")
+	}
+}
+
+func TestPrepareCommitMsg_Initialized(t *testing.T) {
+	run, _ := initHookRepo(t)
+	run("config", config.KeyInitialized, "true")
+
+	msg, _, err := runPrepareCommitMsg(t)
+	if want := "subject\n\nPrompt-Story: none [test]\n"; err != nil || msg != want {
+		t.Errorf("message = %q, err %v; want %q", msg, err, want)
+	}
+}
+
+func TestPrepareCommitMsg_SetUpBeforeInit(t *testing.T) {
+	tests := []struct {
+		name  string
+		setUp func(t *testing.T, run func(args ...string) string)
+	}{
+		{
+			name: "hooks from install-hooks",
+			setUp: func(t *testing.T, run func(args ...string) string) {
+				if err := writeHookScript(filepath.Join(run("rev-parse", "--git-dir"), "hooks"), "prepare-commit-msg", prepareCommitMsgScript); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+		{
+			name: "note on an own commit",
+			setUp: func(t *testing.T, run func(args ...string) string) {
+				run("commit", "-q", "--allow-empty", "-m", "mine\n\nPrompt-Story: none [0.1.0]")
+				run("notes", "--ref="+note.NotesRef, "add", "-m", `{"v":1,"sessions":[]}`)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run, _ := initHookRepo(t)
+			tt.setUp(t, run)
+
+			msg, _, err := runPrepareCommitMsg(t)
+			if want := "subject\n\nPrompt-Story: none [test]\n"; err != nil || msg != want {
+				t.Errorf("message = %q, err %v; want %q", msg, err, want)
+			}
+			if !config.IsInitialized() {
+				t.Error("earlier setup not recorded as initialized")
+			}
+		})
+	}
+}
+
+func TestPrepareCommitMsg_RequireCapture(t *testing.T) {
+	requireCapture := func(t *testing.T) {
+		t.Helper()
+		if err := os.WriteFile(policy.FileName, []byte("require_capture: true\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("not initialized", func(t *testing.T) {
+		initHookRepo(t)
+		requireCapture(t)
+
+		msg, _, err := runPrepareCommitMsg(t)
+		if !errors.Is(err, ErrCaptureRequired) {
+			t.Errorf("err = %v, want ErrCaptureRequired", err)
+		}
+		if msg != "subject\n" {
+			t.Errorf("message = %q, want it left alone", msg)
+		}
+	})
+
+	t.Run("paused", func(t *testing.T) {
+		run, _ := initHookRepo(t)
+		run("config", config.KeyInitialized, "true")
+		if _, err := pause.Pause(0); err != nil {
+			t.Fatal(err)
+		}
+
+		// Only a policy requiring capture warns
+		if _, stderr, _ := runPrepareCommitMsg(t); stderr != "" {
+			t.Errorf("warned without the policy: %q", stderr)
+		}
+		requireCapture(t)
+		msg, stderr, err := runPrepareCommitMsg(t)
+		if want := "subject\n\nPrompt-Story: paused [test]\n"; err != nil || msg != want {
+			t.Errorf("message = %q, err %v; want %q", msg, err, want)
+		}
+		if !strings.Contains(stderr, "requires capture, but capture is paused") {
+			t.Errorf("no warning printed: %q", stderr)
+		}
+	})
+
+	t.Run("tool turned off", func(t *testing.T) {
+		run, home := initHookRepo(t)
+		run("config", config.KeyInitialized, "true")
+		run("config", config.KeyTools, "cursor")
+		writeClaudeSession(t, run, home)
+
+		if _, stderr, _ := runPrepareCommitMsg(t); stderr != "" {
+			t.Errorf("warned without the policy: %q", stderr)
+		}
+		requireCapture(t)
+		msg, stderr, err := runPrepareCommitMsg(t)
+		if want := "subject\n\nPrompt-Story: none [test]\n"; err != nil || msg != want {
+			t.Errorf("message = %q, err %v; want %q", msg, err, want)
+		}
+		if !strings.Contains(stderr, "requires capture, but 1 session(s) of tools turned off by prompt-story.tools are left out") {
+			t.Errorf("no warning printed: %q", stderr)
+		}
+	})
+}
@@ -0,0 +1,85 @@
+package hooks
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/config"
+	"github.com/QuesmaOrg/git-prompt-story/internal/policy"
+	"github.com/QuesmaOrg/git-prompt-story/internal/scrubber"
+)
+
+// scrubReview asks on the terminal whether to replace each scrubber hit.
+// Git runs hooks without stdin, so it talks to /dev/tty.
+type scrubReview struct {
+	tty *os.File
+	in  *bufio.Reader
+
+	// decided is set once the user replaced or kept all remaining hits
+	decided, replaceRest bool
+	hits, kept           int
+}
+
+// startScrubReview opens the review when prompt-story.scrubReview is
+// interactive, or returns nil. Without a terminal (e.g. commits from a
+// GUI) every hit is replaced as usual.
+func startScrubReview(pol *policy.Policy, debugLog *debugLogger) *scrubReview {
+	if config.Get(config.KeyScrubReview) != config.ScrubReviewInteractive {
+		return nil
+	}
+	if pol.ScrubRequired() {
+		fmt.Fprintf(os.Stderr, "git-prompt-story: scrubbing is required by %s, skipping scrub review\n", policy.FileName)
+		return nil
+	}
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		debugLog.log("scrub review skipped, no terminal: %v", err)
+		return nil
+	}
+	fmt.Fprintln(tty, "git-prompt-story: review what the scrubber replaces before capture")
+	fmt.Fprintln(tty, "  y replace (default), n keep, a replace all remaining, k keep all remaining")
+	return &scrubReview{tty: tty, in: bufio.NewReader(tty)}
+}
+
+// review is the scrubber's review callback
+func (r *scrubReview) review(h scrubber.Hit) bool {
+	r.hits++
+	replace := r.ask(h)
+	if !replace {
+		r.kept++
+	}
+	return replace
+}
+
+func (r *scrubReview) ask(h scrubber.Hit) bool {
+	if r.decided {
+		return r.replaceRest
+	}
+	fmt.Fprintf(r.tty, "[%s] %s\n  replace %q? [Y/n/a/k]: ", h.EntityType, h.Context, h.Match)
+	answer, err := r.in.ReadString('\n')
+	if err != nil {
+		// Terminal gone: replace, as without review
+		r.decided, r.replaceRest = true, true
+		return true
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "n", "no":
+		return false
+	case "a":
+		r.decided, r.replaceRest = true, true
+	case "k":
+		r.decided, r.replaceRest = true, false
+		return false
+	}
+	return true
+}
+
+// close reports what was kept and releases the terminal
+func (r *scrubReview) close() {
+	if r.hits > 0 {
+		fmt.Fprintf(r.tty, "git-prompt-story: kept %d of %d scrubber hit(s)\n", r.kept, r.hits)
+	}
+	r.tty.Close()
+}
@@ -0,0 +1,166 @@
+package hooks
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+	"github.com/QuesmaOrg/git-prompt-story/internal/note"
+)
+
+// zeroSHA is what git passes for a created or deleted ref
+const zeroSHA = "0000000000000000000000000000000000000000"
+
+// refUpdate is one line of pre-receive input
+type refUpdate struct {
+	oldSHA string
+	newSHA string
+	ref    string
+}
+
+// ServerVerify implements pre-receive checking for self-hosted servers.
+// It rejects pushes containing commits whose "Prompt-Story: Used" trailer
+// promises a note that is neither part of the push nor already on the
+// server, and notes whose transcripts are missing.
+//
+// Git pre-receive hooks receive on stdin lines of
+// "<old-sha> <new-sha> <ref-name>" and no arguments.
+func ServerVerify(stdin io.Reader, stderr io.Writer) error {
+	var updates []refUpdate
+	scanner := bufio.NewScanner(stdin)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 3 {
+			continue
+		}
+		updates = append(updates, refUpdate{oldSHA: fields[0], newSHA: fields[1], ref: fields[2]})
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("reading stdin: %w", err)
+	}
+
+	// Notes and transcripts as they will be after this push
+	notesCommit := refAfterPush(updates, note.NotesRef)
+	transcriptsTree := refAfterPush(updates, note.TranscriptsRef)
+
+	notes, err := listNotes(notesCommit)
+	if err != nil {
+		return err
+	}
+
+	var problems []string
+	for _, u := range updates {
+		if u.newSHA == zeroSHA || strings.HasPrefix(u.ref, "refs/notes/") {
+			continue
+		}
+
+		// Only commits new to the server; older ones were checked when pushed
+		commits, err := git.RunGit("rev-list", u.newSHA, "--not", "--all")
+		if err != nil {
+			return fmt.Errorf("listing pushed commits for %s: %w", u.ref, err)
+		}
+
+		for _, sha := range strings.Fields(commits) {
+			msg, err := git.GetCommitMessage(sha)
+			if err != nil {
+				return fmt.Errorf("reading commit %s: %w", sha, err)
+			}
+			if !strings.Contains(msg, "Prompt-Story: Used") {
+				continue
+			}
+
+			noteBlob, ok := notes[sha]
+			if !ok {
+				problems = append(problems, fmt.Sprintf("%s (%s): Prompt-Story trailer but no note in %s", sha[:7], u.ref, note.NotesRef))
+				continue
+			}
+
+			for _, missing := range missingTranscripts(noteBlob, transcriptsTree) {
+				problems = append(problems, fmt.Sprintf("%s (%s): note references missing transcript %s", sha[:7], u.ref, missing))
+			}
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+
+	fmt.Fprintln(stderr, "git-prompt-story: push rejected, commits and prompt-story notes are out of sync:")
+	for _, p := range problems {
+		fmt.Fprintf(stderr, "  %s\n", p)
+	}
+	fmt.Fprintln(stderr, "Push the notes together with the commits:")
+	fmt.Fprintf(stderr, "  git push <remote> <branch> %s +%s\n", note.NotesRef, note.TranscriptsRef)
+	return fmt.Errorf("%d commit(s) without consistent notes", len(problems))
+}
+
+// refAfterPush returns the value ref will have once the push is accepted
+func refAfterPush(updates []refUpdate, ref string) string {
+	for _, u := range updates {
+		if u.ref == ref {
+			if u.newSHA == zeroSHA {
+				return ""
+			}
+			return u.newSHA
+		}
+	}
+	sha, _ := git.GetRef(ref)
+	return sha
+}
+
+// listNotes maps annotated commit SHAs to note blob SHAs for a notes commit.
+// Notes trees may fan out paths (ab/cdef...), so slashes are removed.
+func listNotes(notesCommit string) (map[string]string, error) {
+	notes := make(map[string]string)
+	if notesCommit == "" {
+		return notes, nil
+	}
+
+	out, err := git.RunGit("ls-tree", "-r", notesCommit)
+	if err != nil {
+		return nil, fmt.Errorf("reading notes %s: %w", notesCommit, err)
+	}
+	for _, line := range strings.Split(out, "\n") {
+		// Format: mode SP type SP sha TAB path
+		meta, path, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(meta)
+		if len(fields) != 3 || fields[1] != "blob" {
+			continue
+		}
+		notes[strings.ReplaceAll(path, "/", "")] = fields[2]
+	}
+	return notes, nil
+}
+
+// missingTranscripts returns transcript paths referenced by a note blob
+// that don't exist in the transcripts tree. Transcripts gc expired are
+// expected to be gone.
+func missingTranscripts(noteBlob, transcriptsTree string) []string {
+	content, err := git.RunGit("cat-file", "-p", noteBlob)
+	if err != nil {
+		return nil
+	}
+	psNote, err := note.ParseNote([]byte(content))
+	if err != nil {
+		// Not a structured note; nothing to cross-check
+		return nil
+	}
+
+	var missing []string
+	for _, sess := range psNote.Sessions {
+		if sess.Expired != nil {
+			continue
+		}
+		relPath := sess.TranscriptPath()
+		if transcriptsTree == "" || exec.Command("git", "cat-file", "-e", transcriptsTree+":"+relPath).Run() != nil {
+			missing = append(missing, relPath)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,131 @@
+package hooks
+
+import (
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+	"github.com/QuesmaOrg/git-prompt-story/internal/note"
+)
+
+// initTestRepo makes a repository in a temporary directory the test runs
+// in, with one commit on main
+func initTestRepo(t *testing.T) func(args ...string) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Chdir(t.TempDir())
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	for _, kv := range []string{"GIT_AUTHOR_NAME=A", "GIT_AUTHOR_EMAIL=a@example.com", "GIT_COMMITTER_NAME=A", "GIT_COMMITTER_EMAIL=a@example.com"} {
+		k, v, _ := strings.Cut(kv, "=")
+		t.Setenv(k, v)
+	}
+
+	run := func(args ...string) string {
+		t.Helper()
+		out, err := git.RunGit(args...)
+		if err != nil {
+			t.Fatalf("git %v: %v", args, err)
+		}
+		return out
+	}
+	run("init", "-q", "-b", "main")
+	run("commit", "-q", "--allow-empty", "-m", "base")
+	return run
+}
+
+// transcriptsTree returns a transcripts tree holding claude-code/<id>.jsonl
+// for each of ids
+func transcriptsTree(t *testing.T, ids ...string) string {
+	t.Helper()
+	blob, err := git.HashObject([]byte("{}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var files []git.TreeEntry
+	for _, id := range ids {
+		files = append(files, git.TreeEntry{Mode: "100644", Type: "blob", SHA: blob, Name: id + ".jsonl"})
+	}
+	tool, err := git.CreateTree(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := git.CreateTree([]git.TreeEntry{{Mode: "040000", Type: "tree", SHA: tool, Name: "claude-code"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+func TestMissingTranscripts(t *testing.T) {
+	initTestRepo(t)
+	tree := transcriptsTree(t, "s1")
+	noteBlob, err := git.HashObject([]byte(`{"v":1,"sessions":[
+		{"tool":"claude-code","id":"s1"},
+		{"tool":"claude-code","id":"gone"},
+		{"tool":"claude-code","id":"old","expired":"2025-01-01T00:00:00Z"},
+		{"tool":"claude-code","id":"moved","path":"refs/notes/prompt-story-transcripts/claude-code/s1.jsonl"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := missingTranscripts(noteBlob, tree), []string{"claude-code/gone.jsonl"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("missingTranscripts() = %v, want %v", got, want)
+	}
+	if got, want := missingTranscripts(noteBlob, ""), []string{"claude-code/s1.jsonl", "claude-code/gone.jsonl", "claude-code/s1.jsonl"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("missingTranscripts() without transcripts = %v, want %v", got, want)
+	}
+
+	legacy, err := git.HashObject([]byte("Reviewed-by: someone\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := missingTranscripts(legacy, tree); got != nil {
+		t.Errorf("missingTranscripts() of an unstructured note = %v, want none", got)
+	}
+}
+
+func TestServerVerify(t *testing.T) {
+	run := initTestRepo(t)
+
+	// Commits as if received by the server: not reachable from its refs
+	run("checkout", "-q", "--detach")
+	run("commit", "-q", "--allow-empty", "-m", "noted\n\nPrompt-Story: Used Claude Code")
+	noted := run("rev-parse", "HEAD")
+	run("commit", "-q", "--allow-empty", "-m", "unnoted\n\nPrompt-Story: Used Claude Code")
+	unnoted := run("rev-parse", "HEAD")
+	run("commit", "-q", "--allow-empty", "-m", "plain")
+	plain := run("rev-parse", "HEAD")
+	run("checkout", "-q", "main")
+
+	run("notes", "--ref="+note.NotesRef, "add", "-m", `{"v":1,"sessions":[{"tool":"claude-code","id":"s1"}]}`, noted)
+	notesCommit := run("rev-parse", note.NotesRef)
+	run("update-ref", "-d", note.NotesRef) // Arrives with the push
+
+	push := func(lines ...string) (string, error) {
+		var stderr strings.Builder
+		err := ServerVerify(strings.NewReader(strings.Join(lines, "\n")+"\n"), &stderr)
+		return stderr.String(), err
+	}
+	branch := func(sha string) string { return zeroSHA + " " + sha + " refs/heads/feature" }
+	notes := zeroSHA + " " + notesCommit + " " + note.NotesRef
+	transcripts := zeroSHA + " " + transcriptsTree(t, "s1") + " " + note.TranscriptsRef
+
+	if out, err := push(branch(noted), notes, transcripts); err != nil {
+		t.Errorf("commit pushed with its note and transcript rejected: %v\n%s", err, out)
+	}
+	out, err := push(branch(plain))
+	if err == nil || !strings.Contains(out, unnoted[:7]+" (refs/heads/feature): Prompt-Story trailer but no note") {
+		t.Errorf("commit with a trailer but no note: err %v\n%s", err, out)
+	}
+	if strings.Contains(out, plain[:7]) {
+		t.Errorf("commit without the trailer reported:\n%s", out)
+	}
+	if out, err := push(branch(noted), notes); err == nil || !strings.Contains(out, "note references missing transcript claude-code/s1.jsonl") {
+		t.Errorf("note without its transcript: err %v\n%s", err, out)
+	}
+}
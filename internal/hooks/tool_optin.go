@@ -0,0 +1,91 @@
+package hooks
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/config"
+	"github.com/QuesmaOrg/git-prompt-story/internal/note"
+	"github.com/QuesmaOrg/git-prompt-story/internal/session"
+)
+
+// confirmNewTools drops the sessions of tools the user has not opted into
+// for this repository. Claude Code, the default tool, is captured unless
+// turned off. The first time another tool's sessions are found, the user
+// is asked on the terminal and the answer is kept in the repository
+// config; without a terminal the tool is skipped and a hint is printed,
+// so a new data source never lands in commits unannounced.
+func confirmNewTools(sessions []session.ClaudeSession, debugLog *debugLogger) []session.ClaudeSession {
+	found := make(map[string]int)
+	var order []string
+	for _, s := range sessions {
+		if found[s.ToolName()] == 0 {
+			order = append(order, s.ToolName())
+		}
+		found[s.ToolName()]++
+	}
+
+	allowed := make(map[string]bool)
+	for _, tool := range order {
+		capture, decided := config.ToolCaptureConsent(tool)
+		switch {
+		case decided:
+		case tool == session.ToolClaudeCode:
+			capture = true
+		default:
+			capture, decided = askToolCapture(tool, found[tool], debugLog)
+			if decided {
+				if err := config.Set(config.ToolCaptureKey(tool), fmt.Sprint(capture), false); err != nil {
+					debugLog.log("failed to record capture choice for %s: %v", tool, err)
+				}
+			}
+		}
+		debugLog.log("%s capture: %v", tool, capture)
+		allowed[tool] = capture
+	}
+
+	kept := sessions[:0]
+	for _, s := range sessions {
+		if allowed[s.ToolName()] {
+			kept = append(kept, s)
+		}
+	}
+	return kept
+}
+
+// openTerminal opens the terminal askToolCapture talks to. Git runs hooks
+// without stdin, so it is /dev/tty.
+var openTerminal = func() (io.ReadWriteCloser, error) {
+	return os.OpenFile("/dev/tty", os.O_RDWR, 0)
+}
+
+// askToolCapture asks on the terminal whether to capture the sessions of
+// a tool found for the first time. decided is false when there is no
+// terminal to ask on.
+func askToolCapture(tool string, count int, debugLog *debugLogger) (capture, decided bool) {
+	name := note.FormatToolName(tool)
+	tty, err := openTerminal()
+	if err != nil {
+		debugLog.log("%s capture not asked, no terminal: %v", tool, err)
+		fmt.Fprintf(os.Stderr, "git-prompt-story: found %s sessions for this repository; not captured until enabled with:\n", name)
+		fmt.Fprintf(os.Stderr, "git-prompt-story:   git config %s true\n", config.ToolCaptureKey(tool))
+		return false, false
+	}
+	defer tty.Close()
+
+	fmt.Fprintf(tty, "git-prompt-story: found %d %s session(s) for this repository.\n", count, name)
+	fmt.Fprintf(tty, "  Capture %s sessions in this repository's commit notes? [y/N]: ", name)
+	answer, err := bufio.NewReader(tty).ReadString('\n')
+	if err != nil {
+		return false, false
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		capture = true
+	}
+	fmt.Fprintf(tty, "git-prompt-story: remembered; change with: git config %s %v\n", config.ToolCaptureKey(tool), !capture)
+	return capture, true
+}
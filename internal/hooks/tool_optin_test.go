@@ -0,0 +1,148 @@
+package hooks
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/config"
+	"github.com/QuesmaOrg/git-prompt-story/internal/session"
+)
+
+// fakeTerminal answers askToolCapture with its input and keeps what it was
+// shown
+type fakeTerminal struct {
+	io.Reader
+	shown strings.Builder
+}
+
+func (f *fakeTerminal) Write(p []byte) (int, error) { return f.shown.Write(p) }
+func (f *fakeTerminal) Close() error                { return nil }
+
+func TestConfirmNewTools(t *testing.T) {
+	run, _ := initHookRepo(t)
+	debugLog := newDebugLogger(filepath.Join(t.TempDir(), "debug.log"))
+	saved := openTerminal
+	defer func() { openTerminal = saved }()
+
+	sessions := func() []session.ClaudeSession {
+		return []session.ClaudeSession{
+			{ID: "c1"},
+			{ID: "cur1", Tool: session.ToolCursor},
+			{ID: "c2", Tool: session.ToolClaudeCode},
+			{ID: "cur2", Tool: session.ToolCursor},
+		}
+	}
+	ids := func(sessions []session.ClaudeSession) []string {
+		var ids []string
+		for _, s := range sessions {
+			ids = append(ids, s.ID)
+		}
+		return ids
+	}
+	answer := func(input string) *fakeTerminal {
+		tty := &fakeTerminal{Reader: strings.NewReader(input)}
+		openTerminal = func() (io.ReadWriteCloser, error) { return tty, nil }
+		return tty
+	}
+	noTerminal := func() {
+		openTerminal = func() (io.ReadWriteCloser, error) { return nil, errors.New("no such device") }
+	}
+
+	// Without a terminal a new tool is skipped, and how to enable it shown
+	noTerminal()
+	var kept []session.ClaudeSession
+	stderr := captureStderr(t, func() { kept = confirmNewTools(sessions(), debugLog) })
+	if got := ids(kept); !reflect.DeepEqual(got, []string{"c1", "c2"}) {
+		t.Errorf("without a terminal kept %v, want only the Claude Code sessions", got)
+	}
+	if !strings.Contains(stderr, "git config prompt-story.cursor.capture true") {
+		t.Errorf("without a terminal printed %q, want the config command", stderr)
+	}
+	if _, decided := config.ToolCaptureConsent(session.ToolCursor); decided {
+		t.Error("recorded a choice without asking")
+	}
+
+	// A terminal closed before an answer decides nothing either
+	answer("")
+	if got := ids(confirmNewTools(sessions(), debugLog)); !reflect.DeepEqual(got, []string{"c1", "c2"}) {
+		t.Errorf("without an answer kept %v, want only the Claude Code sessions", got)
+	}
+	if _, decided := config.ToolCaptureConsent(session.ToolCursor); decided {
+		t.Error("recorded a choice without an answer")
+	}
+
+	// Yes is asked once, with the count of sessions, and remembered
+	tty := answer("y\n")
+	if got := ids(confirmNewTools(sessions(), debugLog)); !reflect.DeepEqual(got, ids(sessions())) {
+		t.Errorf("after yes kept %v, want every session", got)
+	}
+	if !strings.Contains(tty.shown.String(), "found 2 Cursor session(s)") {
+		t.Errorf("asked %q, want the number of Cursor sessions", tty.shown.String())
+	}
+	if capture, decided := config.ToolCaptureConsent(session.ToolCursor); !capture || !decided {
+		t.Errorf("after yes ToolCaptureConsent() = %v, %v; want true, true", capture, decided)
+	}
+	noTerminal()
+	if got := ids(confirmNewTools(sessions(), debugLog)); len(got) != 4 {
+		t.Errorf("after yes, without a terminal, kept %v, want every session", got)
+	}
+
+	// No is remembered too
+	run("config", "--unset", config.ToolCaptureKey(session.ToolCursor))
+	answer("n\n")
+	if got := ids(confirmNewTools(sessions(), debugLog)); !reflect.DeepEqual(got, []string{"c1", "c2"}) {
+		t.Errorf("after no kept %v, want only the Claude Code sessions", got)
+	}
+	if capture, decided := config.ToolCaptureConsent(session.ToolCursor); capture || !decided {
+		t.Errorf("after no ToolCaptureConsent() = %v, %v; want false, true", capture, decided)
+	}
+
+	// Claude Code is captured until turned off, and never asked about
+	if err := config.Set(config.ToolCaptureKey(session.ToolClaudeCode), "false", false); err != nil {
+		t.Fatal(err)
+	}
+	if got := confirmNewTools(sessions(), debugLog); len(got) != 0 {
+		t.Errorf("with Claude Code turned off kept %v, want none", ids(got))
+	}
+}
+
+func TestConfirmNewTools_ToolsList(t *testing.T) {
+	initHookRepo(t)
+	debugLog := newDebugLogger(filepath.Join(t.TempDir(), "debug.log"))
+	saved := openTerminal
+	defer func() { openTerminal = saved }()
+	openTerminal = func() (io.ReadWriteCloser, error) {
+		t.Error("asked although prompt-story.tools decides")
+		return nil, errors.New("no such device")
+	}
+
+	// An explicit list decides for every tool, listed or not
+	if err := config.Set(config.KeyTools, session.ToolCursor, false); err != nil {
+		t.Fatal(err)
+	}
+	sessions := []session.ClaudeSession{{ID: "c1"}, {ID: "cur1", Tool: session.ToolCursor}, {ID: "a1", Tool: session.ToolAider}}
+	if got := confirmNewTools(sessions, debugLog); len(got) != 1 || got[0].ID != "cur1" {
+		t.Errorf("with prompt-story.tools=cursor kept %+v, want only the Cursor session", got)
+	}
+}
+
+// captureStderr returns what f writes to os.Stderr
+func captureStderr(t *testing.T, f func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := os.Stderr
+	os.Stderr = w
+	f()
+	os.Stderr = saved
+	w.Close()
+	out, _ := io.ReadAll(r)
+	return string(out)
+}
@@ -0,0 +1,197 @@
+// Package migrate moves prompt-story data to a new remote when a
+// repository changes hosting.
+package migrate
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+	"github.com/QuesmaOrg/git-prompt-story/internal/github"
+	"github.com/QuesmaOrg/git-prompt-story/internal/note"
+)
+
+// stagingPrefix holds the notes fetched from the old remote while they are
+// pushed to the new one
+const stagingPrefix = "refs/prompt-story-migrate/"
+
+// Options configures a remote migration
+type Options struct {
+	From     string // remote to copy notes from
+	To       string // remote to copy notes to
+	PagesURL string // new Pages base URL; derived from To if empty
+	Force    bool   // overwrite notes already on the new remote
+	DryRun   bool
+}
+
+// Result describes what a migration copied and rewrote
+type Result struct {
+	Refs     []string  // notes refs copied
+	OldPages string    // Pages base URL of the old remote, if known
+	NewPages string    // Pages base URL of the new remote, if known
+	Rewrites []Rewrite // config values pointing at the old Pages site
+}
+
+// Rewrite is a git config value whose Pages URL changes
+type Rewrite struct {
+	Key string
+	Old string
+	New string
+}
+
+// Remote copies both notes refs, with their full history, from one
+// remote to another and rewrites Pages URLs in the repository's
+// prompt-story config from the old site to the new one. Notes are copied
+// as the old remote has them, so notes never pushed from this clone are
+// not lost and unpushed local ones are not published.
+func Remote(opts Options) (*Result, error) {
+	fromURL, err := remoteURL(opts.From)
+	if err != nil {
+		return nil, err
+	}
+	toURL, err := remoteURL(opts.To)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &Result{OldPages: github.PagesURL(fromURL), NewPages: opts.PagesURL}
+	if res.NewPages == "" {
+		res.NewPages = github.PagesURL(toURL)
+	}
+
+	staged, err := stage(opts.From)
+	defer deleteStaged()
+	if err != nil {
+		return nil, err
+	}
+	if len(staged) == 0 {
+		return nil, fmt.Errorf("%s has no prompt-story notes", opts.From)
+	}
+	res.Refs = staged
+
+	if !opts.DryRun {
+		if err := push(opts.To, staged, opts.Force); err != nil {
+			return nil, err
+		}
+	}
+
+	if res.OldPages != "" && res.NewPages != "" && res.OldPages != res.NewPages {
+		res.Rewrites, err = rewriteConfig(res.OldPages, res.NewPages, opts.DryRun)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// remoteURL returns the URL of a configured remote as written in the
+// config, before any url.*.insteadOf rewriting
+func remoteURL(name string) (string, error) {
+	out, err := git.RunGit("config", "--get", "remote."+name+".url")
+	if err != nil {
+		return "", fmt.Errorf("no remote named %q (add it with git remote add)", name)
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// stage fetches the notes refs the remote has into staging refs and
+// returns the notes refs found
+func stage(remote string) ([]string, error) {
+	out, err := git.RunGit("ls-remote", remote, note.NotesRef, note.TranscriptsRef)
+	if err != nil {
+		return nil, fmt.Errorf("git ls-remote %s: %w", remote, err)
+	}
+
+	var refs, refspecs []string
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		refs = append(refs, fields[1])
+		refspecs = append(refspecs, "+"+fields[1]+":"+stagingRef(fields[1]))
+	}
+	if len(refs) == 0 {
+		return nil, nil
+	}
+
+	args := append([]string{"fetch", "--quiet", "--no-tags", remote}, refspecs...)
+	if _, err := git.RunGit(args...); err != nil {
+		return nil, fmt.Errorf("git fetch %s: %w", remote, err)
+	}
+	return refs, nil
+}
+
+// push sends the staged refs to the remote under their real names. The
+// pre-push hook is skipped, as it would push this clone's notes instead.
+func push(remote string, refs []string, force bool) error {
+	args := []string{"push", "--no-verify", remote}
+	for _, ref := range refs {
+		spec := stagingRef(ref) + ":" + ref
+		if force {
+			spec = "+" + spec
+		}
+		args = append(args, spec)
+	}
+	out, err := exec.Command("git", args...).CombinedOutput()
+	if err != nil {
+		msg := strings.TrimSpace(string(out))
+		if strings.Contains(msg, "rejected") && !force {
+			return fmt.Errorf("%s already has different prompt-story notes (use --force to overwrite): %s", remote, msg)
+		}
+		return fmt.Errorf("git push %s: %s", remote, msg)
+	}
+	return nil
+}
+
+// rewriteConfig replaces the old Pages base URL in the repository's
+// prompt-story.* config values
+func rewriteConfig(oldBase, newBase string, dryRun bool) ([]Rewrite, error) {
+	out, err := git.RunGit("config", "--local", "--get-regexp", `^prompt-story\.`)
+	if err != nil {
+		// Exit status 1: no prompt-story config at all
+		return nil, nil
+	}
+
+	var rewrites []Rewrite
+	for _, line := range strings.Split(out, "\n") {
+		key, value, ok := strings.Cut(line, " ")
+		if !ok {
+			continue
+		}
+		updated := rewriteURL(value, oldBase, newBase)
+		if updated == value {
+			continue
+		}
+		rewrites = append(rewrites, Rewrite{Key: key, Old: value, New: updated})
+		if dryRun {
+			continue
+		}
+		if _, err := git.RunGit("config", "--local", key, updated); err != nil {
+			return nil, fmt.Errorf("git config %s: %w", key, err)
+		}
+	}
+	return rewrites, nil
+}
+
+// rewriteURL moves value from the old Pages base URL to the new one. The
+// host is compared case-insensitively, as github.io hosts are.
+func rewriteURL(value, oldBase, newBase string) string {
+	if !strings.HasPrefix(strings.ToLower(value), strings.ToLower(oldBase)) {
+		return value
+	}
+	return newBase + value[len(oldBase):]
+}
+
+// stagingRef names the staging ref for a notes ref
+func stagingRef(ref string) string {
+	return stagingPrefix + strings.TrimPrefix(ref, "refs/notes/")
+}
+
+// deleteStaged removes the staging refs
+func deleteStaged() {
+	for _, ref := range []string{note.NotesRef, note.TranscriptsRef} {
+		git.RunGit("update-ref", "-d", stagingRef(ref))
+	}
+}
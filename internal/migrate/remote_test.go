@@ -0,0 +1,24 @@
+package migrate
+
+import "testing"
+
+func TestRewriteURL(t *testing.T) {
+	const oldBase = "https://acme.github.io/app/"
+	const newBase = "https://pages.example.com/acme/app/"
+
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"https://acme.github.io/app/prompt-story/pr-42/", "https://pages.example.com/acme/app/prompt-story/pr-42/"},
+		{"https://Acme.github.io/app/", "https://pages.example.com/acme/app/"},
+		{"https://acme.github.io/other/", "https://acme.github.io/other/"},
+		{"~/src", "~/src"},
+	}
+
+	for _, tt := range tests {
+		if got := rewriteURL(tt.value, oldBase, newBase); got != tt.want {
+			t.Errorf("rewriteURL(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
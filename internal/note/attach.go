@@ -0,0 +1,35 @@
+package note
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+)
+
+// AttachNote attaches a note payload to sha, storing it as is so it keeps
+// the hash named in the commit trailer. A different note already on sha,
+// e.g. one written while the payload waited for a retry, is merged with it.
+func AttachNote(sha string, payload []byte) error {
+	existing, err := GetNote(sha)
+	if err != nil {
+		blob, err := git.HashObject(payload)
+		if err != nil {
+			return err
+		}
+		return git.AddNoteFromBlob(NotesRef, blob, sha)
+	}
+	if strings.TrimSpace(existing) == strings.TrimSpace(string(payload)) {
+		return nil
+	}
+
+	current, err := ParseNote([]byte(existing))
+	if err != nil {
+		return fmt.Errorf("failed to parse note on %s: %w", sha[:7], err)
+	}
+	pending, err := ParseNote(payload)
+	if err != nil {
+		return fmt.Errorf("failed to parse pending note: %w", err)
+	}
+	return writeNote(sha, MergeNotes([]*PromptStoryNote{current, pending}))
+}
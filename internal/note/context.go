@@ -0,0 +1,88 @@
+package note
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+	"github.com/QuesmaOrg/git-prompt-story/internal/scrubber"
+	"github.com/QuesmaOrg/git-prompt-story/internal/session"
+)
+
+// SessionContext is the repository state a session started in, kept so
+// its transcript still makes sense after the branch is deleted. Branch and
+// Cwd come from the transcript where the tool records them; Head and Dirty
+// are a snapshot taken at capture.
+type SessionContext struct {
+	Branch string `json:"branch,omitempty"`
+	Cwd    string `json:"cwd,omitempty"`
+	Head   string `json:"head,omitempty"`
+	Dirty  int    `json:"dirty"`
+}
+
+// CaptureContexts records the start context of each of the note's
+// sessions. Branches not recorded in a transcript fall back to the branch
+// checked out now. Branch and directory go through scrub, if not nil, like
+// the transcripts they are taken from.
+func (n *PromptStoryNote) CaptureContexts(sessions []session.ClaudeSession, scrub scrubber.Scrubber) {
+	head, _ := git.GetHead()
+	current, _ := git.GetCurrentBranch()
+	if current == "HEAD" {
+		current = "" // Detached
+	}
+	dirty, _ := git.CountDirtyFiles()
+
+	byID := make(map[string]session.ClaudeSession, len(sessions))
+	for _, s := range sessions {
+		byID[s.ToolName()+"/"+s.ID] = s
+	}
+	for i := range n.Sessions {
+		e := &n.Sessions[i]
+		s, ok := byID[e.Tool+"/"+e.ID]
+		if !ok {
+			continue
+		}
+		branch, cwd := session.StartContext(s)
+		if branch == "" {
+			branch = current
+		}
+		e.Context = &SessionContext{
+			Branch: scrubValue(scrub, branch),
+			Cwd:    scrubValue(scrub, cwd),
+			Head:   head,
+			Dirty:  dirty,
+		}
+	}
+}
+
+// scrubValue scrubs a single value. A trailing slash is added while
+// scrubbing so a directory is matched like the paths in transcripts.
+func scrubValue(scrub scrubber.Scrubber, value string) string {
+	if scrub == nil || value == "" {
+		return value
+	}
+	out, err := scrub.Scrub([]byte(value + "/"))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(string(out), "/")
+}
+
+// Lines formats the context for display, one fact per line
+func (c *SessionContext) Lines() []string {
+	var lines []string
+	if c.Branch != "" {
+		lines = append(lines, "Branch: "+c.Branch)
+	}
+	if c.Head != "" {
+		head := c.Head
+		if len(head) > 7 {
+			head = head[:7]
+		}
+		lines = append(lines, fmt.Sprintf("HEAD: %s, %d uncommitted file(s) at capture", head, c.Dirty))
+	}
+	if c.Cwd != "" {
+		lines = append(lines, "Directory: "+c.Cwd)
+	}
+	return lines
+}
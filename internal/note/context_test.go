@@ -0,0 +1,42 @@
+package note
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/scrubber"
+)
+
+func TestSessionContextLines(t *testing.T) {
+	c := &SessionContext{Branch: "main", Cwd: "/work/app", Head: "0123456789abcdef", Dirty: 0}
+	want := []string{
+		"Branch: main",
+		"HEAD: 0123456, 0 uncommitted file(s) at capture",
+		"Directory: /work/app",
+	}
+	if got := c.Lines(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Lines() = %q, want %q", got, want)
+	}
+
+	// First commit of a repository, tool without a recorded directory
+	c = &SessionContext{Branch: "main"}
+	if got := c.Lines(); !reflect.DeepEqual(got, []string{"Branch: main"}) {
+		t.Errorf("Lines() = %q", got)
+	}
+}
+
+func TestScrubValue(t *testing.T) {
+	s, err := scrubber.NewDefault()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := scrubValue(s, "/home/jane"); got != "/<REDACTED>" {
+		t.Errorf("scrubValue(home) = %q, want /<REDACTED>", got)
+	}
+	if got := scrubValue(s, "/home/jane/src/app"); got != "/<REDACTED>/src/app" {
+		t.Errorf("scrubValue(project) = %q", got)
+	}
+	if got := scrubValue(nil, "/home/jane"); got != "/home/jane" {
+		t.Errorf("scrubValue(nil scrubber) = %q, want unchanged", got)
+	}
+}
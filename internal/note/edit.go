@@ -0,0 +1,241 @@
+package note
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// protectedFields are kept by PatchNote: the legal hold and audit trails,
+// which only the commands maintaining them may change
+var protectedFields = []string{"hold", "hold_audit", "redactions"}
+
+// PatchNote returns the note with a JSON merge patch (RFC 7396) applied,
+// then the assignments in sets, each "key=value" with a dotted key path
+// such as "sessions.0.author" and a value taken as JSON if it parses, as a
+// string otherwise. The result must be a valid note; the legal hold, the
+// audit logs and the transcript seals cannot be changed this way.
+func PatchNote(n *PromptStoryNote, patch []byte, sets []string) (*PromptStoryNote, error) {
+	data, err := json.Marshal(n)
+	if err != nil {
+		return nil, err
+	}
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	if len(bytes.TrimSpace(patch)) > 0 {
+		var p interface{}
+		if err := json.Unmarshal(patch, &p); err != nil {
+			return nil, fmt.Errorf("invalid patch: %w", err)
+		}
+		if _, ok := p.(map[string]interface{}); !ok {
+			return nil, fmt.Errorf("invalid patch: not a JSON object")
+		}
+		doc = mergePatch(doc, p)
+	}
+	for _, set := range sets {
+		key, value, ok := strings.Cut(set, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid assignment %q (expected key=value)", set)
+		}
+		var v interface{}
+		if err := json.Unmarshal([]byte(value), &v); err != nil {
+			v = value
+		}
+		if doc, err = setPath(doc, strings.Split(key, "."), v); err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+	}
+
+	// Decode strictly, so a misspelled field is an error rather than lost
+	data, err = json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var edited PromptStoryNote
+	if err := dec.Decode(&edited); err != nil {
+		return nil, fmt.Errorf("edited note is invalid: %w", err)
+	}
+	if err := checkProtected(n, &edited); err != nil {
+		return nil, err
+	}
+	if err := edited.Validate(); err != nil {
+		return nil, fmt.Errorf("edited note is invalid: %w", err)
+	}
+	return &edited, nil
+}
+
+// mergePatch applies a JSON merge patch to doc: objects merge key by key,
+// null removes a key, anything else replaces the value
+func mergePatch(doc, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	d, ok := doc.(map[string]interface{})
+	if !ok {
+		d = make(map[string]interface{})
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(d, k)
+		} else {
+			d[k] = mergePatch(d[k], v)
+		}
+	}
+	return d
+}
+
+// setPath sets the value at path in doc, path elements being object keys
+// or array indexes. Missing objects on the way are created.
+func setPath(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	switch d := doc.(type) {
+	case []interface{}:
+		i, err := strconv.Atoi(path[0])
+		if err != nil || i < 0 || i >= len(d) {
+			return nil, fmt.Errorf("no element %q in a list of %d", path[0], len(d))
+		}
+		if d[i], err = setPath(d[i], path[1:], value); err != nil {
+			return nil, err
+		}
+		return d, nil
+	case map[string]interface{}:
+		v, err := setPath(d[path[0]], path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		d[path[0]] = v
+		return d, nil
+	case nil:
+		return setPath(make(map[string]interface{}), path, value)
+	default:
+		return nil, fmt.Errorf("%q is inside a %T, not an object or list", path[0], doc)
+	}
+}
+
+// checkProtected rejects edits of protectedFields and transcript seals
+func checkProtected(before, after *PromptStoryNote) error {
+	was, err := protectedView(before)
+	if err != nil {
+		return err
+	}
+	is, err := protectedView(after)
+	if err != nil {
+		return err
+	}
+	for _, field := range protectedFields {
+		if !bytes.Equal(was[field], is[field]) {
+			return fmt.Errorf("%s cannot be edited", field)
+		}
+	}
+
+	chains := make(map[string]*Chain)
+	for _, s := range before.Sessions {
+		chains[s.Tool+"/"+s.ID] = s.Chain
+	}
+	for _, s := range after.Sessions {
+		was := chains[s.Tool+"/"+s.ID]
+		if (was == nil) != (s.Chain == nil) || (was != nil && *was != *s.Chain) {
+			return fmt.Errorf("the seal of session %s/%s cannot be edited", s.Tool, s.ID)
+		}
+	}
+	return nil
+}
+
+// protectedView returns the JSON of the note's fields by name
+func protectedView(n *PromptStoryNote) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(n)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// Validate checks what readers of the note rely on: a known version, a
+// start of work, sessions identified once each, and normalized tags
+func (n *PromptStoryNote) Validate() error {
+	if n.Version != 1 {
+		return fmt.Errorf("unsupported version %d", n.Version)
+	}
+	if n.StartWork.IsZero() {
+		return fmt.Errorf("start_work is missing")
+	}
+	if n.ClockSkew < 0 {
+		return fmt.Errorf("clock_skew is negative")
+	}
+	seen := make(map[string]bool)
+	for i, s := range n.Sessions {
+		if s.Tool == "" || s.ID == "" {
+			return fmt.Errorf("session %d: tool and id are required", i)
+		}
+		key := s.Tool + "/" + s.ID
+		if seen[key] {
+			return fmt.Errorf("session %s is listed twice", key)
+		}
+		seen[key] = true
+		if !strings.HasSuffix(s.TranscriptPath(), ".jsonl") {
+			return fmt.Errorf("session %s: path %q is not a transcript", key, s.Path)
+		}
+		if !s.Modified.IsZero() && s.Modified.Before(s.Created) {
+			return fmt.Errorf("session %s: modified before it was created", key)
+		}
+	}
+	for _, t := range n.Tags {
+		if normalized, err := NormalizeTag(t); err != nil || normalized != t {
+			return fmt.Errorf("invalid tag %q", t)
+		}
+	}
+	return nil
+}
+
+// EditNote applies PatchNote to the note on commit sha and writes the
+// result back, unless dryRun is set. It returns the edited note and
+// whether it differs from the stored one. A note on legal hold is only
+// edited with override, which is recorded in its hold audit.
+func EditNote(sha string, patch []byte, sets []string, dryRun, override bool) (*PromptStoryNote, bool, error) {
+	content, err := GetNote(sha)
+	if err != nil {
+		return nil, false, fmt.Errorf("no prompt-story note on %s", sha[:7])
+	}
+	psNote, err := ParseNote([]byte(content))
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid note on %s: %w", sha[:7], err)
+	}
+	if psNote.OnHold() && !override {
+		return nil, false, fmt.Errorf("note is on legal hold (commit %s); pass --override-hold to edit it anyway", sha[:7])
+	}
+	edited, err := PatchNote(psNote, patch, sets)
+	if err != nil {
+		return nil, false, err
+	}
+
+	before, err := psNote.ToJSON()
+	if err != nil {
+		return nil, false, err
+	}
+	after, err := edited.ToJSON()
+	if err != nil {
+		return nil, false, err
+	}
+	changed := !bytes.Equal(before, after)
+	if !changed || dryRun {
+		return edited, changed, nil
+	}
+	if edited.OnHold() {
+		edited.RecordOverride("edit", "note edited")
+	}
+	return edited, true, writeNote(sha, edited)
+}
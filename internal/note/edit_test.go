@@ -0,0 +1,85 @@
+package note
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func editableNote() *PromptStoryNote {
+	start := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+	return &PromptStoryNote{
+		Version:   1,
+		StartWork: start,
+		Sessions: []SessionEntry{{
+			Tool:     "claude-code",
+			ID:       "abc",
+			Path:     GetTranscriptPath("claude-code", "abc"),
+			Created:  start,
+			Modified: start.Add(time.Hour),
+			Chain:    &Chain{Entries: 3, Head: "h3"},
+		}},
+		Redactions: []RedactionEvent{{Path: "claude-code/abc.jsonl", Action: "redact", By: "jane"}},
+	}
+}
+
+func TestPatchNote(t *testing.T) {
+	n := editableNote()
+	edited, err := PatchNote(n, []byte(`{"start_work": "2025-01-15T09:00:00Z", "tags": ["bugfix"]}`),
+		[]string{"sessions.0.author=Jane Doe <jane@example.com>", "created_by=v1.2.0"})
+	if err != nil {
+		t.Fatalf("PatchNote() error: %v", err)
+	}
+	if want := time.Date(2025, 1, 15, 9, 0, 0, 0, time.UTC); !edited.StartWork.Equal(want) {
+		t.Errorf("StartWork = %v, want %v", edited.StartWork, want)
+	}
+	if len(edited.Tags) != 1 || edited.Tags[0] != "bugfix" {
+		t.Errorf("Tags = %v, want [bugfix]", edited.Tags)
+	}
+	if got := edited.Sessions[0].Author; got != "Jane Doe <jane@example.com>" {
+		t.Errorf("Author = %q", got)
+	}
+	if edited.CreatedBy != "v1.2.0" || edited.Sessions[0].Chain == nil {
+		t.Errorf("edited = %+v, want the rest kept", edited)
+	}
+	if !n.StartWork.Equal(time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)) {
+		t.Error("PatchNote() changed its input")
+	}
+
+	// null removes a field
+	edited, err = PatchNote(edited, []byte(`{"tags": null}`), nil)
+	if err != nil {
+		t.Fatalf("PatchNote() error: %v", err)
+	}
+	if edited.Tags != nil {
+		t.Errorf("Tags = %v, want removed", edited.Tags)
+	}
+}
+
+func TestPatchNote_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		patch string
+		sets  []string
+		want  string
+	}{
+		{"unknown field", `{"start_wrok": "2025-01-15T09:00:00Z"}`, nil, "unknown field"},
+		{"wrong type", `{"start_work": 42}`, nil, "invalid"},
+		{"not an object", `["start_work"]`, nil, "not a JSON object"},
+		{"no start", `{"start_work": null}`, nil, "start_work"},
+		{"version", "", []string{"v=2"}, "unsupported version"},
+		{"tag", `{"tags": ["Two Words"]}`, nil, "invalid tag"},
+		{"duplicate session", "", []string{`sessions=[{"tool":"a","id":"1"},{"tool":"a","id":"1"}]`}, "listed twice"},
+		{"redactions", `{"redactions": null}`, nil, "redactions cannot be edited"},
+		{"hold", "", []string{`hold.reason=x`}, "hold cannot be edited"},
+		{"seal", "", []string{"sessions.0.chain.head=forged"}, "seal"},
+		{"index", "", []string{"sessions.3.author=x"}, "no element"},
+		{"assignment", "", []string{"author"}, "expected key=value"},
+	}
+	for _, tt := range tests {
+		_, err := PatchNote(editableNote(), []byte(tt.patch), tt.sets)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: PatchNote() error = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package note
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+)
+
+// ErrNotesNotFetched is returned when commits record captured sessions but
+// the notes refs are missing locally, as in a fresh clone: git does not
+// fetch notes by default
+var ErrNotesNotFetched = errors.New("prompt-story notes have not been fetched; run with --fetch, or: " +
+	"git fetch origin '+" + NotesRef + ":" + NotesRef + "' '+" + TranscriptsRef + ":" + TranscriptsRef + "'")
+
+// capturedTrailer starts the commit message line of commits with sessions
+const capturedTrailer = "Prompt-Story: Used"
+
+// CheckNotesFetched returns ErrNotesNotFetched when the notes ref is
+// missing although commits were captured with sessions. A missing
+// transcripts ref alone is not reported: the notes still list the
+// sessions, and gc or a partial push can leave it out. It only looks at
+// the local repository.
+func CheckNotesFetched() error {
+	if !slices.Contains(missingNotesRefs(), NotesRef) {
+		return nil
+	}
+	// Trailers are only written when sessions were captured
+	out, err := git.RunGit("log", "--all", "-1", "--format=%H", "--grep=^"+capturedTrailer)
+	if err != nil || out == "" {
+		return nil
+	}
+	return ErrNotesNotFetched
+}
+
+// FetchNotes fetches the notes refs missing locally from remote. It fails
+// if the remote has none of them.
+func FetchNotes(remote string) error {
+	var refspecs []string
+	for _, ref := range missingNotesRefs() {
+		if sha, _ := git.GetRemoteRef(remote, ref); sha != "" {
+			refspecs = append(refspecs, "+"+ref+":"+ref)
+		}
+	}
+	if len(refspecs) == 0 {
+		return fmt.Errorf("%s has no prompt-story notes; they may not have been pushed", remote)
+	}
+
+	args := append([]string{"fetch", "--quiet", "--no-tags", remote}, refspecs...)
+	if _, err := git.RunGit(args...); err != nil {
+		return fmt.Errorf("git fetch %s %s: %w", remote, strings.Join(refspecs, " "), err)
+	}
+	return nil
+}
+
+// missingNotesRefs returns the notes refs that do not exist locally. Notes
+// still under LegacyNotesRef count as fetched.
+func missingNotesRefs() []string {
+	var missing []string
+	if sha, _ := git.GetRef(NotesRef); sha == "" {
+		if legacy, _ := git.GetRef(LegacyNotesRef); legacy == "" {
+			missing = append(missing, NotesRef)
+		}
+	}
+	if sha, _ := git.GetRef(TranscriptsRef); sha == "" {
+		missing = append(missing, TranscriptsRef)
+	}
+	return missing
+}
@@ -0,0 +1,58 @@
+package note
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+)
+
+func TestCheckNotesFetched(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Chdir(t.TempDir())
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	for _, kv := range []string{"GIT_AUTHOR_NAME=A", "GIT_AUTHOR_EMAIL=a@example.com", "GIT_COMMITTER_NAME=A", "GIT_COMMITTER_EMAIL=a@example.com"} {
+		k, v, _ := strings.Cut(kv, "=")
+		t.Setenv(k, v)
+	}
+	run := func(args ...string) string {
+		t.Helper()
+		out, err := git.RunGit(args...)
+		if err != nil {
+			t.Fatalf("git %v: %v", args, err)
+		}
+		return out
+	}
+
+	run("init", "-q", "-b", "main")
+	run("commit", "-q", "--allow-empty", "-m", "plain\n\nPrompt-Story: none [0.1.0]")
+	if err := CheckNotesFetched(); err != nil {
+		t.Errorf("no commit with sessions: %v", err)
+	}
+
+	run("commit", "-q", "--allow-empty", "-m", "captured\n\nPrompt-Story: Used Claude Code")
+	if err := CheckNotesFetched(); !errors.Is(err, ErrNotesNotFetched) {
+		t.Errorf("no notes refs: err = %v, want ErrNotesNotFetched", err)
+	}
+
+	// Transcripts missing alone, e.g. never pushed, do not stop readers
+	run("notes", "--ref="+NotesRef, "add", "-m", `{"v":1,"sessions":[]}`)
+	if err := CheckNotesFetched(); err != nil {
+		t.Errorf("only the transcripts ref missing: %v", err)
+	}
+	if missing := missingNotesRefs(); len(missing) != 1 || missing[0] != TranscriptsRef {
+		t.Errorf("missingNotesRefs() = %v, want only the transcripts ref to fetch", missing)
+	}
+
+	// Notes under the legacy ref count as fetched
+	run("update-ref", LegacyNotesRef, run("rev-parse", NotesRef))
+	run("update-ref", "-d", NotesRef)
+	if err := CheckNotesFetched(); err != nil {
+		t.Errorf("notes under the legacy ref: %v", err)
+	}
+}
@@ -0,0 +1,153 @@
+package note
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+)
+
+// Hold marks a note as under legal hold: the transcripts it references
+// must not be redacted, cleared or garbage collected until it is released
+type Hold struct {
+	Reason string    `json:"reason,omitempty"`
+	By     string    `json:"by"`
+	At     time.Time `json:"at"`
+}
+
+// HoldEvent is one entry in a note's hold audit trail
+type HoldEvent struct {
+	// Action is "hold", "release" or "override:<operation>"
+	Action string    `json:"action"`
+	By     string    `json:"by"`
+	At     time.Time `json:"at"`
+	Reason string    `json:"reason,omitempty"`
+}
+
+// OnHold reports whether the note is under legal hold
+func (n *PromptStoryNote) OnHold() bool {
+	return n.Hold != nil
+}
+
+// PlaceHold puts the note on commit sha under legal hold
+func PlaceHold(sha, reason string) error {
+	return updateHold(sha, func(n *PromptStoryNote, ev HoldEvent) {
+		n.Hold = &Hold{Reason: reason, By: ev.By, At: ev.At}
+		ev.Action, ev.Reason = "hold", reason
+		n.HoldAudit = append(n.HoldAudit, ev)
+	})
+}
+
+// ReleaseHold lifts the legal hold on the note on commit sha. The audit
+// trail is kept.
+func ReleaseHold(sha, reason string) error {
+	return updateHold(sha, func(n *PromptStoryNote, ev HoldEvent) {
+		n.Hold = nil
+		ev.Action, ev.Reason = "release", reason
+		n.HoldAudit = append(n.HoldAudit, ev)
+	})
+}
+
+// CheckHold returns an error if any held note references one of the
+// transcript paths (tool/id.jsonl). With override the operation is allowed,
+// and recorded in the audit trail of every held note it touches.
+func CheckHold(paths []string, operation string, override bool) error {
+	commits, err := ListCommits("--all", 0)
+	if err != nil {
+		return err
+	}
+	held := HeldReferences(commits, paths)
+	if len(held) == 0 {
+		return nil
+	}
+
+	if !override {
+		shas := make([]string, len(held))
+		for i, c := range held {
+			shas[i] = c.SHA[:7]
+		}
+		return fmt.Errorf("transcript is on legal hold (commit %s); pass --override-hold to %s anyway",
+			strings.Join(shas, ", "), operation)
+	}
+
+	for _, c := range held {
+		c.Note.RecordOverride(operation, strings.Join(paths, ", "))
+		if err := writeNote(c.SHA, c.Note); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RecordOverride appends an override of the hold by operation to the
+// note's audit trail; the caller writes the note
+func (n *PromptStoryNote) RecordOverride(operation, detail string) {
+	ev := newHoldEvent()
+	ev.Action = "override:" + operation
+	ev.Reason = detail
+	n.HoldAudit = append(n.HoldAudit, ev)
+}
+
+// HeldReferences returns the held commits whose notes reference any of the
+// transcript paths
+func HeldReferences(commits []ListedCommit, paths []string) []ListedCommit {
+	want := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		want[p] = true
+	}
+
+	var held []ListedCommit
+	for _, c := range commits {
+		if !c.Note.OnHold() {
+			continue
+		}
+		for _, s := range c.Note.Sessions {
+			if want[s.TranscriptPath()] {
+				held = append(held, c)
+				break
+			}
+		}
+	}
+	return held
+}
+
+// updateHold applies fn to the note on sha and writes it back
+func updateHold(sha string, fn func(*PromptStoryNote, HoldEvent)) error {
+	content, err := GetNote(sha)
+	if err != nil {
+		return fmt.Errorf("no prompt-story note on %s", sha[:7])
+	}
+	psNote, err := ParseNote([]byte(content))
+	if err != nil {
+		return fmt.Errorf("invalid note on %s: %w", sha[:7], err)
+	}
+	fn(psNote, newHoldEvent())
+	return writeNote(sha, psNote)
+}
+
+// newHoldEvent returns an event stamped with the current user and time
+func newHoldEvent() HoldEvent {
+	return HoldEvent{By: actor(), At: time.Now().UTC()}
+}
+
+// actor identifies who is changing a note: the git author, else the OS user
+func actor() string {
+	osUser, author := CaptureOwner()
+	if author != "" {
+		return author
+	}
+	return osUser
+}
+
+// writeNote replaces the note on sha
+func writeNote(sha string, n *PromptStoryNote) error {
+	data, err := n.ToJSON()
+	if err != nil {
+		return fmt.Errorf("failed to serialize note for %s: %w", sha[:7], err)
+	}
+	if err := git.AddNote(NotesRef, string(data), sha); err != nil {
+		return fmt.Errorf("failed to update note for %s: %w", sha[:7], err)
+	}
+	return nil
+}
@@ -0,0 +1,194 @@
+package note
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+	"github.com/QuesmaOrg/git-prompt-story/internal/session"
+)
+
+// Chain is the head of a rolling hash chain over a transcript's first
+// Entries entries: h(i) = sha256(h(i-1) || entry(i)), starting from 32 zero
+// bytes. Sessions keep growing after capture, so a note only vouches for
+// the prefix it saw; later captures append without breaking it.
+type Chain struct {
+	Entries int    `json:"entries"`
+	Head    string `json:"head"`
+}
+
+// RedactionEvent records a sanctioned change to a transcript. The chains
+// of every note referencing it are recomputed, keeping the previous head.
+type RedactionEvent struct {
+	Path     string    `json:"path"`
+	Action   string    `json:"action"`
+	Reason   string    `json:"reason,omitempty"`
+	By       string    `json:"by"`
+	At       time.Time `json:"at"`
+	Previous *Chain    `json:"previous,omitempty"`
+}
+
+// RedactionReasons are the reason codes a user redaction may carry
+var RedactionReasons = []string{"secret", "personal", "irrelevant"}
+
+// CheckRedactionReason returns an error unless reason is empty or one of
+// RedactionReasons
+func CheckRedactionReason(reason string) error {
+	if reason == "" || slices.Contains(RedactionReasons, reason) {
+		return nil
+	}
+	return fmt.Errorf("unknown redaction reason %q (expected one of: %s)", reason, strings.Join(RedactionReasons, ", "))
+}
+
+// ComputeChain hashes the first limit entries of a transcript, or all of
+// them when limit is negative
+func ComputeChain(tool string, content []byte, limit int) Chain {
+	entries := chainEntries(tool, content)
+	if limit < 0 || limit > len(entries) {
+		limit = len(entries)
+	}
+
+	head := make([]byte, sha256.Size)
+	for _, e := range entries[:limit] {
+		h := sha256.New()
+		h.Write(head)
+		h.Write(e)
+		head = h.Sum(nil)
+	}
+	return Chain{Entries: limit, Head: hex.EncodeToString(head)}
+}
+
+// chainEntries splits a transcript into the entries that are hashed: JSONL
+// lines, or for Cursor (one JSON document rewritten on every capture) the
+// parsed messages
+func chainEntries(tool string, content []byte) [][]byte {
+	if tool == session.ToolCursor {
+		if msgs, err := session.ParseTranscript(tool, content); err == nil {
+			entries := make([][]byte, 0, len(msgs))
+			for _, m := range msgs {
+				data, _ := json.Marshal(m)
+				entries = append(entries, data)
+			}
+			return entries
+		}
+	}
+
+	var entries [][]byte
+	for _, line := range bytes.Split(content, []byte("\n")) {
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			entries = append(entries, line)
+		}
+	}
+	return entries
+}
+
+// SealTranscripts sets the chain and size accounting of each session
+// stored in blobs (transcript path -> blob SHA), as returned by
+// StoreTranscripts
+func (n *PromptStoryNote) SealTranscripts(blobs map[string]string) error {
+	for i, s := range n.Sessions {
+		sha, ok := blobs[s.TranscriptPath()]
+		if !ok {
+			continue
+		}
+		content, err := git.ReadBlob(sha)
+		if err != nil {
+			return fmt.Errorf("failed to read transcript %s: %w", s.TranscriptPath(), err)
+		}
+		chain := ComputeChain(s.Tool, content, -1)
+		n.Sessions[i].Chain = &chain
+		size := MeasureTranscript(s.Tool, content)
+		n.Sessions[i].Size = &size
+	}
+	return nil
+}
+
+// VerifyChain recomputes the session's chain from the stored transcript.
+// It returns "" when it matches or the session carries no chain, otherwise
+// the problem found.
+func VerifyChain(s SessionEntry) string {
+	return VerifyChainAt(TranscriptsRef, s)
+}
+
+// VerifyChainAt is VerifyChain against the transcript tree at ref
+func VerifyChainAt(ref string, s SessionEntry) string {
+	if s.Chain == nil || s.Expired != nil {
+		return ""
+	}
+	content, err := git.GetBlobContent(ref, s.TranscriptPath())
+	if err != nil {
+		return "transcript missing"
+	}
+	got := ComputeChain(s.Tool, content, s.Chain.Entries)
+	switch {
+	case got.Entries < s.Chain.Entries:
+		return fmt.Sprintf("transcript has %d entries, note sealed %d", got.Entries, s.Chain.Entries)
+	case got.Head != s.Chain.Head:
+		return "transcript modified outside the redaction log"
+	}
+	return ""
+}
+
+// RecordRedaction re-seals every note referencing the transcript at path
+// after a sanctioned change to content, logging action and reason in each
+// note. A "restore" reverts to the chain from before the last quarantine.
+func RecordRedaction(path, action, reason string, content []byte) error {
+	commits, err := ListCommits("--all", 0)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range commits {
+		changed := false
+		for i, s := range c.Note.Sessions {
+			if s.TranscriptPath() != path || s.Chain == nil {
+				continue
+			}
+
+			limit := s.Chain.Entries
+			if action == "restore" {
+				if prev := c.Note.lastQuarantined(path); prev != nil {
+					limit = prev.Entries
+				}
+			}
+			previous := *s.Chain
+			chain := ComputeChain(s.Tool, content, limit)
+			c.Note.Sessions[i].Chain = &chain
+			size := MeasureTranscript(s.Tool, content)
+			c.Note.Sessions[i].Size = &size
+
+			c.Note.Redactions = append(c.Note.Redactions, RedactionEvent{
+				Path:     path,
+				Action:   action,
+				Reason:   reason,
+				By:       actor(),
+				At:       time.Now().UTC(),
+				Previous: &previous,
+			})
+			changed = true
+		}
+		if changed {
+			if err := writeNote(c.SHA, c.Note); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// lastQuarantined returns the chain recorded before the latest quarantine
+// of path, if any
+func (n *PromptStoryNote) lastQuarantined(path string) *Chain {
+	for i := len(n.Redactions) - 1; i >= 0; i-- {
+		if r := n.Redactions[i]; r.Path == path && r.Action == "quarantine" {
+			return r.Previous
+		}
+	}
+	return nil
+}
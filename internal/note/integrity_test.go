@@ -0,0 +1,56 @@
+package note
+
+import "testing"
+
+func TestComputeChain(t *testing.T) {
+	content := []byte("{\"a\":1}\n{\"b\":2}\n\n{\"c\":3}\n")
+
+	full := ComputeChain("claude-code", content, -1)
+	if full.Entries != 3 {
+		t.Fatalf("Entries = %d, want 3", full.Entries)
+	}
+
+	// Appending entries must not change the chain of the sealed prefix
+	grown := append(append([]byte{}, content...), []byte("{\"d\":4}\n")...)
+	if got := ComputeChain("claude-code", grown, 3); got != full {
+		t.Errorf("prefix chain changed after append: %+v vs %+v", got, full)
+	}
+
+	// Editing any entry changes the head
+	edited := []byte("{\"a\":1}\n{\"b\":9}\n{\"c\":3}\n")
+	if got := ComputeChain("claude-code", edited, -1); got.Head == full.Head {
+		t.Error("expected head to change after edit")
+	}
+
+	empty := ComputeChain("claude-code", nil, 5)
+	if empty.Entries != 0 || len(empty.Head) != 64 {
+		t.Errorf("empty chain = %+v", empty)
+	}
+}
+
+func TestLastQuarantined(t *testing.T) {
+	before := &Chain{Entries: 7, Head: "abc"}
+	n := &PromptStoryNote{Redactions: []RedactionEvent{
+		{Path: "claude-code/s1.jsonl", Action: "redact", Previous: &Chain{Entries: 7, Head: "old"}},
+		{Path: "claude-code/s1.jsonl", Action: "quarantine", Previous: before},
+		{Path: "claude-code/s2.jsonl", Action: "quarantine", Previous: &Chain{Entries: 1}},
+	}}
+
+	if got := n.lastQuarantined("claude-code/s1.jsonl"); got != before {
+		t.Errorf("lastQuarantined = %+v, want %+v", got, before)
+	}
+	if got := n.lastQuarantined("cursor/x.jsonl"); got != nil {
+		t.Errorf("lastQuarantined for unknown path = %+v, want nil", got)
+	}
+}
+
+func TestCheckRedactionReason(t *testing.T) {
+	for _, reason := range []string{"", "secret", "personal", "irrelevant"} {
+		if err := CheckRedactionReason(reason); err != nil {
+			t.Errorf("CheckRedactionReason(%q) = %v", reason, err)
+		}
+	}
+	if err := CheckRedactionReason("oops"); err == nil {
+		t.Error("expected error for unknown reason")
+	}
+}
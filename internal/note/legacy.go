@@ -0,0 +1,180 @@
+package note
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+	"gopkg.in/yaml.v3"
+)
+
+// LegacyNotesRef is git's default notes ref, where early versions attached
+// prompt-story notes
+const LegacyNotesRef = "refs/notes/commits"
+
+// ErrNoNote is returned by LoadNote when a commit has no prompt-story note
+var ErrNoNote = errors.New("no prompt-story note found")
+
+// legacyNote is the early YAML note schema: the same fields as
+// PromptStoryNote, with "v" absent or 0
+type legacyNote struct {
+	Version   int       `yaml:"v"`
+	StartWork time.Time `yaml:"start_work"`
+	Sessions  []struct {
+		Tool     string    `yaml:"tool"`
+		ID       string    `yaml:"id"`
+		Path     string    `yaml:"path"`
+		Created  time.Time `yaml:"created"`
+		Modified time.Time `yaml:"modified"`
+	} `yaml:"sessions"`
+}
+
+// LoadNote reads the prompt-story note on sha. Notes in the legacy YAML
+// format, or found only under LegacyNotesRef, are converted on the fly and
+// reported with legacy set. A note written by a newer release prints a
+// one-time version skew warning.
+func LoadNote(sha string) (psNote *PromptStoryNote, legacy bool, err error) {
+	content, err := GetNote(sha)
+	if err == nil {
+		psNote, legacy, err = ParseAnyNote([]byte(content))
+		if err == nil {
+			warnVersionSkew(psNote)
+		}
+		return psNote, legacy, err
+	}
+
+	content, lerr := git.GetNote(LegacyNotesRef, sha)
+	if lerr != nil {
+		return nil, false, fmt.Errorf("%w for commit %s", ErrNoNote, sha[:7])
+	}
+	psNote, _, err = ParseAnyNote([]byte(content))
+	if err != nil {
+		// An unrelated note under git's default ref
+		return nil, false, fmt.Errorf("%w for commit %s", ErrNoNote, sha[:7])
+	}
+	return psNote, true, nil
+}
+
+// ParseAnyNote parses a note in the current JSON format or the legacy YAML
+// one; legacy reports the latter
+func ParseAnyNote(data []byte) (psNote *PromptStoryNote, legacy bool, err error) {
+	if n, jerr := ParseNote(data); jerr == nil {
+		return n, false, nil
+	}
+
+	psNote, err = ParseLegacyNote(data)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to parse note: %w", err)
+	}
+	return psNote, true, nil
+}
+
+// ParseLegacyNote converts a legacy YAML note to the current schema
+func ParseLegacyNote(data []byte) (*PromptStoryNote, error) {
+	var old legacyNote
+	if err := yaml.Unmarshal(data, &old); err != nil {
+		return nil, err
+	}
+	if len(old.Sessions) == 0 {
+		return nil, fmt.Errorf("not a prompt-story note")
+	}
+
+	n := &PromptStoryNote{Version: 1, StartWork: old.StartWork}
+	for _, s := range old.Sessions {
+		if s.Tool == "" || s.ID == "" {
+			return nil, fmt.Errorf("not a prompt-story note")
+		}
+		n.Sessions = append(n.Sessions, SessionEntry{
+			Tool:     s.Tool,
+			ID:       s.ID,
+			Path:     s.Path,
+			Created:  s.Created,
+			Modified: s.Modified,
+		})
+	}
+	return n, nil
+}
+
+// Conversion reports one legacy note rewritten by MigrateLegacyNotes
+type Conversion struct {
+	SHA    string
+	Source string // Ref the legacy note was read from
+	Merged bool   // Combined with an existing current-format note
+}
+
+// MigrateLegacyNotes rewrites legacy notes as JSON under NotesRef: YAML
+// notes under NotesRef itself, and prompt-story notes under LegacyNotesRef
+// (merged into any current note on the same commit). With removeLegacy the
+// converted notes are removed from LegacyNotesRef; other notes there are
+// never touched. With dryRun nothing is written.
+func MigrateLegacyNotes(dryRun, removeLegacy bool) ([]Conversion, error) {
+	var conversions []Conversion
+
+	current, err := ListNoteBlobs(NotesRef)
+	if err != nil {
+		return nil, err
+	}
+	for sha, blob := range current {
+		content, err := git.ReadBlob(blob)
+		if err != nil {
+			return nil, err
+		}
+		psNote, legacy, err := ParseAnyNote(content)
+		if err != nil || !legacy {
+			continue
+		}
+		if !dryRun {
+			if err := writeNote(sha, psNote); err != nil {
+				return nil, err
+			}
+		}
+		conversions = append(conversions, Conversion{SHA: sha, Source: NotesRef})
+	}
+
+	old, err := ListNoteBlobs(LegacyNotesRef)
+	if err != nil {
+		return nil, err
+	}
+	for sha, blob := range old {
+		content, err := git.ReadBlob(blob)
+		if err != nil {
+			return nil, err
+		}
+		psNote, _, err := ParseAnyNote(content)
+		if err != nil || len(psNote.Sessions) == 0 {
+			continue // Not a prompt-story note
+		}
+
+		c := Conversion{SHA: sha, Source: LegacyNotesRef}
+		if existing, ok := current[sha]; ok {
+			if data, err := git.ReadBlob(existing); err == nil {
+				if cur, _, err := ParseAnyNote(data); err == nil {
+					psNote = MergeNotes([]*PromptStoryNote{cur, psNote})
+					c.Merged = true
+				}
+			}
+		}
+
+		if !dryRun {
+			if err := writeNote(sha, psNote); err != nil {
+				return nil, err
+			}
+			if removeLegacy {
+				if _, err := git.RunGit("notes", "--ref="+LegacyNotesRef, "remove", sha); err != nil {
+					return nil, fmt.Errorf("failed to remove legacy note on %s: %w", sha[:7], err)
+				}
+			}
+		}
+		conversions = append(conversions, c)
+	}
+
+	sort.Slice(conversions, func(i, j int) bool {
+		if conversions[i].Source != conversions[j].Source {
+			return conversions[i].Source > conversions[j].Source
+		}
+		return conversions[i].SHA < conversions[j].SHA
+	})
+	return conversions, nil
+}
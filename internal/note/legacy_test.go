@@ -0,0 +1,171 @@
+package note
+
+import (
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+)
+
+func TestParseAnyNote_JSON(t *testing.T) {
+	data := []byte(`{"v":1,"start_work":"2025-01-15T09:00:00Z","sessions":[{"tool":"claude-code","id":"s1"}]}`)
+	n, legacy, err := ParseAnyNote(data)
+	if err != nil || legacy {
+		t.Fatalf("ParseAnyNote() = legacy %v, err %v", legacy, err)
+	}
+	if len(n.Sessions) != 1 || n.Sessions[0].ID != "s1" {
+		t.Errorf("unexpected sessions: %+v", n.Sessions)
+	}
+}
+
+func TestParseAnyNote_LegacyYAML(t *testing.T) {
+	data := []byte(`start_work: 2025-01-15T09:00:00Z
+sessions:
+  - tool: claude-code
+    id: s1
+    path: claude-code/s1.jsonl
+    created: 2025-01-15T10:00:00Z
+    modified: 2025-01-15T10:02:00Z
+`)
+	n, legacy, err := ParseAnyNote(data)
+	if err != nil || !legacy {
+		t.Fatalf("ParseAnyNote() = legacy %v, err %v", legacy, err)
+	}
+	if n.Version != 1 || n.StartWork.IsZero() {
+		t.Errorf("expected converted note, got %+v", n)
+	}
+	if s := n.Sessions[0]; s.Tool != "claude-code" || s.TranscriptPath() != "claude-code/s1.jsonl" || s.Modified.IsZero() {
+		t.Errorf("unexpected session: %+v", s)
+	}
+}
+
+func TestParseAnyNote_UnrelatedNote(t *testing.T) {
+	for _, data := range []string{"Reviewed-by: someone", "just some text", "sessions:\n  - foo: bar\n"} {
+		if _, _, err := ParseAnyNote([]byte(data)); err == nil {
+			t.Errorf("ParseAnyNote(%q) expected error", data)
+		}
+	}
+}
+
+func TestParseAnyNote_Concatenated(t *testing.T) {
+	data := `{"v": 1, "sessions": [{"tool": "claude-code", "id": "a"}]}
+{"v": 1, "sessions": [{"tool": "claude-code", "id": "b"}]}`
+
+	psNote, legacy, err := ParseAnyNote([]byte(data))
+	if err != nil || legacy {
+		t.Fatalf("ParseAnyNote() = legacy %v, error %v", legacy, err)
+	}
+	if len(psNote.Sessions) != 2 {
+		t.Errorf("expected 2 sessions, got %d", len(psNote.Sessions))
+	}
+}
+
+func TestMigrateLegacyNotes(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Chdir(t.TempDir())
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	for _, kv := range []string{"GIT_AUTHOR_NAME=A", "GIT_AUTHOR_EMAIL=a@example.com", "GIT_COMMITTER_NAME=A", "GIT_COMMITTER_EMAIL=a@example.com"} {
+		k, v, _ := strings.Cut(kv, "=")
+		t.Setenv(k, v)
+	}
+	run := func(args ...string) string {
+		t.Helper()
+		out, err := git.RunGit(args...)
+		if err != nil {
+			t.Fatalf("git %v: %v", args, err)
+		}
+		return out
+	}
+	commit := func(msg string) string {
+		t.Helper()
+		run("commit", "-q", "--allow-empty", "-m", msg)
+		return run("rev-parse", "HEAD")
+	}
+	yamlNote := func(id string) string {
+		return "start_work: 2025-01-15T09:00:00Z\nsessions:\n  - tool: claude-code\n    id: " + id + "\n"
+	}
+	sessionIDs := func(sha string) []string {
+		t.Helper()
+		psNote, legacy, err := LoadNote(sha)
+		if err != nil || legacy {
+			t.Fatalf("LoadNote(%s) = legacy %v, err %v; want a current note", sha[:7], legacy, err)
+		}
+		var ids []string
+		for _, s := range psNote.Sessions {
+			ids = append(ids, s.ID)
+		}
+		return ids
+	}
+
+	run("init", "-q", "-b", "main")
+	inPlace := commit("yaml under the prompt-story ref")
+	run("notes", "--ref="+NotesRef, "add", "-m", yamlNote("a"), inPlace)
+	moved := commit("note under the default ref")
+	run("notes", "--ref="+LegacyNotesRef, "add", "-m", `{"v":1,"sessions":[{"tool":"claude-code","id":"b"}]}`, moved)
+	merged := commit("notes under both refs")
+	run("notes", "--ref="+LegacyNotesRef, "add", "-m", yamlNote("c"), merged)
+	run("notes", "--ref="+NotesRef, "add", "-m", `{"v":1,"sessions":[{"tool":"claude-code","id":"d"}]}`, merged)
+	unrelated := commit("someone else's note")
+	run("notes", "--ref="+LegacyNotesRef, "add", "-m", "Reviewed-by: someone", unrelated)
+	current := commit("current note")
+	run("notes", "--ref="+NotesRef, "add", "-m", `{"v":1,"sessions":[{"tool":"claude-code","id":"e"}]}`, current)
+
+	want := []Conversion{
+		{SHA: inPlace, Source: NotesRef},
+		{SHA: moved, Source: LegacyNotesRef},
+		{SHA: merged, Source: LegacyNotesRef, Merged: true},
+	}
+	// Sorted by source, the prompt-story ref first, then by SHA
+	if want[1].SHA > want[2].SHA {
+		want[1], want[2] = want[2], want[1]
+	}
+
+	notesBefore := run("rev-parse", NotesRef)
+	got, err := MigrateLegacyNotes(true, true)
+	if err != nil {
+		t.Fatalf("MigrateLegacyNotes(dry run) error: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("MigrateLegacyNotes(dry run) = %+v, want %+v", got, want)
+	}
+	if after := run("rev-parse", NotesRef); after != notesBefore {
+		t.Error("dry run wrote notes")
+	}
+	if _, legacy, _ := LoadNote(moved); !legacy {
+		t.Error("dry run moved a legacy note")
+	}
+
+	got, err = MigrateLegacyNotes(false, true)
+	if err != nil {
+		t.Fatalf("MigrateLegacyNotes() error: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("MigrateLegacyNotes() = %+v, want %+v", got, want)
+	}
+	for sha, ids := range map[string][]string{inPlace: {"a"}, moved: {"b"}, merged: {"d", "c"}, current: {"e"}} {
+		if got := sessionIDs(sha); !reflect.DeepEqual(got, ids) {
+			t.Errorf("sessions on %s = %v, want %v", sha[:7], got, ids)
+		}
+	}
+	if content, err := git.GetNote(NotesRef, inPlace); err != nil || !strings.HasPrefix(strings.TrimSpace(content), "{") {
+		t.Errorf("note on %s = %q, %v; want JSON", inPlace[:7], content, err)
+	}
+
+	// Only converted notes leave the default ref
+	if _, err := git.GetNote(LegacyNotesRef, moved); err == nil {
+		t.Error("converted note left under the default ref")
+	}
+	if content, err := git.GetNote(LegacyNotesRef, unrelated); err != nil || strings.TrimSpace(content) != "Reviewed-by: someone" {
+		t.Errorf("unrelated note = %q, %v; want it untouched", content, err)
+	}
+
+	// Nothing is left to convert
+	if got, err := MigrateLegacyNotes(false, true); err != nil || len(got) != 0 {
+		t.Errorf("second MigrateLegacyNotes() = %+v, %v; want nothing", got, err)
+	}
+}
@@ -0,0 +1,172 @@
+package note
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+)
+
+// ListedCommit is a commit together with its prompt-story note
+type ListedCommit struct {
+	SHA     string
+	Subject string
+	Date    time.Time
+	Note    *PromptStoryNote
+}
+
+// ListCommits returns the commits reachable by rangeSpec (a ref or range,
+// newest first) that carry a prompt-story note. A positive limit caps the
+// number returned.
+func ListCommits(rangeSpec string, limit int) ([]ListedCommit, error) {
+	// Map annotated commit -> note blob once instead of a lookup per commit
+	noted, err := ListNoteBlobs(NotesRef)
+	if err != nil {
+		return nil, err
+	}
+	if len(noted) == 0 {
+		return nil, nil
+	}
+
+	out, err := git.RunGit("log", "--format=%H%x00%ct%x00%s", rangeSpec, "--")
+	if err != nil {
+		return nil, fmt.Errorf("git log %s: %w", rangeSpec, err)
+	}
+	return listCommits(out, noted, limit), nil
+}
+
+// ListCommitsOf is ListCommits for resolved commits, e.g. those of a
+// git.CommitSet, newest first
+func ListCommitsOf(shas []string, limit int) ([]ListedCommit, error) {
+	noted, err := ListNoteBlobs(NotesRef)
+	if err != nil {
+		return nil, err
+	}
+	if len(noted) == 0 {
+		return nil, nil
+	}
+
+	out, err := git.RunGitInput(strings.Join(shas, "\n"), "log", "--no-walk=sorted", "--stdin", "--format=%H%x00%ct%x00%s")
+	if err != nil {
+		return nil, fmt.Errorf("git log: %w", err)
+	}
+	return listCommits(out, noted, limit), nil
+}
+
+// listCommits reads the noted commits of git log output with the format
+// of ListCommits
+func listCommits(out string, noted map[string]string, limit int) []ListedCommit {
+	var commits []ListedCommit
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.SplitN(line, "\x00", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		blob, ok := noted[fields[0]]
+		if !ok {
+			continue
+		}
+
+		content, err := git.RunGit("cat-file", "-p", blob)
+		if err != nil {
+			continue
+		}
+		psNote, err := ParseNote([]byte(content))
+		if err != nil {
+			continue // Not a JSON prompt-story note
+		}
+		warnVersionSkew(psNote)
+
+		unix, _ := strconv.ParseInt(fields[1], 10, 64)
+		commits = append(commits, ListedCommit{
+			SHA:     fields[0],
+			Subject: fields[2],
+			Date:    time.Unix(unix, 0),
+			Note:    psNote,
+		})
+		if limit > 0 && len(commits) >= limit {
+			break
+		}
+	}
+	return commits
+}
+
+// RecentCommit is a commit offered for selection, noted or not
+type RecentCommit struct {
+	SHA      string
+	Subject  string
+	Date     time.Time
+	HasNote  bool
+	Sessions int
+}
+
+// RecentCommits returns up to limit commits from HEAD, newest first,
+// flagging those that already carry a prompt-story note
+func RecentCommits(limit int) ([]RecentCommit, error) {
+	noted, err := ListNoteBlobs(NotesRef)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := git.RunGit("log", "--format=%H%x00%ct%x00%s", fmt.Sprintf("-n%d", limit), "HEAD", "--")
+	if err != nil {
+		return nil, fmt.Errorf("git log: %w", err)
+	}
+
+	var commits []RecentCommit
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.SplitN(line, "\x00", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		unix, _ := strconv.ParseInt(fields[1], 10, 64)
+		c := RecentCommit{SHA: fields[0], Subject: fields[2], Date: time.Unix(unix, 0)}
+		if blob, ok := noted[c.SHA]; ok {
+			c.HasNote = true
+			if content, err := git.RunGit("cat-file", "-p", blob); err == nil {
+				if psNote, err := ParseNote([]byte(content)); err == nil {
+					c.Sessions = len(psNote.Sessions)
+				}
+			}
+		}
+		commits = append(commits, c)
+	}
+	return commits, nil
+}
+
+// SessionsByAuthor returns the sessions whose author or OS user contains
+// pattern; an empty pattern returns all sessions
+func (c ListedCommit) SessionsByAuthor(pattern string) []SessionEntry {
+	if pattern == "" {
+		return c.Note.Sessions
+	}
+	var matched []SessionEntry
+	for _, s := range c.Note.Sessions {
+		if s.MatchesAuthor(pattern) {
+			matched = append(matched, s)
+		}
+	}
+	return matched
+}
+
+// ListNoteBlobs returns annotated commit SHA -> note blob SHA for ref
+func ListNoteBlobs(ref string) (map[string]string, error) {
+	if sha, _ := git.GetRef(ref); sha == "" {
+		return nil, nil
+	}
+	out, err := git.RunGit("notes", "--ref="+ref, "list")
+	if err != nil {
+		return nil, fmt.Errorf("git notes list: %w", err)
+	}
+
+	blobs := make(map[string]string)
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 {
+			blobs[fields[1]] = fields[0]
+		}
+	}
+	return blobs, nil
+}
@@ -1,7 +1,9 @@
 package note
 
 import (
+	"bytes"
 	"encoding/json"
+	"io"
 	"sort"
 )
 
@@ -9,7 +11,10 @@ import (
 // Used when commits are squashed to preserve all session references.
 // - Sessions are combined and deduplicated by ID
 // - StartWork is set to the earliest timestamp
-// - Version is set to the latest version
+// - ClockSkew is set to the largest tolerance
+// - Version and CreatedBy are set to the latest version
+// - A legal hold on any note carries over, with all audit and redaction logs
+// - Tags are combined
 func MergeNotes(notes []*PromptStoryNote) *PromptStoryNote {
 	if len(notes) == 0 {
 		return nil
@@ -23,6 +28,7 @@ func MergeNotes(notes []*PromptStoryNote) *PromptStoryNote {
 		Version:   1,
 		Sessions:  make([]SessionEntry, 0),
 		StartWork: notes[0].StartWork,
+		CreatedBy: notes[0].CreatedBy,
 	}
 
 	// Track seen session IDs to deduplicate
@@ -34,10 +40,24 @@ func MergeNotes(notes []*PromptStoryNote) *PromptStoryNote {
 			merged.StartWork = note.StartWork
 		}
 
+		// Keep the widest tolerance, so no note's entries fall outside
+		if note.ClockSkew > merged.ClockSkew {
+			merged.ClockSkew = note.ClockSkew
+		}
+
 		// Use the latest version
 		if note.Version > merged.Version {
 			merged.Version = note.Version
 		}
+		merged.CreatedBy = newerVersion(merged.CreatedBy, note.CreatedBy)
+
+		// A hold on any squashed note keeps applying to the result
+		if note.Hold != nil && merged.Hold == nil {
+			merged.Hold = note.Hold
+		}
+		merged.HoldAudit = append(merged.HoldAudit, note.HoldAudit...)
+		merged.Redactions = append(merged.Redactions, note.Redactions...)
+		merged.AddTags(note.Tags...)
 
 		// Add sessions, deduplicating by ID
 		for _, session := range note.Sessions {
@@ -56,11 +76,22 @@ func MergeNotes(notes []*PromptStoryNote) *PromptStoryNote {
 	return merged
 }
 
-// ParseNote parses a JSON note into a PromptStoryNote
+// ParseNote parses a JSON note into a PromptStoryNote. A note holding
+// several JSON documents, as left by `git notes merge -s union` or
+// `git notes append`, is parsed as the MergeNotes of them.
 func ParseNote(data []byte) (*PromptStoryNote, error) {
-	var note PromptStoryNote
-	if err := json.Unmarshal(data, &note); err != nil {
-		return nil, err
+	var notes []*PromptStoryNote
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for {
+		var note PromptStoryNote
+		err := dec.Decode(&note)
+		if err == io.EOF && len(notes) > 0 {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		notes = append(notes, &note)
 	}
-	return &note, nil
+	return MergeNotes(notes), nil
 }
@@ -146,6 +146,38 @@ func TestMergeNotes_EarliestStartWork(t *testing.T) {
 	}
 }
 
+func TestMergeNotes_LargestClockSkew(t *testing.T) {
+	note1 := &PromptStoryNote{
+		Version:   1,
+		ClockSkew: 2 * time.Minute,
+		Sessions:  []SessionEntry{{Tool: "claude-code", ID: "session-A", Created: time.Date(2025, 1, 15, 9, 15, 0, 0, time.UTC)}},
+	}
+	note2 := &PromptStoryNote{
+		Version:   1,
+		ClockSkew: 10 * time.Minute,
+		Sessions:  []SessionEntry{{Tool: "claude-code", ID: "session-B", Created: time.Date(2025, 1, 15, 10, 15, 0, 0, time.UTC)}},
+	}
+	note3 := &PromptStoryNote{
+		Version:  1,
+		Sessions: []SessionEntry{{Tool: "cursor", ID: "session-C", Created: time.Date(2025, 1, 15, 11, 15, 0, 0, time.UTC)}},
+	}
+
+	result := MergeNotes([]*PromptStoryNote{note1, note2, note3})
+	if result.ClockSkew != 10*time.Minute {
+		t.Errorf("Expected ClockSkew 10m (largest), got %v", result.ClockSkew)
+	}
+
+	// Concatenated notes are merged the same way
+	psNote, err := ParseNote([]byte(`{"v": 1, "clock_skew": 300000000000, "sessions": [{"tool": "claude-code", "id": "a"}]}` + "\n" +
+		`{"v": 1, "sessions": [{"tool": "cursor", "id": "b"}]}`))
+	if err != nil {
+		t.Fatalf("ParseNote() error = %v", err)
+	}
+	if psNote.ClockSkew != 5*time.Minute {
+		t.Errorf("Expected ClockSkew 5m from concatenated notes, got %v", psNote.ClockSkew)
+	}
+}
+
 func TestMergeNotes_LatestVersion(t *testing.T) {
 	note1 := &PromptStoryNote{
 		Version:   1,
@@ -422,3 +454,45 @@ func TestMergeNotes_ResultIsSerializable(t *testing.T) {
 		t.Error("Expected 'sessions' field in JSON")
 	}
 }
+
+func TestMergeNotes_KeepsHold(t *testing.T) {
+	at := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+	held := &PromptStoryNote{
+		Version:   1,
+		Sessions:  []SessionEntry{{Tool: "claude-code", ID: "a"}},
+		Hold:      &Hold{Reason: "case 42", By: "legal", At: at},
+		HoldAudit: []HoldEvent{{Action: "hold", By: "legal", At: at}},
+	}
+	plain := &PromptStoryNote{Version: 1, Sessions: []SessionEntry{{Tool: "claude-code", ID: "b"}}}
+
+	merged := MergeNotes([]*PromptStoryNote{plain, held})
+	if !merged.OnHold() || merged.Hold.Reason != "case 42" {
+		t.Errorf("expected hold to carry over, got %+v", merged.Hold)
+	}
+	if len(merged.HoldAudit) != 1 {
+		t.Errorf("expected audit trail to carry over, got %d events", len(merged.HoldAudit))
+	}
+}
+
+func TestParseNote_Concatenated(t *testing.T) {
+	a := `{"v": 1, "start_work": "2025-01-15T09:00:00Z", "sessions": [{"tool": "claude-code", "id": "a", "created": "2025-01-15T09:10:00Z"}]}`
+	b := `{"v": 1, "start_work": "2025-01-15T08:00:00Z", "sessions": [{"tool": "cursor", "id": "b", "created": "2025-01-15T08:30:00Z"}]}`
+
+	psNote, err := ParseNote([]byte(a + "\n\n" + b + "\n"))
+	if err != nil {
+		t.Fatalf("ParseNote() error = %v", err)
+	}
+	if len(psNote.Sessions) != 2 || psNote.Sessions[0].ID != "b" || psNote.Sessions[1].ID != "a" {
+		t.Errorf("expected sessions b, a; got %+v", psNote.Sessions)
+	}
+	if !psNote.StartWork.Equal(time.Date(2025, 1, 15, 8, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected earliest start_work, got %v", psNote.StartWork)
+	}
+
+	if _, err := ParseNote([]byte(a + "\n{not json")); err == nil {
+		t.Error("expected an error for trailing garbage")
+	}
+	if _, err := ParseNote(nil); err == nil {
+		t.Error("expected an error for an empty note")
+	}
+}
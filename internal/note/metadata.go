@@ -3,6 +3,7 @@ package note
 import (
 	"encoding/json"
 	"fmt"
+	"os/user"
 	"sort"
 	"strings"
 	"time"
@@ -16,6 +17,24 @@ type PromptStoryNote struct {
 	Version   int            `json:"v"`
 	StartWork time.Time      `json:"start_work"`
 	Sessions  []SessionEntry `json:"sessions"`
+
+	// ClockSkew is the tolerance the sessions were captured with, see
+	// session.ClockSkew; readers widen the work period by it too
+	ClockSkew time.Duration `json:"clock_skew,omitempty"`
+
+	// CreatedBy is the CLI version that wrote the note
+	CreatedBy string `json:"created_by,omitempty"`
+
+	// Hold is set while the note is under legal hold; HoldAudit records
+	// every hold, release and override
+	Hold      *Hold       `json:"hold,omitempty"`
+	HoldAudit []HoldEvent `json:"hold_audit,omitempty"`
+
+	// Redactions logs sanctioned transcript changes (see RecordRedaction)
+	Redactions []RedactionEvent `json:"redactions,omitempty"`
+
+	// Tags categorize the work (refactor, bugfix, ...), see NormalizeTag
+	Tags []string `json:"tags,omitempty"`
 }
 
 // SessionEntry describes one LLM session referenced by the note
@@ -25,6 +44,54 @@ type SessionEntry struct {
 	Path     string    `json:"path"`
 	Created  time.Time `json:"created"`
 	Modified time.Time `json:"modified"`
+
+	// Who captured the session: the OS account and git author identity at
+	// capture time. Several people may commit from one checkout.
+	OSUser string `json:"os_user,omitempty"`
+	Author string `json:"author,omitempty"`
+
+	// Expired is set when gc removed the transcript under the retention
+	// policy; the entry itself is kept as a record of the session
+	Expired *time.Time `json:"expired,omitempty"`
+
+	// Chain seals the transcript as captured, see ComputeChain
+	Chain *Chain `json:"chain,omitempty"`
+
+	// Size accounts for the stored transcript, see MeasureTranscript
+	Size *SizeStats `json:"size,omitempty"`
+
+	// Context is the repository state the session started in
+	Context *SessionContext `json:"context,omitempty"`
+}
+
+// CaptureOwner returns the OS user name and git author identity ("Name
+// <email>") of whoever is capturing sessions now. Either may be empty.
+func CaptureOwner() (osUser, author string) {
+	if u, err := user.Current(); err == nil {
+		osUser = u.Username
+	}
+	author, _ = git.GetAuthorIdent()
+	return osUser, author
+}
+
+// Owner formats who captured the session for display, or "" if unknown
+func (e SessionEntry) Owner() string {
+	switch {
+	case e.Author != "" && e.OSUser != "":
+		return fmt.Sprintf("%s (%s)", e.Author, e.OSUser)
+	case e.Author != "":
+		return e.Author
+	default:
+		return e.OSUser
+	}
+}
+
+// MatchesAuthor reports whether the session's author or OS user contains
+// pattern, ignoring case
+func (e SessionEntry) MatchesAuthor(pattern string) bool {
+	pattern = strings.ToLower(pattern)
+	return strings.Contains(strings.ToLower(e.Author), pattern) ||
+		strings.Contains(strings.ToLower(e.OSUser), pattern)
 }
 
 // NewPromptStoryNote creates a new note from discovered sessions
@@ -32,8 +99,10 @@ type SessionEntry struct {
 // Optional startTime can be provided to use an explicit start time instead of calculating from git
 func NewPromptStoryNote(sessions []session.ClaudeSession, isAmend bool, startTime ...time.Time) *PromptStoryNote {
 	n := &PromptStoryNote{
-		Version:  1,
-		Sessions: make([]SessionEntry, 0, len(sessions)),
+		Version:   1,
+		Sessions:  make([]SessionEntry, 0, len(sessions)),
+		CreatedBy: CLIVersion,
+		ClockSkew: session.ClockSkew(),
 	}
 
 	// Use explicit start time if provided, otherwise calculate from git
@@ -43,13 +112,16 @@ func NewPromptStoryNote(sessions []session.ClaudeSession, isAmend bool, startTim
 		n.StartWork, _ = git.CalculateWorkStartTime(isAmend)
 	}
 
+	osUser, author := CaptureOwner()
 	for _, s := range sessions {
 		n.Sessions = append(n.Sessions, SessionEntry{
-			Tool:     "claude-code",
+			Tool:     s.ToolName(),
 			ID:       s.ID,
-			Path:     GetTranscriptPath("claude-code", s.ID),
+			Path:     GetTranscriptPath(s.ToolName(), s.ID),
 			Created:  s.Created,
 			Modified: s.Modified,
+			OSUser:   osUser,
+			Author:   author,
 		})
 	}
 
@@ -61,9 +133,20 @@ func (n *PromptStoryNote) ToJSON() ([]byte, error) {
 	return json.MarshalIndent(n, "", "  ")
 }
 
+// DefaultSummaryFormat is the summary line format used unless configured
+const DefaultSummaryFormat = "{tools} ({prompts} user prompts) [{version}]"
+
 // GenerateSummary creates the commit message line
 // Returns: "Prompt-Story: Used Claude Code (N prompts) [version]" or "Prompt-Story: none [version]"
 func (n *PromptStoryNote) GenerateSummary(promptCount int, version string) string {
+	return n.FormatSummary(DefaultSummaryFormat, promptCount, "", version)
+}
+
+// FormatSummary creates the commit message line, expanding the
+// placeholders of format after the fixed "Prompt-Story: Used" marker that
+// hooks and CI look for. Placeholders: {tools} (display names), {tool-ids},
+// {prompts}, {sessions}, {note} (abbreviated note SHA) and {version}.
+func (n *PromptStoryNote) FormatSummary(format string, promptCount int, noteSHA, version string) string {
 	if len(n.Sessions) == 0 {
 		return fmt.Sprintf("Prompt-Story: none [%s]", version)
 	}
@@ -74,13 +157,40 @@ func (n *PromptStoryNote) GenerateSummary(promptCount int, version string) strin
 		tools[s.Tool] = true
 	}
 
-	var toolNames []string
+	var toolIDs, toolNames []string
 	for t := range tools {
+		toolIDs = append(toolIDs, t)
 		toolNames = append(toolNames, FormatToolName(t))
 	}
-	sort.Strings(toolNames) // Consistent ordering
+	sort.Strings(toolIDs) // Consistent ordering
+	sort.Strings(toolNames)
+
+	if len(noteSHA) > 7 {
+		noteSHA = noteSHA[:7]
+	}
+	expanded := strings.NewReplacer(
+		"{tools}", strings.Join(toolNames, ", "),
+		"{tool-ids}", strings.Join(toolIDs, ","),
+		"{prompts}", fmt.Sprint(promptCount),
+		"{sessions}", fmt.Sprint(len(n.Sessions)),
+		"{note}", noteSHA,
+		"{version}", version,
+	).Replace(format)
 
-	return fmt.Sprintf("Prompt-Story: Used %s (%d user prompts) [%s]", strings.Join(toolNames, ", "), promptCount, version)
+	// The summary is a single trailer line
+	expanded = strings.Join(strings.Fields(expanded), " ")
+	if expanded == "" {
+		return "Prompt-Story: Used"
+	}
+	return "Prompt-Story: Used " + expanded
+}
+
+// TranscriptPath returns the session's path inside the transcript tree
+func (e SessionEntry) TranscriptPath() string {
+	if e.Path != "" {
+		return strings.TrimPrefix(e.Path, TranscriptsRef+"/")
+	}
+	return GetTranscriptPath(e.Tool, e.ID)
 }
 
 // GetTranscriptPath returns the path within the transcript tree for a session
@@ -97,8 +207,18 @@ func FormatToolName(tool string) string {
 		return "Claude Cloud"
 	case "cursor":
 		return "Cursor"
+	case "aider":
+		return "Aider"
+	case "gemini-cli":
+		return "Gemini CLI"
+	case "copilot-chat":
+		return "Copilot Chat"
 	case "codex":
 		return "Codex"
+	case "codex-cloud":
+		return "Codex Cloud"
+	case "github-agent":
+		return "GitHub Agent"
 	default:
 		return tool
 	}
@@ -0,0 +1,79 @@
+package note
+
+import "testing"
+
+func TestSessionEntryOwner(t *testing.T) {
+	tests := []struct {
+		entry SessionEntry
+		want  string
+	}{
+		{SessionEntry{Author: "Jane <jane@example.com>", OSUser: "dev"}, "Jane <jane@example.com> (dev)"},
+		{SessionEntry{Author: "Jane <jane@example.com>"}, "Jane <jane@example.com>"},
+		{SessionEntry{OSUser: "dev"}, "dev"},
+		{SessionEntry{}, ""},
+	}
+	for _, tt := range tests {
+		if got := tt.entry.Owner(); got != tt.want {
+			t.Errorf("Owner() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestSessionEntryMatchesAuthor(t *testing.T) {
+	e := SessionEntry{Author: "Jane Doe <jane@example.com>", OSUser: "builder"}
+
+	for _, pattern := range []string{"", "jane", "EXAMPLE.COM", "build"} {
+		if !e.MatchesAuthor(pattern) {
+			t.Errorf("MatchesAuthor(%q) = false, want true", pattern)
+		}
+	}
+	if e.MatchesAuthor("john") {
+		t.Error("MatchesAuthor(\"john\") = true, want false")
+	}
+}
+
+func TestHeldReferences(t *testing.T) {
+	s1 := SessionEntry{Tool: "claude-code", ID: "s1", Path: TranscriptsRef + "/claude-code/s1.jsonl"}
+	s2 := SessionEntry{Tool: "cursor", ID: "s2"}
+
+	commits := []ListedCommit{
+		{SHA: "held", Note: &PromptStoryNote{Sessions: []SessionEntry{s1}, Hold: &Hold{By: "legal"}}},
+		{SHA: "free", Note: &PromptStoryNote{Sessions: []SessionEntry{s1, s2}}},
+	}
+
+	if got := HeldReferences(commits, []string{"claude-code/s1.jsonl"}); len(got) != 1 || got[0].SHA != "held" {
+		t.Errorf("HeldReferences(s1) = %v, want [held]", got)
+	}
+	if got := HeldReferences(commits, []string{"cursor/s2.jsonl"}); len(got) != 0 {
+		t.Errorf("HeldReferences(s2) = %v, want none", got)
+	}
+}
+
+func TestFormatSummary(t *testing.T) {
+	n := &PromptStoryNote{Sessions: []SessionEntry{
+		{Tool: "cursor", ID: "a"},
+		{Tool: "claude-code", ID: "b"},
+		{Tool: "claude-code", ID: "c"},
+	}}
+	sha := "0123456789abcdef0123456789abcdef01234567"
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{DefaultSummaryFormat, "Prompt-Story: Used Claude Code, Cursor (4 user prompts) [v1.2.3]"},
+		{"{tool-ids}; {sessions} sessions, note {note}", "Prompt-Story: Used claude-code,cursor; 3 sessions, note 0123456"},
+		{"{note}\n{unknown}", "Prompt-Story: Used 0123456 {unknown}"},
+		{"", "Prompt-Story: Used"},
+	}
+	for _, tt := range tests {
+		if got := n.FormatSummary(tt.format, 4, sha, "v1.2.3"); got != tt.want {
+			t.Errorf("FormatSummary(%q) = %q, want %q", tt.format, got, tt.want)
+		}
+	}
+
+	empty := &PromptStoryNote{}
+	if got := empty.FormatSummary("{note}", 0, sha, "v1.2.3"); got != "Prompt-Story: none [v1.2.3]" {
+		t.Errorf("FormatSummary() without sessions = %q", got)
+	}
+}
@@ -0,0 +1,191 @@
+package note
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+	"github.com/QuesmaOrg/git-prompt-story/internal/session"
+)
+
+// Orphan is a transcript in the transcripts tree that no note references,
+// e.g. because the note was deleted. gc only looks at referenced
+// transcripts, so orphans stay until re-linked or removed by hand.
+type Orphan struct {
+	Path        string // tool/id.jsonl
+	Tool        string
+	ID          string
+	Blob        string
+	Size        int
+	Start       time.Time // Time of the first entry
+	End         time.Time // Time of the last entry
+	Entries     int
+	FirstPrompt string
+}
+
+// FindOrphans returns the unreferenced transcripts, oldest first. Notes
+// under LegacyNotesRef count as references.
+func FindOrphans() ([]Orphan, error) {
+	stored, err := StoredTranscripts()
+	if err != nil || len(stored) == 0 {
+		return nil, err
+	}
+
+	referenced := make(map[string]bool)
+	for _, ref := range []string{NotesRef, LegacyNotesRef} {
+		blobs, err := ListNoteBlobs(ref)
+		if err != nil {
+			return nil, err
+		}
+		for _, blob := range blobs {
+			content, err := git.ReadBlob(blob)
+			if err != nil {
+				continue
+			}
+			psNote, _, err := ParseAnyNote(content)
+			if err != nil {
+				continue // Not a prompt-story note
+			}
+			for _, s := range psNote.Sessions {
+				referenced[s.TranscriptPath()] = true
+			}
+		}
+	}
+
+	var orphans []Orphan
+	for path, blob := range stored {
+		if referenced[path] {
+			continue
+		}
+		o, err := describeTranscript(path, blob)
+		if err != nil {
+			return nil, err
+		}
+		orphans = append(orphans, o)
+	}
+	sort.Slice(orphans, func(i, j int) bool {
+		if !orphans[i].Start.Equal(orphans[j].Start) {
+			return orphans[i].Start.Before(orphans[j].Start)
+		}
+		return orphans[i].Path < orphans[j].Path
+	})
+	return orphans, nil
+}
+
+// StoredTranscripts returns transcript path -> blob SHA for every
+// transcript in TranscriptsRef
+func StoredTranscripts() (map[string]string, error) {
+	rootSHA, err := git.GetRef(TranscriptsRef)
+	if err != nil || rootSHA == "" {
+		return nil, nil // Nothing stored locally
+	}
+	rootEntries, err := git.ReadTree(rootSHA)
+	if err != nil {
+		return nil, err
+	}
+
+	stored := make(map[string]string)
+	for _, tool := range rootEntries {
+		if tool.Type != "tree" {
+			continue
+		}
+		entries, err := git.ReadTree(tool.SHA)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			if e.Type == "blob" {
+				stored[tool.Name+"/"+e.Name] = e.SHA
+			}
+		}
+	}
+	return stored, nil
+}
+
+// describeTranscript reads what an orphan listing shows about a transcript
+func describeTranscript(path, blob string) (Orphan, error) {
+	tool, name, _ := strings.Cut(path, "/")
+	o := Orphan{Path: path, Tool: tool, ID: strings.TrimSuffix(name, ".jsonl"), Blob: blob}
+
+	content, err := git.ReadBlob(blob)
+	if err != nil {
+		return o, fmt.Errorf("failed to read transcript %s: %w", path, err)
+	}
+	o.Size = len(content)
+
+	entries, err := session.ParseTranscript(tool, content)
+	if err != nil {
+		return o, nil // Listed without details
+	}
+	for _, e := range entries {
+		if e.Timestamp.IsZero() {
+			continue
+		}
+		o.Entries++
+		if o.Start.IsZero() || e.Timestamp.Before(o.Start) {
+			o.Start = e.Timestamp
+		}
+		if e.Timestamp.After(o.End) {
+			o.End = e.Timestamp
+		}
+		if o.FirstPrompt == "" && e.Type == "user" && e.Message != nil {
+			o.FirstPrompt = e.Message.GetTextContent()
+		}
+	}
+	return o, nil
+}
+
+// FindOrphan returns the transcript at path, orphaned or not
+func FindOrphan(path string) (Orphan, error) {
+	stored, err := StoredTranscripts()
+	if err != nil {
+		return Orphan{}, err
+	}
+	blob, ok := stored[path]
+	if !ok {
+		return Orphan{}, fmt.Errorf("no transcript %s in %s", path, TranscriptsRef)
+	}
+	return describeTranscript(path, blob)
+}
+
+// AttachOrphan links the transcript at path to commit sha, adding a session
+// for it to the commit's note
+func AttachOrphan(path, sha string) error {
+	o, err := FindOrphan(path)
+	if err != nil {
+		return err
+	}
+	if existing, _, err := LoadNote(sha); err == nil {
+		for _, s := range existing.Sessions {
+			if s.TranscriptPath() == path {
+				return fmt.Errorf("the note on %s already references %s", sha[:7], path)
+			}
+		}
+	}
+
+	osUser, author := CaptureOwner()
+	psNote := &PromptStoryNote{
+		Version:   1,
+		CreatedBy: CLIVersion,
+		StartWork: o.Start,
+		Sessions: []SessionEntry{{
+			Tool:     o.Tool,
+			ID:       o.ID,
+			Path:     path,
+			Created:  o.Start,
+			Modified: o.End,
+			OSUser:   osUser,
+			Author:   author,
+		}},
+	}
+	if err := psNote.SealTranscripts(map[string]string{path: o.Blob}); err != nil {
+		return fmt.Errorf("failed to seal transcript: %w", err)
+	}
+	payload, err := psNote.ToJSON()
+	if err != nil {
+		return fmt.Errorf("failed to serialize note: %w", err)
+	}
+	return AttachNote(sha, payload)
+}
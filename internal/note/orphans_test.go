@@ -0,0 +1,115 @@
+package note
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+)
+
+func TestOrphans(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Chdir(t.TempDir())
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	for _, kv := range []string{"GIT_AUTHOR_NAME=A", "GIT_AUTHOR_EMAIL=a@example.com", "GIT_COMMITTER_NAME=A", "GIT_COMMITTER_EMAIL=a@example.com"} {
+		k, v, _ := strings.Cut(kv, "=")
+		t.Setenv(k, v)
+	}
+	run := func(args ...string) string {
+		t.Helper()
+		out, err := git.RunGit(args...)
+		if err != nil {
+			t.Fatalf("git %v: %v", args, err)
+		}
+		return out
+	}
+	transcript := func(prompt string, at time.Time) string {
+		return `{"type":"user","timestamp":"` + at.Format(time.RFC3339) + `","message":{"role":"user","content":"` + prompt + `"}}
+{"type":"assistant","timestamp":"` + at.Add(5*time.Minute).Format(time.RFC3339) + `","message":{"role":"assistant","content":[{"type":"text","text":"Done"}]}}
+`
+	}
+
+	run("init", "-q", "-b", "main")
+	if orphans, err := FindOrphans(); err != nil || len(orphans) != 0 {
+		t.Fatalf("FindOrphans() without transcripts = %+v, %v", orphans, err)
+	}
+
+	base := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+	blobs := make(map[string]string)
+	for path, content := range map[string]string{
+		"claude-code/kept.jsonl":   transcript("Kept prompt", base),
+		"claude-code/legacy.jsonl": transcript("Legacy prompt", base.Add(time.Hour)),
+		"claude-code/late.jsonl":   transcript("Late prompt", base.Add(3*time.Hour)),
+		"claude-code/early.jsonl":  transcript("Early prompt", base.Add(-time.Hour)),
+	} {
+		blob, err := git.HashObject([]byte(content))
+		if err != nil {
+			t.Fatal(err)
+		}
+		blobs[path] = blob
+	}
+	if err := UpdateTranscriptTree(blobs); err != nil {
+		t.Fatal(err)
+	}
+
+	run("commit", "-q", "--allow-empty", "-m", "kept")
+	run("notes", "--ref="+NotesRef, "add", "-m", `{"v":1,"sessions":[{"tool":"claude-code","id":"kept"}]}`)
+	run("commit", "-q", "--allow-empty", "-m", "legacy")
+	run("notes", "--ref="+LegacyNotesRef, "add", "-m", "sessions:\n  - tool: claude-code\n    id: legacy\n")
+	run("commit", "-q", "--allow-empty", "-m", "lost its note")
+	head := run("rev-parse", "HEAD")
+
+	orphans, err := FindOrphans()
+	if err != nil {
+		t.Fatalf("FindOrphans() error: %v", err)
+	}
+	if len(orphans) != 2 || orphans[0].Path != "claude-code/early.jsonl" || orphans[1].Path != "claude-code/late.jsonl" {
+		t.Fatalf("FindOrphans() = %+v, want early and late, oldest first", orphans)
+	}
+	if o := orphans[1]; o.Tool != "claude-code" || o.ID != "late" || o.Entries != 2 || o.FirstPrompt != "Late prompt" ||
+		!o.Start.Equal(base.Add(3*time.Hour)) || !o.End.Equal(base.Add(3*time.Hour+5*time.Minute)) || o.Blob != blobs[o.Path] {
+		t.Errorf("late orphan = %+v", o)
+	}
+
+	if err := AttachOrphan("claude-code/late.jsonl", head); err != nil {
+		t.Fatalf("AttachOrphan() error: %v", err)
+	}
+	psNote, _, err := LoadNote(head)
+	if err != nil {
+		t.Fatalf("LoadNote() after attach error: %v", err)
+	}
+	if len(psNote.Sessions) != 1 || psNote.Sessions[0].ID != "late" || !psNote.StartWork.Equal(base.Add(3*time.Hour)) {
+		t.Errorf("attached note = %+v", psNote)
+	}
+	if problem := VerifyChain(psNote.Sessions[0]); problem != "" {
+		t.Errorf("attached session does not verify: %s", problem)
+	}
+
+	// A second transcript is added to the note already on the commit
+	if err := AttachOrphan("claude-code/early.jsonl", head); err != nil {
+		t.Fatalf("AttachOrphan() onto a note error: %v", err)
+	}
+	if psNote, _, _ = LoadNote(head); len(psNote.Sessions) != 2 {
+		t.Errorf("note after the second attach has %d sessions, want 2", len(psNote.Sessions))
+	}
+	if orphans, err := FindOrphans(); err != nil || len(orphans) != 0 {
+		t.Errorf("FindOrphans() after attaching = %+v, %v; want none", orphans, err)
+	}
+
+	for _, tt := range []struct {
+		path    string
+		wantErr string
+	}{
+		{"claude-code/late.jsonl", "already references claude-code/late.jsonl"},
+		{"claude-code/missing.jsonl", "no transcript claude-code/missing.jsonl"},
+	} {
+		if err := AttachOrphan(tt.path, head); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("AttachOrphan(%s) error = %v, want %q", tt.path, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package note
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// quarantineType marks the stub line that replaces a quarantined transcript
+const quarantineType = "quarantine"
+
+// QuarantineStub is stored in place of a transcript moved to QuarantineRef.
+// Like a Tombstone it records why the session was pulled, by whom and
+// when, but the content is kept and can be restored. The notes referencing
+// the transcript record the quarantine in their redactions.
+type QuarantineStub struct {
+	Type   string    `json:"type"`
+	Reason string    `json:"reason"`
+	By     string    `json:"by"`
+	At     time.Time `json:"timestamp"`
+}
+
+// NewQuarantineStub records the quarantine of a transcript by whoever is
+// running the command now
+func NewQuarantineStub(reason string) QuarantineStub {
+	return QuarantineStub{
+		Type:   quarantineType,
+		Reason: reason,
+		By:     actor(),
+		At:     time.Now().UTC(),
+	}
+}
+
+// Encode returns the single-line JSONL content of the stub
+func (q QuarantineStub) Encode() ([]byte, error) {
+	data, err := json.Marshal(q)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal quarantine stub: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// ParseQuarantineStub reports whether transcript content is a quarantine stub
+func ParseQuarantineStub(content []byte) (*QuarantineStub, bool) {
+	trimmed := bytes.TrimSpace(content)
+	if len(trimmed) == 0 || bytes.IndexByte(trimmed, '\n') != -1 {
+		return nil, false
+	}
+
+	var q QuarantineStub
+	if err := json.Unmarshal(trimmed, &q); err != nil || q.Type != quarantineType {
+		return nil, false
+	}
+	return &q, true
+}
+
+// Description is how readers show the quarantined session, e.g. "Session
+// quarantined by Jane Doe on 2025-01-15: contains customer data"
+func (q *QuarantineStub) Description() string {
+	by := q.By
+	if i := strings.Index(by, " <"); i > 0 {
+		by = by[:i] // Name only, not the email
+	}
+	if by == "" {
+		by = "author"
+	}
+	s := fmt.Sprintf("Session quarantined by %s on %s", by, q.At.Local().Format("2006-01-02"))
+	if q.Reason != "" {
+		s += ": " + q.Reason
+	}
+	return s
+}
@@ -0,0 +1,70 @@
+package note
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQuarantineStubRoundTrip(t *testing.T) {
+	stub := QuarantineStub{
+		Type:   quarantineType,
+		Reason: "contains customer data",
+		By:     "Jane Doe <jane@example.com>",
+		At:     time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC),
+	}
+	content, err := stub.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, ok := ParseQuarantineStub(content)
+	if !ok {
+		t.Fatalf("ParseQuarantineStub() did not recognize stub: %s", content)
+	}
+	if *got != stub {
+		t.Errorf("ParseQuarantineStub() = %+v, want %+v", *got, stub)
+	}
+	if desc := got.Description(); !strings.HasPrefix(desc, "Session quarantined by Jane Doe on 2025-01-1") || !strings.HasSuffix(desc, ": contains customer data") {
+		t.Errorf("Description() = %q", desc)
+	}
+}
+
+func TestParseQuarantineStub(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    bool
+	}{
+		{
+			name:    "empty transcript",
+			content: "",
+			want:    false,
+		},
+		{
+			name:    "regular transcript line",
+			content: `{"type":"user","timestamp":"2025-01-15T10:00:00Z","message":{"content":"hi"}}`,
+			want:    false,
+		},
+		{
+			name: "stub followed by more entries",
+			content: `{"type":"quarantine","reason":"x"}
+{"type":"user","timestamp":"2025-01-15T10:00:00Z"}`,
+			want: false,
+		},
+		{
+			name:    "stub with trailing newline",
+			content: `{"type":"quarantine","reason":"x","by":"me","timestamp":"2025-01-15T10:00:00Z"}` + "\n",
+			want:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, got := ParseQuarantineStub([]byte(tt.content))
+			if got != tt.want {
+				t.Errorf("ParseQuarantineStub() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -11,6 +11,10 @@ const (
 
 	// TranscriptsRef is the ref for transcript tree storage
 	TranscriptsRef = "refs/notes/prompt-story-transcripts"
+
+	// QuarantineRef holds transcripts pulled out of TranscriptsRef by
+	// quarantine. It is local-only: pre-push never pushes it.
+	QuarantineRef = "refs/notes/prompt-story-quarantine"
 )
 
 // GetNote retrieves a prompt-story note for the given commit SHA
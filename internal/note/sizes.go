@@ -0,0 +1,146 @@
+package note
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+)
+
+// largestEntries is how many of the biggest entries a note keeps
+const largestEntries = 5
+
+// SizeStats accounts for the bytes a transcript takes in storage, so the
+// tool calls responsible for bloat can be found without reading it
+type SizeStats struct {
+	Bytes      int64       `json:"bytes"`
+	Entries    int         `json:"entries"`
+	ToolOutput int64       `json:"tool_output"` // bytes of tool results
+	Largest    []EntrySize `json:"largest,omitempty"`
+
+	// ToolBytes are the bytes of tool calls and their results by tool name
+	ToolBytes map[string]int64 `json:"tool_bytes,omitempty"`
+}
+
+// EntrySize is the stored size of one transcript entry
+type EntrySize struct {
+	Entry int    `json:"entry"` // 1-based, as counted by the hash chain
+	Kind  string `json:"kind"`  // "prompt", "assistant", "tool_use", "tool_result" or the entry type
+	Tool  string `json:"tool,omitempty"`
+	Bytes int    `json:"bytes"`
+}
+
+// sizedEntry is the part of an entry needed to classify it
+type sizedEntry struct {
+	Type    string `json:"type"`
+	Message *struct {
+		Content json.RawMessage `json:"content"`
+	} `json:"message"`
+}
+
+// sizedBlock is a content block of a message
+type sizedBlock struct {
+	Type      string `json:"type"`
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	ToolUseID string `json:"tool_use_id"`
+}
+
+// MeasureTranscript computes the size accounting of stored content,
+// splitting it into entries the same way as the hash chain
+func MeasureTranscript(tool string, content []byte) SizeStats {
+	stats := SizeStats{Bytes: int64(len(content))}
+	toolNames := make(map[string]string) // tool_use id -> tool name
+
+	var all []EntrySize
+	for i, e := range chainEntries(tool, content) {
+		size := EntrySize{Entry: i + 1, Bytes: len(e)}
+		size.Kind, size.Tool = classifyEntry(e, toolNames)
+		if size.Kind == "tool_result" {
+			stats.ToolOutput += int64(size.Bytes)
+		}
+		if size.Tool != "" {
+			if stats.ToolBytes == nil {
+				stats.ToolBytes = make(map[string]int64)
+			}
+			stats.ToolBytes[size.Tool] += int64(size.Bytes)
+		}
+		all = append(all, size)
+	}
+	stats.Entries = len(all)
+
+	sort.SliceStable(all, func(i, j int) bool { return all[i].Bytes > all[j].Bytes })
+	if len(all) > largestEntries {
+		all = all[:largestEntries]
+	}
+	stats.Largest = all
+	return stats
+}
+
+// StoredSize returns the size accounting of the session's transcript. Notes
+// written before sizes were recorded, or before ToolBytes was, are measured
+// from the stored transcript. It returns nil when no transcript is stored.
+func (e SessionEntry) StoredSize() *SizeStats {
+	if e.Expired != nil {
+		return nil
+	}
+	if e.Size != nil && (e.Size.ToolBytes != nil || e.Size.ToolOutput == 0) {
+		return e.Size
+	}
+	content, err := git.GetBlobContent(TranscriptsRef, e.TranscriptPath())
+	if err != nil {
+		return e.Size
+	}
+	size := MeasureTranscript(e.Tool, content)
+	return &size
+}
+
+// classifyEntry returns an entry's kind and, for tool calls and results,
+// the tool name. Tool use IDs seen are recorded in toolNames so results
+// can be attributed to the call that produced them.
+func classifyEntry(data []byte, toolNames map[string]string) (kind, tool string) {
+	var entry sizedEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return "invalid", ""
+	}
+
+	if entry.Message != nil {
+		var blocks []sizedBlock
+		if json.Unmarshal(entry.Message.Content, &blocks) == nil {
+			for _, b := range blocks {
+				switch b.Type {
+				case "tool_use":
+					toolNames[b.ID] = b.Name
+					kind, tool = "tool_use", b.Name
+				case "tool_result":
+					return "tool_result", toolNames[b.ToolUseID]
+				}
+			}
+			if kind != "" {
+				return kind, tool
+			}
+		}
+	}
+
+	switch entry.Type {
+	case "user":
+		return "prompt", ""
+	case "":
+		return "other", ""
+	default:
+		return entry.Type, ""
+	}
+}
+
+// FormatBytes renders a byte count for display (e.g. "12.3 KB")
+func FormatBytes(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
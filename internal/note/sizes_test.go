@@ -0,0 +1,66 @@
+package note
+
+import "testing"
+
+func TestMeasureTranscript(t *testing.T) {
+	content := []byte(`{"type":"user","message":{"role":"user","content":"Read the config"}}
+{"type":"assistant","message":{"role":"assistant","content":[{"type":"tool_use","id":"t1","name":"Read","input":{"file_path":"config.yaml"}}]}}
+{"type":"user","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"}]}}
+
+{"type":"assistant","message":{"role":"assistant","content":[{"type":"text","text":"Done"}]}}
+`)
+
+	stats := MeasureTranscript("claude-code", content)
+	if stats.Bytes != int64(len(content)) {
+		t.Errorf("Bytes = %d, want %d", stats.Bytes, len(content))
+	}
+	if stats.Entries != 4 {
+		t.Errorf("Entries = %d, want 4", stats.Entries)
+	}
+	if len(stats.Largest) != 4 {
+		t.Fatalf("Largest has %d entries, want 4", len(stats.Largest))
+	}
+
+	top := stats.Largest[0]
+	if top.Entry != 3 || top.Kind != "tool_result" || top.Tool != "Read" {
+		t.Errorf("largest = %+v, want entry 3, tool_result of Read", top)
+	}
+	if stats.ToolOutput != int64(top.Bytes) {
+		t.Errorf("ToolOutput = %d, want %d", stats.ToolOutput, top.Bytes)
+	}
+
+	if got, want := stats.ToolBytes["Read"], int64(stats.Largest[0].Bytes+kindBytes(stats, "tool_use")); got != want {
+		t.Errorf("ToolBytes[Read] = %d, want %d", got, want)
+	}
+
+	kinds := map[int]string{}
+	for _, e := range stats.Largest {
+		kinds[e.Entry] = e.Kind
+	}
+	if kinds[1] != "prompt" || kinds[2] != "tool_use" || kinds[4] != "assistant" {
+		t.Errorf("kinds = %v", kinds)
+	}
+}
+
+// kindBytes returns the bytes of the first of stats.Largest of kind
+func kindBytes(stats SizeStats, kind string) int {
+	for _, e := range stats.Largest {
+		if e.Kind == kind {
+			return e.Bytes
+		}
+	}
+	return 0
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := map[int64]string{
+		512:             "512 B",
+		2048:            "2.0 KB",
+		5 * 1024 * 1024: "5.0 MB",
+	}
+	for n, want := range tests {
+		if got := FormatBytes(n); got != want {
+			t.Errorf("FormatBytes(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
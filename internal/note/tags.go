@@ -0,0 +1,85 @@
+package note
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// tagPattern is what a normalized tag looks like: lowercase words joined
+// by dashes, dots or underscores, short enough to double as a label
+var tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{0,49}$`)
+
+// NormalizeTag lowercases and trims a tag, rejecting ones that are not a
+// single short word
+func NormalizeTag(tag string) (string, error) {
+	t := strings.ToLower(strings.TrimSpace(tag))
+	if !tagPattern.MatchString(t) {
+		return "", fmt.Errorf("invalid tag %q (use letters, digits, '.', '-' or '_')", tag)
+	}
+	return t, nil
+}
+
+// HasTag reports whether the note carries tag
+func (n *PromptStoryNote) HasTag(tag string) bool {
+	for _, t := range n.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// AddTags adds normalized tags to the note, reporting whether any was new
+func (n *PromptStoryNote) AddTags(tags ...string) bool {
+	changed := false
+	for _, t := range tags {
+		if !n.HasTag(t) {
+			n.Tags = append(n.Tags, t)
+			changed = true
+		}
+	}
+	sort.Strings(n.Tags)
+	return changed
+}
+
+// RemoveTags removes tags from the note, reporting whether any was present
+func (n *PromptStoryNote) RemoveTags(tags ...string) bool {
+	kept := n.Tags[:0]
+	for _, t := range n.Tags {
+		remove := false
+		for _, r := range tags {
+			remove = remove || t == r
+		}
+		if !remove {
+			kept = append(kept, t)
+		}
+	}
+	changed := len(kept) != len(n.Tags)
+	n.Tags = kept
+	if len(n.Tags) == 0 {
+		n.Tags = nil
+	}
+	return changed
+}
+
+// TagNote adds and removes tags on the note of commit sha, reporting
+// whether the note changed
+func TagNote(sha string, add, remove []string) (bool, error) {
+	content, err := GetNote(sha)
+	if err != nil {
+		return false, fmt.Errorf("no prompt-story note on %s", sha[:7])
+	}
+	psNote, err := ParseNote([]byte(content))
+	if err != nil {
+		return false, fmt.Errorf("invalid note on %s: %w", sha[:7], err)
+	}
+
+	added := psNote.AddTags(add...)
+	removed := psNote.RemoveTags(remove...)
+	if !added && !removed {
+		return false, nil
+	}
+	return true, writeNote(sha, psNote)
+}
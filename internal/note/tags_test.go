@@ -0,0 +1,62 @@
+package note
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeTag(t *testing.T) {
+	tests := []struct {
+		tag     string
+		want    string
+		wantErr bool
+	}{
+		{"refactor", "refactor", false},
+		{"  BugFix ", "bugfix", false},
+		{"spike-2.0", "spike-2.0", false},
+		{"", "", true},
+		{"two words", "", true},
+		{"-leading", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := NormalizeTag(tt.tag)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("NormalizeTag(%q) = %q, %v; want %q, error %v", tt.tag, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestAddRemoveTags(t *testing.T) {
+	n := &PromptStoryNote{}
+	if !n.AddTags("refactor", "bugfix") {
+		t.Error("AddTags() = false for new tags")
+	}
+	if n.AddTags("bugfix") {
+		t.Error("AddTags() = true for an existing tag")
+	}
+	if want := []string{"bugfix", "refactor"}; !reflect.DeepEqual(n.Tags, want) {
+		t.Errorf("Tags = %v, want %v", n.Tags, want)
+	}
+
+	if !n.RemoveTags("bugfix", "experiment") {
+		t.Error("RemoveTags() = false for a present tag")
+	}
+	if n.RemoveTags("bugfix") {
+		t.Error("RemoveTags() = true for a missing tag")
+	}
+	n.RemoveTags("refactor")
+	if n.Tags != nil {
+		t.Errorf("Tags = %v, want nil", n.Tags)
+	}
+}
+
+func TestMergeNotes_UnionsTags(t *testing.T) {
+	merged := MergeNotes([]*PromptStoryNote{
+		{Version: 1, Tags: []string{"refactor"}},
+		{Version: 1, Tags: []string{"bugfix", "refactor"}},
+	})
+	if want := []string{"bugfix", "refactor"}; !reflect.DeepEqual(merged.Tags, want) {
+		t.Errorf("Tags = %v, want %v", merged.Tags, want)
+	}
+}
@@ -0,0 +1,75 @@
+package note
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// tombstoneType marks the line that replaces a removed transcript
+const tombstoneType = "tombstone"
+
+// Tombstone is stored in place of a transcript its author removed. The
+// content is gone, but the note's sessions keep pointing at a record of
+// who removed it, when and why, so timelines do not silently lose it.
+type Tombstone struct {
+	Type         string    `json:"type"`
+	DeletedAt    time.Time `json:"deleted_at"`
+	By           string    `json:"by"`
+	Reason       string    `json:"reason,omitempty"`
+	OriginalBlob string    `json:"original_blob"`
+}
+
+// NewTombstone records the removal of the transcript stored in
+// originalBlob by whoever is running the command now
+func NewTombstone(reason, originalBlob string) Tombstone {
+	return Tombstone{
+		Type:         tombstoneType,
+		DeletedAt:    time.Now().UTC(),
+		By:           actor(),
+		Reason:       reason,
+		OriginalBlob: originalBlob,
+	}
+}
+
+// Encode returns the single-line JSONL content of the tombstone
+func (t Tombstone) Encode() ([]byte, error) {
+	data, err := json.Marshal(t)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal tombstone: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// ParseTombstone reports whether transcript content is a tombstone
+func ParseTombstone(content []byte) (*Tombstone, bool) {
+	trimmed := bytes.TrimSpace(content)
+	if len(trimmed) == 0 || bytes.IndexByte(trimmed, '\n') != -1 {
+		return nil, false
+	}
+
+	var t Tombstone
+	if err := json.Unmarshal(trimmed, &t); err != nil || t.Type != tombstoneType {
+		return nil, false
+	}
+	return &t, true
+}
+
+// Description is how readers show the removed session, e.g. "Session
+// removed by Jane Doe on 2025-01-15: pasted a customer record"
+func (t *Tombstone) Description() string {
+	by := t.By
+	if i := strings.Index(by, " <"); i > 0 {
+		by = by[:i] // Name only, not the email
+	}
+	if by == "" {
+		by = "author"
+	}
+	s := fmt.Sprintf("Session removed by %s on %s", by, t.DeletedAt.Local().Format("2006-01-02"))
+	if t.Reason != "" {
+		s += ": " + t.Reason
+	}
+	return s
+}
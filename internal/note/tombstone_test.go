@@ -0,0 +1,45 @@
+package note
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTombstoneRoundTrip(t *testing.T) {
+	ts := Tombstone{
+		Type:         tombstoneType,
+		DeletedAt:    time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC),
+		By:           "Jane Doe <jane@example.com>",
+		Reason:       "pasted a customer record",
+		OriginalBlob: "9a730da373d6aa6a87945805766fda1bab8a5e8a",
+	}
+	data, err := ts.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, ok := ParseTombstone(data)
+	if !ok {
+		t.Fatalf("ParseTombstone(%s) failed", data)
+	}
+	if *got != ts {
+		t.Errorf("ParseTombstone() = %+v, want %+v", *got, ts)
+	}
+	if desc := got.Description(); !strings.HasPrefix(desc, "Session removed by Jane Doe on 2025-01-1") || !strings.HasSuffix(desc, ": pasted a customer record") {
+		t.Errorf("Description() = %q", desc)
+	}
+}
+
+func TestParseTombstone_NotATombstone(t *testing.T) {
+	for _, content := range []string{
+		"",
+		`{"type":"user","timestamp":"2025-01-15T10:00:00Z"}`,
+		`{"type":"quarantine","reason":"x"}`,
+		"{\"type\":\"tombstone\"}\n{\"type\":\"user\"}\n",
+	} {
+		if _, ok := ParseTombstone([]byte(content)); ok {
+			t.Errorf("ParseTombstone(%q) = true", content)
+		}
+	}
+}
@@ -2,6 +2,9 @@ package note
 
 import (
 	"fmt"
+	"path"
+	"sort"
+	"strings"
 
 	"github.com/QuesmaOrg/git-prompt-story/internal/git"
 	"github.com/QuesmaOrg/git-prompt-story/internal/scrubber"
@@ -10,19 +13,23 @@ import (
 
 // StoreTranscripts stores session transcripts in the transcript tree
 // If scrub is not nil, PII is scrubbed from content before storing
-// Returns map of session ID -> blob SHA
+// Returns map of transcript path (tool/id.jsonl) -> blob SHA
 func StoreTranscripts(sessions []session.ClaudeSession, scrub scrubber.Scrubber) (map[string]string, error) {
 	blobs := make(map[string]string)
 
 	for _, s := range sessions {
-		content, err := session.ReadSessionContent(s.Path)
+		content, err := session.ReadContent(s)
 		if err != nil {
 			continue // Skip files we can't read
 		}
 
-		// Scrub PII before storing
+		// Scrub PII before storing, with the tool's scrub profiles
 		if scrub != nil {
-			content, err = scrub.Scrub(content)
+			sessionScrub := scrub
+			if ts, ok := scrub.(scrubber.ToolScrubber); ok {
+				sessionScrub = ts.ForTool(s.ToolName())
+			}
+			content, err = sessionScrub.Scrub(content)
 			if err != nil {
 				return nil, fmt.Errorf("scrubbing session %s: %w", s.ID, err)
 			}
@@ -32,67 +39,89 @@ func StoreTranscripts(sessions []session.ClaudeSession, scrub scrubber.Scrubber)
 		if err != nil {
 			return nil, err
 		}
-		blobs[s.ID] = sha
+		blobs[GetTranscriptPath(s.ToolName(), s.ID)] = sha
 	}
 
 	return blobs, nil
 }
 
-// UpdateTranscriptTree updates the transcript tree ref with transcripts
+// UpdateTranscriptTree updates the transcript tree ref with transcripts,
+// keyed by transcript path (tool/id.jsonl). Existing transcripts that are
+// not being replaced, including other tools' subtrees, are kept.
 func UpdateTranscriptTree(blobs map[string]string) error {
-	// Build tree entries for claude-code/
-	var claudeEntries []git.TreeEntry
-	for id, sha := range blobs {
-		claudeEntries = append(claudeEntries, git.TreeEntry{
+	// Group new entries by tool subtree
+	byTool := make(map[string][]git.TreeEntry)
+	for p, sha := range blobs {
+		tool, name := path.Split(p)
+		tool = strings.TrimSuffix(tool, "/")
+		if tool == "" {
+			return fmt.Errorf("invalid transcript path: %s", p)
+		}
+		byTool[tool] = append(byTool[tool], git.TreeEntry{
 			Mode: "100644",
 			Type: "blob",
 			SHA:  sha,
-			Name: id + ".jsonl",
+			Name: name,
 		})
 	}
 
-	// Check if we already have a transcript tree to merge with
+	// Read the existing tree to merge with
+	var rootEntries []git.TreeEntry
 	existingTreeSHA, _ := git.GetRef(TranscriptsRef)
 	if existingTreeSHA != "" {
-		// Read existing tree
-		rootEntries, err := git.ReadTree(existingTreeSHA)
-		if err == nil {
-			// Find existing claude-code subtree
-			for _, entry := range rootEntries {
-				if entry.Name == "claude-code" && entry.Type == "tree" {
-					// Read existing claude-code entries
-					existingClaudeEntries, err := git.ReadTree(entry.SHA)
-					if err == nil {
-						// Merge: add existing entries that aren't being replaced
-						existingIDs := make(map[string]bool)
-						for _, e := range claudeEntries {
-							existingIDs[e.Name] = true
-						}
-						for _, e := range existingClaudeEntries {
-							if !existingIDs[e.Name] {
-								claudeEntries = append(claudeEntries, e)
-							}
-						}
+		if entries, err := git.ReadTree(existingTreeSHA); err == nil {
+			rootEntries = entries
+		}
+	}
+
+	tools := make([]string, 0, len(byTool))
+	for tool := range byTool {
+		tools = append(tools, tool)
+	}
+	sort.Strings(tools)
+
+	for _, tool := range tools {
+		toolEntries := byTool[tool]
+
+		// Merge: add existing entries that aren't being replaced
+		toolIdx := -1
+		for i, entry := range rootEntries {
+			if entry.Name == tool && entry.Type == "tree" {
+				toolIdx = i
+				break
+			}
+		}
+		if toolIdx != -1 {
+			if existing, err := git.ReadTree(rootEntries[toolIdx].SHA); err == nil {
+				replaced := make(map[string]bool)
+				for _, e := range toolEntries {
+					replaced[e.Name] = true
+				}
+				for _, e := range existing {
+					if !replaced[e.Name] {
+						toolEntries = append(toolEntries, e)
 					}
-					break
 				}
 			}
 		}
-	}
 
-	// Create claude-code subtree
-	claudeCodeTreeSHA, err := git.CreateTree(claudeEntries)
-	if err != nil {
-		return err
+		toolTreeSHA, err := git.CreateTree(toolEntries)
+		if err != nil {
+			return err
+		}
+
+		if toolIdx == -1 {
+			rootEntries = append(rootEntries, git.TreeEntry{
+				Mode: "040000",
+				Type: "tree",
+				SHA:  toolTreeSHA,
+				Name: tool,
+			})
+		} else {
+			rootEntries[toolIdx].SHA = toolTreeSHA
+		}
 	}
 
-	// Build root tree with claude-code subtree
-	rootEntries := []git.TreeEntry{{
-		Mode: "040000",
-		Type: "tree",
-		SHA:  claudeCodeTreeSHA,
-		Name: "claude-code",
-	}}
 	rootTreeSHA, err := git.CreateTree(rootEntries)
 	if err != nil {
 		return err
@@ -0,0 +1,106 @@
+package note
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// CLIVersion is the version of the running binary, recorded in the notes it
+// creates. It is set at startup; "dev" builds never warn about skew.
+var CLIVersion = "dev"
+
+// warnedVersions keeps version skew warnings to one per note version
+var (
+	warnedMu       sync.Mutex
+	warnedVersions = map[string]bool{}
+)
+
+// VersionSkew returns a warning when the note was created by a newer
+// minor or major release than the running CLI, or "" otherwise. Patch
+// releases do not change the note format and are ignored.
+func VersionSkew(n *PromptStoryNote) string {
+	if n == nil || !isNewerRelease(n.CreatedBy, CLIVersion) {
+		return ""
+	}
+	return fmt.Sprintf("note was created by git-prompt-story %s, newer than this %s; some data may not be shown. "+
+		"Update with: brew upgrade git-prompt-story (or go install github.com/QuesmaOrg/git-prompt-story@latest)",
+		n.CreatedBy, CLIVersion)
+}
+
+// warnVersionSkew prints VersionSkew to stderr, once per note version
+func warnVersionSkew(n *PromptStoryNote) {
+	msg := VersionSkew(n)
+	if msg == "" {
+		return
+	}
+	warnedMu.Lock()
+	defer warnedMu.Unlock()
+	if warnedVersions[n.CreatedBy] {
+		return
+	}
+	warnedVersions[n.CreatedBy] = true
+	fmt.Fprintf(os.Stderr, "git-prompt-story: warning: %s\n", msg)
+}
+
+// isNewerRelease reports whether version a is a newer major or minor
+// release than b. Unparseable versions (e.g. "dev") never compare newer.
+func isNewerRelease(a, b string) bool {
+	aMajor, aMinor, ok := parseVersion(a)
+	if !ok {
+		return false
+	}
+	bMajor, bMinor, ok := parseVersion(b)
+	if !ok {
+		return false
+	}
+	if aMajor != bMajor {
+		return aMajor > bMajor
+	}
+	return aMinor > bMinor
+}
+
+// pseudoVersion matches the timestamp-hash suffix of Go pseudo-versions,
+// which go build stamps on untagged checkouts
+var pseudoVersion = regexp.MustCompile(`\d{14}-[0-9a-f]{12}`)
+
+// parseVersion extracts major and minor from "v1.2.3", "1.2" or
+// "1.2.3-rc1". Pseudo-versions of untagged builds do not parse.
+func parseVersion(v string) (major, minor int, ok bool) {
+	if pseudoVersion.MatchString(v) {
+		return 0, 0, false
+	}
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	v, _, _ = strings.Cut(v, "-")
+	parts := strings.Split(v, ".")
+	if len(parts) < 2 {
+		return 0, 0, false
+	}
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, false
+	}
+	minor, err = strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, 0, false
+	}
+	return major, minor, true
+}
+
+// newerVersion returns whichever of a and b is the newer release,
+// preferring a parseable version over an unparseable one
+func newerVersion(a, b string) string {
+	if _, _, ok := parseVersion(a); !ok {
+		if b != "" {
+			return b
+		}
+		return a
+	}
+	if isNewerRelease(b, a) {
+		return b
+	}
+	return a
+}
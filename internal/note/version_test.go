@@ -0,0 +1,53 @@
+package note
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIsNewerRelease(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"v0.13.0", "v0.12.4", true},
+		{"1.0.0", "v0.99.0", true},
+		{"v0.12.5", "v0.12.0", false}, // patch releases are compatible
+		{"v0.12.0", "v0.13.0", false},
+		{"v0.14.0-rc1", "v0.13.2", true},
+		{"dev", "v0.12.0", false},
+		{"v0.13.0", "dev", false},
+		{"v0.13.0", "v0.0.0-20261016192933-9b516f85bbd3+dirty", false},
+		{"", "v0.12.0", false},
+	}
+	for _, tt := range tests {
+		if got := isNewerRelease(tt.a, tt.b); got != tt.want {
+			t.Errorf("isNewerRelease(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestVersionSkew(t *testing.T) {
+	old := CLIVersion
+	defer func() { CLIVersion = old }()
+	CLIVersion = "v0.12.0"
+
+	if msg := VersionSkew(&PromptStoryNote{CreatedBy: "v0.12.3"}); msg != "" {
+		t.Errorf("patch skew warned: %q", msg)
+	}
+	msg := VersionSkew(&PromptStoryNote{CreatedBy: "v0.14.0"})
+	if !strings.Contains(msg, "v0.14.0") || !strings.Contains(msg, "Update with") {
+		t.Errorf("VersionSkew() = %q, want a warning naming v0.14.0 with update hint", msg)
+	}
+}
+
+func TestMergeNotes_KeepsNewestCreatedBy(t *testing.T) {
+	merged := MergeNotes([]*PromptStoryNote{
+		{Version: 1, CreatedBy: "dev"},
+		{Version: 1, CreatedBy: "v0.13.0"},
+		{Version: 1, CreatedBy: "v0.12.1"},
+	})
+	if merged.CreatedBy != "v0.13.0" {
+		t.Errorf("CreatedBy = %q, want v0.13.0", merged.CreatedBy)
+	}
+}
@@ -0,0 +1,156 @@
+// Package pause suspends capture for a repository, indefinitely or for a
+// while. The state is a file in the git directory that the hooks check.
+package pause
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+)
+
+// fileName is the state file in the git directory. It holds the RFC 3339
+// time capture resumes, or nothing when paused until resume.
+const fileName = "prompt-story-paused"
+
+// resumedFileName records when capture last resumed, so the first commit
+// afterwards does not pick up prompts from the paused period
+const resumedFileName = "prompt-story-resumed"
+
+// State is whether capture is paused, and until when
+type State struct {
+	Paused bool
+	Until  time.Time // zero: until resumed
+}
+
+// String describes the state for status output
+func (s State) String() string {
+	switch {
+	case !s.Paused:
+		return "active"
+	case s.Until.IsZero():
+		return "paused until resumed"
+	default:
+		left := strings.TrimSuffix(time.Until(s.Until).Round(time.Minute).String(), "0s")
+		return fmt.Sprintf("paused until %s (%s left)", s.Until.Local().Format("2006-01-02 15:04"), left)
+	}
+}
+
+// Pause suspends capture for d, or until resumed when d is 0
+func Pause(d time.Duration) (State, error) {
+	path, err := statePath()
+	if err != nil {
+		return State{}, err
+	}
+	state := State{Paused: true}
+	content := ""
+	if d > 0 {
+		state.Until = time.Now().Add(d).UTC().Truncate(time.Second)
+		content = state.Until.Format(time.RFC3339) + "\n"
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return State{}, fmt.Errorf("failed to write %s: %w", fileName, err)
+	}
+	return state, nil
+}
+
+// Resume restarts capture, reporting whether it was paused
+func Resume() (bool, error) {
+	state, err := Current()
+	if err != nil || !state.Paused {
+		return false, err
+	}
+	path, err := statePath()
+	if err != nil {
+		return false, err
+	}
+	if err := os.Remove(path); err != nil {
+		return false, fmt.Errorf("failed to remove %s: %w", fileName, err)
+	}
+	return true, markResumed(time.Now())
+}
+
+// ResumedAt returns when capture last resumed, or zero if it never was
+// paused. Sessions before then belong to the paused period.
+func ResumedAt() time.Time {
+	path, err := statePath()
+	if err != nil {
+		return time.Time{}
+	}
+	data, err := os.ReadFile(filepath.Join(filepath.Dir(path), resumedFileName))
+	if err != nil {
+		return time.Time{}
+	}
+	t, _ := time.Parse(time.RFC3339, strings.TrimSpace(string(data)))
+	return t
+}
+
+// markResumed records that capture resumed at t
+func markResumed(t time.Time) error {
+	path, err := statePath()
+	if err != nil {
+		return err
+	}
+	content := t.UTC().Format(time.RFC3339) + "\n"
+	if err := os.WriteFile(filepath.Join(filepath.Dir(path), resumedFileName), []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", resumedFileName, err)
+	}
+	return nil
+}
+
+// Current returns the pause state of the repository. An expired pause is
+// cleared and recorded as resumed when it ran out.
+func Current() (State, error) {
+	path, err := statePath()
+	if err != nil {
+		return State{}, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return State{}, nil
+	}
+	if err != nil {
+		return State{}, err
+	}
+
+	state, err := parse(string(data), time.Now())
+	if err != nil {
+		return State{}, err
+	}
+	if !state.Paused {
+		// parse only reports an unpaused state for an expired pause
+		until, _ := time.Parse(time.RFC3339, strings.TrimSpace(string(data)))
+		os.Remove(path)
+		return state, markResumed(until)
+	}
+	return state, nil
+}
+
+// parse reads the state file content as of now
+func parse(content string, now time.Time) (State, error) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return State{Paused: true}, nil
+	}
+	until, err := time.Parse(time.RFC3339, content)
+	if err != nil {
+		return State{}, fmt.Errorf("invalid %s: %w", fileName, err)
+	}
+	if !now.Before(until) {
+		return State{}, nil
+	}
+	return State{Paused: true, Until: until}, nil
+}
+
+// statePath returns the state file of the current repository
+func statePath() (string, error) {
+	gitDir, err := git.GetGitDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(gitDir, fileName), nil
+}
@@ -0,0 +1,33 @@
+package pause
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	now := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		content string
+		want    State
+		wantErr bool
+	}{
+		{"", State{Paused: true}, false},
+		{"\n", State{Paused: true}, false},
+		{"2025-01-15T12:00:00Z\n", State{Paused: true, Until: now.Add(2 * time.Hour)}, false},
+		{"2025-01-15T10:00:00Z", State{}, false},
+		{"tomorrow", State{}, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parse(tt.content, now)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parse(%q) error = %v, wantErr %v", tt.content, err, tt.wantErr)
+			continue
+		}
+		if got.Paused != tt.want.Paused || !got.Until.Equal(tt.want.Until) {
+			t.Errorf("parse(%q) = %+v, want %+v", tt.content, got, tt.want)
+		}
+	}
+}
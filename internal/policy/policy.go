@@ -0,0 +1,244 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+	"github.com/QuesmaOrg/git-prompt-story/internal/scrubber"
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the policy file committed at the repository root
+const FileName = ".prompt-story-policy.yaml"
+
+// Policy is a team-wide set of rules for capturing LLM sessions.
+// It is committed to the repository so every clone and CI run sees the
+// same rules; the hooks and CLI enforce it locally, `verify` in CI.
+type Policy struct {
+	// RequireScrubbing forces PII scrubbing on, ignoring
+	// prompt-story.scrub=false and GIT_PROMPT_STORY_NO_SCRUB
+	RequireScrubbing bool `yaml:"require_scrubbing"`
+
+	// ForbidNoScrub rejects --no-scrub on CLI commands
+	ForbidNoScrub bool `yaml:"forbid_no_scrub"`
+
+	// RequireCapture requires every commit to carry a Prompt-Story trailer
+	RequireCapture bool `yaml:"require_capture"`
+
+	// BannedTools lists tool IDs (e.g. "claude-cloud") whose sessions must
+	// not be captured
+	BannedTools []string `yaml:"banned_tools"`
+
+	// TranscriptRetention is how long transcripts are kept (e.g. "180d");
+	// `gc` removes older ones but keeps the commit notes
+	TranscriptRetention string `yaml:"transcript_retention"`
+
+	// RedactionRules are applied to stored transcripts by `apply-policy`
+	RedactionRules []RedactionRule `yaml:"redaction_rules"`
+
+	// ScrubProfiles turn scrubber recognizer groups on or off per tool
+	// when transcripts are stored
+	ScrubProfiles []scrubber.Profile `yaml:"scrub_profiles"`
+
+	// MaxTranscriptSize is the largest stored transcript (e.g. "10MB")
+	// `pr summary --strict` accepts, DefaultMaxTranscriptSize if unset
+	MaxTranscriptSize string `yaml:"max_transcript_size"`
+}
+
+// DefaultMaxTranscriptSize is the transcript size limit when the policy
+// sets none: the size above which GitHub warns about pushed blobs
+const DefaultMaxTranscriptSize = 50 << 20
+
+// RedactionRule selects transcript entries and names the handler that
+// rewrites them. Tool and OlderThan narrow the selection; a rule with
+// neither applies to every entry.
+type RedactionRule struct {
+	Name    string `yaml:"name"`
+	Handler string `yaml:"handler"`
+
+	// Tool limits the rule to sessions from one tool ID (e.g. "cursor")
+	Tool string `yaml:"tool"`
+
+	// OlderThan limits the rule to entries older than an age (e.g. "90d")
+	OlderThan string `yaml:"older_than"`
+}
+
+// Label returns the rule's name, or its handler if it has none
+func (r RedactionRule) Label() string {
+	if r.Name != "" {
+		return r.Name
+	}
+	return r.Handler
+}
+
+// Age returns the OlderThan window, or 0 if the rule has none
+func (r RedactionRule) Age() (time.Duration, error) {
+	if r.OlderThan == "" {
+		return 0, nil
+	}
+	d, err := ParseAge(r.OlderThan)
+	if err != nil {
+		return 0, fmt.Errorf("redaction rule %q: %w", r.Label(), err)
+	}
+	return d, nil
+}
+
+// Load reads the policy file from repoRoot. A missing file yields an
+// empty policy that allows everything.
+func Load(repoRoot string) (*Policy, error) {
+	path := filepath.Join(repoRoot, FileName)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return &Policy{}, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", FileName, err)
+	}
+	return Parse(data)
+}
+
+// LoadAt reads the policy file committed at rev, for bare repositories
+// where there is no working tree to read it from. A missing file yields an
+// empty policy.
+func LoadAt(rev string) (*Policy, error) {
+	data, err := git.GetBlobContent(rev, FileName)
+	if err != nil {
+		// No such file at rev (or rev does not exist yet)
+		return &Policy{}, nil
+	}
+	return Parse(data)
+}
+
+// Parse parses policy YAML
+func Parse(data []byte) (*Policy, error) {
+	var p Policy
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", FileName, err)
+	}
+	return &p, nil
+}
+
+// ScrubRequired reports whether scrubbing must stay on regardless of
+// local settings
+func (p *Policy) ScrubRequired() bool {
+	return p.RequireScrubbing || p.ForbidNoScrub
+}
+
+// CheckNoScrub returns an error if disabling scrubbing is forbidden
+func (p *Policy) CheckNoScrub() error {
+	if p.ScrubRequired() {
+		return fmt.Errorf("--no-scrub is not allowed by %s", FileName)
+	}
+	return nil
+}
+
+// Scrubber returns the scrubber for captured transcripts: the default one,
+// from the user's and repository's config, with the policy's scrub
+// profiles. Use its ForTool for a transcript of a known tool.
+func (p *Policy) Scrubber() (*scrubber.PIIScrubber, error) {
+	s, err := scrubber.NewDefault()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create scrubber: %w", err)
+	}
+	if err := s.SetProfiles(p.ScrubProfiles); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", FileName, err)
+	}
+	return s, nil
+}
+
+// ToolAllowed reports whether sessions from tool may be captured
+func (p *Policy) ToolAllowed(tool string) bool {
+	for _, banned := range p.BannedTools {
+		if strings.EqualFold(strings.TrimSpace(banned), tool) {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckTool returns an error if tool is banned
+func (p *Policy) CheckTool(tool string) error {
+	if !p.ToolAllowed(tool) {
+		return fmt.Errorf("capturing %s sessions is not allowed by %s", tool, FileName)
+	}
+	return nil
+}
+
+// RetentionPeriod returns the transcript retention window, or 0 if the
+// policy does not set one
+func (p *Policy) RetentionPeriod() (time.Duration, error) {
+	if p.TranscriptRetention == "" {
+		return 0, nil
+	}
+	d, err := ParseAge(p.TranscriptRetention)
+	if err != nil {
+		return 0, fmt.Errorf("invalid transcript_retention in %s: %w", FileName, err)
+	}
+	return d, nil
+}
+
+// TranscriptSizeLimit returns the largest transcript size in bytes the
+// policy accepts
+func (p *Policy) TranscriptSizeLimit() (int64, error) {
+	if p.MaxTranscriptSize == "" {
+		return DefaultMaxTranscriptSize, nil
+	}
+	n, err := ParseSize(p.MaxTranscriptSize)
+	if err != nil {
+		return 0, fmt.Errorf("invalid max_transcript_size in %s: %w", FileName, err)
+	}
+	return n, nil
+}
+
+// ParseSize parses a size such as "500KB", "10MB" or "1GB" (powers of
+// 1024), or a plain number of bytes
+func ParseSize(size string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(size))
+	units := []struct {
+		suffix string
+		mult   int64
+	}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"B", 1}}
+	mult := int64(1)
+	for _, u := range units {
+		if strings.HasSuffix(s, u.suffix) {
+			s, mult = strings.TrimSpace(strings.TrimSuffix(s, u.suffix)), u.mult
+			break
+		}
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid size: %q (use e.g. 500KB, 10MB)", size)
+	}
+	return n * mult, nil
+}
+
+// ParseAge parses an age such as "180d", "12w" or "1y" (days, weeks and
+// 365-day years), falling back to Go durations like "36h"
+func ParseAge(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	units := map[byte]time.Duration{
+		'd': 24 * time.Hour,
+		'w': 7 * 24 * time.Hour,
+		'y': 365 * 24 * time.Hour,
+	}
+	if len(s) > 1 {
+		if unit, ok := units[s[len(s)-1]]; ok {
+			n, err := strconv.Atoi(s[:len(s)-1])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid age: %q", s)
+			}
+			return time.Duration(n) * unit, nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid age: %q (use e.g. 180d, 12w, 1y)", s)
+	}
+	return d, nil
+}
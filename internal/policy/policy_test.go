@@ -0,0 +1,310 @@
+package policy
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+	"github.com/QuesmaOrg/git-prompt-story/internal/note"
+)
+
+func TestLoad_MissingFile(t *testing.T) {
+	p, err := Load(t.TempDir())
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if p.ScrubRequired() || p.RequireCapture || len(p.BannedTools) != 0 {
+		t.Errorf("expected empty policy, got %+v", p)
+	}
+}
+
+func TestLoad_File(t *testing.T) {
+	dir := t.TempDir()
+	content := `require_scrubbing: true
+forbid_no_scrub: true
+require_capture: true
+banned_tools:
+  - claude-cloud
+scrub_profiles:
+  - tool_name: Read
+    disable: [USER_PATH]
+`
+	if err := os.WriteFile(filepath.Join(dir, FileName), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if !p.RequireScrubbing || !p.ForbidNoScrub || !p.RequireCapture {
+		t.Errorf("flags not parsed: %+v", p)
+	}
+	if p.ToolAllowed("claude-cloud") {
+		t.Error("claude-cloud should be banned")
+	}
+	if !p.ToolAllowed("claude-code") {
+		t.Error("claude-code should be allowed")
+	}
+	if len(p.ScrubProfiles) != 1 || p.ScrubProfiles[0].ToolName != "Read" || p.ScrubProfiles[0].Disable[0] != "USER_PATH" {
+		t.Errorf("scrub_profiles not parsed: %+v", p.ScrubProfiles)
+	}
+}
+
+func TestLoadAt_BareRepository(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	for _, kv := range []string{"GIT_AUTHOR_NAME=A", "GIT_AUTHOR_EMAIL=a@example.com", "GIT_COMMITTER_NAME=A", "GIT_COMMITTER_EMAIL=a@example.com"} {
+		k, v, _ := strings.Cut(kv, "=")
+		t.Setenv(k, v)
+	}
+	work, bare := filepath.Join(dir, "work"), filepath.Join(dir, "work.git")
+	run := func(args ...string) string {
+		t.Helper()
+		out, err := git.RunGit(append([]string{"-C", work}, args...)...)
+		if err != nil {
+			t.Fatalf("git %v: %v", args, err)
+		}
+		return out
+	}
+
+	if _, err := git.RunGit("init", "-q", "-b", "main", work); err != nil {
+		t.Fatal(err)
+	}
+	run("commit", "-q", "--allow-empty", "-m", "before the policy")
+	if err := os.WriteFile(filepath.Join(work, FileName), []byte("require_capture: true\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	run("add", FileName)
+	run("commit", "-q", "-m", "Add policy\n\nPrompt-Story: none")
+	run("commit", "-q", "--allow-empty", "-m", "Uncaptured")
+	if _, err := git.RunGit("clone", "-q", "--bare", work, bare); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(bare)
+
+	p, err := LoadAt("HEAD")
+	if err != nil {
+		t.Fatalf("LoadAt(HEAD) error: %v", err)
+	}
+	if !p.RequireCapture {
+		t.Errorf("LoadAt(HEAD) = %+v, want require_capture from the committed file", p)
+	}
+	violations, err := p.Verify("HEAD~2..HEAD")
+	if err != nil {
+		t.Fatalf("Verify() in a bare repository error: %v", err)
+	}
+	if len(violations) != 1 || violations[0].Subject != "Uncaptured" {
+		t.Errorf("Verify() = %+v, want only the uncaptured commit", violations)
+	}
+
+	// Before the file was committed, and before any commit, the policy is empty
+	for _, rev := range []string{"HEAD~2", "refs/heads/unborn"} {
+		p, err := LoadAt(rev)
+		if err != nil || p.RequireCapture {
+			t.Errorf("LoadAt(%s) = %+v, %v; want an empty policy", rev, p, err)
+		}
+	}
+
+	// A committed file that does not parse is an error, not an empty policy
+	if err := os.WriteFile(filepath.Join(work, FileName), []byte("banned_tools: [unterminated\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	run("commit", "-q", "-am", "Break policy")
+	if _, err := git.RunGit("fetch", "-q", work, "main:main"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadAt("HEAD"); err == nil {
+		t.Error("LoadAt(HEAD) with invalid YAML: expected error")
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	if _, err := Parse([]byte("banned_tools: [unterminated")); err == nil {
+		t.Error("expected error for invalid YAML")
+	}
+}
+
+func TestCheckNoScrub(t *testing.T) {
+	if err := (&Policy{}).CheckNoScrub(); err != nil {
+		t.Errorf("empty policy should allow --no-scrub: %v", err)
+	}
+	if err := (&Policy{ForbidNoScrub: true}).CheckNoScrub(); err == nil {
+		t.Error("forbid_no_scrub should reject --no-scrub")
+	}
+	if err := (&Policy{RequireScrubbing: true}).CheckNoScrub(); err == nil {
+		t.Error("require_scrubbing should reject --no-scrub")
+	}
+}
+
+func TestCheckCommit(t *testing.T) {
+	p := &Policy{RequireCapture: true, BannedTools: []string{"claude-cloud"}}
+
+	tests := []struct {
+		name    string
+		info    commitInfo
+		reasons []string
+	}{
+		{
+			name: "captured, allowed tool",
+			info: commitInfo{
+				sha:     "abc",
+				message: "Fix bug\n\nPrompt-Story: Used Claude Code (2 user prompts) [v1]\n",
+				note: &note.PromptStoryNote{Sessions: []note.SessionEntry{
+					{Tool: "claude-code", ID: "s1"},
+				}},
+			},
+		},
+		{
+			name:    "no trailer",
+			info:    commitInfo{sha: "abc", message: "Fix bug\n"},
+			reasons: []string{"missing Prompt-Story trailer"},
+		},
+		{
+			name: "banned tool",
+			info: commitInfo{
+				sha:     "abc",
+				message: "Fix bug\n\nPrompt-Story: Used Claude Code on the web [v1]\n",
+				note: &note.PromptStoryNote{Sessions: []note.SessionEntry{
+					{Tool: "claude-cloud", ID: "s2"},
+				}},
+			},
+			reasons: []string{"banned tool claude-cloud"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := p.checkCommit(tt.info)
+			if len(got) != len(tt.reasons) {
+				t.Fatalf("got %d violations %+v, want %d", len(got), got, len(tt.reasons))
+			}
+			for i, want := range tt.reasons {
+				if !strings.Contains(got[i].Reason, want) {
+					t.Errorf("reason %q should contain %q", got[i].Reason, want)
+				}
+				if got[i].Subject != "Fix bug" {
+					t.Errorf("Subject = %q, want %q", got[i].Subject, "Fix bug")
+				}
+			}
+		})
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+	}{
+		{"180d", 180 * 24 * time.Hour},
+		{"2w", 14 * 24 * time.Hour},
+		{"1y", 365 * 24 * time.Hour},
+		{"36h", 36 * time.Hour},
+	}
+	for _, tt := range tests {
+		got, err := ParseAge(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseAge(%q) = %v, %v; want %v", tt.in, got, err, tt.want)
+		}
+	}
+
+	for _, in := range []string{"", "d", "-5d", "0d", "abc", "10x"} {
+		if _, err := ParseAge(in); err == nil {
+			t.Errorf("ParseAge(%q) expected error", in)
+		}
+	}
+}
+
+func TestRetentionPeriod(t *testing.T) {
+	p, err := Parse([]byte("transcript_retention: 90d\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := p.RetentionPeriod()
+	if err != nil || d != 90*24*time.Hour {
+		t.Errorf("RetentionPeriod() = %v, %v", d, err)
+	}
+
+	if d, err := (&Policy{}).RetentionPeriod(); err != nil || d != 0 {
+		t.Errorf("unset RetentionPeriod() = %v, %v; want 0", d, err)
+	}
+	if _, err := (&Policy{TranscriptRetention: "soon"}).RetentionPeriod(); err == nil {
+		t.Error("expected error for invalid retention")
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+	}{
+		{"1024", 1024},
+		{"500KB", 500 << 10},
+		{"10mb", 10 << 20},
+		{"2 GB", 2 << 30},
+	}
+	for _, tt := range tests {
+		got, err := ParseSize(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseSize(%q) = %v, %v; want %v", tt.in, got, err, tt.want)
+		}
+	}
+
+	for _, in := range []string{"", "MB", "-1MB", "0", "ten"} {
+		if _, err := ParseSize(in); err == nil {
+			t.Errorf("ParseSize(%q) expected error", in)
+		}
+	}
+}
+
+func TestTranscriptSizeLimit(t *testing.T) {
+	if n, err := (&Policy{}).TranscriptSizeLimit(); err != nil || n != DefaultMaxTranscriptSize {
+		t.Errorf("unset TranscriptSizeLimit() = %v, %v; want the default", n, err)
+	}
+	p, err := Parse([]byte("max_transcript_size: 5MB\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, err := p.TranscriptSizeLimit(); err != nil || n != 5<<20 {
+		t.Errorf("TranscriptSizeLimit() = %v, %v", n, err)
+	}
+	if _, err := (&Policy{MaxTranscriptSize: "big"}).TranscriptSizeLimit(); err == nil {
+		t.Error("expected error for invalid size")
+	}
+}
+
+func TestScrubber_Profiles(t *testing.T) {
+	p, err := Parse([]byte(`scrub_profiles:
+  - tool: claude-cloud
+    enable: [phone]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := p.Scrubber()
+	if err != nil {
+		t.Fatalf("Scrubber() error: %v", err)
+	}
+
+	line := `{"type":"user","message":{"content":"call me at +1 415 555 2671"}}` + "\n"
+	got, err := s.ForTool("claude-cloud").Scrub([]byte(line))
+	if err != nil || strings.Contains(string(got), "555") {
+		t.Errorf("cloud session not scrubbed by its profile: %s (%v)", got, err)
+	}
+	got, err = s.ForTool("claude-code").Scrub([]byte(line))
+	if err != nil || !strings.Contains(string(got), "555") {
+		t.Errorf("other tool scrubbed by the cloud profile: %s (%v)", got, err)
+	}
+
+	p.ScrubProfiles[0].Enable = []string{"no-such-group"}
+	if _, err := p.Scrubber(); err == nil {
+		t.Error("Scrubber() = nil error for an unknown group")
+	}
+}
@@ -0,0 +1,185 @@
+package policy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+	"github.com/QuesmaOrg/git-prompt-story/internal/note"
+	"github.com/QuesmaOrg/git-prompt-story/internal/scrubber"
+)
+
+// Violation describes a commit that breaks the policy
+type Violation struct {
+	SHA     string
+	Subject string
+	Reason  string
+}
+
+// commitInfo is the data Verify checks for one commit
+type commitInfo struct {
+	sha     string
+	message string
+	note    *note.PromptStoryNote // nil when the commit has no note
+}
+
+// Verify checks every commit in commitRange against the policy
+func (p *Policy) Verify(commitRange string) ([]Violation, error) {
+	commits, err := git.ResolveCommitSpec(commitRange)
+	if err != nil {
+		return nil, err
+	}
+
+	var violations []Violation
+	for _, sha := range commits {
+		msg, err := git.GetCommitMessage(sha)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read commit %s: %w", sha, err)
+		}
+
+		info := commitInfo{sha: sha, message: msg}
+		if content, err := note.GetNote(sha); err == nil {
+			if psNote, err := note.ParseNote([]byte(content)); err == nil {
+				info.note = psNote
+			}
+		}
+
+		violations = append(violations, p.checkCommit(info)...)
+	}
+	return violations, nil
+}
+
+// VerifyIntegrity recomputes the hash chain of every sealed session in
+// commitRange from the stored transcript and reports mismatches
+func VerifyIntegrity(commitRange string) ([]Violation, error) {
+	commits, err := git.ResolveCommitSpec(commitRange)
+	if err != nil {
+		return nil, err
+	}
+
+	var violations []Violation
+	for _, sha := range commits {
+		content, err := note.GetNote(sha)
+		if err != nil {
+			continue
+		}
+		psNote, err := note.ParseNote([]byte(content))
+		if err != nil {
+			continue
+		}
+
+		for _, sess := range psNote.Sessions {
+			problem := note.VerifyChain(sess)
+			if problem == "" {
+				continue
+			}
+			msg, _ := git.GetCommitMessage(sha)
+			violations = append(violations, Violation{
+				SHA:     sha,
+				Subject: subjectOf(msg),
+				Reason:  fmt.Sprintf("%s: %s", sess.TranscriptPath(), problem),
+			})
+		}
+	}
+	return violations, nil
+}
+
+// VerifyTranscripts checks the stored transcripts of every commit in
+// commitRange against the size limit and, when scrub is set, for text its
+// recognizers would still replace
+func (p *Policy) VerifyTranscripts(commitRange string, scrub *scrubber.PIIScrubber) ([]Violation, error) {
+	limit, err := p.TranscriptSizeLimit()
+	if err != nil {
+		return nil, err
+	}
+	commits, err := git.ResolveCommitSpec(commitRange)
+	if err != nil {
+		return nil, err
+	}
+
+	var violations []Violation
+	for _, sha := range commits {
+		psNote, _, err := note.LoadNote(sha)
+		if err != nil {
+			continue
+		}
+		msg, _ := git.GetCommitMessage(sha)
+		add := func(reason string) {
+			violations = append(violations, Violation{SHA: sha, Subject: subjectOf(msg), Reason: reason})
+		}
+
+		for _, sess := range psNote.Sessions {
+			path := sess.TranscriptPath()
+			content, err := git.GetBlobContent(note.TranscriptsRef, path)
+			if err != nil {
+				continue // Reported as unreadable by the summary
+			}
+			if _, ok := note.ParseTombstone(content); ok {
+				continue
+			}
+			if size := int64(len(content)); size > limit {
+				add(fmt.Sprintf("%s: %d bytes exceeds the %d byte transcript limit", path, size, limit))
+			}
+			if scrub != nil {
+				if kinds := unscrubbed(scrub, sess.Tool, content); len(kinds) > 0 {
+					add(fmt.Sprintf("%s: unscrubbed %s", path, strings.Join(kinds, ", ")))
+				}
+			}
+		}
+	}
+	return violations, nil
+}
+
+// unscrubbed returns the entity types of the matches scrub would replace
+// in content, sorted and without duplicates
+func unscrubbed(scrub *scrubber.PIIScrubber, tool string, content []byte) []string {
+	found := make(map[string]bool)
+	scrub.SetReview(func(h scrubber.Hit) bool {
+		found[h.EntityType] = true
+		return false
+	})
+	defer scrub.SetReview(nil)
+	if _, err := scrub.ForTool(tool).Scrub(content); err != nil {
+		return nil
+	}
+
+	kinds := make([]string, 0, len(found))
+	for kind := range found {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// checkCommit applies the policy rules to a single commit
+func (p *Policy) checkCommit(c commitInfo) []Violation {
+	var violations []Violation
+	add := func(reason string) {
+		violations = append(violations, Violation{
+			SHA:     c.sha,
+			Subject: subjectOf(c.message),
+			Reason:  reason,
+		})
+	}
+
+	if p.RequireCapture && !strings.Contains(c.message, "Prompt-Story:") {
+		add("missing Prompt-Story trailer (hooks not installed?)")
+	}
+
+	if c.note != nil {
+		for _, sess := range c.note.Sessions {
+			if !p.ToolAllowed(sess.Tool) {
+				add(fmt.Sprintf("note contains banned tool %s (session %s)", sess.Tool, sess.ID))
+			}
+		}
+	}
+
+	return violations
+}
+
+// subjectOf returns the first line of a commit message
+func subjectOf(message string) string {
+	subject, _, _ := strings.Cut(strings.TrimSpace(message), "\n")
+	return subject
+}
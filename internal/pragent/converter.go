@@ -0,0 +1,140 @@
+// Package pragent synthesizes prompt-story sessions for commits that coding
+// agents working on GitHub (the Copilot coding agent, Copilot Workspace or
+// other bot accounts) pushed to a pull request. The agents' own transcripts
+// are not available, so the pull request's conversation stands in for them.
+package pragent
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/github"
+	"github.com/QuesmaOrg/git-prompt-story/internal/session"
+)
+
+// Tool is the tool ID of synthesized sessions
+const Tool = "github-agent"
+
+// SessionID returns the ID of the session synthesized for a pull request
+func SessionID(number int) string {
+	return fmt.Sprintf("pr-%d", number)
+}
+
+// IsAgentCommit reports whether a bot account authored the commit. With
+// bots set, only commits of those logins count.
+func IsAgentCommit(c github.PullRequestCommit, bots []string) bool {
+	login := c.Commit.Author.Name
+	isBot := strings.HasSuffix(login, "[bot]")
+	if c.Author != nil {
+		login = c.Author.Login
+		isBot = c.Author.IsBot()
+	}
+	if len(bots) == 0 {
+		return isBot
+	}
+	for _, b := range bots {
+		if strings.EqualFold(login, b) || strings.EqualFold(strings.TrimSuffix(login, "[bot]"), b) {
+			return true
+		}
+	}
+	return false
+}
+
+// TimelineToJSONL converts a pull request's conversation to JSONL
+// compatible with local Claude Code sessions
+func TimelineToJSONL(pr *github.PullRequest, events []github.TimelineEvent, agentCommits map[string]bool) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, entry := range TimelineToMessageEntries(pr, events, agentCommits) {
+		if err := encoder.Encode(entry); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// TimelineToMessageEntries converts a pull request's conversation into
+// message entries. What people wrote (the description, comments and
+// reviews) becomes prompts, what bots wrote becomes responses, and each
+// agent commit a response naming the commit. Other events are left out.
+func TimelineToMessageEntries(pr *github.PullRequest, events []github.TimelineEvent, agentCommits map[string]bool) []session.MessageEntry {
+	id := SessionID(pr.Number)
+	var entries []session.MessageEntry
+	add := func(who *github.User, ts time.Time, text string) {
+		text = strings.TrimSpace(text)
+		if text == "" {
+			return
+		}
+		if who.IsBot() {
+			entries = append(entries, newEntry("assistant", id, pr.Head.Ref, ts, []map[string]any{{"type": "text", "text": text}}))
+		} else {
+			entries = append(entries, newEntry("user", id, pr.Head.Ref, ts, text))
+		}
+	}
+
+	description := pr.Title
+	if body := strings.TrimSpace(pr.Body); body != "" {
+		description += "\n\n" + body
+	}
+	add(&pr.User, pr.CreatedAt, description)
+
+	for _, e := range events {
+		switch e.Event {
+		case "commented":
+			add(e.Who(), e.Time(), e.Body)
+		case "reviewed":
+			text := e.Body
+			if text == "" && e.State == "changes_requested" {
+				text = "Requested changes"
+			}
+			add(e.Who(), e.Time(), text)
+		case "committed":
+			if !agentCommits[e.SHA] {
+				continue
+			}
+			subject, _, _ := strings.Cut(e.Message, "\n")
+			text := fmt.Sprintf("Committed %s: %s", shortSHA(e.SHA), subject)
+			entries = append(entries, newEntry("assistant", id, pr.Head.Ref, e.Time(), []map[string]any{{"type": "text", "text": text}}))
+		}
+	}
+	return entries
+}
+
+// WorkStarts returns the start of work on each agent commit: the date of
+// the previous commit of the pull request, or its creation for the first
+func WorkStarts(pr *github.PullRequest, commits []github.PullRequestCommit, agentCommits map[string]bool) map[string]time.Time {
+	starts := make(map[string]time.Time)
+	prev := pr.CreatedAt
+	for _, c := range commits {
+		if agentCommits[c.SHA] {
+			starts[c.SHA] = prev
+		}
+		if date := c.Commit.Committer.Date; date.After(prev) {
+			prev = date
+		}
+	}
+	return starts
+}
+
+// newEntry builds a message entry with the given role and content
+func newEntry(role, id, branch string, ts time.Time, content any) session.MessageEntry {
+	raw, _ := json.Marshal(content)
+	return session.MessageEntry{
+		Type:      role,
+		SessionID: id,
+		Timestamp: ts.UTC(),
+		GitBranch: branch,
+		Message:   &session.Message{Role: role, RawContent: raw},
+	}
+}
+
+// shortSHA abbreviates a commit SHA
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
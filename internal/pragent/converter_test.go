@@ -0,0 +1,123 @@
+package pragent
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/github"
+	"github.com/QuesmaOrg/git-prompt-story/internal/session"
+)
+
+const timelineFixture = `[
+  {"event": "assigned", "actor": {"login": "jane", "type": "User"}, "created_at": "2025-01-15T09:01:00Z"},
+  {"event": "commented", "actor": {"login": "Copilot", "type": "Bot"}, "body": "Working on it.", "created_at": "2025-01-15T09:02:00Z"},
+  {"event": "committed", "sha": "1111111111111111111111111111111111111111", "message": "Add retry to the client\n\nDetails",
+   "author": {"name": "copilot-swe-agent[bot]", "date": "2025-01-15T09:10:00Z"},
+   "committer": {"name": "GitHub", "date": "2025-01-15T09:10:00Z"}},
+  {"event": "reviewed", "user": {"login": "jane", "type": "User"}, "body": "@copilot please add tests", "state": "commented",
+   "submitted_at": "2025-01-15T10:00:00Z"},
+  {"event": "committed", "sha": "2222222222222222222222222222222222222222", "message": "Fix typo",
+   "committer": {"name": "Jane", "date": "2025-01-15T10:05:00Z"}},
+  {"event": "reviewed", "user": {"login": "jane", "type": "User"}, "body": "", "state": "approved",
+   "submitted_at": "2025-01-15T11:00:00Z"}
+]`
+
+func testPullRequest() *github.PullRequest {
+	pr := &github.PullRequest{
+		Number:    42,
+		Title:     "Retry failed requests",
+		Body:      "Fixes #41",
+		User:      github.User{Login: "jane", Type: "User"},
+		CreatedAt: time.Date(2025, 1, 15, 9, 0, 0, 0, time.UTC),
+	}
+	pr.Head.Ref = "copilot/fix-41"
+	return pr
+}
+
+func TestTimelineToJSONL(t *testing.T) {
+	var events []github.TimelineEvent
+	if err := json.Unmarshal([]byte(timelineFixture), &events); err != nil {
+		t.Fatal(err)
+	}
+	agent := map[string]bool{"1111111111111111111111111111111111111111": true}
+
+	jsonl, err := TimelineToJSONL(testPullRequest(), events, agent)
+	if err != nil {
+		t.Fatalf("TimelineToJSONL() error: %v", err)
+	}
+	entries, err := session.ParseMessages(jsonl)
+	if err != nil {
+		t.Fatalf("ParseMessages() error: %v", err)
+	}
+
+	// description, bot comment, agent commit, review
+	want := []struct {
+		role, text string
+	}{
+		{"user", "Retry failed requests\n\nFixes #41"},
+		{"assistant", "Working on it."},
+		{"assistant", "Committed 1111111: Add retry to the client"},
+		{"user", "@copilot please add tests"},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("Expected %d entries, got %d", len(want), len(entries))
+	}
+	for i, w := range want {
+		if entries[i].Type != w.role || entries[i].Message.GetTextContent() != w.text {
+			t.Errorf("Entry %d: got %s %q, want %s %q", i, entries[i].Type, entries[i].Message.GetTextContent(), w.role, w.text)
+		}
+		if entries[i].SessionID != "pr-42" || entries[i].GitBranch != "copilot/fix-41" {
+			t.Errorf("Entry %d: unexpected session %q on %q", i, entries[i].SessionID, entries[i].GitBranch)
+		}
+	}
+	if !entries[3].Timestamp.Equal(time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("Review should use its submission time, got %v", entries[3].Timestamp)
+	}
+}
+
+func TestIsAgentCommit(t *testing.T) {
+	var bot, human, unlinked github.PullRequestCommit
+	bot.Author = &github.User{Login: "Copilot", Type: "Bot"}
+	human.Author = &github.User{Login: "jane", Type: "User"}
+	unlinked.Commit.Author.Name = "devin-ai-integration[bot]"
+
+	tests := []struct {
+		name   string
+		commit github.PullRequestCommit
+		bots   []string
+		want   bool
+	}{
+		{"bot", bot, nil, true},
+		{"human", human, nil, false},
+		{"unlinked bot", unlinked, nil, true},
+		{"listed bot", bot, []string{"copilot"}, true},
+		{"unlisted bot", bot, []string{"renovate"}, false},
+		{"listed without suffix", unlinked, []string{"devin-ai-integration"}, true},
+	}
+	for _, tt := range tests {
+		if got := IsAgentCommit(tt.commit, tt.bots); got != tt.want {
+			t.Errorf("%s: IsAgentCommit() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestWorkStarts(t *testing.T) {
+	pr := testPullRequest()
+	commits := make([]github.PullRequestCommit, 3)
+	for i, sha := range []string{"a", "b", "c"} {
+		commits[i].SHA = sha
+		commits[i].Commit.Committer.Date = pr.CreatedAt.Add(time.Duration(i+1) * time.Hour)
+	}
+	starts := WorkStarts(pr, commits, map[string]bool{"a": true, "c": true})
+
+	if !starts["a"].Equal(pr.CreatedAt) {
+		t.Errorf("First commit should start at the PR creation, got %v", starts["a"])
+	}
+	if !starts["c"].Equal(commits[1].Commit.Committer.Date) {
+		t.Errorf("Later commit should start at the previous commit, got %v", starts["c"])
+	}
+	if _, ok := starts["b"]; ok {
+		t.Error("Commits of people should have no work start")
+	}
+}
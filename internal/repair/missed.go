@@ -0,0 +1,207 @@
+package repair
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+	"github.com/QuesmaOrg/git-prompt-story/internal/note"
+	"github.com/QuesmaOrg/git-prompt-story/internal/session"
+)
+
+// checkedFile lists, one SHA per line in the git directory, the commits
+// already looked at for missed captures, so each is offered only once
+const checkedFile = "prompt-story-missed"
+
+const (
+	// missedScanDepth is how many of the latest commits on HEAD are looked at
+	missedScanDepth = 10
+
+	// missedLookback is how old a commit may be to still be looked at
+	missedLookback = 7 * 24 * time.Hour
+
+	// confidentPromptGap is the longest time between the last prompt and
+	// the commit for the sessions to be confidently the commit's
+	confidentPromptGap = 30 * time.Minute
+)
+
+// Confidence is how sure a missed capture is to belong to the commit
+type Confidence int
+
+const (
+	ConfidenceNone Confidence = iota // no prompts in the work period
+	ConfidenceLow                    // prompts, but long before the commit or on another branch
+	ConfidenceHigh                   // prompts on the commit's branch shortly before it
+)
+
+// MissedCapture is a recent commit made without the hooks, e.g. with
+// `git commit --no-verify` or from a GUI, and the local sessions that
+// match its work period
+type MissedCapture struct {
+	SHA        string
+	ShortSHA   string
+	Subject    string
+	Sessions   int
+	Prompts    int
+	Confidence Confidence
+}
+
+// matchEvidence is what the confidence of a missed capture is judged on
+type matchEvidence struct {
+	prompts      int
+	lastPrompt   time.Time
+	commitTime   time.Time
+	branches     []string // Branches recorded by the sessions, if any
+	commitBranch string
+}
+
+// confidence grades the evidence. Sessions recording only other branches
+// were most likely working on another checkout of the repository.
+func (e matchEvidence) confidence() Confidence {
+	if e.prompts == 0 {
+		return ConfidenceNone
+	}
+	if len(e.branches) > 0 && !slices.Contains(e.branches, e.commitBranch) {
+		return ConfidenceLow
+	}
+	if gap := e.commitTime.Sub(e.lastPrompt); gap < 0 || gap > confidentPromptGap {
+		return ConfidenceLow
+	}
+	return ConfidenceHigh
+}
+
+// FindMissedCaptures returns the latest commits on HEAD that were not yet
+// checked, carry neither a Prompt-Story trailer nor a note, and were
+// committed by the current user. It only looks when capture is in use,
+// that is some commit on HEAD has a trailer.
+func FindMissedCaptures(opts Options) ([]MissedCapture, error) {
+	if out, err := git.RunGit("log", "-1", "--format=%H", "--grep=^Prompt-Story:", "HEAD"); err != nil || out == "" {
+		return nil, err
+	}
+	checked, err := loadChecked()
+	if err != nil {
+		return nil, err
+	}
+	email, _ := git.RunGit("config", "user.email")
+	branch, _ := git.GetCurrentBranch()
+	repoRoot, err := git.GetRepoRoot()
+	if err != nil {
+		return nil, fmt.Errorf("not in a git repository: %w", err)
+	}
+
+	since := time.Now().Add(-missedLookback).Format(time.RFC3339)
+	out, err := git.RunGit("log", "-n", fmt.Sprint(missedScanDepth), "--since="+since,
+		"--format=%H%x1f%ce%x1f%s", "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list commits: %w", err)
+	}
+
+	var missed []MissedCapture
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.SplitN(line, "\x1f", 3)
+		if len(fields) != 3 || checked[fields[0]] || !strings.EqualFold(fields[1], email) {
+			continue
+		}
+		sha := fields[0]
+		if msg, err := getCommitMessage(sha); err != nil || strings.Contains(msg, "Prompt-Story:") {
+			continue
+		}
+		if existing, err := note.GetNote(sha); err == nil && existing != "" {
+			continue
+		}
+
+		m, err := matchSessions(sha, repoRoot, branch, opts)
+		if err != nil {
+			continue
+		}
+		m.Subject = fields[2]
+		missed = append(missed, m)
+	}
+	return missed, nil
+}
+
+// matchSessions finds the local sessions with prompts in the work period
+// of the commit and grades how well they match it
+func matchSessions(sha, repoRoot, branch string, opts Options) (MissedCapture, error) {
+	m := MissedCapture{SHA: sha, ShortSHA: sha[:7]}
+	startWork, endWork, err := getWorkPeriodForCommit(sha)
+	if err != nil {
+		return m, err
+	}
+	searchStart, searchEnd := session.WidenWorkPeriod(startWork, endWork, session.ClockSkew())
+	sessions, _ := session.FindAllSessions(repoRoot, searchStart, searchEnd, opts.ToolEnabled)
+	sessions = session.FilterSessionsByUserMessages(sessions, searchStart, searchEnd, nil)
+	prompts := session.PromptsInRange(sessions, searchStart, searchEnd)
+
+	e := matchEvidence{prompts: len(prompts), commitTime: endWork, commitBranch: branch}
+	if len(prompts) > 0 {
+		e.lastPrompt = prompts[len(prompts)-1].Time
+	}
+	for _, s := range sessions {
+		if b, _ := session.StartContext(s); b != "" {
+			e.branches = append(e.branches, b)
+		}
+	}
+
+	m.Sessions = len(sessions)
+	m.Prompts = len(prompts)
+	m.Confidence = e.confidence()
+	return m, nil
+}
+
+// MarkChecked records that the commits were looked at, so they are not
+// offered again
+func MarkChecked(shas ...string) error {
+	if len(shas) == 0 {
+		return nil
+	}
+	path, err := checkedPath()
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to record checked commits: %w", err)
+	}
+	defer f.Close()
+	for _, sha := range shas {
+		if _, err := fmt.Fprintln(f, sha); err != nil {
+			return fmt.Errorf("failed to record checked commits: %w", err)
+		}
+	}
+	return nil
+}
+
+func loadChecked() (map[string]bool, error) {
+	path, err := checkedPath()
+	if err != nil {
+		return nil, err
+	}
+	checked := make(map[string]bool)
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return checked, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		checked[strings.TrimSpace(scanner.Text())] = true
+	}
+	return checked, scanner.Err()
+}
+
+func checkedPath() (string, error) {
+	gitDir, err := git.GetGitDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(gitDir, checkedFile), nil
+}
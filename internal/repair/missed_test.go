@@ -0,0 +1,58 @@
+package repair
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMatchEvidenceConfidence(t *testing.T) {
+	commit := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		e    matchEvidence
+		want Confidence
+	}{
+		{
+			name: "no prompts",
+			e:    matchEvidence{commitTime: commit, commitBranch: "main"},
+			want: ConfidenceNone,
+		},
+		{
+			name: "prompt shortly before on the same branch",
+			e: matchEvidence{prompts: 3, lastPrompt: commit.Add(-5 * time.Minute), commitTime: commit,
+				branches: []string{"main"}, commitBranch: "main"},
+			want: ConfidenceHigh,
+		},
+		{
+			name: "tool records no branch",
+			e:    matchEvidence{prompts: 1, lastPrompt: commit.Add(-time.Minute), commitTime: commit, commitBranch: "main"},
+			want: ConfidenceHigh,
+		},
+		{
+			name: "one of several sessions on the branch",
+			e: matchEvidence{prompts: 2, lastPrompt: commit.Add(-time.Minute), commitTime: commit,
+				branches: []string{"other", "main"}, commitBranch: "main"},
+			want: ConfidenceHigh,
+		},
+		{
+			name: "sessions on another branch",
+			e: matchEvidence{prompts: 2, lastPrompt: commit.Add(-time.Minute), commitTime: commit,
+				branches: []string{"other"}, commitBranch: "main"},
+			want: ConfidenceLow,
+		},
+		{
+			name: "last prompt long before the commit",
+			e:    matchEvidence{prompts: 2, lastPrompt: commit.Add(-2 * time.Hour), commitTime: commit, commitBranch: "main"},
+			want: ConfidenceLow,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.e.confidence(); got != tt.want {
+				t.Errorf("confidence() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/QuesmaOrg/git-prompt-story/internal/git"
 	"github.com/QuesmaOrg/git-prompt-story/internal/note"
+	"github.com/QuesmaOrg/git-prompt-story/internal/policy"
 	"github.com/QuesmaOrg/git-prompt-story/internal/scrubber"
 	"github.com/QuesmaOrg/git-prompt-story/internal/session"
 )
@@ -27,6 +28,8 @@ type Options struct {
 	DryRun  bool
 	Force   bool // overwrite existing notes
 	NoScrub bool
+	// ToolEnabled filters which tools' sessions are searched (nil means all)
+	ToolEnabled func(tool string) bool
 }
 
 // RepairCommit attempts to recreate a missing note for a commit
@@ -64,14 +67,16 @@ func RepairCommit(sha string, opts Options) (*RepairResult, error) {
 		return nil, fmt.Errorf("failed to get work period: %w", err)
 	}
 
-	// Find sessions (includes time filtering)
-	sessions, err := session.FindSessions(repoRoot, startWork, endWork, nil)
-	if err != nil {
+	// Find sessions of all enabled tools (includes time filtering), allowing
+	// for clock skew; the note keeps the unwidened start
+	searchStart, searchEnd := session.WidenWorkPeriod(startWork, endWork, session.ClockSkew())
+	sessions, err := session.FindAllSessions(repoRoot, searchStart, searchEnd, opts.ToolEnabled)
+	if err != nil && len(sessions) == 0 {
 		return nil, fmt.Errorf("failed to find sessions: %w", err)
 	}
 
 	// Filter by user messages
-	sessions = session.FilterSessionsByUserMessages(sessions, startWork, endWork, nil)
+	sessions = session.FilterSessionsByUserMessages(sessions, searchStart, searchEnd, nil)
 
 	result.SessionsFound = len(sessions)
 
@@ -87,10 +92,15 @@ func RepairCommit(sha string, opts Options) (*RepairResult, error) {
 	// Create scrubber
 	var piiScrubber scrubber.Scrubber
 	if !opts.NoScrub {
-		piiScrubber, err = scrubber.NewDefault()
+		pol, err := policy.Load(repoRoot)
+		if err != nil {
+			return nil, err
+		}
+		ps, err := pol.Scrubber()
 		if err != nil {
-			return nil, fmt.Errorf("failed to create scrubber: %w", err)
+			return nil, err
 		}
+		piiScrubber = ps
 	}
 
 	// Store transcripts
@@ -106,6 +116,9 @@ func RepairCommit(sha string, opts Options) (*RepairResult, error) {
 
 	// Create note with explicit start time (not using CalculateWorkStartTime)
 	psNote := note.NewPromptStoryNote(sessions, false, startWork)
+	if err := psNote.SealTranscripts(blobs); err != nil {
+		return nil, fmt.Errorf("failed to seal transcripts: %w", err)
+	}
 	noteJSON, err := psNote.ToJSON()
 	if err != nil {
 		return nil, fmt.Errorf("failed to serialize note: %w", err)
@@ -0,0 +1,181 @@
+// Package repometrics collects prompt-story health metrics of a repository
+// and renders them in the Prometheus text exposition format, for serve's
+// /metrics endpoint.
+package repometrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+	"github.com/QuesmaOrg/git-prompt-story/internal/note"
+	"github.com/QuesmaOrg/git-prompt-story/internal/retryqueue"
+	"github.com/QuesmaOrg/git-prompt-story/internal/session"
+)
+
+// Snapshot is the prompt-story state of a repository at one time
+type Snapshot struct {
+	Time            time.Time
+	Notes           int              // commits with a note
+	Prompts         map[string]int   // user actions in stored transcripts, by tool
+	TranscriptBytes map[string]int64 // stored transcript size, by tool
+	MissingNotes    int              // commits with a "Prompt-Story: Used" trailer but no note
+	RetryQueued     int              // notes waiting in the retry queue
+}
+
+// blobStats is what a transcript blob contributes. Blobs never change, so
+// it is computed once per blob.
+type blobStats struct {
+	prompts int
+	bytes   int64
+}
+
+// Collector takes snapshots of the current repository, remembering what it
+// learned about transcripts between runs
+type Collector struct {
+	blobs map[string]blobStats
+}
+
+// NewCollector returns a collector with an empty transcript cache
+func NewCollector() *Collector {
+	return &Collector{blobs: make(map[string]blobStats)}
+}
+
+// Collect takes a snapshot of the repository
+func (c *Collector) Collect() (*Snapshot, error) {
+	s := &Snapshot{
+		Time:            time.Now(),
+		Prompts:         make(map[string]int),
+		TranscriptBytes: make(map[string]int64),
+	}
+
+	notes, err := note.ListNoteBlobs(note.NotesRef)
+	if err != nil {
+		return nil, err
+	}
+	s.Notes = len(notes)
+
+	if err := c.collectTranscripts(s); err != nil {
+		return nil, err
+	}
+
+	out, err := git.RunGit("log", "--all", "--format=%H", "--grep=^Prompt-Story: Used")
+	if err != nil {
+		return nil, fmt.Errorf("git log: %w", err)
+	}
+	for _, sha := range strings.Fields(out) {
+		if _, ok := notes[sha]; !ok {
+			s.MissingNotes++
+		}
+	}
+
+	queued, err := retryqueue.Pending()
+	if err != nil {
+		return nil, err
+	}
+	s.RetryQueued = len(queued)
+	return s, nil
+}
+
+// collectTranscripts adds up the transcripts stored under TranscriptsRef
+func (c *Collector) collectTranscripts(s *Snapshot) error {
+	root, err := git.GetRef(note.TranscriptsRef)
+	if err != nil || root == "" {
+		return nil // Nothing captured yet
+	}
+	tools, err := git.ReadTree(root)
+	if err != nil {
+		return err
+	}
+	seen := make(map[string]bool)
+	for _, tool := range tools {
+		if tool.Type != "tree" {
+			continue
+		}
+		files, err := git.ReadTree(tool.SHA)
+		if err != nil {
+			return err
+		}
+		for _, f := range files {
+			if f.Type != "blob" {
+				continue
+			}
+			seen[f.SHA] = true
+			st, ok := c.blobs[f.SHA]
+			if !ok {
+				content, err := git.ReadBlob(f.SHA)
+				if err != nil {
+					return err
+				}
+				st = blobStats{prompts: session.CountUserActions(tool.Name, content), bytes: int64(len(content))}
+				c.blobs[f.SHA] = st
+			}
+			s.Prompts[tool.Name] += st.prompts
+			s.TranscriptBytes[tool.Name] += st.bytes
+		}
+	}
+
+	// Forget transcripts replaced or removed since the last run
+	for sha := range c.blobs {
+		if !seen[sha] {
+			delete(c.blobs, sha)
+		}
+	}
+	return nil
+}
+
+// Render writes the snapshot in the Prometheus text exposition format.
+// collectErrors counts failed collections since the server started.
+func (s *Snapshot) Render(w io.Writer, collectErrors int) error {
+	var sb strings.Builder
+	metric := func(name, kind, help string) {
+		fmt.Fprintf(&sb, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+	}
+
+	// Counts of what the repository holds now are gauges: gc, redaction,
+	// repair and the retry queue can lower them
+	metric("prompt_story_notes", "gauge", "Commits with a prompt-story note.")
+	fmt.Fprintf(&sb, "prompt_story_notes %d\n", s.Notes)
+
+	metric("prompt_story_prompts", "gauge", "User prompts, commands and tool rejections in stored transcripts.")
+	for _, tool := range sortedKeys(s.Prompts) {
+		fmt.Fprintf(&sb, "prompt_story_prompts{tool=%s} %d\n", labelValue(tool), s.Prompts[tool])
+	}
+
+	metric("prompt_story_capture_errors", "gauge", "Captures that did not end in an attached note.")
+	fmt.Fprintf(&sb, "prompt_story_capture_errors{kind=\"missing_note\"} %d\n", s.MissingNotes)
+	fmt.Fprintf(&sb, "prompt_story_capture_errors{kind=\"retry_queued\"} %d\n", s.RetryQueued)
+
+	metric("prompt_story_transcript_bytes", "gauge", "Size of the stored transcripts.")
+	for _, tool := range sortedKeys(s.TranscriptBytes) {
+		fmt.Fprintf(&sb, "prompt_story_transcript_bytes{tool=%s} %d\n", labelValue(tool), s.TranscriptBytes[tool])
+	}
+
+	metric("prompt_story_collect_errors_total", "counter", "Failed collections of these metrics.")
+	fmt.Fprintf(&sb, "prompt_story_collect_errors_total %d\n", collectErrors)
+
+	metric("prompt_story_last_collect_timestamp_seconds", "gauge", "When these metrics were collected.")
+	fmt.Fprintf(&sb, "prompt_story_last_collect_timestamp_seconds %d\n", s.Time.Unix())
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// labelValue quotes a label value, escaping as the exposition format requires
+func labelValue(v string) string {
+	v = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+	return `"` + v + `"`
+}
+
+// sortedKeys returns the keys of m in order, for stable output
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,46 @@
+package repometrics
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRender(t *testing.T) {
+	s := &Snapshot{
+		Time:            time.Unix(1736935200, 0),
+		Notes:           12,
+		Prompts:         map[string]int{"cursor": 3, "claude-code": 40},
+		TranscriptBytes: map[string]int64{"claude-code": 2048, "cursor": 512},
+		MissingNotes:    1,
+	}
+
+	var sb strings.Builder
+	if err := s.Render(&sb, 2); err != nil {
+		t.Fatal(err)
+	}
+	out := sb.String()
+
+	for _, want := range []string{
+		"# TYPE prompt_story_notes gauge\nprompt_story_notes 12\n",
+		"# TYPE prompt_story_prompts gauge\n",
+		"prompt_story_prompts{tool=\"claude-code\"} 40\nprompt_story_prompts{tool=\"cursor\"} 3\n",
+		"# TYPE prompt_story_capture_errors gauge\n",
+		"prompt_story_capture_errors{kind=\"missing_note\"} 1\n",
+		"prompt_story_capture_errors{kind=\"retry_queued\"} 0\n",
+		"# TYPE prompt_story_transcript_bytes gauge\n",
+		"prompt_story_transcript_bytes{tool=\"cursor\"} 512\n",
+		"prompt_story_collect_errors_total 2\n",
+		"prompt_story_last_collect_timestamp_seconds 1736935200\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestLabelValue(t *testing.T) {
+	if got := labelValue(`a"b\c` + "\n"); got != `"a\"b\\c\n"` {
+		t.Errorf("labelValue() = %s", got)
+	}
+}
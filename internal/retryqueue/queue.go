@@ -0,0 +1,171 @@
+// Package retryqueue keeps notes the post-commit hook failed to attach,
+// e.g. on ref lock contention or a full disk, so a later run can attach
+// them instead of the capture being lost. Each entry is a JSON file in
+// $GIT_DIR/prompt-story-retry/.
+package retryqueue
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+	"github.com/QuesmaOrg/git-prompt-story/internal/note"
+)
+
+// dirName is the queue directory in the git directory
+const dirName = "prompt-story-retry"
+
+// Entry is a note waiting to be attached. The note is kept as a string so
+// its bytes, and so the hash named in the commit trailer, do not change.
+type Entry struct {
+	Commit    string    `json:"commit"`
+	Note      string    `json:"note"`
+	Queued    time.Time `json:"queued"`
+	Attempts  int       `json:"attempts"`
+	LastError string    `json:"last_error,omitempty"`
+}
+
+// Enqueue records that the note in blob could not be attached to commit.
+// The note content is copied into the queue, as nothing references the
+// blob and git gc may prune it before the retry.
+func Enqueue(commit, blob string, cause error) error {
+	payload, err := git.ReadBlob(blob)
+	if err != nil {
+		return fmt.Errorf("failed to read note %s: %w", blob, err)
+	}
+	e := Entry{
+		Commit:    commit,
+		Note:      string(payload),
+		Queued:    time.Now().UTC().Truncate(time.Second),
+		Attempts:  1,
+		LastError: cause.Error(),
+	}
+	return save(e)
+}
+
+// Pending returns the queued entries, oldest first
+func Pending() ([]Entry, error) {
+	dir, err := queueDir()
+	if err != nil {
+		return nil, err
+	}
+	return readEntries(dir)
+}
+
+// readEntries reads the entries in a queue directory, oldest first
+func readEntries(dir string) ([]Entry, error) {
+	files, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	for _, f := range files {
+		if !strings.HasSuffix(f.Name(), ".json") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, f.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var e Entry
+		if err := json.Unmarshal(data, &e); err != nil {
+			return nil, fmt.Errorf("invalid queue entry %s: %w", f.Name(), err)
+		}
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Queued.Before(entries[j].Queued)
+	})
+	return entries, nil
+}
+
+// Drain attaches every queued note. Attached entries leave the queue;
+// failed ones stay with the error recorded, and are returned.
+func Drain() (attached int, failed []Entry, err error) {
+	entries, err := Pending()
+	if err != nil {
+		return 0, nil, err
+	}
+	for _, e := range entries {
+		if err := note.AttachNote(e.Commit, []byte(e.Note)); err != nil {
+			e.Attempts++
+			e.LastError = err.Error()
+			failed = append(failed, e)
+			if err := save(e); err != nil {
+				return attached, failed, err
+			}
+			continue
+		}
+		if err := remove(e.Commit); err != nil {
+			return attached, failed, err
+		}
+		attached++
+	}
+	return attached, failed, nil
+}
+
+// save writes an entry, replacing any earlier one for the same commit
+func save(e Entry) error {
+	dir, err := queueDir()
+	if err != nil {
+		return err
+	}
+	return writeEntry(dir, e)
+}
+
+// writeEntry writes an entry into a queue directory
+func writeEntry(dir string, e Entry) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dirName, err)
+	}
+	data, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return err
+	}
+	// Write then rename, so a crash never leaves half an entry
+	path := filepath.Join(dir, e.Commit+".json")
+	if err := os.WriteFile(path+".tmp", data, 0644); err != nil {
+		return fmt.Errorf("failed to queue note for %s: %w", short(e.Commit), err)
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// remove deletes the entry of a commit
+func remove(commit string) error {
+	dir, err := queueDir()
+	if err != nil {
+		return err
+	}
+	err = os.Remove(filepath.Join(dir, commit+".json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// queueDir returns the queue directory of the current repository
+func queueDir() (string, error) {
+	gitDir, err := git.GetGitDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(gitDir, dirName), nil
+}
+
+// short abbreviates a commit SHA for messages
+func short(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
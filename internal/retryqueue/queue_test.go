@@ -0,0 +1,60 @@
+package retryqueue
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWriteReadEntries(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), dirName)
+	base := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+	// The payload must survive byte for byte, indentation included
+	payload := "{\n  \"v\": 1,\n  \"sessions\": []\n}"
+
+	newer := Entry{Commit: "bbbbbbb2222", Note: payload, Queued: base.Add(time.Minute), Attempts: 1, LastError: "lock"}
+	older := Entry{Commit: "aaaaaaa1111", Note: payload, Queued: base, Attempts: 2}
+	for _, e := range []Entry{newer, older} {
+		if err := writeEntry(dir, e); err != nil {
+			t.Fatalf("writeEntry() error: %v", err)
+		}
+	}
+	// Leftovers of an interrupted write are not entries
+	if err := os.WriteFile(filepath.Join(dir, "ccccccc.json.tmp"), []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := readEntries(dir)
+	if err != nil {
+		t.Fatalf("readEntries() error: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	if entries[0].Commit != older.Commit || entries[1].Commit != newer.Commit {
+		t.Errorf("entries not oldest first: %s, %s", entries[0].Commit, entries[1].Commit)
+	}
+	if entries[0].Note != payload {
+		t.Errorf("note changed: %q", entries[0].Note)
+	}
+	if entries[1].LastError != "lock" || entries[0].Attempts != 2 {
+		t.Errorf("unexpected entries %+v", entries)
+	}
+
+	// Writing a commit's entry again replaces it
+	newer.Attempts = 3
+	if err := writeEntry(dir, newer); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ = readEntries(dir); len(entries) != 2 || entries[1].Attempts != 3 {
+		t.Errorf("entry not replaced: %+v", entries)
+	}
+}
+
+func TestReadEntries_NoQueue(t *testing.T) {
+	entries, err := readEntries(filepath.Join(t.TempDir(), "missing"))
+	if err != nil || entries != nil {
+		t.Errorf("readEntries() = %v, %v; want nothing", entries, err)
+	}
+}
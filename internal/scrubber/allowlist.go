@@ -0,0 +1,103 @@
+package scrubber
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// AllowEntry exempts matches from replacement, for text recognizers catch
+// that is not sensitive, e.g. example.com addresses in docs. A match is
+// exempt if it equals Literal, or if Regex matches in it; anchor the regex
+// with ^ and $ to match the whole text.
+type AllowEntry struct {
+	Literal string `yaml:"literal"`
+	Regex   string `yaml:"regex"`
+
+	// Recognizers limits the entry to these groups: recognizer names or
+	// entity types, as in scrub profiles (empty = every recognizer)
+	Recognizers []string `yaml:"recognizers"`
+
+	Comment string `yaml:"comment"` // Why the text is not sensitive
+}
+
+// String describes the entry for reports
+func (e AllowEntry) String() string {
+	if e.Literal != "" {
+		return "literal " + strconv.Quote(e.Literal)
+	}
+	return "regex " + strconv.Quote(e.Regex)
+}
+
+// compiledAllowEntry is an AllowEntry with its regex compiled
+type compiledAllowEntry struct {
+	AllowEntry
+	re *regexp.Regexp
+}
+
+// compileAllowEntry validates an allowlist entry and compiles its regex
+func compileAllowEntry(e AllowEntry) (compiledAllowEntry, error) {
+	if (e.Literal == "") == (e.Regex == "") {
+		return compiledAllowEntry{}, fmt.Errorf("allowlist entry %s: needs either literal or regex", e)
+	}
+	ce := compiledAllowEntry{AllowEntry: e}
+	if e.Regex != "" {
+		re, err := regexp.Compile(e.Regex)
+		if err != nil {
+			return compiledAllowEntry{}, fmt.Errorf("allowlist entry %s: %w", e, err)
+		}
+		ce.re = re
+	}
+	return ce, nil
+}
+
+// AddAllowlist validates and adds allowlist entries to the scrubber
+func (s *PIIScrubber) AddAllowlist(entries []AllowEntry) error {
+	for _, e := range entries {
+		ce, err := compileAllowEntry(e)
+		if err != nil {
+			return err
+		}
+		s.allowlist = append(s.allowlist, ce)
+	}
+	return nil
+}
+
+// appliesTo reports whether the entry covers matches of the recognizer
+func (e *compiledAllowEntry) appliesTo(r *CompiledRecognizer) bool {
+	if len(e.Recognizers) == 0 {
+		return true
+	}
+	for _, group := range e.Recognizers {
+		if inGroup(r, group) {
+			return true
+		}
+	}
+	return false
+}
+
+// mayAllow reports whether an allowlist entry covers the recognizer, so
+// its matches must be checked one by one
+func (s *PIIScrubber) mayAllow(r *CompiledRecognizer) bool {
+	for i := range s.allowlist {
+		if s.allowlist[i].appliesTo(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowedBy returns the allowlist entry exempting a match of the
+// recognizer, or nil
+func (s *PIIScrubber) allowedBy(r *CompiledRecognizer, match string) *AllowEntry {
+	for i := range s.allowlist {
+		e := &s.allowlist[i]
+		if !e.appliesTo(r) {
+			continue
+		}
+		if e.Literal == match || (e.re != nil && e.re.MatchString(match)) {
+			return &e.AllowEntry
+		}
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package scrubber
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAllowlist(t *testing.T) {
+	s, err := New(DefaultRecognizers(), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.AddAllowlist([]AllowEntry{
+		{Literal: "docs@example.com"},
+		{Regex: `@example\.org$`, Recognizers: []string{"EMAIL"}},
+		{Regex: `^/home/ci/`, Recognizers: []string{"credit_card"}}, // Scoped away from paths
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := s.ScrubText("docs@example.com, ops@example.org, jane@corp.io, /home/ci/build")
+	want := "docs@example.com, ops@example.org, <EMAIL>, /<REDACTED>/build"
+	if got != want {
+		t.Errorf("ScrubText() = %q, want %q", got, want)
+	}
+}
+
+func TestAddAllowlist_Invalid(t *testing.T) {
+	s, err := New(nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range []AllowEntry{
+		{},
+		{Literal: "a", Regex: "b"},
+		{Regex: "("},
+	} {
+		if err := s.AddAllowlist([]AllowEntry{e}); err == nil {
+			t.Errorf("AddAllowlist(%+v) = nil, want an error", e)
+		}
+	}
+}
+
+func TestReportHits(t *testing.T) {
+	s, err := New(DefaultRecognizers(), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AddAllowlist([]AllowEntry{{Literal: "docs@example.com"}}); err != nil {
+		t.Fatal(err)
+	}
+	var hits []Hit
+	s.SetReport(func(h Hit) { hits = append(hits, h) })
+
+	got := s.ScrubText("docs@example.com or jane@corp.io")
+	if want := "docs@example.com or <EMAIL>"; got != want {
+		t.Errorf("ScrubText() = %q, want %q", got, want)
+	}
+	if len(hits) != 2 {
+		t.Fatalf("reported %d hits, want 2: %+v", len(hits), hits)
+	}
+	if hits[0].Allowed == nil || hits[0].Allowed.String() != `literal "docs@example.com"` {
+		t.Errorf("hit %+v, want it allowed by the literal", hits[0])
+	}
+	if hits[1].Allowed != nil || hits[1].Match != "jane@corp.io" {
+		t.Errorf("hit %+v, want jane@corp.io replaced", hits[1])
+	}
+}
+
+func TestLoadPatterns_Allowlist(t *testing.T) {
+	userPath := isolateUserConfig(t)
+	repo := t.TempDir()
+
+	writePatterns(t, userPath, `allowlist:
+  - literal: me@example.com
+`)
+	writePatterns(t, filepath.Join(repo, PatternsFile), `allowlist:
+  - regex: '@example\.org$'
+    recognizers: [email]
+    comment: Addresses in the docs
+`)
+	cfg, err := LoadPatterns(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Allowlist) != 2 || cfg.Allowlist[1].Comment != "Addresses in the docs" {
+		t.Errorf("Allowlist = %+v, want both layers", cfg.Allowlist)
+	}
+
+	writePatterns(t, filepath.Join(repo, PatternsFile), `allowlist:
+  - regex: '(unclosed'
+`)
+	if _, err := LoadPatterns(repo); err == nil || !strings.Contains(err.Error(), PatternsFile) {
+		t.Errorf("LoadPatterns() error = %v, want one naming %s", err, PatternsFile)
+	}
+}
@@ -0,0 +1,215 @@
+package scrubber
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+)
+
+// AllDetectors enables every detector
+const AllDetectors = "all"
+
+// detectors find personal data beyond credentials and paths, enabled one by
+// one with prompt-story.scrubDetectors. They are off by default: phone
+// numbers, addresses and names look like much that is not personal, and
+// teams that must not store them accept more false positives.
+var detectors = map[string][]Recognizer{
+	"phone":  {intlPhoneRecognizer, localPhoneRecognizer},
+	"ip":     {ipv4Recognizer, ipv6Recognizer},
+	"iban":   {ibanRecognizer},
+	"person": {personNameRecognizer},
+}
+
+// localPhoneRecognizer finds phone numbers dialed without a country code:
+// North American ones with separators, e.g. (415) 555-2671, and those with
+// a trunk prefix 0, e.g. 020 7946 0958
+var localPhoneRecognizer = Recognizer{
+	Name:       "local_phone",
+	EntityType: "PHONE",
+	Patterns: []Pattern{
+		{Regex: `(^|[^\w+.-])((?:\([2-9][0-9]{2}\) ?|[2-9][0-9]{2}[-.])[2-9][0-9]{2}[-.][0-9]{4})($|[^\w.-]|\.$|\.[^\w])`},
+		{Regex: `(^|[^\w+.-])(0[1-9][0-9]{1,3}[ /-][0-9]{3,4}[ -]?[0-9]{3,4})($|[^\w.-]|\.$|\.[^\w])`},
+	},
+	Prefilter:   digitRun(3),
+	Validate:    validLocalPhone,
+	Replacement: "${1}<PHONE>${3}",
+}
+
+// ipv4Recognizer finds IPv4 addresses, not inside longer dotted numbers
+// such as 1.2.3.4.5
+var ipv4Recognizer = Recognizer{
+	Name:       "ipv4",
+	EntityType: "IP_ADDRESS",
+	Patterns: []Pattern{
+		{Regex: `(^|[^\w.])((?:(?:25[0-5]|2[0-4][0-9]|1[0-9]{2}|[1-9]?[0-9])\.){3}(?:25[0-5]|2[0-4][0-9]|1[0-9]{2}|[1-9]?[0-9]))($|[^\w.]|\.$|\.[^\w])`},
+	},
+	Keywords:    []string{"."},
+	Prefilter:   digitRun(1),
+	Validate:    func(match string) bool { return validIP(match, "0123456789.") },
+	Replacement: "${1}<IP_ADDRESS>${3}",
+}
+
+// ipv6Recognizer finds IPv6 addresses; candidates such as times (12:30:45)
+// fail to parse
+var ipv6Recognizer = Recognizer{
+	Name:       "ipv6",
+	EntityType: "IP_ADDRESS",
+	Patterns: []Pattern{
+		{Regex: `(^|[^\w:])([0-9a-fA-F]{0,4}(?::[0-9a-fA-F]{0,4}){2,7})($|[^\w:])`},
+	},
+	Keywords:    []string{":"},
+	Validate:    func(match string) bool { return validIP(match, "0123456789abcdefABCDEF:") },
+	Replacement: "${1}<IP_ADDRESS>${3}",
+}
+
+// personNameRecognizer finds a common given name followed by a capitalized
+// surname, e.g. "Jane Doe" or "Jan K. Kowalski". Given names alone are
+// kept: many are also ordinary words.
+var personNameRecognizer = Recognizer{
+	Name:       "person_name",
+	EntityType: "PERSON",
+	Patterns: []Pattern{
+		{Regex: `\b(?:` + strings.Join(givenNames, "|") + `)(?: \p{Lu}\.)? \p{Lu}\p{Ll}+(?:[-']\p{Lu}\p{Ll}+)?`},
+	},
+	Prefilter:   capitalizedAfterSpace,
+	Validate:    validPersonName,
+	Replacement: "<PERSON>",
+}
+
+// givenNames is the dictionary of personNameRecognizer. Names that are
+// also common words, programming languages or the start of tool and product
+// names (May, Will, Ruby, Julia, Adam Optimizer, Charles Proxy, Christian
+// Calendar, Frank Discussion, Victoria Metrics) are left out.
+var givenNames = []string{
+	"Aaron", "Adrian", "Agnieszka", "Ahmed", "Aisha", "Alan", "Albert", "Alejandro", "Aleksander",
+	"Alex", "Alexander", "Alice", "Alicia", "Alina", "Amanda", "Amelia", "Amy", "Ana", "Andrea",
+	"Andrew", "Andrzej", "Angela", "Anna", "Anne", "Anthony", "Antonio", "Arjun", "Barbara", "Beata",
+	"Benjamin", "Brian", "Camille", "Carlos", "Carmen", "Caroline", "Catherine", "Charlotte", "Chen",
+	"Chloe", "Christina", "Christopher", "Claire", "Claudia", "Daniel", "David", "Deborah", "Dennis",
+	"Diana", "Diego", "Dmitri", "Dorota", "Edward", "Elena", "Elizabeth", "Emily", "Emma", "Eric",
+	"Eva", "Fatima", "Francesca", "Francisco", "Gabriel", "George", "Giulia", "Giuseppe", "Hannah",
+	"Hans", "Harry", "Helen", "Henry", "Hiroshi", "Igor", "Ingrid", "Isabel", "Isabella", "Ivan",
+	"Jacek", "Jakub", "James", "Jan", "Jane", "Jason", "Javier", "Jennifer", "Jessica", "Joanna",
+	"John", "Jonathan", "Jorge", "Joseph", "Joshua", "Juan", "Julie", "Karen", "Katarzyna", "Kate",
+	"Katherine", "Kevin", "Krzysztof", "Laura", "Lauren", "Linda", "Lisa", "Lucas", "Lucy", "Luis",
+	"Magdalena", "Marco", "Margaret", "Maria", "Marie", "Marta", "Martin", "Mary", "Matthew", "Michael",
+	"Michelle", "Mohammed", "Monika", "Natalia", "Nicholas", "Nicole", "Olga", "Olivia", "Pablo", "Patricia",
+	"Patrick", "Paul", "Pedro", "Peter", "Piotr", "Priya", "Rachel", "Rahul", "Rebecca", "Richard",
+	"Robert", "Ryan", "Samantha", "Samuel", "Sandra", "Sara", "Sarah", "Sebastian", "Sergei", "Sophie",
+	"Stefan", "Stephanie", "Steven", "Susan", "Thomas", "Timothy", "Tomasz", "Wei", "William",
+	"Wojciech", "Yuki", "Zofia",
+}
+
+// notSurnames are capitalized words that follow a name without being part
+// of it, e.g. "Thanks John Please review"
+var notSurnames = map[string]bool{
+	"And": true, "Are": true, "But": true, "Can": true, "Could": true, "For": true, "From": true,
+	"Has": true, "Have": true, "Here": true, "Is": true, "Let": true, "Please": true, "Said": true,
+	"Says": true, "Should": true, "Thanks": true, "The": true, "There": true, "This": true, "Was": true,
+	"Will": true, "With": true, "Would": true, "Wrote": true,
+}
+
+// Detectors returns the names of the detectors, sorted
+func Detectors() []string {
+	names := make([]string, 0, len(detectors))
+	for name := range detectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DetectorRecognizers returns the recognizers of the named detectors, in
+// the order of Detectors. AllDetectors enables every detector.
+func DetectorRecognizers(names []string) ([]Recognizer, error) {
+	enabled := make(map[string]bool)
+	for _, n := range names {
+		n = strings.ToLower(strings.TrimSpace(n))
+		switch {
+		case n == "":
+		case n == AllDetectors:
+			for name := range detectors {
+				enabled[name] = true
+			}
+		case detectors[n] == nil:
+			return nil, fmt.Errorf("unknown scrubber detector %q (available: %s, %s)", n, strings.Join(Detectors(), ", "), AllDetectors)
+		default:
+			enabled[n] = true
+		}
+	}
+
+	var recognizers []Recognizer
+	for _, name := range Detectors() {
+		if enabled[name] {
+			recognizers = appendNewRecognizers(recognizers, detectors[name]...)
+		}
+	}
+	return recognizers, nil
+}
+
+// appendNewRecognizers appends the recognizers not named like one already
+// in list, as a detector and a locale pack can share one
+func appendNewRecognizers(list []Recognizer, more ...Recognizer) []Recognizer {
+	for _, r := range more {
+		known := false
+		for _, l := range list {
+			known = known || l.Name == r.Name
+		}
+		if !known {
+			list = append(list, r)
+		}
+	}
+	return list
+}
+
+// validLocalPhone checks the number of digits of a local phone number
+func validLocalPhone(match string) bool {
+	digits := 0
+	for _, c := range match {
+		if c >= '0' && c <= '9' {
+			digits++
+		}
+	}
+	return digits >= 9 && digits <= 12
+}
+
+// validIP parses the address in a match, made of the chars, leaving out
+// loopback and unspecified addresses, which say nothing about anyone, and
+// IPv6 candidates with fewer than three groups, such as a::b in code
+func validIP(match, chars string) bool {
+	addr := strings.TrimFunc(match, func(c rune) bool {
+		return !strings.ContainsRune(chars, c)
+	})
+	addr = strings.TrimSuffix(addr, ".")
+	ip := net.ParseIP(addr)
+	if ip == nil || ip.IsLoopback() || ip.IsUnspecified() {
+		return false
+	}
+	if strings.Contains(addr, ":") {
+		groups := 0
+		for _, g := range strings.Split(addr, ":") {
+			if g != "" {
+				groups++
+			}
+		}
+		return groups >= 3
+	}
+	return true
+}
+
+// validPersonName rejects names followed by a word that is not a surname
+func validPersonName(match string) bool {
+	return !notSurnames[match[strings.LastIndexByte(match, ' ')+1:]]
+}
+
+// capitalizedAfterSpace is the prefilter of personNameRecognizer: a
+// lowercase letter, a space and an uppercase or non-ASCII letter
+func capitalizedAfterSpace(text string) bool {
+	for i := 0; i+2 < len(text); i++ {
+		if text[i] >= 'a' && text[i] <= 'z' && text[i+1] == ' ' && (isUpper(text[i+2]) || text[i+2] >= 0x80) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,113 @@
+package scrubber
+
+import (
+	"strings"
+	"testing"
+)
+
+// newDetectorScrubber creates a scrubber with the default recognizers and
+// the given detectors
+func newDetectorScrubber(t *testing.T, names ...string) *PIIScrubber {
+	t.Helper()
+	detected, err := DetectorRecognizers(names)
+	if err != nil {
+		t.Fatalf("DetectorRecognizers(%v) error: %v", names, err)
+	}
+	s, err := New(append(DefaultRecognizers(), detected...), nil, nil)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	return s
+}
+
+func TestScrubLocalPhone(t *testing.T) {
+	checkScrub(t, newDetectorScrubber(t, "phone"), []scrubCase{
+		{"Call (415) 555-2671 tomorrow", "Call <PHONE> tomorrow"},
+		{"cell: 415-555-2671.", "cell: <PHONE>."},
+		{"London office 020 7946 0958", "London office <PHONE>"},
+		{"Berlin 030/12345678", "Berlin <PHONE>"},
+		{"+44 20 7946 0958", "<PHONE>"}, // International form, same detector
+
+		// False positives
+		{"2024-01-15", "2024-01-15"},
+		{"id 0123-4567-8901-2345", "id 0123-4567-8901-2345"},
+		{"v1.415.555.2671", "v1.415.555.2671"},
+		{"call 020 79", "call 020 79"}, // Too short
+	})
+}
+
+func TestScrubIPAddress(t *testing.T) {
+	checkScrub(t, newDetectorScrubber(t, "ip"), []scrubCase{
+		{"ssh deploy at 203.0.113.42 failed", "ssh deploy at <IP_ADDRESS> failed"},
+		{"host=10.1.2.3:8080", "host=<IP_ADDRESS>:8080"},
+		{"Connected to 198.51.100.7.", "Connected to <IP_ADDRESS>."},
+		{"addr 2001:db8:85a3::8a2e:370:7334 up", "addr <IP_ADDRESS> up"},
+		{"[2001:db8::1]:443", "[<IP_ADDRESS>]:443"},
+
+		// False positives
+		{"listen on 127.0.0.1 and ::1", "listen on 127.0.0.1 and ::1"},
+		{"bind 0.0.0.0", "bind 0.0.0.0"},
+		{"version 1.2.3.4.5", "version 1.2.3.4.5"},
+		{"256.1.1.1", "256.1.1.1"},
+		{"at 12:30:45", "at 12:30:45"},
+		{"std::vector and a::b", "std::vector and a::b"},
+		{"mac 00:1a:2b:3c:4d:5e", "mac 00:1a:2b:3c:4d:5e"},
+	})
+}
+
+func TestScrubPersonName(t *testing.T) {
+	checkScrub(t, newDetectorScrubber(t, "person"), []scrubCase{
+		{"Ask Jane Doe about it", "Ask <PERSON> about it"},
+		{"reviewed by Jan K. Kowalski", "reviewed by <PERSON>"},
+		{"cc Anna Nowak-Wiśniewska", "cc <PERSON>"},
+		{"Author: Łukasz? no, Piotr Łęcki", "Author: Łukasz? no, <PERSON>"},
+
+		// False positives
+		{"Thanks John Please review", "Thanks John Please review"},
+		{"Jane wrote the tests", "Jane wrote the tests"}, // Given name alone
+		{"Will Smith", "Will Smith"},                     // Not in the dictionary
+		{"ruby and julia bindings", "ruby and julia bindings"},
+		{"switch to the Adam Optimizer", "switch to the Adam Optimizer"},
+		{"sniff it with Charles Proxy", "sniff it with Charles Proxy"},
+		{"dates in the Christian Calendar", "dates in the Christian Calendar"},
+	})
+}
+
+func TestDetectorRecognizers(t *testing.T) {
+	all, err := DetectorRecognizers([]string{AllDetectors})
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := make(map[string]bool)
+	for _, r := range all {
+		if seen[r.Name] {
+			t.Errorf("recognizer %q enabled twice", r.Name)
+		}
+		seen[r.Name] = true
+	}
+	for _, name := range []string{"intl_phone", "local_phone", "ipv4", "ipv6", "iban", "person_name"} {
+		if !seen[name] {
+			t.Errorf("all detectors lack %q", name)
+		}
+	}
+
+	_, err = DetectorRecognizers([]string{"phone", "ssn"})
+	if err == nil || !strings.Contains(err.Error(), `"ssn"`) {
+		t.Errorf("DetectorRecognizers(ssn) error = %v, want unknown detector", err)
+	}
+
+	// Detectors that are not enabled stay off
+	checkScrub(t, newDetectorScrubber(t, "ip"), []scrubCase{
+		{"Call (415) 555-2671", "Call (415) 555-2671"},
+	})
+}
+
+func TestProfileEnablesDetector(t *testing.T) {
+	s := newDetectorScrubber(t)
+	if err := s.SetProfiles([]Profile{{ToolName: "Bash", Enable: []string{"ip"}}}); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.ForTool("claude-code").(*PIIScrubber).toolRecognizers["Bash"]; !hasRecognizer(got, "ipv4") {
+		t.Errorf("Bash recognizers lack ipv4 enabled by the profile")
+	}
+}
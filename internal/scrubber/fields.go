@@ -0,0 +1,166 @@
+package scrubber
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// FieldRedactor redacts a JSON field of session entries, for policies that
+// regexes over whole values cannot express. Path is a dotted field path
+// (e.g. "toolUseResult.stdout"); arrays on the way are descended into.
+// With ToolName, Path is relative to the tool_use and tool_result parts of
+// that tool's calls instead of the entry, e.g. "content" for the output or
+// "input.command" for the call.
+type FieldRedactor struct {
+	Name        string `yaml:"name"`
+	ToolName    string `yaml:"tool_name"`   // Only calls of this tool (empty = any entry)
+	Path        string `yaml:"path"`        // Field to redact
+	Match       string `yaml:"match"`       // Only values matching this regex (empty = always)
+	Replacement string `yaml:"replacement"` // Replacement for the whole value
+	Comment     string `yaml:"comment"`     // Explanation of why this redaction exists
+}
+
+// compiledFieldRedactor is a FieldRedactor with its path split and its
+// regex compiled
+type compiledFieldRedactor struct {
+	FieldRedactor
+	path  []string
+	match *regexp.Regexp
+}
+
+// AddFieldRedactors validates and adds field redactors to the scrubber
+func (s *PIIScrubber) AddFieldRedactors(redactors []FieldRedactor) error {
+	for _, fr := range redactors {
+		cfr, err := compileFieldRedactor(fr)
+		if err != nil {
+			return err
+		}
+		s.fieldRedactors = append(s.fieldRedactors, cfr)
+	}
+	return nil
+}
+
+// compileFieldRedactor validates a field redactor and compiles its regex
+func compileFieldRedactor(fr FieldRedactor) (compiledFieldRedactor, error) {
+	if fr.Path == "" {
+		return compiledFieldRedactor{}, fmt.Errorf("field redactor %q: no path", fr.Name)
+	}
+	cfr := compiledFieldRedactor{FieldRedactor: fr, path: strings.Split(fr.Path, ".")}
+	if cfr.Replacement == "" {
+		cfr.Replacement = "<REDACTED>"
+	}
+	if fr.Match != "" {
+		re, err := regexp.Compile(fr.Match)
+		if err != nil {
+			return compiledFieldRedactor{}, fmt.Errorf("field redactor %q: %w", fr.Name, err)
+		}
+		cfr.match = re
+	}
+	return cfr, nil
+}
+
+// buildToolNames maps the tool_use IDs in the lines to their tool names,
+// for field redactors and scrub profiles scoped to a tool
+func (s *PIIScrubber) buildToolNames(lines []scrubLine) map[string]string {
+	scoped := len(s.toolRecognizers) > 0
+	for _, fr := range s.fieldRedactors {
+		scoped = scoped || fr.ToolName != ""
+	}
+	if !scoped {
+		return nil
+	}
+
+	names := make(map[string]string)
+	forEachToolUse(lines, func(id, name string) {
+		names[id] = name
+	})
+	return names
+}
+
+// redactFields applies the field redactors to an entry
+func (s *PIIScrubber) redactFields(obj map[string]interface{}, toolNames map[string]string) {
+	for _, fr := range s.fieldRedactors {
+		if fr.ToolName == "" {
+			redactPath(obj, fr.path, fr)
+			continue
+		}
+		for _, part := range contentParts(obj) {
+			if partToolName(part, toolNames) == fr.ToolName {
+				redactPath(part, fr.path, fr)
+			}
+		}
+	}
+}
+
+// partToolName returns the name of the tool a tool_use or tool_result part
+// belongs to, or "" for other parts
+func partToolName(part map[string]interface{}, toolNames map[string]string) string {
+	id, _ := part["id"].(string)
+	if part["type"] == "tool_result" {
+		id, _ = part["tool_use_id"].(string)
+	}
+	if id == "" {
+		return ""
+	}
+	return toolNames[id]
+}
+
+// contentParts returns the parts of an entry's message.content
+func contentParts(obj map[string]interface{}) []map[string]interface{} {
+	msg, ok := obj["message"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	content, ok := msg["content"].([]interface{})
+	if !ok {
+		return nil
+	}
+	var parts []map[string]interface{}
+	for _, part := range content {
+		if partMap, ok := part.(map[string]interface{}); ok {
+			parts = append(parts, partMap)
+		}
+	}
+	return parts
+}
+
+// redactPath replaces the value at path under v, descending into arrays
+func redactPath(v interface{}, path []string, fr compiledFieldRedactor) {
+	switch val := v.(type) {
+	case []interface{}:
+		for _, inner := range val {
+			redactPath(inner, path, fr)
+		}
+	case map[string]interface{}:
+		inner, ok := val[path[0]]
+		if !ok {
+			return
+		}
+		if len(path) > 1 {
+			redactPath(inner, path[1:], fr)
+			return
+		}
+		if fr.matches(inner) {
+			val[path[0]] = fr.Replacement
+		}
+	}
+}
+
+// matches reports whether the value is to be redacted. Values other than
+// strings are matched as JSON.
+func (fr compiledFieldRedactor) matches(value interface{}) bool {
+	if fr.match == nil {
+		return true
+	}
+	text, ok := value.(string)
+	if !ok {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return false
+		}
+		text = string(data)
+	}
+	return fr.match.MatchString(text)
+}
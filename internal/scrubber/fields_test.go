@@ -0,0 +1,163 @@
+package scrubber
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// bashSession has a Bash call printing the environment, a Bash call
+// listing files and an entry with a toolUseResult
+const bashSession = `{"type":"assistant","message":{"role":"assistant","content":[{"type":"tool_use","id":"tool1","name":"Bash","input":{"command":"env"}}]}}
+{"type":"user","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"tool1","content":"HOME=/root\nDB_HOST=10.0.0.5\nPATH=/usr/bin"}]}}
+{"type":"assistant","message":{"role":"assistant","content":[{"type":"tool_use","id":"tool2","name":"Bash","input":{"command":"ls"}}]}}
+{"type":"user","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"tool2","content":"main.go\ngo.mod"}]}}
+{"type":"assistant","message":{"role":"assistant","content":[{"type":"tool_use","id":"tool3","name":"Grep","input":{"pattern":"DB_HOST=x"}}]}}
+{"type":"user","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"tool3","content":"DB_HOST=10.0.0.5"}]},"debug":{"hosts":[{"addr":"10.0.0.5","port":5432}]}}`
+
+func scrubWithFields(t *testing.T, redactors []FieldRedactor) []map[string]interface{} {
+	t.Helper()
+	s, err := New(nil, nil, nil)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	if err := s.AddFieldRedactors(redactors); err != nil {
+		t.Fatalf("AddFieldRedactors() error: %v", err)
+	}
+	out, err := s.Scrub([]byte(bashSession))
+	if err != nil {
+		t.Fatalf("Scrub() error: %v", err)
+	}
+	var entries []map[string]interface{}
+	for _, line := range strings.Split(string(out), "\n") {
+		var obj map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &obj); err != nil {
+			t.Fatalf("invalid output line %q: %v", line, err)
+		}
+		entries = append(entries, obj)
+	}
+	return entries
+}
+
+// partField returns a field of the first content part of an entry
+func partField(entry map[string]interface{}, field string) interface{} {
+	return contentParts(entry)[0][field]
+}
+
+func TestFieldRedactorScopedToTool(t *testing.T) {
+	entries := scrubWithFields(t, []FieldRedactor{{
+		Name:        "bash_env",
+		ToolName:    "Bash",
+		Path:        "content",
+		Match:       `(?m)^[A-Z_]+=`,
+		Replacement: "<ENV>",
+	}})
+
+	if got := partField(entries[1], "content"); got != "<ENV>" {
+		t.Errorf("env output = %v, want <ENV>", got)
+	}
+	if got := partField(entries[3], "content"); got != "main.go\ngo.mod" {
+		t.Errorf("ls output = %v, want unchanged", got)
+	}
+	// Same output from another tool is kept
+	if got := partField(entries[5], "content"); got != "DB_HOST=10.0.0.5" {
+		t.Errorf("Grep output = %v, want unchanged", got)
+	}
+	// The call itself has no content field
+	if input := partField(entries[0], "input").(map[string]interface{}); input["command"] != "env" {
+		t.Errorf("Bash input = %v, want unchanged", input)
+	}
+}
+
+func TestFieldRedactorToolInput(t *testing.T) {
+	entries := scrubWithFields(t, []FieldRedactor{{
+		ToolName: "Bash",
+		Path:     "input.command",
+	}})
+
+	for _, i := range []int{0, 2} {
+		input := partField(entries[i], "input").(map[string]interface{})
+		if input["command"] != "<REDACTED>" {
+			t.Errorf("entry %d command = %v, want default replacement", i, input["command"])
+		}
+	}
+	if got := partField(entries[1], "content"); got == "<REDACTED>" {
+		t.Error("tool output was redacted, want only the input")
+	}
+}
+
+func TestFieldRedactorEntryPath(t *testing.T) {
+	entries := scrubWithFields(t, []FieldRedactor{{
+		Path:        "debug.hosts.addr",
+		Replacement: "<HOST>",
+	}})
+
+	hosts := entries[5]["debug"].(map[string]interface{})["hosts"].([]interface{})
+	host := hosts[0].(map[string]interface{})
+	if host["addr"] != "<HOST>" || host["port"] != float64(5432) {
+		t.Errorf("host = %v, want addr redacted and port kept", host)
+	}
+}
+
+func TestFieldRedactorMatchesJSONValues(t *testing.T) {
+	entries := scrubWithFields(t, []FieldRedactor{{
+		Path:  "debug.hosts",
+		Match: `5432`,
+	}})
+
+	if got := entries[5]["debug"].(map[string]interface{})["hosts"]; got != "<REDACTED>" {
+		t.Errorf("hosts = %v, want <REDACTED>", got)
+	}
+}
+
+func TestAddFieldRedactorsInvalid(t *testing.T) {
+	s, _ := New(nil, nil, nil)
+	if err := s.AddFieldRedactors([]FieldRedactor{{Name: "nopath"}}); err == nil {
+		t.Error("expected error for a redactor without path")
+	}
+	if err := s.AddFieldRedactors([]FieldRedactor{{Name: "bad", Path: "x", Match: "("}}); err == nil {
+		t.Error("expected error for an invalid regex")
+	}
+}
+
+func TestLoadPatterns(t *testing.T) {
+	dir := t.TempDir()
+	isolateUserConfig(t)
+
+	cfg, err := LoadPatterns(dir)
+	if err != nil || len(cfg.FieldRedactors) != 0 {
+		t.Fatalf("LoadPatterns(no file) = %v, %v, want empty config", cfg, err)
+	}
+
+	path := filepath.Join(dir, PatternsFile)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	yaml := `field_redactors:
+  - name: bash_env
+    tool_name: Bash
+    path: content
+    match: '(?m)^[A-Z_]+='
+    replacement: <ENV>
+`
+	if err := os.WriteFile(path, []byte(yaml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err = LoadPatterns(dir)
+	if err != nil {
+		t.Fatalf("LoadPatterns() error: %v", err)
+	}
+	want := FieldRedactor{Name: "bash_env", ToolName: "Bash", Path: "content", Match: `(?m)^[A-Z_]+=`, Replacement: "<ENV>"}
+	if len(cfg.FieldRedactors) != 1 || cfg.FieldRedactors[0] != want {
+		t.Errorf("FieldRedactors = %+v, want [%+v]", cfg.FieldRedactors, want)
+	}
+
+	if err := os.WriteFile(path, []byte("field_redactors: {"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadPatterns(dir); err == nil {
+		t.Error("expected error for invalid YAML")
+	}
+}
@@ -0,0 +1,234 @@
+package scrubber
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+)
+
+// AllLocales enables every locale pack
+const AllLocales = "all"
+
+// localePacks are recognizers for non-US formats, enabled per locale with
+// prompt-story.scrubLocales. They are off by default as their patterns are
+// looser than the default ones; checksums keep false positives down where
+// the format has one.
+var localePacks = map[string][]Recognizer{
+	// International formats used across countries
+	"intl": {ibanRecognizer, intlPhoneRecognizer},
+
+	// European Union
+	"eu": {
+		{
+			Name:       "eu_vat",
+			EntityType: "VAT_ID",
+			Patterns: []Pattern{
+				{Regex: `\b(?:ATU[0-9]{8}|BE[01][0-9]{9}|BG[0-9]{9,10}|CY[0-9]{8}[A-Z]|CZ[0-9]{8,10}|DE[0-9]{9}|DK[0-9]{8}|EE[0-9]{9}|EL[0-9]{9}|ES[0-9A-Z][0-9]{7}[0-9A-Z]|FI[0-9]{8}|FR[0-9A-HJ-NP-Z]{2}[0-9]{9}|HR[0-9]{11}|HU[0-9]{8}|IE[0-9][0-9A-Z+*][0-9]{5}[A-W][A-I]?|IT[0-9]{11}|LT(?:[0-9]{9}|[0-9]{12})|LU[0-9]{8}|LV[0-9]{11}|MT[0-9]{8}|NL[0-9]{9}B[0-9]{2}|PL[0-9]{10}|PT[0-9]{9}|RO[0-9]{6,10}|SE[0-9]{10}01|SI[0-9]{8}|SK[0-9]{10})\b`},
+			},
+			Prefilter:   digitRun(5),
+			Replacement: "<VAT_ID>",
+		},
+	},
+
+	// United Kingdom
+	"uk": {
+		{
+			Name:       "uk_nino",
+			EntityType: "NATIONAL_ID",
+			Patterns: []Pattern{
+				// National Insurance number, e.g. AB 12 34 56 C
+				{Regex: `\b[A-CEGHJ-PR-TW-Z][A-CEGHJ-NPR-TW-Z] ?[0-9]{2} ?[0-9]{2} ?[0-9]{2} ?[A-D]\b`},
+			},
+			Prefilter:   func(text string) bool { return upperPairThenDigit(text, true) },
+			Validate:    validNINO,
+			Replacement: "<NATIONAL_ID>",
+		},
+		{
+			Name:       "uk_vat",
+			EntityType: "VAT_ID",
+			Patterns: []Pattern{
+				{Regex: `\bGB(?:[0-9]{9}|[0-9]{12}|GD[0-4][0-9]{2}|HA[5-9][0-9]{2})\b`},
+			},
+			Keywords:    []string{"gb"},
+			Replacement: "<VAT_ID>",
+		},
+	},
+
+	// Poland
+	"pl": {
+		{
+			Name:       "pl_pesel",
+			EntityType: "NATIONAL_ID",
+			Patterns: []Pattern{
+				{Regex: `\b[0-9]{11}\b`},
+			},
+			Prefilter:   digitRun(11),
+			Validate:    validPESEL,
+			Replacement: "<NATIONAL_ID>",
+		},
+	},
+}
+
+// ibanRecognizer finds IBANs with a valid checksum
+var ibanRecognizer = Recognizer{
+	Name:       "iban",
+	EntityType: "IBAN",
+	Patterns: []Pattern{
+		{Regex: `\b[A-Z]{2}[0-9]{2}(?: ?[A-Z0-9]{4}){2,7}(?: ?[A-Z0-9]{1,3})?\b`},
+	},
+	Prefilter:   func(text string) bool { return upperPairThenDigit(text, false) },
+	Validate:    validIBAN,
+	Replacement: "<IBAN>",
+}
+
+// intlPhoneRecognizer finds phone numbers in E.164 form
+var intlPhoneRecognizer = Recognizer{
+	Name:       "intl_phone",
+	EntityType: "PHONE",
+	Patterns: []Pattern{
+		// E.164 with the usual separators, not inside a longer token
+		{Regex: `(^|[^\w+])\+[1-9][0-9]{0,2}[ .-]?(?:\([0-9]{1,4}\)[ .-]?)?[0-9]{1,4}(?:[ .-]?[0-9]{2,4}){1,4}\b`},
+	},
+	Keywords:    []string{"+"},
+	Validate:    validPhone,
+	Replacement: "${1}<PHONE>",
+}
+
+// Locales returns the names of the locale packs, sorted
+func Locales() []string {
+	names := make([]string, 0, len(localePacks))
+	for name := range localePacks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LocaleRecognizers returns the recognizers of the named locale packs, in
+// the order of Locales. AllLocales enables every pack.
+func LocaleRecognizers(locales []string) ([]Recognizer, error) {
+	enabled := make(map[string]bool)
+	for _, l := range locales {
+		l = strings.ToLower(strings.TrimSpace(l))
+		switch {
+		case l == "":
+		case l == AllLocales:
+			for name := range localePacks {
+				enabled[name] = true
+			}
+		case localePacks[l] == nil:
+			return nil, fmt.Errorf("unknown scrubber locale %q (available: %s, %s)", l, strings.Join(Locales(), ", "), AllLocales)
+		default:
+			enabled[l] = true
+		}
+	}
+
+	var recognizers []Recognizer
+	for _, name := range Locales() {
+		if enabled[name] {
+			recognizers = append(recognizers, localePacks[name]...)
+		}
+	}
+	return recognizers, nil
+}
+
+// upperPairThenDigit is the prefilter of formats starting with two
+// uppercase letters and a digit, optionally after a space
+func upperPairThenDigit(text string, space bool) bool {
+	for i := 0; i+2 < len(text); i++ {
+		if !isUpper(text[i]) || !isUpper(text[i+1]) {
+			continue
+		}
+		j := i + 2
+		if space && text[j] == ' ' && j+1 < len(text) {
+			j++
+		}
+		if text[j] >= '0' && text[j] <= '9' {
+			return true
+		}
+	}
+	return false
+}
+
+func isUpper(c byte) bool {
+	return c >= 'A' && c <= 'Z'
+}
+
+// ibanLengths are the IBAN lengths of the countries using it
+var ibanLengths = map[string]int{
+	"AD": 24, "AE": 23, "AL": 28, "AT": 20, "AZ": 28, "BA": 20, "BE": 16,
+	"BG": 22, "BH": 22, "BR": 29, "BY": 28, "CH": 21, "CR": 22, "CY": 28,
+	"CZ": 24, "DE": 22, "DK": 18, "DO": 28, "EE": 20, "EG": 29, "ES": 24,
+	"FI": 18, "FO": 18, "FR": 27, "GB": 22, "GE": 22, "GI": 23, "GL": 18,
+	"GR": 27, "GT": 28, "HR": 21, "HU": 28, "IE": 22, "IL": 23, "IQ": 23,
+	"IS": 26, "IT": 27, "JO": 30, "KW": 30, "KZ": 20, "LB": 28, "LC": 32,
+	"LI": 21, "LT": 20, "LU": 20, "LV": 21, "MC": 27, "MD": 24, "ME": 22,
+	"MK": 19, "MR": 27, "MT": 31, "MU": 30, "NL": 18, "NO": 15, "PK": 24,
+	"PL": 28, "PS": 29, "PT": 25, "QA": 29, "RO": 24, "RS": 22, "SA": 24,
+	"SC": 31, "SE": 24, "SI": 19, "SK": 24, "SM": 27, "TL": 23, "TN": 24,
+	"TR": 26, "UA": 29, "VA": 22, "VG": 24, "XK": 20,
+}
+
+// validIBAN checks the country's length and the ISO 13616 mod-97 checksum
+func validIBAN(match string) bool {
+	iban := strings.ReplaceAll(match, " ", "")
+	if ibanLengths[iban[:2]] != len(iban) {
+		return false
+	}
+
+	// Move the country and check digits to the end, letters become 10..35
+	var digits strings.Builder
+	for _, c := range iban[4:] + iban[:4] {
+		if c >= 'A' && c <= 'Z' {
+			fmt.Fprintf(&digits, "%d", c-'A'+10)
+		} else {
+			digits.WriteRune(c)
+		}
+	}
+	n, ok := new(big.Int).SetString(digits.String(), 10)
+	return ok && new(big.Int).Mod(n, big.NewInt(97)).Int64() == 1
+}
+
+// validPhone requires the 8 to 15 digits of an E.164 number
+func validPhone(match string) bool {
+	digits := 0
+	for _, c := range match[strings.IndexByte(match, '+'):] {
+		if c >= '0' && c <= '9' {
+			digits++
+		}
+	}
+	return digits >= 8 && digits <= 15
+}
+
+// invalidNINOPrefixes are never allocated
+var invalidNINOPrefixes = map[string]bool{"BG": true, "GB": true, "KN": true, "NK": true, "NT": true, "TN": true, "ZZ": true}
+
+// validNINO rejects prefixes that are never allocated
+func validNINO(match string) bool {
+	return !invalidNINOPrefixes[match[:2]]
+}
+
+// validPESEL checks the birth date encoded in the first six digits and the
+// check digit
+func validPESEL(match string) bool {
+	var d [11]int
+	for i, c := range match {
+		d[i] = int(c - '0')
+	}
+
+	// Months 1-12 are the 1900s, +20 the 2000s, +40 the 2100s, +60 the
+	// 2200s and +80 the 1800s
+	month := (d[2]*10 + d[3]) % 20
+	day := d[4]*10 + d[5]
+	if month < 1 || month > 12 || day < 1 || day > 31 {
+		return false
+	}
+
+	weights := [10]int{1, 3, 7, 9, 1, 3, 7, 9, 1, 3}
+	sum := 0
+	for i, w := range weights {
+		sum += d[i] * w
+	}
+	return (10-sum%10)%10 == d[10]
+}
@@ -0,0 +1,146 @@
+package scrubber
+
+import (
+	"strings"
+	"testing"
+)
+
+// newLocaleScrubber creates a scrubber with the default recognizers and
+// the given locale packs
+func newLocaleScrubber(t *testing.T, locales ...string) *PIIScrubber {
+	t.Helper()
+	locale, err := LocaleRecognizers(locales)
+	if err != nil {
+		t.Fatalf("LocaleRecognizers(%v) error: %v", locales, err)
+	}
+	s, err := New(append(DefaultRecognizers(), locale...), nil, nil)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	return s
+}
+
+type scrubCase struct {
+	input    string
+	expected string
+}
+
+func checkScrub(t *testing.T, s *PIIScrubber, tests []scrubCase) {
+	t.Helper()
+	for _, tc := range tests {
+		if result := s.ScrubText(tc.input); result != tc.expected {
+			t.Errorf("ScrubText(%q) = %q, want %q", tc.input, result, tc.expected)
+		}
+	}
+}
+
+func TestScrubIBAN(t *testing.T) {
+	checkScrub(t, newLocaleScrubber(t, "intl"), []scrubCase{
+		{"Pay to DE89 3704 0044 0532 0130 00 today", "Pay to <IBAN> today"},
+		{"GB82WEST12345698765432", "<IBAN>"},
+		{"iban: PL61109010140000071219812874", "iban: <IBAN>"},
+		{"FR1420041010050500013M02606", "<IBAN>"},
+
+		// False positives
+		{"DE89 3704 0044 0532 0130 01", "DE89 3704 0044 0532 0130 01"}, // Bad checksum
+		{"DE89370400440532013", "DE89370400440532013"},                 // Wrong length for DE
+		{"ZZ12ABCD1234EFGH5678", "ZZ12ABCD1234EFGH5678"},               // Not a country
+		{"SHA256 AB12CDEF3456ABCD7890", "SHA256 AB12CDEF3456ABCD7890"},
+		{"FOO_BAR12_BAZ_QUUX_LONG_NAME", "FOO_BAR12_BAZ_QUUX_LONG_NAME"},
+	})
+}
+
+func TestScrubInternationalPhone(t *testing.T) {
+	checkScrub(t, newLocaleScrubber(t, "intl"), []scrubCase{
+		{"Call +48 601 234 567 tomorrow", "Call <PHONE> tomorrow"},
+		{"+44 20 7946 0958", "<PHONE>"},
+		{"Office: +1 (415) 555-2671.", "Office: <PHONE>."},
+		{"tel:+49-30-1234567", "tel:<PHONE>"},
+		{"+33612345678", "<PHONE>"},
+		{`"phone":"+420 601 123 456"`, `"phone":"<PHONE>"`},
+
+		// False positives
+		{"x = y +1", "x = y +1"},
+		{"bump to +1.2.3", "bump to +1.2.3"},
+		{"a+12345678", "a+12345678"},                     // Inside an expression
+		{"+1 234", "+1 234"},                             // Too short
+		{"offset +100 200", "offset +100 200"},           // Too short
+		{"+1234567890123456789", "+1234567890123456789"}, // Too long
+		{"2024-01-15T10:30:00+01:00", "2024-01-15T10:30:00+01:00"},
+	})
+}
+
+func TestScrubEUVAT(t *testing.T) {
+	checkScrub(t, newLocaleScrubber(t, "eu"), []scrubCase{
+		{"VAT: DE123456789", "VAT: <VAT_ID>"},
+		{"NL123456789B01", "<VAT_ID>"},
+		{"ATU12345678", "<VAT_ID>"},
+		{"FR40303265045", "<VAT_ID>"},
+		{"PL5261040828", "<VAT_ID>"},
+
+		// False positives
+		{"DE12345", "DE12345"},
+		{"DE1234567890", "DE1234567890"}, // Too long for DE
+		{"PLAN1234567890", "PLAN1234567890"},
+		{"CODE123456789", "CODE123456789"},
+		{"nl123456789b01", "nl123456789b01"}, // Lowercase
+	})
+}
+
+func TestScrubUKNationalInsuranceNumber(t *testing.T) {
+	checkScrub(t, newLocaleScrubber(t, "uk"), []scrubCase{
+		{"NINO: AB 12 34 56 C", "NINO: <NATIONAL_ID>"},
+		{"JG103759A", "<NATIONAL_ID>"},
+		{"GB123456789", "<VAT_ID>"},
+
+		// False positives
+		{"GB123456A", "GB123456A"}, // Prefix never allocated
+		{"QQ123456C", "QQ123456C"}, // Example prefix
+		{"AB123456E", "AB123456E"}, // Suffix out of range
+		{"ab123456c", "ab123456c"}, // Lowercase
+		{"XAB123456C", "XAB123456C"},
+	})
+}
+
+func TestScrubPESEL(t *testing.T) {
+	checkScrub(t, newLocaleScrubber(t, "pl"), []scrubCase{
+		{"PESEL 44051401359", "PESEL <NATIONAL_ID>"},
+		{"02270803624", "<NATIONAL_ID>"}, // Born in the 2000s
+
+		// False positives
+		{"44051401358", "44051401358"}, // Bad check digit
+		{"12345678901", "12345678901"}, // No such month
+		{"44053201359", "44053201359"}, // No such day
+		{"17000000000", "17000000000"},
+		{"440514013590", "440514013590"}, // Too long
+		{"id=44051401359;", "id=<NATIONAL_ID>;"},
+	})
+}
+
+func TestLocalePacksOffByDefault(t *testing.T) {
+	checkScrub(t, newLocaleScrubber(t), []scrubCase{
+		{"DE89 3704 0044 0532 0130 00", "DE89 3704 0044 0532 0130 00"},
+		{"+48 601 234 567", "+48 601 234 567"},
+		{"44051401359", "44051401359"},
+		{"AB 12 34 56 C", "AB 12 34 56 C"},
+	})
+}
+
+func TestLocaleRecognizers(t *testing.T) {
+	all, err := LocaleRecognizers([]string{AllLocales})
+	if err != nil {
+		t.Fatalf("LocaleRecognizers(all) error: %v", err)
+	}
+	some, err := LocaleRecognizers([]string{" EU", "pl", ""})
+	if err != nil {
+		t.Fatalf("LocaleRecognizers(eu, pl) error: %v", err)
+	}
+	if len(some) == 0 || len(some) >= len(all) {
+		t.Errorf("got %d recognizers for eu,pl and %d for all", len(some), len(all))
+	}
+
+	_, err = LocaleRecognizers([]string{"xx"})
+	if err == nil || !strings.Contains(err.Error(), "unknown scrubber locale") {
+		t.Errorf("LocaleRecognizers(xx) error = %v, want unknown locale", err)
+	}
+}
@@ -0,0 +1,170 @@
+package scrubber
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+	"gopkg.in/yaml.v3"
+)
+
+// PatternsFile is the repository's scrubber configuration, relative to the
+// repository root
+const PatternsFile = ".git-prompt-story/scrubber.yaml"
+
+// PatternsConfig is the layout of PatternsFile and of the user's scrubber
+// configuration, see UserPatternsPath
+type PatternsConfig struct {
+	// Recognizers run after the built-in ones; one named like a built-in
+	// recognizer, or like one of a lower layer, replaces it
+	Recognizers    []Recognizer    `yaml:"recognizers"`
+	FieldRedactors []FieldRedactor `yaml:"field_redactors"`
+
+	// Allowlist exempts matches of any layer's recognizers from replacement
+	Allowlist []AllowEntry `yaml:"allowlist"`
+}
+
+// UserPatternsPath returns the user's scrubber configuration, applied in
+// every repository: ~/.config/git-prompt-story/scrubber.yaml on Linux
+func UserPatternsPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "git-prompt-story", "scrubber.yaml"), nil
+}
+
+// LoadPatterns reads the user's scrubber configuration and PatternsFile
+// from the repository root, layering the repository's over the user's.
+// Missing files configure nothing.
+func LoadPatterns(repoRoot string) (*PatternsConfig, error) {
+	cfg, err := loadUserPatterns()
+	if err != nil {
+		return nil, err
+	}
+	repo, err := LoadPatternsFile(filepath.Join(repoRoot, PatternsFile))
+	if err != nil {
+		return nil, err
+	}
+	cfg.merge(repo)
+	return cfg, nil
+}
+
+// LoadPatternsFile reads a scrubber configuration file and validates it,
+// so a bad regex is reported when the file is loaded rather than when a
+// transcript is scrubbed. A missing file configures nothing.
+func LoadPatternsFile(path string) (*PatternsConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return &PatternsConfig{}, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var cfg PatternsConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", path, err)
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// loadUserPatterns loads the user's scrubber configuration, if any
+func loadUserPatterns() (*PatternsConfig, error) {
+	path, err := UserPatternsPath()
+	if err != nil {
+		return &PatternsConfig{}, nil // No home directory
+	}
+	return LoadPatternsFile(path)
+}
+
+// loadRepoPatterns loads the scrubber configuration of the current
+// repository, the user's alone outside of one
+func loadRepoPatterns() (*PatternsConfig, error) {
+	repoRoot, err := git.GetRepoRoot()
+	if err != nil {
+		return loadUserPatterns()
+	}
+	return LoadPatterns(repoRoot)
+}
+
+// validate checks the recognizers, field redactors and allowlist and fills
+// in the defaults of recognizers: the entity type is the upper-cased name
+// and the replacement the entity type in angle brackets
+func (c *PatternsConfig) validate() error {
+	for i := range c.Recognizers {
+		r := &c.Recognizers[i]
+		if r.Name == "" {
+			return fmt.Errorf("recognizer %d: no name", i+1)
+		}
+		if len(r.Patterns) == 0 {
+			return fmt.Errorf("recognizer %q: no patterns", r.Name)
+		}
+		for _, p := range r.Patterns {
+			if _, err := regexp.Compile(p.Regex); err != nil {
+				return fmt.Errorf("recognizer %q: %w", r.Name, err)
+			}
+		}
+		if r.EntityType == "" {
+			r.EntityType = strings.ToUpper(r.Name)
+		}
+		if r.Replacement == "" {
+			r.Replacement = "<" + r.EntityType + ">"
+		}
+	}
+	for _, fr := range c.FieldRedactors {
+		if _, err := compileFieldRedactor(fr); err != nil {
+			return err
+		}
+	}
+	for _, e := range c.Allowlist {
+		if _, err := compileAllowEntry(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// merge layers over on c
+func (c *PatternsConfig) merge(over *PatternsConfig) {
+	for _, r := range over.Recognizers {
+		replaced := false
+		for i := range c.Recognizers {
+			if c.Recognizers[i].Name == r.Name {
+				c.Recognizers[i], replaced = r, true
+			}
+		}
+		if !replaced {
+			c.Recognizers = append(c.Recognizers, r)
+		}
+	}
+	c.FieldRedactors = append(c.FieldRedactors, over.FieldRedactors...)
+	c.Allowlist = append(c.Allowlist, over.Allowlist...)
+}
+
+// AddRecognizers compiles recognizers and adds them to the scrubber; one
+// named like a recognizer of the scrubber replaces it in place
+func (s *PIIScrubber) AddRecognizers(recognizers []Recognizer) error {
+	compiled, err := compileRecognizers(recognizers)
+	if err != nil {
+		return err
+	}
+	for _, r := range compiled {
+		replaced := false
+		for i := range s.recognizers {
+			if s.recognizers[i].Name == r.Name {
+				s.recognizers[i], replaced = r, true
+			}
+		}
+		if !replaced {
+			s.recognizers = append(s.recognizers, r)
+		}
+	}
+	return nil
+}
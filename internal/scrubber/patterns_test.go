@@ -0,0 +1,118 @@
+package scrubber
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// isolateUserConfig points the user's config directory at an empty
+// temporary directory and returns the user's scrubber configuration path
+func isolateUserConfig(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	path, err := UserPatternsPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func writePatterns(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadPatterns_Layers(t *testing.T) {
+	userPath := isolateUserConfig(t)
+	repo := t.TempDir()
+
+	writePatterns(t, userPath, `recognizers:
+  - name: ticket
+    patterns:
+      - regex: 'TICKET-\d+'
+  - name: codename
+    patterns:
+      - regex: 'Project Falcon'
+field_redactors:
+  - name: user_env
+    path: env
+`)
+	writePatterns(t, filepath.Join(repo, PatternsFile), `recognizers:
+  - name: ticket
+    entity_type: JIRA
+    patterns:
+      - regex: 'ACME-\d+'
+field_redactors:
+  - name: repo_env
+    path: environment
+`)
+
+	cfg, err := LoadPatterns(repo)
+	if err != nil {
+		t.Fatalf("LoadPatterns() error: %v", err)
+	}
+	if len(cfg.Recognizers) != 2 {
+		t.Fatalf("got %d recognizers, want 2", len(cfg.Recognizers))
+	}
+	ticket := cfg.Recognizers[0]
+	if ticket.Name != "ticket" || ticket.Patterns[0].Regex != `ACME-\d+` || ticket.Replacement != "<JIRA>" {
+		t.Errorf("repository recognizer did not replace the user's: %+v", ticket)
+	}
+	if codename := cfg.Recognizers[1]; codename.EntityType != "CODENAME" || codename.Replacement != "<CODENAME>" {
+		t.Errorf("defaults not filled in: %+v", codename)
+	}
+	if len(cfg.FieldRedactors) != 2 {
+		t.Errorf("got %d field redactors, want both layers", len(cfg.FieldRedactors))
+	}
+}
+
+func TestLoadPatternsFile_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scrubber.yaml")
+	tests := map[string]string{
+		"bad regex":   "recognizers:\n  - name: broken\n    patterns:\n      - regex: '('\n",
+		"no patterns": "recognizers:\n  - name: empty\n",
+		"no name":     "recognizers:\n  - patterns:\n      - regex: 'x'\n",
+		"field regex": "field_redactors:\n  - name: f\n    path: x\n    match: '['\n",
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			writePatterns(t, path, content)
+			_, err := LoadPatternsFile(path)
+			if err == nil || !strings.Contains(err.Error(), path) {
+				t.Errorf("LoadPatternsFile() error = %v, want one naming the file", err)
+			}
+		})
+	}
+}
+
+func TestAddRecognizers(t *testing.T) {
+	s, err := New(DefaultRecognizers(), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.AddRecognizers([]Recognizer{
+		{Name: "email", EntityType: "EMAIL", Patterns: []Pattern{{Regex: `[a-z]+@corp\.example`}}, Replacement: "<CORP_EMAIL>"},
+		{Name: "ticket", EntityType: "TICKET", Patterns: []Pattern{{Regex: `ACME-\d+`}}, Replacement: "<TICKET>"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := s.ScrubText("jane@corp.example fixed ACME-42, ask bob@other.org")
+	want := "<CORP_EMAIL> fixed <TICKET>, ask bob@other.org"
+	if got != want {
+		t.Errorf("ScrubText() = %q, want %q", got, want)
+	}
+	if len(s.recognizers) != len(DefaultRecognizers())+1 {
+		t.Errorf("got %d recognizers, want the override in place and one added", len(s.recognizers))
+	}
+}
@@ -0,0 +1,205 @@
+package scrubber
+
+import (
+	"fmt"
+	"strings"
+)
+
+// AllGroups names every recognizer in a scrub profile
+const AllGroups = "all"
+
+// Profile turns recognizer groups off or on for the sessions of one tool,
+// or for the calls and results of one tool within sessions. A group is an
+// entity type (e.g. "USER_PATH"), a recognizer name, a locale pack (e.g.
+// "intl"), a detector (e.g. "phone") or AllGroups. Profiles apply in
+// order, a session's before those of its tool calls.
+type Profile struct {
+	Tool     string   `yaml:"tool"`      // Only sessions of this tool ID, e.g. "cursor" (empty = any)
+	ToolName string   `yaml:"tool_name"` // Only calls and results of this tool, e.g. "Read" (empty = whole sessions)
+	Disable  []string `yaml:"disable"`   // Groups not to apply
+	Enable   []string `yaml:"enable"`    // Groups to apply, including locale packs and detectors that are off
+}
+
+// ToolScrubber is a Scrubber whose rules depend on the tool that recorded
+// the session
+type ToolScrubber interface {
+	Scrubber
+	// ForTool returns the scrubber for sessions of a tool ID
+	ForTool(tool string) Scrubber
+}
+
+var _ ToolScrubber = (*PIIScrubber)(nil)
+
+// SetProfiles validates and sets the scrub profiles applied by ForTool.
+// Locale packs and detectors that are off can be enabled by the profiles.
+func (s *PIIScrubber) SetProfiles(profiles []Profile) error {
+	if len(profiles) == 0 {
+		return nil
+	}
+
+	pool := append([]CompiledRecognizer(nil), s.recognizers...)
+	var off []Recognizer
+	for _, pack := range Locales() {
+		off = append(off, localePacks[pack]...)
+	}
+	for _, name := range Detectors() {
+		off = append(off, detectors[name]...)
+	}
+	for _, r := range off {
+		if !hasRecognizer(pool, r.Name) {
+			compiled, err := compileRecognizers([]Recognizer{r})
+			if err != nil {
+				return err
+			}
+			pool = append(pool, compiled...)
+		}
+	}
+
+	for i, p := range profiles {
+		for _, group := range append(append([]string(nil), p.Disable...), p.Enable...) {
+			if !hasGroup(pool, group) {
+				return fmt.Errorf("scrub profile %d: unknown recognizer group %q", i+1, group)
+			}
+		}
+	}
+
+	s.profiles = profiles
+	s.pool = pool
+	return nil
+}
+
+// ForTool returns the scrubber for sessions of a tool: the recognizers its
+// profiles leave on, and those of its tool calls by tool name
+func (s *PIIScrubber) ForTool(tool string) Scrubber {
+	if len(s.profiles) == 0 {
+		return s
+	}
+
+	defaults := make([]bool, len(s.pool))
+	for i := range s.recognizers {
+		defaults[i] = true
+	}
+	session := s.applyProfiles(defaults, tool, "")
+
+	ts := *s
+	ts.recognizers = s.selected(session)
+	ts.toolRecognizers = make(map[string][]CompiledRecognizer)
+	for _, p := range s.profiles {
+		if p.ToolName == "" || !profileMatches(p, tool) {
+			continue
+		}
+		if _, ok := ts.toolRecognizers[p.ToolName]; !ok {
+			ts.toolRecognizers[p.ToolName] = s.selected(s.applyProfiles(session, tool, p.ToolName))
+		}
+	}
+	return &ts
+}
+
+// applyProfiles returns which recognizers of the pool are on after the
+// profiles for tool and toolName are applied to on
+func (s *PIIScrubber) applyProfiles(on []bool, tool, toolName string) []bool {
+	on = append([]bool(nil), on...)
+	for _, p := range s.profiles {
+		if p.ToolName != toolName || !profileMatches(p, tool) {
+			continue
+		}
+		for i := range s.pool {
+			for _, group := range p.Disable {
+				if inGroup(&s.pool[i], group) {
+					on[i] = false
+				}
+			}
+			for _, group := range p.Enable {
+				if inGroup(&s.pool[i], group) {
+					on[i] = true
+				}
+			}
+		}
+	}
+	return on
+}
+
+// selected returns the recognizers of the pool that are on
+func (s *PIIScrubber) selected(on []bool) []CompiledRecognizer {
+	var recognizers []CompiledRecognizer
+	for i, r := range s.pool {
+		if on[i] {
+			recognizers = append(recognizers, r)
+		}
+	}
+	return recognizers
+}
+
+// scrubEntry applies the PII patterns to an entry. Tool calls and results
+// of tools with a scrub profile are scrubbed with that profile's
+// recognizers instead of the session's.
+func (s *PIIScrubber) scrubEntry(obj map[string]interface{}, toolNames map[string]string) {
+	if len(s.toolRecognizers) > 0 {
+		if msg, ok := obj["message"].(map[string]interface{}); ok {
+			if content, ok := msg["content"].([]interface{}); ok {
+				// Take profiled parts out while the rest is scrubbed
+				held := make(map[int]interface{})
+				for i, part := range content {
+					partMap, ok := part.(map[string]interface{})
+					if !ok {
+						continue
+					}
+					recognizers, ok := s.toolRecognizers[partToolName(partMap, toolNames)]
+					if !ok {
+						continue
+					}
+					s.scrubValue(partMap, recognizers)
+					held[i] = part
+					content[i] = nil
+				}
+				defer func() {
+					for i, part := range held {
+						content[i] = part
+					}
+				}()
+			}
+		}
+	}
+	s.scrubValue(obj, s.recognizers)
+}
+
+// profileMatches reports whether a profile applies to sessions of tool
+func profileMatches(p Profile, tool string) bool {
+	return p.Tool == "" || strings.EqualFold(p.Tool, tool)
+}
+
+// inGroup reports whether a recognizer belongs to a profile group
+func inGroup(r *CompiledRecognizer, group string) bool {
+	if group == AllGroups || group == r.Name || strings.EqualFold(group, r.EntityType) {
+		return true
+	}
+	for _, lr := range localePacks[group] {
+		if lr.Name == r.Name {
+			return true
+		}
+	}
+	for _, dr := range detectors[group] {
+		if dr.Name == r.Name {
+			return true
+		}
+	}
+	return false
+}
+
+func hasGroup(pool []CompiledRecognizer, group string) bool {
+	for i := range pool {
+		if inGroup(&pool[i], group) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasRecognizer(pool []CompiledRecognizer, name string) bool {
+	for _, r := range pool {
+		if r.Name == name {
+			return true
+		}
+	}
+	return false
+}
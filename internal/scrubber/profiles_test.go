@@ -0,0 +1,91 @@
+package scrubber
+
+import (
+	"strings"
+	"testing"
+)
+
+// editSession has a Read call and a Bash call touching the same home
+// directory, and a prompt mentioning it
+const editSession = `{"type":"user","message":{"role":"user","content":"fix /home/jane/app/main.go, mail jane@example.com"}}
+{"type":"assistant","message":{"role":"assistant","content":[{"type":"tool_use","id":"tool1","name":"Read","input":{"file_path":"/home/jane/app/main.go"}}]}}
+{"type":"user","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"tool1","content":"package main // jane@example.com"}]}}
+{"type":"assistant","message":{"role":"assistant","content":[{"type":"tool_use","id":"tool2","name":"Bash","input":{"command":"cat /home/jane/.profile"}}]}}
+{"type":"user","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"tool2","content":"IBAN GB82 WEST 1234 5698 7654 32"}]}}`
+
+func scrubWithProfiles(t *testing.T, tool string, profiles []Profile) []string {
+	t.Helper()
+	s, err := New(DefaultRecognizers(), nil, nil)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	if err := s.SetProfiles(profiles); err != nil {
+		t.Fatalf("SetProfiles() error: %v", err)
+	}
+	out, err := s.ForTool(tool).Scrub([]byte(editSession))
+	if err != nil {
+		t.Fatalf("Scrub() error: %v", err)
+	}
+	return strings.Split(string(out), "\n")
+}
+
+func TestProfileKeepsPathsForTool(t *testing.T) {
+	lines := scrubWithProfiles(t, "claude-code", []Profile{
+		{ToolName: "Read", Disable: []string{"USER_PATH"}},
+	})
+
+	if !strings.Contains(lines[1], "/home/jane/app/main.go") {
+		t.Errorf("Read call path should be kept: %s", lines[1])
+	}
+	if strings.Contains(lines[2], "jane@example.com") {
+		t.Errorf("Read result should still be scrubbed of emails: %s", lines[2])
+	}
+	if strings.Contains(lines[0], "/home/jane") || strings.Contains(lines[3], "/home/jane") {
+		t.Errorf("paths outside Read calls should be scrubbed:\n%s\n%s", lines[0], lines[3])
+	}
+}
+
+func TestProfileEnablesLocalePackForTool(t *testing.T) {
+	lines := scrubWithProfiles(t, "claude-code", []Profile{
+		{ToolName: "Bash", Enable: []string{"intl"}},
+	})
+
+	if strings.Contains(lines[4], "GB82") {
+		t.Errorf("Bash result should be scrubbed with the intl pack: %s", lines[4])
+	}
+}
+
+func TestProfileScopedToProvider(t *testing.T) {
+	profiles := []Profile{{Tool: "cursor", Disable: []string{"all"}}}
+
+	if lines := scrubWithProfiles(t, "cursor", profiles); !strings.Contains(lines[0], "jane@example.com") {
+		t.Errorf("cursor sessions should not be scrubbed: %s", lines[0])
+	}
+	if lines := scrubWithProfiles(t, "claude-code", profiles); strings.Contains(lines[0], "jane@example.com") {
+		t.Errorf("other tools should be scrubbed: %s", lines[0])
+	}
+}
+
+func TestProfileToolCallOverridesSession(t *testing.T) {
+	lines := scrubWithProfiles(t, "claude-code", []Profile{
+		{Disable: []string{"USER_PATH"}},
+		{ToolName: "Bash", Enable: []string{"USER_PATH"}},
+	})
+
+	if !strings.Contains(lines[0], "/home/jane") {
+		t.Errorf("session paths should be kept: %s", lines[0])
+	}
+	if strings.Contains(lines[3], "/home/jane") {
+		t.Errorf("Bash paths should be scrubbed: %s", lines[3])
+	}
+}
+
+func TestSetProfilesUnknownGroup(t *testing.T) {
+	s, err := New(DefaultRecognizers(), nil, nil)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	if err := s.SetProfiles([]Profile{{Disable: []string{"PATHS"}}}); err == nil {
+		t.Error("SetProfiles() with an unknown group should fail")
+	}
+}
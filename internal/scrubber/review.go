@@ -0,0 +1,102 @@
+package scrubber
+
+import (
+	"regexp"
+	"strings"
+)
+
+// hitContext is how many bytes around a match Hit.Context shows
+const hitContext = 30
+
+// Hit is a match a recognizer is about to replace
+type Hit struct {
+	Recognizer string
+	EntityType string
+	Match      string
+	Context    string      // The match with surrounding text, on one line
+	Allowed    *AllowEntry // The allowlist entry keeping the match, if any
+}
+
+// SetReview makes the scrubber ask review before each replacement; it
+// returns false to keep the matched text. Each distinct match of a
+// recognizer is reviewed once, the decision applies to its other
+// occurrences.
+func (s *PIIScrubber) SetReview(review func(Hit) bool) {
+	s.review = review
+	s.reviewed = make(map[string]bool)
+}
+
+// SetReport makes the scrubber pass every match that passes validation to
+// report, including those the allowlist keeps, before the review sees it
+func (s *PIIScrubber) SetReport(report func(Hit)) {
+	s.report = report
+}
+
+// replaceMatches replaces the matches of pattern in text that pass the
+// recognizer's validation, are not allowlisted and pass the review
+func (s *PIIScrubber) replaceMatches(r *CompiledRecognizer, pattern *regexp.Regexp, text string) string {
+	matches := pattern.FindAllStringSubmatchIndex(text, -1)
+	if matches == nil {
+		return text
+	}
+
+	var sb strings.Builder
+	last := 0
+	for _, m := range matches {
+		match := text[m[0]:m[1]]
+		if r.Validate != nil && !r.Validate(match) {
+			continue
+		}
+		allowed := s.allowedBy(r, match)
+		if s.report != nil {
+			s.report(Hit{
+				Recognizer: r.Name,
+				EntityType: r.EntityType,
+				Match:      match,
+				Context:    hitSnippet(text, m[0], m[1]),
+				Allowed:    allowed,
+			})
+		}
+		if allowed != nil {
+			continue
+		}
+		if s.review != nil && !s.reviewHit(r, text, m[0], m[1]) {
+			continue
+		}
+		sb.WriteString(text[last:m[0]])
+		sb.Write(pattern.ExpandString(nil, r.Replacement, text, m))
+		last = m[1]
+	}
+	sb.WriteString(text[last:])
+	return sb.String()
+}
+
+// reviewHit asks the review about text[start:end], once per distinct match
+func (s *PIIScrubber) reviewHit(r *CompiledRecognizer, text string, start, end int) bool {
+	key := r.Name + "\x00" + text[start:end]
+	if replace, ok := s.reviewed[key]; ok {
+		return replace
+	}
+	replace := s.review(Hit{
+		Recognizer: r.Name,
+		EntityType: r.EntityType,
+		Match:      text[start:end],
+		Context:    hitSnippet(text, start, end),
+	})
+	s.reviewed[key] = replace
+	return replace
+}
+
+// hitSnippet returns the match with up to hitContext bytes around it,
+// whitespace collapsed
+func hitSnippet(text string, start, end int) string {
+	from, to := max(start-hitContext, 0), min(end+hitContext, len(text))
+	snippet := strings.Join(strings.Fields(strings.ToValidUTF8(text[from:to], "")), " ")
+	if from > 0 {
+		snippet = "..." + snippet
+	}
+	if to < len(text) {
+		snippet += "..."
+	}
+	return snippet
+}
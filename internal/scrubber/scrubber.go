@@ -4,7 +4,12 @@ import (
 	"bufio"
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/config"
 )
 
 // Scrubber is the interface for PII scrubbing implementations
@@ -19,6 +24,17 @@ type Recognizer struct {
 	EntityType  string   `yaml:"entity_type"`
 	Patterns    []Pattern `yaml:"patterns"`
 	Replacement string   `yaml:"replacement"`
+
+	// Keywords are strings the patterns cannot match without, compared
+	// case-insensitively; text containing none is skipped (nil = always run)
+	Keywords []string `yaml:"keywords"`
+
+	// Prefilter is a cheap check text must also pass, for conditions
+	// keywords cannot express (nil = always run)
+	Prefilter func(text string) bool `yaml:"-"`
+
+	// Validate filters matches, e.g. by checksum (nil = replace every match)
+	Validate func(match string) bool `yaml:"-"`
 }
 
 // Pattern defines a single regex pattern
@@ -50,6 +66,9 @@ type CompiledRecognizer struct {
 	EntityType  string
 	Patterns    []*regexp.Regexp
 	Replacement string
+	Keywords    []string // Lowercased
+	Prefilter   func(text string) bool
+	Validate    func(match string) bool
 }
 
 // Config holds scrubber configuration
@@ -63,10 +82,36 @@ type PIIScrubber struct {
 	recognizers   []CompiledRecognizer
 	toolRedactors []ToolOutputRedactor
 	nodeRemovers  []NodeRemover
+
+	fieldRedactors []compiledFieldRedactor
+
+	profiles        []Profile
+	pool            []CompiledRecognizer            // Recognizers profiles can turn on, s.recognizers first
+	toolRecognizers map[string][]CompiledRecognizer // By tool name, set by ForTool
+
+	allowlist []compiledAllowEntry
+
+	review   func(Hit) bool
+	reviewed map[string]bool // Decisions by recognizer and match
+	report   func(Hit)
 }
 
 // New creates a new PIIScrubber with the given recognizers, tool redactors, and node removers
 func New(recognizers []Recognizer, toolRedactors []ToolOutputRedactor, nodeRemovers []NodeRemover) (*PIIScrubber, error) {
+	compiled, err := compileRecognizers(recognizers)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PIIScrubber{
+		recognizers:   compiled,
+		toolRedactors: toolRedactors,
+		nodeRemovers:  nodeRemovers,
+	}, nil
+}
+
+// compileRecognizers compiles the patterns of recognizers
+func compileRecognizers(recognizers []Recognizer) ([]CompiledRecognizer, error) {
 	compiled := make([]CompiledRecognizer, 0, len(recognizers))
 
 	for _, r := range recognizers {
@@ -74,9 +119,15 @@ func New(recognizers []Recognizer, toolRedactors []ToolOutputRedactor, nodeRemov
 			Name:        r.Name,
 			EntityType:  r.EntityType,
 			Replacement: r.Replacement,
+			Prefilter:   r.Prefilter,
+			Validate:    r.Validate,
 			Patterns:    make([]*regexp.Regexp, 0, len(r.Patterns)),
 		}
 
+		for _, k := range r.Keywords {
+			cr.Keywords = append(cr.Keywords, strings.ToLower(k))
+		}
+
 		for _, p := range r.Patterns {
 			re, err := regexp.Compile(p.Regex)
 			if err != nil {
@@ -87,46 +138,78 @@ func New(recognizers []Recognizer, toolRedactors []ToolOutputRedactor, nodeRemov
 
 		compiled = append(compiled, cr)
 	}
-
-	return &PIIScrubber{
-		recognizers:   compiled,
-		toolRedactors: toolRedactors,
-		nodeRemovers:  nodeRemovers,
-	}, nil
+	return compiled, nil
 }
 
-// NewDefault creates a PIIScrubber with built-in patterns
+// NewDefault creates a PIIScrubber with built-in patterns, the locale
+// packs enabled in prompt-story.scrubLocales, the detectors enabled in
+// prompt-story.scrubDetectors, and the recognizers, field redactors and
+// allowlist of the user's and the repository's scrubber configuration
 func NewDefault() (*PIIScrubber, error) {
-	return New(DefaultRecognizers(), DefaultToolRedactors(), DefaultNodeRemovers())
+	locale, err := LocaleRecognizers(config.ScrubLocales())
+	if err != nil {
+		return nil, err
+	}
+	detected, err := DetectorRecognizers(config.ScrubDetectors())
+	if err != nil {
+		return nil, err
+	}
+	patterns, err := loadRepoPatterns()
+	if err != nil {
+		return nil, err
+	}
+	recognizers := appendNewRecognizers(append(DefaultRecognizers(), locale...), detected...)
+	s, err := New(recognizers, DefaultToolRedactors(), DefaultNodeRemovers())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.AddRecognizers(patterns.Recognizers); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", PatternsFile, err)
+	}
+	if err := s.AddFieldRedactors(patterns.FieldRedactors); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", PatternsFile, err)
+	}
+	if err := s.AddAllowlist(patterns.Allowlist); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", PatternsFile, err)
+	}
+	return s, nil
 }
 
 // Scrub implements the Scrubber interface for JSONL content
 func (s *PIIScrubber) Scrub(content []byte) ([]byte, error) {
-	// First pass: build set of tool_use IDs to redact
-	toolRedactSet := s.buildToolRedactSet(content)
-
-	// Second pass: process and scrub content
-	var result bytes.Buffer
+	// Parse every line once; tool redaction needs the tool_use parts of
+	// all lines before results can be redacted
+	var lines []scrubLine
 	scanner := bufio.NewScanner(bytes.NewReader(content))
 
 	// Increase buffer for large lines (Claude responses can be big)
 	buf := make([]byte, 0, 64*1024)
 	scanner.Buffer(buf, 1024*1024)
 
-	first := true
 	for scanner.Scan() {
-		line := scanner.Bytes()
+		line := scrubLine{raw: scanner.Text()}
+		if err := json.Unmarshal(scanner.Bytes(), &line.obj); err != nil {
+			line.obj = nil
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	toolRedactSet := s.buildToolRedactSet(lines)
+	toolNames := s.buildToolNames(lines)
 
-		if !first {
+	var result bytes.Buffer
+	for i, line := range lines {
+		if i > 0 {
 			result.WriteByte('\n')
 		}
-		first = false
 
-		// Try to parse as JSON and scrub recursively
-		var obj map[string]interface{}
-		if err := json.Unmarshal(line, &obj); err != nil {
+		obj := line.obj
+		if obj == nil {
 			// Not valid JSON, scrub as plain text
-			scrubbed := s.scrubText(string(line))
+			scrubbed := s.scrubText(line.raw, s.recognizers)
 			result.WriteString(scrubbed)
 			continue
 		}
@@ -137,8 +220,12 @@ func (s *PIIScrubber) Scrub(content []byte) ([]byte, error) {
 		// 2. Redact configured tool outputs (e.g., Read tool)
 		s.redactToolResults(obj, toolRedactSet)
 
-		// 3. Apply PII patterns recursively
-		s.scrubValue(obj)
+		// 3. Redact configured fields (patterns file)
+		s.redactFields(obj, toolNames)
+
+		// 4. Apply PII patterns recursively, with the scrub profile of
+		// the tool for its calls and results
+		s.scrubEntry(obj, toolNames)
 
 		// Re-serialize
 		scrubbed, err := json.Marshal(obj)
@@ -148,41 +235,91 @@ func (s *PIIScrubber) Scrub(content []byte) ([]byte, error) {
 		result.Write(scrubbed)
 	}
 
-	if err := scanner.Err(); err != nil {
-		return nil, err
-	}
-
 	return result.Bytes(), nil
 }
 
-// scrubText applies all recognizers to a plain text string
-func (s *PIIScrubber) scrubText(text string) string {
+// scrubText applies recognizers to a plain text string. Recognizers whose
+// keywords or prefilter rule out a match are skipped, which saves running
+// most patterns over ordinary text.
+func (s *PIIScrubber) scrubText(text string, recognizers []CompiledRecognizer) string {
 	result := text
-	for _, r := range s.recognizers {
+	lower := strings.ToLower(text)
+	for _, r := range recognizers {
+		if !r.mayMatch(result, lower) {
+			continue
+		}
+		before := result
 		for _, pattern := range r.Patterns {
-			result = pattern.ReplaceAllString(result, r.Replacement)
+			if r.Validate == nil && s.review == nil && s.report == nil && !s.mayAllow(&r) {
+				result = pattern.ReplaceAllString(result, r.Replacement)
+				continue
+			}
+			result = s.replaceMatches(&r, pattern, result)
+		}
+		if result != before {
+			lower = strings.ToLower(result)
 		}
 	}
 	return result
 }
 
+// mayMatch reports whether the recognizer's patterns can match text, whose
+// lowercase form is lower
+func (r *CompiledRecognizer) mayMatch(text, lower string) bool {
+	if len(r.Keywords) > 0 && !slices.ContainsFunc(r.Keywords, func(k string) bool {
+		return strings.Contains(lower, k)
+	}) {
+		return false
+	}
+	return r.Prefilter == nil || r.Prefilter(text)
+}
+
+// digitRun returns a prefilter requiring n consecutive digits
+func digitRun(n int) func(string) bool {
+	return byteRun(n, func(c byte) bool { return c >= '0' && c <= '9' })
+}
+
+// alnumRun returns a prefilter requiring n consecutive ASCII letters or
+// digits
+func alnumRun(n int) func(string) bool {
+	return byteRun(n, func(c byte) bool {
+		return c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+	})
+}
+
+func byteRun(n int, in func(byte) bool) func(string) bool {
+	return func(text string) bool {
+		run := 0
+		for i := 0; i < len(text); i++ {
+			if !in(text[i]) {
+				run = 0
+				continue
+			}
+			if run++; run >= n {
+				return true
+			}
+		}
+		return false
+	}
+}
+
 // scrubValue recursively scrubs JSON values
-func (s *PIIScrubber) scrubValue(v interface{}) {
+func (s *PIIScrubber) scrubValue(v interface{}, recognizers []CompiledRecognizer) {
 	switch val := v.(type) {
 	case map[string]interface{}:
 		for k, inner := range val {
 			if str, ok := inner.(string); ok {
-				val[k] = s.scrubText(str)
+				val[k] = s.scrubText(str, recognizers)
 			} else {
-				s.scrubValue(inner)
+				s.scrubValue(inner, recognizers)
 			}
 		}
 	case []interface{}:
 		for i, inner := range val {
 			if str, ok := inner.(string); ok {
-				val[i] = s.scrubText(str)
+				val[i] = s.scrubText(str, recognizers)
 			} else {
-				s.scrubValue(inner)
+				s.scrubValue(inner, recognizers)
 			}
 		}
 	}
@@ -200,9 +337,15 @@ func (s *PIIScrubber) removeNodes(obj map[string]interface{}) {
 	}
 }
 
-// buildToolRedactSet scans JSONL content and returns a set of tool_use IDs
-// that should have their outputs redacted
-func (s *PIIScrubber) buildToolRedactSet(content []byte) map[string]string {
+// scrubLine is a line of JSONL content, with the parsed entry if it is JSON
+type scrubLine struct {
+	raw string
+	obj map[string]interface{}
+}
+
+// buildToolRedactSet returns the set of tool_use IDs in the lines that
+// should have their outputs redacted
+func (s *PIIScrubber) buildToolRedactSet(lines []scrubLine) map[string]string {
 	redactSet := make(map[string]string) // tool_use_id -> replacement
 
 	if len(s.toolRedactors) == 0 {
@@ -215,51 +358,36 @@ func (s *PIIScrubber) buildToolRedactSet(content []byte) map[string]string {
 		toolsToRedact[tr.ToolName] = tr.Replacement
 	}
 
-	scanner := bufio.NewScanner(bytes.NewReader(content))
-	buf := make([]byte, 0, 64*1024)
-	scanner.Buffer(buf, 1024*1024)
-
-	for scanner.Scan() {
-		var obj map[string]interface{}
-		if err := json.Unmarshal(scanner.Bytes(), &obj); err != nil {
-			continue
-		}
-
-		// Look for assistant messages with tool_use content
-		if obj["type"] != "assistant" {
-			continue
+	forEachToolUse(lines, func(toolID, toolName string) {
+		if replacement, shouldRedact := toolsToRedact[toolName]; shouldRedact {
+			redactSet[toolID] = replacement
 		}
+	})
 
-		msg, ok := obj["message"].(map[string]interface{})
-		if !ok {
-			continue
-		}
+	return redactSet
+}
 
-		content, ok := msg["content"].([]interface{})
-		if !ok {
+// forEachToolUse calls fn with the ID and tool name of every tool_use part
+// of the assistant messages in the lines
+func forEachToolUse(lines []scrubLine, fn func(toolID, toolName string)) {
+	for _, line := range lines {
+		// Look for assistant messages with tool_use content
+		if line.obj == nil || line.obj["type"] != "assistant" {
 			continue
 		}
 
-		for _, part := range content {
-			partMap, ok := part.(map[string]interface{})
-			if !ok {
+		for _, part := range contentParts(line.obj) {
+			if part["type"] != "tool_use" {
 				continue
 			}
 
-			if partMap["type"] != "tool_use" {
-				continue
-			}
-
-			toolName, _ := partMap["name"].(string)
-			toolID, _ := partMap["id"].(string)
-
-			if replacement, shouldRedact := toolsToRedact[toolName]; shouldRedact && toolID != "" {
-				redactSet[toolID] = replacement
+			toolName, _ := part["name"].(string)
+			toolID, _ := part["id"].(string)
+			if toolID != "" {
+				fn(toolID, toolName)
 			}
 		}
 	}
-
-	return redactSet
 }
 
 // redactToolResults redacts tool_result content for IDs in the redact set
@@ -302,7 +430,7 @@ func (s *PIIScrubber) redactToolResults(obj map[string]interface{}, redactSet ma
 
 // ScrubText is a convenience method to scrub plain text
 func (s *PIIScrubber) ScrubText(text string) string {
-	return s.scrubText(text)
+	return s.scrubText(text, s.recognizers)
 }
 
 // NoopScrubber is a scrubber that does nothing (pass-through)
@@ -342,6 +470,7 @@ func DefaultRecognizers() []Recognizer {
 				// PostgreSQL, MySQL, MongoDB, Redis with credentials (allow empty username for redis)
 				{Regex: `(?i)((?:postgres(?:ql)?|mysql|mongodb(?:\+srv)?|redis|mariadb)://)[^:]*:[^@]+@[^\s'"]+`},
 			},
+			Keywords:    []string{"://"},
 			Replacement: "${1}<CREDENTIALS>@<HOST>",
 		},
 
@@ -352,6 +481,7 @@ func DefaultRecognizers() []Recognizer {
 			Patterns: []Pattern{
 				{Regex: `(https?://)[^:]+:[^@]+@([^\s'"]+)`},
 			},
+			Keywords:    []string{"@"},
 			Replacement: "${1}<CREDENTIALS>@${2}",
 		},
 
@@ -362,6 +492,7 @@ func DefaultRecognizers() []Recognizer {
 			Patterns: []Pattern{
 				{Regex: `[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}`},
 			},
+			Keywords:    []string{"@"},
 			Replacement: "<EMAIL>",
 		},
 
@@ -372,6 +503,7 @@ func DefaultRecognizers() []Recognizer {
 			Patterns: []Pattern{
 				{Regex: `\b(?:4[0-9]{12}(?:[0-9]{3})?|5[1-5][0-9]{14}|3[47][0-9]{13}|6(?:011|5[0-9]{2})[0-9]{12})\b`},
 			},
+			Prefilter:   digitRun(13),
 			Replacement: "<CREDIT_CARD>",
 		},
 
@@ -390,6 +522,7 @@ func DefaultRecognizers() []Recognizer {
 			Patterns: []Pattern{
 				{Regex: `(?i)aws.{0,20}secret.{0,20}['"][A-Za-z0-9/+=]{40}['"]`},
 			},
+			Keywords:    []string{"aws"},
 			Replacement: "<AWS_SECRET_KEY>",
 		},
 
@@ -401,6 +534,7 @@ func DefaultRecognizers() []Recognizer {
 			Patterns: []Pattern{
 				{Regex: `(?:sk|pk)_(?:live|test)_[a-zA-Z0-9]{24,}`},
 			},
+			Keywords:    []string{"_live_", "_test_"},
 			Replacement: "<STRIPE_KEY>",
 		},
 
@@ -451,6 +585,7 @@ func DefaultRecognizers() []Recognizer {
 			Patterns: []Pattern{
 				{Regex: `[MN][A-Za-z0-9]{23,}\.[A-Za-z0-9_-]{6}\.[A-Za-z0-9_-]{27,}`},
 			},
+			Prefilter:   alnumRun(24),
 			Replacement: "<DISCORD_TOKEN>",
 		},
 
@@ -511,6 +646,7 @@ func DefaultRecognizers() []Recognizer {
 			Patterns: []Pattern{
 				{Regex: `(?i)bearer\s+[a-zA-Z0-9_-]+\.[a-zA-Z0-9_-]+\.[a-zA-Z0-9_-]+`},
 			},
+			Keywords:    []string{"bearer"},
 			Replacement: "Bearer <TOKEN>",
 		},
 
@@ -521,6 +657,7 @@ func DefaultRecognizers() []Recognizer {
 			Patterns: []Pattern{
 				{Regex: `(?i)((?:set-)?cookie:\s*)[^\r\n]+`},
 			},
+			Keywords:    []string{"cookie"},
 			Replacement: "${1}<COOKIE>",
 		},
 
@@ -541,6 +678,8 @@ func DefaultRecognizers() []Recognizer {
 			Patterns: []Pattern{
 				{Regex: `(?i)([A-Z0-9_]*(?:TOKEN|SECRET|API_KEY)[A-Z0-9_]*=)(?:["']?[a-zA-Z0-9_.\-]+["']?)`},
 			},
+			Keywords:    []string{"token", "secret", "api_key"},
+			Prefilter:   func(text string) bool { return strings.Contains(text, "=") },
 			Replacement: "${1}<SECRET>",
 		},
 
@@ -552,6 +691,7 @@ func DefaultRecognizers() []Recognizer {
 				// Match api_key=value but NOT as part of larger word like ANTHROPIC_API_KEY
 				{Regex: `(?i)(?:^|[^A-Z_])(?:api[_-]?key|api[_-]?secret|access[_-]?token|auth[_-]?token)['":\s=]+[a-zA-Z0-9_-]{20,}`},
 			},
+			Keywords:    []string{"api", "token"},
 			Replacement: "<API_KEY>",
 		},
 
@@ -563,6 +703,7 @@ func DefaultRecognizers() []Recognizer {
 				// Match password="value" or password: value, capturing the whole thing
 				{Regex: `(?i)(?:password|passwd|pwd)['":\s=]+[^\s'"]{8,}`},
 			},
+			Keywords:    []string{"passw", "pwd"},
 			Replacement: "<PASSWORD>",
 		},
 	}
@@ -614,7 +755,9 @@ var _ Scrubber = (*NoopScrubber)(nil)
 // scrubText helper for use in tests - unexported version uses receiver
 func init() {
 	// Ensure patterns compile at init time to catch errors early
-	_, err := NewDefault()
+	all, _ := LocaleRecognizers([]string{AllLocales})
+	detected, _ := DetectorRecognizers([]string{AllDetectors})
+	_, err := New(appendNewRecognizers(append(DefaultRecognizers(), all...), detected...), DefaultToolRedactors(), DefaultNodeRemovers())
 	if err != nil {
 		panic("invalid default pattern: " + err.Error())
 	}
@@ -0,0 +1,250 @@
+package session
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Aider's history files, written to the repository root by default
+const (
+	aiderChatHistoryFile  = ".aider.chat.history.md"
+	aiderInputHistoryFile = ".aider.input.history"
+)
+
+// aiderChatHeader starts each chat in the chat history, followed by the
+// local time it started at
+const aiderChatHeader = "# aider chat started at "
+
+// aiderTimeLayout is the local time format of both history files; the
+// input history adds microseconds
+const aiderTimeLayout = "2006-01-02 15:04:05.999999999"
+
+// aiderProvider reads Aider chats from the markdown chat history Aider
+// keeps in the repository. That file has no per-message timestamps, so
+// prompts are matched to the timestamped entries of the input history.
+type aiderProvider struct{}
+
+func (aiderProvider) Name() string { return ToolAider }
+
+// aiderChat is one chat of the chat history, already converted to entries
+type aiderChat struct {
+	ID      string
+	Start   time.Time
+	Entries []MessageEntry
+}
+
+// aiderInput is one entry of the input history
+type aiderInput struct {
+	Time time.Time
+	Text string
+}
+
+// FindSessions returns the chats of the repo's Aider chat history that
+// overlap the work period
+func (aiderProvider) FindSessions(repoPath string, startWork, endWork time.Time, trace *TraceContext) ([]ClaudeSession, error) {
+	absPath, err := filepath.Abs(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	historyPath := filepath.Join(absPath, aiderChatHistoryFile)
+	info, err := os.Stat(historyPath)
+	if err != nil || info.ModTime().Before(startWork) {
+		return nil, nil // Aider never used here, or not during the work period
+	}
+
+	chats, err := readAiderChats(historyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var sessions []ClaudeSession
+	for i, chat := range chats {
+		if len(chat.Entries) == 0 {
+			continue
+		}
+		created := chat.Start
+		modified := chat.Entries[len(chat.Entries)-1].Timestamp
+		// Prompts missing from the input history only have the chat's start;
+		// the last chat went on at least until the file was last written
+		if i == len(chats)-1 && info.ModTime().After(modified) {
+			modified = info.ModTime().UTC()
+		}
+		if modified.Before(startWork) || created.After(endWork) {
+			continue
+		}
+
+		sessions = append(sessions, ClaudeSession{
+			ID:       chat.ID,
+			Path:     historyPath,
+			Created:  created,
+			Modified: modified,
+			Tool:     ToolAider,
+		})
+
+		if trace != nil {
+			st := trace.FindOrCreateSessionTrace(chat.ID)
+			st.Path = historyPath
+			st.Created = created
+			st.Modified = modified
+			st.TimeFilterPassed = true
+			st.TimeFilterReason = "PASS (overlaps work period)"
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].Modified.After(sessions[j].Modified)
+	})
+	return sessions, nil
+}
+
+// ReadContent converts the session's chat to Claude Code JSONL
+func (aiderProvider) ReadContent(s ClaudeSession) ([]byte, error) {
+	chats, err := readAiderChats(s.Path)
+	if err != nil {
+		return nil, err
+	}
+	for _, chat := range chats {
+		if chat.ID != s.ID {
+			continue
+		}
+		var buf bytes.Buffer
+		encoder := json.NewEncoder(&buf)
+		for _, e := range chat.Entries {
+			if err := encoder.Encode(e); err != nil {
+				return nil, err
+			}
+		}
+		return buf.Bytes(), nil
+	}
+	return nil, fmt.Errorf("aider chat %s not found in %s", s.ID, s.Path)
+}
+
+// ParseContent parses stored content, which ReadContent already converted
+func (aiderProvider) ParseContent(content []byte) ([]MessageEntry, error) {
+	return ParseMessages(content)
+}
+
+// readAiderChats reads a chat history and the input history next to it
+func readAiderChats(historyPath string) ([]aiderChat, error) {
+	history, err := os.ReadFile(historyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", historyPath, err)
+	}
+	var inputs []aiderInput
+	input, err := os.ReadFile(filepath.Join(filepath.Dir(historyPath), aiderInputHistoryFile))
+	if err == nil {
+		inputs = parseAiderInputHistory(input, time.Local)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read %s: %w", aiderInputHistoryFile, err)
+	}
+	return parseAiderChats(history, inputs, time.Local), nil
+}
+
+// parseAiderInputHistory parses Aider's input history: a "# <time>" line
+// before each input, whose lines are prefixed with "+"
+func parseAiderInputHistory(content []byte, loc *time.Location) []aiderInput {
+	var inputs []aiderInput
+	var lines []string
+	var ts time.Time
+	flush := func() {
+		if !ts.IsZero() && len(lines) > 0 {
+			inputs = append(inputs, aiderInput{Time: ts, Text: strings.TrimSpace(strings.Join(lines, "\n"))})
+		}
+		lines = nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "# "):
+			flush()
+			ts = time.Time{}
+			if t, err := time.ParseInLocation(aiderTimeLayout, strings.TrimPrefix(line, "# "), loc); err == nil {
+				ts = t.UTC()
+			}
+		case strings.HasPrefix(line, "+"):
+			lines = append(lines, line[1:])
+		}
+	}
+	flush()
+	return inputs
+}
+
+// parseAiderChats splits a chat history into chats. Lines starting with
+// "####" are the user's input, lines starting with ">" are Aider's own
+// output (edits applied, commits, token counts) and are left out; the rest
+// is the assistant's response. Prompts get the time of the matching input
+// history entry, or the previous one's.
+func parseAiderChats(history []byte, inputs []aiderInput, loc *time.Location) []aiderChat {
+	var chats []aiderChat
+	var chat *aiderChat
+	var prompt, response []string
+	var ts time.Time
+	nextInput := 0
+
+	flushPrompt := func() {
+		text := strings.TrimSpace(strings.Join(prompt, "\n"))
+		prompt = nil
+		if chat == nil || text == "" {
+			return
+		}
+		for i := nextInput; i < len(inputs); i++ {
+			if inputs[i].Text == text && !inputs[i].Time.Before(chat.Start) {
+				ts, nextInput = inputs[i].Time, i+1
+				break
+			}
+		}
+		chat.Entries = append(chat.Entries, newMessageEntry("user", chat.ID, ts, text))
+	}
+	flushResponse := func() {
+		text := strings.TrimSpace(strings.Join(response, "\n"))
+		response = nil
+		if chat == nil || text == "" {
+			return
+		}
+		chat.Entries = append(chat.Entries, newMessageEntry("assistant", chat.ID, ts,
+			[]map[string]any{{"type": "text", "text": text}}))
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(history))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, aiderChatHeader):
+			flushPrompt()
+			flushResponse()
+			chat = nil
+			start, err := time.ParseInLocation(aiderTimeLayout, strings.TrimSpace(strings.TrimPrefix(line, aiderChatHeader)), loc)
+			if err != nil {
+				continue // Not a chat we can place in time
+			}
+			start = start.UTC()
+			chats = append(chats, aiderChat{ID: start.Format("20060102T150405Z"), Start: start})
+			chat = &chats[len(chats)-1]
+			ts = start
+		case line == "####" || strings.HasPrefix(line, "#### "):
+			flushResponse()
+			prompt = append(prompt, strings.TrimPrefix(strings.TrimPrefix(line, "####"), " "))
+		case strings.HasPrefix(line, ">"):
+			flushPrompt()
+			flushResponse()
+		default:
+			flushPrompt()
+			response = append(response, line)
+		}
+	}
+	flushPrompt()
+	flushResponse()
+	return chats
+}
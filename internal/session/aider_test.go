@@ -0,0 +1,143 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const aiderChatHistoryFixture = `
+# aider chat started at 2025-01-15 09:00:00
+
+> Aider v0.50.0
+> Main model: claude-3-5-sonnet with diff edit format
+
+#### Add a main function
+
+I'll add it.
+
+main.go
+` + "```go" + `
+func main() {}
+` + "```" + `
+
+> Applied edit to main.go
+> Commit 1a2b3c4 feat: Add main function
+
+#### Now print a greeting
+#### in English
+
+Done.
+
+# aider chat started at 2025-01-16 14:00:00
+
+#### /ask what does main do?
+
+It does nothing yet.
+`
+
+const aiderInputHistoryFixture = `
+# 2025-01-14 10:00:00.000000
++Add a main function
+
+# 2025-01-15 09:01:30.250000
++Add a main function
+
+# 2025-01-15 09:05:00.000000
++Now print a greeting
++in English
+`
+
+func TestParseAiderChats(t *testing.T) {
+	inputs := parseAiderInputHistory([]byte(aiderInputHistoryFixture), time.UTC)
+	if len(inputs) != 3 {
+		t.Fatalf("Expected 3 inputs, got %d", len(inputs))
+	}
+	if inputs[2].Text != "Now print a greeting\nin English" {
+		t.Errorf("Unexpected multi-line input %q", inputs[2].Text)
+	}
+
+	chats := parseAiderChats([]byte(aiderChatHistoryFixture), inputs, time.UTC)
+	if len(chats) != 2 {
+		t.Fatalf("Expected 2 chats, got %d", len(chats))
+	}
+
+	first := chats[0]
+	if first.ID != "20250115T090000Z" {
+		t.Errorf("Unexpected chat ID %q", first.ID)
+	}
+	// prompt, response, prompt, response; Aider's own output is left out
+	if len(first.Entries) != 4 {
+		t.Fatalf("Expected 4 entries, got %d", len(first.Entries))
+	}
+
+	prompt := first.Entries[0]
+	if prompt.Type != "user" || prompt.Message.GetTextContent() != "Add a main function" {
+		t.Errorf("Entry 0: expected user prompt, got %q %q", prompt.Type, prompt.Message.GetTextContent())
+	}
+	// The input from the day before belongs to another chat
+	if !prompt.Timestamp.Equal(time.Date(2025, 1, 15, 9, 1, 30, 250000000, time.UTC)) {
+		t.Errorf("Entry 0: unexpected timestamp %v", prompt.Timestamp)
+	}
+	if prompt.SessionID != first.ID {
+		t.Errorf("Entry 0: expected sessionId %s, got %q", first.ID, prompt.SessionID)
+	}
+
+	response := first.Entries[1]
+	if response.Type != "assistant" || response.Message.GetTextContent() != "I'll add it.\n\nmain.go\n```go\nfunc main() {}\n```" {
+		t.Errorf("Entry 1: unexpected response %q", response.Message.GetTextContent())
+	}
+	if !response.Timestamp.Equal(prompt.Timestamp) {
+		t.Errorf("Entry 1: expected the prompt's timestamp, got %v", response.Timestamp)
+	}
+
+	if got := first.Entries[2].Message.GetTextContent(); got != "Now print a greeting\nin English" {
+		t.Errorf("Entry 2: unexpected multi-line prompt %q", got)
+	}
+	if !first.Entries[2].Timestamp.Equal(time.Date(2025, 1, 15, 9, 5, 0, 0, time.UTC)) {
+		t.Errorf("Entry 2: unexpected timestamp %v", first.Entries[2].Timestamp)
+	}
+
+	// Prompts missing from the input history get the chat's start
+	second := chats[1]
+	if len(second.Entries) != 2 || second.Entries[0].Message.GetTextContent() != "/ask what does main do?" {
+		t.Fatalf("Unexpected second chat %+v", second.Entries)
+	}
+	if !second.Entries[0].Timestamp.Equal(second.Start) {
+		t.Errorf("Expected the chat's start, got %v", second.Entries[0].Timestamp)
+	}
+}
+
+func TestAiderProvider(t *testing.T) {
+	repo := t.TempDir()
+	if err := os.WriteFile(filepath.Join(repo, aiderChatHistoryFile), []byte(aiderChatHistoryFixture), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2025, 1, 15, 0, 0, 0, 0, time.Local)
+	end := start.Add(24 * time.Hour)
+	sessions, err := aiderProvider{}.FindSessions(repo, start, end, nil)
+	if err != nil {
+		t.Fatalf("FindSessions() error: %v", err)
+	}
+	// The second chat started after the work period
+	if len(sessions) != 1 {
+		t.Fatalf("Expected 1 session, got %d", len(sessions))
+	}
+	if sessions[0].Tool != ToolAider {
+		t.Errorf("Expected tool %s, got %q", ToolAider, sessions[0].Tool)
+	}
+
+	content, err := ReadContent(sessions[0])
+	if err != nil {
+		t.Fatalf("ReadContent() error: %v", err)
+	}
+	entries, err := ParseTranscript(ToolAider, content)
+	if err != nil {
+		t.Fatalf("ParseTranscript() error: %v", err)
+	}
+	if len(entries) != 4 || entries[0].Message.GetTextContent() != "Add a main function" {
+		t.Errorf("Unexpected entries %+v", entries)
+	}
+}
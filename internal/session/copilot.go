@@ -0,0 +1,328 @@
+package session
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// copilotProvider reads GitHub Copilot Chat sessions from VS Code's
+// per-workspace storage, where each session is a JSON file under
+// workspaceStorage/<hash>/chatSessions. Sessions of workspaces opened on
+// the repository, or a folder inside it, belong to it.
+type copilotProvider struct{}
+
+func (copilotProvider) Name() string { return ToolCopilotChat }
+
+// copilotEditions are the VS Code user data directories searched, under
+// the user config directory
+var copilotEditions = []string{"Code", "Code - Insiders", "VSCodium"}
+
+// copilotChat is the part of a stored Copilot Chat session the parser reads
+type copilotChat struct {
+	SessionID       string           `json:"sessionId"`
+	CreationDate    int64            `json:"creationDate"`    // Unix milliseconds
+	LastMessageDate int64            `json:"lastMessageDate"` // Unix milliseconds
+	Requests        []copilotRequest `json:"requests"`
+}
+
+// copilotRequest is one prompt of a session with Copilot's response
+type copilotRequest struct {
+	RequestID string `json:"requestId"`
+	Message   struct {
+		Text string `json:"text"`
+	} `json:"message"`
+	Response  []copilotResponsePart `json:"response"`
+	Timestamp int64                 `json:"timestamp"` // Unix milliseconds
+}
+
+// copilotResponsePart is a piece of a response: markdown (no kind, or
+// markdownContent), a tool invocation, or a group of edits to a file
+type copilotResponsePart struct {
+	Kind  string          `json:"kind"`
+	Value json.RawMessage `json:"value"` // String or {"value": string}
+
+	// toolInvocationSerialized
+	ToolID            string          `json:"toolId"`
+	ToolCallID        string          `json:"toolCallId"`
+	InvocationMessage json.RawMessage `json:"invocationMessage"` // String or {"value": string}
+	IsConfirmed       *bool           `json:"isConfirmed"`
+	ToolSpecificData  *struct {
+		Command     string `json:"command"`
+		CommandLine struct {
+			Original string `json:"original"`
+		} `json:"commandLine"`
+	} `json:"toolSpecificData"`
+
+	// textEditGroup
+	URI *struct {
+		Path   string `json:"path"`
+		FSPath string `json:"fsPath"`
+	} `json:"uri"`
+	Edits [][]struct {
+		Text string `json:"text"`
+	} `json:"edits"`
+}
+
+// copilotTools maps Copilot's tool IDs onto Claude Code tools. Stored
+// invocations keep only a description, so inputs are not mapped.
+var copilotTools = map[string]string{
+	"copilot_readFile":        "Read",
+	"copilot_createFile":      "Write",
+	"copilot_insertEdit":      "Edit",
+	"copilot_replaceString":   "Edit",
+	"copilot_applyPatch":      "Edit",
+	"copilot_runInTerminal":   "Bash",
+	"run_in_terminal":         "Bash",
+	"copilot_findTextInFiles": "Grep",
+	"copilot_findFiles":       "Glob",
+	"copilot_listDirectory":   "LS",
+	"copilot_fetchWebPage":    "WebFetch",
+}
+
+// FindSessions returns Copilot Chat sessions of workspaces opened on
+// repoPath that overlap the work period
+func (copilotProvider) FindSessions(repoPath string, startWork, endWork time.Time, trace *TraceContext) ([]ClaudeSession, error) {
+	absPath, err := filepath.Abs(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return nil, err
+	}
+
+	repo := filepath.Clean(absPath)
+	var sessions []ClaudeSession
+	for _, edition := range copilotEditions {
+		files, _ := filepath.Glob(filepath.Join(configDir, edition, "User", "workspaceStorage", "*", "workspace.json"))
+		for _, f := range files {
+			folder := workspaceFolder(f)
+			if folder != repo && !strings.HasPrefix(folder, repo+string(filepath.Separator)) {
+				continue
+			}
+			chats, _ := filepath.Glob(filepath.Join(filepath.Dir(f), "chatSessions", "*.json"))
+			for _, chatPath := range chats {
+				if s, ok := newCopilotSession(chatPath, startWork, endWork); ok {
+					sessions = append(sessions, s)
+					if trace != nil {
+						st := trace.FindOrCreateSessionTrace(s.ID)
+						st.Path = chatPath
+						st.Created = s.Created
+						st.Modified = s.Modified
+						st.TimeFilterPassed = true
+						st.TimeFilterReason = "PASS (overlaps work period)"
+					}
+				}
+			}
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].Modified.After(sessions[j].Modified)
+	})
+	return sessions, nil
+}
+
+// newCopilotSession reads a session file, reporting false if it has no
+// prompts or misses the work period
+func newCopilotSession(path string, startWork, endWork time.Time) (ClaudeSession, bool) {
+	// Fast pre-filter on mtime, as for Claude Code sessions
+	info, err := os.Stat(path)
+	if err != nil || info.ModTime().Before(startWork) {
+		return ClaudeSession{}, false
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return ClaudeSession{}, false
+	}
+	var chat copilotChat
+	if json.Unmarshal(content, &chat) != nil {
+		return ClaudeSession{}, false
+	}
+	id := copilotSessionID(chat, path)
+	entries := convertCopilotChat(chat, id)
+	if len(entries) == 0 {
+		return ClaudeSession{}, false
+	}
+
+	created, modified := entries[0].Timestamp, entries[len(entries)-1].Timestamp
+	if chat.CreationDate != 0 {
+		created = time.UnixMilli(chat.CreationDate).UTC()
+	}
+	if chat.LastMessageDate != 0 {
+		modified = time.UnixMilli(chat.LastMessageDate).UTC()
+	}
+	if modified.Before(startWork) || created.After(endWork) {
+		return ClaudeSession{}, false
+	}
+	return ClaudeSession{
+		ID:       id,
+		Path:     path,
+		Created:  created,
+		Modified: modified,
+		Tool:     ToolCopilotChat,
+	}, true
+}
+
+// ReadContent converts the session file to Claude Code JSONL
+func (copilotProvider) ReadContent(s ClaudeSession) ([]byte, error) {
+	content, err := os.ReadFile(s.Path)
+	if err != nil {
+		return nil, err
+	}
+	var chat copilotChat
+	if err := json.Unmarshal(content, &chat); err != nil {
+		return nil, fmt.Errorf("failed to parse copilot chat session: %w", err)
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, e := range convertCopilotChat(chat, s.ID) {
+		if err := encoder.Encode(e); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// ParseContent parses stored content, which ReadContent already converted
+func (copilotProvider) ParseContent(content []byte) ([]MessageEntry, error) {
+	return ParseMessages(content)
+}
+
+// copilotSessionID returns the session's ID, falling back to the file name
+func copilotSessionID(chat copilotChat, path string) string {
+	if chat.SessionID != "" && !strings.ContainsAny(chat.SessionID, "/\\") {
+		return chat.SessionID
+	}
+	return sessionIDFromPath(path)
+}
+
+// convertCopilotChat converts a Copilot Chat session into message entries
+// shaped like Claude Code's. Each request becomes a prompt followed by the
+// response's text, tool invocations and file edits as one assistant entry;
+// invocations the user declined become rejected tool results.
+func convertCopilotChat(chat copilotChat, id string) []MessageEntry {
+	// Requests without a timestamp inherit the previous one
+	var ts time.Time
+	if chat.CreationDate != 0 {
+		ts = time.UnixMilli(chat.CreationDate).UTC()
+	}
+
+	var entries []MessageEntry
+	for i, req := range chat.Requests {
+		if req.Timestamp != 0 {
+			ts = time.UnixMilli(req.Timestamp).UTC()
+		}
+		if text := strings.TrimSpace(req.Message.Text); text != "" {
+			entries = append(entries, newMessageEntry("user", id, ts, text))
+		}
+
+		var parts, rejections []map[string]any
+		var text strings.Builder
+		flushText := func() {
+			if t := strings.TrimSpace(text.String()); t != "" {
+				parts = append(parts, map[string]any{"type": "text", "text": t})
+			}
+			text.Reset()
+		}
+		for j, part := range req.Response {
+			switch part.Kind {
+			case "", "markdownContent":
+				text.WriteString(copilotString(part.Value))
+
+			case "toolInvocationSerialized":
+				flushText()
+				callID := part.ToolCallID
+				if callID == "" {
+					callID = fmt.Sprintf("%s-%d-%d", id, i, j)
+				}
+				name, input := part.toolUse()
+				parts = append(parts, map[string]any{"type": "tool_use", "id": callID, "name": name, "input": input})
+				if part.IsConfirmed != nil && !*part.IsConfirmed {
+					rejections = append(rejections, map[string]any{
+						"type":        "tool_result",
+						"tool_use_id": callID,
+						"is_error":    true,
+						"content":     "The user doesn't want to proceed with this tool use. The tool use was rejected.",
+					})
+				}
+
+			case "textEditGroup":
+				flushText()
+				if part.URI == nil {
+					continue
+				}
+				path := part.URI.FSPath
+				if path == "" {
+					path = part.URI.Path
+				}
+				var edits []string
+				for _, group := range part.Edits {
+					for _, e := range group {
+						edits = append(edits, e.Text)
+					}
+				}
+				parts = append(parts, map[string]any{
+					"type": "tool_use", "id": fmt.Sprintf("%s-%d-%d", id, i, j), "name": "Edit",
+					"input": map[string]any{"file_path": path, "new_string": strings.Join(edits, "\n")},
+				})
+			}
+		}
+		flushText()
+
+		if len(parts) > 0 {
+			entries = append(entries, newMessageEntry("assistant", id, ts, parts))
+		}
+		if len(rejections) > 0 {
+			entries = append(entries, newMessageEntry("user", id, ts, rejections))
+		}
+	}
+	return entries
+}
+
+// toolUse returns the Claude Code name and input of a tool invocation.
+// Terminal commands keep their command line; other tools are described by
+// Copilot's invocation message. Unknown tools keep their ID.
+func (p copilotResponsePart) toolUse() (string, map[string]any) {
+	name, ok := copilotTools[p.ToolID]
+	if !ok {
+		name = p.ToolID
+	}
+	if d := p.ToolSpecificData; d != nil {
+		command := d.CommandLine.Original
+		if command == "" {
+			command = d.Command
+		}
+		if command != "" {
+			return name, map[string]any{"command": command}
+		}
+	}
+	input := map[string]any{}
+	if msg := copilotString(p.InvocationMessage); msg != "" {
+		input["description"] = msg
+	}
+	return name, input
+}
+
+// copilotString returns a string stored either directly or as the value of
+// a markdown object
+func copilotString(raw json.RawMessage) string {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	var md struct {
+		Value string `json:"value"`
+	}
+	if json.Unmarshal(raw, &md) == nil {
+		return md.Value
+	}
+	return ""
+}
@@ -0,0 +1,140 @@
+package session
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+const copilotChatFixture = `{
+  "version": 3,
+  "sessionId": "5b7e0c1a",
+  "creationDate": 1736931600000,
+  "lastMessageDate": 1736932200000,
+  "requests": [
+    {
+      "requestId": "request_1",
+      "message": {"text": "Add a main function", "parts": []},
+      "timestamp": 1736931660000,
+      "response": [
+        {"value": "I'll add it ", "supportThemeIcons": false},
+        {"kind": "markdownContent", "content": {"value": "x"}, "value": {"value": "to main.go."}},
+        {"kind": "toolInvocationSerialized", "toolId": "copilot_runInTerminal", "toolCallId": "call-1",
+         "invocationMessage": {"value": "Running a command"}, "isConfirmed": false,
+         "toolSpecificData": {"kind": "terminal", "commandLine": {"original": "rm -rf build"}}},
+        {"kind": "toolInvocationSerialized", "toolId": "copilot_readFile", "toolCallId": "call-2",
+         "invocationMessage": "Reading main.go", "isConfirmed": true},
+        {"kind": "textEditGroup", "uri": {"$mid": 1, "fsPath": "/repo/main.go", "path": "/repo/main.go", "scheme": "file"},
+         "edits": [[{"text": "func main() {}", "range": {}}]]}
+      ]
+    },
+    {
+      "requestId": "request_2",
+      "message": {"text": "Thanks"},
+      "response": []
+    }
+  ]
+}`
+
+func TestConvertCopilotChat(t *testing.T) {
+	var chat copilotChat
+	if err := json.Unmarshal([]byte(copilotChatFixture), &chat); err != nil {
+		t.Fatal(err)
+	}
+	entries := convertCopilotChat(chat, "5b7e0c1a")
+
+	// prompt, response, rejection, prompt
+	if len(entries) != 4 {
+		t.Fatalf("Expected 4 entries, got %d", len(entries))
+	}
+	if entries[0].Type != "user" || entries[0].Message.GetTextContent() != "Add a main function" {
+		t.Errorf("Entry 0: expected user prompt, got %q %q", entries[0].Type, entries[0].Message.GetTextContent())
+	}
+	if !entries[0].Timestamp.Equal(time.UnixMilli(1736931660000)) {
+		t.Errorf("Entry 0: unexpected timestamp %v", entries[0].Timestamp)
+	}
+
+	var parts []struct {
+		Type  string         `json:"type"`
+		Text  string         `json:"text"`
+		Name  string         `json:"name"`
+		Input map[string]any `json:"input"`
+	}
+	if err := json.Unmarshal(entries[1].Message.RawContent, &parts); err != nil {
+		t.Fatal(err)
+	}
+	if len(parts) != 4 {
+		t.Fatalf("Entry 1: expected text and 3 tool uses, got %+v", parts)
+	}
+	if parts[0].Text != "I'll add it to main.go." {
+		t.Errorf("Entry 1: unexpected text %q", parts[0].Text)
+	}
+	if parts[1].Name != "Bash" || parts[1].Input["command"] != "rm -rf build" {
+		t.Errorf("Entry 1: expected mapped Bash, got %+v", parts[1])
+	}
+	if parts[2].Name != "Read" || parts[2].Input["description"] != "Reading main.go" {
+		t.Errorf("Entry 1: expected mapped Read, got %+v", parts[2])
+	}
+	if parts[3].Name != "Edit" || parts[3].Input["file_path"] != "/repo/main.go" || parts[3].Input["new_string"] != "func main() {}" {
+		t.Errorf("Entry 1: expected Edit from the edit group, got %+v", parts[3])
+	}
+
+	if !isUserActionEntry(entries[2]) {
+		t.Error("Entry 2: a declined invocation is a user action")
+	}
+
+	// Requests without a timestamp inherit the previous one
+	if entries[3].Message.GetTextContent() != "Thanks" || !entries[3].Timestamp.Equal(entries[0].Timestamp) {
+		t.Errorf("Entry 3: unexpected %q at %v", entries[3].Message.GetTextContent(), entries[3].Timestamp)
+	}
+}
+
+func TestCopilotProvider(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("XDG_CONFIG_HOME only sets the config directory on Linux")
+	}
+	configDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configDir)
+	repo := filepath.Join(t.TempDir(), "repo")
+
+	for hash, folder := range map[string]string{"ws1": repo, "ws2": repo + "-other"} {
+		dir := filepath.Join(configDir, "Code", "User", "workspaceStorage", hash)
+		if err := os.MkdirAll(filepath.Join(dir, "chatSessions"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		os.WriteFile(filepath.Join(dir, "workspace.json"), []byte(`{"folder":"file://`+folder+`"}`), 0644)
+		os.WriteFile(filepath.Join(dir, "chatSessions", "5b7e0c1a.json"), []byte(copilotChatFixture), 0644)
+	}
+
+	start := time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC)
+	sessions, err := copilotProvider{}.FindSessions(repo, start, start.Add(24*time.Hour), nil)
+	if err != nil {
+		t.Fatalf("FindSessions() error: %v", err)
+	}
+	if len(sessions) != 1 {
+		t.Fatalf("Expected 1 session, got %d", len(sessions))
+	}
+	if !sessions[0].Modified.Equal(time.UnixMilli(1736932200000)) {
+		t.Errorf("Unexpected modified time %v", sessions[0].Modified)
+	}
+
+	// Outside the work period
+	if sessions, _ := (copilotProvider{}).FindSessions(repo, start.Add(48*time.Hour), start.Add(72*time.Hour), nil); len(sessions) != 0 {
+		t.Errorf("Expected no sessions after the work period, got %d", len(sessions))
+	}
+
+	content, err := ReadContent(sessions[0])
+	if err != nil {
+		t.Fatalf("ReadContent() error: %v", err)
+	}
+	entries, err := ParseTranscript(ToolCopilotChat, content)
+	if err != nil {
+		t.Fatalf("ParseTranscript() error: %v", err)
+	}
+	if len(entries) != 4 || entries[0].SessionID != "5b7e0c1a" {
+		t.Errorf("Unexpected entries %+v", entries)
+	}
+}
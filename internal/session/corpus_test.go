@@ -0,0 +1,60 @@
+package session
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var updateCorpus = flag.Bool("update", false, "rewrite corpus expectations from the current parsers")
+
+// TestParserCorpus runs every provider parser over the captured fixtures
+// (see git-prompt-story debug capture-fixture). After an intended parser
+// change, refresh expectations with: go test ./internal/session -update
+func TestParserCorpus(t *testing.T) {
+	fixtures, err := filepath.Glob(filepath.Join("testdata", "corpus", "*", "*.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fixtures) == 0 {
+		t.Skip("no corpus fixtures")
+	}
+
+	for _, path := range fixtures {
+		tool := filepath.Base(filepath.Dir(path))
+		name := tool + "/" + strings.TrimSuffix(filepath.Base(path), ".jsonl")
+		t.Run(name, func(t *testing.T) {
+			content, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := ExpectFixture(tool, content)
+			if err != nil {
+				t.Fatalf("parse error: %v", err)
+			}
+
+			if *updateCorpus {
+				if err := WriteFixtureExpectation(path, got); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			data, err := os.ReadFile(strings.TrimSuffix(path, ".jsonl") + ".json")
+			if err != nil {
+				t.Fatalf("missing expectation: %v", err)
+			}
+			var want FixtureExpectation
+			if err := json.Unmarshal(data, &want); err != nil {
+				t.Fatalf("bad expectation: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("parser output changed:\n got  %+v\n want %+v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,503 @@
+package session
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// cursorProvider reads Cursor composer (chat/agent) sessions from the
+// state.vscdb SQLite databases in Cursor's user data directory: the global
+// one and the per-workspace ones under workspaceStorage, whose layout
+// differs between Cursor versions. The sqlite3 CLI is used for access so
+// no cgo driver is needed.
+type cursorProvider struct{}
+
+func (cursorProvider) Name() string { return ToolCursor }
+
+// cursorHeader is the summary Cursor keeps for each composer
+type cursorHeader struct {
+	ComposerID    string `json:"composerId"`
+	CreatedAt     int64  `json:"createdAt"`     // Unix milliseconds
+	LastUpdatedAt int64  `json:"lastUpdatedAt"` // Unix milliseconds
+}
+
+// mayOverlap is a cheap pre-filter on the header's own timestamps
+func (h cursorHeader) mayOverlap(startWork, endWork time.Time) bool {
+	if h.LastUpdatedAt != 0 && time.UnixMilli(h.LastUpdatedAt).Before(startWork) {
+		return false
+	}
+	if h.CreatedAt != 0 && time.UnixMilli(h.CreatedAt).After(endWork) {
+		return false
+	}
+	return true
+}
+
+// cursorWorkspace is a per-workspace state database related to the repo
+type cursorWorkspace struct {
+	dbPath string
+	exact  bool // Workspace folder is the repo or inside it
+}
+
+// FindSessions returns Cursor composers that overlap the work period and
+// belong to repoPath. Composers from a workspace opened on the repo belong
+// to it; others must reference files inside it.
+func (cursorProvider) FindSessions(repoPath string, startWork, endWork time.Time, trace *TraceContext) ([]ClaudeSession, error) {
+	absPath, err := filepath.Abs(repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	userDir, err := cursorUserDir()
+	if err != nil {
+		return nil, err
+	}
+	globalDB := filepath.Join(userDir, "globalStorage", "state.vscdb")
+	_, statErr := os.Stat(globalDB)
+	workspaces := findCursorWorkspaces(userDir, absPath)
+	if statErr != nil && len(workspaces) == 0 {
+		return nil, nil // Cursor not installed or never opened here
+	}
+	if _, err := exec.LookPath("sqlite3"); err != nil {
+		return nil, fmt.Errorf("sqlite3 not found in PATH, needed to read Cursor sessions")
+	}
+
+	found := make(map[string]ClaudeSession)
+
+	// Per-workspace databases: the workspace folder ties composers to the repo
+	for _, ws := range workspaces {
+		headers, err := workspaceComposerHeaders(ws.dbPath)
+		if err != nil {
+			continue
+		}
+		for _, h := range headers {
+			if !h.mayOverlap(startWork, endWork) {
+				continue
+			}
+			content, err := readCursorSession(ws.dbPath, h.ComposerID)
+			if err != nil || (!ws.exact && !referencesPath(content, absPath)) {
+				continue
+			}
+			if s, ok := newCursorSession(h.ComposerID, ws.dbPath, content, startWork, endWork); ok {
+				found[s.ID] = s
+			}
+		}
+	}
+
+	// Global database: composers of every workspace, matched by file paths
+	if statErr == nil && hasTable(globalDB, "cursorDiskKV") {
+		rows, err := querySQLite(globalDB, "SELECT key, CAST(value AS TEXT) AS value FROM cursorDiskKV WHERE key LIKE 'composerData:%'")
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			var h cursorHeader
+			if json.Unmarshal([]byte(row["value"]), &h) != nil || h.ComposerID == "" {
+				continue
+			}
+			if _, ok := found[h.ComposerID]; ok || !h.mayOverlap(startWork, endWork) {
+				continue
+			}
+			content, err := loadCursorComposer(globalDB, h.ComposerID)
+			if err != nil || !referencesPath(content, absPath) {
+				continue
+			}
+			if s, ok := newCursorSession(h.ComposerID, globalDB, content, startWork, endWork); ok {
+				found[s.ID] = s
+			}
+		}
+	}
+
+	sessions := make([]ClaudeSession, 0, len(found))
+	for _, s := range found {
+		sessions = append(sessions, s)
+		if trace != nil {
+			st := trace.FindOrCreateSessionTrace(s.ID)
+			st.Path = s.Path
+			st.Created = s.Created
+			st.Modified = s.Modified
+			st.TimeFilterPassed = true
+			st.TimeFilterReason = "PASS (overlaps work period)"
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].Modified.After(sessions[j].Modified)
+	})
+
+	return sessions, nil
+}
+
+// newCursorSession builds a session from composer content, using the bubble
+// timestamps for its span. It reports false if the span misses the work period.
+func newCursorSession(id, dbPath string, content []byte, startWork, endWork time.Time) (ClaudeSession, bool) {
+	entries, err := ParseCursorComposer(content)
+	if err != nil || len(entries) == 0 {
+		return ClaudeSession{}, false
+	}
+	created := entries[0].Timestamp
+	modified := entries[len(entries)-1].Timestamp
+	if modified.Before(startWork) || created.After(endWork) {
+		return ClaudeSession{}, false
+	}
+	return ClaudeSession{
+		ID:       id,
+		Path:     dbPath,
+		Created:  created,
+		Modified: modified,
+		Tool:     ToolCursor,
+	}, true
+}
+
+// ReadContent returns the composer JSON with its bubbles inlined
+func (cursorProvider) ReadContent(s ClaudeSession) ([]byte, error) {
+	return readCursorSession(s.Path, s.ID)
+}
+
+func (cursorProvider) ParseContent(content []byte) ([]MessageEntry, error) {
+	return ParseCursorComposer(content)
+}
+
+// cursorUserDir returns Cursor's per-user data directory
+func cursorUserDir() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "Cursor", "User"), nil
+}
+
+// cursorGlobalDB returns the path to Cursor's global state database
+func cursorGlobalDB() (string, error) {
+	dir, err := cursorUserDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "globalStorage", "state.vscdb"), nil
+}
+
+// findCursorWorkspaces returns the per-workspace databases whose folder is
+// the repo, inside it, or a parent of it
+func findCursorWorkspaces(userDir, repoPath string) []cursorWorkspace {
+	files, err := filepath.Glob(filepath.Join(userDir, "workspaceStorage", "*", "workspace.json"))
+	if err != nil {
+		return nil
+	}
+
+	repo := filepath.Clean(repoPath)
+	var workspaces []cursorWorkspace
+	for _, f := range files {
+		folder := workspaceFolder(f)
+		if folder == "" {
+			continue
+		}
+
+		dbPath := filepath.Join(filepath.Dir(f), "state.vscdb")
+		if _, err := os.Stat(dbPath); err != nil {
+			continue
+		}
+
+		switch {
+		case folder == repo || strings.HasPrefix(folder, repo+string(filepath.Separator)):
+			workspaces = append(workspaces, cursorWorkspace{dbPath: dbPath, exact: true})
+		case strings.HasPrefix(repo, folder+string(filepath.Separator)):
+			workspaces = append(workspaces, cursorWorkspace{dbPath: dbPath})
+		}
+	}
+	return workspaces
+}
+
+// workspaceFolder returns the folder a VS Code style workspace.json opens,
+// or "" if it is not a local folder
+func workspaceFolder(workspaceJSON string) string {
+	data, err := os.ReadFile(workspaceJSON)
+	if err != nil {
+		return ""
+	}
+	// Multi-root workspaces use "workspace" instead and are not matched
+	var ws struct {
+		Folder string `json:"folder"`
+	}
+	if json.Unmarshal(data, &ws) != nil || ws.Folder == "" {
+		return ""
+	}
+	u, err := url.Parse(ws.Folder)
+	if err != nil || u.Scheme != "file" {
+		return ""
+	}
+	folder := filepath.Clean(filepath.FromSlash(strings.TrimPrefix(u.Path, "/")))
+	if filepath.VolumeName(folder) == "" {
+		folder = filepath.Clean(filepath.FromSlash(u.Path))
+	}
+	return folder
+}
+
+// Keys of the per-workspace ItemTable holding chat state
+const (
+	cursorComposerKey = "composer.composerData"
+	cursorChatKey     = "workbench.panel.aichat.view.aichat.chatdata" // legacy chat tabs
+)
+
+// cursorChatTab is a conversation in the legacy chat panel
+type cursorChatTab struct {
+	TabID        string          `json:"tabId"`
+	LastSendTime int64           `json:"lastSendTime"` // Unix milliseconds
+	Bubbles      json.RawMessage `json:"bubbles"`
+}
+
+// readWorkspaceItems returns the composer and legacy chat state of a
+// workspace database; either may be missing depending on the Cursor version
+func readWorkspaceItems(dbPath string) (composers []map[string]json.RawMessage, tabs []cursorChatTab, err error) {
+	if !hasTable(dbPath, "ItemTable") {
+		return nil, nil, fmt.Errorf("no ItemTable in %s", dbPath)
+	}
+	rows, err := querySQLite(dbPath, fmt.Sprintf(
+		"SELECT key, CAST(value AS TEXT) AS value FROM ItemTable WHERE key IN (%s, %s)",
+		sqlQuote(cursorComposerKey), sqlQuote(cursorChatKey)))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, row := range rows {
+		switch row["key"] {
+		case cursorComposerKey:
+			var data struct {
+				AllComposers []map[string]json.RawMessage `json:"allComposers"`
+			}
+			if json.Unmarshal([]byte(row["value"]), &data) == nil {
+				composers = data.AllComposers
+			}
+		case cursorChatKey:
+			var data struct {
+				Tabs []cursorChatTab `json:"tabs"`
+			}
+			if json.Unmarshal([]byte(row["value"]), &data) == nil {
+				tabs = data.Tabs
+			}
+		}
+	}
+	return composers, tabs, nil
+}
+
+// workspaceComposerHeaders lists the composers and legacy chat tabs of a
+// workspace database
+func workspaceComposerHeaders(dbPath string) ([]cursorHeader, error) {
+	composers, tabs, err := readWorkspaceItems(dbPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var headers []cursorHeader
+	for _, c := range composers {
+		raw, err := json.Marshal(c)
+		if err != nil {
+			continue
+		}
+		var h cursorHeader
+		if json.Unmarshal(raw, &h) == nil && h.ComposerID != "" {
+			headers = append(headers, h)
+		}
+	}
+	for _, t := range tabs {
+		if t.TabID != "" {
+			headers = append(headers, cursorHeader{ComposerID: t.TabID, LastUpdatedAt: t.LastSendTime})
+		}
+	}
+	return headers, nil
+}
+
+// readCursorSession returns the stored JSON of a composer. Workspace
+// databases of older versions hold the conversation inline; newer ones only
+// list the composer, whose bubbles then live in the global database.
+func readCursorSession(dbPath, composerID string) ([]byte, error) {
+	if filepath.Base(filepath.Dir(filepath.Dir(dbPath))) != "workspaceStorage" {
+		return loadCursorComposer(dbPath, composerID)
+	}
+
+	if content, err := loadWorkspaceComposer(dbPath, composerID); err != nil || content != nil {
+		return content, err
+	}
+
+	globalDB, err := cursorGlobalDB()
+	if err != nil {
+		return nil, err
+	}
+	return loadCursorComposer(globalDB, composerID)
+}
+
+// loadWorkspaceComposer returns a composer or legacy chat tab stored inline
+// in a workspace database, converted to composer JSON, or nil if the
+// workspace only references it
+func loadWorkspaceComposer(dbPath, composerID string) ([]byte, error) {
+	composers, tabs, err := readWorkspaceItems(dbPath)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range composers {
+		var id string
+		json.Unmarshal(c["composerId"], &id)
+		if id != composerID {
+			continue
+		}
+		var conversation []json.RawMessage
+		if json.Unmarshal(c["conversation"], &conversation) != nil || len(conversation) == 0 {
+			return nil, nil
+		}
+		out, err := json.Marshal(c)
+		if err != nil {
+			return nil, err
+		}
+		return append(out, '\n'), nil
+	}
+
+	for _, t := range tabs {
+		if t.TabID != composerID {
+			continue
+		}
+		out, err := json.Marshal(map[string]any{
+			"composerId":   t.TabID,
+			"createdAt":    t.LastSendTime,
+			"conversation": t.Bubbles,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return append(out, '\n'), nil
+	}
+
+	return nil, nil
+}
+
+// loadCursorComposer reads a composer record and inlines its bubbles under
+// "conversation", the layout older Cursor versions used natively. Newer
+// versions only keep bubble headers in the composer and the bubbles
+// themselves under separate bubbleId:<composer>:<bubble> keys.
+func loadCursorComposer(dbPath, composerID string) ([]byte, error) {
+	rows, err := querySQLite(dbPath, fmt.Sprintf(
+		"SELECT CAST(value AS TEXT) AS value FROM cursorDiskKV WHERE key = %s",
+		sqlQuote("composerData:"+composerID)))
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("cursor composer not found: %s", composerID)
+	}
+
+	var composer map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(rows[0]["value"]), &composer); err != nil {
+		return nil, fmt.Errorf("failed to parse cursor composer %s: %w", composerID, err)
+	}
+
+	var conversation []json.RawMessage
+	json.Unmarshal(composer["conversation"], &conversation)
+	if len(conversation) == 0 {
+		var headers []struct {
+			BubbleID string `json:"bubbleId"`
+		}
+		json.Unmarshal(composer["fullConversationHeadersOnly"], &headers)
+
+		bubbleRows, err := querySQLite(dbPath, fmt.Sprintf(
+			"SELECT key, CAST(value AS TEXT) AS value FROM cursorDiskKV WHERE key LIKE %s",
+			sqlQuote("bubbleId:"+composerID+":%")))
+		if err != nil {
+			return nil, err
+		}
+		bubbles := make(map[string]string, len(bubbleRows))
+		for _, row := range bubbleRows {
+			bubbles[strings.TrimPrefix(row["key"], "bubbleId:"+composerID+":")] = row["value"]
+		}
+
+		for _, h := range headers {
+			if b, ok := bubbles[h.BubbleID]; ok && json.Valid([]byte(b)) {
+				conversation = append(conversation, json.RawMessage(b))
+			}
+		}
+
+		raw, err := json.Marshal(conversation)
+		if err != nil {
+			return nil, err
+		}
+		composer["conversation"] = raw
+	}
+
+	out, err := json.Marshal(composer)
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
+
+// referencesPath reports whether JSON content mentions path or a file under it
+func referencesPath(content []byte, path string) bool {
+	quoted, err := json.Marshal(filepath.Clean(path))
+	if err != nil {
+		return false
+	}
+	escaped := quoted[1 : len(quoted)-1]
+	sep, _ := json.Marshal(string(filepath.Separator))
+	sep = sep[1 : len(sep)-1]
+
+	for rest := content; ; {
+		idx := bytes.Index(rest, escaped)
+		if idx == -1 {
+			return false
+		}
+		rest = rest[idx+len(escaped):]
+		if bytes.HasPrefix(rest, []byte(`"`)) || bytes.HasPrefix(rest, sep) {
+			return true
+		}
+	}
+}
+
+// querySQLite runs a read-only query through the sqlite3 CLI and returns
+// the rows as column -> text value maps
+func querySQLite(dbPath, query string) ([]map[string]string, error) {
+	cmd := exec.Command("sqlite3", "-readonly", "-json", dbPath, query)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("sqlite3 %s: %w: %s", filepath.Base(dbPath), err, strings.TrimSpace(stderr.String()))
+	}
+
+	// sqlite3 prints nothing at all for an empty result set
+	if len(bytes.TrimSpace(out)) == 0 {
+		return nil, nil
+	}
+
+	var raw []map[string]*string
+	if err := json.Unmarshal(out, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse sqlite3 output: %w", err)
+	}
+	rows := make([]map[string]string, 0, len(raw))
+	for _, r := range raw {
+		row := make(map[string]string, len(r))
+		for k, v := range r {
+			if v != nil {
+				row[k] = *v
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// hasTable reports whether the database has the named table
+func hasTable(dbPath, table string) bool {
+	rows, err := querySQLite(dbPath, fmt.Sprintf(
+		"SELECT name FROM sqlite_master WHERE type = 'table' AND name = %s", sqlQuote(table)))
+	return err == nil && len(rows) > 0
+}
+
+// sqlQuote quotes s as an SQL string literal
+func sqlQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,285 @@
+package session
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// cursorBubbleType is 1/2 in composers and "user"/"ai" in legacy chat tabs
+type cursorBubbleType int
+
+// Cursor bubble types
+const (
+	cursorBubbleUser      cursorBubbleType = 1
+	cursorBubbleAssistant cursorBubbleType = 2
+)
+
+// UnmarshalJSON accepts both the numeric and the legacy string form.
+// Unknown values decode as zero so the bubble is skipped.
+func (t *cursorBubbleType) UnmarshalJSON(data []byte) error {
+	var n int
+	if json.Unmarshal(data, &n) == nil {
+		*t = cursorBubbleType(n)
+		return nil
+	}
+	var s string
+	json.Unmarshal(data, &s)
+	switch s {
+	case "user":
+		*t = cursorBubbleUser
+	case "ai", "assistant":
+		*t = cursorBubbleAssistant
+	default:
+		*t = 0
+	}
+	return nil
+}
+
+// cursorComposer is the part of a stored Cursor composer the parser reads
+type cursorComposer struct {
+	ComposerID   string         `json:"composerId"`
+	CreatedAt    int64          `json:"createdAt"` // Unix milliseconds
+	Conversation []cursorBubble `json:"conversation"`
+}
+
+// cursorBubble is one message in a Cursor composer
+type cursorBubble struct {
+	BubbleID   string           `json:"bubbleId"`
+	Type       cursorBubbleType `json:"type"`
+	Text       string           `json:"text"`
+	CreatedAt  json.RawMessage  `json:"createdAt,omitempty"` // RFC 3339 string or Unix milliseconds
+	TimingInfo *struct {
+		ClientStartTime float64 `json:"clientStartTime"` // Unix milliseconds
+	} `json:"timingInfo,omitempty"`
+	ToolFormerData *cursorToolCall `json:"toolFormerData,omitempty"`
+}
+
+// cursorToolCall is the tool invocation attached to an assistant bubble
+type cursorToolCall struct {
+	ToolCallID   string `json:"toolCallId"`
+	Name         string `json:"name"`
+	RawArgs      string `json:"rawArgs"`
+	Result       string `json:"result"`
+	Status       string `json:"status"`
+	UserDecision string `json:"userDecision"`
+}
+
+// cursorToolMapping maps a Cursor tool onto its Claude Code equivalent,
+// renaming argument fields so the usual tool formatting applies
+type cursorToolMapping struct {
+	name   string
+	fields map[string]string // Claude field -> Cursor field
+}
+
+// cursorTools lists the Cursor tools with a Claude Code equivalent
+var cursorTools = map[string]cursorToolMapping{
+	"read_file":        {"Read", map[string]string{"file_path": "target_file", "offset": "start_line_one_indexed"}},
+	"edit_file":        {"Edit", map[string]string{"file_path": "target_file", "new_string": "code_edit"}},
+	"search_replace":   {"Edit", map[string]string{"file_path": "file_path", "old_string": "old_string", "new_string": "new_string"}},
+	"write":            {"Write", map[string]string{"file_path": "file_path", "content": "contents"}},
+	"run_terminal_cmd": {"Bash", map[string]string{"command": "command", "description": "explanation"}},
+	"grep_search":      {"Grep", map[string]string{"pattern": "query"}},
+	"codebase_search":  {"Grep", map[string]string{"pattern": "query"}},
+	"file_search":      {"Glob", map[string]string{"pattern": "query"}},
+	"list_dir":         {"LS", map[string]string{"path": "relative_workspace_path"}},
+	"web_search":       {"WebSearch", map[string]string{"query": "search_term"}},
+}
+
+// ParseCursorComposer converts a stored Cursor composer into message entries
+// shaped like Claude Code's, so one pipeline renders both. User bubbles
+// become user prompts, assistant bubbles become text and tool_use parts, and
+// tool results (or rejections) become tool_result user entries.
+func ParseCursorComposer(content []byte) ([]MessageEntry, error) {
+	var composer cursorComposer
+	if err := json.Unmarshal(content, &composer); err != nil {
+		return nil, fmt.Errorf("failed to parse cursor composer: %w", err)
+	}
+
+	// Bubbles without a timestamp inherit the previous one
+	ts := time.Time{}
+	if composer.CreatedAt != 0 {
+		ts = time.UnixMilli(composer.CreatedAt).UTC()
+	}
+
+	var entries []MessageEntry
+	for _, b := range composer.Conversation {
+		if t := b.timestamp(); !t.IsZero() {
+			ts = t
+		}
+
+		switch b.Type {
+		case cursorBubbleUser:
+			if b.Text == "" {
+				continue
+			}
+			entries = append(entries, newMessageEntry("user", composer.ComposerID, ts, b.Text))
+
+		case cursorBubbleAssistant:
+			var parts []map[string]any
+			if b.Text != "" {
+				parts = append(parts, map[string]any{"type": "text", "text": b.Text})
+			}
+			tool := b.ToolFormerData
+			if tool != nil && tool.Name != "" {
+				name, input := mapCursorTool(tool.Name, tool.RawArgs)
+				parts = append(parts, map[string]any{"type": "tool_use", "id": tool.id(b.BubbleID), "name": name, "input": input})
+			}
+			if len(parts) == 0 {
+				continue
+			}
+			entries = append(entries, newMessageEntry("assistant", composer.ComposerID, ts, parts))
+
+			if result := tool.toolResult(b.BubbleID); result != nil {
+				entries = append(entries, newMessageEntry("user", composer.ComposerID, ts, []map[string]any{result}))
+			}
+		}
+	}
+
+	return entries, nil
+}
+
+// newMessageEntry builds a Claude Code style entry with the given role and content
+func newMessageEntry(role, sessionID string, ts time.Time, content any) MessageEntry {
+	raw, _ := json.Marshal(content)
+	return MessageEntry{
+		Type:      role,
+		SessionID: sessionID,
+		Timestamp: ts,
+		Message:   &Message{Role: role, RawContent: raw},
+	}
+}
+
+// timestamp returns when the bubble was created, or zero if unknown
+func (b cursorBubble) timestamp() time.Time {
+	if len(b.CreatedAt) > 0 {
+		var s string
+		if json.Unmarshal(b.CreatedAt, &s) == nil {
+			if t, err := time.Parse(time.RFC3339, s); err == nil {
+				return t.UTC()
+			}
+			if ms, err := strconv.ParseInt(s, 10, 64); err == nil {
+				return time.UnixMilli(ms).UTC()
+			}
+		}
+		var ms float64
+		if json.Unmarshal(b.CreatedAt, &ms) == nil && ms > 0 {
+			return time.UnixMilli(int64(ms)).UTC()
+		}
+	}
+	if b.TimingInfo != nil && b.TimingInfo.ClientStartTime > 0 {
+		return time.UnixMilli(int64(b.TimingInfo.ClientStartTime)).UTC()
+	}
+	return time.Time{}
+}
+
+// id returns the tool call ID, falling back to the bubble ID
+func (t *cursorToolCall) id(bubbleID string) string {
+	if t.ToolCallID != "" {
+		return t.ToolCallID
+	}
+	return bubbleID
+}
+
+// toolResult returns the tool_result part for a finished or rejected call
+func (t *cursorToolCall) toolResult(bubbleID string) map[string]any {
+	if t == nil || t.Name == "" {
+		return nil
+	}
+	if t.UserDecision == "rejected" {
+		return map[string]any{
+			"type":        "tool_result",
+			"tool_use_id": t.id(bubbleID),
+			"is_error":    true,
+			"content":     "The user doesn't want to proceed with this tool use. The tool use was rejected.",
+		}
+	}
+	if t.Result == "" {
+		return nil
+	}
+	return map[string]any{"type": "tool_result", "tool_use_id": t.id(bubbleID), "content": t.Result}
+}
+
+// mapCursorTool returns the Claude Code name and input for a Cursor tool call.
+// Unknown tools keep their name and raw arguments.
+func mapCursorTool(name, rawArgs string) (string, json.RawMessage) {
+	var args map[string]any
+	if json.Unmarshal([]byte(rawArgs), &args) != nil {
+		args = map[string]any{}
+	}
+
+	mapping, ok := cursorTools[name]
+	if !ok {
+		input, _ := json.Marshal(args)
+		return name, input
+	}
+
+	mapped := make(map[string]any, len(mapping.fields))
+	for claudeField, cursorField := range mapping.fields {
+		if v, ok := args[cursorField]; ok {
+			mapped[claudeField] = v
+		}
+	}
+	input, _ := json.Marshal(mapped)
+	return mapping.name, input
+}
+
+// RewriteCursorBubbles calls edit with each bubble of a stored Cursor
+// composer, decoded as JSON, and the time it was created, inherited from
+// the bubbles before it when unknown. It returns the composer with the
+// bubbles edit reported changing rewritten, and how many they were; the
+// rest of the composer is kept as stored.
+func RewriteCursorBubbles(content []byte, edit func(bubble map[string]interface{}, created time.Time) bool) ([]byte, int, error) {
+	var composer map[string]json.RawMessage
+	if err := json.Unmarshal(content, &composer); err != nil {
+		return nil, 0, fmt.Errorf("failed to parse cursor composer: %w", err)
+	}
+	var bubbles []json.RawMessage
+	if err := json.Unmarshal(composer["conversation"], &bubbles); err != nil {
+		return nil, 0, fmt.Errorf("failed to parse cursor composer: %w", err)
+	}
+
+	ts := time.Time{}
+	var createdAt int64
+	if json.Unmarshal(composer["createdAt"], &createdAt) == nil && createdAt != 0 {
+		ts = time.UnixMilli(createdAt).UTC()
+	}
+
+	changed := 0
+	for i, raw := range bubbles {
+		var b cursorBubble
+		if json.Unmarshal(raw, &b) == nil {
+			if t := b.timestamp(); !t.IsZero() {
+				ts = t
+			}
+		}
+		var bubble map[string]interface{}
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.UseNumber() // Keep millisecond timestamps exact
+		if dec.Decode(&bubble) != nil || !edit(bubble, ts) {
+			continue
+		}
+		data, err := json.Marshal(bubble)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to marshal cursor bubble: %w", err)
+		}
+		bubbles[i] = data
+		changed++
+	}
+	if changed == 0 {
+		return content, 0, nil
+	}
+
+	conversation, err := json.Marshal(bubbles)
+	if err != nil {
+		return nil, 0, err
+	}
+	composer["conversation"] = conversation
+	out, err := json.Marshal(composer)
+	if err != nil {
+		return nil, 0, err
+	}
+	return out, changed, nil
+}
@@ -0,0 +1,239 @@
+package session
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const cursorComposerFixture = `{"composerId":"c1","createdAt":1736931600000,"conversation":[
+{"bubbleId":"b1","type":1,"text":"Add a main function","createdAt":"2025-01-15T09:05:00Z"},
+{"bubbleId":"b2","type":2,"text":"I'll add it.","timingInfo":{"clientStartTime":1736931960000}},
+{"bubbleId":"b3","type":2,"toolFormerData":{"toolCallId":"call-1","name":"edit_file","rawArgs":"{\"target_file\":\"/repo/main.go\",\"code_edit\":\"func main() {}\"}","result":"ok","status":"completed"}},
+{"bubbleId":"b4","type":2,"toolFormerData":{"toolCallId":"call-2","name":"run_terminal_cmd","rawArgs":"{\"command\":\"rm -rf build\"}","userDecision":"rejected"}},
+{"bubbleId":"b5","type":2,"toolFormerData":{"name":"fetch_rules","rawArgs":"{\"rule_names\":[\"go\"]}"}},
+{"bubbleId":"b6","type":1,"text":""}
+]}`
+
+func TestParseCursorComposer(t *testing.T) {
+	entries, err := ParseCursorComposer([]byte(cursorComposerFixture))
+	if err != nil {
+		t.Fatalf("ParseCursorComposer() error: %v", err)
+	}
+
+	// user, assistant text, edit + result, bash + rejection, unknown tool
+	if len(entries) != 7 {
+		t.Fatalf("Expected 7 entries, got %d", len(entries))
+	}
+
+	if entries[0].Type != "user" || entries[0].Message.GetTextContent() != "Add a main function" {
+		t.Errorf("Entry 0: expected user prompt, got %q %q", entries[0].Type, entries[0].Message.GetTextContent())
+	}
+	if !entries[0].Timestamp.Equal(time.Date(2025, 1, 15, 9, 5, 0, 0, time.UTC)) {
+		t.Errorf("Entry 0: unexpected timestamp %v", entries[0].Timestamp)
+	}
+	if entries[0].SessionID != "c1" {
+		t.Errorf("Entry 0: expected sessionId c1, got %q", entries[0].SessionID)
+	}
+
+	if entries[1].Type != "assistant" || entries[1].Message.GetTextContent() != "I'll add it." {
+		t.Errorf("Entry 1: expected assistant text, got %q", entries[1].Message.GetTextContent())
+	}
+	if !entries[1].Timestamp.Equal(time.Date(2025, 1, 15, 9, 6, 0, 0, time.UTC)) {
+		t.Errorf("Entry 1: unexpected timestamp %v", entries[1].Timestamp)
+	}
+
+	// Bubbles without timestamps inherit the previous one
+	if !entries[2].Timestamp.Equal(entries[1].Timestamp) {
+		t.Errorf("Entry 2: expected inherited timestamp, got %v", entries[2].Timestamp)
+	}
+
+	var toolUse []struct {
+		Type  string         `json:"type"`
+		ID    string         `json:"id"`
+		Name  string         `json:"name"`
+		Input map[string]any `json:"input"`
+	}
+	if err := json.Unmarshal(entries[2].Message.RawContent, &toolUse); err != nil || len(toolUse) != 1 {
+		t.Fatalf("Entry 2: expected one tool_use part, got %s", entries[2].Message.RawContent)
+	}
+	if toolUse[0].Name != "Edit" || toolUse[0].ID != "call-1" {
+		t.Errorf("Entry 2: expected Edit call-1, got %s %s", toolUse[0].Name, toolUse[0].ID)
+	}
+	if toolUse[0].Input["file_path"] != "/repo/main.go" || toolUse[0].Input["new_string"] != "func main() {}" {
+		t.Errorf("Entry 2: unexpected mapped input %v", toolUse[0].Input)
+	}
+
+	isResult, isRejection := isToolResultContent(entries[3].Message.RawContent)
+	if entries[3].Type != "user" || !isResult || isRejection {
+		t.Errorf("Entry 3: expected tool_result, got %s", entries[3].Message.RawContent)
+	}
+
+	isResult, isRejection = isToolResultContent(entries[5].Message.RawContent)
+	if !isResult || !isRejection {
+		t.Errorf("Entry 5: expected rejection, got %s", entries[5].Message.RawContent)
+	}
+	if !isUserActionEntry(entries[5]) {
+		t.Error("Entry 5: rejection should count as a user action")
+	}
+
+	// Unknown tools keep their name and arguments
+	if !strings.Contains(string(entries[6].Message.RawContent), `"name":"fetch_rules"`) {
+		t.Errorf("Entry 6: expected unmapped tool, got %s", entries[6].Message.RawContent)
+	}
+}
+
+func TestParseTranscript_DispatchesByTool(t *testing.T) {
+	entries, err := ParseTranscript(ToolCursor, []byte(cursorComposerFixture))
+	if err != nil || len(entries) != 7 {
+		t.Fatalf("ParseTranscript(cursor) = %d entries, %v", len(entries), err)
+	}
+
+	jsonl := `{"type":"user","sessionId":"s","timestamp":"2025-01-15T09:15:00Z","message":{"role":"user","content":"Hello"}}`
+	for _, tool := range []string{ToolClaudeCode, "claude-cloud", ""} {
+		entries, err := ParseTranscript(tool, []byte(jsonl))
+		if err != nil || len(entries) != 1 {
+			t.Errorf("ParseTranscript(%q) = %d entries, %v", tool, len(entries), err)
+		}
+	}
+}
+
+func TestReferencesPath(t *testing.T) {
+	tests := []struct {
+		content string
+		want    bool
+	}{
+		{`{"target_file":"/home/u/app/main.go"}`, true},
+		{`{"folder":"/home/u/app"}`, true},
+		{`{"uri":"file:///home/u/app/src/x.go"}`, true},
+		{`{"target_file":"/home/u/application/main.go"}`, false},
+		{`{"target_file":"/home/u/other/main.go"}`, false},
+	}
+
+	for _, tt := range tests {
+		if got := referencesPath([]byte(tt.content), "/home/u/app"); got != tt.want {
+			t.Errorf("referencesPath(%s) = %v, want %v", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestLoadCursorComposer_InlinesBubbles(t *testing.T) {
+	if _, err := exec.LookPath("sqlite3"); err != nil {
+		t.Skip("sqlite3 not available")
+	}
+
+	dbPath := filepath.Join(t.TempDir(), "state.vscdb")
+	setup := `CREATE TABLE cursorDiskKV (key TEXT UNIQUE ON CONFLICT REPLACE, value BLOB);
+INSERT INTO cursorDiskKV VALUES ('composerData:c1', '{"composerId":"c1","createdAt":1736931600000,"fullConversationHeadersOnly":[{"bubbleId":"b1","type":1},{"bubbleId":"b2","type":2}]}');
+INSERT INTO cursorDiskKV VALUES ('bubbleId:c1:b2', '{"bubbleId":"b2","type":2,"text":"Done"}');
+INSERT INTO cursorDiskKV VALUES ('bubbleId:c1:b1', '{"bubbleId":"b1","type":1,"text":"It''s broken"}');`
+	if out, err := exec.Command("sqlite3", dbPath, setup).CombinedOutput(); err != nil {
+		t.Fatalf("sqlite3 setup: %v: %s", err, out)
+	}
+
+	content, err := loadCursorComposer(dbPath, "c1")
+	if err != nil {
+		t.Fatalf("loadCursorComposer() error: %v", err)
+	}
+
+	entries, err := ParseCursorComposer(content)
+	if err != nil {
+		t.Fatalf("ParseCursorComposer() error: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries in header order, got %d", len(entries))
+	}
+	if entries[0].Message.GetTextContent() != "It's broken" || entries[1].Message.GetTextContent() != "Done" {
+		t.Errorf("Unexpected bubble order: %q, %q", entries[0].Message.GetTextContent(), entries[1].Message.GetTextContent())
+	}
+
+	if _, err := loadCursorComposer(dbPath, "missing"); err == nil {
+		t.Error("Expected error for missing composer")
+	}
+}
+
+func TestCursorFindSessions_WorkspaceStorage(t *testing.T) {
+	if _, err := exec.LookPath("sqlite3"); err != nil {
+		t.Skip("sqlite3 not available")
+	}
+
+	configDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configDir)
+	repo := filepath.Join(t.TempDir(), "app")
+	userDir := filepath.Join(configDir, "Cursor", "User")
+
+	sqlite := func(dbPath, script string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if out, err := exec.Command("sqlite3", dbPath, script).CombinedOutput(); err != nil {
+			t.Fatalf("sqlite3 setup: %v: %s", err, out)
+		}
+	}
+
+	// Workspace opened on the repo: an inline composer (older versions),
+	// a legacy chat tab, and a composer whose bubbles live in the global DB
+	wsDir := filepath.Join(userDir, "workspaceStorage", "abc123")
+	sqlite(filepath.Join(wsDir, "state.vscdb"), `CREATE TABLE ItemTable (key TEXT UNIQUE ON CONFLICT REPLACE, value BLOB);
+INSERT INTO ItemTable VALUES ('composer.composerData', '{"allComposers":[
+ {"composerId":"inline","createdAt":1736931600000,"lastUpdatedAt":1736931700000,"conversation":[{"type":1,"text":"Inline prompt"}]},
+ {"composerId":"global","createdAt":1736931600000,"lastUpdatedAt":1736931700000},
+ {"composerId":"old","createdAt":1600000000000,"lastUpdatedAt":1600000000000,"conversation":[{"type":1,"text":"Stale"}]}]}');
+INSERT INTO ItemTable VALUES ('workbench.panel.aichat.view.aichat.chatdata', '{"tabs":[{"tabId":"tab1","lastSendTime":1736931650000,"bubbles":[{"type":"user","text":"Legacy prompt"},{"type":"ai","text":"Legacy answer"}]}]}');`)
+	if err := os.WriteFile(filepath.Join(wsDir, "workspace.json"), []byte(`{"folder":"file://`+repo+`"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Unrelated workspace must be ignored
+	otherDir := filepath.Join(userDir, "workspaceStorage", "def456")
+	sqlite(filepath.Join(otherDir, "state.vscdb"), `CREATE TABLE ItemTable (key TEXT, value BLOB);
+INSERT INTO ItemTable VALUES ('composer.composerData', '{"allComposers":[{"composerId":"other","createdAt":1736931600000,"conversation":[{"type":1,"text":"Other"}]}]}');`)
+	os.WriteFile(filepath.Join(otherDir, "workspace.json"), []byte(`{"folder":"file:///elsewhere"}`), 0644)
+
+	sqlite(filepath.Join(userDir, "globalStorage", "state.vscdb"), `CREATE TABLE cursorDiskKV (key TEXT UNIQUE ON CONFLICT REPLACE, value BLOB);
+INSERT INTO cursorDiskKV VALUES ('composerData:global', '{"composerId":"global","createdAt":1736931600000,"fullConversationHeadersOnly":[{"bubbleId":"b1"}]}');
+INSERT INTO cursorDiskKV VALUES ('bubbleId:global:b1', '{"type":1,"text":"Global prompt"}');`)
+
+	startWork := time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC)
+	endWork := time.Date(2025, 1, 16, 0, 0, 0, 0, time.UTC)
+	p := cursorProvider{}
+	sessions, err := p.FindSessions(repo, startWork, endWork, nil)
+	if err != nil {
+		t.Fatalf("FindSessions() error: %v", err)
+	}
+
+	want := map[string]string{
+		"inline": "Inline prompt",
+		"global": "Global prompt",
+		"tab1":   "Legacy prompt",
+	}
+	if len(sessions) != len(want) {
+		t.Fatalf("Expected %d sessions, got %v", len(want), sessions)
+	}
+	for _, s := range sessions {
+		prompt, ok := want[s.ID]
+		if !ok {
+			t.Errorf("Unexpected session %s", s.ID)
+			continue
+		}
+		if s.Tool != ToolCursor {
+			t.Errorf("Session %s: expected tool cursor, got %q", s.ID, s.Tool)
+		}
+		content, err := p.ReadContent(s)
+		if err != nil {
+			t.Fatalf("ReadContent(%s) error: %v", s.ID, err)
+		}
+		entries, err := ParseCursorComposer(content)
+		if err != nil || len(entries) == 0 {
+			t.Fatalf("ParseCursorComposer(%s) = %v, %v", s.ID, entries, err)
+		}
+		if got := entries[0].Message.GetTextContent(); got != prompt {
+			t.Errorf("Session %s: first prompt %q, want %q", s.ID, got, prompt)
+		}
+	}
+}
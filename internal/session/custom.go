@@ -0,0 +1,401 @@
+package session
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CustomConfigFile is the repository file declaring custom JSONL adapters
+const CustomConfigFile = ".git-prompt-story.yaml"
+
+// CustomJSONLConfig describes how to read one tool's JSONL transcripts.
+// Field mappings are JSONPath-style paths into each line, e.g. "$.message.role"
+// or "$.parts[0].text".
+type CustomJSONLConfig struct {
+	// Name is the tool ID recorded in notes (e.g. "my-agent")
+	Name string `yaml:"name"`
+
+	// Paths are glob patterns of transcript files; "~" expands to the home
+	// directory and "{repo}" to the repository root
+	Paths []string `yaml:"paths"`
+
+	Fields struct {
+		Timestamp  string `yaml:"timestamp"`   // RFC 3339 string or Unix seconds/milliseconds
+		Role       string `yaml:"role"`        // Matched against UserRoles/AssistantRoles
+		Text       string `yaml:"text"`        // Message text
+		ToolName   string `yaml:"tool_name"`   // Tool called by an assistant line
+		ToolInput  string `yaml:"tool_input"`  // Tool arguments (object or string)
+		ToolOutput string `yaml:"tool_output"` // Tool result
+		Cwd        string `yaml:"cwd"`         // Working directory, used to match the repo
+	} `yaml:"fields"`
+
+	UserRoles      []string `yaml:"user_roles"`      // Default: user, human
+	AssistantRoles []string `yaml:"assistant_roles"` // Default: assistant, ai, model
+}
+
+// customConfigFile is the layout of CustomConfigFile
+type customConfigFile struct {
+	CustomJSONL []CustomJSONLConfig `yaml:"custom_jsonl"`
+}
+
+// LoadCustomProviders reads the custom JSONL adapters declared in the repo's
+// CustomConfigFile and registers them, so their sessions can be read by tool
+// ID later. A missing file declares none.
+func LoadCustomProviders(repoRoot string) ([]Provider, error) {
+	data, err := os.ReadFile(filepath.Join(repoRoot, CustomConfigFile))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", CustomConfigFile, err)
+	}
+
+	var file customConfigFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", CustomConfigFile, err)
+	}
+
+	var loaded []Provider
+	for _, cfg := range file.CustomJSONL {
+		p, err := NewCustomJSONLProvider(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", CustomConfigFile, err)
+		}
+		registerProvider(p)
+		loaded = append(loaded, p)
+	}
+	return loaded, nil
+}
+
+// customJSONLProvider ingests JSONL transcripts of any tool using a field
+// mapping. Content is converted to Claude Code JSONL when read, so stored
+// transcripts render without the mapping.
+type customJSONLProvider struct {
+	cfg CustomJSONLConfig
+}
+
+// NewCustomJSONLProvider validates cfg and returns a provider for it
+func NewCustomJSONLProvider(cfg CustomJSONLConfig) (Provider, error) {
+	if cfg.Name == "" || strings.ContainsAny(cfg.Name, "/\\") {
+		return nil, fmt.Errorf("custom_jsonl: invalid name %q", cfg.Name)
+	}
+	for _, p := range builtinProviders {
+		if p.Name() == cfg.Name {
+			return nil, fmt.Errorf("custom_jsonl: name %q is a built-in tool", cfg.Name)
+		}
+	}
+	if len(cfg.Paths) == 0 {
+		return nil, fmt.Errorf("custom_jsonl %s: no paths", cfg.Name)
+	}
+	if cfg.Fields.Timestamp == "" || cfg.Fields.Role == "" || cfg.Fields.Text == "" {
+		return nil, fmt.Errorf("custom_jsonl %s: timestamp, role and text fields are required", cfg.Name)
+	}
+	if len(cfg.UserRoles) == 0 {
+		cfg.UserRoles = []string{"user", "human"}
+	}
+	if len(cfg.AssistantRoles) == 0 {
+		cfg.AssistantRoles = []string{"assistant", "ai", "model"}
+	}
+	return customJSONLProvider{cfg: cfg}, nil
+}
+
+func (p customJSONLProvider) Name() string { return p.cfg.Name }
+
+// FindSessions returns transcript files matching the configured globs that
+// overlap the work period. With a cwd mapping, a file must also have a line
+// whose cwd is the repo or inside it.
+func (p customJSONLProvider) FindSessions(repoPath string, startWork, endWork time.Time, trace *TraceContext) ([]ClaudeSession, error) {
+	absPath, err := filepath.Abs(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	homeDir, _ := os.UserHomeDir()
+
+	seen := make(map[string]bool)
+	var sessions []ClaudeSession
+	for _, pattern := range p.cfg.Paths {
+		pattern = strings.ReplaceAll(pattern, "{repo}", absPath)
+		if strings.HasPrefix(pattern, "~/") && homeDir != "" {
+			pattern = filepath.Join(homeDir, pattern[2:])
+		}
+		files, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("custom_jsonl %s: bad path %q: %w", p.cfg.Name, pattern, err)
+		}
+
+		for _, f := range files {
+			if seen[f] {
+				continue
+			}
+			seen[f] = true
+
+			// Fast pre-filter on mtime, as for Claude Code sessions
+			info, err := os.Stat(f)
+			if err != nil || info.IsDir() || info.ModTime().Before(startWork) {
+				continue
+			}
+
+			content, err := os.ReadFile(f)
+			if err != nil {
+				continue
+			}
+			if p.cfg.Fields.Cwd != "" && !p.inRepo(content, absPath) {
+				continue
+			}
+			entries, err := p.convert(content, sessionIDFromPath(f))
+			if err != nil || len(entries) == 0 {
+				continue
+			}
+			created := entries[0].Timestamp
+			modified := entries[len(entries)-1].Timestamp
+			if modified.Before(startWork) || created.After(endWork) {
+				continue
+			}
+
+			id := sessionIDFromPath(f)
+			sessions = append(sessions, ClaudeSession{
+				ID:       id,
+				Path:     f,
+				Created:  created,
+				Modified: modified,
+				Tool:     p.cfg.Name,
+			})
+
+			if trace != nil {
+				st := trace.FindOrCreateSessionTrace(id)
+				st.Path = f
+				st.Created = created
+				st.Modified = modified
+				st.TimeFilterPassed = true
+				st.TimeFilterReason = "PASS (overlaps work period)"
+			}
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].Modified.After(sessions[j].Modified)
+	})
+	return sessions, nil
+}
+
+// ReadContent converts the transcript file to Claude Code JSONL
+func (p customJSONLProvider) ReadContent(s ClaudeSession) ([]byte, error) {
+	content, err := os.ReadFile(s.Path)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := p.convert(content, s.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, e := range entries {
+		if err := encoder.Encode(e); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// ParseContent parses stored content, which ReadContent already converted
+func (p customJSONLProvider) ParseContent(content []byte) ([]MessageEntry, error) {
+	return ParseMessages(content)
+}
+
+// convert maps each JSONL line to message entries, skipping lines without a
+// timestamp or with an unknown role
+func (p customJSONLProvider) convert(content []byte, sessionID string) ([]MessageEntry, error) {
+	var entries []MessageEntry
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	buf := make([]byte, 0, 64*1024)
+	scanner.Buffer(buf, 1024*1024)
+
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		var line any
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			continue // Skip malformed lines
+		}
+
+		ts := parseFlexibleTime(lookupJSONPath(line, p.cfg.Fields.Timestamp))
+		if ts.IsZero() {
+			continue
+		}
+		role := stringValue(lookupJSONPath(line, p.cfg.Fields.Role))
+		text := stringValue(lookupJSONPath(line, p.cfg.Fields.Text))
+
+		switch {
+		case containsFold(p.cfg.UserRoles, role):
+			if text != "" {
+				entries = append(entries, newMessageEntry("user", sessionID, ts, text))
+			}
+
+		case containsFold(p.cfg.AssistantRoles, role):
+			var parts []map[string]any
+			if text != "" {
+				parts = append(parts, map[string]any{"type": "text", "text": text})
+			}
+			toolName := stringValue(lookupJSONPath(line, p.cfg.Fields.ToolName))
+			toolID := fmt.Sprintf("%s-%d", sessionID, lineNum)
+			if toolName != "" {
+				input := lookupJSONPath(line, p.cfg.Fields.ToolInput)
+				if _, ok := input.(map[string]any); !ok {
+					input = map[string]any{"input": stringValue(input)}
+				}
+				parts = append(parts, map[string]any{"type": "tool_use", "id": toolID, "name": toolName, "input": input})
+			}
+			if len(parts) == 0 {
+				continue
+			}
+			entries = append(entries, newMessageEntry("assistant", sessionID, ts, parts))
+
+			if output := stringValue(lookupJSONPath(line, p.cfg.Fields.ToolOutput)); toolName != "" && output != "" {
+				entries = append(entries, newMessageEntry("user", sessionID, ts, []map[string]any{{
+					"type": "tool_result", "tool_use_id": toolID, "content": output,
+				}}))
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// inRepo reports whether any line's cwd is repoPath or inside it
+func (p customJSONLProvider) inRepo(content []byte, repoPath string) bool {
+	repo := filepath.Clean(repoPath)
+	for _, raw := range bytes.Split(content, []byte("\n")) {
+		var line any
+		if json.Unmarshal(raw, &line) != nil {
+			continue
+		}
+		cwd := stringValue(lookupJSONPath(line, p.cfg.Fields.Cwd))
+		if cwd == "" {
+			continue
+		}
+		cwd = filepath.Clean(cwd)
+		if cwd == repo || strings.HasPrefix(cwd, repo+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// sessionIDFromPath derives a session ID from a transcript file name
+func sessionIDFromPath(path string) string {
+	return strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+}
+
+// lookupJSONPath evaluates a JSONPath-style expression such as
+// "$.message.content[0].text" against decoded JSON. Only child and index
+// steps are supported. It returns nil if the path is empty or missing.
+func lookupJSONPath(v any, path string) any {
+	path = strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(path), "$"), ".")
+	if path == "" {
+		return nil
+	}
+
+	for _, step := range strings.Split(path, ".") {
+		name, rest, _ := strings.Cut(step, "[")
+		if name != "" {
+			obj, ok := v.(map[string]any)
+			if !ok {
+				return nil
+			}
+			v = obj[name]
+		}
+		// One or more [n] indices after the name
+		for rest != "" {
+			idxStr, after, ok := strings.Cut(rest, "]")
+			if !ok {
+				return nil
+			}
+			idx, err := strconv.Atoi(idxStr)
+			arr, isArr := v.([]any)
+			if err != nil || !isArr {
+				return nil
+			}
+			if idx < 0 {
+				idx += len(arr)
+			}
+			if idx < 0 || idx >= len(arr) {
+				return nil
+			}
+			v = arr[idx]
+			rest = strings.TrimPrefix(after, "[")
+		}
+	}
+	return v
+}
+
+// stringValue renders a looked-up JSON value as text
+func stringValue(v any) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(t)
+	default:
+		data, _ := json.Marshal(t)
+		return string(data)
+	}
+}
+
+// parseFlexibleTime parses RFC 3339 strings and Unix seconds or
+// milliseconds (numbers above 1e12 are taken as milliseconds)
+func parseFlexibleTime(v any) time.Time {
+	var n float64
+	switch t := v.(type) {
+	case string:
+		if ts, err := time.Parse(time.RFC3339Nano, t); err == nil {
+			return ts.UTC()
+		}
+		f, err := strconv.ParseFloat(t, 64)
+		if err != nil {
+			return time.Time{}
+		}
+		n = f
+	case float64:
+		n = t
+	default:
+		return time.Time{}
+	}
+	if n <= 0 {
+		return time.Time{}
+	}
+	if n > 1e12 {
+		return time.UnixMilli(int64(n)).UTC()
+	}
+	return time.UnixMilli(int64(n * 1000)).UTC()
+}
+
+// containsFold reports whether list contains s, ignoring case
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,149 @@
+package session
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLookupJSONPath(t *testing.T) {
+	var doc any
+	json.Unmarshal([]byte(`{"message":{"role":"user","parts":[{"text":"a"},{"text":"b"}]},"ts":1736931600,"grid":[[1,2],[3,4]]}`), &doc)
+
+	tests := []struct {
+		path string
+		want any
+	}{
+		{"$.message.role", "user"},
+		{"message.role", "user"},
+		{"$.message.parts[1].text", "b"},
+		{"$.message.parts[-1].text", "b"},
+		{"$.grid[1][0]", float64(3)},
+		{"$.ts", float64(1736931600)},
+		{"$.missing.field", nil},
+		{"$.message.parts[5].text", nil},
+		{"$.message.role[0]", nil},
+		{"", nil},
+	}
+
+	for _, tt := range tests {
+		if got := lookupJSONPath(doc, tt.path); got != tt.want {
+			t.Errorf("lookupJSONPath(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestParseFlexibleTime(t *testing.T) {
+	want := time.Date(2025, 1, 15, 9, 0, 0, 0, time.UTC)
+	for _, v := range []any{"2025-01-15T09:00:00Z", "2025-01-15T10:00:00+01:00", float64(1736931600), float64(1736931600000), "1736931600"} {
+		if got := parseFlexibleTime(v); !got.Equal(want) {
+			t.Errorf("parseFlexibleTime(%v) = %v, want %v", v, got, want)
+		}
+	}
+	for _, v := range []any{nil, "yesterday", float64(0), true} {
+		if got := parseFlexibleTime(v); !got.IsZero() {
+			t.Errorf("parseFlexibleTime(%v) = %v, want zero", v, got)
+		}
+	}
+}
+
+func TestCustomJSONLProvider(t *testing.T) {
+	repo := t.TempDir()
+	logDir := filepath.Join(repo, ".agent-logs")
+	os.MkdirAll(logDir, 0755)
+
+	transcript := `{"time":"2025-01-15T09:00:00Z","who":"human","body":"Add a README","cwd":"` + repo + `"}
+{"time":"2025-01-15T09:01:00Z","who":"bot","body":"Writing it now","call":{"name":"Write","args":{"file_path":"README.md"},"result":"written"}}
+{"time":"2025-01-15T09:02:00Z","who":"system","body":"ignored"}
+not json
+{"who":"human","body":"no timestamp"}
+`
+	os.WriteFile(filepath.Join(logDir, "run-1.jsonl"), []byte(transcript), 0644)
+	os.WriteFile(filepath.Join(repo, CustomConfigFile), []byte(`custom_jsonl:
+  - name: my-agent
+    paths: ["{repo}/.agent-logs/*.jsonl"]
+    fields:
+      timestamp: $.time
+      role: $.who
+      text: $.body
+      tool_name: $.call.name
+      tool_input: $.call.args
+      tool_output: $.call.result
+      cwd: $.cwd
+    user_roles: [human]
+    assistant_roles: [bot]
+`), 0644)
+
+	loaded, err := LoadCustomProviders(repo)
+	if err != nil || len(loaded) != 1 {
+		t.Fatalf("LoadCustomProviders() = %v, %v", loaded, err)
+	}
+	p := GetProvider("my-agent")
+	if p.Name() != "my-agent" {
+		t.Fatalf("GetProvider(my-agent) returned %s", p.Name())
+	}
+
+	startWork := time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC)
+	sessions, err := p.FindSessions(repo, startWork, time.Now(), nil)
+	if err != nil || len(sessions) != 1 {
+		t.Fatalf("FindSessions() = %v, %v", sessions, err)
+	}
+	s := sessions[0]
+	if s.ID != "run-1" || s.Tool != "my-agent" {
+		t.Errorf("Unexpected session %+v", s)
+	}
+	if !s.Modified.Equal(time.Date(2025, 1, 15, 9, 1, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected modified time %v", s.Modified)
+	}
+
+	// Stored content is Claude Code JSONL, readable without the mapping
+	content, err := ReadContent(s)
+	if err != nil {
+		t.Fatalf("ReadContent() error: %v", err)
+	}
+	entries, err := ParseMessages(content)
+	if err != nil || len(entries) != 3 {
+		t.Fatalf("Expected user, assistant and tool result entries, got %d (%v):\n%s", len(entries), err, content)
+	}
+	if entries[0].Message.GetTextContent() != "Add a README" {
+		t.Errorf("Entry 0: unexpected text %q", entries[0].Message.GetTextContent())
+	}
+	if isResult, _ := isToolResultContent(entries[2].Message.RawContent); !isResult {
+		t.Errorf("Entry 2: expected tool_result, got %s", entries[2].Message.RawContent)
+	}
+
+	// A cwd outside the repo excludes the file
+	sessions, _ = p.FindSessions(t.TempDir(), startWork, time.Now(), nil)
+	if len(sessions) != 0 {
+		t.Errorf("Expected no sessions for another repo, got %v", sessions)
+	}
+}
+
+func TestNewCustomJSONLProvider_Validation(t *testing.T) {
+	valid := CustomJSONLConfig{Name: "x", Paths: []string{"*.jsonl"}}
+	valid.Fields.Timestamp, valid.Fields.Role, valid.Fields.Text = "$.t", "$.r", "$.x"
+
+	tests := []struct {
+		name   string
+		modify func(c *CustomJSONLConfig)
+	}{
+		{"empty name", func(c *CustomJSONLConfig) { c.Name = "" }},
+		{"slash in name", func(c *CustomJSONLConfig) { c.Name = "a/b" }},
+		{"built-in name", func(c *CustomJSONLConfig) { c.Name = ToolClaudeCode }},
+		{"no paths", func(c *CustomJSONLConfig) { c.Paths = nil }},
+		{"no text field", func(c *CustomJSONLConfig) { c.Fields.Text = "" }},
+	}
+
+	if _, err := NewCustomJSONLProvider(valid); err != nil {
+		t.Fatalf("valid config rejected: %v", err)
+	}
+	for _, tt := range tests {
+		cfg := valid
+		tt.modify(&cfg)
+		if _, err := NewCustomJSONLProvider(cfg); err == nil {
+			t.Errorf("%s: expected error", tt.name)
+		}
+	}
+}
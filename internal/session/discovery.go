@@ -197,17 +197,18 @@ func HasUserMessagesInRange(sessionPath string, startWork, endWork time.Time) (b
 // CountUserMessagesInRangeForSession counts user messages in a single session within the time range
 // Returns (hasMessages, count, error)
 func CountUserMessagesInRangeForSession(sessionPath string, startWork, endWork time.Time) (bool, int, error) {
-	content, err := ReadSessionContent(sessionPath)
-	if err != nil {
-		return false, 0, err
-	}
+	count, _, err := countSessionUserMessages(ClaudeSession{Path: sessionPath}, startWork, endWork)
+	return count > 0, count, err
+}
 
-	entries, err := ParseMessages(content)
+// countSessionUserMessages counts user messages of any tool's session within
+// the time range, and the timestamps that went back in the transcript
+func countSessionUserMessages(s ClaudeSession, startWork, endWork time.Time) (count, regressions int, err error) {
+	entries, regressions, err := readOrderedEntries(s)
 	if err != nil {
-		return false, 0, err
+		return 0, 0, err
 	}
 
-	count := 0
 	for _, entry := range entries {
 		if entry.Type != "user" {
 			continue
@@ -217,7 +218,7 @@ func CountUserMessagesInRangeForSession(sessionPath string, startWork, endWork t
 			count++
 		}
 	}
-	return count > 0, count, nil
+	return count, regressions, nil
 }
 
 // FilterSessionsByUserMessages filters to only sessions with user messages in time range
@@ -225,8 +226,11 @@ func CountUserMessagesInRangeForSession(sessionPath string, startWork, endWork t
 func FilterSessionsByUserMessages(sessions []ClaudeSession, startWork, endWork time.Time, trace *TraceContext) []ClaudeSession {
 	var filtered []ClaudeSession
 	for _, s := range sessions {
-		hasMessages, count, err := CountUserMessagesInRangeForSession(s.Path, startWork, endWork)
-		if err == nil && hasMessages {
+		count, regressions, err := countSessionUserMessages(s, startWork, endWork)
+		if trace != nil && regressions > 0 {
+			trace.FindOrCreateSessionTrace(s.ID).TimestampRegressions = regressions
+		}
+		if err == nil && count > 0 {
 			filtered = append(filtered, s)
 			if trace != nil {
 				st := trace.FindOrCreateSessionTrace(s.ID)
@@ -257,12 +261,7 @@ func FilterSessionsByUserMessages(sessions []ClaudeSession, startWork, endWork t
 func CountUserMessagesInRange(sessions []ClaudeSession, startWork, endWork time.Time) int {
 	count := 0
 	for _, s := range sessions {
-		content, err := ReadSessionContent(s.Path)
-		if err != nil {
-			continue
-		}
-
-		entries, err := ParseMessages(content)
+		entries, err := readSessionEntries(s)
 		if err != nil {
 			continue
 		}
@@ -280,6 +279,22 @@ func CountUserMessagesInRange(sessions []ClaudeSession, startWork, endWork time.
 	return count
 }
 
+// CountUserActions counts the user actions (prompts, commands, tool
+// rejects) in a stored transcript of tool
+func CountUserActions(tool string, content []byte) int {
+	entries, err := ParseTranscript(tool, content)
+	if err != nil {
+		return 0
+	}
+	count := 0
+	for _, entry := range entries {
+		if isUserActionEntry(entry) {
+			count++
+		}
+	}
+	return count
+}
+
 // CountUserActionsInRange counts actual user actions (prompts, commands, tool rejects)
 // across all sessions within the time range, excluding agent sessions.
 // This matches the counting logic used in CI summary.
@@ -291,12 +306,7 @@ func CountUserActionsInRange(sessions []ClaudeSession, startWork, endWork time.T
 			continue
 		}
 
-		content, err := ReadSessionContent(s.Path)
-		if err != nil {
-			continue
-		}
-
-		entries, err := ParseMessages(content)
+		entries, err := readSessionEntries(s)
 		if err != nil {
 			continue
 		}
@@ -318,6 +328,100 @@ func CountUserActionsInRange(sessions []ClaudeSession, startWork, endWork time.T
 	return count
 }
 
+// Prompt is a user prompt of a session
+type Prompt struct {
+	Time    time.Time
+	Tool    string
+	Session string
+	Text    string
+}
+
+// PromptsInRange returns the user prompts within the time range across
+// sessions, oldest first, excluding agent sessions and slash commands
+func PromptsInRange(sessions []ClaudeSession, startWork, endWork time.Time) []Prompt {
+	var prompts []Prompt
+	for _, s := range sessions {
+		if strings.HasPrefix(s.ID, "agent-") {
+			continue
+		}
+
+		entries, err := readSessionEntries(s)
+		if err != nil {
+			continue
+		}
+
+		for _, entry := range entries {
+			ts := entry.Timestamp
+			if ts.IsZero() || ts.Before(startWork) || ts.After(endWork) {
+				continue
+			}
+			if entry.Type != "user" || !isUserActionEntry(entry) {
+				continue
+			}
+			text := entry.Message.GetTextContent()
+			if text == "" || strings.HasPrefix(text, "<command-name>") {
+				continue
+			}
+			prompts = append(prompts, Prompt{Time: ts, Tool: s.ToolName(), Session: s.ID, Text: text})
+		}
+	}
+	sort.SliceStable(prompts, func(i, j int) bool { return prompts[i].Time.Before(prompts[j].Time) })
+	return prompts
+}
+
+// FirstPromptInRange returns the text of the earliest user prompt within
+// the time range across sessions, excluding agent sessions, or "" if none
+func FirstPromptInRange(sessions []ClaudeSession, startWork, endWork time.Time) string {
+	prompts := PromptsInRange(sessions, startWork, endWork)
+	if len(prompts) == 0 {
+		return ""
+	}
+	return prompts[0].Text
+}
+
+// readSessionEntries reads and parses a session with its tool's provider,
+// making its timestamps monotonic
+func readSessionEntries(s ClaudeSession) ([]MessageEntry, error) {
+	entries, _, err := readOrderedEntries(s)
+	return entries, err
+}
+
+// readOrderedEntries is readSessionEntries also returning how many
+// timestamps went back, see MonotonicTimestamps
+func readOrderedEntries(s ClaudeSession) ([]MessageEntry, int, error) {
+	content, err := ReadContent(s)
+	if err != nil {
+		return nil, 0, err
+	}
+	entries, err := ParseTranscript(s.ToolName(), content)
+	if err != nil {
+		return nil, 0, err
+	}
+	return entries, MonotonicTimestamps(entries), nil
+}
+
+// StartContext returns the branch and working directory a session started
+// in, as recorded by the tool in the transcript. Either is empty when the
+// tool does not record it (Cursor, most custom tools).
+func StartContext(s ClaudeSession) (branch, cwd string) {
+	entries, err := readSessionEntries(s)
+	if err != nil {
+		return "", ""
+	}
+	for _, e := range entries {
+		if branch == "" {
+			branch = e.GitBranch
+		}
+		if cwd == "" {
+			cwd = e.Cwd
+		}
+		if branch != "" && cwd != "" {
+			break
+		}
+	}
+	return branch, cwd
+}
+
 // isUserActionEntry determines if a message entry represents a user action
 // (prompt, command, or tool rejection) as opposed to tool results or system messages
 func isUserActionEntry(entry MessageEntry) bool {
@@ -0,0 +1,117 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/scrubber"
+)
+
+// FixtureDir is where captured transcripts live, relative to the repo root.
+// Each <tool>/<name>.jsonl sample has a <name>.json FixtureExpectation.
+const FixtureDir = "internal/session/testdata/corpus"
+
+// FixtureExpectation is what the parser produced for a fixture when it was
+// captured; the corpus test fails when a parser change alters it
+type FixtureExpectation struct {
+	Tool    string         `json:"tool"`
+	Entries int            `json:"entries"`
+	Types   map[string]int `json:"types"`            // entry type -> count
+	Blocks  map[string]int `json:"blocks,omitempty"` // content block type -> count
+}
+
+// homePathRegex matches home directories, which carry user names
+var homePathRegex = regexp.MustCompile(`(/home/|/Users/|C:\\\\Users\\\\)[^/\\"]+`)
+
+// AnonymizeFixture scrubs PII from transcript content and replaces home
+// directory paths, so real sessions can be committed as test data
+func AnonymizeFixture(content []byte) ([]byte, error) {
+	s, err := scrubber.NewDefault()
+	if err != nil {
+		return nil, err
+	}
+	scrubbed, err := s.Scrub(content)
+	if err != nil {
+		return nil, err
+	}
+	scrubbed = homePathRegex.ReplaceAll(scrubbed, []byte("${1}user"))
+	if len(scrubbed) > 0 && scrubbed[len(scrubbed)-1] != '\n' {
+		scrubbed = append(scrubbed, '\n')
+	}
+	return scrubbed, nil
+}
+
+// ExpectFixture runs the tool's parser over content and records the result
+func ExpectFixture(tool string, content []byte) (FixtureExpectation, error) {
+	entries, err := ParseTranscript(tool, content)
+	if err != nil {
+		return FixtureExpectation{}, err
+	}
+
+	exp := FixtureExpectation{Tool: tool, Entries: len(entries), Types: make(map[string]int)}
+	for _, e := range entries {
+		exp.Types[e.Type]++
+		if e.Message == nil {
+			continue
+		}
+		var blocks []struct {
+			Type string `json:"type"`
+		}
+		if json.Unmarshal(e.Message.RawContent, &blocks) != nil {
+			continue
+		}
+		for _, b := range blocks {
+			if exp.Blocks == nil {
+				exp.Blocks = make(map[string]int)
+			}
+			exp.Blocks[b.Type]++
+		}
+	}
+	return exp, nil
+}
+
+// CaptureFixture anonymizes the transcript at path and stores it with its
+// expectation under dir/<tool>/<name>. It returns the fixture path.
+func CaptureFixture(path, tool, name, dir string) (string, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	content, err := AnonymizeFixture(raw)
+	if err != nil {
+		return "", fmt.Errorf("failed to anonymize %s: %w", path, err)
+	}
+	exp, err := ExpectFixture(tool, content)
+	if err != nil {
+		return "", fmt.Errorf("%s parser rejects %s: %w", tool, path, err)
+	}
+
+	if name == "" {
+		name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	toolDir := filepath.Join(dir, tool)
+	if err := os.MkdirAll(toolDir, 0755); err != nil {
+		return "", err
+	}
+	fixturePath := filepath.Join(toolDir, name+".jsonl")
+	if err := os.WriteFile(fixturePath, content, 0644); err != nil {
+		return "", err
+	}
+	if err := WriteFixtureExpectation(fixturePath, exp); err != nil {
+		return "", err
+	}
+	return fixturePath, nil
+}
+
+// WriteFixtureExpectation stores exp next to the fixture at fixturePath
+func WriteFixtureExpectation(fixturePath string, exp FixtureExpectation) error {
+	data, err := json.MarshalIndent(exp, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(strings.TrimSuffix(fixturePath, ".jsonl")+".json", append(data, '\n'), 0644)
+}
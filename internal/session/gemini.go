@@ -0,0 +1,334 @@
+package session
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// geminiProvider reads Gemini CLI chats from ~/.gemini/tmp/<project>/chats,
+// one JSON file per session. The project directory is named after the
+// SHA-256 of the directory Gemini CLI was started in, or, in newer
+// versions, holds that directory in a .project_root file.
+type geminiProvider struct{}
+
+func (geminiProvider) Name() string { return ToolGemini }
+
+// geminiChat is the part of a stored Gemini CLI session the parser reads
+type geminiChat struct {
+	SessionID   string          `json:"sessionId"`
+	StartTime   time.Time       `json:"startTime"`
+	LastUpdated time.Time       `json:"lastUpdated"`
+	Messages    []geminiMessage `json:"messages"`
+}
+
+// geminiMessage is one message of a Gemini CLI session
+type geminiMessage struct {
+	ID        string           `json:"id"`
+	Timestamp time.Time        `json:"timestamp"`
+	Type      string           `json:"type"`    // user, gemini, info, error, warning
+	Content   json.RawMessage  `json:"content"` // String or list of parts
+	ToolCalls []geminiToolCall `json:"toolCalls,omitempty"`
+}
+
+// geminiToolCall is a tool invocation of a model message
+type geminiToolCall struct {
+	ID            string          `json:"id"`
+	Name          string          `json:"name"`
+	Args          map[string]any  `json:"args"`
+	Result        json.RawMessage `json:"result,omitempty"` // Function response parts
+	ResultDisplay json.RawMessage `json:"resultDisplay,omitempty"`
+	Status        string          `json:"status"` // success, error, cancelled
+}
+
+// geminiTools lists the Gemini CLI tools with a Claude Code equivalent,
+// mapped like Cursor's
+var geminiTools = map[string]cursorToolMapping{
+	"read_file":           {"Read", map[string]string{"file_path": "absolute_path", "offset": "offset"}},
+	"write_file":          {"Write", map[string]string{"file_path": "file_path", "content": "content"}},
+	"replace":             {"Edit", map[string]string{"file_path": "file_path", "old_string": "old_string", "new_string": "new_string"}},
+	"run_shell_command":   {"Bash", map[string]string{"command": "command", "description": "description"}},
+	"search_file_content": {"Grep", map[string]string{"pattern": "pattern", "path": "path"}},
+	"glob":                {"Glob", map[string]string{"pattern": "pattern", "path": "path"}},
+	"list_directory":      {"LS", map[string]string{"path": "path"}},
+	"google_web_search":   {"WebSearch", map[string]string{"query": "query"}},
+}
+
+// FindSessions returns Gemini CLI sessions started in repoPath or inside it
+// that overlap the work period
+func (geminiProvider) FindSessions(repoPath string, startWork, endWork time.Time, trace *TraceContext) ([]ClaudeSession, error) {
+	absPath, err := filepath.Abs(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	projectDirs, err := os.ReadDir(filepath.Join(homeDir, ".gemini", "tmp"))
+	if err != nil {
+		return nil, nil // Gemini CLI not installed or never run
+	}
+
+	var sessions []ClaudeSession
+	for _, dir := range projectDirs {
+		projectDir := filepath.Join(homeDir, ".gemini", "tmp", dir.Name())
+		if !dir.IsDir() || !geminiProjectInRepo(projectDir, absPath) {
+			continue
+		}
+		files, _ := filepath.Glob(filepath.Join(projectDir, "chats", "*.json"))
+		for _, f := range files {
+			// Fast pre-filter on mtime, as for Claude Code sessions
+			info, err := os.Stat(f)
+			if err != nil || info.ModTime().Before(startWork) {
+				continue
+			}
+			content, err := os.ReadFile(f)
+			if err != nil {
+				continue
+			}
+			var chat geminiChat
+			if json.Unmarshal(content, &chat) != nil {
+				continue
+			}
+			id := geminiSessionID(chat, f)
+			entries := convertGeminiChat(chat, id)
+			if len(entries) == 0 {
+				continue
+			}
+
+			created, modified := chat.StartTime, chat.LastUpdated
+			if created.IsZero() {
+				created = entries[0].Timestamp
+			}
+			if modified.IsZero() {
+				modified = entries[len(entries)-1].Timestamp
+			}
+			created, modified = created.UTC(), modified.UTC()
+			if modified.Before(startWork) || created.After(endWork) {
+				continue
+			}
+
+			sessions = append(sessions, ClaudeSession{
+				ID:       id,
+				Path:     f,
+				Created:  created,
+				Modified: modified,
+				Tool:     ToolGemini,
+			})
+
+			if trace != nil {
+				st := trace.FindOrCreateSessionTrace(id)
+				st.Path = f
+				st.Created = created
+				st.Modified = modified
+				st.TimeFilterPassed = true
+				st.TimeFilterReason = "PASS (overlaps work period)"
+			}
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].Modified.After(sessions[j].Modified)
+	})
+	return sessions, nil
+}
+
+// ReadContent converts the session file to Claude Code JSONL
+func (geminiProvider) ReadContent(s ClaudeSession) ([]byte, error) {
+	content, err := os.ReadFile(s.Path)
+	if err != nil {
+		return nil, err
+	}
+	var chat geminiChat
+	if err := json.Unmarshal(content, &chat); err != nil {
+		return nil, fmt.Errorf("failed to parse gemini session: %w", err)
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, e := range convertGeminiChat(chat, s.ID) {
+		if err := encoder.Encode(e); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// ParseContent parses stored content, which ReadContent already converted
+func (geminiProvider) ParseContent(content []byte) ([]MessageEntry, error) {
+	return ParseMessages(content)
+}
+
+// geminiProjectInRepo reports whether a Gemini CLI project directory
+// belongs to a session started in repoPath, or inside it when the project
+// root is recorded
+func geminiProjectInRepo(projectDir, repoPath string) bool {
+	if root, err := os.ReadFile(filepath.Join(projectDir, ".project_root")); err == nil {
+		dir := filepath.Clean(strings.TrimSpace(string(root)))
+		return dir == repoPath || strings.HasPrefix(dir, repoPath+string(filepath.Separator))
+	}
+	return filepath.Base(projectDir) == geminiProjectHash(repoPath)
+}
+
+// geminiProjectHash returns the name Gemini CLI gives a project's directory
+func geminiProjectHash(path string) string {
+	sum := sha256.Sum256([]byte(path))
+	return hex.EncodeToString(sum[:])
+}
+
+// geminiSessionID returns the session's ID, falling back to the file name
+func geminiSessionID(chat geminiChat, path string) string {
+	if chat.SessionID != "" && !strings.ContainsAny(chat.SessionID, "/\\") {
+		return chat.SessionID
+	}
+	return sessionIDFromPath(path)
+}
+
+// convertGeminiChat converts a Gemini CLI session into message entries
+// shaped like Claude Code's. User messages become prompts, model messages
+// text and tool_use parts, and tool results (or cancellations) tool_result
+// user entries. Gemini CLI's informational and error messages are left out.
+func convertGeminiChat(chat geminiChat, id string) []MessageEntry {
+	// Messages without a timestamp inherit the previous one
+	ts := chat.StartTime.UTC()
+	var entries []MessageEntry
+	for _, m := range chat.Messages {
+		if !m.Timestamp.IsZero() {
+			ts = m.Timestamp.UTC()
+		}
+		text := geminiText(m.Content)
+
+		switch m.Type {
+		case "user":
+			if text != "" {
+				entries = append(entries, newMessageEntry("user", id, ts, text))
+			}
+
+		case "gemini":
+			var parts []map[string]any
+			if text != "" {
+				parts = append(parts, map[string]any{"type": "text", "text": text})
+			}
+			var results []map[string]any
+			for i, call := range m.ToolCalls {
+				callID := call.ID
+				if callID == "" {
+					callID = fmt.Sprintf("%s-%d", m.ID, i)
+				}
+				name, input := mapGeminiTool(call.Name, call.Args)
+				parts = append(parts, map[string]any{"type": "tool_use", "id": callID, "name": name, "input": input})
+				if result := call.toolResult(callID); result != nil {
+					results = append(results, result)
+				}
+			}
+			if len(parts) == 0 {
+				continue
+			}
+			entries = append(entries, newMessageEntry("assistant", id, ts, parts))
+			if len(results) > 0 {
+				entries = append(entries, newMessageEntry("user", id, ts, results))
+			}
+		}
+	}
+	return entries
+}
+
+// toolResult returns the tool_result part for a finished or cancelled call
+func (c geminiToolCall) toolResult(id string) map[string]any {
+	if c.Status == "cancelled" {
+		return map[string]any{
+			"type":        "tool_result",
+			"tool_use_id": id,
+			"is_error":    true,
+			"content":     "The user doesn't want to proceed with this tool use. The tool use was rejected.",
+		}
+	}
+	output := geminiResultText(c.Result)
+	if output == "" {
+		var display string
+		if json.Unmarshal(c.ResultDisplay, &display) == nil {
+			output = display
+		}
+	}
+	if output == "" {
+		return nil
+	}
+	result := map[string]any{"type": "tool_result", "tool_use_id": id, "content": output}
+	if c.Status == "error" {
+		result["is_error"] = true
+	}
+	return result
+}
+
+// mapGeminiTool returns the Claude Code name and input for a Gemini CLI
+// tool call. Unknown tools keep their name and arguments.
+func mapGeminiTool(name string, args map[string]any) (string, json.RawMessage) {
+	if args == nil {
+		args = map[string]any{}
+	}
+	mapping, ok := geminiTools[name]
+	if !ok {
+		input, _ := json.Marshal(args)
+		return name, input
+	}
+
+	mapped := make(map[string]any, len(mapping.fields))
+	for claudeField, geminiField := range mapping.fields {
+		if v, ok := args[geminiField]; ok {
+			mapped[claudeField] = v
+		}
+	}
+	input, _ := json.Marshal(mapped)
+	return mapping.name, input
+}
+
+// geminiText returns the text of message content, a string or a list of
+// parts
+func geminiText(content json.RawMessage) string {
+	var s string
+	if json.Unmarshal(content, &s) == nil {
+		return strings.TrimSpace(s)
+	}
+	var parts []struct {
+		Text string `json:"text"`
+	}
+	if json.Unmarshal(content, &parts) != nil {
+		return ""
+	}
+	var texts []string
+	for _, p := range parts {
+		if p.Text != "" {
+			texts = append(texts, p.Text)
+		}
+	}
+	return strings.TrimSpace(strings.Join(texts, "\n"))
+}
+
+// geminiResultText returns the output of a tool call's function responses
+func geminiResultText(result json.RawMessage) string {
+	var parts []struct {
+		FunctionResponse struct {
+			Response map[string]any `json:"response"`
+		} `json:"functionResponse"`
+	}
+	if json.Unmarshal(result, &parts) != nil {
+		return ""
+	}
+	var outputs []string
+	for _, p := range parts {
+		for _, key := range []string{"output", "error"} {
+			if v, ok := p.FunctionResponse.Response[key].(string); ok && v != "" {
+				outputs = append(outputs, v)
+			}
+		}
+	}
+	return strings.Join(outputs, "\n")
+}
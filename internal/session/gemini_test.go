@@ -0,0 +1,138 @@
+package session
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const geminiChatFixture = `{
+  "sessionId": "9f1c2d3e",
+  "projectHash": "unused",
+  "startTime": "2025-01-15T09:00:00.000Z",
+  "lastUpdated": "2025-01-15T09:10:00.000Z",
+  "messages": [
+    {"id": "m1", "timestamp": "2025-01-15T09:01:00.000Z", "type": "user", "content": "Add a main function"},
+    {"id": "m2", "timestamp": "2025-01-15T09:02:00.000Z", "type": "gemini", "content": "I'll add it.",
+     "toolCalls": [
+       {"id": "call-1", "name": "replace", "args": {"file_path": "/repo/main.go", "old_string": "", "new_string": "func main() {}"},
+        "result": [{"functionResponse": {"id": "call-1", "name": "replace", "response": {"output": "ok"}}}], "status": "success"},
+       {"id": "call-2", "name": "run_shell_command", "args": {"command": "rm -rf build"}, "status": "cancelled"}
+     ]},
+    {"id": "m3", "timestamp": "2025-01-15T09:03:00.000Z", "type": "info", "content": "Request cancelled."},
+    {"id": "m4", "type": "user", "content": [{"text": "Now print"}, {"text": "a greeting"}]}
+  ]
+}`
+
+func TestConvertGeminiChat(t *testing.T) {
+	var chat geminiChat
+	if err := json.Unmarshal([]byte(geminiChatFixture), &chat); err != nil {
+		t.Fatal(err)
+	}
+	entries := convertGeminiChat(chat, "9f1c2d3e")
+
+	// prompt, model turn, tool results, prompt; info messages are left out
+	if len(entries) != 4 {
+		t.Fatalf("Expected 4 entries, got %d", len(entries))
+	}
+
+	if entries[0].Type != "user" || entries[0].Message.GetTextContent() != "Add a main function" {
+		t.Errorf("Entry 0: expected user prompt, got %q %q", entries[0].Type, entries[0].Message.GetTextContent())
+	}
+	if !entries[0].Timestamp.Equal(time.Date(2025, 1, 15, 9, 1, 0, 0, time.UTC)) {
+		t.Errorf("Entry 0: unexpected timestamp %v", entries[0].Timestamp)
+	}
+
+	var parts []struct {
+		Type  string         `json:"type"`
+		ID    string         `json:"id"`
+		Name  string         `json:"name"`
+		Input map[string]any `json:"input"`
+	}
+	if err := json.Unmarshal(entries[1].Message.RawContent, &parts); err != nil {
+		t.Fatal(err)
+	}
+	if len(parts) != 3 || parts[0].Type != "text" {
+		t.Fatalf("Entry 1: unexpected parts %+v", parts)
+	}
+	if parts[1].Name != "Edit" || parts[1].Input["file_path"] != "/repo/main.go" || parts[1].Input["new_string"] != "func main() {}" {
+		t.Errorf("Entry 1: expected mapped Edit, got %+v", parts[1])
+	}
+	if parts[2].Name != "Bash" || parts[2].Input["command"] != "rm -rf build" {
+		t.Errorf("Entry 1: expected mapped Bash, got %+v", parts[2])
+	}
+
+	var results []struct {
+		ToolUseID string `json:"tool_use_id"`
+		Content   string `json:"content"`
+		IsError   bool   `json:"is_error"`
+	}
+	if err := json.Unmarshal(entries[2].Message.RawContent, &results); err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[0].Content != "ok" || results[0].IsError {
+		t.Errorf("Entry 2: unexpected results %+v", results)
+	}
+	if len(results) == 2 && !results[1].IsError {
+		t.Errorf("Entry 2: expected the cancelled call as a rejection")
+	}
+	if !isUserActionEntry(entries[2]) {
+		t.Error("Entry 2: a rejection is a user action")
+	}
+
+	// Parts are joined; messages without a timestamp inherit the previous one
+	if got := entries[3].Message.GetTextContent(); got != "Now print\na greeting" {
+		t.Errorf("Entry 3: unexpected prompt %q", got)
+	}
+	if !entries[3].Timestamp.Equal(time.Date(2025, 1, 15, 9, 3, 0, 0, time.UTC)) {
+		t.Errorf("Entry 3: unexpected timestamp %v", entries[3].Timestamp)
+	}
+}
+
+func TestGeminiProvider(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	repo := filepath.Join(home, "repo")
+
+	// Older versions name the project directory after the path's hash,
+	// newer ones record the path
+	hashed := filepath.Join(home, ".gemini", "tmp", geminiProjectHash(repo), "chats")
+	named := filepath.Join(home, ".gemini", "tmp", "repo", "chats")
+	other := filepath.Join(home, ".gemini", "tmp", "other", "chats")
+	for _, dir := range []string{hashed, named, other} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	os.WriteFile(filepath.Join(filepath.Dir(named), ".project_root"), []byte(filepath.Join(repo, "sub")+"\n"), 0644)
+	os.WriteFile(filepath.Join(filepath.Dir(other), ".project_root"), []byte(filepath.Join(home, "other")), 0644)
+	os.WriteFile(filepath.Join(hashed, "session-1.json"), []byte(geminiChatFixture), 0644)
+	os.WriteFile(filepath.Join(named, "session-2.json"), []byte(`{"sessionId":"s2","startTime":"2025-01-15T11:00:00Z","lastUpdated":"2025-01-15T11:05:00Z","messages":[{"timestamp":"2025-01-15T11:00:00Z","type":"user","content":"hi"}]}`), 0644)
+	os.WriteFile(filepath.Join(other, "session-3.json"), []byte(geminiChatFixture), 0644)
+
+	start := time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC)
+	sessions, err := geminiProvider{}.FindSessions(repo, start, start.Add(24*time.Hour), nil)
+	if err != nil {
+		t.Fatalf("FindSessions() error: %v", err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("Expected 2 sessions, got %d", len(sessions))
+	}
+	if sessions[0].ID != "s2" || sessions[1].ID != "9f1c2d3e" {
+		t.Errorf("Unexpected sessions %s, %s", sessions[0].ID, sessions[1].ID)
+	}
+
+	content, err := ReadContent(sessions[1])
+	if err != nil {
+		t.Fatalf("ReadContent() error: %v", err)
+	}
+	entries, err := ParseTranscript(ToolGemini, content)
+	if err != nil {
+		t.Fatalf("ParseTranscript() error: %v", err)
+	}
+	if len(entries) != 4 || entries[0].SessionID != "9f1c2d3e" {
+		t.Errorf("Unexpected entries %+v", entries)
+	}
+}
@@ -0,0 +1,131 @@
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+)
+
+// importedDirName is the directory under the git directory holding sessions
+// copied from other machines
+const importedDirName = "prompt-story-imported"
+
+// importedIndexFile lists the imported sessions, whose content is stored
+// next to it as <tool>/<id>.jsonl
+const importedIndexFile = "index.json"
+
+// ImportedSession describes a session copied from another machine
+type ImportedSession struct {
+	Tool     string    `json:"tool"`
+	ID       string    `json:"id"`
+	Created  time.Time `json:"created"`
+	Modified time.Time `json:"modified"`
+	Host     string    `json:"host,omitempty"` // Machine the session was exported from
+}
+
+// ImportedDir returns the directory holding imported sessions of the
+// current repository
+func ImportedDir() (string, error) {
+	gitDir, err := git.GetGitDir()
+	if err != nil {
+		return "", fmt.Errorf("not in a git repository: %w", err)
+	}
+	return filepath.Abs(filepath.Join(gitDir, importedDirName))
+}
+
+// SaveImported stores a session copied from another machine, replacing an
+// earlier copy of it, so discovery finds it like a local one
+func SaveImported(meta ImportedSession, content []byte) (ClaudeSession, error) {
+	dir, err := ImportedDir()
+	if err != nil {
+		return ClaudeSession{}, err
+	}
+	path := filepath.Join(dir, meta.Tool, meta.ID+".jsonl")
+	if filepath.Dir(filepath.Dir(path)) != dir {
+		return ClaudeSession{}, fmt.Errorf("invalid imported session %s/%s", meta.Tool, meta.ID)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return ClaudeSession{}, fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return ClaudeSession{}, fmt.Errorf("failed to write imported session: %w", err)
+	}
+
+	index, err := readImportedIndex(dir)
+	if err != nil {
+		return ClaudeSession{}, err
+	}
+	replaced := false
+	for i, s := range index {
+		if s.Tool == meta.Tool && s.ID == meta.ID {
+			index[i], replaced = meta, true
+		}
+	}
+	if !replaced {
+		index = append(index, meta)
+	}
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return ClaudeSession{}, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, importedIndexFile), data, 0644); err != nil {
+		return ClaudeSession{}, fmt.Errorf("failed to write imported sessions index: %w", err)
+	}
+	return importedSession(dir, meta), nil
+}
+
+// FindImportedSessions returns the imported sessions overlapping the work
+// period whose tool passes enabled
+func FindImportedSessions(startWork, endWork time.Time, enabled func(tool string) bool) ([]ClaudeSession, error) {
+	dir, err := ImportedDir()
+	if err != nil {
+		return nil, nil // Not in a repository, nothing imported
+	}
+	index, err := readImportedIndex(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var sessions []ClaudeSession
+	for _, meta := range index {
+		if enabled != nil && !enabled(meta.Tool) {
+			continue
+		}
+		if meta.Modified.Before(startWork) || meta.Created.After(endWork) {
+			continue
+		}
+		sessions = append(sessions, importedSession(dir, meta))
+	}
+	return sessions, nil
+}
+
+func importedSession(dir string, meta ImportedSession) ClaudeSession {
+	return ClaudeSession{
+		ID:       meta.ID,
+		Path:     filepath.Join(dir, meta.Tool, meta.ID+".jsonl"),
+		Created:  meta.Created,
+		Modified: meta.Modified,
+		Tool:     meta.Tool,
+		Imported: true,
+	}
+}
+
+func readImportedIndex(dir string) ([]ImportedSession, error) {
+	data, err := os.ReadFile(filepath.Join(dir, importedIndexFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read imported sessions index: %w", err)
+	}
+	var index []ImportedSession
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("invalid imported sessions index: %w", err)
+	}
+	return index, nil
+}
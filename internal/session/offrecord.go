@@ -0,0 +1,94 @@
+package session
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// OffTheRecordMarker starts a prompt that must not be captured. The prompt
+// and the assistant's response to it, up to the next prompt, are left out
+// of everything read from a session, so they are never stored or counted.
+const OffTheRecordMarker = "[off-the-record]"
+
+// IsOffTheRecord reports whether a prompt opts out of capture
+func IsOffTheRecord(prompt string) bool {
+	return strings.HasPrefix(strings.TrimSpace(prompt), OffTheRecordMarker)
+}
+
+// StripOffTheRecord removes off-the-record exchanges from a transcript of
+// tool, as returned by its provider's ReadContent
+func StripOffTheRecord(tool string, content []byte) []byte {
+	if !bytes.Contains(content, []byte(OffTheRecordMarker)) {
+		return content
+	}
+	if tool == ToolCursor {
+		return stripCursorOffTheRecord(content)
+	}
+	return stripJSONLOffTheRecord(content)
+}
+
+// stripJSONLOffTheRecord drops the lines of a Claude Code JSONL transcript
+// from an off-the-record prompt up to the next user action. Custom tools
+// are stored in the same format.
+func stripJSONLOffTheRecord(content []byte) []byte {
+	var out bytes.Buffer
+	skipping := false
+	for _, line := range bytes.SplitAfter(content, []byte("\n")) {
+		var entry MessageEntry
+		if json.Unmarshal(bytes.TrimSpace(line), &entry) == nil && isUserActionEntry(entry) {
+			skipping = IsOffTheRecord(promptText(entry))
+		}
+		if !skipping {
+			out.Write(line)
+		}
+	}
+	return out.Bytes()
+}
+
+// stripCursorOffTheRecord drops the bubbles of a Cursor composer from an
+// off-the-record prompt up to the next user bubble
+func stripCursorOffTheRecord(content []byte) []byte {
+	var composer map[string]json.RawMessage
+	if err := json.Unmarshal(content, &composer); err != nil {
+		return content
+	}
+	var bubbles []json.RawMessage
+	if err := json.Unmarshal(composer["conversation"], &bubbles); err != nil {
+		return content
+	}
+
+	kept := make([]json.RawMessage, 0, len(bubbles))
+	skipping := false
+	for _, raw := range bubbles {
+		var b cursorBubble
+		if json.Unmarshal(raw, &b) == nil && b.Type == cursorBubbleUser && b.Text != "" {
+			skipping = IsOffTheRecord(b.Text)
+		}
+		if !skipping {
+			kept = append(kept, raw)
+		}
+	}
+	if len(kept) == len(bubbles) {
+		return content
+	}
+
+	conversation, err := json.Marshal(kept)
+	if err != nil {
+		return content
+	}
+	composer["conversation"] = conversation
+	stripped, err := json.Marshal(composer)
+	if err != nil {
+		return content
+	}
+	return stripped
+}
+
+// promptText returns the text of a user action entry
+func promptText(entry MessageEntry) string {
+	if entry.Message == nil {
+		return entry.Content
+	}
+	return entry.Message.GetTextContent()
+}
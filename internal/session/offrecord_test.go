@@ -0,0 +1,86 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStripOffTheRecord_ClaudeCode(t *testing.T) {
+	content := strings.Join([]string{
+		`{"type":"user","timestamp":"2025-01-15T10:00:00Z","message":{"role":"user","content":"Add a main function"}}`,
+		`{"type":"assistant","timestamp":"2025-01-15T10:01:00Z","message":{"role":"assistant","content":[{"type":"text","text":"Done."}]}}`,
+		`{"type":"user","timestamp":"2025-01-15T10:02:00Z","message":{"role":"user","content":"  [off-the-record] what does my salary look like?"}}`,
+		`{"type":"assistant","timestamp":"2025-01-15T10:03:00Z","message":{"role":"assistant","content":[{"type":"tool_use","id":"t1","name":"Read","input":{"file_path":"/tmp/salary.txt"}}]}}`,
+		`{"type":"user","timestamp":"2025-01-15T10:03:05Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"100k"}]}}`,
+		`{"type":"assistant","timestamp":"2025-01-15T10:04:00Z","message":{"role":"assistant","content":[{"type":"text","text":"It is 100k."}]}}`,
+		`{"type":"user","timestamp":"2025-01-15T10:05:00Z","message":{"role":"user","content":"Now add tests"}}`,
+		`{"type":"assistant","timestamp":"2025-01-15T10:06:00Z","message":{"role":"assistant","content":[{"type":"text","text":"Added."}]}}`,
+		``,
+	}, "\n")
+
+	stripped := string(StripOffTheRecord(ToolClaudeCode, []byte(content)))
+	for _, gone := range []string{"off-the-record", "salary", "100k"} {
+		if strings.Contains(stripped, gone) {
+			t.Errorf("stripped transcript still contains %q", gone)
+		}
+	}
+
+	entries, err := ParseMessages([]byte(stripped))
+	if err != nil {
+		t.Fatalf("ParseMessages() error: %v", err)
+	}
+	if len(entries) != 4 {
+		t.Fatalf("Expected 4 entries, got %d", len(entries))
+	}
+	if got := entries[2].Message.GetTextContent(); got != "Now add tests" {
+		t.Errorf("Entry 2: expected the next prompt, got %q", got)
+	}
+	if !strings.HasSuffix(stripped, "\n") {
+		t.Error("Expected the trailing newline to be kept")
+	}
+}
+
+func TestStripOffTheRecord_MarkerNotAtStart(t *testing.T) {
+	content := `{"type":"user","timestamp":"2025-01-15T10:00:00Z","message":{"role":"user","content":"What does [off-the-record] mean?"}}` + "\n"
+	if got := string(StripOffTheRecord(ToolClaudeCode, []byte(content))); got != content {
+		t.Errorf("Expected content unchanged, got %q", got)
+	}
+}
+
+func TestStripOffTheRecord_Cursor(t *testing.T) {
+	content := `{"composerId":"c1","createdAt":1736931600000,"conversation":[
+{"bubbleId":"b1","type":1,"text":"[off-the-record] rename my ex's folder"},
+{"bubbleId":"b2","type":2,"text":"Renamed."},
+{"bubbleId":"b3","type":1,"text":"Add a main function"},
+{"bubbleId":"b4","type":2,"text":"I'll add it."}
+]}`
+
+	entries, err := ParseCursorComposer(StripOffTheRecord(ToolCursor, []byte(content)))
+	if err != nil {
+		t.Fatalf("ParseCursorComposer() error: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(entries))
+	}
+	if got := entries[0].Message.GetTextContent(); got != "Add a main function" {
+		t.Errorf("Entry 0: expected the next prompt, got %q", got)
+	}
+}
+
+func TestReadContent_StripsOffTheRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "s1.jsonl")
+	content := `{"type":"user","timestamp":"2025-01-15T10:00:00Z","message":{"role":"user","content":"[off-the-record] hi"}}` + "\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ReadContent(ClaudeSession{ID: "s1", Path: path})
+	if err != nil {
+		t.Fatalf("ReadContent() error: %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("Expected empty transcript, got %q", got)
+	}
+}
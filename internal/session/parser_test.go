@@ -372,3 +372,64 @@ func TestParseMessages_GitBranch(t *testing.T) {
 		t.Errorf("Expected gitBranch 'feature/test', got %q", entries[0].GitBranch)
 	}
 }
+
+func TestFirstPromptInRange(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) ClaudeSession {
+		path := filepath.Join(dir, name+".jsonl")
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return ClaudeSession{ID: name, Path: path}
+	}
+
+	later := write("later", `{"type":"user","timestamp":"2025-01-15T10:00:00Z","message":{"role":"user","content":"Second task"}}
+`)
+	earlier := write("earlier", `{"type":"user","timestamp":"2025-01-15T08:00:00Z","message":{"role":"user","content":"Before the work period"}}
+{"type":"user","timestamp":"2025-01-15T09:10:00Z","isMeta":true,"message":{"role":"user","content":"Caveat"}}
+{"type":"user","timestamp":"2025-01-15T09:20:00Z","message":{"role":"user","content":[{"type":"tool_result","content":"ok"}]}}
+{"type":"user","timestamp":"2025-01-15T09:30:00Z","message":{"role":"user","content":"Add a login page"}}
+`)
+	agent := write("agent-1", `{"type":"user","timestamp":"2025-01-15T09:01:00Z","message":{"role":"user","content":"Subagent task"}}
+`)
+
+	start := time.Date(2025, 1, 15, 9, 0, 0, 0, time.UTC)
+	end := time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC)
+	got := FirstPromptInRange([]ClaudeSession{later, earlier, agent}, start, end)
+	if got != "Add a login page" {
+		t.Errorf("FirstPromptInRange() = %q, want %q", got, "Add a login page")
+	}
+	if got := FirstPromptInRange(nil, start, end); got != "" {
+		t.Errorf("FirstPromptInRange(nil) = %q, want empty", got)
+	}
+}
+
+func TestPromptsInRange(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "s1.jsonl")
+	content := `{"type":"user","timestamp":"2025-01-15T09:30:00Z","message":{"role":"user","content":"Add a login page"}}
+{"type":"user","timestamp":"2025-01-15T09:31:00Z","message":{"role":"user","content":"<command-name>/clear</command-name>"}}
+{"type":"user","timestamp":"2025-01-15T09:40:00Z","message":{"role":"user","content":"Now style it"}}
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	other := filepath.Join(dir, "s2.jsonl")
+	if err := os.WriteFile(other, []byte(`{"type":"user","timestamp":"2025-01-15T09:35:00Z","message":{"role":"user","content":"Fix the build"}}
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2025, 1, 15, 9, 0, 0, 0, time.UTC)
+	end := time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC)
+	prompts := PromptsInRange([]ClaudeSession{{ID: "s1", Path: path}, {ID: "s2", Path: other}}, start, end)
+
+	var got []string
+	for _, p := range prompts {
+		got = append(got, p.Session+": "+p.Text)
+	}
+	want := []string{"s1: Add a login page", "s2: Fix the build", "s1: Now style it"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("PromptsInRange() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,137 @@
+package session
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+)
+
+// Provider discovers and reads sessions of one LLM tool
+type Provider interface {
+	// Name is the tool ID used in notes and transcript paths (e.g. claude-code)
+	Name() string
+	// FindSessions returns sessions for repoPath that overlap the work period
+	FindSessions(repoPath string, startWork, endWork time.Time, trace *TraceContext) ([]ClaudeSession, error)
+	// ReadContent returns the transcript bytes stored for a session
+	ReadContent(s ClaudeSession) ([]byte, error)
+	// ParseContent converts stored transcript bytes into message entries
+	ParseContent(content []byte) ([]MessageEntry, error)
+}
+
+// builtinProviders lists the tools supported out of the box; Claude Code is the default
+var builtinProviders = []Provider{
+	claudeProvider{},
+	cursorProvider{},
+	aiderProvider{},
+	geminiProvider{},
+	copilotProvider{},
+}
+
+// providers is the registry: built-ins plus custom adapters loaded from config
+var providers = builtinProviders
+
+// registerProvider adds p to the registry, replacing one with the same name
+func registerProvider(p Provider) {
+	for i, existing := range providers {
+		if existing.Name() == p.Name() {
+			updated := append([]Provider(nil), providers...)
+			updated[i] = p
+			providers = updated
+			return
+		}
+	}
+	providers = append(append([]Provider(nil), providers...), p)
+}
+
+// Providers returns all registered session providers
+func Providers() []Provider {
+	return providers
+}
+
+// GetProvider returns the provider for a tool ID, falling back to Claude Code
+// for unknown or empty IDs since its JSONL format is the common denominator
+func GetProvider(tool string) Provider {
+	for _, p := range providers {
+		if p.Name() == tool {
+			return p
+		}
+	}
+	return providers[0]
+}
+
+// FindAllSessions runs discovery for every provider whose tool passes
+// enabled, including custom adapters from the repo's CustomConfigFile,
+// merging the results. A failing provider does not hide the
+// sessions found by the others; its error is returned alongside them.
+func FindAllSessions(repoPath string, startWork, endWork time.Time, enabled func(tool string) bool) ([]ClaudeSession, error) {
+	var sessions []ClaudeSession
+	var errs []error
+
+	// Custom adapters are declared per repository
+	if _, err := LoadCustomProviders(repoPath); err != nil {
+		errs = append(errs, err)
+	}
+
+	for _, p := range providers {
+		if enabled != nil && !enabled(p.Name()) {
+			continue
+		}
+		found, err := p.FindSessions(repoPath, startWork, endWork, nil)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", p.Name(), err))
+			continue
+		}
+		sessions = append(sessions, found...)
+	}
+
+	// Sessions copied from other machines, unless also present here
+	imported, err := FindImportedSessions(startWork, endWork, enabled)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	for _, s := range imported {
+		if !slices.ContainsFunc(sessions, func(local ClaudeSession) bool {
+			return local.ToolName() == s.ToolName() && local.ID == s.ID
+		}) {
+			sessions = append(sessions, s)
+		}
+	}
+	return sessions, errors.Join(errs...)
+}
+
+// ReadContent reads the transcript of a session using its tool's provider,
+// leaving out off-the-record exchanges
+func ReadContent(s ClaudeSession) ([]byte, error) {
+	if s.Imported {
+		return os.ReadFile(s.Path)
+	}
+	content, err := GetProvider(s.ToolName()).ReadContent(s)
+	if err != nil {
+		return nil, err
+	}
+	return StripOffTheRecord(s.ToolName(), content), nil
+}
+
+// ParseTranscript parses stored transcript content for the given tool
+func ParseTranscript(tool string, content []byte) ([]MessageEntry, error) {
+	return GetProvider(tool).ParseContent(content)
+}
+
+// claudeProvider reads Claude Code JSONL sessions from ~/.claude/projects
+type claudeProvider struct{}
+
+func (claudeProvider) Name() string { return ToolClaudeCode }
+
+func (claudeProvider) FindSessions(repoPath string, startWork, endWork time.Time, trace *TraceContext) ([]ClaudeSession, error) {
+	return FindSessions(repoPath, startWork, endWork, trace)
+}
+
+func (claudeProvider) ReadContent(s ClaudeSession) ([]byte, error) {
+	return ReadSessionContent(s.Path)
+}
+
+func (claudeProvider) ParseContent(content []byte) ([]MessageEntry, error) {
+	return ParseMessages(content)
+}
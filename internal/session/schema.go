@@ -0,0 +1,165 @@
+package session
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// Claude Code's transcript schema changes often. These sets list what the
+// parsers read or deliberately ignore; anything else is reported by
+// SchemaReport so parser gaps show up before data is silently dropped.
+var (
+	knownEntryTypes = stringSet(
+		"user", "assistant", "system", "summary", "file-history-snapshot",
+		"queue-operation", "tool_reject", "progress",
+	)
+
+	knownEntryFields = stringSet(
+		"type", "uuid", "parentUuid", "logicalParentUuid", "leafUuid", "sessionId",
+		"timestamp", "cwd", "gitBranch", "version", "userType", "isSidechain",
+		"isMeta", "message", "requestId", "toolUseResult", "snapshot", "messageId",
+		"isSnapshotUpdate", "operation", "content", "summary", "subtype", "level",
+		"isCompactSummary", "compactMetadata", "isApiErrorMessage", "thinkingMetadata",
+		"todos", "agentId", "slug", "isVisibleInTranscriptOnly", "toolUseID",
+		"sourceToolUseID", "error", "cause", "retryInMs", "retryAttempt", "maxRetries",
+		"data", "durationMs", "permissionMode",
+	)
+
+	knownMessageFields = stringSet(
+		"role", "content", "id", "type", "model", "stop_reason", "stop_sequence",
+		"usage", "container", "context_management",
+	)
+
+	knownBlockTypes = stringSet(
+		"text", "thinking", "redacted_thinking", "tool_use", "tool_result", "image",
+		"document", "server_tool_use", "web_search_tool_result",
+	)
+)
+
+func stringSet(items ...string) map[string]bool {
+	set := make(map[string]bool, len(items))
+	for _, s := range items {
+		set[s] = true
+	}
+	return set
+}
+
+// SchemaReport counts Claude Code transcript elements the parsers do not
+// know, keeping the first session each was seen in
+type SchemaReport struct {
+	Entries  int
+	Invalid  int            // lines that are not JSON objects
+	Unknown  map[string]int // e.g. `entry type "x"`, `field "y"`
+	Examples map[string]string
+}
+
+// NewSchemaReport creates an empty report
+func NewSchemaReport() *SchemaReport {
+	return &SchemaReport{Unknown: make(map[string]int), Examples: make(map[string]string)}
+}
+
+// Check adds the unknown elements of a JSONL transcript to the report;
+// source names it in Examples
+func (r *SchemaReport) Check(content []byte, source string) {
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		r.Entries++
+
+		var entry map[string]json.RawMessage
+		if err := json.Unmarshal(line, &entry); err != nil {
+			r.Invalid++
+			continue
+		}
+
+		var entryType string
+		json.Unmarshal(entry["type"], &entryType)
+		if !knownEntryTypes[entryType] {
+			r.add(fmt.Sprintf("entry type %q", entryType), source)
+		}
+		for field := range entry {
+			if !knownEntryFields[field] {
+				r.add(fmt.Sprintf("field %q", field), source)
+			}
+		}
+
+		var msg map[string]json.RawMessage
+		if json.Unmarshal(entry["message"], &msg) != nil {
+			continue
+		}
+		for field := range msg {
+			if !knownMessageFields[field] {
+				r.add(fmt.Sprintf("message field %q", field), source)
+			}
+		}
+		var blocks []struct {
+			Type string `json:"type"`
+		}
+		if json.Unmarshal(msg["content"], &blocks) == nil {
+			for _, b := range blocks {
+				if !knownBlockTypes[b.Type] {
+					r.add(fmt.Sprintf("content block %q", b.Type), source)
+				}
+			}
+		}
+	}
+}
+
+func (r *SchemaReport) add(key, source string) {
+	r.Unknown[key]++
+	if _, ok := r.Examples[key]; !ok {
+		r.Examples[key] = source
+	}
+}
+
+// Empty reports whether nothing unknown was found
+func (r *SchemaReport) Empty() bool {
+	return len(r.Unknown) == 0 && r.Invalid == 0
+}
+
+// Lines describes each unknown element with its count, most frequent first
+func (r *SchemaReport) Lines() []string {
+	keys := make([]string, 0, len(r.Unknown))
+	for k := range r.Unknown {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if r.Unknown[keys[i]] != r.Unknown[keys[j]] {
+			return r.Unknown[keys[i]] > r.Unknown[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+
+	lines := make([]string, 0, len(keys)+1)
+	for _, k := range keys {
+		lines = append(lines, fmt.Sprintf("unknown %s: %d entries (e.g. %s)", k, r.Unknown[k], r.Examples[k]))
+	}
+	if r.Invalid > 0 {
+		lines = append(lines, fmt.Sprintf("invalid JSON: %d lines", r.Invalid))
+	}
+	return lines
+}
+
+// CheckSessions builds a schema report over the Claude Code sessions among
+// sessions; other tools are converted by their own parsers
+func CheckSessions(sessions []ClaudeSession) *SchemaReport {
+	report := NewSchemaReport()
+	for _, s := range sessions {
+		if s.ToolName() != ToolClaudeCode {
+			continue
+		}
+		content, err := ReadContent(s)
+		if err != nil {
+			continue
+		}
+		report.Check(content, s.ID)
+	}
+	return report
+}
@@ -0,0 +1,54 @@
+package session
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSchemaReport_Check(t *testing.T) {
+	content := `{"type":"user","uuid":"1","timestamp":"2025-01-01T10:00:00Z","message":{"role":"user","content":"hi"}}
+{"type":"assistant","uuid":"2","timestamp":"2025-01-01T10:00:01Z","message":{"role":"assistant","content":[{"type":"text","text":"ok"},{"type":"hologram"}],"mood":"good"}}
+{"type":"teleport","uuid":"3","newField":1}
+{"type":"teleport","uuid":"4"}
+not json
+`
+	r := NewSchemaReport()
+	r.Check([]byte(content), "s1")
+
+	if r.Entries != 5 || r.Invalid != 1 {
+		t.Errorf("Entries = %d, Invalid = %d, want 5 and 1", r.Entries, r.Invalid)
+	}
+	want := map[string]int{
+		`entry type "teleport"`:    2,
+		`field "newField"`:         1,
+		`message field "mood"`:     1,
+		`content block "hologram"`: 1,
+	}
+	if len(r.Unknown) != len(want) {
+		t.Errorf("Unknown = %v, want %v", r.Unknown, want)
+	}
+	for k, n := range want {
+		if r.Unknown[k] != n {
+			t.Errorf("Unknown[%s] = %d, want %d", k, r.Unknown[k], n)
+		}
+		if r.Examples[k] != "s1" {
+			t.Errorf("Examples[%s] = %q, want s1", k, r.Examples[k])
+		}
+	}
+
+	lines := r.Lines()
+	if len(lines) != 5 || !strings.Contains(lines[0], "teleport") || !strings.HasPrefix(lines[4], "invalid JSON") {
+		t.Errorf("Lines() = %q", lines)
+	}
+}
+
+func TestSchemaReport_KnownSchemaIsEmpty(t *testing.T) {
+	content := `{"type":"file-history-snapshot","messageId":"1","snapshot":{},"isSnapshotUpdate":false}
+{"type":"user","sessionId":"s","cwd":"/x","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t","content":"ok"}]},"toolUseResult":{}}
+`
+	r := NewSchemaReport()
+	r.Check([]byte(content), "s1")
+	if !r.Empty() {
+		t.Errorf("expected empty report, got %q", r.Lines())
+	}
+}
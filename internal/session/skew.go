@@ -0,0 +1,58 @@
+package session
+
+import (
+	"time"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/config"
+)
+
+// ClockSkew returns the configured clock skew tolerance, see
+// config.KeyClockSkew. Zero, the default, leaves the work period as is.
+func ClockSkew() time.Duration {
+	value := config.Get(config.KeyClockSkew)
+	if value == "" {
+		return 0
+	}
+	skew, err := time.ParseDuration(value)
+	if err != nil || skew < 0 {
+		return 0
+	}
+	return skew
+}
+
+// WidenWorkPeriod extends the work period by skew on both ends, so entries
+// stamped by a clock that is off by up to skew still fall in it
+func WidenWorkPeriod(startWork, endWork time.Time, skew time.Duration) (time.Time, time.Time) {
+	if skew <= 0 {
+		return startWork, endWork
+	}
+	if !startWork.IsZero() {
+		startWork = startWork.Add(-skew)
+	}
+	return startWork, endWork.Add(skew)
+}
+
+// MonotonicTimestamps makes the timestamps of entries non-decreasing in
+// transcript order, which tools append in. An entry stamped earlier than
+// the one before it, e.g. after the clock was set back, gets that entry's
+// timestamp instead of being sorted before it or falling out of the work
+// period; the recorded timestamp is kept in Recorded. It returns how many
+// timestamps went back.
+func MonotonicTimestamps(entries []MessageEntry) int {
+	regressions := 0
+	var last time.Time
+	for i := range entries {
+		ts := entries[i].Timestamp
+		if ts.IsZero() {
+			continue
+		}
+		if ts.Before(last) {
+			entries[i].Recorded = ts
+			entries[i].Timestamp = last
+			regressions++
+			continue
+		}
+		last = ts
+	}
+	return regressions
+}
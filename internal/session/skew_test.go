@@ -0,0 +1,49 @@
+package session
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMonotonicTimestamps(t *testing.T) {
+	base := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+	entries := []MessageEntry{
+		{Type: "user", Timestamp: base},
+		{Type: "assistant", Timestamp: base.Add(2 * time.Minute)},
+		{Type: "file-history-snapshot"},
+		{Type: "user", Timestamp: base.Add(-5 * time.Minute)}, // Clock set back
+		{Type: "assistant", Timestamp: base.Add(time.Minute)},
+		{Type: "user", Timestamp: base.Add(3 * time.Minute)},
+	}
+
+	if got := MonotonicTimestamps(entries); got != 2 {
+		t.Errorf("MonotonicTimestamps() = %d regressions, want 2", got)
+	}
+	want := []time.Time{base, base.Add(2 * time.Minute), {}, base.Add(2 * time.Minute), base.Add(2 * time.Minute), base.Add(3 * time.Minute)}
+	for i, e := range entries {
+		if !e.Timestamp.Equal(want[i]) {
+			t.Errorf("entry %d timestamp = %s, want %s", i, e.Timestamp, want[i])
+		}
+	}
+	if !entries[3].Recorded.Equal(base.Add(-5*time.Minute)) || !entries[1].Recorded.IsZero() {
+		t.Errorf("Recorded = %s, %s, want the regressed entry's own timestamp only", entries[3].Recorded, entries[1].Recorded)
+	}
+}
+
+func TestWidenWorkPeriod(t *testing.T) {
+	start := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+
+	s, e := WidenWorkPeriod(start, end, 0)
+	if !s.Equal(start) || !e.Equal(end) {
+		t.Errorf("WidenWorkPeriod(0) = %s - %s, want unchanged", s, e)
+	}
+	s, e = WidenWorkPeriod(start, end, 2*time.Minute)
+	if !s.Equal(start.Add(-2*time.Minute)) || !e.Equal(end.Add(2*time.Minute)) {
+		t.Errorf("WidenWorkPeriod(2m) = %s - %s", s, e)
+	}
+	// A zero start means no bound and stays zero
+	if s, _ = WidenWorkPeriod(time.Time{}, end, time.Minute); !s.IsZero() {
+		t.Errorf("WidenWorkPeriod() moved a zero start to %s", s)
+	}
+}
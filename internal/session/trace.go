@@ -1,6 +1,10 @@
 package session
 
-import "time"
+import (
+	"time"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+)
 
 // TraceContext captures decisions made during session discovery and filtering.
 // When nil is passed to functions, they operate normally without tracing overhead.
@@ -15,19 +19,24 @@ type TraceContext struct {
 	CandidateDirs  []string // All candidate directories checked
 	SkippedByMtime int      // Files skipped due to mtime pre-filter
 
+	// RepoState is what the repository is in the middle of; capture is
+	// skipped while it replays or bisects commits
+	RepoState git.RepoState
+
 	WorkPeriod WorkPeriodTrace
 	Sessions   []SessionTrace
 }
 
 // WorkPeriodTrace explains how the work period was calculated
 type WorkPeriodTrace struct {
-	IsAmend             bool
-	Ref                 string
-	PrevCommitTimestamp time.Time
-	BranchSwitchTime    time.Time
-	CalculatedStart     time.Time
-	EndWork             time.Time
-	Explanation         string
+	IsAmend         bool
+	Ref             string
+	Candidates      []git.WorkStartCandidate // Starts proposed by each strategy
+	CalculatedStart time.Time
+	Margin          time.Duration // Lead of the chosen start over the runner-up
+	EndWork         time.Time
+	ClockSkew       time.Duration // Tolerance the period is widened by on both ends
+	Explanation     string
 }
 
 // SessionTrace explains the decision for a single session
@@ -46,6 +55,10 @@ type SessionTrace struct {
 	UserMsgCount  int
 	UserMsgReason string
 
+	// TimestampRegressions counts entries stamped earlier than the entry
+	// before them, a sign of clock skew; they keep their transcript order
+	TimestampRegressions int
+
 	// Final decision
 	Included    bool
 	FinalReason string
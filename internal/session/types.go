@@ -5,12 +5,31 @@ import (
 	"time"
 )
 
-// ClaudeSession represents a discovered Claude Code session
+// Tool IDs of the built-in providers
+const (
+	ToolClaudeCode  = "claude-code"
+	ToolCursor      = "cursor"
+	ToolAider       = "aider"
+	ToolGemini      = "gemini-cli"
+	ToolCopilotChat = "copilot-chat"
+)
+
+// ClaudeSession represents a discovered LLM session (Claude Code unless Tool says otherwise)
 type ClaudeSession struct {
 	ID       string    // Session UUID (filename without .jsonl)
-	Path     string    // Full path to JSONL file
+	Path     string    // Full path to JSONL file (or the database holding it)
 	Created  time.Time // First timestamp in file
 	Modified time.Time // Last timestamp in file
+	Tool     string    // Tool ID; empty means claude-code
+	Imported bool      // Copied from another machine; Path holds its stored content
+}
+
+// ToolName returns the tool ID of the session
+func (s ClaudeSession) ToolName() string {
+	if s.Tool == "" {
+		return ToolClaudeCode
+	}
+	return s.Tool
 }
 
 // MessageEntry represents a single JSONL line from Claude Code
@@ -19,6 +38,7 @@ type MessageEntry struct {
 	SessionID     string         `json:"sessionId"`
 	Timestamp     time.Time      `json:"timestamp"`
 	GitBranch     string         `json:"gitBranch"`
+	Cwd           string         `json:"cwd,omitempty"`
 	IsMeta        bool           `json:"isMeta"` // System-injected message (e.g., caveat warnings)
 	Snapshot      *Snapshot      `json:"snapshot,omitempty"`
 	Message       *Message       `json:"message,omitempty"`
@@ -26,6 +46,9 @@ type MessageEntry struct {
 	// Queue operation fields (for messages typed while Claude is working)
 	Operation string `json:"operation,omitempty"` // "enqueue", "remove"
 	Content   string `json:"content,omitempty"`   // The queued message content
+	// Timestamp as recorded in the transcript, set when MonotonicTimestamps
+	// changed Timestamp; it identifies the entry to redact
+	Recorded time.Time `json:"-"`
 }
 
 // ToolUseResult contains structured answer data from AskUserQuestion
@@ -0,0 +1,34 @@
+package session
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+)
+
+func init() {
+	git.RegisterWorkStartStrategy(git.WorkStartStrategy{Name: git.StrategyFirstPrompt, Find: findFirstPrompt})
+}
+
+// findFirstPrompt proposes the first user prompt after the previous commit
+// in any session of the repository
+func findFirstPrompt(ref string) (time.Time, string, error) {
+	repoRoot, err := git.GetRepoRoot()
+	if err != nil {
+		return time.Time{}, "", nil // No repository, nothing to propose
+	}
+	prev, err := git.GetPreviousCommitTimestamp(ref)
+	if err != nil {
+		return time.Time{}, "", err
+	}
+
+	now := time.Now().UTC()
+	sessions, err := FindAllSessions(repoRoot, prev, now, nil)
+	prompts := PromptsInRange(sessions, prev, now)
+	if len(prompts) == 0 {
+		return time.Time{}, "", err
+	}
+	first := prompts[0]
+	return first.Time, fmt.Sprintf("first prompt in %s session %s", first.Tool, first.Session), nil
+}
@@ -0,0 +1,158 @@
+package setup
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/config"
+	"github.com/QuesmaOrg/git-prompt-story/internal/hooks"
+)
+
+// Options configures the init wizard
+type Options struct {
+	Global bool      // Write global git config and install global hooks
+	Input  io.Reader // Where answers are read from, os.Stdin when nil
+}
+
+// captureExplanation is shown before any question is asked
+const captureExplanation = `git-prompt-story records the LLM sessions behind your commits.
+
+On every commit it will:
+  • find coding-assistant sessions that were active for this repository
+    since your previous commit
+  • store their transcripts as git notes (refs/notes/prompt-story*)
+  • add a "Prompt-Story:" line to the commit message
+
+Transcripts contain your prompts, the assistant's replies and tool calls
+(file edits, shell commands and their output). Notes stay local until they
+are pushed, and anything stored can be redacted later with
+'git-prompt-story show'.
+`
+
+// supportedTools lists the capture sources offered in the wizard
+var supportedTools = []struct {
+	ID   string
+	Name string
+}{
+	{ID: "claude-code", Name: "Claude Code"},
+	{ID: "cursor", Name: "Cursor"},
+	{ID: "aider", Name: "Aider"},
+	{ID: "gemini-cli", Name: "Gemini CLI"},
+	{ID: "copilot-chat", Name: "Copilot Chat"},
+}
+
+// Run walks the user through first-time setup, writes the resulting
+// prompt-story.* git config and installs the hooks
+func Run(opts Options) error {
+	if opts.Input == nil {
+		opts.Input = os.Stdin
+	}
+	reader := bufio.NewReader(opts.Input)
+
+	fmt.Print(captureExplanation)
+	fmt.Println()
+	if !askYesNo(reader, "Enable prompt capture?", true) {
+		fmt.Println("Nothing changed. Run 'git-prompt-story init' again at any time.")
+		return nil
+	}
+	fmt.Println()
+
+	// Scrubbing level
+	fmt.Println("PII scrubbing replaces secrets, emails, home-directory paths and")
+	fmt.Println("file contents read by tools with placeholders before storing.")
+	scrubLevel := askChoice(reader, "Scrubbing level", []string{"default", "off"}, 0)
+	fmt.Println()
+
+	// Tool opt-ins
+	var tools []string
+	for _, t := range supportedTools {
+		if askYesNo(reader, fmt.Sprintf("Capture %s sessions?", t.Name), true) {
+			tools = append(tools, t.ID)
+		}
+	}
+	fmt.Println()
+
+	// Notes push behavior
+	fmt.Println("Notes are not pushed by 'git push' unless a pre-push hook syncs them.")
+	autoPush := askYesNo(reader, "Push notes automatically when you push commits?", true)
+	fmt.Println()
+
+	toolsValue := strings.Join(tools, ",")
+	if toolsValue == "" {
+		toolsValue = "none"
+	}
+
+	settings := []struct{ key, value string }{
+		{config.KeyScrub, strconv.FormatBool(scrubLevel == "default")},
+		{config.KeyTools, toolsValue},
+		{config.KeyAutoPush, strconv.FormatBool(autoPush)},
+		{config.KeyInitialized, "true"},
+	}
+	for _, s := range settings {
+		if err := config.Set(s.key, s.value, opts.Global); err != nil {
+			return err
+		}
+	}
+
+	if err := hooks.InstallHooks(hooks.InstallOptions{Global: opts.Global, AutoPush: autoPush}); err != nil {
+		return err
+	}
+
+	fmt.Println()
+	fmt.Println("Setup complete:")
+	fmt.Printf("  scrubbing: %s\n", scrubLevel)
+	fmt.Printf("  tools:     %s\n", toolsValue)
+	fmt.Printf("  auto-push: %v\n", autoPush)
+	fmt.Println()
+	fmt.Println("Settings are stored in git config under prompt-story.*")
+	return nil
+}
+
+// askYesNo prompts the user with a yes/no question and returns the answer
+func askYesNo(reader *bufio.Reader, question string, defaultYes bool) bool {
+	prompt := question
+	if defaultYes {
+		prompt += " [Y/n]: "
+	} else {
+		prompt += " [y/N]: "
+	}
+
+	fmt.Print(prompt)
+	input, err := reader.ReadString('\n')
+	if err != nil {
+		return defaultYes
+	}
+
+	input = strings.TrimSpace(strings.ToLower(input))
+	if input == "" {
+		return defaultYes
+	}
+
+	return input == "y" || input == "yes"
+}
+
+// askChoice prompts for one of choices and returns it (default on empty input)
+func askChoice(reader *bufio.Reader, question string, choices []string, def int) string {
+	for {
+		fmt.Printf("%s [%s] (default %s): ", question, strings.Join(choices, "/"), choices[def])
+		input, err := reader.ReadString('\n')
+		if err != nil {
+			return choices[def]
+		}
+
+		input = strings.TrimSpace(strings.ToLower(input))
+		if input == "" {
+			return choices[def]
+		}
+		for _, c := range choices {
+			if input == c {
+				return c
+			}
+		}
+		fmt.Printf("Please answer one of: %s\n", strings.Join(choices, ", "))
+	}
+}
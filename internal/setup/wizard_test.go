@@ -0,0 +1,90 @@
+package setup
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/config"
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+)
+
+// initTestRepo makes a repository in a temporary directory the test runs
+// in and returns its git directory
+func initTestRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Chdir(t.TempDir())
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	if _, err := git.RunGit("init", "-q", "-b", "main"); err != nil {
+		t.Fatal(err)
+	}
+	gitDir, err := git.GetGitDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return gitDir
+}
+
+func TestRun(t *testing.T) {
+	gitDir := initTestRepo(t)
+
+	// Enable, scrubbing off, every tool but Cursor, no auto-push
+	answers := "\n" + "off\n" + "\n" + "n\n" + "\n" + "\n" + "\n" + "n\n"
+	if err := Run(Options{Input: strings.NewReader(answers)}); err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+
+	for key, want := range map[string]string{
+		config.KeyInitialized: "true",
+		config.KeyScrub:       "false",
+		config.KeyTools:       "claude-code,aider,gemini-cli,copilot-chat",
+		config.KeyAutoPush:    "false",
+	} {
+		if got := config.Get(key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+	for _, hook := range []string{"prepare-commit-msg", "post-commit", "post-rewrite"} {
+		if _, err := os.Stat(filepath.Join(gitDir, "hooks", hook)); err != nil {
+			t.Errorf("hook %s not installed: %v", hook, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(gitDir, "hooks", "pre-push")); err == nil {
+		t.Error("pre-push hook installed without auto-push")
+	}
+}
+
+func TestRun_Declined(t *testing.T) {
+	gitDir := initTestRepo(t)
+
+	if err := Run(Options{Input: strings.NewReader("n\n")}); err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if config.IsInitialized() {
+		t.Error("declining capture marked the repository initialized")
+	}
+	if _, err := os.Stat(filepath.Join(gitDir, "hooks", "prepare-commit-msg")); err == nil {
+		t.Error("declining capture installed hooks")
+	}
+}
+
+func TestRun_NoTools(t *testing.T) {
+	initTestRepo(t)
+
+	// Answers run out after the tools: auto-push keeps its default
+	answers := "y\n" + "\n" + "n\n" + "n\n" + "n\n" + "n\n" + "n\n"
+	if err := Run(Options{Input: strings.NewReader(answers)}); err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if got := config.Get(config.KeyTools); got != "none" {
+		t.Errorf("%s = %q, want none", config.KeyTools, got)
+	}
+	if got := config.Get(config.KeyAutoPush); got != "true" {
+		t.Errorf("%s = %q, want the default true", config.KeyAutoPush, got)
+	}
+}
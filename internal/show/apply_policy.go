@@ -0,0 +1,388 @@
+package show
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+	"github.com/QuesmaOrg/git-prompt-story/internal/note"
+	"github.com/QuesmaOrg/git-prompt-story/internal/policy"
+	"github.com/QuesmaOrg/git-prompt-story/internal/session"
+)
+
+const codePlaceholder = "<CODE REDACTED BY POLICY>"
+
+// RedactionHandler rewrites one parsed transcript entry in place and
+// reports whether it changed anything. Handlers are named by the handler
+// field of redaction rules in the policy file.
+type RedactionHandler func(rule policy.RedactionRule, entry map[string]interface{}) bool
+
+var redactionHandlers = map[string]RedactionHandler{}
+
+// RegisterRedactionHandler makes a handler available to redaction rules.
+// It rewrites JSONL entries; rules using it fail on Cursor transcripts.
+func RegisterRedactionHandler(name string, h RedactionHandler) {
+	redactionHandlers[name] = h
+}
+
+// cursorRedactionHandlers rewrite one bubble of a Cursor composer, stored
+// in its own format rather than as JSONL entries. Rules with a handler
+// missing here fail on Cursor transcripts instead of skipping them.
+var cursorRedactionHandlers = map[string]RedactionHandler{}
+
+func init() {
+	RegisterRedactionHandler("redact", redactAll)
+	RegisterRedactionHandler("blur-code", blurCode)
+	cursorRedactionHandlers["redact"] = redactCursorBubble
+	cursorRedactionHandlers["blur-code"] = blurCursorBubble
+}
+
+// PolicyChange is a transcript rewritten by redaction rules
+type PolicyChange struct {
+	Path    string
+	Rules   []string // labels of the rules that changed it
+	Entries int      // number of entries changed
+}
+
+// ApplyPolicy runs rules over the transcripts of noted commits in
+// rangeSpec, rewriting them through the same path as manual redaction so
+// each change is sealed and logged in the note. Held transcripts are
+// refused unless overrideHold is set. With dryRun nothing is written.
+func ApplyPolicy(rangeSpec string, rules []policy.RedactionRule, dryRun, overrideHold bool) ([]PolicyChange, error) {
+	ages := make([]time.Duration, len(rules))
+	for i, r := range rules {
+		if _, ok := redactionHandlers[r.Handler]; !ok {
+			return nil, fmt.Errorf("redaction rule %q: unknown handler %q", r.Label(), r.Handler)
+		}
+		age, err := r.Age()
+		if err != nil {
+			return nil, err
+		}
+		ages[i] = age
+	}
+
+	commits, err := note.ListCommits(rangeSpec, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	// A transcript can be referenced by several commits; rewrite it once
+	seen := make(map[string]bool)
+	var changes []PolicyChange
+	contents := make(map[string][]byte)
+	now := time.Now()
+	for _, c := range commits {
+		for _, sess := range c.Note.Sessions {
+			path := sess.TranscriptPath()
+			if seen[path] || sess.Expired != nil {
+				continue
+			}
+			seen[path] = true
+
+			content, err := git.GetBlobContent(note.TranscriptsRef, path)
+			if err != nil {
+				continue // Missing or quarantined transcript
+			}
+			newContent, change, err := applyRules(content, sess, rules, ages, now)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+			if change.Entries == 0 {
+				continue
+			}
+			change.Path = path
+			changes = append(changes, change)
+			contents[path] = newContent
+		}
+	}
+
+	if dryRun || len(changes) == 0 {
+		return changes, nil
+	}
+
+	paths := make([]string, len(changes))
+	for i, ch := range changes {
+		paths[i] = ch.Path
+	}
+	if err := note.CheckHold(paths, "apply-policy", overrideHold); err != nil {
+		return nil, err
+	}
+
+	for _, ch := range changes {
+		if err := updateTranscriptInGit(ch.Path, contents[ch.Path]); err != nil {
+			return nil, fmt.Errorf("failed to update %s: %w", ch.Path, err)
+		}
+		if err := note.RecordRedaction(ch.Path, "policy:"+strings.Join(ch.Rules, ","), "", contents[ch.Path]); err != nil {
+			return nil, fmt.Errorf("failed to record redaction: %w", err)
+		}
+	}
+	return changes, nil
+}
+
+// applyRules runs every matching rule over each JSONL entry of content.
+// Lines that are not JSON objects are kept as-is. Cursor composers are
+// rewritten bubble by bubble.
+func applyRules(content []byte, sess note.SessionEntry, rules []policy.RedactionRule, ages []time.Duration, now time.Time) ([]byte, PolicyChange, error) {
+	if sess.Tool == session.ToolCursor {
+		return applyCursorRules(content, sess, rules, ages, now)
+	}
+	var change PolicyChange
+	var result bytes.Buffer
+	applied := make(map[string]bool)
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var entry map[string]interface{}
+		if err := json.Unmarshal(line, &entry); err != nil {
+			result.Write(line)
+			result.WriteByte('\n')
+			continue
+		}
+
+		entryTime := sess.Modified
+		if ts, ok := entry["timestamp"].(string); ok {
+			if t, err := time.Parse(time.RFC3339, ts); err == nil {
+				entryTime = t
+			}
+		}
+
+		changed := false
+		for i, r := range rules {
+			if r.Tool != "" && !strings.EqualFold(r.Tool, sess.Tool) {
+				continue
+			}
+			if ages[i] > 0 && now.Sub(entryTime) < ages[i] {
+				continue
+			}
+			if redactionHandlers[r.Handler](r, entry) {
+				changed = true
+				applied[r.Label()] = true
+			}
+		}
+
+		if !changed {
+			result.Write(line)
+			result.WriteByte('\n')
+			continue
+		}
+		change.Entries++
+		newLine, err := json.Marshal(entry)
+		if err != nil {
+			return nil, change, fmt.Errorf("failed to marshal redacted entry: %w", err)
+		}
+		result.Write(newLine)
+		result.WriteByte('\n')
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, change, err
+	}
+
+	for label := range applied {
+		change.Rules = append(change.Rules, label)
+	}
+	sort.Strings(change.Rules)
+	return result.Bytes(), change, nil
+}
+
+// applyCursorRules runs every matching rule over each bubble of a Cursor
+// composer
+func applyCursorRules(content []byte, sess note.SessionEntry, rules []policy.RedactionRule, ages []time.Duration, now time.Time) ([]byte, PolicyChange, error) {
+	var change PolicyChange
+	var matching []int
+	for i, r := range rules {
+		if r.Tool != "" && !strings.EqualFold(r.Tool, sess.Tool) {
+			continue
+		}
+		if cursorRedactionHandlers[r.Handler] == nil {
+			return nil, change, fmt.Errorf("redaction rule %q: handler %q does not support %s transcripts", r.Label(), r.Handler, sess.Tool)
+		}
+		matching = append(matching, i)
+	}
+	if len(matching) == 0 {
+		return content, change, nil
+	}
+
+	applied := make(map[string]bool)
+	out, n, err := session.RewriteCursorBubbles(content, func(bubble map[string]interface{}, created time.Time) bool {
+		if created.IsZero() {
+			created = sess.Modified
+		}
+		changed := false
+		for _, i := range matching {
+			r := rules[i]
+			if ages[i] > 0 && now.Sub(created) < ages[i] {
+				continue
+			}
+			if cursorRedactionHandlers[r.Handler](r, bubble) {
+				changed = true
+				applied[r.Label()] = true
+			}
+		}
+		return changed
+	})
+	if err != nil {
+		return nil, change, err
+	}
+	change.Entries = n
+	for label := range applied {
+		change.Rules = append(change.Rules, label)
+	}
+	sort.Strings(change.Rules)
+	return out, change, nil
+}
+
+// redactAll replaces the entry's message content, like a manual redaction
+func redactAll(_ policy.RedactionRule, entry map[string]interface{}) bool {
+	changed := false
+	if msg, ok := entry["message"].(map[string]interface{}); ok {
+		if c, has := msg["content"]; has && !isRedactedPlaceholder(c) {
+			changed = true
+		}
+	}
+	if c, has := entry["content"]; has && !isRedactedPlaceholder(c) {
+		changed = true
+	}
+	if changed {
+		redactEntry(entry, RedactedPlaceholder)
+	}
+	return changed
+}
+
+// redactCursorBubble replaces the text, tool arguments and tool result of
+// a Cursor bubble, like redactAll
+func redactCursorBubble(_ policy.RedactionRule, bubble map[string]interface{}) bool {
+	changed := false
+	if text, ok := bubble["text"].(string); ok && text != "" && !isRedactedPlaceholder(text) {
+		bubble["text"] = RedactedPlaceholder
+		changed = true
+	}
+	if tool, ok := bubble["toolFormerData"].(map[string]interface{}); ok {
+		if args, ok := tool["rawArgs"].(string); ok && args != "{}" {
+			tool["rawArgs"] = "{}"
+			changed = true
+		}
+		if result, ok := tool["result"].(string); ok && result != "" && !isRedactedPlaceholder(result) {
+			tool["result"] = RedactedPlaceholder
+			changed = true
+		}
+	}
+	return changed
+}
+
+// cursorCodeArgs are Cursor tool arguments that carry file contents
+var cursorCodeArgs = []string{"code_edit", "contents", "new_string", "old_string"}
+
+// blurCursorBubble replaces code in a Cursor bubble, like blurCode: fenced
+// blocks in its text, file contents in its tool arguments, and the tool
+// result
+func blurCursorBubble(_ policy.RedactionRule, bubble map[string]interface{}) bool {
+	changed := false
+	if text, ok := bubble["text"].(string); ok {
+		if out, ok := blurContent(text); ok {
+			bubble["text"] = out
+			changed = true
+		}
+	}
+	tool, ok := bubble["toolFormerData"].(map[string]interface{})
+	if !ok {
+		return changed
+	}
+	if raw, ok := tool["rawArgs"].(string); ok {
+		var args map[string]interface{}
+		if json.Unmarshal([]byte(raw), &args) == nil {
+			blurred := false
+			for _, key := range cursorCodeArgs {
+				if s, ok := args[key].(string); ok && s != codePlaceholder {
+					args[key] = codePlaceholder
+					blurred = true
+				}
+			}
+			if data, err := json.Marshal(args); blurred && err == nil {
+				tool["rawArgs"] = string(data)
+				changed = true
+			}
+		}
+	}
+	if result, ok := tool["result"].(string); ok && result != "" && result != codePlaceholder {
+		tool["result"] = codePlaceholder
+		changed = true
+	}
+	return changed
+}
+
+// fencedCode matches a markdown fenced code block
+var fencedCode = regexp.MustCompile("(?s)```[^\n]*\n.*?```")
+
+// codeInputKeys are tool_use input fields that carry file contents
+var codeInputKeys = []string{"content", "new_string", "old_string", "code", "patch"}
+
+// blurCode replaces code in an entry (fenced blocks in text, file contents
+// in tool calls, and tool results) while keeping the surrounding prose
+func blurCode(_ policy.RedactionRule, entry map[string]interface{}) bool {
+	changed := false
+	if msg, ok := entry["message"].(map[string]interface{}); ok {
+		if c, ok := blurContent(msg["content"]); ok {
+			msg["content"] = c
+			changed = true
+		}
+	}
+	if r, has := entry["toolUseResult"]; has && r != codePlaceholder {
+		entry["toolUseResult"] = codePlaceholder
+		changed = true
+	}
+	return changed
+}
+
+// blurContent blurs message content, a string or a list of content blocks
+func blurContent(v interface{}) (interface{}, bool) {
+	switch c := v.(type) {
+	case string:
+		out := fencedCode.ReplaceAllString(c, "```\n"+codePlaceholder+"\n```")
+		return out, out != c
+	case []interface{}:
+		changed := false
+		for _, item := range c {
+			block, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			switch block["type"] {
+			case "text":
+				if out, ok := blurContent(block["text"]); ok {
+					block["text"] = out
+					changed = true
+				}
+			case "tool_use":
+				input, ok := block["input"].(map[string]interface{})
+				if !ok {
+					continue
+				}
+				for _, key := range codeInputKeys {
+					if s, ok := input[key].(string); ok && s != codePlaceholder {
+						input[key] = codePlaceholder
+						changed = true
+					}
+				}
+			case "tool_result":
+				if block["content"] != codePlaceholder {
+					block["content"] = codePlaceholder
+					changed = true
+				}
+			}
+		}
+		return c, changed
+	}
+	return v, false
+}
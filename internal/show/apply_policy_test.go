@@ -0,0 +1,138 @@
+package show
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/note"
+	"github.com/QuesmaOrg/git-prompt-story/internal/policy"
+)
+
+const policyTranscript = `{"timestamp":"2025-01-01T10:00:00Z","type":"user","message":{"content":"Fix this:\n` + "```go\\nfunc main() {}\\n```" + `\nplease"}}
+{"timestamp":"2025-01-01T10:01:00Z","type":"assistant","message":{"content":[{"type":"text","text":"Done"},{"type":"tool_use","name":"Write","input":{"file_path":"main.go","content":"package main"}}]}}
+not json
+{"timestamp":"2025-06-01T10:00:00Z","type":"user","message":{"content":"Recent ` + "```\\nsecret()\\n```" + `"}}
+`
+
+func TestApplyRules_BlurCodeOlderThan(t *testing.T) {
+	rules := []policy.RedactionRule{{Name: "old-code", Handler: "blur-code"}}
+	ages := []time.Duration{90 * 24 * time.Hour}
+	now := mustParseTime("2025-06-15T00:00:00Z")
+	sess := note.SessionEntry{Tool: "claude-code"}
+
+	out, change, err := applyRules([]byte(policyTranscript), sess, rules, ages, now)
+	if err != nil {
+		t.Fatalf("applyRules() error = %v", err)
+	}
+	if change.Entries != 2 {
+		t.Errorf("Entries = %d, want 2", change.Entries)
+	}
+	if len(change.Rules) != 1 || change.Rules[0] != "old-code" {
+		t.Errorf("Rules = %v, want [old-code]", change.Rules)
+	}
+
+	s := string(out)
+	for _, gone := range []string{"func main", "package main"} {
+		if strings.Contains(s, gone) {
+			t.Errorf("old code %q not blurred:\n%s", gone, s)
+		}
+	}
+	for _, kept := range []string{"Fix this", "please", "main.go", "not json", "secret()"} {
+		if !strings.Contains(s, kept) {
+			t.Errorf("expected %q to be kept:\n%s", kept, s)
+		}
+	}
+
+	// Running again changes nothing
+	_, again, err := applyRules(out, sess, rules, ages, now)
+	if err != nil {
+		t.Fatalf("applyRules() second run error = %v", err)
+	}
+	if again.Entries != 0 {
+		t.Errorf("second run changed %d entries, want 0", again.Entries)
+	}
+}
+
+func TestApplyRules_RedactTool(t *testing.T) {
+	rules := []policy.RedactionRule{{Handler: "redact", Tool: "my-agent"}}
+	ages := []time.Duration{0}
+	now := mustParseTime("2025-06-15T00:00:00Z")
+
+	_, change, err := applyRules([]byte(policyTranscript), note.SessionEntry{Tool: "claude-code"}, rules, ages, now)
+	if err != nil {
+		t.Fatalf("applyRules() error = %v", err)
+	}
+	if change.Entries != 0 {
+		t.Errorf("rule for another tool changed %d entries", change.Entries)
+	}
+
+	out, change, err := applyRules([]byte(policyTranscript), note.SessionEntry{Tool: "my-agent"}, rules, ages, now)
+	if err != nil {
+		t.Fatalf("applyRules() error = %v", err)
+	}
+	if change.Entries != 3 || change.Rules[0] != "redact" {
+		t.Errorf("change = %+v, want 3 entries by redact", change)
+	}
+	if strings.Contains(string(out), "Fix this") || !containsRedacted(string(out)) {
+		t.Errorf("expected every message redacted:\n%s", out)
+	}
+}
+
+func TestApplyPolicy_UnknownHandler(t *testing.T) {
+	_, err := ApplyPolicy("HEAD", []policy.RedactionRule{{Name: "x", Handler: "shred"}}, true, false)
+	if err == nil || !strings.Contains(err.Error(), `unknown handler "shred"`) {
+		t.Errorf("ApplyPolicy() error = %v, want unknown handler", err)
+	}
+}
+
+const policyCursorComposer = `{"composerId":"c1","createdAt":1736931600000,"conversation":[
+{"bubbleId":"b1","type":1,"text":"Add a main function","createdAt":"2025-01-15T09:05:00Z"},
+{"bubbleId":"b2","type":2,"text":"Here:\n` + "```go\\nfunc main() {}\\n```" + `","timingInfo":{"clientStartTime":1736931960000}},
+{"bubbleId":"b3","type":2,"toolFormerData":{"name":"edit_file","rawArgs":"{\"target_file\":\"/repo/main.go\",\"code_edit\":\"func main() {}\"}","result":"ok"}}
+]}`
+
+func TestApplyRules_Cursor(t *testing.T) {
+	now := mustParseTime("2025-06-15T00:00:00Z")
+	sess := note.SessionEntry{Tool: "cursor"}
+
+	rules := []policy.RedactionRule{{Name: "no-cursor", Handler: "redact", Tool: "cursor"}}
+	out, change, err := applyRules([]byte(policyCursorComposer), sess, rules, []time.Duration{0}, now)
+	if err != nil {
+		t.Fatalf("applyRules() error = %v", err)
+	}
+	if change.Entries != 3 || len(change.Rules) != 1 || change.Rules[0] != "no-cursor" {
+		t.Errorf("change = %+v, want 3 bubbles by no-cursor", change)
+	}
+	for _, gone := range []string{"Add a main function", "func main", "main.go"} {
+		if strings.Contains(string(out), gone) {
+			t.Errorf("%q not redacted:\n%s", gone, out)
+		}
+	}
+	if !strings.Contains(string(out), `"createdAt":1736931600000`) {
+		t.Errorf("composer fields not kept:\n%s", out)
+	}
+	if _, again, _ := applyRules(out, sess, rules, []time.Duration{0}, now); again.Entries != 0 {
+		t.Errorf("second run changed %d bubbles, want 0", again.Entries)
+	}
+
+	rules = []policy.RedactionRule{{Name: "old-code", Handler: "blur-code"}}
+	out, change, err = applyRules([]byte(policyCursorComposer), sess, rules, []time.Duration{90 * 24 * time.Hour}, now)
+	if err != nil {
+		t.Fatalf("applyRules() error = %v", err)
+	}
+	if change.Entries != 2 || strings.Contains(string(out), "func main") || !strings.Contains(string(out), "main.go") {
+		t.Errorf("change = %+v, want code blurred in 2 bubbles, paths kept:\n%s", change, out)
+	}
+}
+
+func TestApplyRules_CursorUnsupportedHandler(t *testing.T) {
+	RegisterRedactionHandler("test-jsonl-only", func(policy.RedactionRule, map[string]interface{}) bool { return false })
+	defer delete(redactionHandlers, "test-jsonl-only")
+
+	rules := []policy.RedactionRule{{Handler: "test-jsonl-only"}}
+	_, _, err := applyRules([]byte(policyCursorComposer), note.SessionEntry{Tool: "cursor"}, rules, []time.Duration{0}, time.Now())
+	if err == nil {
+		t.Error("applyRules() = nil error, want one for a handler without Cursor support")
+	}
+}
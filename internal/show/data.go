@@ -82,9 +82,18 @@ type SessionNode struct {
 	ID        string
 	ShortID   string
 	IsAgent   bool
+	Owner     string // Who captured the session
 	Start     time.Time
 	End       time.Time
 	CommitSHA string // Parent commit
+
+	// ContinuesFrom is the session this one resumes after a tool restart
+	ContinuesFrom string
+
+	// Removed describes the tombstone of a removed session, or the stub
+	// of a quarantined one, if any
+	Removed     string
+	Quarantined bool
 }
 
 func NewSessionNode(ss ci.SessionSummary, commitSHA string, depth int) *SessionNode {
@@ -98,9 +107,14 @@ func NewSessionNode(ss ci.SessionSummary, commitSHA string, depth int) *SessionN
 		ID:        ss.ID,
 		ShortID:   shortID,
 		IsAgent:   ss.IsAgent,
+		Owner:     ss.Owner,
 		Start:     ss.Start,
 		End:       ss.End,
 		CommitSHA: commitSHA,
+
+		ContinuesFrom: ss.ContinuesFrom,
+		Removed:       ss.Withheld(),
+		Quarantined:   ss.Quarantined != nil,
 	}
 }
 
@@ -110,6 +124,15 @@ func (s *SessionNode) Time() time.Time     { return s.Start }
 
 func (s *SessionNode) Label() string {
 	toolName := note.FormatToolName(s.Tool)
+	if s.Quarantined {
+		return fmt.Sprintf("Session: %s (%s, quarantined)", toolName, s.ShortID)
+	}
+	if s.Removed != "" {
+		return fmt.Sprintf("Session: %s (%s, removed)", toolName, s.ShortID)
+	}
+	if s.ContinuesFrom != "" {
+		return fmt.Sprintf("Session: %s (%s, continued)", toolName, s.ShortID)
+	}
 	return fmt.Sprintf("Session: %s (%s)", toolName, s.ShortID)
 }
 
@@ -183,7 +206,7 @@ func (s *StepNode) Label() string {
 	timeStr := s.entry.Time.Local().Format("15:04")
 
 	// For tool uses, show tool name and truncated input
-	if s.entry.Type == "TOOL_USE" && s.entry.ToolName != "" {
+	if s.entry.IsToolCall() {
 		input := display.TruncateText(s.entry.ToolInput, 20)
 		return fmt.Sprintf("%s %s %s: %s", emoji, timeStr, s.entry.ToolName, input)
 	}
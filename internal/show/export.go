@@ -0,0 +1,89 @@
+package show
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/ci"
+)
+
+// ExportMarkdown renders a node and everything under it, collapsed or not,
+// as markdown in the format of PR summaries
+func ExportMarkdown(n Node) string {
+	var sb strings.Builder
+	writeMarkdown(&sb, n)
+	return strings.TrimLeft(sb.String(), "\n")
+}
+
+func writeMarkdown(sb *strings.Builder, n Node) {
+	switch n := n.(type) {
+	case *CommitNode:
+		sb.WriteString(ci.MarkdownCommitHeader(ci.CommitSummary{SHA: n.SHA, ShortSHA: n.ShortSHA, Subject: n.Subject}))
+	case *SessionNode:
+		var prompts []ci.PromptEntry
+		for _, child := range n.Children() {
+			prompts = append(prompts, subtreeEntries(child)...)
+		}
+		sb.WriteString(ci.MarkdownSessionHeader(ci.SessionSummary{
+			Tool: n.Tool, ID: n.ID, Start: n.Start, End: n.End,
+			ContinuesFrom: n.ContinuesFrom, Prompts: prompts,
+		}))
+		if n.Removed != "" {
+			sb.WriteString("*" + n.Removed + "*\n")
+		}
+	case *UserActionNode:
+		sb.WriteString(ci.MarkdownUserAction(*n.Entry()))
+	case *StepNode:
+		sb.WriteString(ci.MarkdownStep(*n.Entry()))
+	}
+	for _, child := range n.Children() {
+		writeMarkdown(sb, child)
+	}
+	if n.Type() == NodeTypeSession {
+		sb.WriteString("\n")
+	}
+}
+
+// subtreeEntries returns the entries of a node and its descendants
+func subtreeEntries(n Node) []ci.PromptEntry {
+	var entries []ci.PromptEntry
+	if e := n.Entry(); e != nil {
+		entries = append(entries, *e)
+	}
+	for _, child := range n.Children() {
+		entries = append(entries, subtreeEntries(child)...)
+	}
+	return entries
+}
+
+// exportFileName names the file a node is exported to, after its commit
+// and step
+func exportFileName(n Node) string {
+	var commit, step string
+	switch n := n.(type) {
+	case *CommitNode:
+		commit = n.ShortSHA
+	case *SessionNode:
+		commit, step = n.CommitSHA, n.ShortID
+	case *UserActionNode:
+		commit, step = n.CommitSHA, n.Entry().StepID
+	case *StepNode:
+		commit, step = n.CommitSHA, n.Entry().StepID
+	}
+	name := "prompt-story-" + commit
+	if step != "" {
+		name += "-" + step
+	}
+	return name + ".md"
+}
+
+// writeExport writes a node's markdown to exportFileName in the current
+// directory and returns the file name
+func writeExport(n Node) (string, error) {
+	name := exportFileName(n)
+	if err := os.WriteFile(name, []byte(ExportMarkdown(n)), 0644); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return name, nil
+}
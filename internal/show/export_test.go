@@ -0,0 +1,44 @@
+package show
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/ci"
+)
+
+func TestExportMarkdown(t *testing.T) {
+	at := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+	commit := NewCommitNode(ci.CommitSummary{SHA: "abc1234def", ShortSHA: "abc1234", Subject: "Add login"}, 0)
+	session := NewSessionNode(ci.SessionSummary{Tool: "claude-code", ID: "sess1", Start: at, End: at.Add(time.Hour)}, "abc1234", 1)
+	action := NewUserActionNode(ci.PromptEntry{Type: "PROMPT", Text: "Add a login form", Time: at, StepID: "step-1"}, "claude-code", "sess1", "abc1234", 2)
+	step := NewStepNode(ci.PromptEntry{Type: "TOOL_USE", ToolName: "Bash", ToolInput: "go test ./...", Time: at}, "claude-code", "sess1", "abc1234", 3)
+	action.FollowingSteps = []*StepNode{step}
+	session.children = []Node{action}
+	commit.children = []Node{session}
+
+	out := ExportMarkdown(commit)
+	for _, want := range []string{
+		"#### abc1234: Add login",
+		"**Session: Claude Code**",
+		"2 steps",
+		"Add a login form",
+		"Bash: go test ./...",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("export missing %q:\n%s", want, out)
+		}
+	}
+	// The step is exported although its action is collapsed
+	if strings.Index(out, "Add a login form") > strings.Index(out, "Bash:") {
+		t.Errorf("step should follow its action:\n%s", out)
+	}
+
+	if out := ExportMarkdown(action); strings.Contains(out, "Session:") || !strings.Contains(out, "Bash:") {
+		t.Errorf("exporting an action should give it and its steps only:\n%s", out)
+	}
+	if got := exportFileName(action); got != "prompt-story-abc1234-step-1.md" {
+		t.Errorf("exportFileName = %q", got)
+	}
+}
@@ -0,0 +1,156 @@
+package show
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/note"
+	"github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// pickerLimit is how many recent commits the picker offers
+const pickerLimit = 50
+
+var (
+	pickerNotedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	pickerDimStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("244"))
+)
+
+// pickerModel is the Bubble Tea model for choosing commits from a list
+type pickerModel struct {
+	title     string
+	commits   []note.RecentCommit
+	selected  map[int]bool
+	cursor    int
+	offset    int
+	height    int
+	confirmed bool
+}
+
+// PickCommits lets the user select commits among the most recent ones,
+// marking those that already have notes. It returns a comma-separated
+// commit spec (see git.ResolveCommitSpec), or "" if the user cancelled.
+// Confirming without a selection picks the commit under the cursor.
+func PickCommits(title string) (string, error) {
+	commits, err := note.RecentCommits(pickerLimit)
+	if err != nil {
+		return "", err
+	}
+	if len(commits) == 0 {
+		return "", fmt.Errorf("no commits to choose from")
+	}
+
+	m := pickerModel{title: title, commits: commits, selected: make(map[int]bool)}
+	final, err := tea.NewProgram(m).Run()
+	if err != nil {
+		return "", err
+	}
+	return final.(pickerModel).spec(), nil
+}
+
+// spec returns the chosen commits, newest first, as a commit spec
+func (m pickerModel) spec() string {
+	if !m.confirmed {
+		return ""
+	}
+	var shas []string
+	for i, c := range m.commits {
+		if m.selected[i] {
+			shas = append(shas, c.SHA)
+		}
+	}
+	if len(shas) == 0 {
+		shas = append(shas, m.commits[m.cursor].SHA)
+	}
+	return strings.Join(shas, ",")
+}
+
+func (m pickerModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m pickerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.height = msg.Height
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "esc", "ctrl+c":
+			return m, tea.Quit
+		case "enter":
+			m.confirmed = true
+			return m, tea.Quit
+		case "j", "down":
+			if m.cursor < len(m.commits)-1 {
+				m.cursor++
+			}
+		case "k", "up":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case "g", "home":
+			m.cursor = 0
+		case "G", "end":
+			m.cursor = len(m.commits) - 1
+		case " ", "x":
+			m.selected[m.cursor] = !m.selected[m.cursor]
+		case "n":
+			// Select every commit that has a note
+			for i, c := range m.commits {
+				if c.HasNote {
+					m.selected[i] = true
+				}
+			}
+		case "c":
+			m.selected = make(map[int]bool)
+		}
+	}
+
+	// Keep the cursor inside the visible window
+	rows := m.listHeight()
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	} else if m.cursor >= m.offset+rows {
+		m.offset = m.cursor - rows + 1
+	}
+	return m, nil
+}
+
+// listHeight is the number of commit rows that fit below the header
+func (m pickerModel) listHeight() int {
+	if m.height == 0 {
+		return 15
+	}
+	return max(m.height-4, 3)
+}
+
+func (m pickerModel) View() string {
+	if m.confirmed {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString(m.title + "\n\n")
+
+	end := min(m.offset+m.listHeight(), len(m.commits))
+	for i := m.offset; i < end; i++ {
+		c := m.commits[i]
+		check := "[ ]"
+		if m.selected[i] {
+			check = "[x]"
+		}
+		noteMark := pickerDimStyle.Render("        ")
+		if c.HasNote {
+			noteMark = pickerNotedStyle.Render(fmt.Sprintf("● %-6s", fmt.Sprintf("%d sess", c.Sessions)))
+		}
+		line := fmt.Sprintf("%s %s %s %s %s", check, c.SHA[:7], c.Date.Local().Format("2006-01-02"), noteMark, c.Subject)
+		if i == m.cursor {
+			line = selectedStyle.Render(line)
+		}
+		b.WriteString(line + "\n")
+	}
+
+	b.WriteString(pickerDimStyle.Render("\nspace: toggle  n: select noted  c: clear  enter: confirm  q: cancel"))
+	return b.String()
+}
@@ -0,0 +1,64 @@
+package show
+
+import (
+	"testing"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/note"
+	"github.com/charmbracelet/bubbletea"
+)
+
+func pressKeys(m pickerModel, keys ...string) pickerModel {
+	for _, k := range keys {
+		var msg tea.KeyMsg
+		switch k {
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		case "space":
+			msg = tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}}
+		default:
+			msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+		}
+		next, _ := m.Update(msg)
+		m = next.(pickerModel)
+	}
+	return m
+}
+
+func newTestPicker() pickerModel {
+	return pickerModel{
+		commits: []note.RecentCommit{
+			{SHA: "aaaaaaaaaa", HasNote: true, Sessions: 1},
+			{SHA: "bbbbbbbbbb"},
+			{SHA: "cccccccccc", HasNote: true, Sessions: 2},
+		},
+		selected: make(map[int]bool),
+	}
+}
+
+func TestPicker_EnterPicksCursor(t *testing.T) {
+	m := pressKeys(newTestPicker(), "j", "enter")
+	if got := m.spec(); got != "bbbbbbbbbb" {
+		t.Errorf("spec() = %q, want cursor commit", got)
+	}
+}
+
+func TestPicker_MultiSelectKeepsOrder(t *testing.T) {
+	m := pressKeys(newTestPicker(), "G", "space", "g", "space", "enter")
+	if got := m.spec(); got != "aaaaaaaaaa,cccccccccc" {
+		t.Errorf("spec() = %q, want newest-first selection", got)
+	}
+}
+
+func TestPicker_SelectNoted(t *testing.T) {
+	m := pressKeys(newTestPicker(), "n", "enter")
+	if got := m.spec(); got != "aaaaaaaaaa,cccccccccc" {
+		t.Errorf("spec() = %q, want noted commits", got)
+	}
+}
+
+func TestPicker_Cancel(t *testing.T) {
+	m := pressKeys(newTestPicker(), "space", "q")
+	if got := m.spec(); got != "" {
+		t.Errorf("spec() = %q, want empty after cancel", got)
+	}
+}
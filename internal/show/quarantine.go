@@ -0,0 +1,74 @@
+package show
+
+import (
+	"fmt"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+	"github.com/QuesmaOrg/git-prompt-story/internal/note"
+)
+
+// QuarantineSession moves a session transcript into the local-only
+// quarantine ref and replaces it with a note.QuarantineStub. Unlike DeleteSession the
+// original content is kept and can be brought back with RestoreSession.
+// The local session file is left untouched. Held transcripts are refused
+// unless overrideHold is set.
+func QuarantineSession(tool, sessionID, reason string, overrideHold bool) error {
+	sessionPath := fmt.Sprintf("%s/%s.jsonl", tool, sessionID)
+	if err := note.CheckHold([]string{sessionPath}, "quarantine", overrideHold); err != nil {
+		return err
+	}
+
+	content, err := git.GetBlobContent(note.TranscriptsRef, sessionPath)
+	if err != nil {
+		return fmt.Errorf("failed to read transcript: %w", err)
+	}
+	if _, ok := note.ParseQuarantineStub(content); ok {
+		return fmt.Errorf("session already quarantined: %s", sessionPath)
+	}
+
+	// Keep the original blob under the quarantine ref first, so a failure
+	// below never loses content
+	blobSHA, err := git.HashObject(content)
+	if err != nil {
+		return fmt.Errorf("failed to create blob: %w", err)
+	}
+	if err := setTreeBlob(note.QuarantineRef, sessionPath, blobSHA, false); err != nil {
+		return fmt.Errorf("failed to update quarantine ref: %w", err)
+	}
+
+	stub, err := note.NewQuarantineStub(reason).Encode()
+	if err != nil {
+		return err
+	}
+	if err := updateTranscriptInGit(sessionPath, stub); err != nil {
+		return fmt.Errorf("failed to update git ref: %w", err)
+	}
+	if err := note.RecordRedaction(sessionPath, "quarantine", reason, stub); err != nil {
+		return fmt.Errorf("failed to record redaction: %w", err)
+	}
+
+	return nil
+}
+
+// RestoreSession moves a quarantined transcript back into the transcripts ref
+func RestoreSession(tool, sessionID string) error {
+	sessionPath := fmt.Sprintf("%s/%s.jsonl", tool, sessionID)
+
+	content, err := git.GetBlobContent(note.QuarantineRef, sessionPath)
+	if err != nil {
+		return fmt.Errorf("session not quarantined: %s", sessionPath)
+	}
+
+	if err := updateTranscriptInGit(sessionPath, content); err != nil {
+		return fmt.Errorf("failed to update git ref: %w", err)
+	}
+	if err := note.RecordRedaction(sessionPath, "restore", "", content); err != nil {
+		return fmt.Errorf("failed to record redaction: %w", err)
+	}
+
+	if err := setTreeBlob(note.QuarantineRef, sessionPath, "", true); err != nil {
+		return fmt.Errorf("failed to update quarantine ref: %w", err)
+	}
+
+	return nil
+}
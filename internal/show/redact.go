@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"time"
 
@@ -14,12 +15,37 @@ import (
 	"github.com/QuesmaOrg/git-prompt-story/internal/note"
 )
 
-const redactedPlaceholder = "<REDACTED BY USER>"
+// RedactedPlaceholder replaces the content of redacted messages
+const RedactedPlaceholder = "<REDACTED BY USER>"
+
+// redactedPlaceholder returns the placeholder for a redaction, naming its
+// reason code if there is one, e.g. "<REDACTED BY USER: secret>"
+func redactedPlaceholder(reason string) string {
+	if reason == "" {
+		return RedactedPlaceholder
+	}
+	return strings.TrimSuffix(RedactedPlaceholder, ">") + ": " + reason + ">"
+}
+
+// isRedactedPlaceholder reports whether content is a placeholder left by
+// a redaction, with or without a reason
+func isRedactedPlaceholder(content interface{}) bool {
+	s, ok := content.(string)
+	return ok && strings.HasPrefix(s, strings.TrimSuffix(RedactedPlaceholder, ">")) && strings.HasSuffix(s, ">")
+}
 
 // RedactMessage redacts a specific message in a session transcript.
-// It updates both the git ref and local file (if found).
-func RedactMessage(tool, sessionID string, timestamp time.Time) error {
+// It updates both the git ref and local file (if found). reason is an
+// optional code from note.RedactionReasons, shown in the placeholder.
+// Held transcripts are refused unless overrideHold is set.
+func RedactMessage(tool, sessionID string, timestamp time.Time, reason string, overrideHold bool) error {
+	if err := note.CheckRedactionReason(reason); err != nil {
+		return err
+	}
 	sessionPath := fmt.Sprintf("%s/%s.jsonl", tool, sessionID)
+	if err := note.CheckHold([]string{sessionPath}, "redact", overrideHold); err != nil {
+		return err
+	}
 
 	// Read current transcript from git
 	content, err := git.GetBlobContent(note.TranscriptsRef, sessionPath)
@@ -28,7 +54,7 @@ func RedactMessage(tool, sessionID string, timestamp time.Time) error {
 	}
 
 	// Redact the message
-	newContent, err := redactJSONLEntry(content, timestamp)
+	newContent, err := redactJSONLEntry(content, timestamp, redactedPlaceholder(reason))
 	if err != nil {
 		return fmt.Errorf("failed to redact message: %w", err)
 	}
@@ -37,6 +63,9 @@ func RedactMessage(tool, sessionID string, timestamp time.Time) error {
 	if err := updateTranscriptInGit(sessionPath, newContent); err != nil {
 		return fmt.Errorf("failed to update git ref: %w", err)
 	}
+	if err := note.RecordRedaction(sessionPath, "redact", reason, newContent); err != nil {
+		return fmt.Errorf("failed to record redaction: %w", err)
+	}
 
 	// Update local file (best effort - don't fail if not found)
 	if err := updateLocalSessionFile(sessionID, newContent); err != nil {
@@ -47,21 +76,79 @@ func RedactMessage(tool, sessionID string, timestamp time.Time) error {
 	return nil
 }
 
-// DeleteSession clears all content from a session transcript.
-// It updates both the git ref and empties the local file.
-func DeleteSession(tool, sessionID string) error {
+// RedactSpan redacts the text matching pattern in a specific message of a
+// session transcript, keeping the rest of the message. Like RedactMessage,
+// it updates both the git ref and local file (if found).
+func RedactSpan(tool, sessionID string, timestamp time.Time, pattern *regexp.Regexp, reason string, overrideHold bool) error {
+	if err := note.CheckRedactionReason(reason); err != nil {
+		return err
+	}
 	sessionPath := fmt.Sprintf("%s/%s.jsonl", tool, sessionID)
+	if err := note.CheckHold([]string{sessionPath}, "redact", overrideHold); err != nil {
+		return err
+	}
+
+	content, err := git.GetBlobContent(note.TranscriptsRef, sessionPath)
+	if err != nil {
+		return fmt.Errorf("failed to read transcript: %w", err)
+	}
+
+	newContent, err := redactSpanJSONLEntry(content, timestamp, pattern, redactedPlaceholder(reason))
+	if err != nil {
+		return fmt.Errorf("failed to redact message: %w", err)
+	}
+
+	if err := updateTranscriptInGit(sessionPath, newContent); err != nil {
+		return fmt.Errorf("failed to update git ref: %w", err)
+	}
+	if err := note.RecordRedaction(sessionPath, "redact", reason, newContent); err != nil {
+		return fmt.Errorf("failed to record redaction: %w", err)
+	}
+
+	if err := updateLocalSessionFile(sessionID, newContent); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not update local file: %v\n", err)
+	}
+
+	return nil
+}
+
+// DeleteSession clears all content from a session transcript, leaving a
+// tombstone recording who removed it, when, why and the hash of the
+// removed content. It updates the git ref and empties the local file.
+// Held transcripts are refused unless overrideHold is set.
+func DeleteSession(tool, sessionID, reason string, overrideHold bool) error {
+	sessionPath := fmt.Sprintf("%s/%s.jsonl", tool, sessionID)
+	if err := note.CheckHold([]string{sessionPath}, "clear", overrideHold); err != nil {
+		return err
+	}
+
+	content, err := git.GetBlobContent(note.TranscriptsRef, sessionPath)
+	if err != nil {
+		return fmt.Errorf("failed to read transcript: %w", err)
+	}
+	if _, ok := note.ParseTombstone(content); ok {
+		return fmt.Errorf("session already removed: %s", sessionPath)
+	}
+	originalBlob, err := git.HashObject(content)
+	if err != nil {
+		return fmt.Errorf("failed to hash transcript: %w", err)
+	}
 
-	// Empty content for the session
-	emptyContent := []byte{}
+	tombstone, err := note.NewTombstone(reason, originalBlob).Encode()
+	if err != nil {
+		return err
+	}
 
-	// Update git ref with empty content
-	if err := updateTranscriptInGit(sessionPath, emptyContent); err != nil {
+	// Update git ref with the tombstone
+	if err := updateTranscriptInGit(sessionPath, tombstone); err != nil {
 		return fmt.Errorf("failed to update git ref: %w", err)
 	}
+	if err := note.RecordRedaction(sessionPath, "clear", reason, tombstone); err != nil {
+		return fmt.Errorf("failed to record redaction: %w", err)
+	}
 
 	// Empty local file (best effort - keep file but clear content)
-	if err := updateLocalSessionFile(sessionID, emptyContent); err != nil {
+	if err := updateLocalSessionFile(sessionID, []byte{}); err != nil {
 		fmt.Fprintf(os.Stderr, "Warning: could not empty local file: %v\n", err)
 	}
 
@@ -85,8 +172,33 @@ func WasNotesPushed() bool {
 }
 
 // redactJSONLEntry finds and redacts a message by timestamp in JSONL content.
-// It replaces the message content with [REDACTED].
-func redactJSONLEntry(content []byte, timestamp time.Time) ([]byte, error) {
+// It replaces the message content with placeholder.
+func redactJSONLEntry(content []byte, timestamp time.Time, placeholder string) ([]byte, error) {
+	return rewriteJSONLEntry(content, timestamp, func(entry map[string]interface{}) error {
+		redactEntry(entry, placeholder)
+		return nil
+	})
+}
+
+// redactSpanJSONLEntry finds a message by timestamp in JSONL content and
+// replaces only the text matching pattern with placeholder. Patterns that
+// match empty text are refused: they would put a placeholder between every
+// character.
+func redactSpanJSONLEntry(content []byte, timestamp time.Time, pattern *regexp.Regexp, placeholder string) ([]byte, error) {
+	if pattern.MatchString("") {
+		return nil, fmt.Errorf("pattern %q matches empty text", pattern.String())
+	}
+	return rewriteJSONLEntry(content, timestamp, func(entry map[string]interface{}) error {
+		if redactEntrySpans(entry, pattern, placeholder) == 0 {
+			return fmt.Errorf("no text matching %q in message", pattern.String())
+		}
+		return nil
+	})
+}
+
+// rewriteJSONLEntry applies edit to the entry at timestamp in JSONL content,
+// leaving the other lines byte for byte as they were
+func rewriteJSONLEntry(content []byte, timestamp time.Time, edit func(map[string]interface{}) error) ([]byte, error) {
 	var result bytes.Buffer
 	scanner := bufio.NewScanner(bytes.NewReader(content))
 	found := false
@@ -108,8 +220,9 @@ func redactJSONLEntry(content []byte, timestamp time.Time) ([]byte, error) {
 
 		// Check if this is the entry to redact
 		if shouldRedact(entry, timestamp) {
-			// Redact the message content
-			redactEntry(entry)
+			if err := edit(entry); err != nil {
+				return nil, err
+			}
 			found = true
 
 			// Re-serialize
@@ -160,21 +273,82 @@ func shouldRedact(entry map[string]interface{}, timestamp time.Time) bool {
 	return diff < time.Second
 }
 
-// redactEntry replaces the message content with [REDACTED]
-func redactEntry(entry map[string]interface{}) {
+// redactEntry replaces the message content with placeholder
+func redactEntry(entry map[string]interface{}, placeholder string) {
 	if msg, ok := entry["message"].(map[string]interface{}); ok {
 		// Replace content field
 		if _, hasContent := msg["content"]; hasContent {
-			msg["content"] = redactedPlaceholder
+			msg["content"] = placeholder
 		}
 	}
 
 	// Also redact direct content field if present
 	if _, hasContent := entry["content"]; hasContent {
-		entry["content"] = redactedPlaceholder
+		entry["content"] = placeholder
 	}
 }
 
+// redactEntrySpans replaces the text matching pattern in the message
+// content with placeholder and returns the number of replacements
+func redactEntrySpans(entry map[string]interface{}, pattern *regexp.Regexp, placeholder string) int {
+	n := 0
+	if msg, ok := entry["message"].(map[string]interface{}); ok {
+		if c, hasContent := msg["content"]; hasContent {
+			msg["content"] = redactSpans(c, pattern, placeholder, &n)
+		}
+	}
+	if c, hasContent := entry["content"]; hasContent {
+		entry["content"] = redactSpans(c, pattern, placeholder, &n)
+	}
+	return n
+}
+
+// redactSpans redacts matches in string content and in the text, nested
+// content and tool input of content parts, counting replacements in n
+func redactSpans(content interface{}, pattern *regexp.Regexp, placeholder string, n *int) interface{} {
+	switch c := content.(type) {
+	case string:
+		*n += len(pattern.FindAllStringIndex(c, -1))
+		return pattern.ReplaceAllLiteralString(c, placeholder)
+	case []interface{}:
+		for _, item := range c {
+			part, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if text, ok := part["text"].(string); ok {
+				part["text"] = redactSpans(text, pattern, placeholder, n)
+			}
+			if nested, ok := part["content"]; ok {
+				part["content"] = redactSpans(nested, pattern, placeholder, n)
+			}
+			if input, ok := part["input"]; ok {
+				part["input"] = redactInputSpans(input, pattern, placeholder, n)
+			}
+		}
+	}
+	return content
+}
+
+// redactInputSpans redacts matches in every string value of a tool_use
+// input, e.g. a token pasted into a Bash command. Keys are kept.
+func redactInputSpans(input interface{}, pattern *regexp.Regexp, placeholder string, n *int) interface{} {
+	switch v := input.(type) {
+	case string:
+		*n += len(pattern.FindAllStringIndex(v, -1))
+		return pattern.ReplaceAllLiteralString(v, placeholder)
+	case map[string]interface{}:
+		for key, value := range v {
+			v[key] = redactInputSpans(value, pattern, placeholder, n)
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = redactInputSpans(value, pattern, placeholder, n)
+		}
+	}
+	return input
+}
+
 // updateTranscriptInGit updates a transcript blob in the git refs tree
 func updateTranscriptInGit(sessionPath string, content []byte) error {
 	// Create new blob
@@ -183,6 +357,13 @@ func updateTranscriptInGit(sessionPath string, content []byte) error {
 		return fmt.Errorf("failed to create blob: %w", err)
 	}
 
+	return setTreeBlob(note.TranscriptsRef, sessionPath, blobSHA, true)
+}
+
+// setTreeBlob points "tool/filename" in the tree stored at ref to blobSHA.
+// An empty blobSHA removes the entry. With mustExist, the ref, tool subtree
+// and entry must already exist; otherwise they are created as needed.
+func setTreeBlob(ref, sessionPath, blobSHA string, mustExist bool) error {
 	// Parse path to get tool and filename
 	parts := strings.SplitN(sessionPath, "/", 2)
 	if len(parts) != 2 {
@@ -191,47 +372,67 @@ func updateTranscriptInGit(sessionPath string, content []byte) error {
 	tool := parts[0]
 	filename := parts[1]
 
-	// Get existing transcript tree
-	existingTreeSHA, _ := git.GetRef(note.TranscriptsRef)
-	if existingTreeSHA == "" {
+	// Get existing tree
+	existingTreeSHA, _ := git.GetRef(ref)
+	if existingTreeSHA == "" && mustExist {
 		return fmt.Errorf("transcript tree not found")
 	}
 
 	// Read root tree
-	rootEntries, err := git.ReadTree(existingTreeSHA)
-	if err != nil {
-		return fmt.Errorf("failed to read root tree: %w", err)
+	var rootEntries []git.TreeEntry
+	if existingTreeSHA != "" {
+		var err error
+		rootEntries, err = git.ReadTree(existingTreeSHA)
+		if err != nil {
+			return fmt.Errorf("failed to read root tree: %w", err)
+		}
 	}
 
 	// Find tool subtree
-	var toolTreeSHA string
-	for _, entry := range rootEntries {
+	toolIdx := -1
+	for i, entry := range rootEntries {
 		if entry.Name == tool && entry.Type == "tree" {
-			toolTreeSHA = entry.SHA
+			toolIdx = i
 			break
 		}
 	}
-	if toolTreeSHA == "" {
+	if toolIdx == -1 && mustExist {
 		return fmt.Errorf("tool tree not found: %s", tool)
 	}
 
 	// Read tool subtree entries
-	toolEntries, err := git.ReadTree(toolTreeSHA)
-	if err != nil {
-		return fmt.Errorf("failed to read tool tree: %w", err)
+	var toolEntries []git.TreeEntry
+	if toolIdx != -1 {
+		var err error
+		toolEntries, err = git.ReadTree(rootEntries[toolIdx].SHA)
+		if err != nil {
+			return fmt.Errorf("failed to read tool tree: %w", err)
+		}
 	}
 
-	// Update the entry with new blob SHA
+	// Update, add or remove the entry
 	found := false
 	for i, entry := range toolEntries {
 		if entry.Name == filename {
-			toolEntries[i].SHA = blobSHA
+			if blobSHA == "" {
+				toolEntries = append(toolEntries[:i], toolEntries[i+1:]...)
+			} else {
+				toolEntries[i].SHA = blobSHA
+			}
 			found = true
 			break
 		}
 	}
 	if !found {
-		return fmt.Errorf("transcript not found: %s", filename)
+		if mustExist || blobSHA == "" {
+			return fmt.Errorf("transcript not found: %s", filename)
+		}
+		toolEntries = append(toolEntries, git.TreeEntry{
+			Mode: "100644",
+			Type: "blob",
+			SHA:  blobSHA,
+			Name: filename,
+		})
 	}
 
 	// Create new tool subtree
@@ -240,12 +441,18 @@ func updateTranscriptInGit(sessionPath string, content []byte) error {
 		return fmt.Errorf("failed to create tool tree: %w", err)
 	}
 
-	// Update root tree with new tool subtree
-	for i, entry := range rootEntries {
-		if entry.Name == tool {
-			rootEntries[i].SHA = newToolTreeSHA
-			break
-		}
+	// Update root tree with new tool subtree (dropping it once empty)
+	if len(toolEntries) == 0 {
+		rootEntries = append(rootEntries[:toolIdx], rootEntries[toolIdx+1:]...)
+	} else if toolIdx == -1 {
+		rootEntries = append(rootEntries, git.TreeEntry{
+			Mode: "040000",
+			Type: "tree",
+			SHA:  newToolTreeSHA,
+			Name: tool,
+		})
+	} else {
+		rootEntries[toolIdx].SHA = newToolTreeSHA
 	}
 
 	// Create new root tree
@@ -255,7 +462,7 @@ func updateTranscriptInGit(sessionPath string, content []byte) error {
 	}
 
 	// Update ref
-	return git.UpdateRef(note.TranscriptsRef, newRootTreeSHA)
+	return git.UpdateRef(ref, newRootTreeSHA)
 }
 
 // updateLocalSessionFile updates a local session file with new content
@@ -1,6 +1,7 @@
 package show
 
 import (
+	"regexp"
 	"strings"
 	"testing"
 	"time"
@@ -96,7 +97,7 @@ func TestRedactJSONLEntry(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			output, err := redactJSONLEntry([]byte(tt.content), tt.timestamp)
+			output, err := redactJSONLEntry([]byte(tt.content), tt.timestamp, RedactedPlaceholder)
 			if tt.wantErr {
 				if err == nil {
 					t.Error("expected error, got nil")
@@ -239,12 +240,88 @@ func TestRedactEntry(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			redactEntry(tt.entry)
+			redactEntry(tt.entry, RedactedPlaceholder)
 			tt.check(t, tt.entry)
 		})
 	}
 }
 
+func TestRedactSpanJSONLEntry(t *testing.T) {
+	content := `{"timestamp":"2025-01-15T10:00:00Z","type":"user","message":{"content":"Deploy with token sk-abc123 to prod"}}
+{"timestamp":"2025-01-15T10:01:00Z","type":"assistant","message":{"content":[{"type":"text","text":"Using sk-abc123 now"},{"type":"tool_use","input":{"cmd":"ls"}}]}}
+`
+
+	output, err := redactSpanJSONLEntry([]byte(content), mustParseTime("2025-01-15T10:00:00Z"), regexp.MustCompile(`sk-[a-z0-9]+`), RedactedPlaceholder)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	lines := strings.Split(string(output), "\n")
+	if !strings.Contains(lines[0], `Deploy with token \u003cREDACTED BY USER\u003e to prod`) {
+		t.Errorf("expected only the token redacted, got %s", lines[0])
+	}
+	if !strings.Contains(lines[1], "Using sk-abc123 now") {
+		t.Errorf("expected other entries unchanged, got %s", lines[1])
+	}
+
+	// Text parts of array content are redacted, other parts kept
+	output, err = redactSpanJSONLEntry([]byte(content), mustParseTime("2025-01-15T10:01:00Z"), regexp.MustCompile(`sk-[a-z0-9]+`), RedactedPlaceholder)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	lines = strings.Split(string(output), "\n")
+	if !strings.Contains(lines[1], `Using \u003cREDACTED BY USER\u003e now`) || !strings.Contains(lines[1], `"cmd":"ls"`) {
+		t.Errorf("expected text part redacted and tool input kept, got %s", lines[1])
+	}
+
+	// Tool input is redacted too, at any depth
+	withInput := `{"timestamp":"2025-01-15T10:02:00Z","type":"assistant","message":{"content":[{"type":"tool_use","name":"Bash","input":{"command":"curl -H 'Authorization: sk-abc123' api","env":["TOKEN=sk-def456"]}}]}}
+`
+	output, err = redactSpanJSONLEntry([]byte(withInput), mustParseTime("2025-01-15T10:02:00Z"), regexp.MustCompile(`sk-[a-z0-9]+`), RedactedPlaceholder)
+	if err != nil {
+		t.Fatalf("unexpected error redacting tool input: %v", err)
+	}
+	if strings.Contains(string(output), "sk-") || !strings.Contains(string(output), `"command":"curl -H 'Authorization: \u003cREDACTED BY USER\u003e' api"`) {
+		t.Errorf("expected tokens in tool input redacted, got %s", output)
+	}
+
+	// Patterns matching empty text would redact between every character
+	for _, expr := range []string{`x*`, `^`, `(sk-)?`} {
+		_, err = redactSpanJSONLEntry([]byte(content), mustParseTime("2025-01-15T10:00:00Z"), regexp.MustCompile(expr), RedactedPlaceholder)
+		if err == nil || !strings.Contains(err.Error(), "matches empty text") {
+			t.Errorf("pattern %q: expected empty-match error, got %v", expr, err)
+		}
+	}
+
+	// A pattern matching nothing in the message is an error, not a no-op
+	_, err = redactSpanJSONLEntry([]byte(content), mustParseTime("2025-01-15T10:00:00Z"), regexp.MustCompile(`password`), RedactedPlaceholder)
+	if err == nil || !strings.Contains(err.Error(), "no text matching") {
+		t.Errorf("expected no-match error, got %v", err)
+	}
+}
+
+func TestRedactedPlaceholder(t *testing.T) {
+	if got := redactedPlaceholder(""); got != RedactedPlaceholder {
+		t.Errorf("redactedPlaceholder(\"\") = %q", got)
+	}
+	withReason := redactedPlaceholder("secret")
+	if withReason != "<REDACTED BY USER: secret>" {
+		t.Errorf("redactedPlaceholder(secret) = %q", withReason)
+	}
+
+	// Policies must not redact an already redacted entry again and lose
+	// its reason
+	for _, c := range []interface{}{RedactedPlaceholder, withReason} {
+		if !isRedactedPlaceholder(c) {
+			t.Errorf("isRedactedPlaceholder(%q) = false", c)
+		}
+	}
+	for _, c := range []interface{}{"Use <REDACTED BY USER> here", []interface{}{}} {
+		if isRedactedPlaceholder(c) {
+			t.Errorf("isRedactedPlaceholder(%v) = true", c)
+		}
+	}
+}
+
 func mustParseTime(s string) time.Time {
 	t, err := time.Parse(time.RFC3339, s)
 	if err != nil {
@@ -0,0 +1,155 @@
+package show
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+	"github.com/QuesmaOrg/git-prompt-story/internal/note"
+	"github.com/QuesmaOrg/git-prompt-story/internal/scrubber"
+)
+
+// RescrubChange is a stored transcript the current scrubber rewrites
+type RescrubChange struct {
+	Path    string
+	Matches map[string]int // Replacements by entity type
+}
+
+// Replacements returns the number of replacements in the transcript
+func (c RescrubChange) Replacements() int {
+	n := 0
+	for _, count := range c.Matches {
+		n += count
+	}
+	return n
+}
+
+// Kinds returns the replaced entity types with their counts, sorted
+func (c RescrubChange) Kinds() string {
+	kinds := make([]string, 0, len(c.Matches))
+	for kind, count := range c.Matches {
+		kinds = append(kinds, fmt.Sprintf("%s %d", kind, count))
+	}
+	sort.Strings(kinds)
+	return strings.Join(kinds, ", ")
+}
+
+// RescrubHistory scrubs every transcript stored in the transcripts ref
+// again with scrub, for patterns added after the transcripts were
+// captured. Changed transcripts are written in one new tree, then re-sealed
+// and logged in the notes referencing them, as with manual redaction.
+// Held transcripts are refused unless overrideHold is set. With dryRun
+// nothing is written.
+func RescrubHistory(scrub *scrubber.PIIScrubber, dryRun, overrideHold bool) ([]RescrubChange, error) {
+	rootSHA, err := git.GetRef(note.TranscriptsRef)
+	if err != nil || rootSHA == "" {
+		return nil, nil // Nothing stored yet
+	}
+	root, err := git.ReadTree(rootSHA)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transcript tree: %w", err)
+	}
+
+	var changes []RescrubChange
+	contents := make(map[string][]byte)
+	toolTrees := make(map[int][]git.TreeEntry)
+	for i, dir := range root {
+		if dir.Type != "tree" {
+			continue
+		}
+		entries, err := git.ReadTree(dir.SHA)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s transcripts: %w", dir.Name, err)
+		}
+		for j, e := range entries {
+			if e.Type != "blob" {
+				continue
+			}
+			path := dir.Name + "/" + e.Name
+			content, err := git.ReadBlob(e.SHA)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", path, err)
+			}
+			scrubbed, matches, err := rescrubTranscript(scrub, dir.Name, content)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+			if len(matches) == 0 {
+				continue
+			}
+			changes = append(changes, RescrubChange{Path: path, Matches: matches})
+			if dryRun {
+				continue
+			}
+			blobSHA, err := git.HashObject(scrubbed)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create blob: %w", err)
+			}
+			entries[j].SHA = blobSHA
+			contents[path] = scrubbed
+			toolTrees[i] = entries
+		}
+	}
+	if dryRun || len(changes) == 0 {
+		return changes, nil
+	}
+
+	paths := make([]string, len(changes))
+	for i, c := range changes {
+		paths[i] = c.Path
+	}
+	if err := note.CheckHold(paths, "scrub --history", overrideHold); err != nil {
+		return nil, err
+	}
+
+	for i, entries := range toolTrees {
+		sha, err := git.CreateTree(entries)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create tool tree: %w", err)
+		}
+		root[i].SHA = sha
+	}
+	newRoot, err := git.CreateTree(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create root tree: %w", err)
+	}
+	if err := git.UpdateRef(note.TranscriptsRef, newRoot); err != nil {
+		return nil, err
+	}
+
+	for _, c := range changes {
+		if err := note.RecordRedaction(c.Path, "rescrub", c.Kinds(), contents[c.Path]); err != nil {
+			return nil, fmt.Errorf("failed to record redaction: %w", err)
+		}
+	}
+	return changes, nil
+}
+
+// rescrubTranscript scrubs a stored transcript of tool, returning the
+// result and what was replaced by entity type. Tombstones and quarantine
+// stubs are kept.
+func rescrubTranscript(scrub *scrubber.PIIScrubber, tool string, content []byte) ([]byte, map[string]int, error) {
+	if _, ok := note.ParseTombstone(content); ok {
+		return content, nil, nil
+	}
+	if _, ok := note.ParseQuarantineStub(content); ok {
+		return content, nil, nil
+	}
+	matches := make(map[string]int)
+	scrub.SetReport(func(h scrubber.Hit) {
+		if h.Allowed == nil {
+			matches[h.EntityType]++
+		}
+	})
+	defer scrub.SetReport(nil)
+
+	scrubbed, err := scrub.ForTool(tool).Scrub(content)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(matches) == 0 {
+		return content, nil, nil
+	}
+	return scrubbed, matches, nil
+}
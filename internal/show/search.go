@@ -0,0 +1,141 @@
+package show
+
+import (
+	"strings"
+)
+
+// searchText is the text a search matches for a node: its label and the
+// content shown for it in the detail panel
+func searchText(n Node) string {
+	parts := []string{n.Label()}
+	switch n := n.(type) {
+	case *CommitNode:
+		parts = append(parts, n.SHA, n.Subject)
+	case *SessionNode:
+		parts = append(parts, n.ID, n.Owner, n.Removed)
+	}
+	if e := n.Entry(); e != nil {
+		parts = append(parts, e.Text, e.ToolName, e.ToolInput, e.ToolOutput, e.DecisionHeader, e.DecisionAnswer, e.StepID)
+	}
+	return strings.Join(parts, "\n")
+}
+
+// nodeMatches reports whether a node's label or detail content contains
+// query, ignoring case
+func nodeMatches(n Node, query string) bool {
+	return query != "" && strings.Contains(strings.ToLower(searchText(n)), strings.ToLower(query))
+}
+
+// flattenAll returns every node in display order, expanded or not
+func (t *Tree) flattenAll() []Node {
+	var result []Node
+	var walk func(n Node)
+	walk = func(n Node) {
+		result = append(result, n)
+		for _, child := range n.Children() {
+			walk(child)
+		}
+	}
+	for _, root := range t.Roots {
+		walk(root)
+	}
+	return result
+}
+
+// Matches returns the nodes matching query in display order, including
+// nodes hidden under collapsed ones
+func (t *Tree) Matches(query string) []Node {
+	var matches []Node
+	for _, n := range t.flattenAll() {
+		if nodeMatches(n, query) {
+			matches = append(matches, n)
+		}
+	}
+	return matches
+}
+
+// FindStep returns the node of the step with stepID, nil if there is none
+func (t *Tree) FindStep(stepID string) Node {
+	if stepID == "" {
+		return nil
+	}
+	for _, n := range t.flattenAll() {
+		if e := n.Entry(); e != nil && e.StepID == stepID {
+			return n
+		}
+	}
+	return nil
+}
+
+// Reveal expands the ancestors of target so that it is visible. It
+// returns false if target is not in the tree.
+func (t *Tree) Reveal(target Node) bool {
+	var reveal func(n Node) bool
+	reveal = func(n Node) bool {
+		if n == target {
+			return true
+		}
+		for _, child := range n.Children() {
+			if reveal(child) {
+				n.SetExpanded(true)
+				return true
+			}
+		}
+		return false
+	}
+	for _, root := range t.Roots {
+		if reveal(root) {
+			return true
+		}
+	}
+	return false
+}
+
+// FlattenFiltered returns the nodes matching query with their ancestors,
+// in display order, hiding everything else. Ancestors are listed
+// regardless of their expansion state.
+func (t *Tree) FlattenFiltered(query string) []Node {
+	var result []Node
+	var walk func(n Node) []Node
+	walk = func(n Node) []Node {
+		var below []Node
+		for _, child := range n.Children() {
+			below = append(below, walk(child)...)
+		}
+		if len(below) == 0 && !nodeMatches(n, query) {
+			return nil
+		}
+		return append([]Node{n}, below...)
+	}
+	for _, root := range t.Roots {
+		result = append(result, walk(root)...)
+	}
+	return result
+}
+
+// highlightMatches renders the occurrences of query in line (ignoring
+// case) with style
+func highlightMatches(line, query string, render func(...string) string) string {
+	if query == "" {
+		return line
+	}
+	lower := strings.ToLower(line)
+	q := strings.ToLower(query)
+	// Lowercasing may change byte lengths; leave such lines alone
+	if len(lower) != len(line) {
+		return line
+	}
+
+	var sb strings.Builder
+	for {
+		i := strings.Index(lower, q)
+		if i < 0 {
+			break
+		}
+		sb.WriteString(line[:i])
+		sb.WriteString(render(line[i : i+len(q)]))
+		line, lower = line[i+len(q):], lower[i+len(q):]
+	}
+	sb.WriteString(line)
+	return sb.String()
+}
@@ -0,0 +1,104 @@
+package show
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/ci"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// searchTestTree is a commit with two prompts; the second one's tool call
+// mentions "migration" only in its output, hidden while collapsed
+func searchTestTree() (*Tree, *UserActionNode, *StepNode) {
+	commit := NewCommitNode(ci.CommitSummary{ShortSHA: "abc1234", Subject: "Add login"}, 0)
+	session := NewSessionNode(ci.SessionSummary{Tool: "claude-code", ID: "sess1"}, "abc1234", 1)
+	first := NewUserActionNode(ci.PromptEntry{Type: "PROMPT", Text: "Add a login form"}, "claude-code", "sess1", "abc1234", 2)
+	second := NewUserActionNode(ci.PromptEntry{Type: "PROMPT", Text: "Run the tests"}, "claude-code", "sess1", "abc1234", 2)
+	step := NewStepNode(ci.PromptEntry{Type: "TOOL_USE", ToolName: "Bash", ToolInput: "go test", ToolOutput: "ran Migration 42"}, "claude-code", "sess1", "abc1234", 3)
+	second.FollowingSteps = []*StepNode{step}
+	session.children = []Node{first, second}
+	commit.children = []Node{session}
+	return &Tree{Roots: []Node{commit}}, first, step
+}
+
+func TestTreeMatches(t *testing.T) {
+	tree, first, step := searchTestTree()
+
+	if got := tree.Matches("LOGIN"); len(got) != 2 || got[1] != first {
+		t.Errorf("Matches(LOGIN) = %d nodes, want the commit and the first prompt", len(got))
+	}
+	if got := tree.Matches("migration"); len(got) != 1 || got[0] != step {
+		t.Errorf("Matches(migration) should find the collapsed step by its output, got %d nodes", len(got))
+	}
+	if got := tree.Matches(""); len(got) != 0 {
+		t.Errorf("empty query matched %d nodes", len(got))
+	}
+}
+
+func TestTreeReveal(t *testing.T) {
+	tree, _, step := searchTestTree()
+
+	if !tree.Reveal(step) {
+		t.Fatal("Reveal should find the step")
+	}
+	visible := tree.FlattenVisible()
+	if visible[len(visible)-1] != step {
+		t.Error("the step should be visible after Reveal")
+	}
+}
+
+func TestTreeFlattenFiltered(t *testing.T) {
+	tree, _, step := searchTestTree()
+
+	got := tree.FlattenFiltered("migration")
+	// commit, session, second prompt, step
+	if len(got) != 4 || got[3] != step {
+		t.Errorf("FlattenFiltered = %d nodes, want the step with its 3 ancestors", len(got))
+	}
+	if got := tree.FlattenFiltered("nothing like this"); len(got) != 0 {
+		t.Errorf("FlattenFiltered without matches = %d nodes, want none", len(got))
+	}
+}
+
+func TestHighlightMatches(t *testing.T) {
+	mark := func(s ...string) string { return "[" + strings.Join(s, "") + "]" }
+	if got := highlightMatches("Run the Tests, test again", "test", mark); got != "Run the [Test]s, [test] again" {
+		t.Errorf("highlightMatches = %q", got)
+	}
+	if got := highlightMatches("unchanged", "", mark); got != "unchanged" {
+		t.Errorf("empty query changed the line: %q", got)
+	}
+}
+
+func TestModelSearchKeys(t *testing.T) {
+	tree, first, step := searchTestTree()
+	var m tea.Model = model{tree: tree, visible: tree.FlattenVisible(), height: 40}
+	press := func(keys ...tea.KeyMsg) {
+		for _, k := range keys {
+			m, _ = m.Update(k)
+		}
+	}
+	runes := func(s string) tea.KeyMsg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)} }
+
+	press(runes("/"), runes("form"), tea.KeyMsg{Type: tea.KeyEnter})
+	got := m.(model)
+	if got.visible[got.cursor] != first {
+		t.Errorf("typing the query should jump to the first prompt, cursor at %q", got.visible[got.cursor].Label())
+	}
+
+	press(runes("/"), runes("migration"), tea.KeyMsg{Type: tea.KeyEnter})
+	got = m.(model)
+	if got.visible[got.cursor] != step {
+		t.Errorf("search should reveal and select the collapsed step, cursor at %q", got.visible[got.cursor].Label())
+	}
+
+	press(runes("f"))
+	if got := m.(model); !got.filterMode || len(got.visible) != 4 {
+		t.Errorf("filter mode should list the match and its ancestors, got %d nodes", len(got.visible))
+	}
+	press(tea.KeyMsg{Type: tea.KeyEsc})
+	if got := m.(model); got.filterMode || got.searchQuery != "" {
+		t.Error("esc should clear the search and leave filter mode")
+	}
+}
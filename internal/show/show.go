@@ -1,11 +1,11 @@
 package show
 
 import (
-	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
 
+	"github.com/QuesmaOrg/git-prompt-story/internal/ci"
 	"github.com/QuesmaOrg/git-prompt-story/internal/git"
 	"github.com/QuesmaOrg/git-prompt-story/internal/note"
 	"github.com/QuesmaOrg/git-prompt-story/internal/session"
@@ -35,16 +35,10 @@ func ShowPrompts(commitRef string, full bool) error {
 // showCommitPrompts displays prompts for a single commit
 func showCommitPrompts(sha string, full bool) error {
 
-	// Get note attached to commit
-	noteContent, err := note.GetNote(sha)
+	// Get note attached to commit, reading legacy notes too
+	psNote, legacy, err := note.LoadNote(sha)
 	if err != nil {
-		return fmt.Errorf("no prompt-story note found for commit %s", sha[:7])
-	}
-
-	// Parse note JSON
-	var psNote note.PromptStoryNote
-	if err := json.Unmarshal([]byte(noteContent), &psNote); err != nil {
-		return fmt.Errorf("failed to parse note: %w", err)
+		return err
 	}
 
 	// Get commit timestamp from git (end of work period)
@@ -60,19 +54,31 @@ func showCommitPrompts(sha string, full bool) error {
 
 	// Print header
 	fmt.Printf("Commit: %s\n", sha[:7])
+	if legacy {
+		fmt.Println("Note format: legacy (convert with: git-prompt-story migrate-format)")
+	}
 	fmt.Printf("Work period: %s - %s\n\n",
 		psNote.StartWork.Local().Format("2006-01-02 15:04"),
 		endWork.Local().Format("2006-01-02 15:04"))
+	if psNote.OnHold() {
+		fmt.Printf("Legal hold: %s (%s, %s)\n\n", psNote.Hold.Reason, psNote.Hold.By,
+			psNote.Hold.At.Local().Format("2006-01-02"))
+	}
 
 	if len(psNote.Sessions) == 0 {
 		fmt.Println("No sessions recorded")
 		return nil
 	}
 
-	// Process each session, filtering out empty ones
+	// Best effort: without links sessions are shown unlinked
+	continued, _ := ci.Continuations(sha)
+
+	// Process each session, filtering out empty ones; entries may lie
+	// outside the work period by the clock skew of the capture
+	startEntries, endEntries := session.WidenWorkPeriod(psNote.StartWork, endWork, psNote.ClockSkew)
 	shownSessions := 0
 	for _, sess := range psNote.Sessions {
-		shown, err := showSession(sess, psNote.StartWork, endWork, full)
+		shown, err := showSession(sess, startEntries, endEntries, full, continued[sess.Tool+"/"+sess.ID])
 		if err != nil {
 			fmt.Printf("Warning: could not load session %s: %v\n", sess.ID, err)
 			continue
@@ -96,7 +102,14 @@ type displayEntry struct {
 	text     string
 }
 
-func showSession(sess note.SessionEntry, startWork, endWork time.Time, full bool) (bool, error) {
+func showSession(sess note.SessionEntry, startWork, endWork time.Time, full bool, continues string) (bool, error) {
+	if sess.Expired != nil {
+		fmt.Printf("Session: %s/%s\n", sess.Tool, sess.ID)
+		fmt.Printf("Transcript removed by retention policy on %s\n\n",
+			sess.Expired.Local().Format("2006-01-02"))
+		return true, nil
+	}
+
 	// Extract relative path from full ref path
 	relPath := strings.TrimPrefix(sess.Path, note.TranscriptsRef+"/")
 
@@ -105,12 +118,23 @@ func showSession(sess note.SessionEntry, startWork, endWork time.Time, full bool
 	if err != nil {
 		return false, fmt.Errorf("failed to fetch transcript: %w", err)
 	}
+	if tombstone, ok := note.ParseTombstone(content); ok {
+		fmt.Printf("Session: %s/%s\n", sess.Tool, sess.ID)
+		fmt.Printf("%s\n\n", tombstone.Description())
+		return true, nil
+	}
+	if stub, ok := note.ParseQuarantineStub(content); ok {
+		fmt.Printf("Session: %s/%s\n", sess.Tool, sess.ID)
+		fmt.Printf("%s\n\n", stub.Description())
+		return true, nil
+	}
 
 	// Parse messages
-	entries, err := session.ParseMessages(content)
+	entries, err := session.ParseTranscript(sess.Tool, content)
 	if err != nil {
 		return false, fmt.Errorf("failed to parse messages: %w", err)
 	}
+	session.MonotonicTimestamps(entries)
 
 	// Collect displayable entries within the work period
 	var displayEntries []displayEntry
@@ -190,9 +214,21 @@ func showSession(sess note.SessionEntry, startWork, endWork time.Time, full bool
 	if len(displayEntries) == 0 {
 		return false, nil
 	}
+	if sess.Context != nil {
+		if lines := sess.Context.Lines(); len(lines) > 0 {
+			ctx := displayEntry{ts: sess.Created, entryType: "CONTEXT", text: strings.Join(lines, "\n")}
+			displayEntries = append([]displayEntry{ctx}, displayEntries...)
+		}
+	}
 
 	// Print session header
 	fmt.Printf("Session: %s/%s\n", sess.Tool, sess.ID)
+	if owner := sess.Owner(); owner != "" {
+		fmt.Printf("Captured by: %s\n", owner)
+	}
+	if continues != "" {
+		fmt.Printf("Continued session: resumes %s/%s after a restart\n", sess.Tool, continues)
+	}
 	fmt.Printf("Duration: %s - %s\n\n",
 		sess.Created.Local().Format("2006-01-02 15:04"),
 		sess.Modified.Local().Format("2006-01-02 15:04"))
@@ -255,6 +291,7 @@ func wrapAndTruncate(s string, maxWidth, maxLines int) ([]string, bool) {
 		for len(para) > 0 && len(result) < maxLines {
 			if len(para) <= maxWidth {
 				result = append(result, para)
+				para = ""
 				break
 			}
 			// Find break point (prefer space)
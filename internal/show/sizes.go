@@ -0,0 +1,54 @@
+package show
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/QuesmaOrg/git-prompt-story/internal/git"
+	"github.com/QuesmaOrg/git-prompt-story/internal/note"
+)
+
+// ShowSizes prints the stored size of each session's transcript for a
+// commit or range, with the largest entries, to find what bloats storage.
+// Notes written before size accounting are measured from the stored
+// transcript.
+func ShowSizes(commitRef string) error {
+	commits, err := git.ResolveCommitSpec(commitRef)
+	if err != nil {
+		return err
+	}
+
+	var total int64
+	for _, sha := range commits {
+		psNote, _, err := note.LoadNote(sha)
+		if errors.Is(err, note.ErrNoNote) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		subject, _ := git.RunGit("log", "-1", "--format=%s", sha)
+		fmt.Printf("%s %s\n", sha[:7], subject)
+
+		for _, sess := range psNote.Sessions {
+			size := sess.StoredSize()
+			if size == nil {
+				fmt.Printf("  %s/%s  transcript not stored\n", sess.Tool, sess.ID)
+				continue
+			}
+
+			total += size.Bytes
+			fmt.Printf("  %s/%s  %s in %d entries (tool output %s)\n", sess.Tool, sess.ID,
+				note.FormatBytes(size.Bytes), size.Entries, note.FormatBytes(size.ToolOutput))
+			for _, e := range size.Largest {
+				label := e.Kind
+				if e.Tool != "" {
+					label += " " + e.Tool
+				}
+				fmt.Printf("    #%-5d %-24s %s\n", e.Entry, label, note.FormatBytes(int64(e.Bytes)))
+			}
+		}
+	}
+	fmt.Printf("Total: %s\n", note.FormatBytes(total))
+	return nil
+}
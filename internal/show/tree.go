@@ -45,7 +45,7 @@ func LoadTree(commitSpec string, full bool) (*Tree, error) {
 			showSessions := len(commit.Sessions) > 1
 
 			for _, sess := range commit.Sessions {
-				if showSessions {
+				if showSessions || sess.Withheld() != "" {
 					sessNode := buildSessionNode(sess, commit.ShortSHA, 0)
 					tree.Roots = append(tree.Roots, sessNode)
 					tree.TotalActions += countUserActions(sessNode)
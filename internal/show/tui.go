@@ -2,11 +2,14 @@ package show
 
 import (
 	"fmt"
+	"os"
+	"regexp"
 	"strings"
 	"time"
 
 	"github.com/QuesmaOrg/git-prompt-story/internal/display"
 	"github.com/QuesmaOrg/git-prompt-story/internal/note"
+	"github.com/aymanbagabas/go-osc52/v2"
 	"github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
@@ -32,6 +35,11 @@ var (
 			Background(lipgloss.Color("62")).
 			Foreground(lipgloss.Color("255"))
 
+	// Search match highlight
+	matchStyle = lipgloss.NewStyle().
+			Background(lipgloss.Color("220")).
+			Foreground(lipgloss.Color("0"))
+
 	// Tree indent
 	indentStr = "  "
 
@@ -57,9 +65,17 @@ type model struct {
 
 	// Edit mode state
 	editMode     bool      // true when showing confirmation dialog
-	pendingOp    string    // "redact" or "delete_session"
+	pendingOp    string    // "redact", "redact_span" or "delete_session"
+	spanInput    bool      // true while typing the text to redact
+	spanText     string    // Text to redact within the selected message
 	statusMsg    string    // Success/error message to display
 	statusExpiry time.Time // When to clear status message
+
+	// Search state
+	searchInput  bool   // true while typing the search query
+	searchQuery  string // Text matched against labels and detail content
+	searchOrigin int    // Cursor when the search started, for incremental jumps
+	filterMode   bool   // true when non-matching nodes are hidden
 }
 
 // NewModel creates a new TUI model
@@ -94,16 +110,74 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		// Handle typing the text of a span redaction
+		if m.spanInput {
+			switch msg.Type {
+			case tea.KeyEnter:
+				if m.spanText != "" {
+					m.spanInput = false
+					m.editMode = true
+					m.pendingOp = "redact_span"
+				}
+			case tea.KeyEsc, tea.KeyCtrlC:
+				m.spanInput = false
+				m.spanText = ""
+			case tea.KeyBackspace:
+				if r := []rune(m.spanText); len(r) > 0 {
+					m.spanText = string(r[:len(r)-1])
+				}
+			case tea.KeyRunes, tea.KeySpace:
+				m.spanText += string(msg.Runes)
+			}
+			return m, nil
+		}
+
+		// Handle typing the search query
+		if m.searchInput {
+			switch msg.Type {
+			case tea.KeyEnter:
+				m.searchInput = false
+			case tea.KeyEsc, tea.KeyCtrlC:
+				m.searchInput = false
+				m.clearSearch()
+			case tea.KeyBackspace:
+				if r := []rune(m.searchQuery); len(r) > 0 {
+					m.searchQuery = string(r[:len(r)-1])
+					m.cursor = m.searchOrigin
+					m.refreshVisible()
+					m.jumpToMatch(true, true)
+				}
+			case tea.KeyRunes, tea.KeySpace:
+				m.searchQuery += string(msg.Runes)
+				m.cursor = m.searchOrigin
+				m.refreshVisible()
+				m.jumpToMatch(true, true)
+			}
+			m.adjustListScroll()
+			return m, nil
+		}
+
 		// Handle edit mode confirmation
 		if m.editMode {
-			switch msg.String() {
+			key := msg.String()
+			switch key {
 			case "y", "Y":
-				m.executeOperation()
+				m.executeOperation("")
 				m.editMode = false
 				m.pendingOp = ""
+				m.spanText = ""
+			case "s", "p", "i":
+				// Confirm a redaction with a reason code
+				if m.pendingOp != "delete_session" {
+					m.executeOperation(reasonKeys[key])
+					m.editMode = false
+					m.pendingOp = ""
+					m.spanText = ""
+				}
 			case "n", "N", "escape", "esc":
 				m.editMode = false
 				m.pendingOp = ""
+				m.spanText = ""
 			}
 			return m, nil
 		}
@@ -148,16 +222,34 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Expand/Collapse
 		case "e", "enter", "l", "right":
 			m.tree.Expand(m.visible, m.cursor)
-			m.visible = m.tree.FlattenVisible()
+			m.refreshVisible()
 		case "c", "h", "left":
 			m.tree.Collapse(m.visible, m.cursor)
-			m.visible = m.tree.FlattenVisible()
+			m.refreshVisible()
 		case "E":
 			m.tree.ExpandAll()
-			m.visible = m.tree.FlattenVisible()
+			m.refreshVisible()
 		case "C":
 			m.tree.CollapseAll()
-			m.visible = m.tree.FlattenVisible()
+			m.refreshVisible()
+
+		// Search
+		case "/":
+			m.searchInput = true
+			m.searchQuery = ""
+			m.searchOrigin = m.cursor
+			m.refreshVisible()
+		case "n":
+			m.jumpToMatch(true, false)
+		case "N":
+			m.jumpToMatch(false, false)
+		case "f":
+			if m.searchQuery != "" {
+				m.filterMode = !m.filterMode
+				m.refreshVisible()
+			}
+		case "esc":
+			m.clearSearch()
 
 		// Redaction operations
 		case "r":
@@ -165,11 +257,25 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.editMode = true
 				m.pendingOp = "redact"
 			}
+		case "R":
+			if m.canRedact() {
+				m.spanInput = true
+				m.spanText = ""
+			}
 		case "D":
 			if m.canDeleteSession() {
 				m.editMode = true
 				m.pendingOp = "delete_session"
 			}
+
+		case "y":
+			m.copyStepID()
+
+		// Export the selected subtree as markdown
+		case "x":
+			m.exportSelection(false)
+		case "X":
+			m.exportSelection(true)
 		}
 
 	case tea.WindowSizeMsg:
@@ -290,9 +396,11 @@ func (m model) renderTreeLine(node Node, width int, selected bool) string {
 		line = line + strings.Repeat(" ", width-len(line))
 	}
 
-	// Apply selection style
+	// Apply selection style; highlighting inside it would reset its colors
 	if selected {
 		line = selectedStyle.Render(line)
+	} else {
+		line = highlightMatches(line, m.searchQuery, matchStyle.Render)
 	}
 
 	return line
@@ -320,18 +428,28 @@ func (m model) renderDetail(width, height int) string {
 		if n.IsAgent {
 			sb.WriteString("Type: Agent session\n")
 		}
+		if n.ContinuesFrom != "" {
+			sb.WriteString(fmt.Sprintf("Continues: %s (after a restart)\n", n.ContinuesFrom))
+		}
+		if n.Owner != "" {
+			sb.WriteString(fmt.Sprintf("Captured by: %s\n", n.Owner))
+		}
 		if !n.Start.IsZero() {
 			sb.WriteString(fmt.Sprintf("Start: %s\n", n.Start.Local().Format("2006-01-02 15:04:05")))
 		}
 		if !n.End.IsZero() {
 			sb.WriteString(fmt.Sprintf("End: %s\n", n.End.Local().Format("2006-01-02 15:04:05")))
 		}
+		if n.Removed != "" {
+			sb.WriteString("\n" + n.Removed + "\n")
+		}
 
 	case *UserActionNode:
 		entry := n.Entry()
 		sb.WriteString(fmt.Sprintf("Type: %s %s\n", display.GetTypeEmoji(entry.Type), entry.Type))
 		sb.WriteString(fmt.Sprintf("Time: %s\n", entry.Time.Local().Format("2006-01-02 15:04:05")))
 		sb.WriteString(fmt.Sprintf("Session: %s\n", n.SessionID[:min(8, len(n.SessionID))]))
+		sb.WriteString(fmt.Sprintf("Step: %s\n", entry.StepID))
 		sb.WriteString("\n")
 
 		// Content based on type
@@ -358,7 +476,7 @@ func (m model) renderDetail(width, height int) string {
 				stepEntry := step.Entry()
 				emoji := display.GetTypeEmoji(stepEntry.Type)
 				timeStr := stepEntry.Time.Local().Format("15:04")
-				if stepEntry.Type == "TOOL_USE" && stepEntry.ToolName != "" {
+				if stepEntry.IsToolCall() {
 					input := display.TruncateText(stepEntry.ToolInput, width-20)
 					sb.WriteString(fmt.Sprintf("%s %s %s: %s\n", emoji, timeStr, stepEntry.ToolName, input))
 				} else {
@@ -374,9 +492,10 @@ func (m model) renderDetail(width, height int) string {
 		entry := n.Entry()
 		sb.WriteString(fmt.Sprintf("Type: %s %s\n", display.GetTypeEmoji(entry.Type), entry.Type))
 		sb.WriteString(fmt.Sprintf("Time: %s\n", entry.Time.Local().Format("2006-01-02 15:04:05")))
+		sb.WriteString(fmt.Sprintf("Step: %s\n", entry.StepID))
 		sb.WriteString("\n")
 
-		if entry.Type == "TOOL_USE" {
+		if entry.IsToolCall() {
 			sb.WriteString(fmt.Sprintf("Tool: %s\n", entry.ToolName))
 			if entry.ToolInput != "" {
 				sb.WriteString("\nInput:\n")
@@ -405,17 +524,33 @@ func (m model) renderDetail(width, height int) string {
 		lines = lines[:height]
 	}
 
+	for i := range lines {
+		lines[i] = highlightMatches(lines[i], m.searchQuery, matchStyle.Render)
+	}
+
 	return strings.Join(lines, "\n")
 }
 
 // renderStatusBar renders the status bar
 func (m model) renderStatusBar() string {
+	// Search: show the query typed so far
+	if m.searchInput {
+		return statusBarStyle.Width(m.width).Render(fmt.Sprintf(" /%s█  (%s  enter:confirm  esc:cancel)", m.searchQuery, m.matchCount()))
+	}
+
+	// Span redaction: show the text typed so far
+	if m.spanInput {
+		return statusBarStyle.Width(m.width).Render(" Text to redact: " + m.spanText + "█  (enter:confirm  esc:cancel)")
+	}
+
 	// Edit mode: show confirmation prompt
 	if m.editMode {
 		var prompt string
 		switch m.pendingOp {
 		case "redact":
-			prompt = "Redact message in JSONL and git notes? (y/n)"
+			prompt = "Redact message in JSONL and git notes? (y/n, or reason s:secret p:personal i:irrelevant)"
+		case "redact_span":
+			prompt = fmt.Sprintf("Redact %q in this message in JSONL and git notes? (y/n, or reason s:secret p:personal i:irrelevant)", m.spanText)
 		case "delete_session":
 			prompt = "Clear session from JSONL and git notes? (y/n)"
 		}
@@ -439,7 +574,14 @@ func (m model) renderStatusBar() string {
 	}
 
 	// Keybindings help
-	help := "j/k:nav  e:expand  r:redact  D:del session  q:quit"
+	help := "j/k:nav  e:expand  /:search  y:copy step ID  x/X:export to clipboard/file  r:redact  R:redact text  D:del session  q:quit"
+	if m.searchQuery != "" {
+		filter := "f:filter"
+		if m.filterMode {
+			filter = "f:show all"
+		}
+		help = fmt.Sprintf("/%s (%s)  n/N:next/prev  %s  esc:clear  q:quit", m.searchQuery, m.matchCount(), filter)
+	}
 
 	// Build status bar
 	status := fmt.Sprintf(" %s | %s | %s", position, context, help)
@@ -449,6 +591,55 @@ func (m model) renderStatusBar() string {
 
 // Helper functions
 
+// copyStepID puts the selected step's permalink ID on the clipboard using
+// the OSC 52 terminal escape, which also works over SSH
+func (m *model) copyStepID() {
+	if m.cursor >= len(m.visible) {
+		return
+	}
+	entry := m.visible[m.cursor].Entry()
+	if entry == nil || entry.StepID == "" {
+		return
+	}
+
+	seq := osc52.New(entry.StepID)
+	if os.Getenv("TMUX") != "" {
+		seq = seq.Tmux()
+	}
+	seq.WriteTo(os.Stderr)
+	m.statusMsg = fmt.Sprintf("Copied %s", entry.StepID)
+	m.statusExpiry = time.Now().Add(3 * time.Second)
+}
+
+// exportSelection puts the markdown of the selected node and everything
+// under it on the clipboard (OSC 52, like copyStepID) or, with toFile, in a
+// file in the current directory
+func (m *model) exportSelection(toFile bool) {
+	if m.cursor >= len(m.visible) {
+		return
+	}
+	node := m.visible[m.cursor]
+	m.statusExpiry = time.Now().Add(3 * time.Second)
+
+	if toFile {
+		name, err := writeExport(node)
+		if err != nil {
+			m.statusMsg = fmt.Sprintf("Error: %v", err)
+			return
+		}
+		m.statusMsg = fmt.Sprintf("Wrote %s", name)
+		return
+	}
+
+	markdown := ExportMarkdown(node)
+	seq := osc52.New(markdown)
+	if os.Getenv("TMUX") != "" {
+		seq = seq.Tmux()
+	}
+	seq.WriteTo(os.Stderr)
+	m.statusMsg = fmt.Sprintf("Copied %d lines of markdown", strings.Count(markdown, "\n"))
+}
+
 func (m model) listHeight() int {
 	return max(m.height-5, 1) // Account for borders and status bar
 }
@@ -533,8 +724,12 @@ func (m model) getSelectedSessionInfo() (string, string) {
 	return "", ""
 }
 
-// executeOperation executes the pending redact or delete operation
-func (m *model) executeOperation() {
+// reasonKeys maps the keys confirming a redaction to its reason code
+var reasonKeys = map[string]string{"s": "secret", "p": "personal", "i": "irrelevant"}
+
+// executeOperation executes the pending redact or delete operation, giving
+// redactions the reason code reason
+func (m *model) executeOperation(reason string) {
 	if m.cursor >= len(m.visible) {
 		return
 	}
@@ -546,7 +741,7 @@ func (m *model) executeOperation() {
 	wasPushed := WasNotesPushed()
 
 	switch m.pendingOp {
-	case "redact":
+	case "redact", "redact_span":
 		// Get the entry to redact
 		entry := node.Entry()
 		if entry == nil {
@@ -563,12 +758,16 @@ func (m *model) executeOperation() {
 			tool, sessionID = n.Tool, n.SessionID
 		}
 
-		err = RedactMessage(tool, sessionID, entry.Time)
+		if m.pendingOp == "redact_span" {
+			err = RedactSpan(tool, sessionID, entry.TranscriptTime(), regexp.MustCompile(regexp.QuoteMeta(m.spanText)), reason, false)
+		} else {
+			err = RedactMessage(tool, sessionID, entry.TranscriptTime(), reason, false)
+		}
 		if err != nil {
 			m.statusMsg = fmt.Sprintf("Error: %v", err)
 		} else {
 			if wasPushed {
-				m.statusMsg = "Redacted. Force push: git push -f origin refs/notes/*"
+				m.statusMsg = "Redacted. Force push: git push -f origin refs/notes/prompt-story refs/notes/prompt-story-transcripts"
 			} else {
 				m.statusMsg = "Redacted"
 			}
@@ -583,12 +782,12 @@ func (m *model) executeOperation() {
 			return
 		}
 
-		err = DeleteSession(tool, sessionID)
+		err = DeleteSession(tool, sessionID, "", false)
 		if err != nil {
 			m.statusMsg = fmt.Sprintf("Error: %v", err)
 		} else {
 			if wasPushed {
-				m.statusMsg = "Cleared. Force push: git push -f origin refs/notes/*"
+				m.statusMsg = "Cleared. Force push: git push -f origin refs/notes/prompt-story refs/notes/prompt-story-transcripts"
 			} else {
 				m.statusMsg = "Session cleared"
 			}
@@ -608,7 +807,7 @@ func (m *model) refreshTree() {
 		return
 	}
 	m.tree = tree
-	m.visible = tree.FlattenVisible()
+	m.refreshVisible()
 
 	// Adjust cursor if it's out of bounds
 	if m.cursor >= len(m.visible) {
@@ -616,12 +815,110 @@ func (m *model) refreshTree() {
 	}
 }
 
+// refreshVisible recomputes the listed nodes, keeping only matches and
+// their ancestors in filter mode
+func (m *model) refreshVisible() {
+	if m.filterMode && m.searchQuery != "" {
+		m.visible = m.tree.FlattenFiltered(m.searchQuery)
+	} else {
+		m.visible = m.tree.FlattenVisible()
+	}
+	if m.cursor >= len(m.visible) {
+		m.cursor = max(0, len(m.visible)-1)
+	}
+}
+
+// jumpToMatch moves the cursor to the next (or previous) node matching the
+// search query, wrapping around and expanding collapsed nodes to reveal it.
+// With inclusive, the node under the cursor counts as the next match.
+func (m *model) jumpToMatch(forward, inclusive bool) {
+	matches := m.tree.Matches(m.searchQuery)
+	if len(matches) == 0 {
+		return
+	}
+
+	// Find the cursor's position among all nodes, expanded or not
+	all := m.tree.flattenAll()
+	pos := 0
+	if m.cursor < len(m.visible) {
+		for i, n := range all {
+			if n == m.visible[m.cursor] {
+				pos = i
+				break
+			}
+		}
+	}
+	index := make(map[Node]int, len(all))
+	for i, n := range all {
+		index[n] = i
+	}
+
+	target := matches[0]
+	if !forward {
+		target = matches[len(matches)-1]
+	}
+	for i := range matches {
+		if !forward {
+			i = len(matches) - 1 - i
+		}
+		at := index[matches[i]]
+		if (forward && (at > pos || inclusive && at == pos)) || (!forward && at < pos) {
+			target = matches[i]
+			break
+		}
+	}
+
+	m.focus(target)
+}
+
+// focus reveals target and moves the cursor to it
+func (m *model) focus(target Node) {
+	m.tree.Reveal(target)
+	m.refreshVisible()
+	for i, n := range m.visible {
+		if n == target {
+			m.cursor = i
+			break
+		}
+	}
+	m.detailOffset = 0
+}
+
+// matchCount describes the number of nodes matching the search query
+func (m model) matchCount() string {
+	switch n := len(m.tree.Matches(m.searchQuery)); n {
+	case 0:
+		return "no matches"
+	case 1:
+		return "1 match"
+	default:
+		return fmt.Sprintf("%d matches", n)
+	}
+}
+
+// clearSearch drops the search query and leaves filter mode
+func (m *model) clearSearch() {
+	m.searchQuery = ""
+	m.filterMode = false
+	m.refreshVisible()
+}
+
 // RunTUI starts the interactive TUI
 func RunTUI(commitSpec string, full bool) error {
-	m, err := NewModel(commitSpec, full)
+	return RunTUIAt(commitSpec, full, "")
+}
+
+// RunTUIAt starts the interactive TUI with the cursor on the step with
+// stepID, see ci.StepID, or on the first node if it is not found
+func RunTUIAt(commitSpec string, full bool, stepID string) error {
+	tm, err := NewModel(commitSpec, full)
 	if err != nil {
 		return err
 	}
+	m := tm.(model)
+	if target := m.tree.FindStep(stepID); target != nil {
+		m.focus(target)
+	}
 
 	p := tea.NewProgram(m, tea.WithAltScreen())
 	_, err = p.Run()
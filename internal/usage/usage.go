@@ -0,0 +1,224 @@
+// Package usage keeps opt-in, anonymous usage counters of the CLI itself:
+// how often each command runs and how long it takes. They are aggregated
+// locally in the user's config directory and never sent anywhere; teams
+// piloting the tool export them to measure their own adoption.
+package usage
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// dayLayout is the key format of Metrics.Days
+const dayLayout = "2006-01-02"
+
+// Metrics are the aggregated counters. Only command names, counts and
+// durations are kept; no arguments, paths or identities.
+type Metrics struct {
+	Since    time.Time                `json:"since"`
+	Commands map[string]*CommandStats `json:"commands"`
+	Days     map[string]int           `json:"days"` // Runs per day (YYYY-MM-DD, local time)
+}
+
+// CommandStats are the counters of one command
+type CommandStats struct {
+	Runs      int       `json:"runs"`
+	Completed int       `json:"completed"` // Runs that ended without an error
+	TotalMs   int64     `json:"total_ms"`  // Duration of completed runs
+	MaxMs     int64     `json:"max_ms"`
+	LastRun   time.Time `json:"last_run"`
+}
+
+// AverageDuration returns the mean duration of completed runs
+func (s *CommandStats) AverageDuration() time.Duration {
+	if s.Completed == 0 {
+		return 0
+	}
+	return time.Duration(s.TotalMs/int64(s.Completed)) * time.Millisecond
+}
+
+// Path returns the metrics file, ~/.config/git-prompt-story/metrics.json
+// on Linux
+func Path() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "git-prompt-story", "metrics.json"), nil
+}
+
+// Load reads the metrics file, returning empty metrics if there is none
+func Load() (*Metrics, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+	return load(path)
+}
+
+func load(path string) (*Metrics, error) {
+	m := &Metrics{Commands: make(map[string]*CommandStats), Days: make(map[string]int)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if m.Commands == nil {
+		m.Commands = make(map[string]*CommandStats)
+	}
+	if m.Days == nil {
+		m.Days = make(map[string]int)
+	}
+	return m, nil
+}
+
+// save writes the metrics file atomically, so concurrent runs (e.g. hooks)
+// never leave a truncated file
+func (m *Metrics) save(path string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "metrics-*.json")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// update loads the metrics file, applies fn and saves it
+func update(fn func(m *Metrics)) error {
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+	m, err := load(path)
+	if err != nil {
+		return err
+	}
+	fn(m)
+	return m.save(path)
+}
+
+// RecordStart counts a run of command starting at now
+func RecordStart(command string, now time.Time) error {
+	return update(func(m *Metrics) { m.start(command, now) })
+}
+
+// RecordEnd counts a run of command that completed after d
+func RecordEnd(command string, d time.Duration) error {
+	return update(func(m *Metrics) { m.end(command, d) })
+}
+
+// Reset removes the metrics file
+func Reset() error {
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (m *Metrics) stats(command string) *CommandStats {
+	s := m.Commands[command]
+	if s == nil {
+		s = &CommandStats{}
+		m.Commands[command] = s
+	}
+	return s
+}
+
+func (m *Metrics) start(command string, now time.Time) {
+	if m.Since.IsZero() {
+		m.Since = now.Truncate(time.Second)
+	}
+	s := m.stats(command)
+	s.Runs++
+	s.LastRun = now.Truncate(time.Second)
+	m.Days[now.Format(dayLayout)]++
+}
+
+func (m *Metrics) end(command string, d time.Duration) {
+	s := m.stats(command)
+	s.Completed++
+	ms := d.Milliseconds()
+	s.TotalMs += ms
+	if ms > s.MaxMs {
+		s.MaxMs = ms
+	}
+}
+
+// CommandNames returns the commands with counters, most run first
+func (m *Metrics) CommandNames() []string {
+	names := make([]string, 0, len(m.Commands))
+	for name := range m.Commands {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		a, b := m.Commands[names[i]], m.Commands[names[j]]
+		if a.Runs != b.Runs {
+			return a.Runs > b.Runs
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+// ActiveDays returns the number of days with runs on or after since
+func (m *Metrics) ActiveDays(since time.Time) int {
+	from := since.Format(dayLayout)
+	n := 0
+	for day, runs := range m.Days {
+		if runs > 0 && day >= from {
+			n++
+		}
+	}
+	return n
+}
+
+// WriteCSV writes one row per command
+func (m *Metrics) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"command", "runs", "completed", "avg_ms", "max_ms", "last_run"})
+	for _, name := range m.CommandNames() {
+		s := m.Commands[name]
+		cw.Write([]string{
+			name,
+			strconv.Itoa(s.Runs),
+			strconv.Itoa(s.Completed),
+			strconv.FormatInt(s.AverageDuration().Milliseconds(), 10),
+			strconv.FormatInt(s.MaxMs, 10),
+			s.LastRun.UTC().Format(time.RFC3339),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
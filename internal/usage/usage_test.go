@@ -0,0 +1,56 @@
+package usage
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "git-prompt-story", "metrics.json")
+	m, err := load(path)
+	if err != nil {
+		t.Fatalf("load() of a missing file error: %v", err)
+	}
+
+	day := time.Date(2025, 1, 15, 10, 0, 0, 0, time.Local)
+	m.start("show", day)
+	m.end("show", 300*time.Millisecond)
+	m.start("show", day.Add(24*time.Hour))
+	m.end("show", 100*time.Millisecond)
+	m.start("pr summary", day.Add(24*time.Hour)) // Failed, never ended
+	if err := m.save(path); err != nil {
+		t.Fatalf("save() error: %v", err)
+	}
+
+	m, err = load(path)
+	if err != nil {
+		t.Fatalf("load() error: %v", err)
+	}
+	if !m.Since.Equal(day) {
+		t.Errorf("Since = %v, want %v", m.Since, day)
+	}
+	show := m.Commands["show"]
+	if show.Runs != 2 || show.Completed != 2 || show.MaxMs != 300 || show.AverageDuration() != 200*time.Millisecond {
+		t.Errorf("Unexpected show stats %+v", show)
+	}
+	if s := m.Commands["pr summary"]; s.Runs != 1 || s.Completed != 0 || s.AverageDuration() != 0 {
+		t.Errorf("Unexpected pr summary stats %+v", s)
+	}
+	if got := m.CommandNames(); strings.Join(got, ",") != "show,pr summary" {
+		t.Errorf("CommandNames() = %v", got)
+	}
+	if m.ActiveDays(day) != 2 || m.ActiveDays(day.Add(24*time.Hour)) != 1 {
+		t.Errorf("Unexpected active days in %v", m.Days)
+	}
+
+	var sb strings.Builder
+	if err := m.WriteCSV(&sb); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(sb.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[1], "show,2,2,200,300,") {
+		t.Errorf("Unexpected CSV:\n%s", sb.String())
+	}
+}